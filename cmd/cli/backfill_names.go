@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/fleveque/logo-service/internal/app"
+)
+
+// backfillNamesCmd names logos that have no company name — GitHub imports
+// used to leave them nameless — from their repo's names file
+// (github.names_file) or the instruments table, and prints what it named.
+//
+//	logo-cli import --source instruments && logo-cli backfill-names --dry-run
+func backfillNamesCmd() *cobra.Command {
+	var (
+		dryRun bool
+		asJSON bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "backfill-names",
+		Short: "Fill in missing company names from repo names files and the instruments table",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+
+			logger, err := app.NewLogger(cfg.Log.Level)
+			if err != nil {
+				return fmt.Errorf("creating logger: %w", err)
+			}
+			defer func() { _ = logger.Sync() }()
+
+			core, err := app.NewCore(cfg, logger)
+			if err != nil {
+				return err
+			}
+			defer core.Close()
+
+			report, err := core.Service.BackfillCompanyNames(cmd.Context(), dryRun)
+			if err != nil {
+				return err
+			}
+
+			if asJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(report)
+			}
+			for _, fill := range report.Filled {
+				fmt.Printf("%-10s %s (%s)\n", fill.Symbol, fill.CompanyName, fill.From)
+			}
+			verb := "Named"
+			if dryRun {
+				verb = "Would name"
+			}
+			fmt.Printf("%s %d of %d unnamed logos\n", verb, len(report.Filled), report.Checked)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Report what would change without changing it")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print the report as JSON")
+	return cmd
+}
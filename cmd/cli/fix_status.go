@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/fleveque/logo-service/internal/app"
+	"github.com/fleveque/logo-service/internal/service"
+)
+
+// fixStatusCmd normalizes records whose status contradicts the rest of the
+// record, the same as POST /api/v1/admin/maintenance/fix-status, and prints
+// what it changed.
+//
+//	logo-cli fix-status --dry-run
+//	logo-cli fix-status --pending-days 2 --json
+func fixStatusCmd() *cobra.Command {
+	var (
+		pendingDays int
+		dryRun      bool
+		asJSON      bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "fix-status",
+		Short: "Normalize inconsistent logo statuses (processed without sizes, stale pending, failed without error)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if pendingDays < 1 {
+				return fmt.Errorf("--pending-days must be at least 1")
+			}
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+
+			logger, err := app.NewLogger(cfg.Log.Level)
+			if err != nil {
+				return fmt.Errorf("creating logger: %w", err)
+			}
+			defer func() { _ = logger.Sync() }()
+
+			core, err := app.NewCore(cfg, logger)
+			if err != nil {
+				return err
+			}
+			defer core.Close()
+
+			report, err := core.Service.FixStatus(cmd.Context(), service.FixStatusOptions{
+				PendingAge: time.Duration(pendingDays) * 24 * time.Hour,
+				DryRun:     dryRun,
+			})
+			if err != nil {
+				return err
+			}
+
+			if asJSON {
+				// Same JSON as the admin endpoint, so scripts can parse either.
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(report)
+			}
+			for _, fix := range report.Fixes {
+				fmt.Printf("%-10s %s → %s: %s\n", fix.Symbol, fix.From, fix.To, fix.Reason)
+			}
+			verb := "Fixed"
+			if dryRun {
+				verb = "Would fix"
+			}
+			fmt.Printf("%s %d of %d logos checked\n", verb, len(report.Fixes), report.Checked)
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&pendingDays, "pending-days", 7, "Pending logos older than this many days are stale")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Report what would change without changing it")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print the report as JSON")
+	return cmd
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/fleveque/logo-service/internal/model"
+	"github.com/fleveque/logo-service/pkg/client"
+	"github.com/fleveque/logo-service/pkg/logos"
+)
+
+// remoteFlags are shared by the read-only commands: with --url they ask a
+// running server through the client SDK, without it they read the storage
+// in the config directly.
+type remoteFlags struct {
+	baseURL, apiKey string
+}
+
+func (f *remoteFlags) register(cmd *cobra.Command, keyHelp string) {
+	cmd.Flags().StringVar(&f.baseURL, "url", "", "Base URL of a running logo service (default: read local storage)")
+	cmd.Flags().StringVar(&f.apiKey, "api-key", "", keyHelp+" (default $LOGO_API_KEY)")
+}
+
+func (f *remoteFlags) client() *client.Client {
+	if f.apiKey == "" {
+		f.apiKey = os.Getenv("LOGO_API_KEY")
+	}
+	return client.New(f.baseURL, f.apiKey)
+}
+
+// openLocal opens the storage from the --config file without logging.
+func openLocal() (*logos.Service, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	return logos.Open(cfg, nil)
+}
+
+// statsCmd prints logo counts by status.
+//
+//	logo-cli stats
+//	logo-cli stats --url https://logos.example.com --api-key $ADMIN_KEY
+func statsCmd() *cobra.Command {
+	var remote remoteFlags
+
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Print catalog statistics",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var stats *model.Stats
+			var err error
+			if remote.baseURL != "" {
+				stats, err = remote.client().Stats(cmd.Context())
+			} else {
+				stats, err = localStats(cmd.Context())
+			}
+			if err != nil {
+				return err
+			}
+
+			// Same JSON as GET /admin/stats, so scripts can parse either.
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(stats)
+		},
+	}
+	remote.register(cmd, "Admin API key")
+	return cmd
+}
+
+func localStats(ctx context.Context) (*model.Stats, error) {
+	svc, err := openLocal()
+	if err != nil {
+		return nil, err
+	}
+	defer svc.Close()
+	return svc.Stats(ctx)
+}
+
+// getCmd writes one logo to a file.
+//
+//	logo-cli get AAPL --size xl -o aapl.png
+//
+// Locally it only reads what's cached — it never pays for a search. Against
+// a server it makes a normal logo request, which may acquire the logo.
+func getCmd() *cobra.Command {
+	var (
+		remote remoteFlags
+		size   string
+		output string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "get SYMBOL",
+		Short: "Fetch a single logo to a local file",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !model.ValidSize(size) {
+				return fmt.Errorf("invalid size %q: must be xs, s, m, l, or xl", size)
+			}
+			symbol, err := model.NormalizeSymbol(args[0])
+			if err != nil {
+				return fmt.Errorf("%q: %w", args[0], err)
+			}
+			if output == "" {
+				output = fmt.Sprintf("%s_%s.png", symbol, size)
+			}
+
+			var data []byte
+			if remote.baseURL != "" {
+				data, err = remote.client().Logo(cmd.Context(), symbol, model.LogoSize(size))
+			} else {
+				data, err = localLogo(cmd.Context(), symbol, model.LogoSize(size))
+			}
+			if err != nil {
+				return fmt.Errorf("getting %s: %w", symbol, err)
+			}
+
+			if err := os.WriteFile(output, data, 0644); err != nil {
+				return err
+			}
+			fmt.Printf("Wrote %s (%d bytes)\n", output, len(data))
+			return nil
+		},
+	}
+	remote.register(cmd, "API key")
+	cmd.Flags().StringVar(&size, "size", "m", "Logo size: xs, s, m, l, or xl")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Output file (default SYMBOL_SIZE.png)")
+	return cmd
+}
+
+func localLogo(ctx context.Context, symbol string, size model.LogoSize) ([]byte, error) {
+	svc, err := openLocal()
+	if err != nil {
+		return nil, err
+	}
+	defer svc.Close()
+	return svc.CachedLogo(ctx, symbol, size)
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// loadtestCmd replays a symbol list against a running instance and reports
+// latency percentiles — numbers to compare before and after caching changes.
+//
+//	logo-cli loadtest --url http://localhost:8080 --symbols-file symbols.txt --requests 2000
+func loadtestCmd() *cobra.Command {
+	var opts loadtestOptions
+
+	cmd := &cobra.Command{
+		Use:   "loadtest",
+		Short: "Replay a symbol list against a running instance and report latency percentiles",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.apiKey == "" {
+				opts.apiKey = os.Getenv("LOGO_API_KEY")
+			}
+			return runLoadtest(cmd.Context(), opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.baseURL, "url", "http://localhost:8080", "Base URL of the logo service")
+	cmd.Flags().StringVar(&opts.symbolsFile, "symbols-file", "", "File with one symbol per line (first CSV column is used)")
+	cmd.Flags().StringVar(&opts.size, "size", "m", "Logo size to request")
+	cmd.Flags().StringVar(&opts.apiKey, "api-key", "", "API key (default $LOGO_API_KEY)")
+	cmd.Flags().IntVar(&opts.concurrency, "concurrency", 10, "Concurrent workers")
+	cmd.Flags().IntVar(&opts.requests, "requests", 0, "Total requests (default: one pass over the symbol list)")
+	_ = cmd.MarkFlagRequired("symbols-file")
+	return cmd
+}
+
+type loadtestOptions struct {
+	baseURL     string
+	symbolsFile string
+	size        string
+	apiKey      string
+	concurrency int
+	requests    int
+}
+
+// sample is the outcome of one request.
+type sample struct {
+	latency time.Duration
+	status  int // 0 if the request failed before a response
+}
+
+func runLoadtest(ctx context.Context, opts loadtestOptions) error {
+	symbols, err := readSymbols(opts.symbolsFile)
+	if err != nil {
+		return err
+	}
+	if len(symbols) == 0 {
+		return fmt.Errorf("no symbols in %s", opts.symbolsFile)
+	}
+	if opts.requests <= 0 {
+		opts.requests = len(symbols)
+	}
+
+	urls := make([]string, opts.requests)
+	for i := range urls {
+		urls[i] = logoURL(opts.baseURL, symbols[i%len(symbols)], opts.size, "")
+	}
+
+	start := time.Now()
+	samples := replay(ctx, urls, opts.apiKey, opts.concurrency, nil)
+	elapsed := time.Since(start)
+
+	printLoadtestReport(samples, elapsed)
+	return nil
+}
+
+// replay requests every URL once using concurrency workers, and returns one
+// sample per URL. onSample, if set, is called from the workers as each
+// request completes.
+func replay(ctx context.Context, urls []string, apiKey string, concurrency int, onSample func(u string, s sample)) []sample {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	client := &http.Client{Timeout: 2 * time.Minute} // Cache misses may go all the way to an LLM
+
+	// Workers pull request indexes from a channel; results are written into a
+	// pre-sized slice at their own index, so no mutex is needed for them.
+	jobs := make(chan int)
+	samples := make([]sample, len(urls))
+
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				samples[i] = doRequest(ctx, client, urls[i], apiKey)
+				if onSample != nil {
+					onSample(urls[i], samples[i])
+				}
+			}
+		}()
+	}
+
+	for i := range urls {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return samples
+}
+
+// logoURL builds the GetLogo URL for a symbol; bg may be empty.
+func logoURL(baseURL, symbol, size, bg string) string {
+	q := url.Values{"size": {size}}
+	if bg != "" {
+		q.Set("bg", bg)
+	}
+	return fmt.Sprintf("%s/api/v1/logos/%s?%s", strings.TrimRight(baseURL, "/"), url.PathEscape(symbol), q.Encode())
+}
+
+func doRequest(ctx context.Context, client *http.Client, u, apiKey string) sample {
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return sample{}
+	}
+	if apiKey != "" {
+		req.Header.Set("X-API-Key", apiKey)
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return sample{latency: time.Since(start)}
+	}
+	// Read the whole body: latency should include transferring the image.
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return sample{latency: time.Since(start), status: resp.StatusCode}
+}
+
+func printLoadtestReport(samples []sample, elapsed time.Duration) {
+	statuses := make(map[int]int)
+	latencies := make([]time.Duration, 0, len(samples))
+	for _, s := range samples {
+		statuses[s.status]++
+		if s.status != 0 {
+			latencies = append(latencies, s.latency)
+		}
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	fmt.Printf("\nRequests:   %d in %s (%.1f req/s)\n", len(samples), elapsed.Round(time.Millisecond), float64(len(samples))/elapsed.Seconds())
+	for status, count := range statuses {
+		label := fmt.Sprintf("HTTP %d", status)
+		if status == 0 {
+			label = "errors"
+		}
+		fmt.Printf("  %-9s %d\n", label+":", count)
+	}
+	if len(latencies) == 0 {
+		return
+	}
+	fmt.Printf("Latency:    p50 %s  p95 %s  p99 %s  max %s\n",
+		percentile(latencies, 50), percentile(latencies, 95), percentile(latencies, 99), latencies[len(latencies)-1])
+}
+
+// percentile returns the p-th percentile of sorted latencies (nearest-rank method).
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100 // ceil(p/100 * n)
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1].Round(time.Microsecond)
+}
+
+// readSymbols reads one symbol per line. Lines may be CSV — only the first
+// column is used — and a header row or blank lines are skipped.
+func readSymbols(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening symbols file: %w", err)
+	}
+	defer f.Close()
+
+	var symbols []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		symbol, _, _ := strings.Cut(scanner.Text(), ",")
+		symbol = strings.TrimSpace(symbol)
+		if symbol == "" || strings.EqualFold(symbol, "symbol") {
+			continue
+		}
+		symbols = append(symbols, symbol)
+	}
+	return symbols, scanner.Err()
+}
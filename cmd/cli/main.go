@@ -7,17 +7,22 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"syscall"
 
+	"github.com/jmoiron/sqlx"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 
+	"github.com/fleveque/logo-service/internal/app"
 	"github.com/fleveque/logo-service/internal/config"
+	"github.com/fleveque/logo-service/internal/flags"
 	"github.com/fleveque/logo-service/internal/model"
+	"github.com/fleveque/logo-service/internal/phash"
 	"github.com/fleveque/logo-service/internal/provider"
 	"github.com/fleveque/logo-service/internal/service"
 	"github.com/fleveque/logo-service/internal/storage"
@@ -32,16 +37,120 @@ func main() {
 // rootCmd creates the root command. Cobra builds a tree of commands:
 // logo-cli import --source all
 // logo-cli import --source github
+// logo-cli import --source instruments
+// logo-cli import --source index
+// logo-cli migrate-storage
+// logo-cli migrate-storage --to-dir /mnt/logos
+// logo-cli migrate-db --to-driver sqlite3 --to-dsn copy.db --dry-run
+// logo-cli loadtest --symbols-file symbols.txt
+// logo-cli warm --symbols-file sp500.csv --sizes m,l
+// logo-cli stats
+// logo-cli get AAPL --size xl -o aapl.png
+// logo-cli sprite AAPL MSFT --size s
+// logo-cli seed --fixture testdata/seed.yaml
+// logo-cli fix-status --dry-run
+// logo-cli backfill-names --dry-run
+// logo-cli serve
+// logo-cli version
 func rootCmd() *cobra.Command {
 	root := &cobra.Command{
 		Use:   "logo-cli",
 		Short: "Logo service CLI tools",
 	}
 
+	// Persistent flags are inherited by every subcommand, so the server and
+	// the admin tools read the config the same way.
+	root.PersistentFlags().StringVar(&configPath, "config", os.Getenv("LOGO_CONFIG_PATH"), "Config file (default $LOGO_CONFIG_PATH, else config.yaml in . or ./config)")
+
 	root.AddCommand(importCmd())
+	root.AddCommand(migrateStorageCmd())
+	root.AddCommand(migrateDBCmd())
+	root.AddCommand(loadtestCmd())
+	root.AddCommand(warmCmd())
+	root.AddCommand(statsCmd())
+	root.AddCommand(getCmd())
+	root.AddCommand(spriteCmd())
+	root.AddCommand(seedCmd())
+	root.AddCommand(fixStatusCmd())
+	root.AddCommand(backfillNamesCmd())
+	root.AddCommand(serveCmd())
+	root.AddCommand(versionCmd())
 	return root
 }
 
+// configPath is set by the --config flag on the root command.
+var configPath string
+
+// loadConfig loads the config file chosen with --config.
+func loadConfig() (*config.Config, error) {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+	return cfg, nil
+}
+
+// serveCmd runs the HTTP server, wired exactly like cmd/server, so one
+// binary covers both the daemon and the admin tooling.
+//
+//	logo-cli serve --config /etc/logo-service/config.yaml
+//	logo-cli serve --demo
+//	logo-cli serve --demo --fixture testdata/seed.yaml
+func serveCmd() *cobra.Command {
+	var (
+		port    int
+		demo    bool
+		fixture string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Run the HTTP server",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			if port != 0 {
+				cfg.Server.Port = port
+			}
+			var setups []func(*app.Core) error
+			if demo {
+				// Nothing to set up and nothing left behind: in-memory storage
+				// with a few sample logos already in it.
+				cfg.Storage.Backend = "memory"
+				if fixture != "" {
+					setups = append(setups, app.SeedFixture(fixture))
+				} else {
+					setups = append(setups, app.SeedDemo)
+				}
+				// The API refuses every request without keys; give the demo
+				// a well-known one unless the config has its own.
+				if len(cfg.Auth.APIKeys) == 0 {
+					cfg.Auth.APIKeys = []string{"demo"}
+				}
+				if len(cfg.Auth.AdminKeys) == 0 {
+					cfg.Auth.AdminKeys = []string{"demo"}
+				}
+			} else if fixture != "" {
+				return fmt.Errorf("--fixture needs --demo; to seed persistent storage, run logo-cli seed")
+			}
+
+			logger, err := app.NewLogger(cfg.Log.Level)
+			if err != nil {
+				return fmt.Errorf("creating logger: %w", err)
+			}
+			defer func() { _ = logger.Sync() }()
+
+			return app.Serve(cfg, logger, setups...)
+		},
+	}
+	cmd.Flags().IntVar(&port, "port", 0, "Listen port (default server.port from the config)")
+	cmd.Flags().BoolVar(&demo, "demo", false, "Use in-memory storage seeded with sample logos (AAPL, MSFT, ...); API key \"demo\" unless configured")
+	cmd.Flags().StringVar(&fixture, "fixture", "", "With --demo, seed this fixture instead of the sample logos")
+	return cmd
+}
+
 func importCmd() *cobra.Command {
 	var source string
 
@@ -55,16 +164,124 @@ func importCmd() *cobra.Command {
 	}
 
 	// Cobra flags: --source with default "all"
-	cmd.Flags().StringVar(&source, "source", "all", "Import source: all, github")
+	cmd.Flags().StringVar(&source, "source", "all", "Import source: all, github, instruments, index")
+	return cmd
+}
+
+// migrateStorageCmd converts logo_dir to storage.layout in place or, with a
+// target (storage.migration, or --to-dir), copies it there while the server
+// keeps serving from logo_dir:
+//
+//	logo-cli migrate-storage                          # in place, after changing storage.layout
+//	logo-cli migrate-storage --to-dir /mnt/logos --to-layout hash
+func migrateStorageCmd() *cobra.Command {
+	var (
+		to       string
+		toDir    string
+		toLayout string
+		asJSON   bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "migrate-storage",
+		Short: "Move logo directories into storage.layout, or copy them to another store",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			if to != "fs" {
+				// BlobStore is the seam a new backend plugs into; there is
+				// no S3 one yet.
+				return fmt.Errorf("--to %q: only fs is supported", to)
+			}
+			if cmd.Flags().Changed("to-dir") {
+				cfg.Storage.Migration.LogoDir = toDir
+			}
+			if cmd.Flags().Changed("to-layout") {
+				cfg.Storage.Migration.Layout = toLayout
+			}
+
+			if cfg.Storage.Migration.LogoDir == "" {
+				fs, err := storage.NewFileSystem(cfg.Storage.LogoDir, storage.Layout(cfg.Storage.Layout))
+				if err != nil {
+					return fmt.Errorf("creating filesystem: %w", err)
+				}
+				renamed, err := fs.MigrateLayout()
+				if err != nil {
+					return err
+				}
+				fmt.Printf("Migrated %d logo directories\n", renamed)
+				return nil
+			}
+			return copyStorage(cmd.Context(), cfg, asJSON)
+		},
+	}
+
+	cmd.Flags().StringVar(&to, "to", "fs", "Backend to copy to (fs)")
+	cmd.Flags().StringVar(&toDir, "to-dir", "", "Directory to copy logo_dir to (default storage.migration.logo_dir)")
+	cmd.Flags().StringVar(&toLayout, "to-layout", "", "Layout of the copy: flat, prefix or hash (default storage.migration.layout, then storage.layout)")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print the copy's stats as JSON")
 	return cmd
 }
 
+// copyStorage copies logo_dir to storage.migration. Run it again to resume
+// an interrupted copy or to catch up before flipping storage_cutover.
+func copyStorage(ctx context.Context, cfg *config.Config, asJSON bool) error {
+	// --to-dir and --to-layout skip the config's validation of storage.migration.
+	m := cfg.Storage.Migration
+	if m.Layout != "" && !storage.ValidLayout(m.Layout) {
+		return fmt.Errorf("unknown layout %q: flat, prefix or hash", m.Layout)
+	}
+	if filepath.Clean(m.LogoDir) == filepath.Clean(cfg.Storage.LogoDir) {
+		return fmt.Errorf("the target must be another directory than storage.logo_dir; without one, logo_dir is migrated in place")
+	}
+
+	logger, err := app.NewLogger(cfg.Log.Level)
+	if err != nil {
+		return fmt.Errorf("creating logger: %w", err)
+	}
+	defer func() { _ = logger.Sync() }()
+
+	core, err := app.NewCore(cfg, logger)
+	if err != nil {
+		return err
+	}
+	defer core.Close()
+
+	// Once cut over, the server writes to the target: copying the old
+	// store again would overwrite newer logos with older ones.
+	if core.Flags.Enabled(flags.StorageCutover) {
+		return fmt.Errorf("the storage_cutover flag is on: the migration is over")
+	}
+	target, err := app.MigrationTarget(cfg)
+	if err != nil {
+		return err
+	}
+
+	stats, err := core.Service.CopyStorage(ctx, target)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(stats)
+	}
+	fmt.Printf("Copied %d files and %d originals of %d logos (%d files and %d originals already there)\n",
+		stats.Files.Copied, stats.Originals.Copied, stats.Logos, stats.Files.Skipped, stats.Originals.Skipped)
+	if stats.Cleared > 0 {
+		fmt.Printf("Cleared %d size flags whose files were missing\n", stats.Cleared)
+	}
+	fmt.Println("Once nothing is left to copy, turn on the storage_cutover flag.")
+	return nil
+}
+
 func runImport(source string) error {
 	// Load config
-	configPath := os.Getenv("LOGO_CONFIG_PATH")
-	cfg, err := config.Load(configPath)
+	cfg, err := loadConfig()
 	if err != nil {
-		return fmt.Errorf("loading config: %w", err)
+		return err
 	}
 
 	// Set up logger (always use development mode for CLI)
@@ -85,13 +302,24 @@ func runImport(source string) error {
 	}
 	defer db.Close()
 
-	fs, err := storage.NewFileSystem(cfg.Storage.LogoDir)
+	fs, err := storage.NewFileSystem(cfg.Storage.LogoDir, storage.Layout(cfg.Storage.Layout))
 	if err != nil {
 		return fmt.Errorf("creating filesystem: %w", err)
 	}
 
+	// Imports are what download the most: keep them within the limits too,
+	// with the credentials of private sources and the providers' User-Agent.
+	provider.SetOutboundLimits(app.OutboundLimits(cfg))
+	provider.SetCredentials(app.Credentials(cfg))
+	provider.SetIdentity(app.Identity(cfg))
+
 	logoRepo := storage.NewLogoRepository(db)
+	blocklist := storage.NewBlocklistRepository(db)
+	originals := storage.NewOriginalRepository(db)
+	instruments := storage.NewInstrumentRepository(db)
+	events := storage.NewEventRepository(db)
 	processor := service.NewImageProcessor(fs)
+	processor.SetMaxBytes(app.MaxImageBytes(cfg))
 
 	// Set up context with cancellation (Ctrl+C to stop import gracefully)
 	ctx, cancel := context.WithCancel(context.Background())
@@ -106,26 +334,112 @@ func runImport(source string) error {
 	}()
 
 	// Run import based on source
+	// "all" loads instruments first so company names are known for the logos that follow,
+	// and the curated index (if configured) last, so its corrections win.
 	switch source {
-	case "all", "github":
-		return runGitHubImport(ctx, cfg, logoRepo, processor, logger)
+	case "all":
+		if err := runInstrumentImport(ctx, cfg, db, logger); err != nil {
+			return err
+		}
+		if err := runGitHubImport(ctx, cfg, logoRepo, blocklist, originals, instruments, events, fs, processor, logger); err != nil {
+			return err
+		}
+		if cfg.Index.URL == "" {
+			return nil
+		}
+		return runIndexImport(ctx, cfg, logger)
+	case "github":
+		return runGitHubImport(ctx, cfg, logoRepo, blocklist, originals, instruments, events, fs, processor, logger)
+	case "instruments":
+		return runInstrumentImport(ctx, cfg, db, logger)
+	case "index":
+		return runIndexImport(ctx, cfg, logger)
 	default:
 		return fmt.Errorf("unknown source: %s", source)
 	}
 }
 
-func runGitHubImport(ctx context.Context, cfg *config.Config, logoRepo storage.LogoRepository, processor *service.ImageProcessor, logger *zap.Logger) error {
-	ghProvider := provider.NewGitHubProvider(cfg.GitHub.Repos, logger)
+func runInstrumentImport(ctx context.Context, cfg *config.Config, db *sqlx.DB, logger *zap.Logger) error {
+	importer, err := provider.NewInstrumentImporter(cfg.Instruments.Format, cfg.Instruments.URL, cfg.Instruments.UserAgent, logger)
+	if err != nil {
+		return err
+	}
+
+	instrumentRepo := storage.NewInstrumentRepository(db)
+	stats, err := importer.Import(ctx, func(inst *model.Instrument) error {
+		return instrumentRepo.Upsert(ctx, inst)
+	})
+	if err != nil {
+		return fmt.Errorf("instrument import: %w", err)
+	}
+
+	if len(stats.Errors) > 0 {
+		logger.Warn("instrument import had errors", zap.Int("count", len(stats.Errors)))
+	}
+
+	return nil
+}
+
+func runGitHubImport(ctx context.Context, cfg *config.Config, logoRepo storage.LogoRepository, blocklist storage.BlocklistRepository, originals storage.OriginalRepository, instruments storage.InstrumentRepository, events storage.EventRepository, fs *storage.FileSystem, processor *service.ImageProcessor, logger *zap.Logger) error {
+	ghProvider := app.GitHubProvider(cfg, logoRepo, logger)
+
+	// Authoritative repos correct logos already stored: like the index,
+	// their results go through the service's ReplaceLogo.
+	var replace func(result *provider.LogoResult) error
+	if len(cfg.GitHub.Authoritative) > 0 {
+		core, err := app.NewCore(cfg, logger)
+		if err != nil {
+			return err
+		}
+		defer core.Close()
+		replace = func(result *provider.LogoResult) error {
+			return core.Service.ReplaceLogo(ctx, result)
+		}
+	}
+
+	// Stop cleanly when logo_dir runs low, rather than failing every write after it.
+	disk := storage.NewDiskMonitor(cfg.Storage.LogoDir, uint64(cfg.Storage.Disk.MinFree), cfg.Storage.Disk.CheckInterval)
 
 	// The callback processes each logo as it's downloaded.
 	// This is where the provider → processor → repository pipeline runs.
 	callback := func(result *provider.LogoResult) error {
+		if result.Authoritative && replace != nil {
+			return replace(result)
+		}
+
+		// Blocked symbols are counted as skipped by BulkImport
+		blocked, err := blocklist.IsBlocked(ctx, result.Symbol)
+		if err != nil {
+			return err
+		}
+		if blocked {
+			return storage.ErrSymbolBlocked
+		}
+
 		// Check if already exists
 		existing, err := logoRepo.GetBySymbol(ctx, result.Symbol)
 		if err == nil && existing.Status == model.StatusProcessed {
+			// Same file as processed: keep its validators for the next import
+			if existing.OriginalURL == result.OriginalURL && existing.OriginalHash == storage.HashOriginal(result.ImageData) {
+				if err := logoRepo.SetValidators(ctx, result.Symbol, result.ETag, result.LastModified); err != nil {
+					logger.Error("setting validators", zap.String("symbol", result.Symbol), zap.Error(err))
+				}
+			}
 			return fmt.Errorf("already exists")
 		}
 
+		if err := disk.Check(); err != nil {
+			return err
+		}
+
+		// The repo's names file, if it has one, named it already; "all"
+		// imports instruments first so this finds the rest.
+		if result.CompanyName == "" {
+			if inst, err := instruments.GetBySymbol(ctx, result.Symbol); err == nil {
+				result.CompanyName = inst.Name
+			}
+		}
+
 		// Create or update the logo record
 		if existing == nil {
 			logo := &model.Logo{
@@ -133,6 +447,8 @@ func runGitHubImport(ctx context.Context, cfg *config.Config, logoRepo storage.L
 				CompanyName: result.CompanyName,
 				Source:      result.Source,
 				OriginalURL: result.OriginalURL,
+				License:     result.License,
+				Attribution: result.Attribution,
 				Status:      model.StatusPending,
 			}
 			if err := logoRepo.Create(ctx, logo); err != nil {
@@ -140,23 +456,42 @@ func runGitHubImport(ctx context.Context, cfg *config.Config, logoRepo storage.L
 			}
 		}
 
+		// Keep the download, so the logo can be reprocessed without GitHub
+		originalHash, err := service.SaveOriginal(ctx, fs, originals, result)
+		if err != nil {
+			logger.Error("saving original", zap.String("symbol", result.Symbol), zap.Error(err))
+		}
+
 		// Process the image (resize to all sizes)
-		sizes, err := processor.ProcessAll(result.Symbol, result.ImageData)
+		defaults := model.ProcessOptions{WhitenBackground: cfg.Processing.WhitenBackground}
+		sizes, err := processor.ProcessAll(result.Symbol, result.ImageData, existing.ProcessOptions(defaults))
 		if err != nil {
 			_ = logoRepo.SetStatus(ctx, result.Symbol, model.StatusFailed, err.Error())
 			return fmt.Errorf("processing image: %w", err)
 		}
 
-		// Mark each size as available
-		for size, ok := range sizes {
-			if ok {
-				if err := logoRepo.SetSizeAvailable(ctx, result.Symbol, size); err != nil {
-					logger.Error("setting size available",
-						zap.String("symbol", result.Symbol),
-						zap.String("size", string(size)),
-						zap.Error(err),
-					)
-				}
+		// Mark each size as available, with its dimensions and byte count
+		if err := service.StoreSizes(ctx, fs, logoRepo, result.Symbol, sizes); err != nil {
+			logger.Error("storing sizes", zap.String("symbol", result.Symbol), zap.Error(err))
+		}
+
+		if originalHash != "" {
+			if err := logoRepo.SetOriginalHash(ctx, result.Symbol, originalHash); err != nil {
+				logger.Error("setting original hash", zap.String("symbol", result.Symbol), zap.Error(err))
+			}
+		}
+		if err := logoRepo.SetValidators(ctx, result.Symbol, result.ETag, result.LastModified); err != nil {
+			logger.Error("setting validators", zap.String("symbol", result.Symbol), zap.Error(err))
+		}
+
+		rendered, _ := fs.Read(result.Symbol, model.SizeXL)
+		score, notes := service.AssessQuality(result.ImageData, rendered)
+		if err := logoRepo.SetQuality(ctx, result.Symbol, score, notes); err != nil {
+			logger.Error("setting quality score", zap.String("symbol", result.Symbol), zap.Error(err))
+		}
+		if hash, err := phash.FromPNG(rendered); err == nil {
+			if err := logoRepo.SetPHash(ctx, result.Symbol, hash.String()); err != nil {
+				logger.Error("setting phash", zap.String("symbol", result.Symbol), zap.Error(err))
 			}
 		}
 
@@ -165,6 +500,18 @@ func runGitHubImport(ctx context.Context, cfg *config.Config, logoRepo storage.L
 			return fmt.Errorf("setting status: %w", err)
 		}
 
+		// Running servers pick the logo up from the changes feed, as they
+		// would one their own service stored. Processed logos were skipped
+		// above, so only one that was served before (and then sent to
+		// review, say) is an update.
+		eventType := model.EventUpdated
+		if existing == nil || existing.PHash == "" {
+			eventType = model.EventCreated
+		}
+		if err := events.Record(ctx, &model.Event{Type: eventType, Symbol: result.Symbol}); err != nil {
+			logger.Error("recording event", zap.String("symbol", result.Symbol), zap.Error(err))
+		}
+
 		return nil
 	}
 
@@ -186,3 +533,31 @@ func runGitHubImport(ctx context.Context, cfg *config.Config, logoRepo storage.L
 
 	return nil
 }
+
+// runIndexImport imports the curated index through the service, whose
+// ReplaceLogo replaces logos the index has a different image for — unlike
+// the GitHub import, which leaves processed logos alone.
+func runIndexImport(ctx context.Context, cfg *config.Config, logger *zap.Logger) error {
+	if cfg.Index.URL == "" {
+		return fmt.Errorf("no index is configured (index.url)")
+	}
+
+	core, err := app.NewCore(cfg, logger)
+	if err != nil {
+		return err
+	}
+	defer core.Close()
+
+	stats, err := core.Index.BulkImport(ctx, func(result *provider.LogoResult) error {
+		return core.Service.ReplaceLogo(ctx, result)
+	})
+	if err != nil {
+		return fmt.Errorf("index import: %w", err)
+	}
+
+	if len(stats.Errors) > 0 {
+		logger.Warn("index import had errors", zap.Int("count", len(stats.Errors)))
+	}
+
+	return nil
+}
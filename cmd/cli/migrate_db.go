@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	_ "github.com/jackc/pgx/v5/stdlib" // Registers the "pgx" Postgres driver
+	"github.com/jmoiron/sqlx"
+	"github.com/spf13/cobra"
+
+	"github.com/fleveque/logo-service/internal/storage"
+)
+
+// migrateDBCmd copies the SQLite database to another database, table by
+// table, and checks the row counts on both sides. A Postgres target gets
+// its tables created from the SQLite schema first, and its id sequences
+// moved past the copied rows afterwards.
+//
+//	logo-cli migrate-db --to-driver postgres --to-dsn "postgres://..." --dry-run
+//	logo-cli migrate-db --to-driver sqlite3 --to-dsn ./storage/copy.db
+func migrateDBCmd() *cobra.Command {
+	var (
+		toDriver  string
+		toDSN     string
+		batchSize int
+		dryRun    bool
+		asJSON    bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "migrate-db",
+		Short: "Copy every table of the SQLite database to another database",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if toDSN == "" {
+				return fmt.Errorf("--to-dsn is required")
+			}
+			if batchSize < 1 {
+				return fmt.Errorf("--batch-size must be at least 1")
+			}
+			postgres := toDriver == "postgres" || toDriver == "pgx"
+			if !postgres && toDriver != "sqlite3" {
+				return fmt.Errorf("--to-driver must be postgres or sqlite3, got %q", toDriver)
+			}
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+
+			src, err := storage.NewReadDatabase(cfg.Storage.DatabasePath, 1)
+			if err != nil {
+				return err
+			}
+			defer src.Close()
+
+			// A SQLite target gets the schema from NewDatabase, a Postgres
+			// one from PostgresSchema (not on a dry run: it writes nothing).
+			var dst *sqlx.DB
+			if postgres {
+				dst, err = sqlx.Connect("pgx", toDSN)
+			} else {
+				dst, err = storage.NewDatabase(toDSN)
+			}
+			if err != nil {
+				return fmt.Errorf("opening the target database: %w", err)
+			}
+			defer dst.Close()
+			if postgres && !dryRun {
+				statements, err := storage.PostgresSchema(cmd.Context(), src)
+				if err != nil {
+					return err
+				}
+				for _, stmt := range statements {
+					if _, err := dst.ExecContext(cmd.Context(), stmt); err != nil {
+						return fmt.Errorf("creating the Postgres schema: %w", err)
+					}
+				}
+			}
+
+			copies, err := storage.CopyDatabase(cmd.Context(), src, dst, storage.CopyOptions{
+				BatchSize: batchSize,
+				DryRun:    dryRun,
+			})
+			if err == nil && postgres && !dryRun {
+				err = storage.ResetPostgresSequences(cmd.Context(), src, dst)
+			}
+			if asJSON {
+				// Partial results too: they show which table stopped the copy.
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				if encErr := enc.Encode(copies); encErr != nil && err == nil {
+					err = encErr
+				}
+				return err
+			}
+			var total int64
+			for _, tc := range copies {
+				if tc.Missing {
+					fmt.Printf("%-24s %8d rows  (not in the target yet)\n", tc.Table, tc.Rows)
+					continue
+				}
+				fmt.Printf("%-24s %8d rows  %8d in the target\n", tc.Table, tc.Rows, tc.Target)
+				total += tc.Rows
+			}
+			if err != nil {
+				return err
+			}
+			verb := "Copied"
+			if dryRun {
+				verb = "Would copy"
+			}
+			fmt.Printf("%s %d rows in %d tables\n", verb, total, len(copies))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&toDriver, "to-driver", "postgres", "Target database: postgres or sqlite3")
+	cmd.Flags().StringVar(&toDSN, "to-dsn", "", "Target database (a connection string, or a file for sqlite3)")
+	cmd.Flags().IntVar(&batchSize, "batch-size", storage.DefaultCopyBatch, "Rows per INSERT")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Count the rows to copy without writing anything")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print the tables' counts as JSON")
+	return cmd
+}
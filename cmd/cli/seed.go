@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/fleveque/logo-service/internal/app"
+)
+
+// seedCmd loads a fixture into the configured storage: logos in every
+// status, blocked symbols and demand, so a fresh instance has known data
+// for admin UI work, integration tests and demos. Symbols that already
+// exist are skipped, so running it twice is harmless.
+//
+//	logo-cli seed --fixture testdata/seed.yaml
+//	logo-cli serve --demo --fixture testdata/seed.yaml
+func seedCmd() *cobra.Command {
+	var fixturePath string
+
+	cmd := &cobra.Command{
+		Use:   "seed",
+		Short: "Load a fixture of logos, blocks and demand into the catalog",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Load the fixture first: a typo shouldn't cost opening storage.
+			fixture, err := app.LoadFixture(fixturePath)
+			if err != nil {
+				return err
+			}
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			if cfg.Storage.Backend == "memory" {
+				return fmt.Errorf("storage.backend is memory: nothing would outlive this command, use serve --demo --fixture instead")
+			}
+
+			logger, err := app.NewLogger(cfg.Log.Level)
+			if err != nil {
+				return fmt.Errorf("creating logger: %w", err)
+			}
+			defer func() { _ = logger.Sync() }()
+
+			core, err := app.NewCore(cfg, logger)
+			if err != nil {
+				return err
+			}
+			defer core.Close()
+
+			stats, err := app.Seed(cmd.Context(), core, fixture)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Seeded %d logos (%d already existed), %d blocked, %d requested symbols\n",
+				stats.Created, stats.Skipped, len(fixture.Blocked), len(fixture.Requested))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&fixturePath, "fixture", "", "Fixture file (e.g. testdata/seed.yaml)")
+	_ = cmd.MarkFlagRequired("fixture")
+	return cmd
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/fleveque/logo-service/internal/model"
+	"github.com/fleveque/logo-service/internal/sprite"
+)
+
+// spriteCmd writes a sprite sheet and its coordinate maps.
+//
+//	logo-cli sprite AAPL MSFT GOOGL --size s -o public/logos
+//	logo-cli sprite --symbols-file portfolio.csv --url https://logos.example.com
+//
+// writes logos.png, logos.json and logos.css into the -o directory. Like
+// get, it only uses cached logos locally; against a server, logos are
+// requested one by one and may be acquired.
+func spriteCmd() *cobra.Command {
+	var (
+		remote      remoteFlags
+		symbolsFile string
+		size        string
+		outDir      string
+		name        string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "sprite [SYMBOL...]",
+		Short: "Compose logos into a sprite sheet with JSON and CSS maps",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !model.ValidSize(size) {
+				return fmt.Errorf("invalid size %q: must be xs, s, m, l, or xl", size)
+			}
+			symbols := args
+			if symbolsFile != "" {
+				fromFile, err := readSymbols(symbolsFile)
+				if err != nil {
+					return err
+				}
+				symbols = append(symbols, fromFile...)
+			}
+			for i, s := range symbols {
+				normalized, err := model.NormalizeSymbol(s)
+				if err != nil {
+					return fmt.Errorf("%q: %w", s, err)
+				}
+				symbols[i] = normalized
+			}
+			if len(symbols) == 0 {
+				return fmt.Errorf("no symbols: pass them as arguments or with --symbols-file")
+			}
+
+			ctx := cmd.Context()
+			var get func(symbol string) ([]byte, error)
+			if remote.baseURL != "" {
+				c := remote.client()
+				get = func(symbol string) ([]byte, error) { return c.Logo(ctx, symbol, model.LogoSize(size)) }
+			} else {
+				svc, err := openLocal()
+				if err != nil {
+					return err
+				}
+				defer svc.Close()
+				get = func(symbol string) ([]byte, error) { return svc.CachedLogo(ctx, symbol, model.LogoSize(size)) }
+			}
+
+			sheet, err := sprite.Build(symbols, model.SizePixels[model.LogoSize(size)], get)
+			if err != nil {
+				return err
+			}
+			return writeSprite(sheet, outDir, name)
+		},
+	}
+	remote.register(cmd, "API key")
+	cmd.Flags().StringVar(&symbolsFile, "symbols-file", "", "File with one symbol per line (first CSV column is used)")
+	cmd.Flags().StringVar(&size, "size", "s", "Logo size: xs, s, m, l, or xl")
+	cmd.Flags().StringVarP(&outDir, "output", "o", ".", "Output directory")
+	cmd.Flags().StringVar(&name, "name", "logos", "Base name of the .png, .json and .css files")
+	return cmd
+}
+
+func writeSprite(sheet *sprite.Sheet, dir, name string) error {
+	data, err := sheet.PNG()
+	if err != nil {
+		return err
+	}
+	coords, err := json.MarshalIndent(sheet, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	// The CSS sits next to the PNG, so a relative URL keeps working wherever
+	// the directory is deployed.
+	files := map[string][]byte{
+		name + ".png":  data,
+		name + ".json": append(coords, '\n'),
+		name + ".css":  []byte(sheet.CSS(name + ".png")),
+	}
+	for file, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, file), content, 0644); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Wrote %s.{png,json,css} to %s: %d logos, %dx%d px\n", name, dir, len(sheet.Logos), sheet.Width, sheet.Height)
+	if len(sheet.Missing) > 0 {
+		fmt.Printf("Missing (empty cells): %v\n", sheet.Missing)
+	}
+	return nil
+}
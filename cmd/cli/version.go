@@ -0,0 +1,41 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/fleveque/logo-service/internal/version"
+)
+
+// versionCmd prints which build this is, the same as GET /version.
+//
+//	logo-cli version
+//	logo-cli version --json
+func versionCmd() *cobra.Command {
+	var asJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "version",
+		Short: "Print the version, commit and build date",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			info := version.Get()
+			if asJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(info)
+			}
+			modified := ""
+			if info.Modified {
+				modified = " (modified)"
+			}
+			fmt.Printf("logo-cli %s\ncommit %s%s\nbuilt %s with %s\n", info.Version, info.Commit, modified, info.Date, info.GoVersion)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print the build info as JSON")
+	return cmd
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+// warmCmd requests every symbol/size/background combination once, so the
+// first users after a deploy hit warm caches: missing logos get acquired,
+// and background variants get rendered into the variant cache. With
+// --popular, the most requested symbols of the last 30 days come first
+// (from GET /admin/popular, so the key must be an admin key).
+//
+//	logo-cli warm --symbols-file sp500.csv --sizes m,l --bg ffffff
+//	logo-cli warm --popular 1000 --api-key $LOGO_ADMIN_KEY
+func warmCmd() *cobra.Command {
+	var (
+		baseURL, symbolsFile, apiKey string
+		sizes, backgrounds           []string
+		concurrency, popular         int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "warm",
+		Short: "Pre-request logos (and background variants) so caches are warm after a deploy",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if apiKey == "" {
+				apiKey = os.Getenv("LOGO_API_KEY")
+			}
+			for _, size := range sizes {
+				if !model.ValidSize(size) {
+					return fmt.Errorf("invalid size %q: must be xs, s, m, l, or xl", size)
+				}
+			}
+
+			if symbolsFile == "" && popular == 0 {
+				return fmt.Errorf("nothing to warm: give --symbols-file, --popular or both")
+			}
+			var symbols []string
+			if popular > 0 {
+				top, err := fetchPopular(cmd.Context(), baseURL, apiKey, popular)
+				if err != nil {
+					return err
+				}
+				symbols = top
+			}
+			if symbolsFile != "" {
+				listed, err := readSymbols(symbolsFile)
+				if err != nil {
+					return err
+				}
+				for _, s := range listed {
+					if !slices.Contains(symbols, s) {
+						symbols = append(symbols, s)
+					}
+				}
+			}
+
+			// The transparent rendition is always warmed; each --bg adds a variant.
+			variants := append([]string{""}, backgrounds...)
+
+			var urls []string
+			for _, raw := range symbols {
+				symbol, err := model.NormalizeSymbol(raw)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "skipping %q: %v\n", raw, err)
+					continue
+				}
+				for _, size := range sizes {
+					for _, bg := range variants {
+						urls = append(urls, logoURL(baseURL, symbol, size, bg))
+					}
+				}
+			}
+			if len(urls) == 0 {
+				return fmt.Errorf("nothing to warm: no valid symbols")
+			}
+
+			return runWarm(cmd.Context(), urls, apiKey, concurrency)
+		},
+	}
+
+	cmd.Flags().StringVar(&baseURL, "url", "http://localhost:8080", "Base URL of the logo service")
+	cmd.Flags().StringVar(&symbolsFile, "symbols-file", "", "File with one symbol per line (first CSV column is used)")
+	cmd.Flags().IntVar(&popular, "popular", 0, "Warm the N most requested symbols of the last 30 days first (needs an admin key)")
+	cmd.Flags().StringSliceVar(&sizes, "sizes", []string{"m"}, "Sizes to warm, comma-separated")
+	cmd.Flags().StringSliceVar(&backgrounds, "bg", nil, "Background colors to warm as variants, comma-separated (e.g. ffffff,000000)")
+	cmd.Flags().StringVar(&apiKey, "api-key", "", "API key (default $LOGO_API_KEY)")
+	// Low by default: misses go to GitHub and the LLMs, which are rate limited anyway.
+	cmd.Flags().IntVar(&concurrency, "concurrency", 4, "Concurrent requests")
+	return cmd
+}
+
+// runWarm requests each URL once and prints the same report as loadtest —
+// the status counts show how many logos are still missing.
+func runWarm(ctx context.Context, urls []string, apiKey string, concurrency int) error {
+	start := time.Now()
+	samples := replay(ctx, urls, apiKey, concurrency, func(u string, s sample) {
+		switch s.status {
+		case http.StatusOK:
+		case 0:
+			fmt.Fprintf(os.Stderr, "%s: request failed\n", u)
+		default:
+			fmt.Fprintf(os.Stderr, "%s: HTTP %d\n", u, s.status)
+		}
+	})
+
+	printLoadtestReport(samples, time.Since(start))
+	return nil
+}
+
+// fetchPopular returns the limit most requested symbols of the last 30
+// days, most first, from the admin API.
+func fetchPopular(ctx context.Context, baseURL, apiKey string, limit int) ([]string, error) {
+	u := fmt.Sprintf("%s/api/v1/admin/popular?days=30&limit=%d", strings.TrimRight(baseURL, "/"), limit)
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-API-Key", apiKey)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching popular symbols: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching popular symbols: HTTP %d (is the key an admin key?)", resp.StatusCode)
+	}
+
+	var body struct {
+		Symbols []model.PopularSymbol `json:"symbols"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decoding popular symbols: %w", err)
+	}
+	symbols := make([]string, len(body.Symbols))
+	for i, p := range body.Symbols {
+		symbols[i] = p.Symbol
+	}
+	return symbols, nil
+}
@@ -0,0 +1,311 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+// errSkipped marks a check that couldn't run, e.g. for want of a key.
+var errSkipped = errors.New("skipped")
+
+// smoke holds the target and the state checks hand on to each other.
+type smoke struct {
+	baseURL        string
+	apiKey         string
+	adminKey       string
+	symbols        []string
+	pendingTimeout time.Duration
+
+	httpClient *http.Client
+	logo       []byte // The first symbol's logo, once fetched
+}
+
+// check is one step of the run. A step that depends on an earlier one
+// (the cache check needs the logo fetched) fails with a clear error if
+// that one did.
+type check struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+// result is a check's outcome, as the report shows it.
+type result struct {
+	name     string
+	err      error // nil: passed; errSkipped: skipped
+	duration time.Duration
+}
+
+// run performs every check in order and returns their results. A failed
+// check doesn't stop the run: the report should show everything that's
+// wrong with a deploy, not just the first thing.
+func (s *smoke) run(ctx context.Context) []result {
+	if s.httpClient == nil {
+		s.httpClient = &http.Client{Timeout: 60 * time.Second}
+	}
+	checks := []check{
+		{"health", s.checkHealth},
+		{"auth failure", s.checkAuthFailure},
+		{"fetch", s.checkFetch},
+		{"cached fetch", s.checkCachedFetch},
+		{"background", s.checkBackground},
+		{"batch archive", s.checkArchive},
+		{"admin stats", s.checkAdminStats},
+	}
+
+	results := make([]result, 0, len(checks))
+	for _, c := range checks {
+		start := time.Now()
+		err := c.run(ctx)
+		results = append(results, result{name: c.name, err: err, duration: time.Since(start)})
+	}
+	return results
+}
+
+// checkHealth expects /healthz to answer {"status": "ok"}.
+func (s *smoke) checkHealth(ctx context.Context) error {
+	var body struct {
+		Status string `json:"status"`
+	}
+	resp, err := s.get(ctx, "/healthz", "")
+	if err != nil {
+		return err
+	}
+	if err := expectJSON(resp, &body); err != nil {
+		return err
+	}
+	if body.Status != "ok" {
+		return fmt.Errorf("status %q, want ok", body.Status)
+	}
+	return nil
+}
+
+// checkAuthFailure expects a logo request with a made-up key to be refused.
+// A 200 here means the deployment serves logos to anyone.
+func (s *smoke) checkAuthFailure(ctx context.Context) error {
+	resp, err := s.get(ctx, s.logoPath(s.symbols[0], nil), "smoketest-invalid-key")
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		return fmt.Errorf("HTTP %d for an invalid key, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+	return nil
+}
+
+// checkFetch fetches every symbol's logo, waiting out acquisitions, and
+// expects PNGs.
+func (s *smoke) checkFetch(ctx context.Context) error {
+	for i, symbol := range s.symbols {
+		data, err := s.fetchLogo(ctx, symbol, nil)
+		if err != nil {
+			return fmt.Errorf("%s: %w", symbol, err)
+		}
+		if i == 0 {
+			s.logo = data
+		}
+	}
+	return nil
+}
+
+// checkCachedFetch fetches the first symbol again: now it must be served
+// straight from the cache, and be the same image.
+func (s *smoke) checkCachedFetch(ctx context.Context) error {
+	if s.logo == nil {
+		return errors.New("no logo: the fetch check failed")
+	}
+	resp, err := s.get(ctx, s.logoPath(s.symbols[0], nil), s.apiKey)
+	if err != nil {
+		return err
+	}
+	data, err := expectPNG(resp)
+	if err != nil {
+		return err
+	}
+	if status := resp.Header.Get("X-Logo-Status"); status != "processed" {
+		return fmt.Errorf("X-Logo-Status %q, want processed", status)
+	}
+	if !bytes.Equal(data, s.logo) {
+		return errors.New("a different image than the first fetch")
+	}
+	return nil
+}
+
+// checkBackground expects ?bg= to render a variant, which can't be the
+// transparent logo itself.
+func (s *smoke) checkBackground(ctx context.Context) error {
+	if s.logo == nil {
+		return errors.New("no logo: the fetch check failed")
+	}
+	data, err := s.fetchLogo(ctx, s.symbols[0], url.Values{"bg": {"ffffff"}})
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(data, s.logo) {
+		return errors.New("bg=ffffff returned the transparent logo unchanged")
+	}
+	return nil
+}
+
+// checkArchive fetches every symbol in one archive request and expects a
+// zip with a PNG for each: the fetch check has cached them all.
+func (s *smoke) checkArchive(ctx context.Context) error {
+	q := url.Values{"symbols": {strings.Join(s.symbols, ",")}, "format": {"zip"}}
+	resp, err := s.get(ctx, "/api/v1/logos/archive?"+q.Encode(), s.apiKey)
+	if err != nil {
+		return err
+	}
+	data, err := expectBody(resp, "application/zip")
+	if err != nil {
+		return err
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return fmt.Errorf("reading zip: %w", err)
+	}
+	files := make(map[string]bool, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = true
+	}
+	var missing []string
+	for _, symbol := range s.symbols {
+		if !files[symbol+".png"] {
+			missing = append(missing, symbol)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("archive lacks %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// checkAdminStats expects the admin key to read catalog stats, and the
+// catalog to hold at least the logos fetched.
+func (s *smoke) checkAdminStats(ctx context.Context) error {
+	if s.adminKey == "" {
+		return fmt.Errorf("%w: no admin key", errSkipped)
+	}
+	resp, err := s.get(ctx, "/api/v1/admin/stats", s.adminKey)
+	if err != nil {
+		return err
+	}
+	var stats model.Stats
+	if err := expectJSON(resp, &stats); err != nil {
+		return err
+	}
+	if stats.Processed < int64(len(s.symbols)) {
+		return fmt.Errorf("%d processed logos, want at least %d", stats.Processed, len(s.symbols))
+	}
+	return nil
+}
+
+// fetchLogo gets a logo, polling while the server answers 202 (acquiring)
+// at the pace its Retry-After asks for, up to pendingTimeout.
+func (s *smoke) fetchLogo(ctx context.Context, symbol string, q url.Values) ([]byte, error) {
+	deadline := time.Now().Add(s.pendingTimeout)
+	for {
+		resp, err := s.get(ctx, s.logoPath(symbol, q), s.apiKey)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusAccepted {
+			return expectPNG(resp)
+		}
+		resp.Body.Close()
+
+		wait := 2 * time.Second
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			wait = min(time.Duration(seconds)*time.Second, 10*time.Second)
+		}
+		if time.Now().Add(wait).After(deadline) {
+			return nil, fmt.Errorf("still pending after %s", s.pendingTimeout)
+		}
+		// Go note: select waits for whichever comes first — the timer,
+		// or the overall deadline cancelling ctx.
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+func (s *smoke) logoPath(symbol string, q url.Values) string {
+	if q == nil {
+		q = url.Values{}
+	}
+	q.Set("size", "m")
+	return "/api/v1/logos/" + url.PathEscape(symbol) + "?" + q.Encode()
+}
+
+// get requests path with key as X-API-Key (none if empty). The caller
+// closes the body.
+func (s *smoke) get(ctx context.Context, path, key string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.baseURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if key != "" {
+		req.Header.Set("X-API-Key", key)
+	}
+	return s.httpClient.Do(req)
+}
+
+// expectBody reads a 200 response of the given content type, and closes it.
+func expectBody(resp *http.Response, contentType string) ([]byte, error) {
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<20))
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, snippet(data))
+	}
+	if got := resp.Header.Get("Content-Type"); !strings.HasPrefix(got, contentType) {
+		return nil, fmt.Errorf("Content-Type %q, want %s", got, contentType)
+	}
+	return data, nil
+}
+
+func expectPNG(resp *http.Response) ([]byte, error) {
+	data, err := expectBody(resp, "image/png")
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")) {
+		return nil, errors.New("body is not a PNG")
+	}
+	return data, nil
+}
+
+func expectJSON(resp *http.Response, v any) error {
+	data, err := expectBody(resp, "application/json")
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
+
+// snippet shortens a response body for the report.
+func snippet(data []byte) string {
+	s := strings.TrimSpace(string(data))
+	if len(s) > 200 {
+		s = s[:200] + "..."
+	}
+	return s
+}
@@ -0,0 +1,56 @@
+// Package main is an end-to-end smoke test against a running logo-service:
+// it makes the requests a client would — health, a refused key, a logo
+// fetched and fetched again from cache, a background variant, an archive of
+// several symbols, admin stats — and exits non-zero if any of them
+// misbehaves, so a deploy pipeline can gate on real HTTP behavior.
+//
+//	smoketest --url https://logos.example.com --symbols AAPL,MSFT
+//
+// Keys default to $LOGO_API_KEY and $LOGO_ADMIN_KEY. Without an admin key
+// the admin check is skipped, not failed.
+//
+// It only depends on the standard library and the model types, so it
+// builds without libvips and runs from any CI image.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+func main() {
+	var s smoke
+	var symbols string
+	flag.StringVar(&s.baseURL, "url", "http://localhost:8080", "Base URL of the deployment, including server.base_path")
+	flag.StringVar(&s.apiKey, "api-key", os.Getenv("LOGO_API_KEY"), "API key (default $LOGO_API_KEY)")
+	flag.StringVar(&s.adminKey, "admin-key", os.Getenv("LOGO_ADMIN_KEY"), "Admin key for the stats check (default $LOGO_ADMIN_KEY; skipped if empty)")
+	flag.StringVar(&symbols, "symbols", "AAPL,MSFT", "Symbols to fetch, comma-separated; the first is used for the single-logo checks")
+	// A cold instance may have to acquire the symbols, which can mean a web search.
+	flag.DurationVar(&s.pendingTimeout, "pending-timeout", 2*time.Minute, "How long to wait for a symbol being acquired")
+	timeout := flag.Duration("timeout", 5*time.Minute, "Overall deadline")
+	flag.Parse()
+
+	s.baseURL = strings.TrimSuffix(s.baseURL, "/")
+	for _, symbol := range strings.Split(symbols, ",") {
+		if symbol = strings.TrimSpace(symbol); symbol != "" {
+			s.symbols = append(s.symbols, strings.ToUpper(symbol))
+		}
+	}
+	if s.apiKey == "" || len(s.symbols) == 0 {
+		fmt.Fprintln(os.Stderr, "smoketest: --api-key (or $LOGO_API_KEY) and at least one symbol are required")
+		os.Exit(2)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	results := s.run(ctx)
+	failed := printReport(os.Stdout, s.baseURL, results)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
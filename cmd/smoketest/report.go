@@ -0,0 +1,41 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// printReport writes one line per check and a summary, and returns how
+// many checks failed.
+//
+//	PASS  health         12ms
+//	FAIL  auth failure   8ms    HTTP 200 for an invalid key, want 401
+//	SKIP  admin stats    0s     skipped: no admin key
+func printReport(w io.Writer, target string, results []result) int {
+	fmt.Fprintf(w, "Smoke test of %s\n\n", target)
+
+	// Go note: tabwriter aligns tab-separated cells into columns.
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	var passed, failed, skipped int
+	for _, r := range results {
+		outcome, detail := "PASS", ""
+		switch {
+		case errors.Is(r.err, errSkipped):
+			outcome, detail = "SKIP", r.err.Error()
+			skipped++
+		case r.err != nil:
+			outcome, detail = "FAIL", r.err.Error()
+			failed++
+		default:
+			passed++
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", outcome, r.name, r.duration.Round(time.Millisecond), detail)
+	}
+	_ = tw.Flush()
+
+	fmt.Fprintf(w, "\n%d passed, %d failed, %d skipped\n", passed, failed, skipped)
+	return failed
+}
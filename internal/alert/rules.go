@@ -0,0 +1,132 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// Metric names a value the rules watch; a MetricSource measures it.
+type Metric string
+
+const (
+	MetricAcquisitionFailures Metric = "acquisition_failures" // Cache misses in the last hour no provider could answer
+	MetricLLMSpend            Metric = "llm_spend"            // Estimated LLM spend over the last 24 hours, in dollars
+	MetricCacheHitRate        Metric = "cache_hit_rate"       // Share of today's requests served from cache, 0 to 1
+)
+
+// Metrics lists every metric a rule can watch.
+var Metrics = []Metric{MetricAcquisitionFailures, MetricLLMSpend, MetricCacheHitRate}
+
+// Rule fires when its metric goes above Above, or below Below. Exactly one
+// of them is set.
+type Rule struct {
+	Name   string
+	Metric Metric
+	Above  *float64
+	Below  *float64
+}
+
+// breached reports whether value breaks the rule.
+func (r Rule) breached(value float64) bool {
+	if r.Above != nil {
+		return value > *r.Above
+	}
+	return value < *r.Below
+}
+
+// threshold describes the rule's limit, e.g. "above 50".
+func (r Rule) threshold() string {
+	if r.Above != nil {
+		return fmt.Sprintf("above %g", *r.Above)
+	}
+	return fmt.Sprintf("below %g", *r.Below)
+}
+
+// MetricSource measures metrics. ok is false when there's nothing to
+// measure yet (e.g. a hit rate before any request), and the rule is left
+// as it was.
+type MetricSource interface {
+	Value(ctx context.Context, metric Metric) (value float64, ok bool, err error)
+}
+
+// Notifier delivers an alert. Webhook is one.
+type Notifier interface {
+	Send(ctx context.Context, event, text string, details any) error
+}
+
+// RuleStatus is the details sent with an alert.
+type RuleStatus struct {
+	Rule      string  `json:"rule"`
+	Metric    Metric  `json:"metric"`
+	Value     float64 `json:"value"`
+	Threshold string  `json:"threshold"`
+}
+
+// Engine evaluates rules and notifies when one starts firing
+// ("alert_firing") and when it stops ("alert_resolved") — not on every
+// evaluation in between, so a sustained problem is one message.
+type Engine struct {
+	rules    []Rule
+	metrics  MetricSource
+	notifier Notifier
+	logger   *zap.Logger
+
+	mu     sync.Mutex
+	firing map[string]bool // Rule name → firing
+}
+
+// NewEngine creates an engine for rules. Nothing fires until Evaluate.
+func NewEngine(rules []Rule, metrics MetricSource, notifier Notifier, logger *zap.Logger) *Engine {
+	return &Engine{
+		rules:    rules,
+		metrics:  metrics,
+		notifier: notifier,
+		logger:   logger,
+		firing:   make(map[string]bool),
+	}
+}
+
+// Evaluate measures each rule's metric and notifies the rules that changed
+// state. A metric that can't be measured, or a notification that fails, is
+// logged and tried again on the next evaluation.
+func (e *Engine) Evaluate(ctx context.Context) {
+	// Go note: the lock makes evaluations run one at a time, so two can't
+	// both see a rule as not firing and notify twice.
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, rule := range e.rules {
+		value, ok, err := e.metrics.Value(ctx, rule.Metric)
+		if err != nil {
+			if ctx.Err() == nil {
+				e.logger.Error("measuring alert metric", zap.String("rule", rule.Name), zap.String("metric", string(rule.Metric)), zap.Error(err))
+			}
+			continue
+		}
+		if !ok {
+			continue
+		}
+
+		breached := rule.breached(value)
+		if breached == e.firing[rule.Name] {
+			continue
+		}
+
+		status := RuleStatus{Rule: rule.Name, Metric: rule.Metric, Value: value, Threshold: rule.threshold()}
+		event, text := "alert_resolved", fmt.Sprintf("logo-service: %s resolved, %s is %g", rule.Name, rule.Metric, value)
+		if breached {
+			event, text = "alert_firing", fmt.Sprintf("logo-service: %s, %s is %g (%s)", rule.Name, rule.Metric, value, status.Threshold)
+			e.logger.Warn("alert firing", zap.String("rule", rule.Name), zap.Float64("value", value))
+		} else {
+			e.logger.Info("alert resolved", zap.String("rule", rule.Name), zap.Float64("value", value))
+		}
+		if err := e.notifier.Send(ctx, event, text, status); err != nil {
+			e.logger.Error("sending alert", zap.String("rule", rule.Name), zap.Error(err))
+			continue
+		}
+		e.firing[rule.Name] = breached
+	}
+}
@@ -0,0 +1,83 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// fakeMetrics returns the values set in it; missing metrics can't be measured.
+type fakeMetrics map[Metric]float64
+
+func (f fakeMetrics) Value(_ context.Context, metric Metric) (float64, bool, error) {
+	v, ok := f[metric]
+	return v, ok, nil
+}
+
+type sent struct{ event, rule string }
+
+type fakeNotifier struct {
+	sent []sent
+	err  error
+}
+
+func (f *fakeNotifier) Send(_ context.Context, event, _ string, details any) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.sent = append(f.sent, sent{event, details.(RuleStatus).Rule})
+	return nil
+}
+
+func ptr(v float64) *float64 { return &v }
+
+func TestEngine_Evaluate(t *testing.T) {
+	rules := []Rule{
+		{Name: "failures", Metric: MetricAcquisitionFailures, Above: ptr(10)},
+		{Name: "hit rate", Metric: MetricCacheHitRate, Below: ptr(0.9)},
+	}
+	metrics := fakeMetrics{MetricAcquisitionFailures: 3}
+	notifier := &fakeNotifier{}
+	engine := NewEngine(rules, metrics, notifier, zap.NewNop())
+	ctx := context.Background()
+
+	steps := []struct {
+		name    string
+		metrics fakeMetrics
+		want    []sent
+	}{
+		{"all fine, hit rate unknown", fakeMetrics{MetricAcquisitionFailures: 3}, nil},
+		{"failures spike", fakeMetrics{MetricAcquisitionFailures: 11, MetricCacheHitRate: 0.95}, []sent{{"alert_firing", "failures"}}},
+		{"still high: no repeat", fakeMetrics{MetricAcquisitionFailures: 40, MetricCacheHitRate: 0.95}, nil},
+		{"exactly the limit is fine", fakeMetrics{MetricAcquisitionFailures: 10, MetricCacheHitRate: 0.5}, []sent{{"alert_resolved", "failures"}, {"alert_firing", "hit rate"}}},
+		{"unknown keeps the state", fakeMetrics{}, nil},
+	}
+	for _, step := range steps {
+		clear(metrics)
+		for k, v := range step.metrics {
+			metrics[k] = v
+		}
+		notifier.sent = nil
+		engine.Evaluate(ctx)
+		if len(notifier.sent) != len(step.want) {
+			t.Fatalf("%s: sent %v, want %v", step.name, notifier.sent, step.want)
+		}
+		for i := range step.want {
+			if notifier.sent[i] != step.want[i] {
+				t.Errorf("%s: sent %v, want %v", step.name, notifier.sent, step.want)
+			}
+		}
+	}
+
+	// A failed notification is sent again on the next evaluation.
+	metrics[MetricCacheHitRate] = 0.99
+	notifier.err = errors.New("slack down")
+	engine.Evaluate(ctx)
+	notifier.err = nil
+	engine.Evaluate(ctx)
+	if len(notifier.sent) != 1 || notifier.sent[0] != (sent{"alert_resolved", "hit rate"}) {
+		t.Errorf("expected the resolution to be retried, sent %v", notifier.sent)
+	}
+}
@@ -0,0 +1,60 @@
+// Package alert sends operational alerts to an HTTP webhook — a Slack
+// incoming webhook, an Alertmanager receiver, or anything that accepts JSON.
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Event is the JSON body posted to the webhook. Text duplicates the summary
+// so Slack-compatible receivers display something useful without a template.
+type Event struct {
+	Event   string    `json:"event"`
+	Text    string    `json:"text"`
+	Time    time.Time `json:"time"`
+	Details any       `json:"details,omitempty"`
+}
+
+// Webhook posts events to a URL.
+type Webhook struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhook creates a webhook sender for url.
+func NewWebhook(url string) *Webhook {
+	return &Webhook{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Send posts an event. Alerts are best-effort: callers log the error and move on.
+func (w *Webhook) Send(ctx context.Context, event, text string, details any) error {
+	body, err := json.Marshal(Event{Event: event, Text: text, Time: time.Now().UTC(), Details: details})
+	if err != nil {
+		return fmt.Errorf("encoding alert: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating alert request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending alert: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("alert webhook returned HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
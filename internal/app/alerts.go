@@ -0,0 +1,107 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/fleveque/logo-service/internal/alert"
+	"github.com/fleveque/logo-service/internal/config"
+	"github.com/fleveque/logo-service/internal/service"
+	"github.com/fleveque/logo-service/internal/storage"
+)
+
+// minHitRateRequests is how many requests a day needs before its cache
+// hit rate is judged: just after midnight, a handful of misses would fire
+// the rule.
+const minHitRateRequests = 100
+
+// AlertEngine builds the engine of the alerts.rules, sending to
+// alerts.webhook_url. Nil if there are no rules.
+func AlertEngine(cfg *config.Config, core *Core, logger *zap.Logger) *alert.Engine {
+	if len(cfg.Alerts.Rules) == 0 {
+		return nil
+	}
+	rules := make([]alert.Rule, len(cfg.Alerts.Rules))
+	for i, r := range cfg.Alerts.Rules {
+		rules[i] = alert.Rule{Name: r.Name, Metric: alert.Metric(r.Metric), Above: r.Above, Below: r.Below}
+	}
+	metrics := &alertMetrics{
+		history:      storage.NewStatsHistoryRepository(core.DB, core.Reader),
+		llmCallCents: int64(cfg.Stats.LLMCallCost),
+	}
+	return alert.NewEngine(rules, metrics, alert.NewWebhook(cfg.Alerts.WebhookURL), logger)
+}
+
+// alertMetrics measures the rules' metrics from the attempts, LLM calls and
+// API usage tables.
+type alertMetrics struct {
+	history      storage.StatsHistoryRepository
+	llmCallCents int64
+}
+
+func (m *alertMetrics) Value(ctx context.Context, metric alert.Metric) (float64, bool, error) {
+	now := time.Now().UTC()
+	switch metric {
+	case alert.MetricAcquisitionFailures:
+		activity, err := m.history.Activity(ctx, now.Add(-time.Hour))
+		if err != nil {
+			return 0, false, err
+		}
+		return float64(activity.AcquisitionFailures), true, nil
+	case alert.MetricLLMSpend:
+		activity, err := m.history.Activity(ctx, now.Add(-24*time.Hour))
+		if err != nil {
+			return 0, false, err
+		}
+		return float64(activity.LLMCalls*m.llmCallCents) / 100, true, nil
+	case alert.MetricCacheHitRate:
+		activity, err := m.history.Activity(ctx, now.Truncate(24*time.Hour))
+		if err != nil {
+			return 0, false, err
+		}
+		if activity.Requests < minHitRateRequests {
+			return 0, false, nil
+		}
+		hits := max(activity.Requests-activity.Misses, 0)
+		return float64(hits) / float64(activity.Requests), true, nil
+	default:
+		return 0, false, fmt.Errorf("unknown metric %q", metric)
+	}
+}
+
+// ScanGuard builds the guard of rate_limit.scanning, nil if it's off. A
+// throttled caller is logged and, with alerts.webhook_url, sent as a
+// "scan_detected" alert.
+func ScanGuard(cfg *config.Config, logger *zap.Logger) *service.ScanGuard {
+	sc := cfg.RateLimit.Scanning
+	if sc.MaxMisses <= 0 {
+		return nil
+	}
+	var webhook *alert.Webhook
+	if cfg.Alerts.WebhookURL != "" {
+		webhook = alert.NewWebhook(cfg.Alerts.WebhookURL)
+	}
+	policy := service.ScanPolicy{MaxMisses: sc.MaxMisses, Window: sc.Window, Cooldown: sc.Cooldown}
+	return service.NewScanGuard(policy, func(a service.ScanAlert) {
+		logger.Warn("throttling symbol scanning",
+			zap.String("caller", a.Caller),
+			zap.Int("misses", a.Misses),
+			zap.Time("until", a.Until),
+		)
+		if webhook == nil {
+			return
+		}
+		// Sent in the background: the request that tipped the caller over
+		// shouldn't wait on the webhook.
+		go func() {
+			text := fmt.Sprintf("%s asked for %d symbols that don't exist within %s; its misses are refused until %s",
+				a.Caller, a.Misses, a.Window, a.Until.Format(time.RFC3339))
+			if err := webhook.Send(context.Background(), "scan_detected", text, a); err != nil {
+				logger.Warn("sending scan alert", zap.Error(err))
+			}
+		}()
+	})
+}
@@ -0,0 +1,441 @@
+// Package app builds the logo acquisition pipeline from the config: the
+// database, the logo files, the providers and the LogoService on top.
+// The server, the CLI and the public pkg/logos package all start from here,
+// so they can't drift apart in how a config turns into a running pipeline.
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"github.com/fleveque/logo-service/internal/chaos"
+	"github.com/fleveque/logo-service/internal/clock"
+	"github.com/fleveque/logo-service/internal/config"
+	"github.com/fleveque/logo-service/internal/flags"
+	"github.com/fleveque/logo-service/internal/imagefmt"
+	"github.com/fleveque/logo-service/internal/llm"
+	"github.com/fleveque/logo-service/internal/model"
+	"github.com/fleveque/logo-service/internal/moderation"
+	"github.com/fleveque/logo-service/internal/provider"
+	"github.com/fleveque/logo-service/internal/service"
+	"github.com/fleveque/logo-service/internal/storage"
+)
+
+// Core is everything needed to serve and acquire logos, without the HTTP
+// server around it. The fields are the pieces the server wires into its
+// handlers; library users only need Service.
+type Core struct {
+	DB             *sqlx.DB
+	Reader         *sqlx.DB // Read pool (storage.read_connections); nil: reads use DB
+	FS             *storage.FileSystem
+	LogoRepo       storage.LogoRepository
+	LLMCallRepo    storage.LLMCallRepository
+	RequestedRepo  storage.RequestedSymbolRepository
+	InstrumentRepo storage.InstrumentRepository
+	Processor      *service.ImageProcessor
+	GitHub         *provider.GitHubProvider
+	Index          *provider.IndexProvider // nil if no index.url is configured
+	LLM            *provider.LLMProvider   // nil if no LLM keys are configured
+	Service        *service.LogoService
+	Catalog        *storage.Catalog // nil unless storage.catalog_preload; Serve loads it
+	Flags          *flags.Set       // The flags section plus the admin overrides; Serve refreshes them
+	Clock          clock.Clock      // What the pipeline and Serve's bookkeeping tell the time by: clock.System
+}
+
+// NewCore opens the storage configured in cfg and builds the pipeline on it.
+// opts add to (or override) the service options read from the config, e.g.
+// the server's disk monitor. Close releases the database.
+//
+// With storage.backend "memory", database_path and logo_dir are ignored:
+// the database and the logos are in memory, gone after Close.
+func NewCore(cfg *config.Config, logger *zap.Logger, opts ...service.Option) (*Core, error) {
+	memory := cfg.Storage.Backend == "memory"
+	dbPath := cfg.Storage.DatabasePath
+	if memory {
+		dbPath = storage.MemoryDatabase
+	} else if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+		return nil, fmt.Errorf("creating database directory: %w", err)
+	}
+
+	db, err := storage.NewDatabase(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("opening database: %w", err)
+	}
+
+	var fs *storage.FileSystem
+	if memory {
+		fs, err = storage.NewMemoryFileSystem(storage.Layout(cfg.Storage.Layout))
+	} else {
+		fs, err = storage.NewFileSystem(cfg.Storage.LogoDir, storage.Layout(cfg.Storage.Layout))
+	}
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("creating filesystem storage: %w", err)
+	}
+
+	provider.SetOutboundLimits(OutboundLimits(cfg))
+	provider.SetCredentials(Credentials(cfg))
+	provider.SetIdentity(Identity(cfg))
+	providerFaults, storageFaults := Faults(cfg)
+	provider.SetFaultInjector(providerFaults)
+
+	// A read pool on the same file, so reads don't queue behind writes on
+	// DB's single connection. A memory database exists in one connection only.
+	var reader *sqlx.DB
+	if conns := cfg.Storage.ReadConnections; conns > 0 && !memory {
+		if reader, err = storage.NewReadDatabase(dbPath, conns); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+
+	c := &Core{
+		DB:             db,
+		Reader:         reader,
+		FS:             fs,
+		LogoRepo:       storage.CoalesceReads(storage.InjectFaults(storage.NewLogoRepository(db, reader), storageFaults), cfg.Storage.CoalesceWindow),
+		LLMCallRepo:    storage.NewLLMCallRepository(db, reader),
+		RequestedRepo:  storage.NewRequestedSymbolRepository(db, reader),
+		InstrumentRepo: storage.NewInstrumentRepository(db, reader),
+		Processor:      service.NewImageProcessor(fs),
+		Clock:          clock.System,
+	}
+	c.Processor.SetMaxBytes(MaxImageBytes(cfg))
+	// With the catalog, the pipeline writes through it to keep it current.
+	blocklist := storage.NewBlocklistRepository(db, reader)
+	if cfg.Storage.CatalogPreload {
+		c.Catalog = storage.NewCatalog(c.LogoRepo, blocklist)
+		c.LogoRepo, blocklist = c.Catalog.Logos(), c.Catalog.Blocklist()
+	}
+	c.GitHub = GitHubProvider(cfg, c.LogoRepo, logger)
+
+	// Overrides an admin set earlier apply from the start, to CLI commands too.
+	c.Flags, err = flags.New(cfg.Flags, storage.NewFlagRepository(db, reader))
+	if err == nil {
+		err = c.Flags.Refresh(context.Background())
+	}
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("loading feature flags: %w", err)
+	}
+
+	// During a storage migration, the storage_cutover flag moves every
+	// instance to the new store at once.
+	if !memory && cfg.Storage.Migration.LogoDir != "" {
+		target, err := MigrationTarget(cfg)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		fs.SetCutover(target, func() bool { return c.Flags.Enabled(flags.StorageCutover) })
+	}
+
+	// Build LLM clients in the configured order.
+	// Only clients with API keys are created — missing keys mean that provider is skipped.
+	c.LLM = LLMProvider(cfg, c.LLMCallRepo, logger)
+	if c.LLM != nil {
+		// Clients an admin disabled stay off across restarts.
+		c.LLM.SetClientStore(storage.NewLLMClientRepository(db, reader))
+		if err := c.LLM.RefreshClients(context.Background()); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("loading disabled LLM clients: %w", err)
+		}
+	}
+
+	serviceOpts := []service.Option{
+		service.WithPolicy(AcceptancePolicy(cfg)),
+		service.WithCircuitBreaker(service.CircuitPolicy{
+			Failures: cfg.Providers.CircuitFailures,
+			Cooldown: cfg.Providers.CircuitCooldown,
+		}),
+		service.WithStrategy(cfg.Providers.Strategy),
+		service.WithBlocklist(blocklist),
+		service.WithAuditLog(storage.NewAuditRepository(db, reader)),
+		service.WithEventLog(storage.NewEventRepository(db, reader)),
+		service.WithOriginals(storage.NewOriginalRepository(db, reader)),
+		service.WithAttemptLog(storage.NewAttemptRepository(db, reader)),
+		service.WithStatsHistory(storage.NewStatsHistoryRepository(db, reader), int64(cfg.Stats.LLMCallCost)),
+		service.WithDeleteRetention(cfg.Storage.DeleteRetention),
+		service.WithProcessDefaults(model.ProcessOptions{WhitenBackground: cfg.Processing.WhitenBackground}),
+		service.WithFlags(c.Flags),
+		service.WithLogger(logger),
+		service.WithClock(c.Clock),
+	}
+	if c.Catalog != nil {
+		serviceOpts = append(serviceOpts, service.WithCatalog(c.Catalog))
+	}
+	if cfg.Storage.CoalesceWindow > 0 {
+		serviceOpts = append(serviceOpts, service.WithBatchCoalescing(cfg.Storage.CoalesceWindow))
+	}
+	moderator, err := Moderator(cfg)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	if moderator != nil {
+		serviceOpts = append(serviceOpts, service.WithModeration(moderator, service.ModerationPolicy{
+			Sources:      cfg.Moderation.Sources,
+			ServeOnError: cfg.Moderation.OnError == "serve",
+		}))
+		logger.Info("moderation enabled", zap.String("moderator", moderator.Name()), zap.Strings("sources", cfg.Moderation.Sources))
+	}
+	// Only add when non-nil: a nil *LLMProvider inside the interface would
+	// not compare equal to nil (see WithLLMProvider).
+	if c.LLM != nil {
+		serviceOpts = append(serviceOpts, service.WithLLMProvider(c.LLM))
+	}
+	if cfg.Index.URL != "" {
+		c.Index, err = provider.NewIndexProvider(cfg.Index.Name, cfg.Index.URL, cfg.Index.Format,
+			cfg.Index.RefreshInterval, logger)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		c.Index.SetRetryPolicy(RetryPolicy(cfg.Index.Timeout, cfg.Index.Retries))
+		c.Index.SetMaxBytes(MaxImageBytes(cfg))
+		c.Index.SetClock(c.Clock)
+		serviceOpts = append(serviceOpts, service.WithIndexProvider(c.Index))
+	}
+	if maxSize := cfg.Storage.Variants.MaxSize; maxSize > 0 {
+		serviceOpts = append(serviceOpts, service.WithCache(service.NewVariantCache(storage.NewVariantRepository(db), fs, int64(maxSize), logger)))
+	}
+
+	// LogoService is the core orchestrator: cache → index → GitHub → LLM.
+	// Options from the caller come last, so they win.
+	c.Service = service.NewLogoService(c.LogoRepo, c.RequestedRepo, c.InstrumentRepo, fs, c.Processor, c.GitHub,
+		append(serviceOpts, opts...)...)
+
+	return c, nil
+}
+
+// Close closes the database.
+func (c *Core) Close() error {
+	if c.Reader != nil {
+		c.Reader.Close()
+	}
+	return c.DB.Close()
+}
+
+// MigrationTarget opens storage.migration, the store logo-cli
+// migrate-storage copies logo_dir to.
+func MigrationTarget(cfg *config.Config) (*storage.FileSystem, error) {
+	m := cfg.Storage.Migration
+	layout := m.Layout
+	if layout == "" {
+		layout = cfg.Storage.Layout
+	}
+	fs, err := storage.NewFileSystem(m.LogoDir, storage.Layout(layout))
+	if err != nil {
+		return nil, fmt.Errorf("opening storage.migration: %w", err)
+	}
+	return fs, nil
+}
+
+// RetryPolicy builds a provider's retry policy from its *.timeout and
+// *.retries settings.
+func RetryPolicy(timeout time.Duration, retries int) provider.RetryPolicy {
+	return provider.RetryPolicy{
+		Timeout: timeout,
+		Retries: retries,
+	}
+}
+
+// MaxImageBytes is processing.max_fetch_size: one limit for downloads and
+// for what reaches libvips, so nothing that was fetched is rejected
+// afterwards for its size. A config built by hand rather than loaded may
+// leave it 0: imagefmt.MaxBytes then.
+func MaxImageBytes(cfg *config.Config) int {
+	if size := cfg.Processing.MaxFetchSize; size > 0 {
+		return int(size)
+	}
+	return imagefmt.MaxBytes
+}
+
+// OutboundLimits reads the per-host limits of provider downloads from the config.
+func OutboundLimits(cfg *config.Config) provider.OutboundLimits {
+	return provider.OutboundLimits{
+		RPS:         cfg.Providers.OutboundRPS,
+		Concurrency: cfg.Providers.OutboundConcurrency,
+	}
+}
+
+// Identity is the User-Agent and contacts of providers.user_agent and
+// providers.contact_*.
+func Identity(cfg *config.Config) provider.Identity {
+	return provider.Identity{
+		Product:      cfg.Providers.UserAgent,
+		ContactURL:   cfg.Providers.ContactURL,
+		ContactEmail: cfg.Providers.ContactEmail,
+	}
+}
+
+// Credentials converts providers.auth, reading the ${VAR}s in its values
+// from the environment so secrets can stay out of the file.
+func Credentials(cfg *config.Config) []provider.Credentials {
+	creds := make([]provider.Credentials, len(cfg.Providers.Auth))
+	for i, a := range cfg.Providers.Auth {
+		headers := make(map[string]string, len(a.Headers))
+		for name, value := range a.Headers {
+			headers[name] = os.ExpandEnv(value)
+		}
+		creds[i] = provider.Credentials{
+			Host:       a.Host,
+			PathPrefix: a.PathPrefix,
+			Token:      os.ExpandEnv(a.Token),
+			Username:   os.ExpandEnv(a.Username),
+			Password:   os.ExpandEnv(a.Password),
+			Headers:    headers,
+		}
+	}
+	return creds
+}
+
+// Faults builds the injectors of chaos.providers and chaos.storage. Both
+// are nil — nothing injected — unless chaos.enabled.
+func Faults(cfg *config.Config) (providers, store *chaos.Injector) {
+	if !cfg.Chaos.Enabled {
+		return nil, nil
+	}
+	fault := func(f config.FaultConfig) chaos.Fault {
+		return chaos.Fault{ErrorRate: f.ErrorRate, Latency: f.Latency, LatencyRate: f.LatencyRate}
+	}
+	return chaos.New("providers", fault(cfg.Chaos.Providers)), chaos.New("storage", fault(cfg.Chaos.Storage))
+}
+
+// Moderator creates the moderator of moderation.driver, or returns nil
+// if there's none. The vision driver needs an Anthropic API key.
+func Moderator(cfg *config.Config) (moderation.Moderator, error) {
+	m := cfg.Moderation
+	switch m.Driver {
+	case "hook":
+		return moderation.NewHook(m.URL, m.Token, m.Timeout), nil
+	case "vision":
+		apiKey := cfg.LLM.Anthropic.APIKey
+		if apiKey == "" {
+			apiKey = os.Getenv("LOGO_LLM_ANTHROPIC_API_KEY")
+		}
+		if apiKey == "" {
+			return nil, fmt.Errorf("moderation driver vision needs llm.anthropic.api_key")
+		}
+		modelName := m.Model
+		if modelName == "" {
+			modelName = cfg.LLM.Anthropic.Model
+		}
+		return moderation.NewVision(llm.NewAnthropicClient(apiKey, modelName, llm.AnthropicOptions{Timeout: m.Timeout})), nil
+	}
+	return nil, nil
+}
+
+// AcceptancePolicy reads what the service does with provider results from the config.
+func AcceptancePolicy(cfg *config.Config) service.AcceptancePolicy {
+	return service.AcceptancePolicy{
+		MinConfidence:       cfg.LLM.MinConfidence,
+		LowConfidenceAction: cfg.LLM.LowConfidenceAction,
+		RequireKnownSymbol:  cfg.Instruments.RequireKnown,
+		NotFoundRecheck:     cfg.LLM.NotFoundRecheck,
+		InvalidRecheck:      cfg.LLM.InvalidRecheck,
+		BudgetRecheck:       cfg.LLM.BudgetRecheck,
+	}
+}
+
+// GitHubProvider creates the GitHub provider the github config describes.
+// Files whose stored validators (see storage.LogoRepository) still match
+// cost a 304 instead of a download.
+func GitHubProvider(cfg *config.Config, validators provider.ValidatorSource, logger *zap.Logger) *provider.GitHubProvider {
+	gh := provider.NewGitHubProvider(cfg.GitHub.Repos, logger)
+	gh.SetRouting(routingPolicy(cfg.GitHub.Regions))
+	gh.SetValidators(validators)
+	gh.SetRetryPolicy(RetryPolicy(cfg.GitHub.Timeout, cfg.GitHub.Retries))
+	gh.SetMaxBytes(MaxImageBytes(cfg))
+	gh.SetNamesFile(cfg.GitHub.NamesFile)
+	gh.SetToken(cfg.GitHub.Token)
+	gh.SetAuthoritative(cfg.GitHub.Authoritative)
+	gh.SetPriority(cfg.GitHub.Priority)
+	gh.SetConflictPolicy(provider.ConflictPolicy(cfg.GitHub.ConflictPolicy))
+	return gh
+}
+
+// routingPolicy converts the github.regions config into a provider routing policy.
+func routingPolicy(regions []config.GitHubRegionConfig) *provider.RoutingPolicy {
+	converted := make([]provider.Region, len(regions))
+	for i, r := range regions {
+		converted[i] = provider.Region{Name: r.Name, Suffixes: r.Suffixes, Repos: r.Repos}
+	}
+	return provider.NewRoutingPolicy(converted)
+}
+
+// LLMProvider creates an LLM provider with clients in the configured order.
+// Returns nil if no LLM API keys are configured — the service will skip the LLM layer.
+//
+// Go note: extracting this into a function keeps the callers clean. In Go, you compose
+// complex initialization from small, focused functions rather than using a DI framework.
+func LLMProvider(cfg *config.Config, llmCallRepo storage.LLMCallRepository, logger *zap.Logger) *provider.LLMProvider {
+	var clients []llm.Client
+
+	// Optional external search engine, shared by every client that can call tools.
+	searchKey := cfg.LLM.Search.APIKey
+	if searchKey == "" {
+		searchKey = os.Getenv("LOGO_LLM_SEARCH_API_KEY")
+	}
+	search, err := llm.NewSearchTool(cfg.LLM.Search.Engine, searchKey)
+	if err != nil {
+		logger.Warn("search engine not available, using built-in search", zap.Error(err))
+		search = nil
+	}
+
+	for _, name := range cfg.LLM.ProviderOrder {
+		switch name {
+		case "anthropic":
+			apiKey := cfg.LLM.Anthropic.APIKey
+			if apiKey == "" {
+				apiKey = os.Getenv("LOGO_LLM_ANTHROPIC_API_KEY")
+			}
+			if apiKey != "" {
+				clients = append(clients, llm.NewAnthropicClient(apiKey, cfg.LLM.Anthropic.Model, llm.AnthropicOptions{
+					MaxTurns:  cfg.LLM.Anthropic.MaxTurns,
+					MaxTokens: cfg.LLM.Anthropic.MaxTokens,
+					Timeout:   cfg.LLM.Anthropic.Timeout,
+					Search:    search,
+				}))
+				logger.Info("LLM provider added", zap.String("provider", "anthropic"), zap.String("model", cfg.LLM.Anthropic.Model))
+			}
+
+		case "openai":
+			apiKey := cfg.LLM.OpenAI.APIKey
+			if apiKey == "" {
+				apiKey = os.Getenv("LOGO_LLM_OPENAI_API_KEY")
+			}
+			if apiKey != "" {
+				clients = append(clients, llm.NewOpenAIClient(apiKey, cfg.LLM.OpenAI.Model, llm.OpenAIOptions{
+					Search: search,
+				}))
+				logger.Info("LLM provider added", zap.String("provider", "openai"), zap.String("model", cfg.LLM.OpenAI.Model))
+			}
+
+		case "search":
+			// Non-agentic: one search query, no model. Cheap, but low confidence.
+			if search != nil {
+				clients = append(clients, llm.NewSearchClient(search))
+				logger.Info("LLM provider added", zap.String("provider", "search"), zap.String("engine", search.EngineName()))
+			}
+
+		default:
+			logger.Warn("unknown LLM provider in config, skipping", zap.String("provider", name))
+		}
+	}
+
+	if len(clients) == 0 {
+		return nil
+	}
+
+	p := provider.NewLLMProvider(clients, cfg.LLM.RatePerMinute, llmCallRepo, logger)
+	p.SetRetryPolicy(RetryPolicy(cfg.LLM.DownloadTimeout, cfg.LLM.DownloadRetries))
+	p.SetMaxBytes(MaxImageBytes(cfg))
+	return p
+}
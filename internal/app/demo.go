@@ -0,0 +1,71 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// demoFixture is what SeedDemo stores: a handful of symbols, each drawn as
+// a disc in a brand-like color. They're placeholders, not the companies'
+// real logos.
+var demoFixture = Fixture{
+	Logos: []FixtureLogo{
+		{Symbol: "AAPL", CompanyName: "Apple Inc.", Source: "demo", Color: "#555555"},
+		{Symbol: "MSFT", CompanyName: "Microsoft Corporation", Source: "demo", Color: "#00a4ef"},
+		{Symbol: "GOOGL", CompanyName: "Alphabet Inc.", Source: "demo", Color: "#4285f4"},
+		{Symbol: "AMZN", CompanyName: "Amazon.com, Inc.", Source: "demo", Color: "#ff9900"},
+		{Symbol: "NVDA", CompanyName: "NVIDIA Corporation", Source: "demo", Color: "#76b900"},
+		{Symbol: "TSLA", CompanyName: "Tesla, Inc.", Source: "demo", Color: "#cc0000"},
+	},
+}
+
+// SeedDemo stores a handful of sample logos at every size, so a fresh
+// instance (usually on the memory backend) has something to serve without
+// API keys or network access. The images are drawn here rather than run
+// through the image processor. Symbols that already exist are left alone.
+func SeedDemo(c *Core) error {
+	f := demoFixture
+	f.Logos = append([]FixtureLogo(nil), demoFixture.Logos...) // normalize writes to them
+	if err := f.normalize(); err != nil {
+		return err
+	}
+	_, err := Seed(context.Background(), c, &f)
+	return err
+}
+
+// SeedFixture returns a setup for Serve that seeds the fixture at path,
+// e.g. for `logo-cli serve --demo --fixture testdata/seed.yaml`.
+func SeedFixture(path string) func(*Core) error {
+	return func(c *Core) error {
+		f, err := LoadFixture(path)
+		if err != nil {
+			return err
+		}
+		_, err = Seed(context.Background(), c, f)
+		return err
+	}
+}
+
+// demoPNG draws a filled disc of the given color on a transparent square.
+func demoPNG(px int, c color.RGBA) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, px, px))
+	r := float64(px) / 2
+	for y := 0; y < px; y++ {
+		for x := 0; x < px; x++ {
+			dx, dy := float64(x)+0.5-r, float64(y)+0.5-r
+			if dx*dx+dy*dy <= r*r {
+				img.SetRGBA(x, y, c)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("encoding demo logo: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,292 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"github.com/fleveque/logo-service/internal/model"
+	"github.com/fleveque/logo-service/internal/provider"
+	"github.com/fleveque/logo-service/internal/service"
+	"github.com/fleveque/logo-service/internal/storage"
+)
+
+// fixtureRecheck is when a not_found fixture logo is due for a check.
+const fixtureRecheck = 7 * 24 * time.Hour
+
+// Fixture is a seed file's contents: logos in any status, blocked symbols
+// and unmet demand, to give a fresh instance known data for admin UI work,
+// integration tests and demos. See testdata/seed.yaml.
+type Fixture struct {
+	Logos     []FixtureLogo    `mapstructure:"logos"`
+	Blocked   []FixtureBlock   `mapstructure:"blocked"`
+	Requested []FixtureRequest `mapstructure:"requested"`
+
+	dir string // Image paths are relative to the fixture file
+}
+
+// FixtureLogo is one logo to seed. A processed or review logo gets its
+// sizes from Image, run through the image processor like any acquired
+// logo (so it needs libvips), or else drawn as a disc of Color, which
+// doesn't.
+type FixtureLogo struct {
+	Symbol      string `mapstructure:"symbol"`
+	CompanyName string `mapstructure:"company_name"`
+	Status      string `mapstructure:"status"` // Default processed
+	Source      string `mapstructure:"source"` // Default "fixture"
+	License     string `mapstructure:"license"`
+	Attribution string `mapstructure:"attribution"`
+	Image       string `mapstructure:"image"` // Source image file
+	Color       string `mapstructure:"color"` // "#rrggbb"; gray if neither is set
+	Error       string `mapstructure:"error"` // A failed logo's error message
+	Deleted     bool   `mapstructure:"deleted"`
+
+	rgba color.RGBA
+}
+
+// FixtureBlock is a symbol to put on the blocklist.
+type FixtureBlock struct {
+	Symbol string `mapstructure:"symbol"`
+	Reason string `mapstructure:"reason"`
+}
+
+// FixtureRequest is demand for a symbol we couldn't serve, as the
+// admin missing list shows it.
+type FixtureRequest struct {
+	Symbol string `mapstructure:"symbol"`
+	Count  int    `mapstructure:"count"` // Default 1
+}
+
+// SeedStats counts what Seed did.
+type SeedStats struct {
+	Created int // Logos stored
+	Skipped int // Logos whose symbol already existed
+}
+
+// fixtureStatuses are the statuses a fixture logo can have.
+var fixtureStatuses = map[model.LogoStatus]bool{
+	model.StatusProcessed: true,
+	model.StatusReview:    true,
+	model.StatusPending:   true,
+	model.StatusFailed:    true,
+	model.StatusNotFound:  true,
+}
+
+// LoadFixture reads and checks a seed file (YAML, or anything else viper
+// reads by extension). Every problem is reported, not just the first.
+func LoadFixture(path string) (*Fixture, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("reading fixture: %w", err)
+	}
+	f := &Fixture{dir: filepath.Dir(path)}
+	if err := v.Unmarshal(f); err != nil {
+		return nil, fmt.Errorf("parsing fixture %s: %w", path, err)
+	}
+	if err := f.normalize(); err != nil {
+		return nil, fmt.Errorf("fixture %s: %w", path, err)
+	}
+	return f, nil
+}
+
+// normalize validates the fixture, normalizing symbols and filling in
+// defaults.
+func (f *Fixture) normalize() error {
+	var errs []error
+	symbol := func(where, raw string) string {
+		s, err := model.NormalizeSymbol(raw)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: invalid symbol %q", where, raw))
+		}
+		return s
+	}
+
+	for i := range f.Logos {
+		l := &f.Logos[i]
+		where := fmt.Sprintf("logos[%d]", i)
+		l.Symbol = symbol(where, l.Symbol)
+		if l.Status == "" {
+			l.Status = string(model.StatusProcessed)
+		}
+		if !fixtureStatuses[model.LogoStatus(l.Status)] {
+			errs = append(errs, fmt.Errorf("%s: unknown status %q", where, l.Status))
+		}
+		if l.Source == "" {
+			l.Source = "fixture"
+		}
+		if l.Image != "" && l.Color != "" {
+			errs = append(errs, fmt.Errorf("%s: set image or color, not both", where))
+		}
+		hasSizes := l.Status == string(model.StatusProcessed) || l.Status == string(model.StatusReview)
+		if !hasSizes && (l.Image != "" || l.Color != "") {
+			errs = append(errs, fmt.Errorf("%s: a %s logo has no image", where, l.Status))
+		}
+		if l.Image != "" && !filepath.IsAbs(l.Image) {
+			l.Image = filepath.Join(f.dir, l.Image)
+		}
+		l.rgba = color.RGBA{0x88, 0x88, 0x88, 0xff}
+		if l.Color != "" {
+			rgba, err := parseHexColor(l.Color)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", where, err))
+			}
+			l.rgba = rgba
+		}
+	}
+	for i := range f.Blocked {
+		f.Blocked[i].Symbol = symbol(fmt.Sprintf("blocked[%d]", i), f.Blocked[i].Symbol)
+	}
+	for i := range f.Requested {
+		f.Requested[i].Symbol = symbol(fmt.Sprintf("requested[%d]", i), f.Requested[i].Symbol)
+		if f.Requested[i].Count <= 0 {
+			f.Requested[i].Count = 1
+		}
+	}
+	// Go note: errors.Join returns nil when there are no errors.
+	return errors.Join(errs...)
+}
+
+// parseHexColor parses "#rrggbb" (the "#" is optional).
+func parseHexColor(s string) (color.RGBA, error) {
+	hex := strings.TrimPrefix(s, "#")
+	n, err := strconv.ParseUint(hex, 16, 32)
+	if len(hex) != 6 || err != nil {
+		return color.RGBA{}, fmt.Errorf("invalid color %q, want #rrggbb", s)
+	}
+	return color.RGBA{uint8(n >> 16), uint8(n >> 8), uint8(n), 0xff}, nil
+}
+
+// Seed stores a fixture's logos, blocks and demand. Logos whose symbol
+// already exists are left alone, so seeding twice is harmless; blocks and
+// demand are applied again (demand adds up).
+func Seed(ctx context.Context, c *Core, f *Fixture) (SeedStats, error) {
+	var stats SeedStats
+	events := storage.NewEventRepository(c.DB)
+	for _, l := range f.Logos {
+		_, err := c.LogoRepo.GetBySymbol(ctx, l.Symbol)
+		if err == nil || errors.Is(err, storage.ErrLogoDeleted) {
+			stats.Skipped++
+			continue
+		}
+		if !errors.Is(err, storage.ErrNotFound) {
+			return stats, err
+		}
+		if err := seedLogo(ctx, c, events, l); err != nil {
+			return stats, fmt.Errorf("seeding %s: %w", l.Symbol, err)
+		}
+		stats.Created++
+	}
+
+	for _, b := range f.Blocked {
+		if err := c.Service.BlockSymbol(ctx, b.Symbol, b.Reason); err != nil {
+			return stats, fmt.Errorf("blocking %s: %w", b.Symbol, err)
+		}
+	}
+	for _, r := range f.Requested {
+		for range r.Count {
+			if err := c.RequestedRepo.RecordMiss(ctx, r.Symbol); err != nil {
+				return stats, fmt.Errorf("recording demand for %s: %w", r.Symbol, err)
+			}
+		}
+	}
+	return stats, nil
+}
+
+// seedLogo stores one fixture logo in its status.
+func seedLogo(ctx context.Context, c *Core, events storage.EventRepository, l FixtureLogo) error {
+	status := model.LogoStatus(l.Status)
+	switch {
+	case l.Image != "":
+		// The full pipeline, as if a provider had found the image.
+		data, err := os.ReadFile(l.Image)
+		if err != nil {
+			return err
+		}
+		err = c.Service.ProcessAndStore(ctx, &provider.LogoResult{
+			Symbol:      l.Symbol,
+			CompanyName: l.CompanyName,
+			ImageData:   data,
+			Source:      l.Source,
+			OriginalURL: "file://" + filepath.ToSlash(l.Image),
+			License:     l.License,
+			Attribution: l.Attribution,
+		})
+		if err != nil {
+			return err
+		}
+	case status == model.StatusProcessed || status == model.StatusReview:
+		if err := seedDrawn(ctx, c, l); err != nil {
+			return err
+		}
+		// So the changes feed has something to show too.
+		if status == model.StatusProcessed {
+			if err := events.Record(ctx, &model.Event{Type: model.EventCreated, Symbol: l.Symbol}); err != nil {
+				return err
+			}
+		}
+	case status == model.StatusNotFound:
+		if err := c.LogoRepo.MarkNotFound(ctx, l.Symbol, time.Now().Add(fixtureRecheck)); err != nil {
+			return err
+		}
+	default:
+		logo := &model.Logo{
+			Symbol:      l.Symbol,
+			CompanyName: l.CompanyName,
+			Source:      l.Source,
+			License:     l.License,
+			Attribution: l.Attribution,
+			Status:      status,
+		}
+		if err := c.LogoRepo.Create(ctx, logo); err != nil {
+			return err
+		}
+	}
+
+	if status == model.StatusReview || status == model.StatusFailed {
+		if err := c.LogoRepo.SetStatus(ctx, l.Symbol, status, l.Error); err != nil {
+			return err
+		}
+	}
+	if l.Deleted {
+		return c.Service.DeleteLogo(ctx, l.Symbol, "fixture")
+	}
+	return nil
+}
+
+// seedDrawn stores a logo drawn as a disc at every size. The image
+// processor isn't involved, so this works without libvips.
+func seedDrawn(ctx context.Context, c *Core, l FixtureLogo) error {
+	all := make(map[model.LogoSize]bool, len(model.AllSizes))
+	for _, size := range model.AllSizes {
+		data, err := demoPNG(model.SizePixels[size], l.rgba)
+		if err != nil {
+			return err
+		}
+		if err := c.FS.Write(l.Symbol, size, data); err != nil {
+			return err
+		}
+		all[size] = true
+	}
+
+	logo := &model.Logo{
+		Symbol:      l.Symbol,
+		CompanyName: l.CompanyName,
+		Source:      l.Source,
+		License:     l.License,
+		Attribution: l.Attribution,
+		Status:      model.StatusProcessed,
+	}
+	if err := c.LogoRepo.Create(ctx, logo); err != nil {
+		return err
+	}
+	return service.StoreSizes(ctx, c.FS, c.LogoRepo, l.Symbol, all)
+}
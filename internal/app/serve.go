@@ -0,0 +1,451 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/fleveque/logo-service/internal/alert"
+	"github.com/fleveque/logo-service/internal/clock"
+	"github.com/fleveque/logo-service/internal/config"
+	"github.com/fleveque/logo-service/internal/flags"
+	"github.com/fleveque/logo-service/internal/lifecycle"
+	"github.com/fleveque/logo-service/internal/middleware"
+	"github.com/fleveque/logo-service/internal/model"
+	"github.com/fleveque/logo-service/internal/provider"
+	"github.com/fleveque/logo-service/internal/publish"
+	"github.com/fleveque/logo-service/internal/server"
+	"github.com/fleveque/logo-service/internal/service"
+	"github.com/fleveque/logo-service/internal/storage"
+	"github.com/fleveque/logo-service/internal/version"
+)
+
+// NewLogger creates the zap logger for log.level: human-readable for
+// "debug", JSON for anything else.
+func NewLogger(level string) (*zap.Logger, error) {
+	// Every line says which build wrote it.
+	build := zap.Fields(zap.String("version", version.Version))
+	if level == "debug" {
+		return zap.NewDevelopment(build)
+	}
+	return zap.NewProduction(build)
+}
+
+// Serve runs the HTTP server until SIGINT or SIGTERM, then shuts it down
+// gracefully. Both cmd/server and `logo-cli serve` end up here.
+//
+// setups run on the pipeline before the server starts listening, e.g.
+// SeedDemo for `logo-cli serve --demo`.
+func Serve(cfg *config.Config, logger *zap.Logger, setups ...func(*Core) error) error {
+	build := version.Get()
+	logger.Info("starting logo-service",
+		zap.String("commit", build.Commit),
+		zap.String("built", build.Date),
+		zap.String("go", build.GoVersion),
+	)
+	for _, d := range cfg.Deprecations {
+		logger.Warn("deprecated config key", zap.String("detail", d))
+	}
+	if c := cfg.Chaos; c.Enabled {
+		logger.Warn("fault injection enabled — not for production",
+			zap.Float64("providers_error_rate", c.Providers.ErrorRate),
+			zap.Duration("providers_latency", c.Providers.Latency),
+			zap.Float64("providers_latency_rate", c.Providers.LatencyRate),
+			zap.Float64("storage_error_rate", c.Storage.ErrorRate),
+			zap.Duration("storage_latency", c.Storage.Latency),
+			zap.Float64("storage_latency_rate", c.Storage.LatencyRate),
+		)
+	}
+
+	// Watch free space in logo_dir: below the minimum, reads keep working but
+	// acquisitions are refused. The memory backend has no logo_dir to watch.
+	var disk *storage.DiskMonitor
+	var coreOpts []service.Option
+	if cfg.Storage.Backend != "memory" {
+		disk = buildDiskMonitor(cfg, logger)
+		coreOpts = append(coreOpts, service.WithSpaceChecker(disk))
+	}
+
+	// Storage, providers and the LogoService — the same pipeline pkg/logos
+	// embeds in other programs.
+	core, err := NewCore(cfg, logger, coreOpts...)
+	if err != nil {
+		return err
+	}
+
+	// Everything that runs alongside the HTTP server is a component: added
+	// in dependency order, started in that order, stopped in reverse. The
+	// database comes first, so it closes last.
+	components := lifecycle.New(logger)
+	components.Add("database", lifecycle.OnStop(func(context.Context) error { return core.Close() }))
+	if err := addComponents(components, cfg, core, disk, logger); err != nil {
+		_ = core.Close()
+		return err
+	}
+	// The jobs run from here on, setups included. Start is called again for
+	// the components added below; Stop stops whatever is running, however
+	// Serve returns.
+	if err := components.Start(context.Background()); err != nil {
+		return err
+	}
+	defer components.Stop()
+
+	for _, setup := range setups {
+		if err := setup(core); err != nil {
+			return err
+		}
+	}
+
+	instImporter, err := provider.NewInstrumentImporter(cfg.Instruments.Format, cfg.Instruments.URL, cfg.Instruments.UserAgent, logger)
+	if err != nil {
+		logger.Warn("instrument import disabled", zap.Error(err))
+	}
+
+	if cfg.Storage.Backend == "memory" {
+		logger.Warn("storage is in memory — logos are lost on shutdown")
+	} else {
+		logger.Info("storage initialized",
+			zap.String("database", cfg.Storage.DatabasePath),
+			zap.String("logo_dir", cfg.Storage.LogoDir),
+		)
+	}
+
+	if core.LLM != nil {
+		logger.Info("LLM providers configured",
+			zap.Strings("provider_order", cfg.LLM.ProviderOrder),
+		)
+	} else {
+		logger.Warn("no LLM providers configured — logo discovery limited to GitHub repos")
+	}
+
+	// Relay the changes feed to NATS or Kafka, if configured. The publisher
+	// is added first, so the relay stops before it closes.
+	deliveries := storage.NewDeliveryRepository(core.DB, core.Reader)
+	if pubCfg := cfg.Events.Publish; pubCfg.Driver != "" {
+		pub, err := publish.New(pubCfg.Driver, pubCfg.URL, publish.Options{
+			Subject:  pubCfg.Subject,
+			Timeout:  pubCfg.Timeout,
+			NKeySeed: pubCfg.NKeySeed,
+		})
+		if err != nil {
+			return err
+		}
+		components.Add("publisher", lifecycle.OnStop(func(context.Context) error { return pub.Close() }))
+
+		relay := publish.NewRelay(storage.NewEventRepository(core.DB, core.Reader), deliveries, pub, publish.RelayOptions{
+			PollInterval: pubCfg.PollInterval,
+			MaxAttempts:  pubCfg.MaxAttempts,
+		}, logger)
+		components.Add("event relay", lifecycle.Background(relay.Run))
+		logger.Info("publishing events", zap.String("target", pub.Target()))
+	}
+
+	// Per-key request counts are kept in memory by the limiter and written
+	// to the database every rate_limit.usage_flush_interval, and once more
+	// after the HTTP server has stopped taking requests.
+	limiter := server.NewRateLimiter(cfg.RateLimit)
+	limiter.SetClock(core.Clock)
+	usageRepo := storage.NewUsageRepository(core.DB, core.Reader)
+	// Monthly transfer caps count what was served before a restart too.
+	refreshTransferred(context.Background(), limiter, usageRepo, core.Clock, logger)
+	components.Add("usage flush", lifecycle.OnStop(func(ctx context.Context) error {
+		saveUsage(ctx, limiter, usageRepo, core.Clock, logger)
+		return nil
+	}))
+	components.Add("usage flush loop", lifecycle.Loop(cfg.RateLimit.UsageFlushInterval, func(ctx context.Context) {
+		saveUsage(ctx, limiter, usageRepo, core.Clock, logger)
+	}))
+
+	// Logo requests per symbol are counted the same way, sampled, and
+	// written every stats.popularity_flush_interval.
+	popularityRepo := storage.NewPopularityRepository(core.DB, core.Reader)
+	var popularity *service.Popularity
+	if rate := cfg.Stats.PopularitySampleRate; rate > 0 {
+		popularity = service.NewPopularity(rate)
+		save := func(ctx context.Context) {
+			savePopularity(ctx, popularity, popularityRepo, cfg.Stats.PopularityRetention, core.Clock, logger)
+		}
+		components.Add("popularity flush", lifecycle.OnStop(func(ctx context.Context) error {
+			save(ctx)
+			return nil
+		}))
+		components.Add("popularity flush loop", lifecycle.Loop(cfg.Stats.PopularityFlushInterval, save))
+	}
+
+	// Create and start the HTTP server, last: it stops first, so nothing
+	// it serves outlives what it depends on.
+	deps := server.Deps{
+		LogoRepo:       core.LogoRepo,
+		LLMCallRepo:    core.LLMCallRepo,
+		RequestedRepo:  core.RequestedRepo,
+		UsageRepo:      usageRepo,
+		Deliveries:     deliveries,
+		FileSystem:     core.FS,
+		DiskMonitor:    disk,
+		GitHubProvider: core.GitHub,
+		InstImporter:   instImporter,
+		IndexProvider:  core.Index,
+		LLMProvider:    core.LLM,
+		ImageProcessor: core.Processor,
+		LogoService:    core.Service,
+		RateLimiter:    limiter,
+		Flags:          core.Flags,
+		Popularity:     popularity,
+		PopularityRepo: popularityRepo,
+		ScanGuard:      ScanGuard(cfg, logger),
+	}
+	httpSrv, err := server.New(cfg, logger, deps)
+	if err != nil {
+		return err
+	}
+	srv := &httpServer{srv: httpSrv, errs: make(chan error, 1)}
+	// In-flight requests get 10 seconds to complete.
+	components.Add("http server", srv, lifecycle.WithStopTimeout(10*time.Second))
+
+	// Graceful shutdown: listen for SIGINT (Ctrl+C) or SIGTERM (docker stop).
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+
+	if err := components.Start(context.Background()); err != nil {
+		return err
+	}
+
+	// Block until we receive a signal or the server errors out.
+	select {
+	case sig := <-quit:
+		logger.Info("received shutdown signal", zap.String("signal", sig.String()))
+	case err := <-srv.errs:
+		if err != nil {
+			return err
+		}
+	}
+	return components.Stop()
+}
+
+// addComponents adds the background jobs of the pipeline: the disk monitor,
+// the catalog sync, the storage check, the purge of deleted logos, the
+// stats history, the alert rules and the flag refresh. With storage.catalog_preload it loads the catalog first, so
+// the first requests already skip the database.
+func addComponents(components *lifecycle.Manager, cfg *config.Config, core *Core, disk *storage.DiskMonitor, logger *zap.Logger) error {
+	if disk != nil {
+		components.Add("disk monitor", lifecycle.Background(disk.Run))
+	}
+
+	if core.Catalog != nil {
+		events := storage.NewEventRepository(core.DB, core.Reader)
+		if err := loadCatalog(context.Background(), core.Catalog, events, core.Clock, logger); err != nil {
+			return err
+		}
+		components.Add("catalog sync", lifecycle.Loop(cfg.Storage.CatalogSyncInterval, func(ctx context.Context) {
+			syncCatalog(ctx, core.Catalog, events, logger)
+		}))
+	}
+
+	if cfg.Storage.VerifyOnStartup {
+		components.Add("storage check", lifecycle.Background(func(ctx context.Context) {
+			verifyStorage(ctx, core.Service, core.Clock, logger)
+		}))
+	}
+	components.Add("purge", lifecycle.Loop(cfg.Storage.PurgeInterval, func(ctx context.Context) {
+		purgeDeleted(ctx, core.Service, logger)
+	}))
+	if cfg.Stats.HistoryInterval > 0 {
+		components.Add("stats history", lifecycle.Loop(cfg.Stats.HistoryInterval, func(ctx context.Context) {
+			recordStats(ctx, core.Service, logger)
+		}))
+	}
+	if engine := AlertEngine(cfg, core, logger); engine != nil {
+		components.Add("alerts", lifecycle.Loop(cfg.Alerts.Interval, engine.Evaluate))
+	}
+	// Pick up the flag overrides and disabled LLM clients set through other instances.
+	components.Add("flag refresh", lifecycle.Loop(flagRefreshInterval, func(ctx context.Context) {
+		refreshFlags(ctx, core.Flags, logger)
+		if core.LLM != nil {
+			refreshLLMClients(ctx, core.LLM, logger)
+		}
+	}))
+	return nil
+}
+
+// httpServer runs the HTTP server as a component. A listener that fails
+// after Start (a port already taken) reports on errs.
+type httpServer struct {
+	srv  *server.Server
+	errs chan error
+}
+
+func (h *httpServer) Start(context.Context) error {
+	go func() { h.errs <- h.srv.Start() }()
+	return nil
+}
+
+func (h *httpServer) Stop(ctx context.Context) error {
+	return h.srv.Shutdown(ctx)
+}
+
+// buildDiskMonitor creates the free-space monitor for logo_dir. Crossing the
+// minimum in either direction is logged and, if configured, sent to the alert webhook.
+func buildDiskMonitor(cfg *config.Config, logger *zap.Logger) *storage.DiskMonitor {
+	diskCfg := cfg.Storage.Disk
+	disk := storage.NewDiskMonitor(cfg.Storage.LogoDir, uint64(diskCfg.MinFree), diskCfg.CheckInterval)
+
+	var webhook *alert.Webhook
+	if diskCfg.AlertWebhookURL != "" {
+		webhook = alert.NewWebhook(diskCfg.AlertWebhookURL)
+	}
+
+	disk.OnChange(func(status storage.DiskStatus) {
+		event, text := "disk_space_recovered", "logo-service: disk space recovered, acquisitions resumed"
+		if status.Low {
+			event = "disk_space_low"
+			text = fmt.Sprintf("logo-service: only %d MB free in %s, new logos are not being acquired", status.FreeBytes>>20, cfg.Storage.LogoDir)
+			logger.Warn("disk space low, refusing acquisitions", zap.Uint64("free_bytes", status.FreeBytes), zap.Uint64("min_free_bytes", status.MinFreeBytes))
+		} else {
+			logger.Info("disk space recovered, acquisitions resumed", zap.Uint64("free_bytes", status.FreeBytes))
+		}
+
+		if webhook == nil {
+			return
+		}
+		if err := webhook.Send(context.Background(), event, text, status); err != nil {
+			logger.Error("sending disk space alert", zap.Error(err))
+		}
+	})
+
+	return disk
+}
+
+// loadCatalog preloads the catalog (storage.catalog_preload) before the
+// server starts listening, so the first requests already skip the database.
+func loadCatalog(ctx context.Context, catalog *storage.Catalog, events storage.EventRepository, clk clock.Clock, logger *zap.Logger) error {
+	start := clk.Now()
+	if err := catalog.Load(ctx, events); err != nil {
+		return fmt.Errorf("loading catalog: %w", err)
+	}
+	logger.Info("catalog loaded", zap.Int("logos", catalog.Len()), zap.Duration("took", clk.Now().Sub(start)))
+	return nil
+}
+
+// syncCatalog applies the changes feed to the catalog, for the writes of
+// other processes sharing the database. It runs every
+// storage.catalog_sync_interval.
+func syncCatalog(ctx context.Context, catalog *storage.Catalog, events storage.EventRepository, logger *zap.Logger) {
+	if err := catalog.Sync(ctx, events); err != nil && ctx.Err() == nil {
+		logger.Error("syncing catalog", zap.Error(err))
+	}
+}
+
+// verifyStorage runs the startup consistency scan (storage.verify_on_startup).
+// It runs alongside the server: reads heal missing files on their own, the
+// scan just gets to the logos nobody has asked for yet.
+func verifyStorage(ctx context.Context, svc *service.LogoService, clk clock.Clock, logger *zap.Logger) {
+	start := clk.Now()
+	stats, err := svc.VerifyStorage(ctx)
+	if err != nil {
+		logger.Error("verifying storage", zap.Error(err))
+		return
+	}
+	logger.Info("storage verified",
+		zap.Int("checked", stats.Checked),
+		zap.Int("flagged", stats.Flagged),
+		zap.Int("cleared", stats.Cleared),
+		zap.Int("regenerated", stats.Regenerated),
+		zap.Int("missing", stats.Missing),
+		zap.Duration("took", clk.Now().Sub(start)),
+	)
+}
+
+// purgeDeleted removes the logos whose delete can no longer be undone
+// (storage.delete_retention). It runs every storage.purge_interval.
+func purgeDeleted(ctx context.Context, svc *service.LogoService, logger *zap.Logger) {
+	purged, err := svc.PurgeDeleted(ctx)
+	if err != nil && ctx.Err() == nil {
+		logger.Error("purging deleted logos", zap.Int("purged", purged), zap.Error(err))
+	} else if purged > 0 {
+		logger.Info("purged deleted logos", zap.Int("purged", purged))
+	}
+}
+
+// flagRefreshInterval is how often overrides are reloaded from the
+// database: how long an override set on one instance takes to reach the rest.
+const flagRefreshInterval = 30 * time.Second
+
+// refreshFlags reloads the flag overrides. It runs every flagRefreshInterval.
+func refreshFlags(ctx context.Context, set *flags.Set, logger *zap.Logger) {
+	if err := set.Refresh(ctx); err != nil && ctx.Err() == nil {
+		logger.Warn("refreshing feature flags", zap.Error(err))
+	}
+}
+
+// refreshLLMClients reloads the disabled LLM clients, with the flags.
+func refreshLLMClients(ctx context.Context, llm *provider.LLMProvider, logger *zap.Logger) {
+	if err := llm.RefreshClients(ctx); err != nil && ctx.Err() == nil {
+		logger.Warn("refreshing disabled LLM clients", zap.Error(err))
+	}
+}
+
+// recordStats refreshes today's snapshot in the stats history. It runs
+// every stats.history_interval.
+func recordStats(ctx context.Context, svc *service.LogoService, logger *zap.Logger) {
+	if _, err := svc.RecordStats(ctx); err != nil && ctx.Err() == nil {
+		logger.Error("recording stats history", zap.Error(err))
+	}
+}
+
+// saveUsage writes the counts taken from the limiter, under today's date
+// (UTC): a flush just after midnight puts the last minute of the day before
+// on the new day. Counts that fail to save are dropped with an error log;
+// they're statistics, not billing (the transfer caps let those bytes go).
+// Then the transfer caps catch up with the other instances.
+func saveUsage(ctx context.Context, limiter *middleware.RateLimiter, repo storage.UsageRepository, clk clock.Clock, logger *zap.Logger) {
+	defer refreshTransferred(ctx, limiter, repo, clk, logger)
+	taken := limiter.TakeUsage()
+	if len(taken) == 0 {
+		return
+	}
+	day := clk.Now().UTC().Format(time.DateOnly)
+	usage := make([]model.KeyUsage, len(taken))
+	for i, u := range taken {
+		usage[i] = model.KeyUsage{KeyID: u.KeyID, Day: day, Tier: u.Tier, Requests: u.Requests, Rejected: u.Rejected, CreditsUsed: u.CreditsUsed, BytesServed: u.Bytes}
+	}
+	if err := repo.Add(ctx, usage); err != nil {
+		logger.Error("saving API usage", zap.Int("keys", len(usage)), zap.Error(err))
+	}
+}
+
+// refreshTransferred gives the limiter this month's bytes served per key,
+// from every instance's saved usage, for the monthly transfer caps. The
+// caps are enforced that much behind: between two flushes, an instance
+// only adds what it served itself.
+func refreshTransferred(ctx context.Context, limiter *middleware.RateLimiter, repo storage.UsageRepository, clk clock.Clock, logger *zap.Logger) {
+	now := clk.Now().UTC()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	transferred, err := repo.Transferred(ctx, monthStart.Format(time.DateOnly))
+	if err != nil {
+		logger.Error("loading bytes served", zap.Error(err))
+		return
+	}
+	limiter.SetTransferred(monthStart.Format("2006-01"), transferred)
+}
+
+// savePopularity writes the request counts taken from popularity under
+// today's date and deletes the days older than retention. A failed write
+// loses those counts: they're statistics, not worth a retry queue.
+func savePopularity(ctx context.Context, popularity *service.Popularity, repo storage.PopularityRepository, retention time.Duration, clk clock.Clock, logger *zap.Logger) {
+	counts, dropped := popularity.Take()
+	if dropped > 0 {
+		logger.Warn("popular symbols: too many symbols requested, requests not counted", zap.Int64("dropped", dropped))
+	}
+	now := clk.Now().UTC()
+	if err := repo.Add(ctx, now.Format(time.DateOnly), counts); err != nil {
+		logger.Error("saving symbol requests", zap.Int("symbols", len(counts)), zap.Error(err))
+	}
+	if _, err := repo.Prune(ctx, now.Add(-retention).Format(time.DateOnly)); err != nil {
+		logger.Error("pruning symbol requests", zap.Error(err))
+	}
+}
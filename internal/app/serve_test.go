@@ -0,0 +1,91 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/fleveque/logo-service/internal/clock"
+	"github.com/fleveque/logo-service/internal/middleware"
+	"github.com/fleveque/logo-service/internal/model"
+	"github.com/fleveque/logo-service/internal/service"
+	"github.com/fleveque/logo-service/internal/storage"
+	"github.com/fleveque/logo-service/internal/testutil"
+)
+
+func TestRefreshTransferred_MonthRollover(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	fake := clock.NewFake(time.Date(2026, 9, 30, 23, 59, 0, 0, time.UTC))
+	limiter := middleware.NewRateLimiter(100, 100)
+	limiter.SetClock(fake)
+	limiter.SetTier(middleware.Tier{Name: "capped", RPS: 100, Burst: 100, MonthlyTransfer: 1000}, "capped-key")
+	repo := storage.NewUsageRepository(testutil.NewDB(t))
+	ctx := context.Background()
+	logger := zap.NewNop()
+	key := middleware.KeyID("capped-key")
+
+	used := func() int64 { return limiter.Bucket("capped-key").TransferUsed }
+
+	// Another instance served 600 bytes in September.
+	if err := repo.Add(ctx, []model.KeyUsage{{KeyID: key, Day: "2026-09-30", Tier: "capped", BytesServed: 600}}); err != nil {
+		t.Fatal(err)
+	}
+	refreshTransferred(ctx, limiter, repo, fake, logger)
+	if used() != 600 {
+		t.Errorf("September: expected 600 bytes used, got %d", used())
+	}
+
+	// In October, September's bytes no longer count.
+	fake.Advance(2 * time.Minute)
+	refreshTransferred(ctx, limiter, repo, fake, logger)
+	if used() != 0 {
+		t.Errorf("October: expected 0 bytes used, got %d", used())
+	}
+
+	// What this instance serves is saved under the clock's day.
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("api_key", "capped-key")
+		c.Next()
+	})
+	router.Use(limiter.Middleware())
+	router.GET("/logo", func(c *gin.Context) {
+		c.Data(http.StatusOK, "image/png", make([]byte, 300))
+	})
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/logo", nil))
+
+	saveUsage(ctx, limiter, repo, fake, logger)
+	transferred, err := repo.Transferred(ctx, "2026-10-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transferred[key] != 300 {
+		t.Errorf("expected 300 bytes saved on 2026-10-01, got %v", transferred)
+	}
+	if used() != 300 {
+		t.Errorf("expected 300 bytes used after the flush, got %d", used())
+	}
+}
+
+func TestSavePopularity_ClockDay(t *testing.T) {
+	fake := clock.NewFake(time.Date(2026, 10, 1, 0, 30, 0, 0, time.UTC))
+	popularity := service.NewPopularity(1)
+	repo := storage.NewPopularityRepository(testutil.NewDB(t))
+	ctx := context.Background()
+
+	popularity.Record("AAPL")
+	savePopularity(ctx, popularity, repo, 24*time.Hour, fake, zap.NewNop())
+
+	top, err := repo.Top(ctx, "2026-10-01", false, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(top) != 1 || top[0].Symbol != "AAPL" || top[0].LastRequested != "2026-10-01" {
+		t.Errorf("expected AAPL counted on the clock's day, got %+v", top)
+	}
+}
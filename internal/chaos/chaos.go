@@ -0,0 +1,81 @@
+// Package chaos injects faults — added latency and errors — into provider
+// calls and storage operations, at configured rates. It's for resilience
+// testing: watching the circuit breakers open, the retries retry and
+// requests fall back to 202 on purpose, before an incident does it for us.
+// It's off unless chaos.enabled is set, and never belongs in production.
+package chaos
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/fleveque/logo-service/internal/clock"
+)
+
+// ErrInjected is the error of every injected failure, so logs and tests can
+// tell it from a real one.
+var ErrInjected = errors.New("injected fault")
+
+// Fault describes what to inject into one kind of operation. Rates are
+// fractions of operations, from 0 (never) to 1 (always).
+type Fault struct {
+	ErrorRate   float64       // Operations that fail with ErrInjected
+	Latency     time.Duration // Added to the delayed operations
+	LatencyRate float64       // Operations delayed by Latency
+}
+
+// Active reports whether the fault injects anything at all.
+func (f Fault) Active() bool {
+	return f.ErrorRate > 0 || (f.Latency > 0 && f.LatencyRate > 0)
+}
+
+// Injector injects one Fault into the operations that ask it. A nil
+// Injector injects nothing, so call sites needn't check. It's safe for
+// concurrent use.
+type Injector struct {
+	name  string
+	fault Fault
+
+	clock  clock.Clock
+	random clock.Random
+}
+
+// New returns an Injector for fault, or nil if the fault is inactive. name
+// ("providers", "storage") goes into the injected errors.
+func New(name string, fault Fault) *Injector {
+	if !fault.Active() {
+		return nil
+	}
+	return &Injector{name: name, fault: fault, clock: clock.System, random: clock.Global}
+}
+
+// Inject is called before an operation: it may wait Latency (returning
+// early with ctx's error if ctx is done first), then may return an error
+// wrapping ErrInjected, which the caller returns instead of doing the
+// operation.
+func (i *Injector) Inject(ctx context.Context) error {
+	if i == nil {
+		return nil
+	}
+	if i.fault.Latency > 0 && i.random.Float64() < i.fault.LatencyRate {
+		if err := i.clock.Sleep(ctx, i.fault.Latency); err != nil {
+			return err
+		}
+	}
+	if i.random.Float64() < i.fault.ErrorRate {
+		return &injectedError{name: i.name}
+	}
+	return nil
+}
+
+// injectedError says where the fault was injected, and matches ErrInjected.
+type injectedError struct {
+	name string
+}
+
+func (e *injectedError) Error() string { return "injected fault (" + e.name + ")" }
+
+// Go note: an Is method makes errors.Is(err, ErrInjected) true without the
+// error being ErrInjected itself.
+func (e *injectedError) Is(target error) bool { return target == ErrInjected }
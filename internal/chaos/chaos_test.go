@@ -0,0 +1,71 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fleveque/logo-service/internal/clock"
+)
+
+func TestNew_InactiveIsNil(t *testing.T) {
+	tests := []struct {
+		name  string
+		fault Fault
+	}{
+		{"zero", Fault{}},
+		{"latency without a rate", Fault{Latency: time.Second}},
+		{"rate without latency", Fault{LatencyRate: 0.5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inj := New("providers", tt.fault)
+			if inj != nil {
+				t.Fatalf("expected no injector for %+v", tt.fault)
+			}
+			// A nil injector never injects.
+			if err := inj.Inject(context.Background()); err != nil {
+				t.Errorf("nil Inject = %v", err)
+			}
+		})
+	}
+}
+
+func TestInject_Rates(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+	inj := New("storage", Fault{ErrorRate: 0.25, Latency: 2 * time.Second, LatencyRate: 0.5})
+	inj.clock, inj.random = fake, clock.NewRandom(1)
+
+	const runs = 2000
+	var failed int
+	for range runs {
+		err := inj.Inject(context.Background())
+		if err != nil {
+			if !errors.Is(err, ErrInjected) {
+				t.Fatalf("expected ErrInjected, got %v", err)
+			}
+			failed++
+		}
+	}
+
+	// Every delay is 2s on the fake clock.
+	delayed := int(fake.Now().Sub(start) / (2 * time.Second))
+	// Roughly the configured rates; the seed makes it the same every run.
+	if failed < runs*20/100 || failed > runs*30/100 {
+		t.Errorf("%d of %d failed, want about 25%%", failed, runs)
+	}
+	if delayed < runs*45/100 || delayed > runs*55/100 {
+		t.Errorf("%d of %d delayed, want about 50%%", delayed, runs)
+	}
+}
+
+func TestInject_CanceledDuringLatency(t *testing.T) {
+	inj := New("providers", Fault{Latency: time.Hour, LatencyRate: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := inj.Inject(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package clock
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFake(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	f := NewFake(start)
+
+	f.Advance(time.Minute)
+	if err := f.Sleep(context.Background(), 2*time.Second); err != nil {
+		t.Fatalf("Sleep: %v", err)
+	}
+	if got, want := f.Now(), start.Add(time.Minute+2*time.Second); !got.Equal(want) {
+		t.Errorf("Now() = %v, want %v", got, want)
+	}
+	if got := f.Slept(); !reflect.DeepEqual(got, []time.Duration{2 * time.Second}) {
+		t.Errorf("Slept() = %v", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := f.Sleep(ctx, time.Hour); err == nil {
+		t.Error("expected Sleep to fail on a cancelled context")
+	}
+	if len(f.Slept()) != 1 {
+		t.Error("a cancelled Sleep shouldn't advance the clock")
+	}
+}
+
+func TestSystemSleepCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := System.Sleep(ctx, time.Hour); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestNewRandomIsDeterministic(t *testing.T) {
+	a, b := NewRandom(42), NewRandom(42)
+	for i := 0; i < 5; i++ {
+		if x, y := a.Float64(), b.Float64(); x != y {
+			t.Fatalf("draw %d: %v != %v with the same seed", i, x, y)
+		}
+	}
+}
+
+func TestJitter(t *testing.T) {
+	r := NewRandom(1)
+	for i := 0; i < 100; i++ {
+		d := Jitter(time.Second, 0.2, r)
+		if d < 800*time.Millisecond || d > 1200*time.Millisecond {
+			t.Fatalf("Jitter(1s, 0.2) = %v, outside [800ms, 1200ms]", d)
+		}
+	}
+	if d := Jitter(time.Second, 0, r); d != time.Second {
+		t.Errorf("Jitter with fraction 0 = %v, want 1s", d)
+	}
+}
@@ -0,0 +1,47 @@
+package clock
+
+import (
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// Random is a source of random numbers in [0, 1). Production code uses
+// Global; tests pass NewRandom(seed) to get the same numbers every run.
+type Random interface {
+	Float64() float64
+}
+
+// Global draws from math/rand/v2's top-level functions: randomly seeded at
+// startup and safe for concurrent use.
+var Global Random = globalRandom{}
+
+type globalRandom struct{}
+
+func (globalRandom) Float64() float64 { return rand.Float64() }
+
+// NewRandom returns a deterministic source: the same seed always yields the
+// same sequence. Safe for concurrent use.
+func NewRandom(seed uint64) Random {
+	return &seededRandom{r: rand.New(rand.NewPCG(seed, seed))}
+}
+
+// seededRandom guards a *rand.Rand, which on its own isn't safe for
+// concurrent use (unlike the top-level functions).
+type seededRandom struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+func (s *seededRandom) Float64() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.r.Float64()
+}
+
+// Jitter spreads d by up to ±fraction of it (0.2: anywhere from 80% to
+// 120%), so clients retrying after the same failure don't all come back
+// at the same instant.
+func Jitter(d time.Duration, fraction float64, r Random) time.Duration {
+	return time.Duration(float64(d) * (1 + fraction*(2*r.Float64()-1)))
+}
@@ -5,33 +5,169 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
+
+	"github.com/fleveque/logo-service/internal/alert"
+	"github.com/fleveque/logo-service/internal/origin"
 )
 
 // Config is the root configuration struct. Nested structs organize related settings.
 // `mapstructure` tags tell Viper how to map YAML/env keys to struct fields.
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Storage  StorageConfig  `mapstructure:"storage"`
-	Auth     AuthConfig     `mapstructure:"auth"`
-	CORS     CORSConfig     `mapstructure:"cors"`
-	LLM      LLMConfig      `mapstructure:"llm"`
-	GitHub   GitHubConfig   `mapstructure:"github"`
-	RateLimit RateLimitConfig `mapstructure:"rate_limit"`
-	Log      LogConfig      `mapstructure:"log"`
+	Server      ServerConfig      `mapstructure:"server"`
+	API         APIConfig         `mapstructure:"api"`
+	Storage     StorageConfig     `mapstructure:"storage"`
+	Auth        AuthConfig        `mapstructure:"auth"`
+	CORS        CORSConfig        `mapstructure:"cors"`
+	LLM         LLMConfig         `mapstructure:"llm"`
+	GitHub      GitHubConfig      `mapstructure:"github"`
+	Instruments InstrumentsConfig `mapstructure:"instruments"`
+	Index       IndexConfig       `mapstructure:"index"`
+	Providers   ProvidersConfig   `mapstructure:"providers"`
+	Processing  ProcessingConfig  `mapstructure:"processing"`
+	Serve       ServeConfig       `mapstructure:"serve"`
+	RateLimit   RateLimitConfig   `mapstructure:"rate_limit"`
+	Events      EventsConfig      `mapstructure:"events"`
+	Moderation  ModerationConfig  `mapstructure:"moderation"`
+	Stats       StatsConfig       `mapstructure:"stats"`
+	Alerts      AlertsConfig      `mapstructure:"alerts"`
+	Log         LogConfig         `mapstructure:"log"`
+	Chaos       ChaosConfig       `mapstructure:"chaos"`
+
+	// Flags switches risky behaviors on or off for this environment, by
+	// flag name (see package flags); flags not listed keep their default.
+	// Admins can still override them at runtime.
+	Flags map[string]bool `mapstructure:"-"`
+
+	// Deprecations lists the deprecated keys the config still uses, one
+	// message each, for the server to warn about at startup.
+	Deprecations []string `mapstructure:"-"`
 }
 
 type ServerConfig struct {
 	Host string `mapstructure:"host"`
 	Port int    `mapstructure:"port"`
+
+	// PendingResponse is what a logo request gets while the logo is being
+	// acquired: "accepted" (a 202 with a JSON body) or "placeholder" (a 202 with a
+	// neutral image, for <img> tags). Both carry Retry-After.
+	PendingResponse   string        `mapstructure:"pending_response"`
+	PendingRetryAfter time.Duration `mapstructure:"pending_retry_after"`
+
+	// BasePath mounts every route under a prefix, e.g. "/logo-service" for
+	// /logo-service/api/v1/logos/AAPL behind a gateway that forwards the
+	// full path. Empty serves from the root.
+	BasePath string `mapstructure:"base_path"`
+
+	// AdminPort moves /api/*/admin and /metrics to a second listener, to
+	// keep off the public load balancer; 0 serves them on Port. AdminHost
+	// defaults to Host, e.g. "127.0.0.1" to only allow local access.
+	AdminPort int            `mapstructure:"admin_port"`
+	AdminHost string         `mapstructure:"admin_host"`
+	AdminTLS  AdminTLSConfig `mapstructure:"admin_tls"`
+
+	// Maintenance is what clients are told while the maintenance_mode flag
+	// is on.
+	Maintenance MaintenanceConfig `mapstructure:"maintenance"`
+}
+
+// MaintenanceConfig is the status shown during maintenance: in the 503s of
+// the requests refused, and in /healthz and /readyz.
+type MaintenanceConfig struct {
+	Message    string        `mapstructure:"message"`
+	StatusURL  string        `mapstructure:"status_url"`  // A status page to point clients to; optional
+	RetryAfter time.Duration `mapstructure:"retry_after"` // Sent as Retry-After on the 503s
+}
+
+// AdminTLSConfig serves the admin port over HTTPS. With ClientCAFile,
+// clients may authenticate with a certificate signed by one of its CAs
+// instead of an admin key (mutual TLS).
+type AdminTLSConfig struct {
+	CertFile     string   `mapstructure:"cert_file"`
+	KeyFile      string   `mapstructure:"key_file"`
+	ClientCAFile string   `mapstructure:"client_ca_file"` // PEM bundle; empty: keys only
+	ClientNames  []string `mapstructure:"client_names"`   // Common or DNS names allowed; empty: any the CAs signed
+}
+
+// APIConfig schedules the retirement of /api/v1 now that /api/v2 exists.
+// Dates are YYYY-MM-DD (UTC); empty means not decided yet.
+type APIConfig struct {
+	// V1DeprecatedAt turns on the Deprecation and Link headers on /api/v1
+	// responses. V1SunsetAt adds Sunset and requires V1DeprecatedAt.
+	V1DeprecatedAt string `mapstructure:"v1_deprecated_at"`
+	V1SunsetAt     string `mapstructure:"v1_sunset_at"`
 }
 
 type StorageConfig struct {
-	DatabasePath string `mapstructure:"database_path"`
-	LogoDir      string `mapstructure:"logo_dir"`
+	Backend      string         `mapstructure:"backend"` // "disk", or "memory": nothing outlives the process (demos, evaluation)
+	DatabasePath string         `mapstructure:"database_path"`
+	LogoDir      string         `mapstructure:"logo_dir"`
+	Layout       string         `mapstructure:"layout"` // "flat", "prefix" or "hash" — see storage.Layout
+	Disk         DiskConfig     `mapstructure:"disk"`
+	Variants     VariantsConfig `mapstructure:"variants"`
+
+	// Migration is the store logo-cli migrate-storage copies logo_dir to,
+	// and that the server serves from once the storage_cutover flag is on.
+	Migration MigrationConfig `mapstructure:"migration"`
+
+	// VerifyOnStartup checks every processed logo's size flags against the
+	// files in logo_dir when the server starts (in the background), and
+	// renders missing sizes again from the stored originals.
+	VerifyOnStartup bool `mapstructure:"verify_on_startup"`
+
+	// CatalogPreload loads every logo's status and sizes into memory when
+	// the server starts, so serving a cached logo reads its file without a
+	// database query. Changes made by other processes are picked up from
+	// the changes feed every CatalogSyncInterval.
+	CatalogPreload      bool          `mapstructure:"catalog_preload"`
+	CatalogSyncInterval time.Duration `mapstructure:"catalog_sync_interval"`
+
+	// ReadConnections opens a read-only pool of that many connections for
+	// lookups, counts and listings, so they don't wait behind writes (an
+	// import) on the single writer connection. Zero reads through the
+	// writer. Ignored by the memory backend.
+	ReadConnections int `mapstructure:"read_connections"`
+
+	// CoalesceWindow shares a logo lookup, and an archive's or sprite's
+	// batch of them, between the requests asking for the same thing while
+	// it runs and for this long after: synchronized dashboard refreshes
+	// then cost one query each. Changes made by other processes can take
+	// that long to show. Zero turns it off.
+	CoalesceWindow time.Duration `mapstructure:"coalesce_window"`
+
+	// DeleteRetention is how long a logo deleted by an admin can be
+	// restored. Every PurgeInterval, the server removes the records and
+	// files of those deleted longer ago.
+	DeleteRetention time.Duration `mapstructure:"delete_retention"`
+	PurgeInterval   time.Duration `mapstructure:"purge_interval"`
+}
+
+// MigrationConfig is a storage migration's target: another directory,
+// possibly with another layout. An empty LogoDir means no migration.
+type MigrationConfig struct {
+	LogoDir string `mapstructure:"logo_dir"`
+	Layout  string `mapstructure:"layout"` // "" keeps storage.layout
+}
+
+// VariantsConfig caps the disk used by cached variants (sizes rendered onto
+// a background color). Canonical sizes don't count and are never evicted.
+type VariantsConfig struct {
+	MaxSize ByteSize `mapstructure:"max_size"` // 0 disables variant caching
+}
+
+// DiskConfig controls free-space monitoring of logo_dir. Below MinFree, new
+// logos aren't acquired (cached ones are still served) and an alert is sent.
+type DiskConfig struct {
+	MinFree         ByteSize      `mapstructure:"min_free"` // 0 disables the check
+	CheckInterval   time.Duration `mapstructure:"check_interval"`
+	AlertWebhookURL string        `mapstructure:"alert_webhook_url"` // Optional; receives JSON when space runs low or recovers
 }
 
 type AuthConfig struct {
@@ -39,8 +175,19 @@ type AuthConfig struct {
 	AdminKeys []string `mapstructure:"admin_keys"`
 }
 
+// CORSConfig controls which browser origins may call the API.
 type CORSConfig struct {
-	AllowedOrigins []string `mapstructure:"allowed_origins"`
+	// AllowedOrigins: exact origins, wildcard subdomains ("https://*.quantic.es")
+	// or "*". See the origin package for the exact rules.
+	AllowedOrigins   []string          `mapstructure:"allowed_origins"`
+	AllowCredentials bool              `mapstructure:"allow_credentials"` // Not allowed together with "*"
+	Methods          []string          `mapstructure:"methods"`           // Allowed on every route (default GET)
+	Routes           []CORSRouteConfig `mapstructure:"routes"`            // Per-path overrides, longest prefix wins
+}
+
+type CORSRouteConfig struct {
+	PathPrefix string   `mapstructure:"path_prefix"`
+	Methods    []string `mapstructure:"methods"`
 }
 
 type LLMConfig struct {
@@ -49,12 +196,38 @@ type LLMConfig struct {
 	ProviderOrder []string        `mapstructure:"provider_order"`
 	Anthropic     AnthropicConfig `mapstructure:"anthropic"`
 	OpenAI        OpenAIConfig    `mapstructure:"openai"`
+	Search        SearchConfig    `mapstructure:"search"`
 	RatePerMinute int             `mapstructure:"rate_per_minute"`
+
+	// MinConfidence is the lowest LLM confidence served without review: "low", "medium" or "high".
+	// LowConfidenceAction decides what happens below it: "review" (hold for an admin) or "reject".
+	MinConfidence       string `mapstructure:"min_confidence"`
+	LowConfidenceAction string `mapstructure:"low_confidence_action"`
+
+	// NotFoundRecheck is how long a symbol no provider had a logo for is
+	// answered with a 404 before GitHub and the LLMs are asked again.
+	// 0 asks them on every request.
+	NotFoundRecheck time.Duration `mapstructure:"not_found_recheck"`
+
+	// InvalidRecheck is the same for a symbol whose image was found but
+	// rejected or failed processing; 0 acquires it again on every request.
+	// BudgetRecheck is the same for a symbol GitHub didn't have while every
+	// LLM client was disabled; 0 doesn't remember those misses.
+	InvalidRecheck time.Duration `mapstructure:"invalid_recheck"`
+	BudgetRecheck  time.Duration `mapstructure:"budget_recheck"`
+
+	// Downloads of the images the LLMs point at. The LLM APIs themselves
+	// have their own timeouts (anthropic.timeout).
+	DownloadTimeout time.Duration `mapstructure:"download_timeout"`
+	DownloadRetries int           `mapstructure:"download_retries"`
 }
 
 type AnthropicConfig struct {
-	APIKey string `mapstructure:"api_key"`
-	Model  string `mapstructure:"model"`
+	APIKey    string        `mapstructure:"api_key"`
+	Model     string        `mapstructure:"model"`
+	MaxTurns  int           `mapstructure:"max_turns"`
+	MaxTokens int64         `mapstructure:"max_tokens"`
+	Timeout   time.Duration `mapstructure:"timeout"` // Per-call timeout for each streamed turn
 }
 
 type OpenAIConfig struct {
@@ -62,19 +235,293 @@ type OpenAIConfig struct {
 	Model  string `mapstructure:"model"`
 }
 
+// SearchConfig selects an external web search engine for the LLM clients.
+// Leave Engine empty to use Claude's built-in web search (and no search for OpenAI).
+type SearchConfig struct {
+	Engine string `mapstructure:"engine"` // "brave", "serpapi", "bing", or ""
+	APIKey string `mapstructure:"api_key"`
+}
+
 type GitHubConfig struct {
-	Repos []string `mapstructure:"repos"`
+	Repos   []string             `mapstructure:"repos"`
+	Regions []GitHubRegionConfig `mapstructure:"regions"` // Symbols matching a region use its repos instead of Repos
+
+	Timeout time.Duration `mapstructure:"timeout"` // Per attempt
+	Retries int           `mapstructure:"retries"` // After a network error, timeout, 429 or 5xx
+
+	// NamesFile is a file in each repo mapping symbols to company names
+	// (CSV with symbol and name columns, or a JSON object), e.g. "names.csv".
+	// Repos without it are fine. See GitHubProvider.SetNamesFile.
+	NamesFile string `mapstructure:"names_file"`
+
+	// Token authenticates to GitHub (or set LOGO_GITHUB_TOKEN), to read
+	// private repos. Authoritative lists repos — private or not — whose
+	// logos replace the stored ones on import, e.g. a design team's
+	// corrections. See GitHubProvider.SetToken and SetAuthoritative.
+	Token         string   `mapstructure:"token"`
+	Authoritative []string `mapstructure:"authoritative"`
+
+	// Priority lists repos to try before the others, in order; unlisted
+	// repos follow in the order they're configured. ConflictPolicy decides
+	// which repo wins a symbol several have: "keep_first" (priority order),
+	// "prefer_higher_resolution" (the biggest image; SVG beats any PNG) or
+	// "prefer_authoritative" (keep_first with Authoritative repos ahead).
+	// See GitHubProvider.SetPriority and SetConflictPolicy.
+	Priority       []string `mapstructure:"priority"`
+	ConflictPolicy string   `mapstructure:"conflict_policy"`
+}
+
+// GitHubRegionConfig routes symbols by exchange suffix, e.g. ".T" (Tokyo)
+// to a Japanese logo repo. See provider.RoutingPolicy.
+type GitHubRegionConfig struct {
+	Name     string   `mapstructure:"name"`
+	Suffixes []string `mapstructure:"suffixes"`
+	Repos    []string `mapstructure:"repos"`
+}
+
+// InstrumentsConfig points at a ticker reference dataset (symbol → company name).
+type InstrumentsConfig struct {
+	Format    string `mapstructure:"format"`     // "sec" (company_tickers.json) or "csv"
+	URL       string `mapstructure:"url"`        // Empty with format "sec" uses the SEC's public file
+	UserAgent string `mapstructure:"user_agent"` // The SEC requires contact info; empty sends providers.user_agent's, which has it with contact_email set
+
+	// RequireKnown skips LLM searches for symbols missing from the instruments
+	// table (once it has been imported) — nonsense symbols never cost money.
+	RequireKnown bool `mapstructure:"require_known"`
+}
+
+// IndexConfig points at a curated logo index: a JSON or CSV manifest
+// mapping symbols to image URLs (see provider.IndexProvider). Empty URL
+// disables it.
+type IndexConfig struct {
+	URL    string `mapstructure:"url"`
+	Format string `mapstructure:"format"` // "json" or "csv"
+	Name   string `mapstructure:"name"`   // Logos get source "index:{name}"; defaults to the URL's host
+
+	// RefreshInterval is how long the manifest is cached before on-demand
+	// lookups read it again. Imports always read it afresh.
+	RefreshInterval time.Duration `mapstructure:"refresh_interval"`
+
+	Timeout time.Duration `mapstructure:"timeout"` // Per attempt
+	Retries int           `mapstructure:"retries"` // After a network error, timeout, 429 or 5xx
 }
 
+// ProvidersConfig limits the traffic providers send to each host they
+// download from (GitHub, the index, sites LLMs point at), so bulk imports
+// don't get the server banned.
+type ProvidersConfig struct {
+	OutboundRPS         float64 `mapstructure:"outbound_rps"`         // Requests per second per host; 0 is unlimited
+	OutboundConcurrency int     `mapstructure:"outbound_concurrency"` // Requests in flight per host; 0 is unlimited
+
+	// UserAgent names the product in the User-Agent of every outbound
+	// request, followed by the version and the contacts, e.g.
+	// "logo-service/v1.4.0 (+https://logos.example.com; ops@example.com)".
+	// ContactEmail is also sent as the From header.
+	UserAgent    string `mapstructure:"user_agent"`
+	ContactURL   string `mapstructure:"contact_url"`
+	ContactEmail string `mapstructure:"contact_email"`
+
+	// CircuitFailures consecutive failures (network errors, timeouts, 5xx —
+	// not misses) make the acquisition chain skip a provider for
+	// CircuitCooldown. 0 never skips one.
+	CircuitFailures int           `mapstructure:"circuit_failures"`
+	CircuitCooldown time.Duration `mapstructure:"circuit_cooldown"`
+
+	// Strategy is how a cache miss asks the free providers (the index and
+	// GitHub): "sequential", in order, or "race", all at once with the
+	// first logo found winning. The LLM is always asked last.
+	Strategy string `mapstructure:"strategy"`
+
+	// Auth holds the credentials of sources that need them: private GitHub
+	// repos, an internal asset CDN. See ProviderAuthConfig.
+	Auth []ProviderAuthConfig `mapstructure:"auth"`
+}
+
+// ProviderAuthConfig gives the providers credentials for one source. They
+// go with every HTTPS request to its host (under PathPrefix, if set) and
+// no other, so a URL an LLM came up with can't leak them. Values may name
+// environment variables, e.g. token: "${GITHUB_TOKEN}".
+type ProviderAuthConfig struct {
+	Host       string            `mapstructure:"host"`        // e.g. "raw.githubusercontent.com", or "*.cdn.example.com"
+	PathPrefix string            `mapstructure:"path_prefix"` // e.g. "/acme/" for one GitHub org's repos
+	Token      string            `mapstructure:"token"`       // Sent as a Bearer token
+	Username   string            `mapstructure:"username"`    // Basic auth, with Password
+	Password   string            `mapstructure:"password"`
+	Headers    map[string]string `mapstructure:"headers"` // Added as they are, e.g. X-Api-Key
+}
+
+// ServeConfig picks the image format of logo requests that don't ask for
+// one with format=png or format=webp.
+type ServeConfig struct {
+	DefaultFormat string `mapstructure:"default_format"` // "png" or "webp"
+
+	// AutoNegotiate serves default_format only to clients whose Accept
+	// header lists it (modern browsers list image/webp), and PNG to the
+	// rest, with Vary: Accept for CDNs. Without it, everyone gets
+	// default_format.
+	AutoNegotiate bool `mapstructure:"auto_negotiate"`
+}
+
+// ProcessingConfig holds the defaults for turning source images into sizes.
+// Individual logos can override them through the admin API.
+type ProcessingConfig struct {
+	// WhitenBackground makes a uniform white background transparent, so
+	// logos shipped on white rectangles don't look boxed-in on dark UIs.
+	WhitenBackground bool `mapstructure:"whiten_background"`
+
+	// MaxFetchSize caps a downloaded source image. Bigger downloads fail
+	// with an error instead of being cut off into a corrupt image.
+	MaxFetchSize ByteSize `mapstructure:"max_fetch_size"`
+}
+
+// RateLimitConfig limits requests per API key. Keys not listed in a tier
+// get RequestsPerSecond and Burst, unless a tier is named "default".
 type RateLimitConfig struct {
+	RequestsPerSecond float64               `mapstructure:"requests_per_second"`
+	Burst             int                   `mapstructure:"burst"`
+	Tiers             []RateLimitTierConfig `mapstructure:"tiers"`
+
+	// UsageFlushInterval is how often per-key request counts are written to
+	// the database (see GET /api/v1/admin/stats).
+	UsageFlushInterval time.Duration `mapstructure:"usage_flush_interval"`
+
+	// Misses limits, on top of the above, the logo requests that miss the
+	// cache and acquire a logo: those cost provider calls (and money, with
+	// the LLM), cached reads don't.
+	Misses RouteLimitConfig `mapstructure:"misses"`
+
+	// Imports limits POST /api/v1/admin/import per admin key.
+	Imports RouteLimitConfig `mapstructure:"imports"`
+
+	// Scanning throttles the misses of callers requesting many symbols
+	// that don't exist (see service.ScanGuard).
+	Scanning ScanningConfig `mapstructure:"scanning"`
+}
+
+// ScanningConfig throttles, for Cooldown, the acquisitions of an API key
+// (and with ByIP, a client IP) that asked for MaxMisses distinct symbols no
+// provider had within Window, and sends a "scan_detected" alert to
+// alerts.webhook_url. Cached logos are still served. Zero MaxMisses turns
+// it off.
+type ScanningConfig struct {
+	MaxMisses int           `mapstructure:"max_misses"`
+	Window    time.Duration `mapstructure:"window"`
+	Cooldown  time.Duration `mapstructure:"cooldown"`
+	ByIP      bool          `mapstructure:"by_ip"`
+}
+
+// RouteLimitConfig is the per-key limit of one group of requests. Zero
+// RequestsPerSecond means no limit of its own.
+type RouteLimitConfig struct {
 	RequestsPerSecond float64 `mapstructure:"requests_per_second"`
 	Burst             int     `mapstructure:"burst"`
 }
 
+// RateLimitTierConfig is a rate limit plan and the API keys on it.
+type RateLimitTierConfig struct {
+	Name              string   `mapstructure:"name"`
+	RequestsPerSecond float64  `mapstructure:"requests_per_second"`
+	Burst             int      `mapstructure:"burst"`
+	Credits           int      `mapstructure:"credits"` // Extra requests per day once the bucket is empty
+	Keys              []string `mapstructure:"keys"`
+
+	// MonthlyTransfer caps the bytes served to each key of the tier per
+	// calendar month (UTC); past it, requests get a 429 until the month
+	// ends. Zero means no cap.
+	MonthlyTransfer ByteSize `mapstructure:"monthly_transfer"`
+}
+
+// EventsConfig controls what happens with the changes feed beyond
+// GET /api/v1/changes.
+type EventsConfig struct {
+	Publish PublishConfig `mapstructure:"publish"`
+}
+
+// PublishConfig relays every event to a NATS subject or Kafka topic, at
+// least once, in order. See the publish package.
+type PublishConfig struct {
+	Driver       string        `mapstructure:"driver"`    // "nats", "kafka", or "" to not publish
+	URL          string        `mapstructure:"url"`       // nats://[user:pass@]host:4222 or tls://..., or the Kafka REST Proxy's base URL
+	Subject      string        `mapstructure:"subject"`   // NATS subject, captured by a JetStream stream, or Kafka topic
+	NKeySeed     string        `mapstructure:"nkey_seed"` // NATS: user nkey seed to authenticate with, instead of the URL's credentials
+	PollInterval time.Duration `mapstructure:"poll_interval"`
+	MaxAttempts  int           `mapstructure:"max_attempts"` // Per event, before it goes to the dead-letter log
+	Timeout      time.Duration `mapstructure:"timeout"`
+}
+
+// ModerationConfig screens new logos before they're served, and holds the
+// ones it flags in the review queue with a reason (see the moderation
+// package).
+type ModerationConfig struct {
+	Driver  string        `mapstructure:"driver"`   // "hook", "vision", or "" to serve unscreened
+	URL     string        `mapstructure:"url"`      // hook: where logos are POSTed
+	Token   string        `mapstructure:"token"`    // hook: sent as a Bearer token, if set
+	Model   string        `mapstructure:"model"`    // vision: Anthropic model, llm.anthropic.model if empty
+	Timeout time.Duration `mapstructure:"timeout"`  // Per logo
+	Sources []string      `mapstructure:"sources"`  // Provider kinds screened: llm, github, or an index's name
+	OnError string        `mapstructure:"on_error"` // When the moderator fails: "review" or "serve"
+}
+
+// StatsConfig keeps a daily history of the stats for trend charts (see
+// GET /api/v1/admin/stats/history).
+type StatsConfig struct {
+	// HistoryInterval is how often today's snapshot is refreshed; the last
+	// one of a day is the day's point. 0 keeps no history.
+	HistoryInterval time.Duration `mapstructure:"history_interval"`
+
+	// LLMCallCost estimates what one LLM search costs, for the history's
+	// llm_spend. The calls themselves are counted, not billed tokens.
+	LLMCallCost Money `mapstructure:"llm_call_cost"`
+
+	// PopularitySampleRate is the fraction of logo requests counted for the
+	// most requested symbols (see service.Popularity); 0 counts none. The
+	// counts are written every PopularityFlushInterval and kept per day
+	// for PopularityRetention.
+	PopularitySampleRate    float64       `mapstructure:"popularity_sample_rate"`
+	PopularityFlushInterval time.Duration `mapstructure:"popularity_flush_interval"`
+	PopularityRetention     time.Duration `mapstructure:"popularity_retention"`
+}
+
+// AlertsConfig sends a webhook (Slack, Alertmanager...) when a rule starts
+// or stops firing (see alert.Engine).
+type AlertsConfig struct {
+	WebhookURL string            `mapstructure:"webhook_url"`
+	Interval   time.Duration     `mapstructure:"interval"` // How often the rules are evaluated
+	Rules      []AlertRuleConfig `mapstructure:"rules"`
+}
+
+// AlertRuleConfig fires when Metric goes above Above or below Below; set
+// exactly one. Metrics: acquisition_failures (per hour), llm_spend (dollars
+// over 24 hours) and cache_hit_rate (today's, 0 to 1).
+type AlertRuleConfig struct {
+	Name   string   `mapstructure:"name"`
+	Metric string   `mapstructure:"metric"`
+	Above  *float64 `mapstructure:"above"`
+	Below  *float64 `mapstructure:"below"`
+}
+
 type LogConfig struct {
 	Level string `mapstructure:"level"`
 }
 
+// ChaosConfig injects faults into provider calls and storage operations,
+// to check the circuit breakers, retries and 202 fallback do their job
+// (see the chaos package). Nothing is injected unless Enabled: the rates
+// can stay in a staging config and be switched on for a test.
+type ChaosConfig struct {
+	Enabled   bool        `mapstructure:"enabled"`
+	Providers FaultConfig `mapstructure:"providers"` // Every provider HTTP attempt and LLM search
+	Storage   FaultConfig `mapstructure:"storage"`   // Logo lookups and the writes of an acquisition
+}
+
+// FaultConfig is what to inject into one kind of operation. Rates are
+// fractions of operations, from 0 to 1.
+type FaultConfig struct {
+	ErrorRate   float64       `mapstructure:"error_rate"`
+	Latency     time.Duration `mapstructure:"latency"`
+	LatencyRate float64       `mapstructure:"latency_rate"`
+}
+
 // Load reads configuration from a YAML file and environment variables.
 // In Go, functions return errors as the last return value — callers must check them.
 // This pattern replaces try/catch: if err != nil { handle it }.
@@ -84,20 +531,124 @@ func Load(configPath string) (*Config, error) {
 	// Set defaults — these apply when neither file nor env provides a value
 	v.SetDefault("server.host", "0.0.0.0")
 	v.SetDefault("server.port", 8080)
+	v.SetDefault("server.pending_response", "accepted")
+	v.SetDefault("server.pending_retry_after", "5s")
+	v.SetDefault("server.base_path", "")
+	v.SetDefault("server.admin_port", 0)
+	v.SetDefault("server.admin_host", "")
+	v.SetDefault("server.admin_tls.cert_file", "")
+	v.SetDefault("server.admin_tls.key_file", "")
+	v.SetDefault("server.admin_tls.client_ca_file", "")
+	v.SetDefault("server.admin_tls.client_names", []string{})
+	v.SetDefault("server.maintenance.message", "The service is under maintenance; cached logos are still served.")
+	v.SetDefault("server.maintenance.status_url", "")
+	v.SetDefault("server.maintenance.retry_after", "5m")
+
+	v.SetDefault("api.v1_deprecated_at", "")
+	v.SetDefault("api.v1_sunset_at", "")
+	v.SetDefault("storage.backend", "disk")
 	v.SetDefault("storage.database_path", "./storage/logo-service.db")
 	v.SetDefault("storage.logo_dir", "./storage/logos")
+	v.SetDefault("storage.layout", "hash")
+	v.SetDefault("storage.disk.min_free", "500MB")
+	v.SetDefault("storage.disk.check_interval", "30s")
+	v.SetDefault("storage.disk.alert_webhook_url", "") // Registered so LOGO_STORAGE_DISK_ALERT_WEBHOOK_URL is picked up
+	v.SetDefault("storage.variants.max_size", "256MB")
+	v.SetDefault("storage.migration.logo_dir", "")
+	v.SetDefault("storage.migration.layout", "")
+	v.SetDefault("storage.verify_on_startup", false)
+	v.SetDefault("storage.catalog_preload", false)
+	v.SetDefault("storage.catalog_sync_interval", "5s")
+	v.SetDefault("storage.read_connections", 0)
+	v.SetDefault("storage.coalesce_window", "250ms")
+	v.SetDefault("storage.delete_retention", "30d")
+	v.SetDefault("storage.purge_interval", "1h")
 	v.SetDefault("cors.allowed_origins", []string{"http://localhost:3000", "http://localhost:3036"})
+	v.SetDefault("cors.allow_credentials", false)
+	v.SetDefault("cors.methods", []string{"GET"})
 	v.SetDefault("llm.provider_order", []string{"anthropic", "openai"})
 	v.SetDefault("llm.anthropic.model", "claude-sonnet-4-5-20250929")
+	v.SetDefault("llm.anthropic.max_turns", 5)
+	v.SetDefault("llm.anthropic.max_tokens", 1024)
+	v.SetDefault("llm.anthropic.timeout", "60s")
 	v.SetDefault("llm.openai.model", "gpt-4o")
 	v.SetDefault("llm.rate_per_minute", 10)
+	v.SetDefault("llm.download_timeout", "30s")
+	v.SetDefault("llm.download_retries", 1)
+	v.SetDefault("github.timeout", "30s")
+	v.SetDefault("github.retries", 2)
+	v.SetDefault("github.names_file", "")
+	v.SetDefault("github.token", "")
+	v.SetDefault("github.conflict_policy", "prefer_authoritative")
+	v.SetDefault("index.timeout", "30s")
+	v.SetDefault("index.retries", 2)
+	v.SetDefault("providers.circuit_failures", 5)
+	v.SetDefault("providers.circuit_cooldown", "60s")
+	v.SetDefault("providers.strategy", "sequential")
+	v.SetDefault("llm.min_confidence", "medium")
+	v.SetDefault("llm.low_confidence_action", "review")
+	v.SetDefault("llm.not_found_recheck", "7d")
+	v.SetDefault("llm.invalid_recheck", "0")
+	v.SetDefault("llm.budget_recheck", "0")
 	v.SetDefault("github.repos", []string{
 		"davidepalazzo/ticker-logos",
 		"nvstly/icons",
 	})
+	v.SetDefault("instruments.format", "sec")
+	v.SetDefault("instruments.user_agent", "")
+	v.SetDefault("instruments.require_known", false)
+	v.SetDefault("index.url", "")
+	v.SetDefault("index.format", "json")
+	v.SetDefault("index.name", "")
+	v.SetDefault("index.refresh_interval", "1h")
+	v.SetDefault("providers.outbound_rps", 5)
+	v.SetDefault("providers.outbound_concurrency", 4)
+	v.SetDefault("providers.user_agent", "logo-service")
+	v.SetDefault("providers.contact_url", "")
+	v.SetDefault("providers.contact_email", "")
+	v.SetDefault("processing.whiten_background", false)
+	v.SetDefault("serve.default_format", "png")
+	v.SetDefault("serve.auto_negotiate", false)
+	v.SetDefault("processing.max_fetch_size", "10MB")
 	v.SetDefault("rate_limit.requests_per_second", 10)
 	v.SetDefault("rate_limit.burst", 20)
+	v.SetDefault("rate_limit.usage_flush_interval", "60s")
+	v.SetDefault("rate_limit.misses.requests_per_second", 1)
+	v.SetDefault("rate_limit.misses.burst", 5)
+	v.SetDefault("rate_limit.imports.requests_per_second", 0.1)
+	v.SetDefault("rate_limit.imports.burst", 2)
+	v.SetDefault("rate_limit.scanning.max_misses", 50)
+	v.SetDefault("rate_limit.scanning.window", "10m")
+	v.SetDefault("rate_limit.scanning.cooldown", "1h")
+	v.SetDefault("rate_limit.scanning.by_ip", true)
+	v.SetDefault("events.publish.driver", "")
+	v.SetDefault("events.publish.url", "")
+	v.SetDefault("events.publish.subject", "logo-service.events")
+	v.SetDefault("events.publish.nkey_seed", "")
+	v.SetDefault("events.publish.poll_interval", "5s")
+	v.SetDefault("events.publish.max_attempts", 5)
+	v.SetDefault("events.publish.timeout", "10s")
+	v.SetDefault("moderation.driver", "")
+	v.SetDefault("moderation.url", "")
+	v.SetDefault("moderation.token", "")
+	v.SetDefault("moderation.model", "")
+	v.SetDefault("moderation.timeout", "30s")
+	v.SetDefault("moderation.sources", []string{"llm"})
+	v.SetDefault("moderation.on_error", "review")
+	v.SetDefault("stats.history_interval", "1h")
+	v.SetDefault("stats.llm_call_cost", "$0")
+	v.SetDefault("stats.popularity_sample_rate", 1.0)
+	v.SetDefault("stats.popularity_flush_interval", "60s")
+	v.SetDefault("stats.popularity_retention", "90d")
+	v.SetDefault("alerts.webhook_url", "")
+	v.SetDefault("alerts.interval", "5m")
 	v.SetDefault("log.level", "info")
+	v.SetDefault("chaos.enabled", false)
+	for _, target := range []string{"providers", "storage"} {
+		v.SetDefault("chaos."+target+".error_rate", 0)
+		v.SetDefault("chaos."+target+".latency", "0")
+		v.SetDefault("chaos."+target+".latency_rate", 0)
+	}
 
 	// Read from YAML config file if provided
 	if configPath != "" {
@@ -122,10 +673,21 @@ func Load(configPath string) (*Config, error) {
 	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	v.AutomaticEnv()
 
-	// Unmarshal into our Config struct
+	deprecations, err := applyRenamedKeys(v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	// Unmarshal into our Config struct. The hook parses "30s", "512MB" and
+	// "$50" into the typed fields (see units.go).
 	var cfg Config
-	if err := v.Unmarshal(&cfg); err != nil {
-		return nil, fmt.Errorf("unmarshaling config: %w", err)
+	if err := v.Unmarshal(&cfg, viper.DecodeHook(decodeHook())); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", keyErrors(err))
+	}
+	cfg.Deprecations = deprecations
+
+	if cfg.Flags, err = readFlags(v); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
 	}
 
 	// Viper can't auto-split comma-separated env vars into []string slices.
@@ -134,10 +696,473 @@ func Load(configPath string) (*Config, error) {
 	parseCommaSeparatedEnv(&cfg.Auth.APIKeys, "LOGO_AUTH_API_KEYS")
 	parseCommaSeparatedEnv(&cfg.Auth.AdminKeys, "LOGO_AUTH_ADMIN_KEYS")
 	parseCommaSeparatedEnv(&cfg.CORS.AllowedOrigins, "LOGO_CORS_ALLOWED_ORIGINS")
+	parseCommaSeparatedEnv(&cfg.Server.AdminTLS.ClientNames, "LOGO_SERVER_ADMIN_TLS_CLIENT_NAMES")
+	parseCommaSeparatedEnv(&cfg.Moderation.Sources, "LOGO_MODERATION_SOURCES")
+
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
 
 	return &cfg, nil
 }
 
+// validate checks values that Viper can't check for us (enums, ranges).
+// Failing at startup beats discovering a typo on the first request.
+func (c *Config) validate() error {
+	switch c.Server.PendingResponse {
+	case "accepted", "placeholder":
+	default:
+		return fmt.Errorf("server.pending_response must be accepted or placeholder, got %q", c.Server.PendingResponse)
+	}
+	if c.Server.PendingRetryAfter < time.Second {
+		return fmt.Errorf("server.pending_retry_after must be at least 1s, got %s", c.Server.PendingRetryAfter)
+	}
+	if c.Server.Maintenance.RetryAfter < time.Second {
+		return fmt.Errorf("server.maintenance.retry_after must be at least 1s, got %s", c.Server.Maintenance.RetryAfter)
+	}
+	if b := c.Server.BasePath; b != "" && (!strings.HasPrefix(b, "/") || strings.HasSuffix(b, "/") || strings.ContainsAny(b, "?#:* ")) {
+		return fmt.Errorf("server.base_path must look like /logo-service (leading slash, no trailing slash), got %q", b)
+	}
+	if p := c.Server.AdminPort; p < 0 || p > 65535 || (p != 0 && p == c.Server.Port) {
+		return fmt.Errorf("server.admin_port must be 0 or a port other than server.port, got %d", p)
+	}
+	if t := c.Server.AdminTLS; t.CertFile != "" || t.KeyFile != "" || t.ClientCAFile != "" {
+		if c.Server.AdminPort == 0 {
+			return fmt.Errorf("server.admin_tls needs server.admin_port")
+		}
+		if t.CertFile == "" || t.KeyFile == "" {
+			return fmt.Errorf("server.admin_tls needs both cert_file and key_file")
+		}
+	}
+	if len(c.Server.AdminTLS.ClientNames) > 0 && c.Server.AdminTLS.ClientCAFile == "" {
+		return fmt.Errorf("server.admin_tls.client_names needs client_ca_file")
+	}
+	if _, _, err := c.API.V1Retirement(); err != nil {
+		return err
+	}
+
+	switch c.Storage.Backend {
+	case "disk", "memory":
+	default:
+		return fmt.Errorf("storage.backend must be disk or memory, got %q", c.Storage.Backend)
+	}
+
+	switch c.Storage.Layout {
+	case "flat", "prefix", "hash":
+	default:
+		return fmt.Errorf("storage.layout must be flat, prefix or hash, got %q", c.Storage.Layout)
+	}
+	if m := c.Storage.Migration; m.LogoDir != "" {
+		switch m.Layout {
+		case "", "flat", "prefix", "hash":
+		default:
+			return fmt.Errorf("storage.migration.layout must be flat, prefix or hash, got %q", m.Layout)
+		}
+		// Two layouts in one directory can collide (an "AA" shard is also
+		// the flat directory of AA); migrate-storage without a target
+		// converts a directory in place instead.
+		if filepath.Clean(m.LogoDir) == filepath.Clean(c.Storage.LogoDir) {
+			return fmt.Errorf("storage.migration.logo_dir must differ from storage.logo_dir")
+		}
+	}
+
+	if c.Storage.ReadConnections < 0 {
+		return fmt.Errorf("storage.read_connections must not be negative, got %d", c.Storage.ReadConnections)
+	}
+	if c.Storage.DeleteRetention < 0 {
+		return fmt.Errorf("storage.delete_retention must not be negative, got %s", c.Storage.DeleteRetention)
+	}
+	if c.Storage.PurgeInterval < time.Minute {
+		return fmt.Errorf("storage.purge_interval must be at least 1m, got %s", c.Storage.PurgeInterval)
+	}
+	if c.Storage.CoalesceWindow < 0 {
+		return fmt.Errorf("storage.coalesce_window must not be negative, got %s", c.Storage.CoalesceWindow)
+	}
+	if c.Storage.CatalogSyncInterval < time.Second {
+		return fmt.Errorf("storage.catalog_sync_interval must be at least 1s, got %s", c.Storage.CatalogSyncInterval)
+	}
+	if c.Storage.Disk.CheckInterval < time.Second {
+		return fmt.Errorf("storage.disk.check_interval must be at least 1s, got %s", c.Storage.Disk.CheckInterval)
+	}
+
+	if err := c.CORS.validate(); err != nil {
+		return err
+	}
+
+	for i, r := range c.GitHub.Regions {
+		if len(r.Suffixes) == 0 {
+			return fmt.Errorf("github.regions[%d].suffixes must not be empty", i)
+		}
+		for _, s := range r.Suffixes {
+			if len(s) < 2 || s[0] != '.' {
+				return fmt.Errorf("github.regions[%d].suffixes: %q must start with a dot, e.g. \".T\"", i, s)
+			}
+		}
+		if len(r.Repos) == 0 {
+			return fmt.Errorf("github.regions[%d].repos must not be empty", i)
+		}
+	}
+	knownRepo := func(repo string) bool {
+		known := slices.Contains(c.GitHub.Repos, repo)
+		for _, r := range c.GitHub.Regions {
+			known = known || slices.Contains(r.Repos, repo)
+		}
+		return known
+	}
+	for _, repo := range c.GitHub.Authoritative {
+		if !knownRepo(repo) {
+			return fmt.Errorf("github.authoritative: %q is not in github.repos or a region's repos", repo)
+		}
+	}
+	for _, repo := range c.GitHub.Priority {
+		if !knownRepo(repo) {
+			return fmt.Errorf("github.priority: %q is not in github.repos or a region's repos", repo)
+		}
+	}
+	switch c.GitHub.ConflictPolicy {
+	case "keep_first", "prefer_higher_resolution", "prefer_authoritative":
+	default:
+		return fmt.Errorf("github.conflict_policy must be keep_first, prefer_higher_resolution or prefer_authoritative, got %q", c.GitHub.ConflictPolicy)
+	}
+
+	switch c.LLM.MinConfidence {
+	case "low", "medium", "high":
+	default:
+		return fmt.Errorf("llm.min_confidence must be low, medium or high, got %q", c.LLM.MinConfidence)
+	}
+
+	switch c.LLM.LowConfidenceAction {
+	case "review", "reject":
+	default:
+		return fmt.Errorf("llm.low_confidence_action must be review or reject, got %q", c.LLM.LowConfidenceAction)
+	}
+
+	if c.LLM.NotFoundRecheck < 0 {
+		return fmt.Errorf("llm.not_found_recheck must not be negative, got %s", c.LLM.NotFoundRecheck)
+	}
+	if c.LLM.InvalidRecheck < 0 {
+		return fmt.Errorf("llm.invalid_recheck must not be negative, got %s", c.LLM.InvalidRecheck)
+	}
+	if c.LLM.BudgetRecheck < 0 {
+		return fmt.Errorf("llm.budget_recheck must not be negative, got %s", c.LLM.BudgetRecheck)
+	}
+
+	switch c.Instruments.Format {
+	case "sec":
+	case "csv":
+		if c.Instruments.URL == "" {
+			return fmt.Errorf("instruments.url is required when instruments.format is csv")
+		}
+	default:
+		return fmt.Errorf("instruments.format must be sec or csv, got %q", c.Instruments.Format)
+	}
+
+	if c.Index.URL != "" {
+		if u, err := url.Parse(c.Index.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("index.url must be an http(s) URL, got %q", c.Index.URL)
+		}
+		if c.Index.Format != "json" && c.Index.Format != "csv" {
+			return fmt.Errorf("index.format must be json or csv, got %q", c.Index.Format)
+		}
+		if c.Index.RefreshInterval < time.Minute {
+			return fmt.Errorf("index.refresh_interval must be at least 1m, got %s", c.Index.RefreshInterval)
+		}
+	}
+
+	for _, t := range []struct {
+		timeoutKey, retriesKey string
+		timeout                time.Duration
+		retries                int
+	}{
+		{"github.timeout", "github.retries", c.GitHub.Timeout, c.GitHub.Retries},
+		{"index.timeout", "index.retries", c.Index.Timeout, c.Index.Retries},
+		{"llm.download_timeout", "llm.download_retries", c.LLM.DownloadTimeout, c.LLM.DownloadRetries},
+	} {
+		if t.timeout < time.Second {
+			return fmt.Errorf("%s must be at least 1s, got %s", t.timeoutKey, t.timeout)
+		}
+		if t.retries < 0 || t.retries > 10 {
+			return fmt.Errorf("%s must be between 0 and 10, got %d", t.retriesKey, t.retries)
+		}
+	}
+	if c.Providers.CircuitFailures < 0 {
+		return fmt.Errorf("providers.circuit_failures must not be negative, got %d", c.Providers.CircuitFailures)
+	}
+	if c.Providers.CircuitFailures > 0 && c.Providers.CircuitCooldown < time.Second {
+		return fmt.Errorf("providers.circuit_cooldown must be at least 1s, got %s", c.Providers.CircuitCooldown)
+	}
+	if c.Providers.Strategy != "sequential" && c.Providers.Strategy != "race" {
+		return fmt.Errorf("providers.strategy must be sequential or race, got %q", c.Providers.Strategy)
+	}
+	if c.Providers.OutboundRPS < 0 {
+		return fmt.Errorf("providers.outbound_rps must not be negative, got %g", c.Providers.OutboundRPS)
+	}
+	if c.Providers.OutboundConcurrency < 0 {
+		return fmt.Errorf("providers.outbound_concurrency must not be negative, got %d", c.Providers.OutboundConcurrency)
+	}
+	if ua := c.Providers.UserAgent; strings.ContainsAny(ua, " /()") {
+		return fmt.Errorf("providers.user_agent must be a product name without spaces, slashes or parentheses, got %q", ua)
+	}
+	if u := c.Providers.ContactURL; u != "" {
+		if parsed, err := url.Parse(u); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("providers.contact_url must be an http(s) URL, got %q", u)
+		}
+	}
+	if e := c.Providers.ContactEmail; e != "" && (!strings.Contains(e, "@") || strings.ContainsAny(e, " ;()<>")) {
+		return fmt.Errorf("providers.contact_email must be an email address, got %q", e)
+	}
+	for i, a := range c.Providers.Auth {
+		if a.Host == "" || strings.ContainsAny(a.Host, "/:") {
+			return fmt.Errorf("providers.auth[%d].host must be a host name, got %q", i, a.Host)
+		}
+		if a.PathPrefix != "" && !strings.HasPrefix(a.PathPrefix, "/") {
+			return fmt.Errorf("providers.auth[%d].path_prefix must start with /, got %q", i, a.PathPrefix)
+		}
+		if a.Token != "" && a.Username != "" {
+			return fmt.Errorf("providers.auth[%d] has a token and a username: use one of them", i)
+		}
+		if a.Token == "" && a.Username == "" && len(a.Headers) == 0 {
+			return fmt.Errorf("providers.auth[%d] (%s) needs a token, a username or headers", i, a.Host)
+		}
+	}
+
+	if err := c.RateLimit.validate(); err != nil {
+		return err
+	}
+
+	if f := c.Serve.DefaultFormat; f != "png" && f != "webp" {
+		return fmt.Errorf("serve.default_format must be png or webp, got %q", f)
+	}
+	if c.Processing.MaxFetchSize < Megabyte || c.Processing.MaxFetchSize > 100*Megabyte {
+		return fmt.Errorf("processing.max_fetch_size must be between 1MB and 100MB, got %s", c.Processing.MaxFetchSize)
+	}
+
+	if p := c.Events.Publish; p.Driver != "" {
+		if p.Driver != "nats" && p.Driver != "kafka" {
+			return fmt.Errorf("events.publish.driver must be nats, kafka or empty, got %q", p.Driver)
+		}
+		if p.URL == "" || p.Subject == "" {
+			return fmt.Errorf("events.publish.url and events.publish.subject are required with driver %s", p.Driver)
+		}
+		if p.PollInterval < time.Second || p.Timeout < time.Second {
+			return fmt.Errorf("events.publish.poll_interval and timeout must be at least 1s")
+		}
+		if p.MaxAttempts < 1 {
+			return fmt.Errorf("events.publish.max_attempts must be at least 1, got %d", p.MaxAttempts)
+		}
+	}
+
+	if m := c.Moderation; m.Driver != "" {
+		if m.Driver != "hook" && m.Driver != "vision" {
+			return fmt.Errorf("moderation.driver must be hook, vision or empty, got %q", m.Driver)
+		}
+		if m.Driver == "hook" && m.URL == "" {
+			return fmt.Errorf("moderation.url is required with driver hook")
+		}
+		if m.OnError != "review" && m.OnError != "serve" {
+			return fmt.Errorf("moderation.on_error must be review or serve, got %q", m.OnError)
+		}
+		if m.Timeout < time.Second {
+			return fmt.Errorf("moderation.timeout must be at least 1s, got %s", m.Timeout)
+		}
+	}
+
+	if i := c.Stats.HistoryInterval; i != 0 && i < time.Minute {
+		return fmt.Errorf("stats.history_interval must be 0 or at least 1m, got %s", i)
+	}
+	if r := c.Stats.PopularitySampleRate; r < 0 || r > 1 {
+		return fmt.Errorf("stats.popularity_sample_rate must be between 0 and 1, got %g", r)
+	}
+	if c.Stats.PopularitySampleRate > 0 {
+		if i := c.Stats.PopularityFlushInterval; i < time.Second {
+			return fmt.Errorf("stats.popularity_flush_interval must be at least 1s, got %s", i)
+		}
+		if r := c.Stats.PopularityRetention; r < 24*time.Hour {
+			return fmt.Errorf("stats.popularity_retention must be at least 1d, got %s", r)
+		}
+	}
+	if err := c.Alerts.validate(); err != nil {
+		return err
+	}
+
+	for _, f := range []struct {
+		key   string
+		fault FaultConfig
+	}{{"chaos.providers", c.Chaos.Providers}, {"chaos.storage", c.Chaos.Storage}} {
+		if f.fault.ErrorRate < 0 || f.fault.ErrorRate > 1 || f.fault.LatencyRate < 0 || f.fault.LatencyRate > 1 {
+			return fmt.Errorf("%s.error_rate and latency_rate must be between 0 and 1", f.key)
+		}
+		if f.fault.Latency < 0 {
+			return fmt.Errorf("%s.latency can't be negative, got %s", f.key, f.fault.Latency)
+		}
+	}
+
+	return nil
+}
+
+// validate checks origin patterns and method names. A typo in a pattern
+// would otherwise silently block a frontend, and "*" with credentials is
+// something browsers refuse anyway.
+func (c CORSConfig) validate() error {
+	for _, o := range c.AllowedOrigins {
+		p, err := origin.Parse(o)
+		if err != nil {
+			return fmt.Errorf("cors.allowed_origins: %w", err)
+		}
+		if p.IsAny() && c.AllowCredentials {
+			return fmt.Errorf("cors.allow_credentials can't be combined with the \"*\" origin")
+		}
+	}
+
+	if err := validateMethods("cors.methods", c.Methods); err != nil {
+		return err
+	}
+	for i, r := range c.Routes {
+		if !strings.HasPrefix(r.PathPrefix, "/") {
+			return fmt.Errorf("cors.routes[%d].path_prefix must start with /, got %q", i, r.PathPrefix)
+		}
+		if len(r.Methods) == 0 {
+			return fmt.Errorf("cors.routes[%d].methods must not be empty", i)
+		}
+		if err := validateMethods(fmt.Sprintf("cors.routes[%d].methods", i), r.Methods); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validate checks the tiers. A key on two tiers would get whichever came
+// last, so it's refused rather than guessed.
+func (c RateLimitConfig) validate() error {
+	if c.RequestsPerSecond <= 0 || c.Burst < 1 {
+		return fmt.Errorf("rate_limit.requests_per_second and burst must be positive")
+	}
+	if c.UsageFlushInterval < time.Second {
+		return fmt.Errorf("rate_limit.usage_flush_interval must be at least 1s, got %s", c.UsageFlushInterval)
+	}
+	for key, r := range map[string]RouteLimitConfig{"misses": c.Misses, "imports": c.Imports} {
+		if r.RequestsPerSecond < 0 || (r.RequestsPerSecond > 0 && r.Burst < 1) {
+			return fmt.Errorf("rate_limit.%s: requests_per_second must not be negative, and burst must be positive with a rate", key)
+		}
+	}
+	if sc := c.Scanning; sc.MaxMisses < 0 || (sc.MaxMisses > 0 && (sc.Window <= 0 || sc.Cooldown <= 0)) {
+		return fmt.Errorf("rate_limit.scanning: max_misses must not be negative, and window and cooldown must be positive with it")
+	}
+	names := make(map[string]bool)
+	tierOf := make(map[string]string)
+	for i, t := range c.Tiers {
+		if t.Name == "" || names[t.Name] {
+			return fmt.Errorf("rate_limit.tiers[%d].name must be set and unique, got %q", i, t.Name)
+		}
+		names[t.Name] = true
+		if t.RequestsPerSecond <= 0 || t.Burst < 1 {
+			return fmt.Errorf("rate_limit.tiers[%d] (%s): requests_per_second and burst must be positive", i, t.Name)
+		}
+		if t.Credits < 0 {
+			return fmt.Errorf("rate_limit.tiers[%d] (%s): credits must not be negative, got %d", i, t.Name, t.Credits)
+		}
+		if t.MonthlyTransfer < 0 {
+			return fmt.Errorf("rate_limit.tiers[%d] (%s): monthly_transfer must not be negative, got %s", i, t.Name, t.MonthlyTransfer)
+		}
+		for _, key := range t.Keys {
+			if other, ok := tierOf[key]; ok {
+				return fmt.Errorf("rate_limit.tiers: a key is on both %s and %s", other, t.Name)
+			}
+			tierOf[key] = t.Name
+		}
+	}
+	return nil
+}
+
+// validate checks the rules can be evaluated and sent somewhere.
+func (a AlertsConfig) validate() error {
+	if len(a.Rules) == 0 {
+		return nil
+	}
+	if a.WebhookURL == "" {
+		return fmt.Errorf("alerts.webhook_url is required with alerts.rules")
+	}
+	if a.Interval < 10*time.Second {
+		return fmt.Errorf("alerts.interval must be at least 10s, got %s", a.Interval)
+	}
+	names := map[string]bool{}
+	for i, r := range a.Rules {
+		if r.Name == "" || names[r.Name] {
+			return fmt.Errorf("alerts.rules[%d].name must be set and unique, got %q", i, r.Name)
+		}
+		names[r.Name] = true
+		if !slices.Contains(alert.Metrics, alert.Metric(r.Metric)) {
+			return fmt.Errorf("alerts.rules[%d].metric must be one of %v, got %q", i, alert.Metrics, r.Metric)
+		}
+		if (r.Above == nil) == (r.Below == nil) {
+			return fmt.Errorf("alerts.rules[%d] must set one of above or below", i)
+		}
+	}
+	return nil
+}
+
+func validateMethods(key string, methods []string) error {
+	for _, m := range methods {
+		switch strings.ToUpper(m) {
+		case "GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS":
+		default:
+			return fmt.Errorf("%s: unknown HTTP method %q", key, m)
+		}
+	}
+	return nil
+}
+
+// renamedKeys are the keys that had their unit in the name before values
+// carried their own. A config that still sets one keeps working: its number
+// is read as the new key with that unit (delete_retention_days: 30 is
+// delete_retention: 30d), and Load reports it in Config.Deprecations.
+var renamedKeys = []struct{ old, new, unit string }{
+	{"server.pending_retry_after_seconds", "server.pending_retry_after", "s"},
+	{"storage.catalog_sync_seconds", "storage.catalog_sync_interval", "s"},
+	{"storage.delete_retention_days", "storage.delete_retention", "d"},
+	{"storage.purge_interval_minutes", "storage.purge_interval", "m"},
+	{"storage.disk.min_free_mb", "storage.disk.min_free", "MB"},
+	{"storage.disk.check_interval_seconds", "storage.disk.check_interval", "s"},
+	{"storage.variants.max_mb", "storage.variants.max_size", "MB"},
+	{"llm.not_found_recheck_hours", "llm.not_found_recheck", "h"},
+	{"llm.download_timeout_seconds", "llm.download_timeout", "s"},
+	{"llm.anthropic.timeout_seconds", "llm.anthropic.timeout", "s"},
+	{"github.timeout_seconds", "github.timeout", "s"},
+	{"index.refresh_minutes", "index.refresh_interval", "m"},
+	{"index.timeout_seconds", "index.timeout", "s"},
+	{"providers.circuit_cooldown_seconds", "providers.circuit_cooldown", "s"},
+	{"processing.max_fetch_mb", "processing.max_fetch_size", "MB"},
+	{"rate_limit.usage_flush_seconds", "rate_limit.usage_flush_interval", "s"},
+	{"events.publish.poll_interval_seconds", "events.publish.poll_interval", "s"},
+	{"events.publish.timeout_seconds", "events.publish.timeout", "s"},
+}
+
+// applyRenamedKeys moves values set under renamed keys (in the file or the
+// environment) to their new keys, and returns a deprecation message for
+// each. Setting both the old and the new key is an error: which one was
+// meant can't be told.
+func applyRenamedKeys(v *viper.Viper) ([]string, error) {
+	var deprecations []string
+	for _, k := range renamedKeys {
+		if !v.IsSet(k.old) {
+			continue
+		}
+		if v.InConfig(k.new) || os.Getenv(envKey(k.new)) != "" {
+			return nil, fmt.Errorf("%s replaces the deprecated %s: set only %s", k.new, k.old, k.new)
+		}
+		value := strings.TrimSpace(v.GetString(k.old)) + k.unit
+		v.Set(k.new, value)
+		deprecations = append(deprecations, fmt.Sprintf("%s is deprecated: use %s: %s", k.old, k.new, value))
+	}
+	return deprecations, nil
+}
+
+// envKey is the environment variable for a key: LOGO_ and the key in upper
+// case with dots as underscores, as AutomaticEnv looks it up.
+func envKey(key string) string {
+	return "LOGO_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
 // parseCommaSeparatedEnv checks if an env var is set and splits it into a string slice.
 // This fills a gap in Viper: env vars are strings, but config fields can be slices.
 // If the env var is set, it replaces whatever the YAML/default provided.
@@ -159,8 +1184,36 @@ func parseCommaSeparatedEnv(target *[]string, envKey string) {
 	}
 }
 
+// V1Retirement parses the /api/v1 deprecation and sunset dates. Zero times
+// mean not set.
+func (a APIConfig) V1Retirement() (deprecated, sunset time.Time, err error) {
+	if a.V1DeprecatedAt != "" {
+		if deprecated, err = time.Parse(time.DateOnly, a.V1DeprecatedAt); err != nil {
+			return deprecated, sunset, fmt.Errorf("api.v1_deprecated_at must be YYYY-MM-DD, got %q", a.V1DeprecatedAt)
+		}
+	}
+	if a.V1SunsetAt != "" {
+		if sunset, err = time.Parse(time.DateOnly, a.V1SunsetAt); err != nil {
+			return deprecated, sunset, fmt.Errorf("api.v1_sunset_at must be YYYY-MM-DD, got %q", a.V1SunsetAt)
+		}
+		if deprecated.IsZero() || !sunset.After(deprecated) {
+			return deprecated, sunset, fmt.Errorf("api.v1_sunset_at needs an earlier api.v1_deprecated_at")
+		}
+	}
+	return deprecated, sunset, nil
+}
+
 // Address returns the listen address string like "0.0.0.0:8080".
 // This is a method on ServerConfig — Go attaches methods to types via receiver syntax.
 func (s ServerConfig) Address() string {
 	return fmt.Sprintf("%s:%d", s.Host, s.Port)
 }
+
+// AdminAddress returns the listen address of the admin port.
+func (s ServerConfig) AdminAddress() string {
+	host := s.AdminHost
+	if host == "" {
+		host = s.Host
+	}
+	return fmt.Sprintf("%s:%d", host, s.AdminPort)
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/spf13/viper"
+
+	"github.com/fleveque/logo-service/internal/flags"
+)
+
+// readFlags reads the flags section: only the flags set there, from the
+// file or as LOGO_FLAGS_<NAME>, so the admin API can tell a configured value
+// from a default. An unknown name is refused, since a misspelled flag would
+// otherwise be silently ignored — and the behavior it meant to switch off
+// left on.
+//
+// Go note: the section is read by hand rather than unmarshaled, because
+// viper only sees env vars for keys it already knows, and registering a
+// default per flag would make every flag look configured.
+func readFlags(v *viper.Viper) (map[string]bool, error) {
+	var unknown []string
+	for name := range v.GetStringMap("flags") {
+		if _, ok := flags.Lookup(name); !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("flags.%s is not a known flag", unknown[0])
+	}
+
+	set := make(map[string]bool)
+	for _, f := range flags.All {
+		key := "flags." + string(f.Name)
+		if !v.IsSet(key) {
+			continue
+		}
+		enabled, err := strconv.ParseBool(v.GetString(key))
+		if err != nil {
+			return nil, fmt.Errorf("%s must be true or false, got %q", key, v.GetString(key))
+		}
+		set[string(f.Name)] = enabled
+	}
+	return set, nil
+}
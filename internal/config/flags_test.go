@@ -0,0 +1,48 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoad_Flags(t *testing.T) {
+	// Flags not set anywhere are left out, so their defaults apply.
+	cfg, err := Load(writeConfig(t, "server:\n  port: 9090\n"))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(cfg.Flags) != 0 {
+		t.Errorf("expected no configured flags, got %v", cfg.Flags)
+	}
+
+	path := writeConfig(t, `
+flags:
+  purge_deleted: false
+`)
+	t.Setenv("LOGO_FLAGS_ON_DEMAND_ACQUISITION", "false")
+
+	cfg, err = Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(cfg.Flags) != 2 || cfg.Flags["purge_deleted"] || cfg.Flags["on_demand_acquisition"] {
+		t.Errorf("unexpected flags: %v", cfg.Flags)
+	}
+}
+
+func TestLoad_FlagErrors(t *testing.T) {
+	tests := []struct {
+		name, yaml, wantErr string
+	}{
+		{"unknown", "flags:\n  on_demand_aquisition: false\n", "flags.on_demand_aquisition is not a known flag"},
+		{"not a bool", "flags:\n  purge_deleted: sometimes\n", `flags.purge_deleted must be true or false, got "sometimes"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeConfig(t, tt.yaml))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
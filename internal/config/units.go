@@ -0,0 +1,227 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-viper/mapstructure/v2"
+)
+
+// Durations, sizes and amounts of money are written with their unit —
+// "30s", "512MB", "$50" — and decoded into typed fields: time.Duration,
+// ByteSize and Money. A bare duration or size is refused (except 0), since
+// whether "30" meant seconds or minutes is exactly the mistake units
+// prevent; a bare amount is in dollars, the only currency.
+
+// ByteSize is a size in bytes, written like "512MB". KB, MB, GB and TB are
+// powers of 1024 (so are KiB, MiB, GiB and TiB); B is bytes.
+type ByteSize int64
+
+// Sizes, for defaults and comparisons.
+const (
+	Byte     ByteSize = 1
+	Kilobyte          = 1024 * Byte
+	Megabyte          = 1024 * Kilobyte
+	Gigabyte          = 1024 * Megabyte
+	Terabyte          = 1024 * Gigabyte
+)
+
+var byteUnits = map[string]ByteSize{
+	"B":  Byte,
+	"KB": Kilobyte, "KIB": Kilobyte,
+	"MB": Megabyte, "MIB": Megabyte,
+	"GB": Gigabyte, "GIB": Gigabyte,
+	"TB": Terabyte, "TIB": Terabyte,
+}
+
+// ParseByteSize parses a size like "512MB", "1.5GB" or "64 KiB". Units are
+// case-insensitive.
+func ParseByteSize(s string) (ByteSize, error) {
+	s = strings.TrimSpace(s)
+	if s == "0" {
+		return 0, nil
+	}
+	// Go note: the number is everything before the first letter.
+	i := strings.IndexFunc(s, func(r rune) bool { return r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' })
+	if i < 0 {
+		return 0, fmt.Errorf("%q needs a unit, e.g. \"512MB\"", s)
+	}
+	unit, ok := byteUnits[strings.ToUpper(s[i:])]
+	if !ok {
+		return 0, fmt.Errorf("%q has an unknown unit, want B, KB, MB, GB or TB", s)
+	}
+	n, err := strconv.ParseFloat(strings.TrimSpace(s[:i]), 64)
+	if err != nil || n < 0 || math.IsInf(n, 0) {
+		return 0, fmt.Errorf("%q is not a size, e.g. \"512MB\"", s)
+	}
+	bytes := n * float64(unit)
+	if bytes > math.MaxInt64 {
+		return 0, fmt.Errorf("%q is too large", s)
+	}
+	return ByteSize(bytes), nil
+}
+
+// String renders the size in the largest unit that divides it, so it
+// reads back as the same value: "512MB", "1536KB".
+func (b ByteSize) String() string {
+	for _, u := range []struct {
+		name string
+		size ByteSize
+	}{{"TB", Terabyte}, {"GB", Gigabyte}, {"MB", Megabyte}, {"KB", Kilobyte}} {
+		if b != 0 && b%u.size == 0 {
+			return strconv.FormatInt(int64(b/u.size), 10) + u.name
+		}
+	}
+	return strconv.FormatInt(int64(b), 10) + "B"
+}
+
+// Money is an amount in US dollars, held in cents so sums don't drift.
+// It's written like "$50", "$0.25" or "50 USD".
+type Money int64
+
+// Dollar is one dollar.
+const Dollar Money = 100
+
+// ParseMoney parses an amount like "$50", "$12.5", "0.25" or "50 USD".
+// More than two decimals are refused rather than rounded.
+func ParseMoney(s string) (Money, error) {
+	amount := strings.TrimSpace(s)
+	amount = strings.TrimPrefix(amount, "$")
+	amount = strings.TrimSpace(strings.TrimSuffix(strings.TrimSuffix(amount, "USD"), "usd"))
+
+	whole, frac, _ := strings.Cut(amount, ".")
+	if len(frac) > 2 {
+		return 0, fmt.Errorf("%q has fractions of a cent", s)
+	}
+	dollars, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || dollars < 0 || dollars > math.MaxInt64/100-1 || strings.HasPrefix(whole, "+") {
+		return 0, fmt.Errorf("%q is not an amount, e.g. \"$50\"", s)
+	}
+	var cents int64
+	if frac != "" {
+		if cents, err = strconv.ParseInt(frac, 10, 64); err != nil || strings.HasPrefix(frac, "+") || strings.HasPrefix(frac, "-") {
+			return 0, fmt.Errorf("%q is not an amount, e.g. \"$50\"", s)
+		}
+		if len(frac) == 1 {
+			cents *= 10
+		}
+	}
+	return Money(dollars*100 + cents), nil
+}
+
+// String renders the amount like "$50" or "$0.25".
+func (m Money) String() string {
+	if m%100 == 0 {
+		return fmt.Sprintf("$%d", m/100)
+	}
+	return fmt.Sprintf("$%d.%02d", m/100, m%100)
+}
+
+// ParseDuration parses a duration like "30s", "1h30m" or "500ms" (see
+// time.ParseDuration), and also whole days, "7d", which retention and
+// recheck periods are usually thought of in.
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "0" {
+		return 0, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 || n > math.MaxInt64/int(24*time.Hour) {
+			return 0, fmt.Errorf("%q is not a number of days, e.g. \"7d\"", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		if _, numErr := strconv.ParseFloat(s, 64); numErr == nil {
+			return 0, fmt.Errorf("%q needs a unit, e.g. \"30s\"", s)
+		}
+		return 0, fmt.Errorf("%q is not a duration, e.g. \"30s\", \"5m\" or \"7d\"", s)
+	}
+	return d, nil
+}
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	byteSizeType = reflect.TypeOf(ByteSize(0))
+	moneyType    = reflect.TypeOf(Money(0))
+)
+
+// unitsHook decodes durations, sizes and amounts from what YAML or the
+// environment hold. Viper wraps its errors with the key being decoded, so
+// a bad value is reported as the config key it was set under.
+//
+// Go note: a decode hook sees every value on its way into the struct,
+// with the source type (f) and the field's type (t); it returns the value
+// to decode instead, or data unchanged for types it doesn't handle.
+func unitsHook(f, t reflect.Type, data any) (any, error) {
+	if t != durationType && t != byteSizeType && t != moneyType {
+		return data, nil
+	}
+	// Defaults set in code are already typed.
+	if f == t {
+		return data, nil
+	}
+
+	var s string
+	switch v := data.(type) {
+	case string:
+		s = v
+	case int, int64, uint, uint64:
+		s = fmt.Sprint(v)
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return nil, fmt.Errorf("unsupported value %v", data)
+	}
+
+	switch t {
+	case durationType:
+		return ParseDuration(s)
+	case byteSizeType:
+		return ParseByteSize(s)
+	default:
+		// A bare YAML number is an amount in dollars: "budget: 50".
+		return ParseMoney(s)
+	}
+}
+
+// decodeHook is unitsHook plus the hook viper uses by default for
+// comma-separated lists, which setting a hook would otherwise drop.
+func decodeHook() mapstructure.DecodeHookFunc {
+	return mapstructure.ComposeDecodeHookFunc(
+		unitsHook,
+		mapstructure.StringToWeakSliceHookFunc(","),
+	)
+}
+
+// keyErrors rewrites a decoding error as one "key: problem" per bad value,
+// instead of mapstructure's multi-line report.
+func keyErrors(err error) error {
+	var errs []error
+	var walk func(error)
+	walk = func(err error) {
+		// Go note: a type switch on interfaces matches errors.Join's
+		// Unwrap() []error as well as the usual Unwrap() error.
+		switch e := err.(type) {
+		case *mapstructure.DecodeError:
+			errs = append(errs, fmt.Errorf("%s: %w", e.Name(), e.Unwrap()))
+		case interface{ Unwrap() []error }:
+			for _, inner := range e.Unwrap() {
+				walk(inner)
+			}
+		case interface{ Unwrap() error }:
+			walk(e.Unwrap())
+		default:
+			errs = append(errs, err)
+		}
+	}
+	walk(err)
+	return errors.Join(errs...)
+}
@@ -0,0 +1,198 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr string
+	}{
+		{"30s", 30 * time.Second, ""},
+		{"1h30m", 90 * time.Minute, ""},
+		{"500ms", 500 * time.Millisecond, ""},
+		{"7d", 7 * 24 * time.Hour, ""},
+		{" 0 ", 0, ""},
+		{"30", 0, "needs a unit"},
+		{"1.5d", 0, "number of days"},
+		{"soon", 0, "not a duration"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseDuration(tt.in)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("ParseDuration(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    ByteSize
+		wantErr string
+	}{
+		{"512MB", 512 * Megabyte, ""},
+		{"1.5GB", 1536 * Megabyte, ""},
+		{"64 KiB", 64 * Kilobyte, ""},
+		{"10mb", 10 * Megabyte, ""},
+		{"100B", 100, ""},
+		{"0", 0, ""},
+		{"512", 0, "needs a unit"},
+		{"5PB", 0, "unknown unit"},
+		{"-5MB", 0, "not a size"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseByteSize(tt.in)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("ParseByteSize(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+			}
+			// String reads back as the same size.
+			if again, err := ParseByteSize(got.String()); err != nil || again != got {
+				t.Errorf("%s doesn't read back: %v, %v", got, again, err)
+			}
+		})
+	}
+}
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Money
+		wantErr string
+	}{
+		{"$50", 50 * Dollar, ""},
+		{"$0.25", 25, ""},
+		{"12.5", 1250, ""},
+		{"50 USD", 50 * Dollar, ""},
+		{"$0.001", 0, "fractions of a cent"},
+		{"-$5", 0, "not an amount"},
+		{"$-5", 0, "not an amount"},
+		{"fifty", 0, "not an amount"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseMoney(tt.in)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("ParseMoney(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+			}
+		})
+	}
+	if s := Money(1250).String(); s != "$12.50" {
+		t.Errorf("String() = %q, want $12.50", s)
+	}
+}
+
+// writeConfig writes a config file for Load in a temp dir.
+func writeConfig(t *testing.T, yaml string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(yaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad_Units(t *testing.T) {
+	path := writeConfig(t, `
+github:
+  timeout: 45s
+storage:
+  variants:
+    max_size: 1GB
+`)
+	t.Setenv("LOGO_STORAGE_DELETE_RETENTION", "14d")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.GitHub.Timeout != 45*time.Second {
+		t.Errorf("github.timeout = %v, want 45s", cfg.GitHub.Timeout)
+	}
+	if cfg.Storage.Variants.MaxSize != Gigabyte {
+		t.Errorf("storage.variants.max_size = %v, want 1GB", cfg.Storage.Variants.MaxSize)
+	}
+	if cfg.Storage.DeleteRetention != 14*24*time.Hour {
+		t.Errorf("storage.delete_retention = %v, want 14d", cfg.Storage.DeleteRetention)
+	}
+	// Defaults go through the same parsing.
+	if cfg.Processing.MaxFetchSize != 10*Megabyte || cfg.LLM.NotFoundRecheck != 7*24*time.Hour {
+		t.Errorf("unexpected defaults: max_fetch_size=%v not_found_recheck=%v", cfg.Processing.MaxFetchSize, cfg.LLM.NotFoundRecheck)
+	}
+	if len(cfg.Deprecations) != 0 {
+		t.Errorf("expected no deprecations, got %v", cfg.Deprecations)
+	}
+}
+
+func TestLoad_ErrorsNameTheKey(t *testing.T) {
+	tests := []struct {
+		name, yaml, wantErr string
+	}{
+		{"bare number", "github:\n  timeout: 30\n", `github.timeout: "30" needs a unit`},
+		{"bad unit", "storage:\n  disk:\n    min_free: 5 parsecs\n", `storage.disk.min_free: "5 parsecs" has an unknown unit`},
+		{"out of range", "processing:\n  max_fetch_size: 1GB\n", "processing.max_fetch_size must be between 1MB and 100MB, got 1GB"},
+		{"old and new key", "github:\n  timeout: 30s\n  timeout_seconds: 30\n", "github.timeout replaces the deprecated github.timeout_seconds"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeConfig(t, tt.yaml))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestLoad_RenamedKeys(t *testing.T) {
+	path := writeConfig(t, `
+storage:
+  delete_retention_days: 10
+  disk:
+    min_free_mb: 200
+`)
+	t.Setenv("LOGO_GITHUB_TIMEOUT_SECONDS", "12")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.Storage.DeleteRetention != 10*24*time.Hour {
+		t.Errorf("delete_retention_days: 10 read as %v", cfg.Storage.DeleteRetention)
+	}
+	if cfg.Storage.Disk.MinFree != 200*Megabyte {
+		t.Errorf("min_free_mb: 200 read as %v", cfg.Storage.Disk.MinFree)
+	}
+	if cfg.GitHub.Timeout != 12*time.Second {
+		t.Errorf("LOGO_GITHUB_TIMEOUT_SECONDS=12 read as %v", cfg.GitHub.Timeout)
+	}
+	if len(cfg.Deprecations) != 3 {
+		t.Errorf("expected 3 deprecations, got %v", cfg.Deprecations)
+	}
+}
@@ -0,0 +1,215 @@
+// Package flags switches risky behaviors on and off without a redeploy.
+// Each flag has a default, which the config's flags section can change per
+// environment, and which an admin can override at runtime through the API
+// — to roll a behavior back the moment it misbehaves. Overrides are stored
+// in the database so they outlive restarts and reach every instance.
+package flags
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Name identifies a flag. It's the key under flags: in the config.
+type Name string
+
+// The known flags. A new risky behavior adds its own here (and to All),
+// off by default until it has proven itself.
+const (
+	// OnDemandAcquisition lets a request for an unknown symbol acquire its
+	// logo from the providers. Off, cache misses are answered 503 and only
+	// logos already in the catalog are served.
+	OnDemandAcquisition Name = "on_demand_acquisition"
+	// PurgeDeleted lets the purge job remove soft-deleted logos for good
+	// once their retention is over. Off, every delete stays restorable.
+	PurgeDeleted Name = "purge_deleted"
+	// MaintenanceMode serves cached logos only, e.g. during a storage
+	// migration: acquisitions and admin changes get 503, the purge job
+	// pauses, and /healthz says so.
+	MaintenanceMode Name = "maintenance_mode"
+	// StorageCutover serves logos from storage.migration, the store
+	// logo-cli migrate-storage copied them to, instead of storage.logo_dir.
+	// Flipping it back returns to the old store, which stops receiving
+	// writes meanwhile.
+	StorageCutover Name = "storage_cutover"
+	// Moderation screens new logos with moderation.driver before they're
+	// served. It does nothing without a driver; off, new logos are served
+	// unscreened, e.g. while the moderator flags everything it sees.
+	Moderation Name = "moderation"
+)
+
+// Flag describes a flag.
+type Flag struct {
+	Name        Name
+	Description string
+	Default     bool
+}
+
+// All lists the known flags.
+var All = []Flag{
+	{OnDemandAcquisition, "Acquire logos for unknown symbols on request", true},
+	{PurgeDeleted, "Purge soft-deleted logos after their retention", true},
+	{MaintenanceMode, "Serve cached logos only; refuse acquisitions and changes", false},
+	{StorageCutover, "Serve logos from storage.migration instead of storage.logo_dir", false},
+	{Moderation, "Screen new logos with moderation.driver before serving them", true},
+}
+
+// ErrUnknownFlag is returned for a name that isn't in All.
+var ErrUnknownFlag = errors.New("unknown flag")
+
+// Lookup returns the flag with the given name.
+func Lookup(name string) (Flag, bool) {
+	for _, f := range All {
+		if string(f.Name) == name {
+			return f, true
+		}
+	}
+	return Flag{}, false
+}
+
+// Default returns a flag's default, false for an unknown one.
+func Default(name Name) bool {
+	f, _ := Lookup(string(name))
+	return f.Default
+}
+
+// Store persists overrides. storage.FlagRepository satisfies it.
+type Store interface {
+	List(ctx context.Context) (map[string]bool, error)
+	Set(ctx context.Context, name string, enabled bool) error
+	Delete(ctx context.Context, name string) error
+}
+
+// Set holds the state of every flag: its override if an admin set one,
+// else its configured value, else its default. It's safe for concurrent use.
+type Set struct {
+	store Store
+
+	mu         sync.RWMutex
+	configured map[Name]bool
+	overrides  map[Name]bool
+}
+
+// New creates a Set from the config's flags section. It refuses unknown
+// names, which are most likely typos that would otherwise be ignored. A
+// nil store keeps overrides in memory only.
+func New(configured map[string]bool, store Store) (*Set, error) {
+	s := &Set{store: store, configured: make(map[Name]bool), overrides: make(map[Name]bool)}
+	for name, enabled := range configured {
+		if _, ok := Lookup(name); !ok {
+			return nil, fmt.Errorf("%w %q", ErrUnknownFlag, name)
+		}
+		s.configured[Name(name)] = enabled
+	}
+	return s, nil
+}
+
+// Enabled reports whether a flag is on. A nil Set answers the defaults,
+// so code that wasn't given one behaves as before flags existed.
+func (s *Set) Enabled(name Name) bool {
+	if s == nil {
+		return Default(name)
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if enabled, ok := s.overrides[name]; ok {
+		return enabled
+	}
+	if enabled, ok := s.configured[name]; ok {
+		return enabled
+	}
+	return Default(name)
+}
+
+// Override turns a flag on or off regardless of the config, until
+// ClearOverride.
+func (s *Set) Override(ctx context.Context, name string, enabled bool) error {
+	if _, ok := Lookup(name); !ok {
+		return fmt.Errorf("%w %q", ErrUnknownFlag, name)
+	}
+	if s.store != nil {
+		if err := s.store.Set(ctx, name, enabled); err != nil {
+			return err
+		}
+	}
+	s.mu.Lock()
+	s.overrides[Name(name)] = enabled
+	s.mu.Unlock()
+	return nil
+}
+
+// ClearOverride drops a flag's override, so its configured value applies
+// again. The store's error is returned as is, e.g. when there was none.
+func (s *Set) ClearOverride(ctx context.Context, name string) error {
+	if _, ok := Lookup(name); !ok {
+		return fmt.Errorf("%w %q", ErrUnknownFlag, name)
+	}
+	if s.store != nil {
+		if err := s.store.Delete(ctx, name); err != nil {
+			return err
+		}
+	}
+	s.mu.Lock()
+	delete(s.overrides, Name(name))
+	s.mu.Unlock()
+	return nil
+}
+
+// Refresh reloads the overrides from the store, picking up those another
+// instance set. Stored names no flag has any more are ignored.
+func (s *Set) Refresh(ctx context.Context) error {
+	if s.store == nil {
+		return nil
+	}
+	stored, err := s.store.List(ctx)
+	if err != nil {
+		return err
+	}
+	overrides := make(map[Name]bool, len(stored))
+	for name, enabled := range stored {
+		if _, ok := Lookup(name); ok {
+			overrides[Name(name)] = enabled
+		}
+	}
+	s.mu.Lock()
+	s.overrides = overrides
+	s.mu.Unlock()
+	return nil
+}
+
+// State is a flag as the admin API shows it: where its value comes from
+// as well as the value.
+type State struct {
+	Name        Name   `json:"name"`
+	Description string `json:"description"`
+	Default     bool   `json:"default"`
+	Configured  *bool  `json:"configured,omitempty"` // Set in the config
+	Override    *bool  `json:"override,omitempty"`   // Set through the admin API
+	Enabled     bool   `json:"enabled"`
+}
+
+// States returns every known flag's state, sorted by name.
+func (s *Set) States() []State {
+	states := make([]State, 0, len(All))
+	for _, f := range All {
+		st := State{Name: f.Name, Description: f.Description, Default: f.Default, Enabled: s.Enabled(f.Name)}
+		if s != nil {
+			s.mu.RLock()
+			// Go note: copying into a fresh variable before taking its
+			// address gives each State its own bool to point to.
+			if v, ok := s.configured[f.Name]; ok {
+				st.Configured = &v
+			}
+			if v, ok := s.overrides[f.Name]; ok {
+				st.Override = &v
+			}
+			s.mu.RUnlock()
+		}
+		states = append(states, st)
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Name < states[j].Name })
+	return states
+}
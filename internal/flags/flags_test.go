@@ -0,0 +1,111 @@
+package flags
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// memStore is a Store in a map, standing in for the database.
+type memStore map[string]bool
+
+func (m memStore) List(context.Context) (map[string]bool, error) {
+	out := make(map[string]bool, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out, nil
+}
+
+func (m memStore) Set(_ context.Context, name string, enabled bool) error {
+	m[name] = enabled
+	return nil
+}
+
+var errNoOverride = errors.New("no override")
+
+func (m memStore) Delete(_ context.Context, name string) error {
+	if _, ok := m[name]; !ok {
+		return errNoOverride
+	}
+	delete(m, name)
+	return nil
+}
+
+func TestSet_Precedence(t *testing.T) {
+	ctx := context.Background()
+	store := memStore{}
+	s, err := New(map[string]bool{string(PurgeDeleted): false}, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Default, then config.
+	if !s.Enabled(OnDemandAcquisition) {
+		t.Error("on_demand_acquisition should be on by default")
+	}
+	if s.Enabled(PurgeDeleted) {
+		t.Error("purge_deleted should be off as configured")
+	}
+
+	// An override beats the config, and is stored.
+	if err := s.Override(ctx, string(PurgeDeleted), true); err != nil {
+		t.Fatal(err)
+	}
+	if !s.Enabled(PurgeDeleted) || !store[string(PurgeDeleted)] {
+		t.Errorf("override not applied: enabled=%v store=%v", s.Enabled(PurgeDeleted), store)
+	}
+
+	// Cleared, the config applies again; clearing twice is the store's error.
+	if err := s.ClearOverride(ctx, string(PurgeDeleted)); err != nil {
+		t.Fatal(err)
+	}
+	if s.Enabled(PurgeDeleted) {
+		t.Error("purge_deleted should be back to its configured value")
+	}
+	if err := s.ClearOverride(ctx, string(PurgeDeleted)); !errors.Is(err, errNoOverride) {
+		t.Errorf("expected the store's error, got %v", err)
+	}
+}
+
+func TestSet_Refresh(t *testing.T) {
+	// Another instance turned acquisition off; a stale name is ignored.
+	store := memStore{string(OnDemandAcquisition): false, "retired_flag": true}
+	s, err := New(nil, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.Enabled(OnDemandAcquisition) {
+		t.Fatal("overrides shouldn't apply before Refresh")
+	}
+	if err := s.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if s.Enabled(OnDemandAcquisition) {
+		t.Error("expected the stored override after Refresh")
+	}
+
+	states := s.States()
+	if len(states) != len(All) {
+		t.Fatalf("expected %d states, got %+v", len(All), states)
+	}
+	for _, st := range states {
+		if st.Name == OnDemandAcquisition && (st.Override == nil || *st.Override || st.Configured != nil || st.Enabled) {
+			t.Errorf("unexpected state: %+v", st)
+		}
+	}
+}
+
+func TestUnknownFlags(t *testing.T) {
+	if _, err := New(map[string]bool{"dark_mode": true}, nil); !errors.Is(err, ErrUnknownFlag) {
+		t.Errorf("New: expected ErrUnknownFlag, got %v", err)
+	}
+	var s *Set
+	if !s.Enabled(OnDemandAcquisition) || s.Enabled("dark_mode") {
+		t.Error("a nil Set should answer the defaults, false for unknown flags")
+	}
+	s, _ = New(nil, nil)
+	if err := s.Override(context.Background(), "dark_mode", true); !errors.Is(err, ErrUnknownFlag) {
+		t.Errorf("Override: expected ErrUnknownFlag, got %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+// Package flight coalesces identical reads: while one call for a key is
+// running, or for a short window after it succeeded, other calls for that
+// key get its result instead of running their own. At market open, a
+// hundred dashboards refreshing the same symbols at once then cost one
+// database query per symbol rather than a hundred.
+//
+// It's golang.org/x/sync/singleflight plus the window, small enough to
+// keep here rather than add a dependency.
+package flight
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/fleveque/logo-service/internal/clock"
+)
+
+// Group coalesces the calls of one kind of read, e.g. logo lookups by
+// symbol. The zero value isn't usable; use NewGroup. Safe for concurrent use.
+type Group[T any] struct {
+	window time.Duration // How long a success is reused; 0: only while it runs
+	clock  clock.Clock
+
+	mu        sync.Mutex
+	calls     map[string]*call[T]
+	lastSweep time.Time
+}
+
+// call is one read, shared by every caller of its key.
+type call[T any] struct {
+	done  chan struct{} // Closed when val and err are set
+	val   T
+	err   error
+	until time.Time // Reused until then, if err is nil
+}
+
+// NewGroup creates a Group reusing successful results for window.
+func NewGroup[T any](window time.Duration) *Group[T] {
+	return &Group[T]{window: window, clock: clock.System, calls: make(map[string]*call[T])}
+}
+
+// SetClock replaces the clock, for tests.
+func (g *Group[T]) SetClock(c clock.Clock) {
+	g.clock = c
+}
+
+// Do returns the result of fn for key, running it only if no call for key
+// is running or was reused recently. Every caller gets the same value, so
+// one that changes it must copy it first. Errors are shared with the
+// callers waiting on the call, never kept afterwards.
+//
+// fn runs without ctx's cancellation: one caller giving up mustn't fail
+// the others waiting on its read. A caller whose ctx ends while waiting
+// gets ctx.Err().
+func (g *Group[T]) Do(ctx context.Context, key string, fn func(context.Context) (T, error)) (T, error) {
+	g.mu.Lock()
+	g.sweep()
+	c, ok := g.calls[key]
+	if ok && !g.reusable(c) {
+		delete(g.calls, key)
+		ok = false
+	}
+	if !ok {
+		c = &call[T]{done: make(chan struct{})}
+		g.calls[key] = c
+		g.mu.Unlock()
+		g.run(ctx, key, c, fn)
+		return c.val, c.err
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.val, c.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// reusable reports whether c is running, or succeeded within the window.
+// Called with g.mu held.
+func (g *Group[T]) reusable(c *call[T]) bool {
+	select {
+	case <-c.done:
+		return c.err == nil && g.clock.Now().Before(c.until)
+	default:
+		return true
+	}
+}
+
+// sweep drops the expired results of keys not asked for again, at most
+// once per window, so keys read once don't stay forever. Called with g.mu
+// held.
+func (g *Group[T]) sweep() {
+	now := g.clock.Now()
+	if now.Sub(g.lastSweep) < g.window {
+		return
+	}
+	g.lastSweep = now
+	for key, c := range g.calls {
+		if !g.reusable(c) {
+			delete(g.calls, key)
+		}
+	}
+}
+
+func (g *Group[T]) run(ctx context.Context, key string, c *call[T], fn func(context.Context) (T, error)) {
+	c.val, c.err = fn(context.WithoutCancel(ctx))
+	c.until = g.clock.Now().Add(g.window)
+
+	g.mu.Lock()
+	// Forget may already have replaced it; only drop our own call.
+	if (c.err != nil || g.window <= 0) && g.calls[key] == c {
+		delete(g.calls, key)
+	}
+	g.mu.Unlock()
+	close(c.done)
+}
+
+// Forget drops what's known about key: the next Do runs a new call, even
+// if one is still running. Writers call it after changing what key reads.
+func (g *Group[T]) Forget(key string) {
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+}
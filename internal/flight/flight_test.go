@@ -0,0 +1,100 @@
+package flight
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fleveque/logo-service/internal/clock"
+)
+
+func TestGroup_CoalescesConcurrentCalls(t *testing.T) {
+	g := NewGroup[int](0)
+	var runs atomic.Int32
+	release := make(chan struct{})
+	fn := func(context.Context) (int, error) {
+		runs.Add(1)
+		<-release
+		return 42, nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]int, 10)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], _ = g.Do(context.Background(), "AAPL", fn)
+		}()
+	}
+	// Let every caller find the running call before it finishes.
+	for !g.waiting("AAPL") {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := runs.Load(); n != 1 {
+		t.Errorf("expected one run for 10 concurrent calls, got %d", n)
+	}
+	for i, v := range results {
+		if v != 42 {
+			t.Errorf("caller %d got %d", i, v)
+		}
+	}
+}
+
+func TestGroup_Window(t *testing.T) {
+	g := NewGroup[int](time.Second)
+	fake := clock.NewFake(time.Date(2025, 1, 1, 9, 30, 0, 0, time.UTC))
+	g.SetClock(fake)
+	ctx := context.Background()
+
+	runs := 0
+	fn := func(context.Context) (int, error) {
+		runs++
+		return runs, nil
+	}
+	for _, tt := range []struct {
+		name    string
+		advance time.Duration
+		forget  bool
+		want    int
+	}{
+		{"first", 0, false, 1},
+		{"within the window", 500 * time.Millisecond, false, 1},
+		{"after the window", time.Second, false, 2},
+		{"after Forget", 0, true, 3},
+	} {
+		fake.Advance(tt.advance)
+		if tt.forget {
+			g.Forget("AAPL")
+		}
+		if got, err := g.Do(ctx, "AAPL", fn); err != nil || got != tt.want {
+			t.Errorf("%s: got %d (%v), want %d", tt.name, got, err, tt.want)
+		}
+	}
+
+	// Errors aren't kept.
+	failing := func(context.Context) (int, error) { runs++; return 0, errors.New("boom") }
+	for range 2 {
+		if _, err := g.Do(ctx, "MSFT", failing); err == nil {
+			t.Fatal("expected the error")
+		}
+	}
+	if runs != 5 {
+		t.Errorf("expected a failed call to run again, got %d runs", runs)
+	}
+}
+
+// waiting reports whether a call for key is running.
+func (g *Group[T]) waiting(key string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	_, ok := g.calls[key]
+	return ok
+}
@@ -2,11 +2,18 @@ package handler
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"go.uber.org/zap"
 
+	"github.com/fleveque/logo-service/internal/imagefmt"
 	"github.com/fleveque/logo-service/internal/model"
 	"github.com/fleveque/logo-service/internal/provider"
 	"github.com/fleveque/logo-service/internal/service"
@@ -15,79 +22,141 @@ import (
 
 // AdminHandler handles administrative endpoints.
 type AdminHandler struct {
-	logoRepo    storage.LogoRepository
-	llmCallRepo storage.LLMCallRepository
-	ghProvider  *provider.GitHubProvider
-	logoService *service.LogoService
-	logger      *zap.Logger
+	logoRepo      storage.LogoRepository
+	llmCallRepo   storage.LLMCallRepository
+	requestedRepo storage.RequestedSymbolRepository
+	usageRepo     storage.UsageRepository // nil: stats without API usage
+	ghProvider    *provider.GitHubProvider
+	instImporter  *provider.InstrumentImporter // nil if instruments are misconfigured
+	index         *provider.IndexProvider      // nil if no index is configured
+	logoService   *service.LogoService
+	maxBytes      int // Largest candidate Compare accepts
+	logger        *zap.Logger
 }
 
 // NewAdminHandler creates a new AdminHandler.
 func NewAdminHandler(
 	logoRepo storage.LogoRepository,
 	llmCallRepo storage.LLMCallRepository,
+	requestedRepo storage.RequestedSymbolRepository,
+	usageRepo storage.UsageRepository,
 	ghProvider *provider.GitHubProvider,
+	instImporter *provider.InstrumentImporter,
+	index *provider.IndexProvider,
 	logoService *service.LogoService,
 	logger *zap.Logger,
 ) *AdminHandler {
 	return &AdminHandler{
-		logoRepo:    logoRepo,
-		llmCallRepo: llmCallRepo,
-		ghProvider:  ghProvider,
-		logoService: logoService,
-		logger:      logger,
+		logoRepo:      logoRepo,
+		llmCallRepo:   llmCallRepo,
+		requestedRepo: requestedRepo,
+		usageRepo:     usageRepo,
+		ghProvider:    ghProvider,
+		instImporter:  instImporter,
+		index:         index,
+		logoService:   logoService,
+		maxBytes:      imagefmt.MaxBytes,
+		logger:        logger,
 	}
 }
 
-// Stats returns logo counts and service statistics.
-// Route: GET /api/v1/admin/stats
+// SetMaxImageBytes replaces imagefmt.MaxBytes as the largest candidate
+// Compare accepts, to match processing.max_fetch_size.
+func (h *AdminHandler) SetMaxImageBytes(n int) {
+	h.maxBytes = n
+}
+
+// Stats returns logo counts by status, and each API key's requests, 429s
+// and burst credits used over the last usage_days days (today included),
+// keys rejected most first: those are the clients that need a higher tier.
+// Each key also gets the LLM searches its misses caused and their
+// estimated cost, for chargeback.
+// For capacity planning it adds the LLM calls per provider and day over the
+// same days, and the requests, bytes and quota left of each host the
+// providers download from.
+// Route: GET /api/v1/admin/stats?usage_days=7
 func (h *AdminHandler) Stats(c *gin.Context) {
-	ctx := c.Request.Context()
+	var req struct {
+		UsageDays int `form:"usage_days,default=7" binding:"min=1,max=90"`
+	}
+	if !bindQuery(c, &req) {
+		return
+	}
 
-	total, err := h.logoRepo.Count(ctx)
+	stats, err := h.logoService.Stats(c.Request.Context())
 	if err != nil {
 		h.logger.Error("counting logos", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
 		return
 	}
-
-	processed, err := h.logoRepo.CountByStatus(ctx, model.StatusProcessed)
-	if err != nil {
-		h.logger.Error("counting processed logos", zap.Error(err))
+	since := time.Now().UTC().AddDate(0, 0, 1-req.UsageDays).Format(time.DateOnly)
+	if h.usageRepo != nil {
+		if stats.APIUsage, err = h.usageRepo.Summary(c.Request.Context(), since); err != nil {
+			h.logger.Error("summarizing API usage", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+			return
+		}
+		for i := range stats.APIUsage {
+			stats.APIUsage[i].LLMSpendCents = h.logoService.LLMSpendCents(stats.APIUsage[i].LLMCalls)
+		}
+	}
+	if stats.LLMUsage, err = h.llmCallRepo.Usage(c.Request.Context(), since); err != nil {
+		h.logger.Error("summarizing LLM calls", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
 		return
 	}
+	stats.Outbound = provider.OutboundUsage()
+	c.JSON(http.StatusOK, stats)
+}
 
-	pending, err := h.logoRepo.CountByStatus(ctx, model.StatusPending)
-	if err != nil {
-		h.logger.Error("counting pending logos", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+// StatsHistory returns the daily stats snapshots of the last days days,
+// oldest first, for trend charts: counts by status and source, LLM calls
+// and estimated spend, requests and cache hit rate.
+// Route: GET /api/v1/admin/stats/history?days=90
+func (h *AdminHandler) StatsHistory(c *gin.Context) {
+	var req struct {
+		Days int `form:"days,default=90" binding:"min=1,max=366"`
+	}
+	if !bindQuery(c, &req) {
 		return
 	}
 
-	failed, err := h.logoRepo.CountByStatus(ctx, model.StatusFailed)
+	snapshots, err := h.logoService.StatsHistory(c.Request.Context(), req.Days)
 	if err != nil {
-		h.logger.Error("counting failed logos", zap.Error(err))
+		h.logger.Error("listing stats history", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
 		return
 	}
-
 	c.JSON(http.StatusOK, gin.H{
-		"total":     total,
-		"processed": processed,
-		"pending":   pending,
-		"failed":    failed,
+		"days":    req.Days,
+		"count":   len(snapshots),
+		"history": snapshots,
 	})
 }
 
 // Import triggers a bulk logo import in a background goroutine.
 // Returns 202 Accepted immediately — the import runs asynchronously.
 // Route: POST /api/v1/admin/import?source=all
+//
+// Sources: "github" (logos), "instruments" (symbol → company name reference data),
+// "index" (the curated index, replacing logos it has a different image for),
+// or "all" — instruments first, so GitHub-imported logos can pick up company names,
+// and the index last, so its corrections win.
 func (h *AdminHandler) Import(c *gin.Context) {
-	source := c.DefaultQuery("source", "all")
+	var req struct {
+		Source string `form:"source,default=all" binding:"oneof=all github instruments index"`
+	}
+	if !bindQuery(c, &req) {
+		return
+	}
+	source := req.Source
 
-	if source != "all" && source != "github" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid source: must be 'all' or 'github'"})
+	if source == "instruments" && h.instImporter == nil {
+		abortInvalid(c, FieldError{Field: "source", Message: "instruments import is not configured"})
+		return
+	}
+	if source == "index" && h.index == nil {
+		abortInvalid(c, FieldError{Field: "source", Message: "no index is configured (index.url)"})
 		return
 	}
 
@@ -95,27 +164,35 @@ func (h *AdminHandler) Import(c *gin.Context) {
 	// Use context.Background() — the HTTP request context gets cancelled
 	// when we send the 202 response, but the import should keep running.
 	go func() {
+		ctx := context.Background()
 		h.logger.Info("starting background import", zap.String("source", source))
 
-		// The callback delegates to LogoService.ProcessAndStore, which handles
-		// the full create-record → resize → mark-processed pipeline.
-		// This keeps the import logic DRY with the on-demand pipeline.
-		callback := func(result *provider.LogoResult) error {
-			return h.logoService.ProcessAndStore(context.Background(), result)
+		if (source == "all" || source == "instruments") && h.instImporter != nil {
+			if _, err := h.logoService.ImportInstruments(ctx, h.instImporter); err != nil {
+				h.logger.Error("instrument import failed", zap.Error(err))
+			}
 		}
 
-		stats, err := h.ghProvider.BulkImport(context.Background(), callback)
-		if err != nil {
-			h.logger.Error("import failed", zap.Error(err))
-			return
+		// The callbacks delegate to LogoService, which handles the full
+		// create-record → resize → mark-processed pipeline.
+		// This keeps the import logic DRY with the on-demand pipeline.
+		if source == "all" || source == "github" {
+			h.logImport("github", func() (*provider.ImportStats, error) {
+				return h.ghProvider.BulkImport(ctx, func(result *provider.LogoResult) error {
+					if result.Authoritative {
+						return h.logoService.ReplaceLogo(ctx, result)
+					}
+					return h.logoService.ProcessAndStore(ctx, result)
+				})
+			})
+		}
+		if (source == "all" || source == "index") && h.index != nil {
+			h.logImport("index", func() (*provider.ImportStats, error) {
+				return h.index.BulkImport(ctx, func(result *provider.LogoResult) error {
+					return h.logoService.ReplaceLogo(ctx, result)
+				})
+			})
 		}
-
-		h.logger.Info("import complete",
-			zap.Int("total", stats.Total),
-			zap.Int("imported", stats.Imported),
-			zap.Int("skipped", stats.Skipped),
-			zap.Int("failed", stats.Failed),
-		)
 	}()
 
 	c.JSON(http.StatusAccepted, gin.H{
@@ -124,3 +201,738 @@ func (h *AdminHandler) Import(c *gin.Context) {
 		"message": "import started in background",
 	})
 }
+
+// logImport runs one logo import and logs how it went.
+func (h *AdminHandler) logImport(source string, run func() (*provider.ImportStats, error)) {
+	stats, err := run()
+	if err != nil {
+		h.logger.Error("import failed", zap.String("source", source), zap.Error(err))
+		return
+	}
+
+	h.logger.Info("import complete",
+		zap.String("source", source),
+		zap.Int("total", stats.Total),
+		zap.Int("imported", stats.Imported),
+		zap.Int("skipped", stats.Skipped),
+		zap.Int("failed", stats.Failed),
+	)
+}
+
+// Missing returns symbols that were requested but couldn't be served,
+// sorted by how often they were asked for.
+// Route: GET /api/v1/admin/missing?limit=100
+func (h *AdminHandler) Missing(c *gin.Context) {
+	var req pageQuery
+	if !bindQuery(c, &req) {
+		return
+	}
+	limit := req.Limit
+
+	missing, err := h.requestedRepo.ListMissing(c.Request.Context(), limit)
+	if err != nil {
+		h.logger.Error("listing missing symbols", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":   len(missing),
+		"symbols": missing,
+	})
+}
+
+// ListNotFound returns symbols no provider had a logo for, with the time
+// each will be looked for again (next_check_at).
+// Most recently checked first; sort takes symbol, created_at or updated_at.
+// Route: GET /api/v1/admin/not-found?limit=100&cursor=...&sort=-updated_at
+func (h *AdminHandler) ListNotFound(c *gin.Context) {
+	var req cursorQuery
+	if !bindQuery(c, &req) {
+		return
+	}
+
+	logos, next, err := h.logoService.ListNotFound(c.Request.Context(), req.options())
+	if abortListError(c, err) {
+		return
+	}
+	if err != nil {
+		h.logger.Error("listing not found logos", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":       len(logos),
+		"logos":       logos,
+		"next_cursor": next,
+	})
+}
+
+// Requeue makes a not_found logo due for a check: the next request for it
+// asks the providers again instead of waiting for next_check_at.
+// Route: POST /api/v1/admin/not-found/:symbol/requeue
+func (h *AdminHandler) Requeue(c *gin.Context) {
+	symbol, ok := symbolParam(c)
+	if !ok {
+		return
+	}
+
+	err := h.logoService.Requeue(c.Request.Context(), symbol)
+	if errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "logo not found"})
+		return
+	}
+	if errors.Is(err, service.ErrWrongStatus) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.logger.Error("requeueing logo", zap.String("symbol", symbol), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	h.logger.Info("logo requeued", zap.String("symbol", symbol))
+	c.JSON(http.StatusOK, gin.H{"symbol": symbol, "status": "requeued"})
+}
+
+// ListReview returns logos held in the review queue (low-confidence LLM results).
+// Most recently updated first; sort takes symbol, created_at or updated_at.
+// Route: GET /api/v1/admin/review?limit=100&cursor=...&sort=-updated_at
+func (h *AdminHandler) ListReview(c *gin.Context) {
+	var req cursorQuery
+	if !bindQuery(c, &req) {
+		return
+	}
+
+	logos, next, err := h.logoService.ListReview(c.Request.Context(), req.options())
+	if abortListError(c, err) {
+		return
+	}
+	if err != nil {
+		h.logger.Error("listing review queue", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":       len(logos),
+		"logos":       logos,
+		"next_cursor": next,
+	})
+}
+
+// ListLogos returns a page of every logo, or of those in one status. By
+// symbol; sort also takes created_at and updated_at.
+// Route: GET /api/v1/admin/logos?status=failed&limit=100&cursor=...&sort=symbol
+func (h *AdminHandler) ListLogos(c *gin.Context) {
+	var req struct {
+		cursorQuery
+		Status string `form:"status" binding:"omitempty,oneof=pending processed failed not_found review"`
+	}
+	if !bindQuery(c, &req) {
+		return
+	}
+
+	logos, next, err := h.logoService.ListLogos(c.Request.Context(), model.LogoStatus(req.Status), req.options())
+	if abortListError(c, err) {
+		return
+	}
+	if err != nil {
+		h.logger.Error("listing logos", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":       len(logos),
+		"logos":       logos,
+		"next_cursor": next,
+	})
+}
+
+// ListLLMCalls returns a page of LLM provider calls, for one symbol or all,
+// newest first (sort=created_at for oldest first): what the LLM fallback
+// has been spending.
+// Route: GET /api/v1/admin/llm-calls?symbol=AAPL&limit=100&cursor=...
+func (h *AdminHandler) ListLLMCalls(c *gin.Context) {
+	var req struct {
+		cursorQuery
+		Symbol string `form:"symbol" binding:"omitempty,symbol"`
+	}
+	if !bindQuery(c, &req) {
+		return
+	}
+	// Validated above, so only normalizing is left.
+	symbol, _ := model.NormalizeSymbol(req.Symbol)
+
+	calls, next, err := h.llmCallRepo.List(c.Request.Context(), symbol, req.options())
+	if abortListError(c, err) {
+		return
+	}
+	if err != nil {
+		h.logger.Error("listing llm calls", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":       len(calls),
+		"calls":       calls,
+		"next_cursor": next,
+	})
+}
+
+// ListByQuality returns scored logos, worst first, so curators know which
+// ones to replace. max_score filters out anything better (default: all).
+// Route: GET /api/v1/admin/quality?max_score=50&limit=100
+func (h *AdminHandler) ListByQuality(c *gin.Context) {
+	var req struct {
+		pageQuery
+		MaxScore int `form:"max_score,default=100" binding:"min=0,max=100"`
+	}
+	if !bindQuery(c, &req) {
+		return
+	}
+
+	logos, err := h.logoService.ListByQuality(c.Request.Context(), req.MaxScore, req.Limit)
+	if err != nil {
+		h.logger.Error("listing logos by quality", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(logos),
+		"logos": logos,
+	})
+}
+
+// Duplicates reports groups of different companies' symbols whose logos
+// look identical or nearly so — a strong sign one of them is wrong.
+// max_distance is how many of the 64 perceptual-hash bits may differ.
+// Route: GET /api/v1/admin/duplicates?max_distance=4
+func (h *AdminHandler) Duplicates(c *gin.Context) {
+	var req struct {
+		MaxDistance int `form:"max_distance,default=4" binding:"min=0,max=16"`
+	}
+	if !bindQuery(c, &req) {
+		return
+	}
+
+	groups, err := h.logoService.FindDuplicates(c.Request.Context(), req.MaxDistance)
+	if err != nil {
+		h.logger.Error("finding duplicate logos", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":  len(groups),
+		"groups": groups,
+	})
+}
+
+// SendToReview pulls a served logo back into the review queue, where it can
+// be approved or rejected like a low-confidence result.
+// Route: POST /api/v1/admin/review/:symbol
+func (h *AdminHandler) SendToReview(c *gin.Context) {
+	symbol, ok := symbolParam(c)
+	if !ok {
+		return
+	}
+	h.reviewAction(c, symbol, "review", h.logoService.SendToReview)
+}
+
+// ApproveReview releases a logo from the review queue.
+// Route: POST /api/v1/admin/review/:symbol/approve
+func (h *AdminHandler) ApproveReview(c *gin.Context) {
+	symbol, ok := symbolParam(c)
+	if !ok {
+		return
+	}
+	h.reviewAction(c, symbol, "approved", h.logoService.ApproveReview)
+}
+
+// RejectReview discards a logo from the review queue and deletes its files.
+// Route: POST /api/v1/admin/review/:symbol/reject
+func (h *AdminHandler) RejectReview(c *gin.Context) {
+	symbol, ok := symbolParam(c)
+	if !ok {
+		return
+	}
+	h.reviewAction(c, symbol, "rejected", h.logoService.RejectReview)
+}
+
+// reviewAction runs a review function (send, approve, reject) and maps its errors to HTTP statuses.
+// Passing the method as a func value avoids duplicating the error handling.
+func (h *AdminHandler) reviewAction(c *gin.Context, symbol, result string, action func(ctx context.Context, symbol string) error) {
+	err := action(c.Request.Context(), symbol)
+	if errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "logo not found"})
+		return
+	}
+	if errors.Is(err, service.ErrWrongStatus) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.logger.Error("review decision", zap.String("symbol", symbol), zap.String("result", result), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	h.logger.Info("review decision", zap.String("symbol", symbol), zap.String("result", result))
+	c.JSON(http.StatusOK, gin.H{"symbol": symbol, "status": result})
+}
+
+// ListBlocked returns every symbol on the blocklist.
+// Route: GET /api/v1/admin/blocklist
+func (h *AdminHandler) ListBlocked(c *gin.Context) {
+	blocked, err := h.logoService.ListBlocked(c.Request.Context())
+	if err != nil {
+		h.logger.Error("listing blocklist", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":   len(blocked),
+		"symbols": blocked,
+	})
+}
+
+// Block puts a symbol on the blocklist: it's answered with 410 Gone and never
+// acquired or imported again. The body may carry a reason: {"reason": "delisted"}.
+// Route: PUT /api/v1/admin/blocklist/:symbol
+func (h *AdminHandler) Block(c *gin.Context) {
+	symbol, ok := symbolParam(c)
+	if !ok {
+		return
+	}
+
+	// An empty body is fine: the reason is optional.
+	var body struct {
+		Reason string `json:"reason"`
+	}
+	if !bindJSON(c, &body) {
+		return
+	}
+
+	if err := h.logoService.BlockSymbol(c.Request.Context(), symbol, body.Reason); err != nil {
+		h.logger.Error("blocking symbol", zap.String("symbol", symbol), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	h.logger.Info("symbol blocked", zap.String("symbol", symbol), zap.String("reason", body.Reason))
+	c.JSON(http.StatusOK, gin.H{"symbol": symbol, "status": "blocked"})
+}
+
+// Unblock removes a symbol from the blocklist; its logo is served again.
+// Route: DELETE /api/v1/admin/blocklist/:symbol
+func (h *AdminHandler) Unblock(c *gin.Context) {
+	symbol, ok := symbolParam(c)
+	if !ok {
+		return
+	}
+
+	err := h.logoService.UnblockSymbol(c.Request.Context(), symbol)
+	if errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "symbol is not blocked"})
+		return
+	}
+	if err != nil {
+		h.logger.Error("unblocking symbol", zap.String("symbol", symbol), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	h.logger.Info("symbol unblocked", zap.String("symbol", symbol))
+	c.JSON(http.StatusOK, gin.H{"symbol": symbol, "status": "unblocked"})
+}
+
+// Takedown blocks a symbol and deletes its files, recording the reason in the
+// audit log. The reason is required: {"reason": "trademark complaint #123"}.
+// Route: POST /api/v1/admin/takedown/:symbol
+func (h *AdminHandler) Takedown(c *gin.Context) {
+	symbol, ok := symbolParam(c)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Reason string `json:"reason" binding:"notblank"`
+	}
+	if !bindJSON(c, &body) {
+		return
+	}
+
+	if err := h.logoService.Takedown(c.Request.Context(), symbol, body.Reason); err != nil {
+		h.logger.Error("taking down logo", zap.String("symbol", symbol), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	h.logger.Info("logo taken down", zap.String("symbol", symbol), zap.String("reason", body.Reason))
+	c.JSON(http.StatusOK, gin.H{"symbol": symbol, "status": "taken_down"})
+}
+
+// SetProcessing overrides processing options for one logo. A null value goes
+// back to the configured default: {"whiten_background": true} or
+// {"whiten_background": null}. The change applies the next time the logo is
+// processed: right away with Reprocess, if its original was kept.
+// Route: PUT /api/v1/admin/logos/:symbol/processing
+func (h *AdminHandler) SetProcessing(c *gin.Context) {
+	symbol, ok := symbolParam(c)
+	if !ok {
+		return
+	}
+
+	// Go note: a *bool tells "null" (nil) apart from false, which a plain
+	// bool can't — encoding/json leaves the pointer nil for null.
+	var body struct {
+		WhitenBackground *bool `json:"whiten_background"`
+	}
+	if !requireJSON(c, &body) {
+		return
+	}
+
+	err := h.logoService.SetWhitenBackground(c.Request.Context(), symbol, body.WhitenBackground)
+	if errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "logo not found"})
+		return
+	}
+	if err != nil {
+		h.logger.Error("setting processing options", zap.String("symbol", symbol), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"symbol": symbol, "whiten_background": body.WhitenBackground})
+}
+
+// SetBackground sets the background a logo is served on when a request
+// has no bg parameter: {"default_bg": "ffffff"}. null or "" clears it, and
+// the logo is served as stored again.
+// Route: PUT /api/v1/admin/logos/:symbol/background
+func (h *AdminHandler) SetBackground(c *gin.Context) {
+	symbol, ok := symbolParam(c)
+	if !ok {
+		return
+	}
+
+	// null leaves the string empty, just like "".
+	var body struct {
+		DefaultBackground string `json:"default_bg" binding:"omitempty,rgbhex"`
+	}
+	if !requireJSON(c, &body) {
+		return
+	}
+
+	err := h.logoService.SetDefaultBackground(c.Request.Context(), symbol, body.DefaultBackground)
+	if errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "logo not found"})
+		return
+	}
+	if errors.Is(err, service.ErrInvalidColor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.logger.Error("setting default background", zap.String("symbol", symbol), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	color := strings.ToLower(strings.TrimPrefix(body.DefaultBackground, "#"))
+	h.logger.Info("default background set", zap.String("symbol", symbol), zap.String("default_bg", color))
+	c.JSON(http.StatusOK, gin.H{"symbol": symbol, "default_bg": color})
+}
+
+// maxMetadataPatch caps how many logos one bulk metadata update corrects.
+const maxMetadataPatch = 1000
+
+// metadataPatch is one item of a bulk metadata update: a symbol and the
+// fields to change (see model.MetadataUpdate).
+type metadataPatch struct {
+	Symbol string `json:"symbol" binding:"required,symbol"`
+	model.MetadataUpdate
+}
+
+// metadataResult is the outcome of one item of a bulk metadata update.
+type metadataResult struct {
+	Symbol string       `json:"symbol"`
+	Status string       `json:"status"` // updated, unchanged or failed
+	Error  string       `json:"error,omitempty"`
+	Fields []FieldError `json:"fields,omitempty"` // The item's invalid fields, if that's why it failed
+}
+
+// UpdateMetadata corrects the descriptive fields of many logos at once, e.g.
+// from a spreadsheet export:
+//
+//	[{"symbol": "AAPL", "company_name": "Apple Inc.", "website": "https://apple.com"},
+//	 {"symbol": "MSFT", "license": ""}]
+//
+// Fields left out are unchanged; an empty string clears one. Each item
+// succeeds or fails on its own, and the response has a result per item, in
+// order. Every change is recorded in the audit log.
+// Route: PATCH /api/v1/admin/logos
+func (h *AdminHandler) UpdateMetadata(c *gin.Context) {
+	// Decoded by hand rather than bound: gin would reject the whole array
+	// for one invalid item, and each should get its own result.
+	var items []metadataPatch
+	if err := json.NewDecoder(c.Request.Body).Decode(&items); err != nil {
+		abortInvalid(c, FieldError{Message: "malformed: " + err.Error()})
+		return
+	}
+	if len(items) == 0 || len(items) > maxMetadataPatch {
+		abortInvalid(c, FieldError{Message: fmt.Sprintf("must list between 1 and %d logos, got %d", maxMetadataPatch, len(items))})
+		return
+	}
+
+	counts := map[string]int{}
+	results := make([]metadataResult, len(items))
+	for i := range items {
+		results[i] = h.updateMetadata(c.Request.Context(), &items[i])
+		counts[results[i].Status]++
+	}
+
+	h.logger.Info("bulk metadata update",
+		zap.Int("updated", counts["updated"]),
+		zap.Int("unchanged", counts["unchanged"]),
+		zap.Int("failed", counts["failed"]),
+	)
+	c.JSON(http.StatusOK, gin.H{
+		"updated":   counts["updated"],
+		"unchanged": counts["unchanged"],
+		"failed":    counts["failed"],
+		"results":   results,
+	})
+}
+
+// updateMetadata applies one item of a bulk metadata update.
+func (h *AdminHandler) updateMetadata(ctx context.Context, item *metadataPatch) metadataResult {
+	result := metadataResult{Symbol: item.Symbol, Status: "failed"}
+	if err := binding.Validator.ValidateStruct(item); err != nil {
+		result.Error = "invalid item"
+		result.Fields = fieldErrors(err)
+		return result
+	}
+	// Validated above, so only normalizing is left.
+	symbol, _ := model.NormalizeSymbol(item.Symbol)
+	result.Symbol = symbol
+
+	changed, err := h.logoService.UpdateMetadata(ctx, symbol, item.MetadataUpdate)
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		result.Error = "logo not found"
+	case errors.Is(err, service.ErrInvalidMetadata):
+		result.Error = err.Error()
+	case err != nil:
+		h.logger.Error("updating metadata", zap.String("symbol", symbol), zap.Error(err))
+		result.Error = "internal error"
+	case changed:
+		result.Status = "updated"
+	default:
+		result.Status = "unchanged"
+	}
+	return result
+}
+
+// DeleteLogo soft-deletes a logo: it's answered with 410 Gone and no longer
+// listed or acquired, but it can be restored until the retention
+// (storage.delete_retention_days) is over and the purge job removes it.
+// The body may carry a reason for the audit log: {"reason": "wrong company"}.
+// Route: DELETE /api/v1/admin/logos/:symbol
+func (h *AdminHandler) DeleteLogo(c *gin.Context) {
+	symbol, ok := symbolParam(c)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Reason string `json:"reason"`
+	}
+	if !bindJSON(c, &body) {
+		return
+	}
+
+	err := h.logoService.DeleteLogo(c.Request.Context(), symbol, body.Reason)
+	if errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "logo not found"})
+		return
+	}
+	if err != nil {
+		h.logger.Error("deleting logo", zap.String("symbol", symbol), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	h.logger.Info("logo deleted", zap.String("symbol", symbol), zap.String("reason", body.Reason))
+	c.JSON(http.StatusOK, gin.H{"symbol": symbol, "status": "deleted"})
+}
+
+// RestoreLogo undoes DeleteLogo: the logo is served again as it was.
+// Route: POST /api/v1/admin/logos/:symbol/restore
+func (h *AdminHandler) RestoreLogo(c *gin.Context) {
+	symbol, ok := symbolParam(c)
+	if !ok {
+		return
+	}
+
+	err := h.logoService.RestoreLogo(c.Request.Context(), symbol)
+	if errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no deleted logo to restore"})
+		return
+	}
+	if err != nil {
+		h.logger.Error("restoring logo", zap.String("symbol", symbol), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	h.logger.Info("logo restored", zap.String("symbol", symbol))
+	c.JSON(http.StatusOK, gin.H{"symbol": symbol, "status": "restored"})
+}
+
+// Reprocess renders a logo's sizes again from its stored original, e.g.
+// after SetProcessing. No provider is asked.
+// Route: POST /api/v1/admin/logos/:symbol/reprocess
+func (h *AdminHandler) Reprocess(c *gin.Context) {
+	symbol, ok := symbolParam(c)
+	if !ok {
+		return
+	}
+
+	err := h.logoService.Reprocess(c.Request.Context(), symbol)
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "logo not found"})
+		return
+	case errors.Is(err, storage.ErrSymbolBlocked):
+		c.JSON(http.StatusGone, gin.H{"error": "logo is no longer available"})
+		return
+	case errors.Is(err, service.ErrNoOriginal):
+		c.JSON(http.StatusConflict, gin.H{"error": "no stored original for this logo; reject it to acquire it again"})
+		return
+	case err != nil:
+		h.logger.Error("reprocessing logo", zap.String("symbol", symbol), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	h.logger.Info("logo reprocessed", zap.String("symbol", symbol))
+	c.JSON(http.StatusOK, gin.H{"symbol": symbol, "status": "reprocessed"})
+}
+
+// FixStatus normalizes records whose status contradicts the rest of the
+// record — processed without sizes, pending for more than pending_days,
+// failed without an error — and reports each one. With dry_run=true it
+// only reports.
+// Route: POST /api/v1/admin/maintenance/fix-status?pending_days=7&dry_run=true
+func (h *AdminHandler) FixStatus(c *gin.Context) {
+	var req struct {
+		PendingDays int  `form:"pending_days,default=7" binding:"min=1"`
+		DryRun      bool `form:"dry_run"`
+	}
+	if !bindQuery(c, &req) {
+		return
+	}
+
+	report, err := h.logoService.FixStatus(c.Request.Context(), service.FixStatusOptions{
+		PendingAge: time.Duration(req.PendingDays) * 24 * time.Hour,
+		DryRun:     req.DryRun,
+	})
+	if err != nil {
+		h.logger.Error("fixing statuses", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	h.logger.Info("statuses fixed", zap.Int("checked", report.Checked), zap.Int("fixes", len(report.Fixes)), zap.Bool("dry_run", report.DryRun))
+	c.JSON(http.StatusOK, report)
+}
+
+// Originals lists the source images downloaded for a logo, newest first,
+// with where each came from.
+// Route: GET /api/v1/admin/logos/:symbol/originals
+func (h *AdminHandler) Originals(c *gin.Context) {
+	symbol, ok := symbolParam(c)
+	if !ok {
+		return
+	}
+
+	originals, err := h.logoService.ListOriginals(c.Request.Context(), symbol)
+	if err != nil {
+		h.logger.Error("listing originals", zap.String("symbol", symbol), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"symbol": symbol, "originals": originals})
+}
+
+// Trace shows which providers were asked for a symbol on its latest cache
+// misses, newest first: when, for how long, and what each answered. Attempts
+// of the same miss share a trace. The logo record, if any, says where things
+// stand now (e.g. not_found until next_check_at).
+// Route: GET /api/v1/admin/logos/:symbol/trace?limit=100
+func (h *AdminHandler) Trace(c *gin.Context) {
+	symbol, ok := symbolParam(c)
+	if !ok {
+		return
+	}
+	var req pageQuery
+	if !bindQuery(c, &req) {
+		return
+	}
+	ctx := c.Request.Context()
+
+	attempts, err := h.logoService.ListAttempts(ctx, symbol, req.Limit)
+	if err != nil {
+		h.logger.Error("listing acquisition attempts", zap.String("symbol", symbol), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+	logo, err := h.logoRepo.GetBySymbol(ctx, symbol)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		h.logger.Error("getting logo", zap.String("symbol", symbol), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"symbol":   symbol,
+		"logo":     logo, // null if never looked for
+		"count":    len(attempts),
+		"attempts": attempts,
+	})
+}
+
+// Audit returns recent admin actions (blocks, unblocks, takedowns, sends to review), newest first.
+// Route: GET /api/v1/admin/audit?symbol=AAPL&limit=100
+func (h *AdminHandler) Audit(c *gin.Context) {
+	var req struct {
+		pageQuery
+		Symbol string `form:"symbol" binding:"omitempty,symbol"`
+	}
+	if !bindQuery(c, &req) {
+		return
+	}
+	// Validated above, so only normalizing is left.
+	symbol, _ := model.NormalizeSymbol(req.Symbol)
+
+	entries, err := h.logoService.ListAudit(c.Request.Context(), symbol, req.Limit)
+	if err != nil {
+		h.logger.Error("listing audit log", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":   len(entries),
+		"entries": entries,
+	})
+}
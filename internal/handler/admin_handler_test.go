@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/fleveque/logo-service/internal/service"
+	"github.com/fleveque/logo-service/internal/storage"
+)
+
+func TestReviewAction(t *testing.T) {
+	h := &AdminHandler{logger: zap.NewNop()}
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"done", nil, http.StatusOK},
+		{"unknown symbol", storage.ErrNotFound, http.StatusNotFound},
+		{"wrong status", fmt.Errorf("%w: AAPL is processed, not in review", service.ErrWrongStatus), http.StatusConflict},
+		{"storage failure", errors.New("database is locked"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		router := gin.New()
+		router.POST("/review/:symbol/approve", func(c *gin.Context) {
+			h.reviewAction(c, "AAPL", "approved", func(context.Context, string) error { return tt.err })
+		})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/review/AAPL/approve", nil))
+		if w.Code != tt.want {
+			t.Errorf("%s: expected %d, got %d: %s", tt.name, tt.want, w.Code, w.Body)
+		}
+	}
+}
+
+func TestApproveReview_WrongStatus(t *testing.T) {
+	logos := newTestLogoHandler(t, map[string][]byte{"AAPL": []byte("apple png")})
+	h := &AdminHandler{logoService: logos.logoService, logger: zap.NewNop()}
+	router := gin.New()
+	router.POST("/review/:symbol/approve", h.ApproveReview)
+	router.POST("/not-found/:symbol/requeue", h.Requeue)
+
+	// AAPL is processed: neither in review nor not_found.
+	for _, target := range []string{"/review/AAPL/approve", "/not-found/AAPL/requeue"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, target, nil))
+		if w.Code != http.StatusConflict {
+			t.Errorf("%s: expected 409, got %d: %s", target, w.Code, w.Body)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/fleveque/logo-service/internal/model"
+	"github.com/fleveque/logo-service/internal/service"
+	"github.com/fleveque/logo-service/internal/storage"
+)
+
+// maxListSymbols caps the symbols of one archive or sprite request. Bigger
+// sets can be split across requests; each one is cheap, since both only
+// read the cache.
+const maxListSymbols = 500
+
+// archiveWriter is what GetArchive needs from archive/zip and archive/tar,
+// so both formats share one loop.
+type archiveWriter interface {
+	add(name string, data []byte) error
+	Close() error
+}
+
+type zipArchive struct{ *zip.Writer }
+
+func (z zipArchive) add(name string, data []byte) error {
+	// PNGs are already compressed: Store skips a pointless deflate pass.
+	w, err := z.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+type tarArchive struct{ *tar.Writer }
+
+func (t tarArchive) add(name string, data []byte) error {
+	hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), ModTime: time.Now()}
+	if err := t.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := t.Write(data)
+	return err
+}
+
+// archiveRequest holds the query parameters of GetArchive.
+type archiveRequest struct {
+	Symbols string `form:"symbols" binding:"required"`
+	Size    string `form:"size,default=m" binding:"logosize"`
+	Format  string `form:"format,default=zip" binding:"oneof=zip tar"`
+}
+
+// GetArchive streams the logos of several symbols as one zip (or tar) file,
+// one {SYMBOL}.png per symbol.
+// Route: GET /api/v1/logos/archive?symbols=AAPL,MSFT&size=l&format=zip
+//
+// Only cached logos are included — acquiring a whole portfolio could hold
+// the request for minutes. Anything left out is listed with the reason in
+// missing.txt at the end of the archive; request those symbols individually
+// (or warm them) and download again.
+func (h *LogoHandler) GetArchive(c *gin.Context) {
+	var req archiveRequest
+	if !bindQuery(c, &req) {
+		return
+	}
+	symbols, ok := symbolListParam(c, req.Symbols)
+	if !ok {
+		return
+	}
+	size, format := model.LogoSize(req.Size), req.Format
+
+	var contentType string
+	var w archiveWriter
+	switch format {
+	case "zip":
+		contentType, w = "application/zip", zipArchive{zip.NewWriter(c.Writer)}
+	case "tar":
+		contentType, w = "application/x-tar", tarArchive{tar.NewWriter(c.Writer)}
+	}
+
+	// Headers go out before the first logo is read: from here on the
+	// response is committed, so errors can only be logged.
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="logos_%s.%s"`, size, format))
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+
+	var missing strings.Builder
+	for _, logo := range h.logoService.GetCachedLogos(c.Request.Context(), symbols, size) {
+		if logo.Err != nil {
+			fmt.Fprintf(&missing, "%s\t%s\n", logo.Symbol, archiveMissingReason(logo.Err))
+			continue
+		}
+		if err := w.add(logo.Symbol+".png", logo.Data); err != nil {
+			h.logger.Warn("writing logo archive", zap.String("symbol", logo.Symbol), zap.Error(err))
+			return // Most likely the client went away
+		}
+	}
+	if missing.Len() > 0 {
+		if err := w.add("missing.txt", []byte(missing.String())); err != nil {
+			h.logger.Warn("writing logo archive", zap.Error(err))
+			return
+		}
+	}
+	if err := w.Close(); err != nil {
+		h.logger.Warn("writing logo archive", zap.Error(err))
+	}
+}
+
+// symbolListParam parses a comma-separated symbols parameter, normalizing
+// each symbol and dropping duplicates. On failure it writes the 400
+// response itself, so callers just return.
+func symbolListParam(c *gin.Context, param string) ([]string, bool) {
+	var symbols []string
+	seen := make(map[string]bool)
+	for _, raw := range strings.Split(param, ",") {
+		if strings.TrimSpace(raw) == "" {
+			continue
+		}
+		symbol, err := model.NormalizeSymbol(raw)
+		if err != nil {
+			abortInvalid(c, FieldError{Field: "symbols", Message: fmt.Sprintf("contains an invalid symbol: %q", raw)})
+			return nil, false
+		}
+		if !seen[symbol] {
+			seen[symbol] = true
+			symbols = append(symbols, symbol)
+		}
+	}
+
+	if len(symbols) == 0 {
+		abortInvalid(c, FieldError{Field: "symbols", Message: "is required, e.g. symbols=AAPL,MSFT"})
+		return nil, false
+	}
+	if len(symbols) > maxListSymbols {
+		abortInvalid(c, FieldError{Field: "symbols", Message: fmt.Sprintf("has %d symbols, at most %d per request", len(symbols), maxListSymbols)})
+		return nil, false
+	}
+	return symbols, true
+}
+
+// archiveMissingReason explains in missing.txt why a symbol was left out,
+// using the X-Logo-Status values.
+func archiveMissingReason(err error) string {
+	switch {
+	case errors.Is(err, storage.ErrSymbolBlocked):
+		return LogoStatusBlocked
+	case errors.Is(err, storage.ErrLogoDeleted):
+		return LogoStatusDeleted
+	case errors.Is(err, service.ErrPendingReview):
+		return LogoStatusReview
+	case errors.Is(err, service.ErrAcquisitionPending):
+		return LogoStatusPending
+	case errors.Is(err, service.ErrLogoNotFound):
+		return LogoStatusNotFound
+	default:
+		return "not_cached"
+	}
+}
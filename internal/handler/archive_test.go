@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+)
+
+// readZip returns the entries of a zip file by name.
+func readZip(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("reading zip: %v", err)
+	}
+	entries := make(map[string]string)
+	for _, f := range r.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries[f.Name] = string(b)
+	}
+	return entries
+}
+
+// readTar returns the entries of a tar file by name.
+func readTar(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	r := tar.NewReader(bytes.NewReader(data))
+	entries := make(map[string]string)
+	for {
+		hdr, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return entries
+		}
+		if err != nil {
+			t.Fatalf("reading tar: %v", err)
+		}
+		b, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries[hdr.Name] = string(b)
+	}
+}
+
+func TestGetArchive(t *testing.T) {
+	h := newTestLogoHandler(t, map[string][]byte{"AAPL": []byte("apple png"), "MSFT": []byte("microsoft png")})
+
+	tests := []struct {
+		format      string
+		contentType string
+		read        func(*testing.T, []byte) map[string]string
+	}{
+		{"zip", "application/zip", readZip},
+		{"tar", "application/x-tar", readTar},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			w := serve(h.GetArchive, "/logos/archive", "/logos/archive?symbols=aapl,MSFT,AAPL,NOPE&size=l&format="+tt.format)
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", w.Code, w.Body)
+			}
+			if got := w.Header().Get("Content-Type"); got != tt.contentType {
+				t.Errorf("expected %s, got %s", tt.contentType, got)
+			}
+			if got, want := w.Header().Get("Content-Disposition"), `attachment; filename="logos_l.`+tt.format+`"`; got != want {
+				t.Errorf("expected %s, got %s", want, got)
+			}
+
+			entries := tt.read(t, w.Body.Bytes())
+			want := map[string]string{
+				"AAPL.png":    "apple png",
+				"MSFT.png":    "microsoft png",
+				"missing.txt": "NOPE\tnot_cached\n",
+			}
+			if len(entries) != len(want) {
+				t.Errorf("expected entries %v, got %v", want, entries)
+			}
+			for name, content := range want {
+				if entries[name] != content {
+					t.Errorf("%s: expected %q, got %q", name, content, entries[name])
+				}
+			}
+		})
+	}
+}
+
+func TestGetArchive_NothingMissing(t *testing.T) {
+	h := newTestLogoHandler(t, map[string][]byte{"AAPL": []byte("apple png")})
+
+	w := serve(h.GetArchive, "/logos/archive", "/logos/archive?symbols=AAPL")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body)
+	}
+	entries := readZip(t, w.Body.Bytes()) // zip is the default
+	if _, ok := entries["missing.txt"]; ok || entries["AAPL.png"] != "apple png" || len(entries) != 1 {
+		t.Errorf("expected only AAPL.png, got %v", entries)
+	}
+}
+
+func TestGetArchive_Invalid(t *testing.T) {
+	h := newTestLogoHandler(t, nil)
+
+	tests := []struct {
+		query string
+		want  FieldError
+	}{
+		{"symbols=AAPL&format=rar", FieldError{"format", "must be one of zip, tar"}},
+		{"symbols=AAPL&size=huge", FieldError{"size", "must be one of xs, s, m, l, xl"}},
+		{"format=zip", FieldError{"symbols", "is required"}},
+		{"symbols=,,", FieldError{"symbols", "is required, e.g. symbols=AAPL,MSFT"}},
+		{"symbols=AAPL,../etc", FieldError{"symbols", `contains an invalid symbol: "../etc"`}},
+	}
+	for _, tt := range tests {
+		w := serve(h.GetArchive, "/logos/archive", "/logos/archive?"+tt.query)
+		if got := invalidFields(t, w); len(got) != 1 || got[0] != tt.want {
+			t.Errorf("%q: expected %v, got %v", tt.query, tt.want, got)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "application/json; charset=utf-8" {
+			t.Errorf("%q: expected a JSON error, got %s", tt.query, ct)
+		}
+	}
+}
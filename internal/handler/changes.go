@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// GetChanges returns the feed of changes to served logos, oldest first, so
+// downstream systems can sync incrementally instead of polling every symbol.
+// Route: GET /api/v1/changes?since=0&limit=100
+//
+// Start with since=0 (or omit it), then pass back "next" each time. While
+// "has_more" is true another page is ready right away; otherwise poll later.
+func (h *LogoHandler) GetChanges(c *gin.Context) {
+	var req struct {
+		pageQuery
+		Since int64 `form:"since,default=0" binding:"min=0"`
+	}
+	if !bindQuery(c, &req) {
+		return
+	}
+	since, limit := req.Since, req.Limit
+
+	events, err := h.logoService.ListChanges(c.Request.Context(), since, limit)
+	if err != nil {
+		h.logger.Error("listing changes", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	next := since
+	if len(events) > 0 {
+		next = events[len(events)-1].ID
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{
+		"events":   events,
+		"next":     next,
+		"has_more": len(events) == limit,
+	})
+}
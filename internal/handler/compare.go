@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/fleveque/logo-service/internal/imagefmt"
+	"github.com/fleveque/logo-service/internal/provider"
+	"github.com/fleveque/logo-service/internal/storage"
+)
+
+// compareRequest is the JSON body of Compare when the candidate is a URL.
+type compareRequest struct {
+	URL string `json:"url" binding:"required,http_url"`
+}
+
+// Compare sets a candidate image beside a logo's current one: format,
+// dimensions, quality scores and perceptual-hash distance, for the review
+// UI's "replace or keep" decision. Nothing is stored. The candidate is
+// either uploaded as multipart form field "image" or downloaded from a URL:
+//
+//	curl -F image=@apple.png .../admin/logos/AAPL/compare
+//	curl -d '{"url": "https://example.com/apple.svg"}' .../admin/logos/AAPL/compare
+//
+// Route: POST /api/v1/admin/logos/:symbol/compare
+func (h *AdminHandler) Compare(c *gin.Context) {
+	symbol, ok := symbolParam(c)
+	if !ok {
+		return
+	}
+
+	var candidate []byte
+	field := "image"
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		if candidate, ok = readUpload(c, "image", h.maxBytes); !ok {
+			return
+		}
+	} else {
+		var req compareRequest
+		if !bindJSON(c, &req) {
+			return
+		}
+		field = "url"
+		data, err := provider.FetchImage(c.Request.Context(), req.URL, h.maxBytes)
+		var verr *provider.ValidationError
+		switch {
+		case errors.As(err, &verr):
+			abortInvalid(c, FieldError{Field: "url", Message: "is not a usable image: " + verr.Reason})
+			return
+		case err != nil:
+			h.logger.Warn("downloading candidate", zap.String("symbol", symbol), zap.String("url", req.URL), zap.Error(err))
+			c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("downloading candidate: %v", err)})
+			return
+		}
+		candidate = data
+	}
+
+	cmp, err := h.logoService.CompareCandidate(c.Request.Context(), symbol, candidate)
+	switch {
+	case errors.Is(err, storage.ErrNotFound), errors.Is(err, storage.ErrLogoDeleted):
+		c.JSON(http.StatusNotFound, gin.H{"error": "logo not found"})
+		return
+	case unusableImage(err):
+		abortInvalid(c, FieldError{Field: field, Message: "is not a usable image: " + err.Error()})
+		return
+	case err != nil:
+		h.logger.Error("comparing candidate", zap.String("symbol", symbol), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, cmp)
+}
+
+// readUpload reads the image uploaded as multipart form field field, up to
+// maxBytes. On failure it writes the 400 response itself, so callers just
+// return.
+func readUpload(c *gin.Context, field string, maxBytes int) ([]byte, bool) {
+	// Go note: MaxBytesReader fails the read past the limit, so a huge
+	// upload is cut off instead of buffered. The slack covers the
+	// multipart headers around the file.
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, int64(maxBytes)+64<<10)
+	file, err := c.FormFile(field)
+	if err != nil {
+		abortInvalid(c, FieldError{Field: field, Message: "must be an uploaded image: " + err.Error()})
+		return nil, false
+	}
+	f, err := file.Open()
+	if err != nil {
+		abortInvalid(c, FieldError{Field: field, Message: err.Error()})
+		return nil, false
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, int64(maxBytes)+1))
+	if err != nil {
+		abortInvalid(c, FieldError{Field: field, Message: err.Error()})
+		return nil, false
+	}
+	return data, true
+}
+
+// unusableImage reports whether err says an image was rejected by
+// imagefmt's checks: the client's fault, so a 400.
+func unusableImage(err error) bool {
+	return errors.Is(err, imagefmt.ErrEmpty) || errors.Is(err, imagefmt.ErrTooLarge) ||
+		errors.Is(err, imagefmt.ErrUnsupported) || errors.Is(err, imagefmt.ErrMalformed) ||
+		errors.Is(err, imagefmt.ErrUnsafeSVG)
+}
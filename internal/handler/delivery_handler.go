@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/fleveque/logo-service/internal/storage"
+)
+
+// DeliveryHandler shows the events the broker publisher gave up on (see
+// events.publish in the config), so they can be replayed by hand.
+type DeliveryHandler struct {
+	deliveries storage.DeliveryRepository
+	logger     *zap.Logger
+}
+
+// NewDeliveryHandler creates a DeliveryHandler.
+func NewDeliveryHandler(deliveries storage.DeliveryRepository, logger *zap.Logger) *DeliveryHandler {
+	return &DeliveryHandler{deliveries: deliveries, logger: logger}
+}
+
+// DeadLetters lists dead-lettered events, newest first.
+// GET /api/v1/admin/dead-letters?limit=100
+func (h *DeliveryHandler) DeadLetters(c *gin.Context) {
+	var req pageQuery
+	if !bindQuery(c, &req) {
+		return
+	}
+
+	letters, err := h.deliveries.ListDeadLetters(c.Request.Context(), req.Limit)
+	if err != nil {
+		h.logger.Error("listing dead letters", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":        len(letters),
+		"dead_letters": letters,
+	})
+}
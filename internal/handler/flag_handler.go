@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/fleveque/logo-service/internal/flags"
+	"github.com/fleveque/logo-service/internal/storage"
+)
+
+// FlagHandler lets admins see the feature flags and override them at
+// runtime, e.g. to switch off on-demand acquisition while a provider
+// misbehaves, without a redeploy.
+type FlagHandler struct {
+	flags  *flags.Set
+	logger *zap.Logger
+}
+
+// NewFlagHandler creates a FlagHandler.
+func NewFlagHandler(set *flags.Set, logger *zap.Logger) *FlagHandler {
+	return &FlagHandler{flags: set, logger: logger}
+}
+
+// List returns every flag: its default, configured value, override and
+// whether it's on.
+// GET /api/v1/admin/flags
+func (h *FlagHandler) List(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"flags": h.flags.States()})
+}
+
+// Override turns a flag on or off until the override is cleared:
+// {"enabled": false}. It takes effect here at once, and on other instances
+// sharing the database at their next refresh.
+// PUT /api/v1/admin/flags/:name
+func (h *FlagHandler) Override(c *gin.Context) {
+	name := c.Param("name")
+	// A pointer tells "enabled": false from a missing field.
+	var body struct {
+		Enabled *bool `json:"enabled" binding:"required"`
+	}
+	if !bindJSON(c, &body) {
+		return
+	}
+
+	err := h.flags.Override(c.Request.Context(), name, *body.Enabled)
+	if errors.Is(err, flags.ErrUnknownFlag) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "unknown flag"})
+		return
+	}
+	if err != nil {
+		h.logger.Error("overriding flag", zap.String("flag", name), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	h.logger.Warn("flag overridden", zap.String("flag", name), zap.Bool("enabled", *body.Enabled))
+	c.JSON(http.StatusOK, gin.H{"name": name, "enabled": *body.Enabled})
+}
+
+// ClearOverride drops a flag's override, so its configured value applies
+// again.
+// DELETE /api/v1/admin/flags/:name
+func (h *FlagHandler) ClearOverride(c *gin.Context) {
+	name := c.Param("name")
+	err := h.flags.ClearOverride(c.Request.Context(), name)
+	if errors.Is(err, flags.ErrUnknownFlag) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "unknown flag"})
+		return
+	}
+	if errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "flag is not overridden"})
+		return
+	}
+	if err != nil {
+		h.logger.Error("clearing flag override", zap.String("flag", name), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	h.logger.Warn("flag override cleared", zap.String("flag", name))
+	c.JSON(http.StatusOK, gin.H{"name": name, "enabled": h.flags.Enabled(flags.Name(name))})
+}
@@ -4,25 +4,121 @@
 package handler
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+
+	"github.com/fleveque/logo-service/internal/storage"
+	"github.com/fleveque/logo-service/internal/version"
 )
 
 // HealthHandler handles health check requests.
-type HealthHandler struct{}
+type HealthHandler struct {
+	disk *storage.DiskMonitor // nil: disk space isn't reported
+
+	// inMaintenance reports the maintenance_mode flag; nil: never.
+	inMaintenance func() bool
+	maintenance   MaintenanceResponse
+}
 
 // NewHealthHandler creates a new HealthHandler.
 // In Go, constructors are just regular functions prefixed with "New".
-func NewHealthHandler() *HealthHandler {
-	return &HealthHandler{}
+func NewHealthHandler(disk *storage.DiskMonitor) *HealthHandler {
+	return &HealthHandler{disk: disk}
+}
+
+// SetMaintenance makes the health checks report "maintenance", with the
+// message, while inMaintenance returns true.
+func (h *HealthHandler) SetMaintenance(inMaintenance func() bool, maintenance MaintenanceResponse) {
+	h.inMaintenance, h.maintenance = inMaintenance, maintenance
 }
 
 // Healthz responds with service status. The method receiver (h *HealthHandler)
 // is Go's way of attaching methods to a struct — similar to `self` or `this`.
+// During maintenance it still answers 200: the instance is up, serving
+// cached logos.
 func (h *HealthHandler) Healthz(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
+	resp := gin.H{
 		"status":  "ok",
 		"service": "logo-service",
-	})
+		"version": version.Version,
+		"commit":  version.Get().Commit,
+	}
+	h.addMaintenance(resp)
+	c.JSON(http.StatusOK, resp)
+}
+
+// Version tells which build answers: version, commit, build date and Go
+// version, as logo-cli version prints them.
+func (h *HealthHandler) Version(c *gin.Context) {
+	c.JSON(http.StatusOK, version.Get())
+}
+
+// addMaintenance sets the status to "maintenance", with the message and
+// status page, while maintenance is on.
+func (h *HealthHandler) addMaintenance(resp gin.H) {
+	if h.inMaintenance == nil || !h.inMaintenance() {
+		return
+	}
+	resp["status"] = "maintenance"
+	resp["message"] = h.maintenance.Message
+	if h.maintenance.StatusURL != "" {
+		resp["status_url"] = h.maintenance.StatusURL
+	}
+}
+
+// Readyz reports whether the instance can do its job, including free disk space.
+// Low space answers "degraded" with 200, not 503: cached logos are still
+// served, and failing readiness would pull the instance out of the load balancer.
+// Maintenance, for the same reason, answers "maintenance" with 200.
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	resp := gin.H{"status": "ok", "service": "logo-service", "version": version.Version, "commit": version.Get().Commit}
+
+	if h.disk != nil {
+		status, err := h.disk.Status()
+		switch {
+		case err != nil:
+			resp["disk"] = gin.H{"error": err.Error()}
+		case status.Low:
+			resp["status"] = "degraded"
+			resp["disk"] = status
+		default:
+			resp["disk"] = status
+		}
+	}
+
+	h.addMaintenance(resp)
+	c.JSON(http.StatusOK, resp)
+}
+
+// Metrics exposes gauges in the Prometheus text format. There are few enough
+// that writing them by hand beats pulling in the Prometheus client library.
+func (h *HealthHandler) Metrics(c *gin.Context) {
+	c.Header("Content-Type", "text/plain; version=0.0.4")
+
+	if h.disk == nil {
+		c.Status(http.StatusOK)
+		return
+	}
+	status, err := h.disk.Status()
+	if err != nil {
+		c.Status(http.StatusOK)
+		return
+	}
+
+	var low uint64
+	if status.Low {
+		low = 1
+	}
+	w := c.Writer
+	writeGauge(w, "logo_disk_free_bytes", "Bytes available in logo_dir.", status.FreeBytes)
+	writeGauge(w, "logo_disk_total_bytes", "Size of the filesystem holding logo_dir.", status.TotalBytes)
+	writeGauge(w, "logo_disk_min_free_bytes", "Free space below which acquisitions are refused.", status.MinFreeBytes)
+	writeGauge(w, "logo_disk_low", "1 while acquisitions are refused for lack of space.", low)
+}
+
+// writeGauge writes one gauge with its HELP and TYPE lines.
+func writeGauge(w gin.ResponseWriter, name, help string, value uint64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %d\n", name, help, name, name, value)
 }
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/fleveque/logo-service/internal/provider"
+	"github.com/fleveque/logo-service/internal/storage"
+)
+
+// LLMClientHandler lets admins switch individual LLM clients off and on at
+// runtime — during a provider outage or a budget freeze — without a
+// restart. The next client in llm.provider_order takes over.
+type LLMClientHandler struct {
+	llm    *provider.LLMProvider // nil if no LLM keys are configured
+	logger *zap.Logger
+}
+
+// NewLLMClientHandler creates an LLMClientHandler.
+func NewLLMClientHandler(llm *provider.LLMProvider, logger *zap.Logger) *LLMClientHandler {
+	return &LLMClientHandler{llm: llm, logger: logger}
+}
+
+// List returns the configured clients in the order they're tried, with
+// whether each is enabled and, if not, why.
+// GET /api/v1/admin/llm-clients
+func (h *LLMClientHandler) List(c *gin.Context) {
+	clients := []provider.LLMClientState{}
+	if h.llm != nil {
+		clients = h.llm.Clients()
+	}
+	c.JSON(http.StatusOK, gin.H{"clients": clients})
+}
+
+// Set disables or enables a client: {"enabled": false, "reason": "outage"}.
+// It takes effect here at once, on other instances sharing the database at
+// their next refresh, and lasts across restarts.
+// PUT /api/v1/admin/llm-clients/:name
+func (h *LLMClientHandler) Set(c *gin.Context) {
+	name := c.Param("name")
+	var body struct {
+		Enabled *bool  `json:"enabled" binding:"required"`
+		Reason  string `json:"reason"`
+	}
+	if !bindJSON(c, &body) {
+		return
+	}
+	if h.llm == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "unknown LLM client"})
+		return
+	}
+
+	var err error
+	if *body.Enabled {
+		err = h.llm.EnableClient(c.Request.Context(), name)
+		if errors.Is(err, storage.ErrNotFound) {
+			err = nil // Already on: nothing to do
+		}
+	} else {
+		err = h.llm.DisableClient(c.Request.Context(), name, body.Reason)
+	}
+	if errors.Is(err, provider.ErrUnknownLLMClient) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "unknown LLM client"})
+		return
+	}
+	if err != nil {
+		h.logger.Error("switching LLM client", zap.String("provider", name), zap.Bool("enabled", *body.Enabled), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"provider": name, "enabled": *body.Enabled})
+}
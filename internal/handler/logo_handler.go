@@ -1,76 +1,463 @@
 package handler
 
 import (
+	"encoding/base64"
+	"errors"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 
+	"github.com/fleveque/logo-service/internal/imagefmt"
 	"github.com/fleveque/logo-service/internal/model"
+	"github.com/fleveque/logo-service/internal/provider"
 	"github.com/fleveque/logo-service/internal/service"
+	"github.com/fleveque/logo-service/internal/storage"
 )
 
+// Values of the X-Logo-Status response header, which tells clients why
+// they did or didn't get a logo without parsing the body.
+const (
+	LogoStatusProcessed = "processed"
+	LogoStatusPending   = "pending" // Being acquired: retry after Retry-After
+	LogoStatusReview    = "review"  // Held for an admin; not served for now
+	LogoStatusNotFound  = "not_found"
+	LogoStatusBlocked   = "blocked"
+	LogoStatusDeleted   = "deleted" // Deleted by an admin; restorable until purged
+)
+
+// Values of the X-Logo-Error response header, sent with the image that
+// replaces an error's JSON when a request asks for one (onerror).
+const (
+	LogoErrorInvalidRequest = "invalid_request" // Bad symbol, size or color
+	LogoErrorNotFound       = "not_found"
+	LogoErrorReview         = "review"
+	LogoErrorBlocked        = "blocked"
+	LogoErrorDeleted        = "deleted"
+	LogoErrorRateLimited    = "rate_limited"
+	LogoErrorDisabled       = "acquisition_disabled"
+	LogoErrorLowDiskSpace   = "low_disk_space"
+	LogoErrorUnavailable    = "unavailable" // Providers down: worth a retry
+	LogoErrorMaintenance    = "maintenance" // Not cached, and nothing is acquired during maintenance
+)
+
+// Values of the onerror parameter.
+const (
+	onErrorImage       = "image"       // A transparent 1×1 PNG
+	onErrorPlaceholder = "placeholder" // The pending placeholder at the requested size
+)
+
+// Values of the format parameter, and of FormatPolicy.Default.
+const (
+	FormatPNG  = "png"
+	FormatWebP = "webp"
+)
+
+// FormatPolicy picks the image format of a logo request that doesn't ask
+// for one with format; a request that does always gets it.
+type FormatPolicy struct {
+	Default string // FormatPNG (also "") or FormatWebP
+
+	// Negotiate serves Default only to clients whose Accept header lists
+	// it, and PNG to the others, with Vary: Accept so caches keep both.
+	Negotiate bool
+}
+
+// format is the format to serve a request for requested (its format
+// parameter, possibly empty) with the given Accept header.
+func (p FormatPolicy) format(requested, accept string) string {
+	if requested != "" {
+		return requested
+	}
+	if p.Default == FormatWebP && (!p.Negotiate || acceptsWebP(accept)) {
+		return FormatWebP
+	}
+	return FormatPNG
+}
+
+// acceptsWebP reports whether an Accept header lists image/webp, with a
+// nonzero q. Wildcards don't count: every browser that decodes WebP says
+// so explicitly, and older ones send */* too.
+func acceptsWebP(accept string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, _ := strings.Cut(part, ";")
+		if !strings.EqualFold(strings.TrimSpace(mediaType), "image/webp") {
+			continue
+		}
+		for _, param := range strings.Split(params, ";") {
+			if key, value, ok := strings.Cut(strings.TrimSpace(param), "="); ok && key == "q" {
+				if q, err := strconv.ParseFloat(value, 64); err == nil && q == 0 {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// PendingResponse configures the answer to a request for a logo that's
+// still being acquired.
+type PendingResponse struct {
+	Placeholder bool // Send a placeholder image with the 202 instead of JSON
+	RetryAfter  time.Duration
+}
+
+// MaintenanceResponse is what clients are told during maintenance (the
+// maintenance_mode flag): in the 503 of a logo that isn't cached, and in
+// the health checks.
+type MaintenanceResponse struct {
+	Message    string
+	StatusURL  string // Optional
+	RetryAfter time.Duration
+}
+
+// body is the JSON of a 503 refused for maintenance.
+func (m MaintenanceResponse) body() gin.H {
+	body := gin.H{"error": "maintenance", "message": m.Message}
+	if m.StatusURL != "" {
+		body["status_url"] = m.StatusURL
+	}
+	return body
+}
+
 // LogoHandler handles requests for logo images.
 // It delegates to LogoService which implements the full 3-layer pipeline:
 // cache → GitHub → LLM.
 type LogoHandler struct {
 	logoService *service.LogoService
+	pending     PendingResponse
+	maintenance MaintenanceResponse
+	formats     FormatPolicy
+	popularity  *service.Popularity // nil: requests aren't counted
+	maxBytes    int                 // Largest image Transform accepts
 	logger      *zap.Logger
 }
 
 // NewLogoHandler creates a new LogoHandler with the logo service.
+// Pending logos get a JSON 202 with a 5s Retry-After until
+// SetPendingResponse says otherwise, misses during maintenance a 503 with
+// a 5m one until SetMaintenanceResponse does.
 func NewLogoHandler(logoService *service.LogoService, logger *zap.Logger) *LogoHandler {
 	return &LogoHandler{
 		logoService: logoService,
+		pending:     PendingResponse{RetryAfter: 5 * time.Second},
+		maintenance: MaintenanceResponse{Message: "under maintenance", RetryAfter: 5 * time.Minute},
+		maxBytes:    imagefmt.MaxBytes,
 		logger:      logger,
 	}
 }
 
+// SetMaxImageBytes replaces imagefmt.MaxBytes as the largest image
+// Transform accepts, to match processing.max_fetch_size.
+func (h *LogoHandler) SetMaxImageBytes(n int) {
+	h.maxBytes = n
+}
+
+// SetPendingResponse configures the response for logos being acquired.
+func (h *LogoHandler) SetPendingResponse(pending PendingResponse) {
+	h.pending = pending
+}
+
+// SetMaintenanceResponse configures the response for logos that aren't
+// cached during maintenance.
+func (h *LogoHandler) SetMaintenanceResponse(maintenance MaintenanceResponse) {
+	h.maintenance = maintenance
+}
+
+// SetFormatPolicy sets the format of logos requested without one. The
+// default is PNG for everyone.
+func (h *LogoHandler) SetFormatPolicy(formats FormatPolicy) {
+	h.formats = formats
+}
+
+// SetPopularity counts every valid logo request, found or not, towards the
+// most requested symbols (see PopularityHandler).
+func (h *LogoHandler) SetPopularity(popularity *service.Popularity) {
+	h.popularity = popularity
+}
+
+// logoRequest holds the query parameters of GetLogo.
+type logoRequest struct {
+	Size       string `form:"size,default=m" binding:"logosize"`
+	Background string `form:"bg" binding:"omitempty,bgcolor"`
+	Encoding   string `form:"encoding" binding:"omitempty,oneof=base64 json"`
+	OnError    string `form:"onerror" binding:"omitempty,oneof=image placeholder"`
+	Format     string `form:"format" binding:"omitempty,oneof=png webp"`
+}
+
 // GetLogo serves a logo image for the given stock symbol.
-// Route: GET /api/v1/logos/:symbol?size=m&bg=ffffff
+// Route: GET /api/v1/logos/:symbol?size=m&bg=ffffff&format=webp&encoding=base64
 //
 // If the logo isn't cached, the service transparently acquires it from
 // GitHub repos or via LLM web search, processes it, and caches it.
+//
+// encoding is for renderers that inline logos (server-side HTML, email
+// templates): "base64" answers with a data:image/png;base64 URI as plain
+// text, "json" wraps it in {"symbol", "size", "data_uri"}. Errors are the
+// same whatever the encoding.
+//
+// onerror is for <img> tags, which show a broken icon for a JSON error:
+// "image" answers every error, a bad request included, with a transparent
+// 1×1 PNG instead, "placeholder" with the gray placeholder at the requested
+// size. The status and X-Logo-Status stay the same, X-Logo-Error says what
+// went wrong, and Cache-Control lets browsers keep the image only for
+// errors that last (not found, blocked, deleted, in review). A logo being
+// acquired gets the image as well.
+//
+// format picks PNG or WebP. Without it, the FormatPolicy decides, possibly
+// from the Accept header. Error images are always PNG.
 func (h *LogoHandler) GetLogo(c *gin.Context) {
-	symbol := strings.ToUpper(c.Param("symbol"))
-
-	// Validate size parameter
-	sizeStr := c.DefaultQuery("size", "m")
-	if !model.ValidSize(sizeStr) {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "invalid size: must be xs, s, m, l, or xl",
-		})
+	// Both are checked before answering, so that onerror=image applies to
+	// a bad symbol too: <img> tags get symbols from user data.
+	var req logoRequest
+	symbol, symbolErr := model.NormalizeSymbol(c.Param("symbol"))
+	bindErr := c.ShouldBindQuery(&req)
+	if symbolErr != nil || bindErr != nil {
+		h.respondInvalid(c, symbolErr, bindErr)
 		return
 	}
-	size := model.LogoSize(sizeStr)
+	encoding := req.Encoding
+	size := model.LogoSize(req.Size)
+	format := h.formats.format(req.Format, c.GetHeader("Accept"))
+	if req.Format == "" && h.formats.Negotiate {
+		// On every answer, errors included, so caches never mix formats up.
+		// Added, not set: CORS may have put Origin there already.
+		c.Writer.Header().Add("Vary", "Accept")
+	}
+	h.popularity.Record(symbol)
 
-	// GetLogo handles the full pipeline: cache → GitHub → LLM → process
-	data, err := h.logoService.GetLogo(c.Request.Context(), symbol, size)
+	// GetLogo handles the full pipeline: cache → GitHub → LLM → process.
+	// With a background color, the flattened variant is served (and cached).
+	// Without one, the logo's default background applies, if it has one;
+	// bg=none asks for the logo as stored regardless.
+	var data []byte
+	var err error
+	switch req.Background {
+	case "":
+		data, err = h.logoService.GetLogoDefault(c.Request.Context(), symbol, size)
+	case bgNone:
+		data, err = h.logoService.GetLogo(c.Request.Context(), symbol, size)
+	default:
+		data, err = h.logoService.GetLogoWithBackground(c.Request.Context(), symbol, size, req.Background)
+	}
+	if errors.Is(err, storage.ErrSymbolBlocked) {
+		c.Header("X-Logo-Status", LogoStatusBlocked)
+		h.respondError(c, req, http.StatusGone, LogoErrorBlocked, "logo is no longer available")
+		return
+	}
+	if errors.Is(err, storage.ErrLogoDeleted) {
+		c.Header("X-Logo-Status", LogoStatusDeleted)
+		h.respondError(c, req, http.StatusGone, LogoErrorDeleted, "logo is no longer available")
+		return
+	}
+	if errors.Is(err, service.ErrAcquisitionPending) {
+		h.respondPending(c, size, req.OnError)
+		return
+	}
+	if errors.Is(err, service.ErrAcquisitionLimited) {
+		// The misses limit set Retry-After; cached logos are still served.
+		h.respondError(c, req, http.StatusTooManyRequests, LogoErrorRateLimited, "rate limit exceeded for logos not cached yet")
+		return
+	}
+	if errors.Is(err, service.ErrLogoNotFound) {
+		// Known to be missing until its next check: a cheap, stable answer,
+		// so clients and CDNs may hold on to it briefly. Not for long — an
+		// admin can requeue the symbol at any time.
+		h.logger.Debug("logo not found", zap.String("symbol", symbol))
+		c.Header("Cache-Control", "public, max-age=300")
+		c.Header("X-Logo-Status", LogoStatusNotFound)
+		h.respondError(c, req, http.StatusNotFound, LogoErrorNotFound, "logo not found")
+		return
+	}
+	if errors.Is(err, service.ErrInvalidColor) {
+		h.respondError(c, req, http.StatusBadRequest, LogoErrorInvalidRequest, err.Error())
+		return
+	}
+	if errors.Is(err, service.ErrMaintenance) {
+		c.Header("Retry-After", strconv.Itoa(int(h.maintenance.RetryAfter.Seconds())))
+		if req.OnError != "" {
+			h.respondError(c, req, http.StatusServiceUnavailable, LogoErrorMaintenance, h.maintenance.Message)
+			return
+		}
+		c.JSON(http.StatusServiceUnavailable, h.maintenance.body())
+		return
+	}
+	if errors.Is(err, service.ErrAcquisitionDisabled) {
+		// Switched off by a flag, typically while providers misbehave.
+		c.Header("Retry-After", "300")
+		h.respondError(c, req, http.StatusServiceUnavailable, LogoErrorDisabled, "logo not cached and on-demand acquisition is disabled")
+		return
+	}
+	if errors.Is(err, storage.ErrLowDiskSpace) {
+		// Temporary: the logo exists somewhere, we just can't store it right now.
+		h.logger.Warn("refusing acquisition", zap.String("symbol", symbol), zap.Error(err))
+		c.Header("Retry-After", "300")
+		h.respondError(c, req, http.StatusServiceUnavailable, LogoErrorLowDiskSpace, "logo not cached and acquisition is paused (low disk space)")
+		return
+	}
+	if errors.Is(err, provider.ErrUnavailable) {
+		// Not known to be missing: a provider was down, so ask again later.
+		h.logger.Warn("providers unavailable", zap.String("symbol", symbol), zap.Error(err))
+		c.Header("Retry-After", "60")
+		h.respondError(c, req, http.StatusServiceUnavailable, LogoErrorUnavailable, "logo not cached and its providers are unavailable")
+		return
+	}
 	if err != nil {
 		h.logger.Warn("logo not found",
 			zap.String("symbol", symbol),
 			zap.Error(err),
 		)
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "logo not found",
-		})
+		status, code := LogoStatusNotFound, LogoErrorNotFound
+		if errors.Is(err, service.ErrPendingReview) {
+			status, code = LogoStatusReview, LogoErrorReview
+		}
+		c.Header("X-Logo-Status", status)
+		h.respondError(c, req, http.StatusNotFound, code, "logo not found")
 		return
 	}
 
-	// Apply background color if requested
-	bgColor := c.Query("bg")
-	if bgColor != "" {
-		data, err = service.ApplyBackground(data, bgColor)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": "invalid background color: " + err.Error(),
-			})
-			return
+	contentType := "image/png"
+	if format == FormatWebP {
+		if webp, err := service.EncodeWebP(data); err != nil {
+			// The PNG, labeled as such, beats no logo at all.
+			h.logger.Warn("encoding webp", zap.String("symbol", symbol), zap.Error(err))
+		} else {
+			data, contentType = webp, "image/webp"
 		}
 	}
 
 	// Set cache headers — logos don't change often
 	c.Header("Cache-Control", "public, max-age=86400")
-	c.Data(http.StatusOK, "image/png", data)
+	c.Header("X-Logo-Status", LogoStatusProcessed)
+	switch encoding {
+	case "base64":
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(dataURI(contentType, data)))
+	case "json":
+		c.JSON(http.StatusOK, gin.H{"symbol": symbol, "size": size, "data_uri": dataURI(contentType, data)})
+	default:
+		c.Data(http.StatusOK, contentType, data)
+	}
+}
+
+// dataURI encodes an image for use directly in src="..." or url(...).
+func dataURI(contentType string, data []byte) string {
+	return "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
+
+// respondPending answers 202 Accepted for a logo that's being acquired.
+// Nothing about it may be cached: the real logo replaces it in seconds.
+// onError is the request's onerror: an <img> gets an image here too.
+func (h *LogoHandler) respondPending(c *gin.Context, size model.LogoSize, onError string) {
+	c.Header("X-Logo-Status", LogoStatusPending)
+	c.Header("Retry-After", strconv.Itoa(int(h.pending.RetryAfter.Seconds())))
+	c.Header("Cache-Control", "no-store")
+
+	switch {
+	case h.pending.Placeholder || onError == onErrorPlaceholder:
+		c.Data(http.StatusAccepted, "image/png", service.PlaceholderPNG(size))
+	case onError == onErrorImage:
+		c.Data(http.StatusAccepted, "image/png", service.TransparentPNG())
+	default:
+		c.JSON(http.StatusAccepted, gin.H{"status": LogoStatusPending})
+	}
+}
+
+// respondError answers a GetLogo error: {"error": message} by default, or
+// the image the request's onerror asks for, with the same status and code
+// in X-Logo-Error.
+func (h *LogoHandler) respondError(c *gin.Context, req logoRequest, status int, code, message string) {
+	if req.OnError == "" {
+		c.JSON(status, gin.H{"error": message})
+		return
+	}
+	c.Header("X-Logo-Error", code)
+	c.Header("Cache-Control", errorImageCacheControl(code))
+	c.Data(status, "image/png", errorImage(req.OnError, model.LogoSize(req.Size)))
+}
+
+// respondInvalid answers a GetLogo request whose symbol or query parameters
+// are invalid: with the 400 listing every bad field, or with an error image
+// if onerror asks for one (its own value being valid, or it would be listed).
+func (h *LogoHandler) respondInvalid(c *gin.Context, symbolErr, bindErr error) {
+	if onError := c.Query("onerror"); onError == onErrorImage || onError == onErrorPlaceholder {
+		size := c.DefaultQuery("size", string(model.SizeM))
+		if !model.ValidSize(size) {
+			size = string(model.SizeM)
+		}
+		h.respondError(c, logoRequest{OnError: onError, Size: size}, http.StatusBadRequest, LogoErrorInvalidRequest, "invalid request")
+		c.Abort()
+		return
+	}
+
+	var fields []FieldError
+	if symbolErr != nil {
+		fields = append(fields, FieldError{Field: "symbol", Message: "is not a valid symbol"})
+	}
+	if bindErr != nil {
+		fields = append(fields, fieldErrors(bindErr)...)
+	}
+	abortInvalid(c, fields...)
+}
+
+// errorImage is the image onerror asks for.
+func errorImage(onError string, size model.LogoSize) []byte {
+	if onError == onErrorPlaceholder {
+		return service.PlaceholderPNG(size)
+	}
+	return service.TransparentPNG()
+}
+
+// errorImageCacheControl says how long browsers may keep an error image.
+// Missing, blocked, deleted or held logos stay that way for a while (and
+// a bad request stays bad), so they're kept as long as the JSON not_found
+// answer; anything else may pass at any moment.
+func errorImageCacheControl(code string) string {
+	switch code {
+	case LogoErrorNotFound, LogoErrorBlocked, LogoErrorDeleted, LogoErrorReview, LogoErrorInvalidRequest:
+		return "public, max-age=300"
+	default:
+		return "no-store"
+	}
+}
+
+// GetMetadata returns the stored record for a symbol as JSON: source, status,
+// available sizes, confidence. It never triggers acquisition.
+// Route: GET /api/v1/logos/:symbol/metadata
+func (h *LogoHandler) GetMetadata(c *gin.Context) {
+	symbol, ok := symbolParam(c)
+	if !ok {
+		return
+	}
+
+	logo, err := h.logoService.GetMetadata(c.Request.Context(), symbol)
+	if errors.Is(err, storage.ErrSymbolBlocked) {
+		c.JSON(http.StatusGone, gin.H{"error": "logo is no longer available"})
+		return
+	}
+	if errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "logo not found"})
+		return
+	}
+	if err != nil {
+		h.logger.Error("getting logo metadata", zap.String("symbol", symbol), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, logo)
+}
+
+// symbolParam normalizes and validates the :symbol path parameter.
+// On failure it writes the 400 response itself, so callers just return.
+func symbolParam(c *gin.Context) (string, bool) {
+	symbol, err := model.NormalizeSymbol(c.Param("symbol"))
+	if err != nil {
+		abortInvalid(c, FieldError{Field: "symbol", Message: "is not a valid symbol"})
+		return "", false
+	}
+	return symbol, true
 }
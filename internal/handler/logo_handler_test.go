@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/fleveque/logo-service/internal/provider"
+	"github.com/fleveque/logo-service/internal/service"
+	"github.com/fleveque/logo-service/internal/storage"
+	"github.com/fleveque/logo-service/internal/testutil"
+)
+
+// newTestLogoHandler returns a LogoHandler over a fresh database and an
+// in-memory cache holding the given logos, stored as every size with their
+// bytes unchanged.
+func newTestLogoHandler(t *testing.T, logos map[string][]byte) *LogoHandler {
+	t.Helper()
+
+	db := testutil.NewDB(t)
+	fs := testutil.NewFileSystem(t)
+	svc := service.NewLogoService(storage.NewLogoRepository(db), storage.NewRequestedSymbolRepository(db),
+		storage.NewInstrumentRepository(db), fs, &testutil.FakeProcessor{FS: fs}, testutil.NewFakeProvider(),
+		service.WithBlocklist(storage.NewBlocklistRepository(db)))
+	for symbol, data := range logos {
+		if err := svc.ProcessAndStore(context.Background(), &provider.LogoResult{Symbol: symbol, ImageData: data, Source: "github:test"}); err != nil {
+			t.Fatalf("storing %s: %v", symbol, err)
+		}
+	}
+	return NewLogoHandler(svc, zap.NewNop())
+}
+
+// serve runs one request against a handler mounted at path.
+func serve(handler gin.HandlerFunc, path, target string) *httptest.ResponseRecorder {
+	router := gin.New()
+	router.GET(path, handler)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+	return w
+}
+
+func TestGetLogo_Encoding(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\xff logo")
+	h := newTestLogoHandler(t, map[string][]byte{"AAPL": png})
+
+	// decode checks a data URI and returns the image it carries.
+	decode := func(t *testing.T, uri string) []byte {
+		t.Helper()
+		encoded, ok := strings.CutPrefix(uri, "data:image/png;base64,")
+		if !ok {
+			t.Fatalf("expected a PNG data URI, got %q", uri)
+		}
+		data, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			t.Fatalf("decoding %q: %v", uri, err)
+		}
+		return data
+	}
+
+	w := serve(h.GetLogo, "/logos/:symbol", "/logos/aapl?encoding=base64")
+	if w.Code != http.StatusOK {
+		t.Fatalf("base64: expected 200, got %d: %s", w.Code, w.Body)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/plain; charset=utf-8" {
+		t.Errorf("base64: expected text/plain, got %s", ct)
+	}
+	if got := decode(t, w.Body.String()); string(got) != string(png) {
+		t.Errorf("base64: expected the stored bytes, got %q", got)
+	}
+
+	w = serve(h.GetLogo, "/logos/:symbol", "/logos/aapl?encoding=json&size=s")
+	if w.Code != http.StatusOK {
+		t.Fatalf("json: expected 200, got %d: %s", w.Code, w.Body)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json; charset=utf-8" {
+		t.Errorf("json: expected application/json, got %s", ct)
+	}
+	var body struct {
+		Symbol  string `json:"symbol"`
+		Size    string `json:"size"`
+		DataURI string `json:"data_uri"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Symbol != "AAPL" || body.Size != "s" {
+		t.Errorf("json: unexpected body %+v", body)
+	}
+	if got := decode(t, body.DataURI); string(got) != string(png) {
+		t.Errorf("json: expected the stored bytes, got %q", got)
+	}
+
+	// Without an encoding, the image itself.
+	w = serve(h.GetLogo, "/logos/:symbol", "/logos/aapl")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/png" || w.Body.String() != string(png) {
+		t.Errorf("raw: expected the PNG, got %d %s %q", w.Code, w.Header().Get("Content-Type"), w.Body)
+	}
+
+	// Errors are the same whatever the encoding.
+	w = serve(h.GetLogo, "/logos/:symbol", "/logos/nope?encoding=base64")
+	if w.Code != http.StatusNotFound || w.Header().Get("Content-Type") != "application/json; charset=utf-8" {
+		t.Errorf("missing: expected a JSON 404, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	w = serve(h.GetLogo, "/logos/:symbol", "/logos/aapl?encoding=hex")
+	if fields := invalidFields(t, w); len(fields) != 1 || fields[0] != (FieldError{"encoding", "must be one of base64, json"}) {
+		t.Errorf("bad encoding: got %v", fields)
+	}
+}
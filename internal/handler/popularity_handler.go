@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/fleveque/logo-service/internal/storage"
+)
+
+// PopularityHandler lists the most requested symbols: the ones to warm
+// first after a deploy, and, among those without a logo, the ones worth
+// curating by hand.
+type PopularityHandler struct {
+	repo   storage.PopularityRepository
+	logger *zap.Logger
+}
+
+// NewPopularityHandler creates a PopularityHandler.
+func NewPopularityHandler(repo storage.PopularityRepository, logger *zap.Logger) *PopularityHandler {
+	return &PopularityHandler{repo: repo, logger: logger}
+}
+
+// popularRequest holds the query parameters of List.
+type popularRequest struct {
+	pageQuery
+	Days    int  `form:"days,default=30" binding:"min=1,max=365"`
+	Missing bool `form:"missing"`
+}
+
+// List returns the symbols requested most over the last days days (today
+// included), most first, with their request counts and logo status. With
+// missing=true, only those without a processed logo. Counts are sampled
+// (stats.popularity_sample_rate) and written every
+// stats.popularity_flush_interval, so the last minute may not show yet.
+// Route: GET /api/v1/admin/popular?days=30&limit=100&missing=true
+func (h *PopularityHandler) List(c *gin.Context) {
+	var req popularRequest
+	if !bindQuery(c, &req) {
+		return
+	}
+
+	since := time.Now().UTC().AddDate(0, 0, 1-req.Days).Format(time.DateOnly)
+	popular, err := h.repo.Top(c.Request.Context(), since, req.Missing, req.Limit)
+	if err != nil {
+		h.logger.Error("listing popular symbols", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"days": req.Days, "count": len(popular), "symbols": popular})
+}
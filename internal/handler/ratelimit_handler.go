@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/fleveque/logo-service/internal/middleware"
+)
+
+// RateLimitHandler lets admins inspect and reset per-key rate limit buckets,
+// e.g. after a client's runaway retry loop has been fixed.
+type RateLimitHandler struct {
+	limiter *middleware.RateLimiter
+	logger  *zap.Logger
+}
+
+// NewRateLimitHandler creates a RateLimitHandler. The limiter must be the one
+// installed on the authenticated routes, or the buckets shown are empty.
+func NewRateLimitHandler(limiter *middleware.RateLimiter, logger *zap.Logger) *RateLimitHandler {
+	return &RateLimitHandler{limiter: limiter, logger: logger}
+}
+
+// Get returns the current bucket for an API key.
+// GET /api/v1/admin/ratelimits/:key
+func (h *RateLimitHandler) Get(c *gin.Context) {
+	key := c.Param("key")
+	c.JSON(http.StatusOK, gin.H{
+		"key":    key,
+		"bucket": h.limiter.Bucket(key),
+	})
+}
+
+// Reset refills an API key's bucket immediately.
+// DELETE /api/v1/admin/ratelimits/:key
+func (h *RateLimitHandler) Reset(c *gin.Context) {
+	key := c.Param("key")
+	existed := h.limiter.Reset(key)
+	h.logger.Info("rate limit bucket reset", zap.Bool("existed", existed))
+	c.JSON(http.StatusOK, gin.H{
+		"key":   key,
+		"reset": existed,
+	})
+}
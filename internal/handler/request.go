@@ -0,0 +1,214 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+
+	"github.com/fleveque/logo-service/internal/model"
+	"github.com/fleveque/logo-service/internal/storage"
+)
+
+// Query parameters and JSON bodies are bound into typed request structs:
+// the form/json tags name the parameters (with their defaults), and the
+// binding tags say what's valid. Anything invalid is answered with a 400
+// listing every offending field:
+//
+//	{"error": "invalid request: size must be one of xs, s, m, l, xl",
+//	 "fields": [{"field": "size", "message": "must be one of xs, s, m, l, xl"}]}
+//
+// Go note: gin validates with go-playground/validator. Besides its built-in
+// rules (min, max, oneof, required...), RegisterValidators adds the ones
+// this API needs: logosize, symbol, rgbhex, bgcolor and notblank.
+
+// pageQuery is the limit parameter shared by every list endpoint.
+type pageQuery struct {
+	Limit int `form:"limit,default=100" binding:"min=1,max=1000"`
+}
+
+// cursorQuery is pageQuery for lists paginated by cursor: a response's
+// next_cursor, passed back as cursor, returns the following page. sort
+// names one of the list's orders, "-" prefixed for descending.
+type cursorQuery struct {
+	pageQuery
+	Cursor string `form:"cursor"`
+	Sort   string `form:"sort"`
+}
+
+// options converts the query into repository list options.
+func (q cursorQuery) options() storage.ListOptions {
+	return storage.ListOptions{Cursor: q.Cursor, Limit: q.Limit, Sort: q.Sort}
+}
+
+// FieldError is one invalid parameter in a 400 response.
+type FieldError struct {
+	Field   string `json:"field,omitempty"` // Empty when the request doesn't parse at all
+	Message string `json:"message"`
+}
+
+// rgbHex is a background color: six hex digits, with or without "#".
+var rgbHex = regexp.MustCompile(`^#?[0-9a-fA-F]{6}$`)
+
+// bgNone is the bg parameter asking for no background at all, not even a
+// logo's default one.
+const bgNone = "none"
+
+// validators are the rules this API adds to validator's built-in ones.
+var validators = map[string]validator.Func{
+	"logosize": func(fl validator.FieldLevel) bool {
+		return model.ValidSize(fl.Field().String())
+	},
+	"symbol": func(fl validator.FieldLevel) bool {
+		_, err := model.NormalizeSymbol(fl.Field().String())
+		return err == nil
+	},
+	"rgbhex": func(fl validator.FieldLevel) bool {
+		return rgbHex.MatchString(fl.Field().String())
+	},
+	"bgcolor": func(fl validator.FieldLevel) bool {
+		return fl.Field().String() == bgNone || rgbHex.MatchString(fl.Field().String())
+	},
+	"notblank": func(fl validator.FieldLevel) bool {
+		return strings.TrimSpace(fl.Field().String()) != ""
+	},
+}
+
+// RegisterValidators sets up gin's validator for the request structs:
+// the rules above, and fields reported by the name clients use rather than
+// the Go field name. It must run before the first request is bound, since
+// binding a struct with an unknown rule panics.
+func RegisterValidators() error {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return fmt.Errorf("gin validates with %T, not go-playground/validator", binding.Validator.Engine())
+	}
+	return registerValidators(v)
+}
+
+func registerValidators(v *validator.Validate) error {
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		for _, key := range []string{"form", "json", "uri"} {
+			if name, _, _ := strings.Cut(f.Tag.Get(key), ","); name != "" && name != "-" {
+				return name
+			}
+		}
+		return f.Name
+	})
+	for tag, fn := range validators {
+		if err := v.RegisterValidation(tag, fn); err != nil {
+			return fmt.Errorf("registering the %s rule: %w", tag, err)
+		}
+	}
+	return nil
+}
+
+// bindQuery fills req from the query string and validates it. On failure it
+// writes the 400 response itself, so callers just return.
+func bindQuery(c *gin.Context, req any) bool {
+	if err := c.ShouldBindQuery(req); err != nil {
+		abortInvalid(c, fieldErrors(err)...)
+		return false
+	}
+	return true
+}
+
+// bindJSON is bindQuery for a JSON body. An empty body counts as {}: for
+// bodies whose every field is optional. See requireJSON.
+func bindJSON(c *gin.Context, req any) bool {
+	if c.Request.ContentLength == 0 {
+		if err := binding.Validator.ValidateStruct(req); err != nil {
+			abortInvalid(c, fieldErrors(err)...)
+			return false
+		}
+		return true
+	}
+	if err := c.ShouldBindJSON(req); err != nil {
+		abortInvalid(c, fieldErrors(err)...)
+		return false
+	}
+	return true
+}
+
+// requireJSON is bindJSON for a body that must be sent, even if every field
+// may be null: an empty one is a 400 rather than a request to clear them.
+func requireJSON(c *gin.Context, req any) bool {
+	if c.Request.ContentLength == 0 {
+		abortInvalid(c, FieldError{Message: "a JSON body is required"})
+		return false
+	}
+	return bindJSON(c, req)
+}
+
+// abortListError answers a 400 if err is a bad cursor or sort, and
+// reports whether it did.
+func abortListError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, storage.ErrInvalidCursor):
+		abortInvalid(c, FieldError{Field: "cursor", Message: "is not a cursor from this list and sort"})
+	case errors.Is(err, storage.ErrInvalidSort):
+		abortInvalid(c, FieldError{Field: "sort", Message: "is not supported by this list"})
+	default:
+		return false
+	}
+	return true
+}
+
+// abortInvalid answers 400 with the given field errors.
+func abortInvalid(c *gin.Context, fields ...FieldError) {
+	messages := make([]string, len(fields))
+	for i, f := range fields {
+		messages[i] = strings.TrimSpace(f.Field + " " + f.Message)
+	}
+	c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+		"error":  "invalid request: " + strings.Join(messages, "; "),
+		"fields": fields,
+	})
+}
+
+// fieldErrors turns a binding error into field errors. Values that don't
+// even parse (limit=abc, malformed JSON) come without a field name from gin
+// and are reported as a whole.
+func fieldErrors(err error) []FieldError {
+	var invalid validator.ValidationErrors
+	if !errors.As(err, &invalid) {
+		return []FieldError{{Message: "malformed: " + err.Error()}}
+	}
+	fields := make([]FieldError, len(invalid))
+	for i, fe := range invalid {
+		fields[i] = FieldError{Field: fe.Field(), Message: ruleMessage(fe)}
+	}
+	return fields
+}
+
+// ruleMessage explains a failed rule in words.
+func ruleMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required", "notblank":
+		return "is required"
+	case "min":
+		return "must be at least " + fe.Param()
+	case "max":
+		return "must be at most " + fe.Param()
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "logosize":
+		return "must be one of xs, s, m, l, xl"
+	case "symbol":
+		return "is not a valid symbol"
+	case "rgbhex":
+		return "must be six hex digits, e.g. ffffff"
+	case "bgcolor":
+		return "must be six hex digits, e.g. ffffff, or none"
+	case "http_url":
+		return "must be an http or https URL"
+	default:
+		return fmt.Sprintf("fails %s", fe.Tag())
+	}
+}
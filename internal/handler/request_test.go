@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	if err := RegisterValidators(); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+func TestValidators(t *testing.T) {
+	v := validator.New()
+	if err := registerValidators(v); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		tag, value string
+		valid      bool
+	}{
+		{"logosize", "m", true},
+		{"logosize", "xl", true},
+		{"logosize", "xxl", false},
+		{"logosize", "", false},
+		{"symbol", "AAPL", true},
+		{"symbol", "brk.b", true},
+		{"symbol", "BTC-USD", true},
+		{"symbol", "^GSPC", true},
+		{"symbol", "../etc", false},
+		{"symbol", "", false},
+		{"rgbhex", "ffffff", true},
+		{"rgbhex", "#1A1a1A", true},
+		{"rgbhex", "fff", false},
+		{"rgbhex", "none", false},
+		{"rgbhex", "gggggg", false},
+		{"bgcolor", "none", true},
+		{"bgcolor", "#000000", true},
+		{"bgcolor", "black", false},
+		{"notblank", "x", true},
+		{"notblank", "  ", false},
+		{"notblank", "", false},
+	}
+	for _, tt := range tests {
+		err := v.Var(tt.value, tt.tag)
+		if (err == nil) != tt.valid {
+			t.Errorf("%s %q: expected valid=%v, got %v", tt.tag, tt.value, tt.valid, err)
+		}
+	}
+}
+
+// invalidFields is the fields list of a 400 response.
+func invalidFields(t *testing.T, w *httptest.ResponseRecorder) []FieldError {
+	t.Helper()
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", w.Code, w.Body)
+	}
+	var body struct {
+		Error  string       `json:"error"`
+		Fields []FieldError `json:"fields"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(body.Error, "invalid request: ") {
+		t.Errorf("unexpected error %q", body.Error)
+	}
+	return body.Fields
+}
+
+func TestBindQuery_FieldErrors(t *testing.T) {
+	type query struct {
+		Size   string `form:"size" binding:"required,logosize"`
+		Limit  int    `form:"limit,default=10" binding:"min=1,max=50"`
+		Bg     string `form:"bg" binding:"omitempty,bgcolor"`
+		Color  string `form:"color" binding:"omitempty,rgbhex"`
+		Symbol string `form:"symbol" binding:"omitempty,symbol"`
+		Mode   string `form:"mode" binding:"omitempty,oneof=fast exact"`
+		URL    string `form:"url" binding:"omitempty,http_url"`
+	}
+	router := gin.New()
+	router.GET("/", func(c *gin.Context) {
+		var q query
+		if bindQuery(c, &q) {
+			c.Status(http.StatusOK)
+		}
+	})
+
+	tests := []struct {
+		query string
+		want  []FieldError // nil: valid
+	}{
+		{"size=m", nil},
+		{"size=m&limit=50&bg=none&color=ffffff&symbol=aapl&mode=fast&url=https://example.com/a.png", nil},
+		{"", []FieldError{{"size", "is required"}}},
+		{"size=huge", []FieldError{{"size", "must be one of xs, s, m, l, xl"}}},
+		{"size=m&limit=0", []FieldError{{"limit", "must be at least 1"}}},
+		{"size=m&limit=51", []FieldError{{"limit", "must be at most 50"}}},
+		{"size=m&bg=red", []FieldError{{"bg", "must be six hex digits, e.g. ffffff, or none"}}},
+		{"size=m&color=none", []FieldError{{"color", "must be six hex digits, e.g. ffffff"}}},
+		{"size=m&symbol=a/b", []FieldError{{"symbol", "is not a valid symbol"}}},
+		{"size=m&mode=slow", []FieldError{{"mode", "must be one of fast, exact"}}},
+		{"size=m&url=ftp://example.com", []FieldError{{"url", "must be an http or https URL"}}},
+		{"size=x&limit=0", []FieldError{{"size", "must be one of xs, s, m, l, xl"}, {"limit", "must be at least 1"}}},
+		{"size=m&limit=abc", []FieldError{{"", "malformed: strconv.ParseInt: parsing \"abc\": invalid syntax"}}},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/?"+tt.query, nil))
+		if tt.want == nil {
+			if w.Code != http.StatusOK {
+				t.Errorf("%q: expected 200, got %d: %s", tt.query, w.Code, w.Body)
+			}
+			continue
+		}
+		got := invalidFields(t, w)
+		if len(got) != len(tt.want) {
+			t.Errorf("%q: expected %v, got %v", tt.query, tt.want, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%q: expected %v, got %v", tt.query, tt.want[i], got[i])
+			}
+		}
+	}
+}
+
+func TestBindJSON(t *testing.T) {
+	type body struct {
+		Reason string `json:"reason" binding:"omitempty,notblank"`
+		Color  *bool  `json:"color"`
+	}
+	router := gin.New()
+	router.PUT("/optional", func(c *gin.Context) {
+		var b body
+		if bindJSON(c, &b) {
+			c.Status(http.StatusOK)
+		}
+	})
+	router.PUT("/required", func(c *gin.Context) {
+		var b body
+		if requireJSON(c, &b) {
+			c.Status(http.StatusOK)
+		}
+	})
+
+	tests := []struct {
+		path, body string
+		want       []FieldError // nil: valid
+	}{
+		{"/optional", "", nil},
+		{"/optional", `{"reason": "spam"}`, nil},
+		{"/optional", `{"reason": "  "}`, []FieldError{{"reason", "is required"}}},
+		{"/optional", `{"reason": `, []FieldError{{"", "malformed: unexpected EOF"}}},
+		{"/required", "", []FieldError{{"", "a JSON body is required"}}},
+		{"/required", `{"color": null}`, nil},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("PUT", tt.path, strings.NewReader(tt.body)))
+		if tt.want == nil {
+			if w.Code != http.StatusOK {
+				t.Errorf("%s %q: expected 200, got %d: %s", tt.path, tt.body, w.Code, w.Body)
+			}
+			continue
+		}
+		got := invalidFields(t, w)
+		if len(got) != 1 || got[0] != tt.want[0] {
+			t.Errorf("%s %q: expected %v, got %v", tt.path, tt.body, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/fleveque/logo-service/internal/model"
+	"github.com/fleveque/logo-service/internal/service"
+	"github.com/fleveque/logo-service/internal/sprite"
+)
+
+// spriteRequest holds the query parameters of GetSprite.
+type spriteRequest struct {
+	Symbols string `form:"symbols" binding:"required"`
+	Size    string `form:"size,default=s" binding:"logosize"`
+	Format  string `form:"format,default=png" binding:"oneof=png json css"`
+}
+
+// GetSprite composes the cached logos of several symbols into one sprite sheet.
+// Route: GET /api/v1/logos/sprite?symbols=AAPL,MSFT&size=s&format=png
+//
+// format picks what comes back for the same symbols and size:
+//   - png: the sheet itself
+//   - json: where each logo sits ({"logos": [{"symbol", "x", "y"}], ...})
+//   - css: a .logo-{SYMBOL} class per logo, pointing at the png format of
+//     this same URL (so pass the key as api_key in the query, not a header,
+//     for browsers to load the image)
+//
+// Like archives, sprites only read the cache; uncached symbols get an
+// empty cell and are listed under "missing" in the JSON.
+func (h *LogoHandler) GetSprite(c *gin.Context) {
+	var req spriteRequest
+	if !bindQuery(c, &req) {
+		return
+	}
+	symbols, ok := symbolListParam(c, req.Symbols)
+	if !ok {
+		return
+	}
+	size, format := model.LogoSize(req.Size), req.Format
+
+	logos := make(map[string]service.CachedLogo, len(symbols))
+	for _, logo := range h.logoService.GetCachedLogos(c.Request.Context(), symbols, size) {
+		logos[logo.Symbol] = logo
+	}
+	sheet, err := sprite.Build(symbols, model.SizePixels[size], func(symbol string) ([]byte, error) {
+		return logos[symbol].Data, logos[symbol].Err
+	})
+	if errors.Is(err, sprite.ErrTooLarge) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error() + "; request fewer symbols or a smaller size"})
+		return
+	}
+	if err != nil {
+		h.logger.Error("building sprite", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	// The sheet changes as logos get cached, so it's only briefly cacheable.
+	c.Header("Cache-Control", "public, max-age=300")
+	switch format {
+	case "json":
+		c.JSON(http.StatusOK, sheet)
+	case "css":
+		q := c.Request.URL.Query()
+		q.Set("format", "png")
+		c.Data(http.StatusOK, "text/css; charset=utf-8", []byte(sheet.CSS(c.Request.URL.Path+"?"+q.Encode())))
+	default:
+		data, err := sheet.PNG()
+		if err != nil {
+			h.logger.Error("encoding sprite", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+			return
+		}
+		c.Data(http.StatusOK, "image/png", data)
+	}
+}
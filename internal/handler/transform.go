@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/fleveque/logo-service/internal/model"
+	"github.com/fleveque/logo-service/internal/service"
+)
+
+// transformRequest holds the query parameters of Transform.
+type transformRequest struct {
+	Sizes      string `form:"sizes,default=m"`
+	Background string `form:"bg" binding:"omitempty,rgbhex"`
+	Whiten     bool   `form:"whiten"`
+}
+
+// Transform runs an uploaded image through the logo pipeline (validation,
+// optional white background removal, square PNG at each size, optional
+// background color) and returns the result without storing anything, for
+// internal tools that want their images sized like logos. The image is
+// either multipart form field "image" or the raw request body:
+//
+//	curl -F image=@apple.svg '.../transform?sizes=s,l'
+//	curl --data-binary @apple.svg '.../transform?sizes=m&bg=ffffff'
+//
+// One size comes back as image/png, several as a zip of {size}.png.
+// Route: POST /api/v1/transform?sizes=m&bg=ffffff&whiten=true
+func (h *LogoHandler) Transform(c *gin.Context) {
+	var req transformRequest
+	if !bindQuery(c, &req) {
+		return
+	}
+	sizes, ok := sizeListParam(c, req.Sizes)
+	if !ok {
+		return
+	}
+
+	var data []byte
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		if data, ok = readUpload(c, "image", h.maxBytes); !ok {
+			return
+		}
+	} else {
+		var err error
+		data, err = io.ReadAll(io.LimitReader(c.Request.Body, int64(h.maxBytes)+1))
+		if err != nil {
+			abortInvalid(c, FieldError{Field: "image", Message: err.Error()})
+			return
+		}
+	}
+
+	renditions, err := service.Transform(data, h.maxBytes, sizes, req.Background, model.ProcessOptions{WhitenBackground: req.Whiten})
+	switch {
+	case unusableImage(err):
+		abortInvalid(c, FieldError{Field: "image", Message: "is not a usable image: " + err.Error()})
+		return
+	case err != nil:
+		h.logger.Warn("transforming image", zap.Error(err))
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "image could not be processed"})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	if len(sizes) == 1 {
+		c.Data(http.StatusOK, "image/png", renditions[sizes[0]])
+		return
+	}
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", `attachment; filename="logos.zip"`)
+	c.Status(http.StatusOK)
+	w := zipArchive{zip.NewWriter(c.Writer)}
+	for _, size := range sizes {
+		if err := w.add(string(size)+".png", renditions[size]); err != nil {
+			h.logger.Warn("writing transform archive", zap.Error(err))
+			return // Most likely the client went away
+		}
+	}
+	if err := w.Close(); err != nil {
+		h.logger.Warn("writing transform archive", zap.Error(err))
+	}
+}
+
+// sizeListParam parses a comma-separated sizes parameter, dropping
+// duplicates; "all" means every size. On failure it writes the 400
+// response itself, so callers just return.
+func sizeListParam(c *gin.Context, param string) ([]model.LogoSize, bool) {
+	if strings.TrimSpace(param) == "all" {
+		return model.AllSizes, true
+	}
+	var sizes []model.LogoSize
+	seen := make(map[string]bool)
+	for _, raw := range strings.Split(param, ",") {
+		size := strings.TrimSpace(raw)
+		if size == "" || seen[size] {
+			continue
+		}
+		if !model.ValidSize(size) {
+			abortInvalid(c, FieldError{Field: "sizes", Message: fmt.Sprintf("contains an invalid size: %q (xs, s, m, l, xl or all)", raw)})
+			return nil, false
+		}
+		seen[size] = true
+		sizes = append(sizes, model.LogoSize(size))
+	}
+	if len(sizes) == 0 {
+		abortInvalid(c, FieldError{Field: "sizes", Message: "is required, e.g. sizes=s,m"})
+		return nil, false
+	}
+	return sizes, true
+}
@@ -0,0 +1,139 @@
+package imagefmt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// Header parsers for the container formats the standard library doesn't
+// know. Like webpSize, they read just enough to learn the dimensions, and
+// every offset is bounds-checked: these bytes come from the internet.
+
+// icoEntry is one image in an ICO file's directory.
+type icoEntry struct {
+	width, height int
+	bitCount      int
+	data          []byte // PNG file or headerless BMP (DIB)
+}
+
+// largestICOEntry returns the biggest image in an ICO file. Favicons bundle
+// several sizes (16, 32, 48, 256…); the largest scales down best.
+// Ties go to the higher bit depth.
+func largestICOEntry(data []byte) (icoEntry, error) {
+	count := int(binary.LittleEndian.Uint16(data[4:6]))
+	if len(data) < 6+16*count {
+		return icoEntry{}, fmt.Errorf("%w: ico directory truncated", ErrMalformed)
+	}
+
+	var best icoEntry
+	for i := 0; i < count; i++ {
+		d := data[6+16*i : 6+16*(i+1)]
+		e := icoEntry{
+			width:    int(d[0]),
+			height:   int(d[1]),
+			bitCount: int(binary.LittleEndian.Uint16(d[6:8])),
+		}
+		// A stored 0 means 256, the format's maximum.
+		if e.width == 0 {
+			e.width = 256
+		}
+		if e.height == 0 {
+			e.height = 256
+		}
+
+		size := int(binary.LittleEndian.Uint32(d[8:12]))
+		offset := int(binary.LittleEndian.Uint32(d[12:16]))
+		if size <= 0 || offset < 0 || offset > len(data) || size > len(data)-offset {
+			return icoEntry{}, fmt.Errorf("%w: ico entry %d out of bounds", ErrMalformed, i)
+		}
+		e.data = data[offset : offset+size]
+
+		if e.width*e.height > best.width*best.height ||
+			(e.width*e.height == best.width*best.height && e.bitCount > best.bitCount) {
+			best = e
+		}
+	}
+	return best, nil
+}
+
+// bmpSize reads the dimensions from a BMP file's DIB header. A negative
+// height means the rows are stored top-down.
+func bmpSize(data []byte) (int, int, error) {
+	if len(data) < 26 {
+		return 0, 0, fmt.Errorf("%w: bmp too short", ErrMalformed)
+	}
+	if binary.LittleEndian.Uint32(data[14:18]) == 12 { // OS/2 BITMAPCOREHEADER: 16-bit sizes
+		return int(binary.LittleEndian.Uint16(data[18:20])), int(binary.LittleEndian.Uint16(data[20:22])), nil
+	}
+	w := int(int32(binary.LittleEndian.Uint32(data[18:22])))
+	h := int(int32(binary.LittleEndian.Uint32(data[22:26])))
+	if h < 0 {
+		h = -h
+	}
+	return w, h, nil
+}
+
+// tiffSize reads ImageWidth (tag 256) and ImageLength (tag 257) from the
+// first image file directory.
+func tiffSize(data []byte) (int, int, error) {
+	if len(data) < 8 {
+		return 0, 0, fmt.Errorf("%w: tiff too short", ErrMalformed)
+	}
+	var order binary.ByteOrder = binary.LittleEndian
+	if data[0] == 'M' {
+		order = binary.BigEndian
+	}
+
+	ifd := int(order.Uint32(data[4:8]))
+	if ifd < 8 || ifd > len(data)-2 {
+		return 0, 0, fmt.Errorf("%w: tiff directory out of bounds", ErrMalformed)
+	}
+	count := int(order.Uint16(data[ifd : ifd+2]))
+	if len(data) < ifd+2+12*count {
+		return 0, 0, fmt.Errorf("%w: tiff directory truncated", ErrMalformed)
+	}
+
+	var w, h int
+	for i := 0; i < count; i++ {
+		entry := data[ifd+2+12*i : ifd+2+12*(i+1)]
+		// The value is a SHORT (type 3) or LONG (type 4), stored inline.
+		var value int
+		switch order.Uint16(entry[2:4]) {
+		case 3:
+			value = int(order.Uint16(entry[8:10]))
+		case 4:
+			value = int(order.Uint32(entry[8:12]))
+		default:
+			continue
+		}
+		switch order.Uint16(entry[0:2]) {
+		case 256:
+			w = value
+		case 257:
+			h = value
+		}
+	}
+	if w == 0 || h == 0 {
+		return 0, 0, fmt.Errorf("%w: tiff without dimensions", ErrMalformed)
+	}
+	return w, h, nil
+}
+
+// heicSize finds the first "ispe" (image spatial extents) property, which
+// holds the primary image's width and height. Walking the full box tree
+// (meta → iprp → ipco) would be more exact, but ispe only appears there.
+func heicSize(data []byte) (int, int, error) {
+	head := data
+	if len(head) > 64<<10 {
+		head = head[:64<<10]
+	}
+	i := bytes.Index(head, []byte("ispe"))
+	// "ispe", then 4 bytes of version/flags, then 32-bit width and height.
+	if i < 0 || i+16 > len(head) {
+		return 0, 0, fmt.Errorf("%w: heic without image extents", ErrMalformed)
+	}
+	w := int(binary.BigEndian.Uint32(head[i+8 : i+12]))
+	h := int(binary.BigEndian.Uint32(head[i+12 : i+16]))
+	return w, h, nil
+}
@@ -0,0 +1,185 @@
+package imagefmt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// ToPNG converts formats libvips can't load by itself to PNG: an ICO becomes
+// its largest entry, a BMP is decoded here. Other formats are returned as is.
+// Call it after Validate, which has already bounded the dimensions.
+func ToPNG(data []byte, format Format) ([]byte, error) {
+	switch format {
+	case ICO:
+		entry, err := largestICOEntry(data)
+		if err != nil {
+			return nil, err
+		}
+		// Modern favicons embed their large sizes as PNG files.
+		if Detect(entry.data) == PNG {
+			return entry.data, nil
+		}
+		// Otherwise it's a BMP without its file header, with a transparency mask.
+		img, err := decodeDIB(entry.data, nil, true)
+		if err != nil {
+			return nil, fmt.Errorf("ico entry %dx%d: %w", entry.width, entry.height, err)
+		}
+		return encodePNG(img)
+	case BMP:
+		if len(data) < 14 {
+			return nil, fmt.Errorf("%w: bmp too short", ErrMalformed)
+		}
+		offset := int(binary.LittleEndian.Uint32(data[10:14]))
+		if offset < 14 || offset > len(data) {
+			return nil, fmt.Errorf("%w: bmp pixel offset out of bounds", ErrMalformed)
+		}
+		img, err := decodeDIB(data[14:], data[offset:], false)
+		if err != nil {
+			return nil, fmt.Errorf("bmp: %w", err)
+		}
+		return encodePNG(img)
+	}
+	return data, nil
+}
+
+func encodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("encoding png: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeDIB decodes an uncompressed device-independent bitmap: the part of a
+// BMP file after its 14-byte file header, and the format of non-PNG ICO entries.
+//
+// pixels is where the pixel rows start; nil means right after the header and
+// palette, as in ICO entries. In an ICO entry the height is doubled and a
+// 1-bit AND mask (1 = transparent) follows the color rows.
+func decodeDIB(dib, pixels []byte, icoMask bool) (*image.NRGBA, error) {
+	if len(dib) < 40 {
+		return nil, fmt.Errorf("%w: unsupported bitmap header (%d bytes)", ErrUnsupported, len(dib))
+	}
+	headerSize := int(binary.LittleEndian.Uint32(dib[0:4]))
+	if headerSize < 40 || headerSize > len(dib) {
+		return nil, fmt.Errorf("%w: unsupported bitmap header (%d bytes)", ErrUnsupported, headerSize)
+	}
+
+	width := int(int32(binary.LittleEndian.Uint32(dib[4:8])))
+	height := int(int32(binary.LittleEndian.Uint32(dib[8:12])))
+	bitCount := int(binary.LittleEndian.Uint16(dib[14:16]))
+	compression := binary.LittleEndian.Uint32(dib[16:20])
+	colorsUsed := int(binary.LittleEndian.Uint32(dib[32:36]))
+
+	topDown := height < 0
+	if topDown {
+		height = -height
+	}
+	if icoMask {
+		height /= 2
+	}
+	if width <= 0 || height <= 0 || width > MaxPixels || height > MaxPixels || width*height > MaxPixels {
+		return nil, fmt.Errorf("%w: bitmap %dx%d", ErrMalformed, width, height)
+	}
+
+	// 0 is BI_RGB (uncompressed). 3 (BI_BITFIELDS) with 32 bits is, in
+	// practice, plain BGRA. RLE and embedded JPEG/PNG bitmaps are rare enough to refuse.
+	if compression != 0 && !(compression == 3 && bitCount == 32) {
+		return nil, fmt.Errorf("%w: compressed bitmap (compression %d)", ErrUnsupported, compression)
+	}
+
+	var palette []color.NRGBA
+	offset := headerSize
+	if compression == 3 && headerSize == 40 {
+		offset += 12 // Color masks follow a plain BITMAPINFOHEADER
+	}
+	switch bitCount {
+	case 1, 4, 8:
+		n := colorsUsed
+		if n == 0 || n > 1<<bitCount {
+			n = 1 << bitCount
+		}
+		if len(dib) < offset+4*n {
+			return nil, fmt.Errorf("%w: bitmap palette truncated", ErrMalformed)
+		}
+		palette = make([]color.NRGBA, n)
+		for i := range palette {
+			p := dib[offset+4*i:]
+			palette[i] = color.NRGBA{R: p[2], G: p[1], B: p[0], A: 0xFF}
+		}
+		offset += 4 * n
+	case 24, 32:
+	default:
+		return nil, fmt.Errorf("%w: %d-bit bitmap", ErrUnsupported, bitCount)
+	}
+	if pixels == nil {
+		pixels = dib[offset:]
+	}
+
+	// Rows are padded to 4 bytes.
+	stride := (width*bitCount + 31) / 32 * 4
+	maskStride := (width + 31) / 32 * 4
+	need := stride * height
+	if icoMask {
+		need += maskStride * height
+	}
+	if len(pixels) < need {
+		return nil, fmt.Errorf("%w: bitmap pixel data truncated", ErrMalformed)
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	hasAlpha := false
+	for y := 0; y < height; y++ {
+		row := pixels[y*stride : (y+1)*stride]
+		// Bottom-up unless the height was negative.
+		dy := height - 1 - y
+		if topDown {
+			dy = y
+		}
+		for x := 0; x < width; x++ {
+			var c color.NRGBA
+			switch bitCount {
+			case 32:
+				c = color.NRGBA{R: row[4*x+2], G: row[4*x+1], B: row[4*x], A: row[4*x+3]}
+				hasAlpha = hasAlpha || c.A != 0
+			case 24:
+				c = color.NRGBA{R: row[3*x+2], G: row[3*x+1], B: row[3*x], A: 0xFF}
+			default:
+				perByte := 8 / bitCount
+				shift := uint(8 - bitCount*(x%perByte+1))
+				index := int(row[x/perByte]>>shift) & (1<<bitCount - 1)
+				if index < len(palette) {
+					c = palette[index]
+				}
+			}
+			img.SetNRGBA(x, dy, c)
+		}
+	}
+
+	// 32-bit bitmaps often leave the alpha byte at zero: that means opaque,
+	// not invisible.
+	if bitCount == 32 && !hasAlpha {
+		for i := 3; i < len(img.Pix); i += 4 {
+			img.Pix[i] = 0xFF
+		}
+	}
+
+	// The AND mask carries the transparency of ICO entries without an alpha channel.
+	if icoMask && !(bitCount == 32 && hasAlpha) {
+		mask := pixels[stride*height:]
+		for y := 0; y < height; y++ {
+			row := mask[y*maskStride : (y+1)*maskStride]
+			dy := height - 1 - y
+			for x := 0; x < width; x++ {
+				if row[x/8]&(0x80>>uint(x%8)) != 0 {
+					img.SetNRGBA(x, dy, color.NRGBA{})
+				}
+			}
+		}
+	}
+	return img, nil
+}
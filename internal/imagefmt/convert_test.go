@@ -0,0 +1,208 @@
+package imagefmt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+// dibHeader builds a 40-byte BITMAPINFOHEADER.
+func dibHeader(w, h, bitCount int) []byte {
+	hdr := make([]byte, 40)
+	binary.LittleEndian.PutUint32(hdr[0:], 40)
+	binary.LittleEndian.PutUint32(hdr[4:], uint32(int32(w)))
+	binary.LittleEndian.PutUint32(hdr[8:], uint32(int32(h)))
+	binary.LittleEndian.PutUint16(hdr[12:], 1)
+	binary.LittleEndian.PutUint16(hdr[14:], uint16(bitCount))
+	return hdr
+}
+
+// dib32 is a w×h ICO entry in 32-bit BGRA: the top-left pixel is opaque
+// red, everything else fully transparent. The AND mask is all zeros.
+func dib32(w, h int) []byte {
+	data := dibHeader(w, h*2, 32)
+	for y := h - 1; y >= 0; y-- { // Bottom-up
+		for x := 0; x < w; x++ {
+			if x == 0 && y == 0 {
+				data = append(data, 0, 0, 0xFF, 0xFF)
+			} else {
+				data = append(data, 0, 0, 0, 0)
+			}
+		}
+	}
+	return append(data, make([]byte, (w+31)/32*4*h)...)
+}
+
+// testICO wraps entries (PNG files or DIBs) in an ICO directory.
+func testICO(t testing.TB, entries ...[]byte) []byte {
+	t.Helper()
+	data := []byte{0, 0, 1, 0, byte(len(entries)), 0}
+	offset := 6 + 16*len(entries)
+	var body []byte
+	for _, e := range entries {
+		w, h := 16, 16
+		if Detect(e) == PNG {
+			cfg, err := png.DecodeConfig(bytes.NewReader(e))
+			if err != nil {
+				t.Fatal(err)
+			}
+			w, h = cfg.Width, cfg.Height
+		} else {
+			w = int(binary.LittleEndian.Uint32(e[4:8]))
+			h = int(binary.LittleEndian.Uint32(e[8:12])) / 2
+		}
+		dir := make([]byte, 16)
+		dir[0], dir[1] = byte(w), byte(h) // 256 wraps to 0, as in real files
+		binary.LittleEndian.PutUint16(dir[6:], 32)
+		binary.LittleEndian.PutUint32(dir[8:], uint32(len(e)))
+		binary.LittleEndian.PutUint32(dir[12:], uint32(offset+len(body)))
+		data = append(data, dir...)
+		body = append(body, e...)
+	}
+	return append(data, body...)
+}
+
+// testBMP is a 2×1 24-bit BMP: a blue pixel, then a green one.
+func testBMP(t testing.TB) []byte {
+	t.Helper()
+	pixels := []byte{0xFF, 0, 0, 0, 0xFF, 0, 0, 0} // BGR BGR + 2 bytes of row padding
+	file := make([]byte, 14)
+	copy(file, "BM")
+	binary.LittleEndian.PutUint32(file[2:], uint32(14+40+len(pixels)))
+	binary.LittleEndian.PutUint32(file[10:], 14+40)
+	file = append(file, dibHeader(2, 1, 24)...)
+	return append(file, pixels...)
+}
+
+// testTIFF is a little-endian TIFF header whose first directory holds only
+// the image size, as LONG values.
+func testTIFF(w, h uint32) []byte {
+	data := []byte("II*\x00\x08\x00\x00\x00\x02\x00")
+	for _, tag := range []struct {
+		id    uint16
+		value uint32
+	}{{256, w}, {257, h}} {
+		entry := make([]byte, 12)
+		binary.LittleEndian.PutUint16(entry[0:], tag.id)
+		binary.LittleEndian.PutUint16(entry[2:], 4)
+		binary.LittleEndian.PutUint32(entry[4:], 1)
+		binary.LittleEndian.PutUint32(entry[8:], tag.value)
+		data = append(data, entry...)
+	}
+	return data
+}
+
+// testHEIC is an ftyp box followed by an ispe property.
+func testHEIC(w, h uint32) []byte {
+	data := []byte("\x00\x00\x00\x18ftypheic\x00\x00\x00\x00mif1heic")
+	ispe := []byte("\x00\x00\x00\x14ispe\x00\x00\x00\x00")
+	ispe = binary.BigEndian.AppendUint32(ispe, w)
+	ispe = binary.BigEndian.AppendUint32(ispe, h)
+	return append(data, ispe...)
+}
+
+func decodePNG(t *testing.T, data []byte) image.Image {
+	t.Helper()
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("output is not a PNG: %v", err)
+	}
+	return img
+}
+
+func TestToPNG_ICOPicksLargestEntry(t *testing.T) {
+	large := testPNG(t, 48, 48)
+	ico := testICO(t, dib32(16, 16), large, dib32(32, 32))
+
+	out, err := ToPNG(ico, ICO)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// PNG entries are passed through untouched.
+	if !bytes.Equal(out, large) {
+		t.Errorf("expected the 48×48 PNG entry, got %d bytes", len(out))
+	}
+}
+
+func TestToPNG_ICOBitmapEntry(t *testing.T) {
+	out, err := ToPNG(testICO(t, dib32(2, 2)), ICO)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img := decodePNG(t, out)
+
+	if got := color.NRGBAModel.Convert(img.At(0, 0)).(color.NRGBA); got != (color.NRGBA{R: 0xFF, A: 0xFF}) {
+		t.Errorf("top-left = %v, want opaque red (rows are stored bottom-up)", got)
+	}
+	if _, _, _, a := img.At(1, 1).RGBA(); a != 0 {
+		t.Errorf("bottom-right alpha = %d, want transparent", a)
+	}
+}
+
+func TestToPNG_ICOPaletteWithMask(t *testing.T) {
+	// 2×2, 4-bit: palette [black, blue]; every pixel is blue, and the AND
+	// mask hides the bottom-left one.
+	entry := dibHeader(2, 4, 4)
+	binary.LittleEndian.PutUint32(entry[32:], 2)        // Colors used
+	entry = append(entry, 0, 0, 0, 0, 0xFF, 0, 0, 0)    // Palette (BGRX)
+	entry = append(entry, 0x11, 0, 0, 0, 0x11, 0, 0, 0) // Rows, bottom-up
+	entry = append(entry, 0x80, 0, 0, 0, 0x00, 0, 0, 0) // AND mask, bottom-up
+
+	out, err := ToPNG(testICO(t, entry), ICO)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img := decodePNG(t, out)
+
+	if got := color.NRGBAModel.Convert(img.At(0, 0)).(color.NRGBA); got != (color.NRGBA{B: 0xFF, A: 0xFF}) {
+		t.Errorf("top-left = %v, want opaque blue", got)
+	}
+	if _, _, _, a := img.At(0, 1).RGBA(); a != 0 {
+		t.Errorf("bottom-left alpha = %d, want masked out", a)
+	}
+}
+
+func TestToPNG_BMP(t *testing.T) {
+	out, err := ToPNG(testBMP(t), BMP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img := decodePNG(t, out)
+
+	if got := color.NRGBAModel.Convert(img.At(0, 0)).(color.NRGBA); got != (color.NRGBA{B: 0xFF, A: 0xFF}) {
+		t.Errorf("pixel 0 = %v, want blue", got)
+	}
+	if got := color.NRGBAModel.Convert(img.At(1, 0)).(color.NRGBA); got != (color.NRGBA{G: 0xFF, A: 0xFF}) {
+		t.Errorf("pixel 1 = %v, want green", got)
+	}
+}
+
+func TestToPNG_Errors(t *testing.T) {
+	rle := testBMP(t)
+	binary.LittleEndian.PutUint32(rle[14+16:], 1) // BI_RLE8
+
+	truncated := testBMP(t)
+	truncated = truncated[:len(truncated)-4]
+
+	tests := []struct {
+		name    string
+		data    []byte
+		format  Format
+		wantErr error
+	}{
+		{"compressed bmp", rle, BMP, ErrUnsupported},
+		{"truncated bmp", truncated, BMP, ErrMalformed},
+		{"ico entry truncated", testICO(t, dib32(2, 2)[:50]), ICO, ErrMalformed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ToPNG(tt.data, tt.format); !errors.Is(err, tt.wantErr) {
+				t.Errorf("ToPNG() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+// Package imagefmt identifies image formats from their bytes and rejects
+// inputs that shouldn't reach libvips: unknown formats, truncated headers,
+// absurd dimensions (decompression bombs). It's pure Go on purpose — a
+// malformed file should fail here with an error, not inside CGO.
+package imagefmt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+
+	// Blank imports register decoders with the image package, so
+	// image.DecodeConfig can read their headers. Only headers are read —
+	// decoding whole images is libvips' job.
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+// Format is an image container format detected from magic bytes.
+type Format string
+
+const (
+	Unknown Format = ""
+	PNG     Format = "png"
+	JPEG    Format = "jpeg"
+	GIF     Format = "gif"
+	WebP    Format = "webp"
+	SVG     Format = "svg"
+	ICO     Format = "ico"  // Favicons; converted to PNG (see ToPNG)
+	BMP     Format = "bmp"  // Converted to PNG: libvips only loads it through ImageMagick
+	TIFF    Format = "tiff" // Loaded by libvips directly
+	HEIC    Format = "heic" // Needs libvips built with libheif
+)
+
+// MaxBytes is the default cap on an input we'll hand to libvips, and on
+// what providers download. processing.max_fetch_size replaces it: code that
+// was given a limit passes that to Validate instead.
+const MaxBytes = 10 << 20
+
+// MaxPixels bounds decoded size: ~6300×6300, logos never need more.
+const MaxPixels = 40_000_000
+
+var (
+	ErrEmpty       = errors.New("image is empty")
+	ErrTooLarge    = errors.New("image is too large")
+	ErrUnsupported = errors.New("unsupported image format")
+	ErrMalformed   = errors.New("malformed image header")
+)
+
+// Detect returns the format of data based on its leading bytes.
+func Detect(data []byte) Format {
+	switch {
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return PNG
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8, 0xFF}):
+		return JPEG
+	case bytes.HasPrefix(data, []byte("GIF87a")), bytes.HasPrefix(data, []byte("GIF89a")):
+		return GIF
+	case len(data) >= 12 && bytes.Equal(data[0:4], []byte("RIFF")) && bytes.Equal(data[8:12], []byte("WEBP")):
+		return WebP
+	case isSVG(data):
+		return SVG
+	case len(data) >= 6 && bytes.Equal(data[0:4], []byte{0, 0, 1, 0}) && binary.LittleEndian.Uint16(data[4:6]) > 0:
+		return ICO
+	case isBMP(data):
+		return BMP
+	case bytes.HasPrefix(data, []byte("II*\x00")), bytes.HasPrefix(data, []byte("MM\x00*")):
+		return TIFF
+	case isHEIC(data):
+		return HEIC
+	}
+	return Unknown
+}
+
+// isBMP checks the "BM" signature and a known DIB header size, since two
+// letters alone would match plenty of text.
+func isBMP(data []byte) bool {
+	if len(data) < 18 || !bytes.HasPrefix(data, []byte("BM")) {
+		return false
+	}
+	switch binary.LittleEndian.Uint32(data[14:18]) {
+	case 12, 40, 52, 56, 108, 124:
+		return true
+	}
+	return false
+}
+
+// isHEIC checks for an ISO-BMFF "ftyp" box with a HEIF image brand.
+// AVIF shares the container but has its own brand, so it isn't matched.
+func isHEIC(data []byte) bool {
+	if len(data) < 12 || string(data[4:8]) != "ftyp" {
+		return false
+	}
+	switch string(data[8:12]) {
+	case "heic", "heix", "hevc", "hevx", "heim", "heis", "mif1", "msf1":
+		return true
+	}
+	return false
+}
+
+// isSVG looks for an <svg element near the start, after an optional BOM,
+// XML declaration, comments or doctype.
+func isSVG(data []byte) bool {
+	head := data
+	if len(head) > 1024 {
+		head = head[:1024]
+	}
+	head = bytes.TrimPrefix(head, []byte("\xEF\xBB\xBF"))
+	head = bytes.TrimLeft(head, " \t\r\n")
+	if !bytes.HasPrefix(head, []byte("<")) {
+		return false
+	}
+	return bytes.Contains(head, []byte("<svg"))
+}
+
+// Info describes an image as read from its header.
+type Info struct {
+	Format Format
+	Width  int // 0 for SVG: vectors have no pixel size until rendered
+	Height int
+}
+
+// Validate checks that data is a supported image of at most maxBytes with
+// sane dimensions and returns its format. Call it before passing untrusted
+// bytes to libvips.
+func Validate(data []byte, maxBytes int) (Format, error) {
+	if len(data) > maxBytes {
+		return Unknown, fmt.Errorf("%w: %d bytes (max %d)", ErrTooLarge, len(data), maxBytes)
+	}
+	info, err := Inspect(data)
+	return info.Format, err
+}
+
+// Inspect is Validate without the byte limit, for images already read
+// within one, that also returns the dimensions it read.
+func Inspect(data []byte) (Info, error) {
+	if len(data) == 0 {
+		return Info{}, ErrEmpty
+	}
+
+	format := Detect(data)
+	var width, height int
+	switch format {
+	case PNG, JPEG, GIF:
+		cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			return Info{Format: format}, fmt.Errorf("%w: %v", ErrMalformed, err)
+		}
+		width, height = cfg.Width, cfg.Height
+	case WebP:
+		w, h, err := webpSize(data)
+		if err != nil {
+			return Info{Format: format}, err
+		}
+		width, height = w, h
+	case SVG:
+		// Vector: no pixel dimensions until rendered at the size we ask for.
+		return Info{Format: format}, nil
+	case ICO:
+		entry, err := largestICOEntry(data)
+		if err != nil {
+			return Info{Format: format}, err
+		}
+		width, height = entry.width, entry.height
+	case BMP:
+		w, h, err := bmpSize(data)
+		if err != nil {
+			return Info{Format: format}, err
+		}
+		width, height = w, h
+	case TIFF:
+		w, h, err := tiffSize(data)
+		if err != nil {
+			return Info{Format: format}, err
+		}
+		width, height = w, h
+	case HEIC:
+		w, h, err := heicSize(data)
+		if err != nil {
+			return Info{Format: format}, err
+		}
+		width, height = w, h
+	default:
+		return Info{}, ErrUnsupported
+	}
+
+	if width <= 0 || height <= 0 {
+		return Info{Format: format}, fmt.Errorf("%w: %dx%d", ErrMalformed, width, height)
+	}
+	// Check each side first: two 32-bit sizes can overflow the product.
+	if width > MaxPixels || height > MaxPixels || width*height > MaxPixels {
+		return Info{Format: format}, fmt.Errorf("%w: %dx%d pixels", ErrTooLarge, width, height)
+	}
+	return Info{Format: format, Width: width, Height: height}, nil
+}
+
+// webpSize reads the canvas size from the first chunk of a WebP file.
+// The standard library has no WebP decoder, and the header is simple enough
+// not to pull in golang.org/x/image for it.
+func webpSize(data []byte) (int, int, error) {
+	if len(data) < 30 {
+		return 0, 0, fmt.Errorf("%w: webp too short", ErrMalformed)
+	}
+
+	switch string(data[12:16]) {
+	case "VP8X": // Extended: 24-bit little-endian width-1 and height-1
+		w := int(data[24]) | int(data[25])<<8 | int(data[26])<<16
+		h := int(data[27]) | int(data[28])<<8 | int(data[29])<<16
+		return w + 1, h + 1, nil
+	case "VP8 ": // Lossy: 14-bit dimensions after the 3-byte start code
+		if !bytes.Equal(data[23:26], []byte{0x9D, 0x01, 0x2A}) {
+			return 0, 0, fmt.Errorf("%w: bad VP8 start code", ErrMalformed)
+		}
+		w := int(binary.LittleEndian.Uint16(data[26:28]) & 0x3FFF)
+		h := int(binary.LittleEndian.Uint16(data[28:30]) & 0x3FFF)
+		return w, h, nil
+	case "VP8L": // Lossless: signature byte, then 14-bit width-1 and height-1 packed
+		if data[20] != 0x2F {
+			return 0, 0, fmt.Errorf("%w: bad VP8L signature", ErrMalformed)
+		}
+		bits := binary.LittleEndian.Uint32(data[21:25])
+		return int(bits&0x3FFF) + 1, int((bits>>14)&0x3FFF) + 1, nil
+	}
+	return 0, 0, fmt.Errorf("%w: unknown webp chunk %q", ErrMalformed, data[12:16])
+}
@@ -0,0 +1,150 @@
+package imagefmt
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testPNG(t testing.TB, w, h int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	img.Set(0, 0, color.NRGBA{R: 255, A: 255})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want Format
+	}{
+		{"png", testPNG(t, 2, 2), PNG},
+		{"jpeg", []byte{0xFF, 0xD8, 0xFF, 0xE0}, JPEG},
+		{"gif", []byte("GIF89a\x01\x00\x01\x00"), GIF},
+		{"webp", []byte("RIFF\x00\x00\x00\x00WEBPVP8 "), WebP},
+		{"svg", []byte(`<svg xmlns="http://www.w3.org/2000/svg"/>`), SVG},
+		{"svg with prolog", []byte("\xEF\xBB\xBF<?xml version=\"1.0\"?>\n<!-- logo -->\n<svg/>"), SVG},
+		{"ico", []byte{0, 0, 1, 0, 1, 0}, ICO},
+		{"bmp", testBMP(t), BMP},
+		{"tiff little-endian", []byte("II*\x00\x08\x00\x00\x00"), TIFF},
+		{"tiff big-endian", []byte("MM\x00*\x00\x00\x00\x08"), TIFF},
+		{"heic", []byte("\x00\x00\x00\x18ftypheic\x00\x00\x00\x00"), HEIC},
+		{"avif", []byte("\x00\x00\x00\x18ftypavif\x00\x00\x00\x00"), Unknown},
+		{"text starting with BM", []byte("BMW annual report, page 1"), Unknown},
+		{"html", []byte("<!DOCTYPE html><html><body>Not found</body></html>"), Unknown},
+		{"text", []byte("hello"), Unknown},
+		{"empty", nil, Unknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Detect(tt.data); got != tt.want {
+				t.Errorf("Detect() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	// VP8X header for a 3000×2000 canvas (stored as width-1, height-1).
+	webp := []byte("RIFF\x00\x00\x00\x00WEBPVP8X\x0a\x00\x00\x00\x00\x00\x00\x00\xb7\x0b\x00\xcf\x07\x00")
+
+	tests := []struct {
+		name    string
+		data    []byte
+		want    Format
+		wantErr error
+	}{
+		{"valid png", testPNG(t, 4, 4), PNG, nil},
+		{"valid webp", webp, WebP, nil},
+		{"svg", []byte("<svg/>"), SVG, nil},
+		{"ico", testICO(t, dib32(2, 2)), ICO, nil},
+		{"ico entry out of bounds", testICO(t, dib32(2, 2))[:30], ICO, ErrMalformed},
+		{"bmp", testBMP(t), BMP, nil},
+		{"tiff", testTIFF(300, 200), TIFF, nil},
+		{"tiff bomb", testTIFF(100000, 100000), TIFF, ErrTooLarge},
+		{"tiff overflowing sizes", testTIFF(0xFFFFFFFF, 0xFFFFFFFF), TIFF, ErrTooLarge},
+		{"heic", testHEIC(4032, 3024), HEIC, nil},
+		{"heic without extents", []byte("\x00\x00\x00\x18ftypheic\x00\x00\x00\x00"), HEIC, ErrMalformed},
+		{"empty", nil, Unknown, ErrEmpty},
+		{"html error page", []byte("<html>404</html>"), Unknown, ErrUnsupported},
+		{"truncated png", testPNG(t, 4, 4)[:20], PNG, ErrMalformed},
+		{"oversized", append(testPNG(t, 1, 1), make([]byte, MaxBytes)...), Unknown, ErrTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Validate(tt.data, MaxBytes)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Validate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate_DecompressionBomb(t *testing.T) {
+	// A PNG header claiming 100000×100000 pixels, with no real data behind it.
+	data := testPNG(t, 1, 1)
+	copy(data[16:24], []byte{0x00, 0x01, 0x86, 0xA0, 0x00, 0x01, 0x86, 0xA0})
+
+	if _, err := Validate(data, MaxBytes); !errors.Is(err, ErrTooLarge) && !errors.Is(err, ErrMalformed) {
+		t.Errorf("expected bomb to be rejected, got %v", err)
+	}
+}
+
+func TestValidate_Limit(t *testing.T) {
+	data := testPNG(t, 4, 4)
+	if _, err := Validate(data, len(data)); err != nil {
+		t.Errorf("expected an image at the limit to pass, got %v", err)
+	}
+	if _, err := Validate(data, len(data)-1); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("expected ErrTooLarge past the limit, got %v", err)
+	}
+}
+
+// FuzzValidate feeds arbitrary bytes through format detection, header parsing
+// and ICO/BMP conversion.
+// Run with: go test ./internal/imagefmt -fuzz FuzzValidate
+func FuzzValidate(f *testing.F) {
+	f.Add(testPNG(f, 3, 3))
+	f.Add([]byte("<svg/>"))
+	f.Add([]byte("RIFF\x00\x00\x00\x00WEBPVP8L\x00\x00\x00\x00\x2f\x00\x00\x00\x00"))
+	f.Add(testICO(f, dib32(2, 2)))
+	f.Add(testBMP(f))
+	f.Add(testTIFF(3, 3))
+	if fixtures, err := filepath.Glob("../service/testdata/fixtures/*"); err == nil {
+		for _, path := range fixtures {
+			if data, err := os.ReadFile(path); err == nil {
+				f.Add(data)
+			}
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		format, err := Validate(data, MaxBytes)
+		if err == nil && format == Unknown {
+			t.Fatalf("accepted data with unknown format")
+		}
+		if err == nil && Detect(data) != format {
+			t.Fatalf("Validate and Detect disagree: %q vs %q", format, Detect(data))
+		}
+		// Conversion runs on validated input only, and must not panic on it.
+		if err == nil {
+			_, _ = ToPNG(data, format)
+		}
+		// The quality estimate walks JPEG segments and must not panic on any input.
+		_, _ = JPEGQuality(data)
+	})
+}
@@ -0,0 +1,84 @@
+package imagefmt
+
+import "encoding/binary"
+
+// stdLuminance is the luminance quantization table from the JPEG spec
+// (Annex K), which libjpeg scales for its quality setting.
+var stdLuminance = [64]int{
+	16, 11, 10, 16, 24, 40, 51, 61,
+	12, 12, 14, 19, 26, 58, 60, 55,
+	14, 13, 16, 24, 40, 57, 69, 56,
+	14, 17, 22, 29, 51, 87, 80, 62,
+	18, 22, 37, 56, 68, 109, 103, 77,
+	24, 35, 55, 64, 81, 104, 113, 92,
+	49, 64, 78, 87, 103, 121, 120, 101,
+	72, 92, 95, 98, 112, 100, 103, 99,
+}
+
+// JPEGQuality estimates the quality setting (1–100) a JPEG was saved with,
+// by comparing its luminance quantization table with the one libjpeg scales.
+// Coarser tables mean more compression artifacts. ok is false when data
+// isn't a JPEG or has no luminance table before the image data.
+//
+// It's an estimate: encoders with custom tables (Photoshop, mozjpeg) land
+// near, not on, the quality they were asked for.
+func JPEGQuality(data []byte) (quality int, ok bool) {
+	if Detect(data) != JPEG {
+		return 0, false
+	}
+
+	// Walk the marker segments: 0xFF, marker, 2-byte length (including itself).
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return 0, false
+		}
+		marker := data[i+1]
+		length := int(binary.BigEndian.Uint16(data[i+2 : i+4]))
+		if marker == 0xDA || length < 2 || i+2+length > len(data) { // Start of scan: no tables after it
+			return 0, false
+		}
+
+		if marker == 0xDB { // DQT: one or more tables, each a Pq/Tq byte and 64 entries
+			segment := data[i+4 : i+2+length]
+			for len(segment) > 0 {
+				precision, id := segment[0]>>4, segment[0]&0x0F
+				size := 64
+				if precision == 1 {
+					size = 128
+				}
+				if len(segment) < 1+size {
+					return 0, false
+				}
+				if id == 0 {
+					return scaleToQuality(segment[1:1+size], precision == 1), true
+				}
+				segment = segment[1+size:]
+			}
+		}
+		i += 2 + length
+	}
+	return 0, false
+}
+
+// scaleToQuality inverts libjpeg's quality scaling: quality q < 50 scales
+// the standard table by 5000/q percent, q ≥ 50 by 200-2q percent.
+func scaleToQuality(table []byte, wide bool) int {
+	var sum, std int
+	for i := 0; i < 64; i++ {
+		v := int(table[i])
+		if wide {
+			v = int(binary.BigEndian.Uint16(table[2*i:]))
+		}
+		sum += v
+		std += stdLuminance[i]
+	}
+
+	scale := float64(sum) * 100 / float64(std)
+	var q float64
+	if scale <= 100 {
+		q = (200 - scale) / 2
+	} else {
+		q = 5000 / scale
+	}
+	return max(1, min(100, int(q+0.5)))
+}
@@ -0,0 +1,35 @@
+package imagefmt
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"testing"
+)
+
+func TestJPEGQuality(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+
+	// Go's encoder scales the standard tables the way libjpeg does, so the
+	// estimate should land on (or right next to) the requested quality.
+	for _, want := range []int{20, 50, 75, 90} {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: want}); err != nil {
+			t.Fatalf("encoding q%d: %v", want, err)
+		}
+		got, ok := JPEGQuality(buf.Bytes())
+		if !ok {
+			t.Fatalf("q%d: no quality found", want)
+		}
+		if got < want-2 || got > want+2 {
+			t.Errorf("q%d: estimated %d", want, got)
+		}
+	}
+
+	if _, ok := JPEGQuality(testPNG(t, 4, 4)); ok {
+		t.Error("a PNG has no JPEG quality")
+	}
+	if _, ok := JPEGQuality([]byte{0xFF, 0xD8, 0xFF, 0xDB, 0x00}); ok {
+		t.Error("expected a truncated JPEG to report no quality")
+	}
+}
@@ -0,0 +1,230 @@
+package imagefmt
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrUnsafeSVG is returned for an SVG that can't be made safe to store and
+// render: not well-formed XML, declaring entities, or not an <svg> document.
+var ErrUnsafeSVG = errors.New("svg cannot be sanitized")
+
+// unsafeSVGElements are dropped along with everything inside them: scripts,
+// and elements that embed other documents (foreignObject carries HTML).
+var unsafeSVGElements = map[string]bool{
+	"script":        true,
+	"foreignobject": true,
+	"iframe":        true,
+	"embed":         true,
+	"object":        true,
+	"handler":       true,
+}
+
+// SanitizeSVG rewrites an SVG keeping only what draws it. Scripts and
+// embedded documents are removed, and so are event handler attributes
+// (onload...) and anything referring outside the file: hrefs other than
+// "#fragment" or an inline raster image, url() in attributes and styles,
+// @import, stylesheets and doctypes. Comments go too. The result is UTF-8
+// without an XML declaration, and sanitizing it again changes nothing.
+//
+// libvips renders SVGs with librsvg, which resolves external references
+// itself: left in, they would be fetched from wherever the file says.
+//
+// Go note: RawToken, unlike Token, leaves namespace prefixes as written
+// (xlink:href stays xlink:href), so the output can be written back as is.
+// It doesn't check that end tags match, so we keep our own stack.
+func SanitizeSVG(data []byte) ([]byte, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.CharsetReader = charsetReader
+
+	var out bytes.Buffer
+	var stack []xml.Name
+	skip := 0      // > 0 inside a dropped element
+	var css []byte // The text of the <style> being read
+	sawRoot := false
+	for {
+		tok, err := dec.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrUnsafeSVG, err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if len(stack) == 0 {
+				if sawRoot || !strings.EqualFold(t.Name.Local, "svg") {
+					return nil, fmt.Errorf("%w: root element <%s> is not <svg>", ErrUnsafeSVG, qualifiedName(t.Name))
+				}
+				sawRoot = true
+			}
+			// Elements inside a <style> go, so its text is all one stylesheet.
+			inStyle := len(stack) > 0 && strings.EqualFold(stack[len(stack)-1].Local, "style")
+			stack = append(stack, t.Name)
+			if skip > 0 || inStyle || unsafeSVGElement(t) {
+				skip++
+				continue
+			}
+			writeStartElement(&out, t)
+		case xml.EndElement:
+			if len(stack) == 0 || stack[len(stack)-1] != t.Name {
+				return nil, fmt.Errorf("%w: unexpected </%s>", ErrUnsafeSVG, qualifiedName(t.Name))
+			}
+			stack = stack[:len(stack)-1]
+			if skip > 0 {
+				skip--
+				continue
+			}
+			// A stylesheet that imports or fetches anything is dropped whole.
+			// It's checked once complete: comments between its pieces are
+			// dropped, which would join "@imp" and "ort" into "@import".
+			if strings.EqualFold(t.Name.Local, "style") {
+				if localCSS(string(css)) {
+					_ = xml.EscapeText(&out, css)
+				}
+				css = css[:0]
+			}
+			out.WriteString("</" + qualifiedName(t.Name) + ">")
+		case xml.CharData:
+			if skip > 0 || len(stack) == 0 {
+				continue
+			}
+			if strings.EqualFold(stack[len(stack)-1].Local, "style") {
+				css = append(css, t...)
+				continue
+			}
+			_ = xml.EscapeText(&out, t)
+		case xml.Directive:
+			// Entities are how XML bombs and external entity reads work.
+			if bytes.Contains(t, []byte("ENTITY")) {
+				return nil, fmt.Errorf("%w: declares entities", ErrUnsafeSVG)
+			}
+		}
+		// Comments, processing instructions (the XML declaration,
+		// xml-stylesheet) and other directives are dropped.
+	}
+
+	if !sawRoot {
+		return nil, fmt.Errorf("%w: no <svg> element", ErrUnsafeSVG)
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("%w: <%s> is never closed", ErrUnsafeSVG, qualifiedName(stack[len(stack)-1]))
+	}
+	return out.Bytes(), nil
+}
+
+// unsafeSVGElement reports whether an element is dropped whole: one of
+// unsafeSVGElements, or an animation rewriting an href (to javascript:...).
+func unsafeSVGElement(t xml.StartElement) bool {
+	local := strings.ToLower(t.Name.Local)
+	if unsafeSVGElements[local] {
+		return true
+	}
+	if local == "set" || local == "animate" {
+		for _, a := range t.Attr {
+			if a.Name.Local == "attributeName" && strings.HasSuffix(strings.ToLower(a.Value), "href") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// writeStartElement writes an element's start tag, without the attributes
+// safeSVGAttr refuses.
+func writeStartElement(out *bytes.Buffer, t xml.StartElement) {
+	out.WriteString("<" + qualifiedName(t.Name))
+	for _, a := range t.Attr {
+		if !safeSVGAttr(a) {
+			continue
+		}
+		out.WriteString(" " + qualifiedName(a.Name) + `="`)
+		_ = xml.EscapeText(out, []byte(a.Value))
+		out.WriteString(`"`)
+	}
+	out.WriteString(">")
+}
+
+// safeSVGAttr reports whether an attribute can be kept: no event handlers,
+// and no reference outside the file.
+func safeSVGAttr(a xml.Attr) bool {
+	local := strings.ToLower(a.Name.Local)
+	switch {
+	case strings.HasPrefix(local, "on"):
+		return false
+	case local == "href": // href and xlink:href
+		return localRef(a.Value)
+	default:
+		return localCSS(a.Value)
+	}
+}
+
+// localRef reports whether a reference stays inside the file: a fragment,
+// or an inline raster image. Inline SVGs are refused, since they'd need
+// sanitizing too.
+func localRef(ref string) bool {
+	ref = strings.ToLower(strings.TrimSpace(ref))
+	if strings.HasPrefix(ref, "#") {
+		return true
+	}
+	return strings.HasPrefix(ref, "data:image/") && !strings.HasPrefix(ref, "data:image/svg")
+}
+
+// localCSS reports whether every url() in a style (or presentation
+// attribute, like fill="url(#gradient)") is a localRef, and nothing is
+// imported. CSS escapes spell the same keywords differently (u\72l(,
+// @\69mport), and logos have no use for them: any backslash fails.
+func localCSS(css string) bool {
+	lower := strings.ToLower(css)
+	if strings.Contains(lower, "@import") || strings.Contains(lower, `\`) {
+		return false
+	}
+	for {
+		i := strings.Index(lower, "url(")
+		if i < 0 {
+			return true
+		}
+		lower = lower[i+len("url("):]
+		end := strings.IndexByte(lower, ')')
+		if end < 0 {
+			return false
+		}
+		if !localRef(strings.Trim(lower[:end], ` "'`)) {
+			return false
+		}
+		lower = lower[end+1:]
+	}
+}
+
+// qualifiedName writes a name back as it was in the file, prefix included.
+func qualifiedName(n xml.Name) string {
+	if n.Space == "" {
+		return n.Local
+	}
+	return n.Space + ":" + n.Local
+}
+
+// charsetReader decodes the non-UTF-8 encodings SVGs declare in practice.
+// Latin-1 maps byte for byte onto the first 256 code points.
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "us-ascii", "ascii":
+		return input, nil
+	case "iso-8859-1", "latin1", "latin-1":
+		data, err := io.ReadAll(input)
+		if err != nil {
+			return nil, err
+		}
+		var utf8 strings.Builder
+		for _, b := range data {
+			utf8.WriteRune(rune(b))
+		}
+		return strings.NewReader(utf8.String()), nil
+	}
+	return nil, fmt.Errorf("unsupported encoding %q", charset)
+}
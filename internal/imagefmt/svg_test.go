@@ -0,0 +1,136 @@
+package imagefmt
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSanitizeSVG(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			"clean svg is kept",
+			`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 10 10"><path d="M0 0h10v10z" fill="#f00"/></svg>`,
+			`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 10 10"><path d="M0 0h10v10z" fill="#f00"></path></svg>`,
+		},
+		{
+			"prolog and comments dropped",
+			"<?xml version=\"1.0\"?>\n<!DOCTYPE svg PUBLIC \"-//W3C//DTD SVG 1.1//EN\" \"http://www.w3.org/Graphics/SVG/1.1/DTD/svg11.dtd\">\n<!-- logo --><svg></svg>",
+			`<svg></svg>`,
+		},
+		{
+			"script removed",
+			`<svg><script>alert(1)</script><rect width="1"/></svg>`,
+			`<svg><rect width="1"></rect></svg>`,
+		},
+		{
+			"foreignObject removed with its content",
+			`<svg><foreignObject><body xmlns="http://www.w3.org/1999/xhtml"><iframe src="x"/></body></foreignObject></svg>`,
+			`<svg></svg>`,
+		},
+		{
+			"event handlers removed",
+			`<svg onload="alert(1)"><rect onClick="x()" width="1"/></svg>`,
+			`<svg><rect width="1"></rect></svg>`,
+		},
+		{
+			"internal href kept, external removed",
+			`<svg xmlns:xlink="http://www.w3.org/1999/xlink"><use xlink:href="#a"/><use href="https://evil.example/x.svg#a"/><a href="javascript:alert(1)"></a></svg>`,
+			`<svg xmlns:xlink="http://www.w3.org/1999/xlink"><use xlink:href="#a"></use><use></use><a></a></svg>`,
+		},
+		{
+			"inline raster kept, inline svg removed",
+			`<svg><image href="data:image/png;base64,AAAA"/><image href="data:image/svg+xml;base64,AAAA"/></svg>`,
+			`<svg><image href="data:image/png;base64,AAAA"></image><image></image></svg>`,
+		},
+		{
+			"external url in attributes removed",
+			`<svg><rect fill="url(#g)" stroke="url('http://evil.example/p')" style="fill: url(https://evil.example/p)"/></svg>`,
+			`<svg><rect fill="url(#g)"></rect></svg>`,
+		},
+		{
+			"stylesheet fetching anything dropped",
+			`<svg><style>@import url(https://evil.example/a.css);</style><style>.a{fill:red}</style></svg>`,
+			`<svg><style></style><style>.a{fill:red}</style></svg>`,
+		},
+		{
+			"escaped url and @import removed",
+			`<svg><rect fill="u\72l(https://evil.example/p)" style="fill: \75rl(https://evil.example/p)" width="1"/><style>@\69mport 'https://evil.example/a.css';</style><style>.a{fill:u\rl(https://evil.example/p)}</style></svg>`,
+			`<svg><rect width="1"></rect><style></style><style></style></svg>`,
+		},
+		{
+			"escape spelled as an entity removed",
+			`<svg><rect style="fill: u&#92;72l(https://evil.example/p)" width="1"/></svg>`,
+			`<svg><rect width="1"></rect></svg>`,
+		},
+		{
+			"stylesheet split by comments checked whole",
+			`<svg><style>@imp<!-- -->ort 'https://evil.example/a.css';</style><style>.a{fill:<!-- red -->blue}</style></svg>`,
+			`<svg><style></style><style>.a{fill:blue}</style></svg>`,
+		},
+		{
+			"elements inside a stylesheet removed",
+			`<svg><style>@imp<b>x</b>ort 'https://evil.example/a.css';</style><style>.a{<b/>fill:red}</style></svg>`,
+			`<svg><style></style><style>.a{fill:red}</style></svg>`,
+		},
+		{
+			"href animation removed",
+			`<svg><a><set attributeName="href" to="javascript:alert(1)"/><animate attributeName="opacity" to="1"/></a></svg>`,
+			`<svg><a><animate attributeName="opacity" to="1"></animate></a></svg>`,
+		},
+		{
+			"latin-1 converted to utf-8",
+			"<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?><svg><title>Soci\xe9t\xe9</title></svg>",
+			`<svg><title>Société</title></svg>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SanitizeSVG([]byte(tt.in))
+			if err != nil {
+				t.Fatalf("SanitizeSVG: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+			if Detect(got) != SVG {
+				t.Errorf("sanitized output no longer detected as svg")
+			}
+			again, err := SanitizeSVG(got)
+			if err != nil || string(again) != string(got) {
+				t.Errorf("sanitizing again changed it: %s, %v", again, err)
+			}
+		})
+	}
+}
+
+func TestSanitizeSVG_Rejects(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"entities", `<!DOCTYPE svg [<!ENTITY a "aaaa">]><svg>&a;</svg>`, "declares entities"},
+		{"unclosed", `<svg><g>`, "never closed"},
+		{"mismatched", `<svg><g></svg>`, "unexpected"},
+		{"not svg", `<html><svg/></html>`, "is not <svg>"},
+		{"two roots", `<svg/><svg/>`, "is not <svg>"},
+		{"empty", ``, "no <svg> element"},
+		{"unknown encoding", `<?xml version="1.0" encoding="EBCDIC"?><svg/>`, "EBCDIC"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := SanitizeSVG([]byte(tt.in))
+			if !errors.Is(err, ErrUnsafeSVG) {
+				t.Fatalf("expected ErrUnsafeSVG, got %v", err)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected %q in %q", tt.want, err)
+			}
+		})
+	}
+}
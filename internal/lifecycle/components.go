@@ -0,0 +1,73 @@
+package lifecycle
+
+import (
+	"context"
+	"time"
+)
+
+// Background runs run in a goroutine from Start until Stop, which cancels
+// its context and waits for it to return: a relay, a monitor, a one-off
+// job like a storage check.
+func Background(run func(ctx context.Context)) Component {
+	return &background{run: run}
+}
+
+type background struct {
+	run    func(ctx context.Context)
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Start ignores ctx, which only covers startup: the goroutine lives until
+// Stop.
+func (b *background) Start(context.Context) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	b.cancel, b.done = cancel, make(chan struct{})
+	go func() {
+		defer close(b.done)
+		b.run(ctx)
+	}()
+	return nil
+}
+
+func (b *background) Stop(ctx context.Context) error {
+	b.cancel()
+	// Go note: select waits for whichever comes first — the goroutine
+	// returning, or the stop timeout.
+	select {
+	case <-b.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Loop calls tick every interval from Start until Stop, in a goroutine:
+// the periodic jobs. tick gets a context canceled by Stop, so a slow tick
+// is cut short on shutdown. The first tick is an interval after Start.
+func Loop(interval time.Duration, tick func(ctx context.Context)) Component {
+	return Background(func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				tick(ctx)
+			}
+		}
+	})
+}
+
+// OnStop is a component that only has something to do on shutdown, e.g.
+// closing a connection or flushing counters: stop runs when it's stopped,
+// that is after every component added later has stopped.
+func OnStop(stop func(ctx context.Context) error) Component {
+	return onStop(stop)
+}
+
+type onStop func(ctx context.Context) error
+
+func (onStop) Start(context.Context) error      { return nil }
+func (f onStop) Stop(ctx context.Context) error { return f(ctx) }
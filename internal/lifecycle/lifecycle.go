@@ -0,0 +1,108 @@
+// Package lifecycle starts and stops the parts of the server that live
+// alongside the HTTP listener — background loops, relays, monitors — in
+// one place. Components start in the order they were added, which is
+// their dependency order (the database before the jobs that use it), and
+// stop in reverse, each with a timeout of its own, so one component stuck
+// on shutdown can't eat the time the others need.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultStopTimeout is how long a component gets to stop, unless added
+// WithStopTimeout.
+const DefaultStopTimeout = 5 * time.Second
+
+// Component is a part of the server with a life of its own.
+type Component interface {
+	// Start returns once the component runs; long-running work goes on in
+	// goroutines the component owns. An error aborts startup.
+	Start(ctx context.Context) error
+	// Stop ends the work Start began, and returns once it has ended or ctx
+	// (the component's stop timeout) is done.
+	Stop(ctx context.Context) error
+}
+
+// Option configures a component when it's added.
+type Option func(*entry)
+
+// WithStopTimeout gives a component longer (or shorter) than
+// DefaultStopTimeout to stop, e.g. the HTTP server draining requests.
+func WithStopTimeout(d time.Duration) Option {
+	return func(e *entry) { e.stopTimeout = d }
+}
+
+type entry struct {
+	name        string
+	component   Component
+	stopTimeout time.Duration
+}
+
+// Manager starts and stops components. It's meant to be driven from one
+// goroutine: Add everything, Start, and Stop on shutdown.
+type Manager struct {
+	logger  *zap.Logger
+	entries []entry
+	started int // entries[:started] are running
+}
+
+// New creates an empty Manager.
+func New(logger *zap.Logger) *Manager {
+	return &Manager{logger: logger}
+}
+
+// Add registers a component after the ones added before: it starts after
+// them and stops before them.
+func (m *Manager) Add(name string, c Component, opts ...Option) {
+	e := entry{name: name, component: c, stopTimeout: DefaultStopTimeout}
+	for _, opt := range opts {
+		opt(&e)
+	}
+	m.entries = append(m.entries, e)
+}
+
+// Start starts the components added since the last Start, in order, so a
+// program can start the components it depends on early and add the rest
+// later. If one fails, every running component is stopped again and its
+// error returned, so a failed startup leaves nothing running.
+func (m *Manager) Start(ctx context.Context) error {
+	for m.started < len(m.entries) {
+		e := m.entries[m.started]
+		if err := e.component.Start(ctx); err != nil {
+			err = fmt.Errorf("starting %s: %w", e.name, err)
+			return errors.Join(err, m.Stop())
+		}
+		m.logger.Debug("component started", zap.String("component", e.name))
+		m.started++
+	}
+	return nil
+}
+
+// Stop stops the running components in reverse order, each under its own
+// stop timeout. A component that fails or times out doesn't keep the
+// others from stopping; the errors are returned together.
+func (m *Manager) Stop() error {
+	var errs []error
+	for m.started > 0 {
+		m.started--
+		e := m.entries[m.started]
+
+		start := time.Now()
+		ctx, cancel := context.WithTimeout(context.Background(), e.stopTimeout)
+		err := e.component.Stop(ctx)
+		cancel()
+		if err != nil {
+			m.logger.Error("stopping component", zap.String("component", e.name), zap.Error(err))
+			errs = append(errs, fmt.Errorf("stopping %s: %w", e.name, err))
+			continue
+		}
+		m.logger.Debug("component stopped", zap.String("component", e.name), zap.Duration("took", time.Since(start)))
+	}
+	return errors.Join(errs...)
+}
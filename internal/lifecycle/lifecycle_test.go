@@ -0,0 +1,161 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// recorder is a component that logs its starts and stops to a shared list.
+type recorder struct {
+	name     string
+	log      *[]string
+	startErr error
+	stopErr  error
+	block    bool // Stop waits for its timeout
+}
+
+func (r *recorder) Start(context.Context) error {
+	*r.log = append(*r.log, "start "+r.name)
+	return r.startErr
+}
+
+func (r *recorder) Stop(ctx context.Context) error {
+	*r.log = append(*r.log, "stop "+r.name)
+	if r.block {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return r.stopErr
+}
+
+func TestManager_Order(t *testing.T) {
+	var log []string
+	m := New(zap.NewNop())
+	for _, name := range []string{"database", "jobs", "http"} {
+		m.Add(name, &recorder{name: name, log: &log})
+	}
+
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	// Stopping again is a no-op: nothing is running.
+	if err := m.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"start database", "start jobs", "start http", "stop http", "stop jobs", "stop database"}
+	if !reflect.DeepEqual(log, want) {
+		t.Errorf("got %v, want %v", log, want)
+	}
+}
+
+func TestManager_StartInStages(t *testing.T) {
+	var log []string
+	m := New(zap.NewNop())
+	m.Add("database", &recorder{name: "database", log: &log})
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	m.Add("http", &recorder{name: "http", log: &log})
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"start database", "start http", "stop http", "stop database"}
+	if !reflect.DeepEqual(log, want) {
+		t.Errorf("got %v, want %v", log, want)
+	}
+}
+
+func TestManager_StartFailureStopsTheStarted(t *testing.T) {
+	var log []string
+	boom := errors.New("boom")
+	m := New(zap.NewNop())
+	m.Add("database", &recorder{name: "database", log: &log})
+	m.Add("relay", &recorder{name: "relay", log: &log, startErr: boom})
+	m.Add("http", &recorder{name: "http", log: &log})
+
+	err := m.Start(context.Background())
+	if !errors.Is(err, boom) {
+		t.Fatalf("expected the start error, got %v", err)
+	}
+
+	want := []string{"start database", "start relay", "stop database"}
+	if !reflect.DeepEqual(log, want) {
+		t.Errorf("got %v, want %v", log, want)
+	}
+}
+
+func TestManager_StopTimeoutsAndErrors(t *testing.T) {
+	var log []string
+	boom := errors.New("boom")
+	m := New(zap.NewNop())
+	m.Add("database", &recorder{name: "database", log: &log})
+	m.Add("stuck", &recorder{name: "stuck", log: &log, block: true}, WithStopTimeout(10*time.Millisecond))
+	m.Add("failing", &recorder{name: "failing", log: &log, stopErr: boom})
+
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	err := m.Stop()
+	if !errors.Is(err, boom) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected both stop errors, got %v", err)
+	}
+	// Neither kept the database from stopping.
+	if last := log[len(log)-1]; last != "stop database" {
+		t.Errorf("expected the database to stop last, got %v", log)
+	}
+}
+
+func TestLoop(t *testing.T) {
+	var ticks atomic.Int32
+	loop := Loop(time.Millisecond, func(context.Context) { ticks.Add(1) })
+	if err := loop.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for ticks.Load() < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := loop.Stop(ctx); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	stopped := ticks.Load()
+	if stopped < 3 {
+		t.Fatalf("expected at least 3 ticks, got %d", stopped)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if n := ticks.Load(); n != stopped {
+		t.Errorf("ticked %d more times after Stop", n-stopped)
+	}
+}
+
+func TestBackground_StopTimesOut(t *testing.T) {
+	// A goroutine that ignores cancellation makes Stop give up at its timeout.
+	release := make(chan struct{})
+	defer close(release)
+	c := Background(func(context.Context) { <-release })
+	if err := c.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := c.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected DeadlineExceeded, got %v", err)
+	}
+}
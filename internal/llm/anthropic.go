@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/anthropics/anthropic-sdk-go"
 	"github.com/anthropics/anthropic-sdk-go/option"
@@ -16,16 +17,42 @@ import (
 type AnthropicClient struct {
 	client *anthropic.Client
 	model  string
+	opts   AnthropicOptions
+}
+
+// AnthropicOptions tunes the agentic loop. Zero values fall back to defaults,
+// so callers only set what they want to override.
+type AnthropicOptions struct {
+	MaxTurns  int           // Max request/response rounds before giving up (default 5)
+	MaxTokens int64         // Max output tokens per turn (default 1024)
+	Timeout   time.Duration // Per-call timeout for each streamed turn (default 60s)
+	Search    SearchTool    // Optional: external search engine instead of Claude's built-in web_search
+}
+
+// withDefaults fills in zero-valued fields. A value receiver returns a copy,
+// leaving the caller's struct untouched.
+func (o AnthropicOptions) withDefaults() AnthropicOptions {
+	if o.MaxTurns <= 0 {
+		o.MaxTurns = 5
+	}
+	if o.MaxTokens <= 0 {
+		o.MaxTokens = 1024
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 60 * time.Second
+	}
+	return o
 }
 
 // NewAnthropicClient creates a new Claude-powered logo finder.
-func NewAnthropicClient(apiKey string, model string) *AnthropicClient {
+func NewAnthropicClient(apiKey string, model string, opts AnthropicOptions) *AnthropicClient {
 	client := anthropic.NewClient(
 		option.WithAPIKey(apiKey),
 	)
 	return &AnthropicClient{
 		client: &client,
 		model:  model,
+		opts:   opts.withDefaults(),
 	}
 }
 
@@ -82,16 +109,38 @@ func (a *AnthropicClient) FindLogoURL(ctx context.Context, symbol string, compan
 		{OfTool: &submitTool},
 	}
 
+	// With an external SearchTool configured, swap the built-in web_search for
+	// our own search_web tool — we run the query and feed the results back.
+	if a.opts.Search != nil {
+		searchTool := anthropic.ToolParam{
+			Name:        searchToolName,
+			Description: param.NewOpt(searchToolDescription),
+			InputSchema: anthropic.ToolInputSchemaParam{
+				Properties: map[string]interface{}{
+					"query": map[string]interface{}{
+						"type":        "string",
+						"description": "The search query.",
+					},
+				},
+				Required: []string{"query"},
+			},
+		}
+		tools = []anthropic.ToolUnionParam{
+			{OfTool: &searchTool},
+			{OfTool: &submitTool},
+		}
+	}
+
 	// Agentic loop: Claude may need multiple turns (search → read results → search more → submit).
 	// We keep sending tool results back until Claude calls submit_logo_url or gives up.
 	messages := []anthropic.MessageParam{
 		anthropic.NewUserMessage(anthropic.NewTextBlock(prompt)),
 	}
 
-	for i := 0; i < 5; i++ { // Max 5 turns to prevent runaway
-		message, err := a.client.Messages.New(ctx, anthropic.MessageNewParams{
+	for i := 0; i < a.opts.MaxTurns; i++ { // Bounded turns to prevent runaway
+		message, err := a.streamTurn(ctx, anthropic.MessageNewParams{
 			Model:     anthropic.Model(a.model),
-			MaxTokens: 1024,
+			MaxTokens: a.opts.MaxTokens,
 			Messages:  messages,
 			Tools:     tools,
 		})
@@ -147,7 +196,13 @@ func (a *AnthropicClient) FindLogoURL(ctx context.Context, symbol string, compan
 			if !ok || toolUse.Name == "web_search" {
 				continue
 			}
-			if toolUse.Name != "submit_logo_url" {
+			switch toolUse.Name {
+			case "submit_logo_url":
+				// Handled above
+			case searchToolName:
+				toolResults = append(toolResults,
+					anthropic.NewToolResultBlock(toolUse.ID, a.runSearchTool(ctx, toolUse.Input), false))
+			default:
 				toolResults = append(toolResults,
 					anthropic.NewToolResultBlock(toolUse.ID, "Received, please continue searching.", false))
 			}
@@ -160,6 +215,48 @@ func (a *AnthropicClient) FindLogoURL(ctx context.Context, symbol string, compan
 	return nil, fmt.Errorf("exceeded max turns without finding logo for %s", symbol)
 }
 
+// runSearchTool executes a search_web call from Claude against the configured SearchTool.
+func (a *AnthropicClient) runSearchTool(ctx context.Context, input json.RawMessage) string {
+	if a.opts.Search == nil {
+		return "Error: search is not available."
+	}
+	var args struct {
+		Query string `json:"query"`
+	}
+	if err := json.Unmarshal(input, &args); err != nil {
+		return fmt.Sprintf("Error: invalid search input: %v", err)
+	}
+	return runSearch(ctx, a.opts.Search, args.Query)
+}
+
+// streamTurn runs one turn of the conversation using the streaming API and
+// returns the fully accumulated message.
+//
+// Why stream? Web search turns can take a long time. With a plain request we'd
+// block until the whole completion arrives; with a stream, cancelling the context
+// (client disconnected, per-call timeout hit) tears down the connection right away.
+func (a *AnthropicClient) streamTurn(ctx context.Context, params anthropic.MessageNewParams) (*anthropic.Message, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.opts.Timeout)
+	defer cancel()
+
+	stream := a.client.Messages.NewStreaming(ctx, params)
+	defer stream.Close()
+
+	// Accumulate rebuilds a regular Message from the stream events, so the
+	// rest of the loop doesn't care whether we streamed or not.
+	message := anthropic.Message{}
+	for stream.Next() {
+		if err := message.Accumulate(stream.Current()); err != nil {
+			return nil, fmt.Errorf("accumulating stream: %w", err)
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return nil, err
+	}
+
+	return &message, nil
+}
+
 // buildPrompt creates the user prompt for the LLM.
 func buildPrompt(symbol string, companyName string) string {
 	hint := ""
@@ -0,0 +1,60 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// BingSearch implements SearchTool using the Bing Web Search API.
+type BingSearch struct {
+	apiKey  string
+	baseURL string
+	client  *http.Client
+}
+
+// NewBingSearch creates a Bing Web Search client.
+func NewBingSearch(apiKey string, client *http.Client) *BingSearch {
+	return &BingSearch{
+		apiKey:  apiKey,
+		baseURL: "https://api.bing.microsoft.com/v7.0/search",
+		client:  client,
+	}
+}
+
+func (b *BingSearch) EngineName() string { return "bing" }
+
+type bingResponse struct {
+	WebPages struct {
+		Value []struct {
+			Name    string `json:"name"`
+			URL     string `json:"url"`
+			Snippet string `json:"snippet"`
+		} `json:"value"`
+	} `json:"webPages"`
+}
+
+func (b *BingSearch) Search(ctx context.Context, query string, limit int) ([]SearchResult, error) {
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("count", strconv.Itoa(limit))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", b.baseURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Ocp-Apim-Subscription-Key", b.apiKey)
+
+	var body bingResponse
+	if err := doSearchRequest(b.client, req, &body); err != nil {
+		return nil, fmt.Errorf("bing search: %w", err)
+	}
+
+	results := make([]SearchResult, 0, len(body.WebPages.Value))
+	for _, r := range body.WebPages.Value {
+		results = append(results, SearchResult{Title: r.Name, URL: r.URL, Snippet: r.Snippet})
+	}
+	return results, nil
+}
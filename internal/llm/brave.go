@@ -0,0 +1,61 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// BraveSearch implements SearchTool using the Brave Search API.
+type BraveSearch struct {
+	apiKey  string
+	baseURL string
+	client  *http.Client
+}
+
+// NewBraveSearch creates a Brave Search client.
+func NewBraveSearch(apiKey string, client *http.Client) *BraveSearch {
+	return &BraveSearch{
+		apiKey:  apiKey,
+		baseURL: "https://api.search.brave.com/res/v1/web/search",
+		client:  client,
+	}
+}
+
+func (b *BraveSearch) EngineName() string { return "brave" }
+
+type braveResponse struct {
+	Web struct {
+		Results []struct {
+			Title       string `json:"title"`
+			URL         string `json:"url"`
+			Description string `json:"description"`
+		} `json:"results"`
+	} `json:"web"`
+}
+
+func (b *BraveSearch) Search(ctx context.Context, query string, limit int) ([]SearchResult, error) {
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("count", strconv.Itoa(limit))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", b.baseURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Subscription-Token", b.apiKey)
+
+	var body braveResponse
+	if err := doSearchRequest(b.client, req, &body); err != nil {
+		return nil, fmt.Errorf("brave search: %w", err)
+	}
+
+	results := make([]SearchResult, 0, len(body.Web.Results))
+	for _, r := range body.Web.Results {
+		results = append(results, SearchResult{Title: r.Title, URL: r.URL, Snippet: r.Description})
+	}
+	return results, nil
+}
@@ -13,13 +13,31 @@ import (
 type OpenAIClient struct {
 	client *openai.Client
 	model  string
+	opts   OpenAIOptions
+}
+
+// OpenAIOptions configures optional OpenAI client behavior.
+type OpenAIOptions struct {
+	// Search gives the model a search_web function backed by an external engine.
+	// Without it, the model can only rely on what it already knows.
+	Search SearchTool
+
+	// BaseURL overrides the API endpoint (default https://api.openai.com/v1).
+	// Useful for OpenAI-compatible gateways, and for the fake server in tests.
+	BaseURL string
 }
 
 // NewOpenAIClient creates a new OpenAI-powered logo finder.
-func NewOpenAIClient(apiKey string, model string) *OpenAIClient {
+func NewOpenAIClient(apiKey string, model string, opts OpenAIOptions) *OpenAIClient {
+	cfg := openai.DefaultConfig(apiKey)
+	if opts.BaseURL != "" {
+		cfg.BaseURL = opts.BaseURL
+	}
+
 	return &OpenAIClient{
-		client: openai.NewClient(apiKey),
+		client: openai.NewClientWithConfig(cfg),
 		model:  model,
+		opts:   opts,
 	}
 }
 
@@ -64,6 +82,26 @@ func (o *OpenAIClient) FindLogoURL(ctx context.Context, symbol string, companyNa
 		},
 	}
 
+	if o.opts.Search != nil {
+		tools = append(tools, openai.Tool{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        searchToolName,
+				Description: searchToolDescription,
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"query": map[string]interface{}{
+							"type":        "string",
+							"description": "The search query.",
+						},
+					},
+					"required": []string{"query"},
+				},
+			},
+		})
+	}
+
 	messages := []openai.ChatCompletionMessage{
 		{
 			Role: openai.ChatMessageRoleSystem,
@@ -116,10 +154,14 @@ Return the direct image URL via the submit_logo_url function. Prefer high-resolu
 					}, nil
 				}
 
-				// For other tool calls, send a generic result back
+				// For other tool calls, send a result back — search output, or a generic nudge
+				content := "Received. Please continue and call submit_logo_url with the logo URL."
+				if toolCall.Function.Name == searchToolName && o.opts.Search != nil {
+					content = o.runSearchTool(ctx, toolCall.Function.Arguments)
+				}
 				messages = append(messages, openai.ChatCompletionMessage{
 					Role:       openai.ChatMessageRoleTool,
-					Content:    "Received. Please continue and call submit_logo_url with the logo URL.",
+					Content:    content,
 					ToolCallID: toolCall.ID,
 				})
 			}
@@ -134,3 +176,15 @@ Return the direct image URL via the submit_logo_url function. Prefer high-resolu
 
 	return nil, fmt.Errorf("exceeded max turns without finding logo for %s", symbol)
 }
+
+// runSearchTool executes a search_web function call against the configured SearchTool.
+// OpenAI sends function arguments as a JSON string, not a decoded object.
+func (o *OpenAIClient) runSearchTool(ctx context.Context, arguments string) string {
+	var args struct {
+		Query string `json:"query"`
+	}
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return fmt.Sprintf("Error: invalid search arguments: %v", err)
+	}
+	return runSearch(ctx, o.opts.Search, args.Query)
+}
@@ -0,0 +1,90 @@
+package llm
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/packages/param"
+)
+
+// LogoReview is a model's opinion of a logo it was shown.
+type LogoReview struct {
+	Flagged bool   `json:"flagged"` // Doubtful: hold it for an admin
+	Reason  string `json:"reason"`
+}
+
+// ReviewLogo shows Claude a rendered logo (PNG) and asks whether it's
+// plausibly the official logo of the company behind symbol, and fit to
+// serve. One turn, no search: Claude answers through the submit_review
+// tool, which tool_choice makes it call.
+func (a *AnthropicClient) ReviewLogo(ctx context.Context, symbol, companyName string, png []byte) (*LogoReview, error) {
+	reviewTool := anthropic.ToolParam{
+		Name:        "submit_review",
+		Description: param.NewOpt("Submit your review of the logo."),
+		InputSchema: anthropic.ToolInputSchemaParam{
+			Properties: map[string]interface{}{
+				"flagged": map[string]interface{}{
+					"type":        "boolean",
+					"description": "True if the image is probably not this company's official logo, or is inappropriate to show.",
+				},
+				"reason": map[string]interface{}{
+					"type":        "string",
+					"description": "One sentence explaining the decision, e.g. which company the logo belongs to instead.",
+				},
+			},
+			Required: []string{"flagged", "reason"},
+		},
+	}
+
+	message, err := a.streamTurn(ctx, anthropic.MessageNewParams{
+		Model:     anthropic.Model(a.model),
+		MaxTokens: a.opts.MaxTokens,
+		Messages: []anthropic.MessageParam{
+			anthropic.NewUserMessage(
+				anthropic.NewImageBlockBase64("image/png", base64.StdEncoding.EncodeToString(png)),
+				anthropic.NewTextBlock(buildReviewPrompt(symbol, companyName)),
+			),
+		},
+		Tools:      []anthropic.ToolUnionParam{{OfTool: &reviewTool}},
+		ToolChoice: anthropic.ToolChoiceUnionParam{OfTool: &anthropic.ToolChoiceToolParam{Name: reviewTool.Name}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("anthropic API call: %w", err)
+	}
+
+	for _, block := range message.Content {
+		toolUse, ok := block.AsAny().(anthropic.ToolUseBlock)
+		if !ok || toolUse.Name != reviewTool.Name {
+			continue
+		}
+		inputBytes, err := json.Marshal(toolUse.Input)
+		if err != nil {
+			return nil, fmt.Errorf("marshaling tool input: %w", err)
+		}
+		var review LogoReview
+		if err := json.Unmarshal(inputBytes, &review); err != nil {
+			return nil, fmt.Errorf("parsing tool input: %w", err)
+		}
+		return &review, nil
+	}
+	return nil, fmt.Errorf("Claude did not review the logo for %s", symbol)
+}
+
+// buildReviewPrompt creates the user prompt of ReviewLogo.
+func buildReviewPrompt(symbol, companyName string) string {
+	subject := fmt.Sprintf("the company with stock ticker %s", symbol)
+	if companyName != "" {
+		subject = fmt.Sprintf("%s (stock ticker %s)", companyName, symbol)
+	}
+	return fmt.Sprintf(`This image was found by a web search for the official logo of %s, and is about to be served as such.
+
+Flag it if:
+- It is another company's logo, or an old or unofficial one (fan art, parody, mockup)
+- It isn't a logo at all (a photo, a screenshot, a product, a placeholder)
+- It is offensive or inappropriate
+
+Don't flag a correct logo for its colors, size or background. Call submit_review with your decision.`, subject)
+}
@@ -0,0 +1,117 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// SearchResult is a single web search hit.
+type SearchResult struct {
+	Title   string
+	URL     string
+	Snippet string
+}
+
+// SearchTool is a web search engine the LLM clients can call as a tool.
+// It decouples "which model reasons" from "which engine searches": Claude can
+// search with Brave, GPT-4o can search with Bing, and so on.
+type SearchTool interface {
+	Search(ctx context.Context, query string, limit int) ([]SearchResult, error)
+	EngineName() string
+}
+
+// NewSearchTool creates a SearchTool for the named engine.
+// Returns nil (and no error) when engine is empty — search is optional.
+func NewSearchTool(engine, apiKey string) (SearchTool, error) {
+	if engine == "" {
+		return nil, nil
+	}
+	if apiKey == "" {
+		return nil, fmt.Errorf("search engine %q requires an API key", engine)
+	}
+
+	client := &http.Client{Timeout: 15 * time.Second}
+
+	switch engine {
+	case "brave":
+		return NewBraveSearch(apiKey, client), nil
+	case "serpapi":
+		return NewSerpAPISearch(apiKey, client), nil
+	case "bing":
+		return NewBingSearch(apiKey, client), nil
+	default:
+		return nil, fmt.Errorf("unknown search engine: %s", engine)
+	}
+}
+
+// searchToolName is the tool name both LLM clients expose for SearchTool.
+const searchToolName = "search_web"
+
+// searchToolDescription is shared by the Anthropic and OpenAI tool definitions.
+const searchToolDescription = "Search the web. Returns result titles, URLs and snippets. Use it to find the company's official logo image."
+
+// maxSearchResults caps how many hits are fed back into the conversation.
+const maxSearchResults = 8
+
+// formatSearchResults renders hits as a numbered plain-text list for a tool result.
+// LLMs handle simple text better than nested JSON here, and it's cheaper in tokens.
+func formatSearchResults(results []SearchResult) string {
+	if len(results) == 0 {
+		return "No results found."
+	}
+
+	var b strings.Builder
+	for i, r := range results {
+		fmt.Fprintf(&b, "%d. %s\n   %s\n", i+1, r.Title, r.URL)
+		if r.Snippet != "" {
+			fmt.Fprintf(&b, "   %s\n", r.Snippet)
+		}
+	}
+	return b.String()
+}
+
+// runSearch executes a search_web tool call and returns the text to send back.
+// Errors are returned as text too — the model can retry with a different query.
+func runSearch(ctx context.Context, tool SearchTool, query string) string {
+	if query == "" {
+		return "Error: query must not be empty."
+	}
+	results, err := tool.Search(ctx, query, maxSearchResults)
+	if err != nil {
+		return fmt.Sprintf("Search failed: %v", err)
+	}
+	return formatSearchResults(results)
+}
+
+// doSearchRequest sends a search request and decodes the JSON response into out.
+// Shared by all engines — they differ only in URLs, auth headers and response shape.
+//
+// A transport error names the host but not the full URL: some engines take
+// the API key in the query string, and the error ends up in the model's
+// tool result.
+func doSearchRequest(client *http.Client, req *http.Request, out any) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return fmt.Errorf("%s %s: %w", urlErr.Op, req.URL.Host, urlErr.Err)
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
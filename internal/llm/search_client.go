@@ -0,0 +1,71 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// SearchClient is a non-agentic Client: it runs a single search query and picks
+// the first result that points directly at an image file. No model is involved,
+// so it costs one search API call instead of several LLM turns — useful as a
+// cheap first attempt before falling back to a real LLM.
+type SearchClient struct {
+	search SearchTool
+}
+
+// NewSearchClient creates a Client that uses only the given search engine.
+func NewSearchClient(search SearchTool) *SearchClient {
+	return &SearchClient{search: search}
+}
+
+func (s *SearchClient) ProviderName() string { return "search" }
+func (s *SearchClient) ModelName() string    { return s.search.EngineName() }
+
+func (s *SearchClient) FindLogoURL(ctx context.Context, symbol string, companyName string) (*LogoSearchResult, error) {
+	subject := symbol
+	if companyName != "" {
+		subject = companyName
+	}
+	query := fmt.Sprintf("%s logo png OR svg", subject)
+
+	results, err := s.search.Search(ctx, query, maxSearchResults)
+	if err != nil {
+		return nil, fmt.Errorf("%s search: %w", s.search.EngineName(), err)
+	}
+
+	for _, r := range results {
+		if !isImageURL(r.URL) {
+			continue
+		}
+		source := ""
+		if u, err := url.Parse(r.URL); err == nil {
+			source = u.Host
+		}
+		// Without a model to judge the result, we can't claim more than low confidence.
+		return &LogoSearchResult{
+			LogoURL:     r.URL,
+			CompanyName: companyName,
+			Source:      source,
+			Confidence:  "low",
+		}, nil
+	}
+
+	return nil, fmt.Errorf("no direct image URL in search results for %s", symbol)
+}
+
+// isImageURL reports whether a URL's path ends in a known image extension.
+func isImageURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(path.Ext(u.Path)) {
+	case ".png", ".svg", ".jpg", ".jpeg", ".webp", ".ico", ".bmp", ".tif", ".tiff":
+		return true
+	default:
+		return false
+	}
+}
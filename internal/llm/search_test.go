@@ -0,0 +1,129 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSearchEngines_ParseResults(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		authCheck func(r *http.Request) bool
+		newTool   func(baseURL string) SearchTool
+	}{
+		{
+			name: "brave",
+			body: `{"web":{"results":[{"title":"Apple","url":"https://apple.com/logo.svg","description":"Apple logo"}]}}`,
+			authCheck: func(r *http.Request) bool {
+				return r.Header.Get("X-Subscription-Token") == "key"
+			},
+			newTool: func(baseURL string) SearchTool {
+				s := NewBraveSearch("key", http.DefaultClient)
+				s.baseURL = baseURL
+				return s
+			},
+		},
+		{
+			name: "serpapi",
+			body: `{"organic_results":[{"title":"Apple","link":"https://apple.com/logo.svg","snippet":"Apple logo"}]}`,
+			authCheck: func(r *http.Request) bool {
+				return r.URL.Query().Get("api_key") == "key"
+			},
+			newTool: func(baseURL string) SearchTool {
+				s := NewSerpAPISearch("key", http.DefaultClient)
+				s.baseURL = baseURL
+				return s
+			},
+		},
+		{
+			name: "bing",
+			body: `{"webPages":{"value":[{"name":"Apple","url":"https://apple.com/logo.svg","snippet":"Apple logo"}]}}`,
+			authCheck: func(r *http.Request) bool {
+				return r.Header.Get("Ocp-Apim-Subscription-Key") == "key"
+			},
+			newTool: func(baseURL string) SearchTool {
+				s := NewBingSearch("key", http.DefaultClient)
+				s.baseURL = baseURL
+				return s
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if !tt.authCheck(r) {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				if r.URL.Query().Get("q") != "apple logo" {
+					t.Errorf("unexpected query: %q", r.URL.Query().Get("q"))
+				}
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			results, err := tt.newTool(srv.URL).Search(context.Background(), "apple logo", 5)
+			if err != nil {
+				t.Fatalf("search failed: %v", err)
+			}
+			if len(results) != 1 {
+				t.Fatalf("expected 1 result, got %d", len(results))
+			}
+			want := SearchResult{Title: "Apple", URL: "https://apple.com/logo.svg", Snippet: "Apple logo"}
+			if results[0] != want {
+				t.Errorf("got %+v, want %+v", results[0], want)
+			}
+		})
+	}
+}
+
+func TestRunSearch_FailureHidesAPIKey(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close() // Every request fails at the transport
+
+	s := NewSerpAPISearch("secret-key", http.DefaultClient)
+	s.baseURL = srv.URL
+	got := runSearch(context.Background(), s, "apple logo")
+	if !strings.HasPrefix(got, "Search failed:") {
+		t.Fatalf("expected a failed search, got %q", got)
+	}
+	if strings.Contains(got, "secret-key") {
+		t.Errorf("the API key leaked into the tool result: %q", got)
+	}
+}
+
+func TestFormatSearchResults(t *testing.T) {
+	out := formatSearchResults([]SearchResult{
+		{Title: "Apple", URL: "https://apple.com", Snippet: "Official site"},
+	})
+	if !strings.Contains(out, "1. Apple") || !strings.Contains(out, "https://apple.com") {
+		t.Errorf("unexpected formatting: %q", out)
+	}
+
+	if got := formatSearchResults(nil); got != "No results found." {
+		t.Errorf("expected empty-results message, got %q", got)
+	}
+}
+
+func TestIsImageURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://upload.wikimedia.org/a/Apple_logo.svg", true},
+		{"https://example.com/logo.PNG?w=200", true},
+		{"https://example.com/favicon.ico", true},
+		{"https://en.wikipedia.org/wiki/Apple_Inc.", false},
+		{"https://example.com/", false},
+	}
+	for _, tt := range tests {
+		if got := isImageURL(tt.url); got != tt.want {
+			t.Errorf("isImageURL(%q) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// SerpAPISearch implements SearchTool using SerpAPI (Google results).
+type SerpAPISearch struct {
+	apiKey  string
+	baseURL string
+	client  *http.Client
+}
+
+// NewSerpAPISearch creates a SerpAPI client.
+func NewSerpAPISearch(apiKey string, client *http.Client) *SerpAPISearch {
+	return &SerpAPISearch{
+		apiKey:  apiKey,
+		baseURL: "https://serpapi.com/search.json",
+		client:  client,
+	}
+}
+
+func (s *SerpAPISearch) EngineName() string { return "serpapi" }
+
+type serpAPIResponse struct {
+	OrganicResults []struct {
+		Title   string `json:"title"`
+		Link    string `json:"link"`
+		Snippet string `json:"snippet"`
+	} `json:"organic_results"`
+}
+
+func (s *SerpAPISearch) Search(ctx context.Context, query string, limit int) ([]SearchResult, error) {
+	params := url.Values{}
+	params.Set("engine", "google")
+	params.Set("q", query)
+	params.Set("num", strconv.Itoa(limit))
+	params.Set("api_key", s.apiKey)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", s.baseURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	var body serpAPIResponse
+	if err := doSearchRequest(s.client, req, &body); err != nil {
+		return nil, fmt.Errorf("serpapi search: %w", err)
+	}
+
+	results := make([]SearchResult, 0, len(body.OrganicResults))
+	for _, r := range body.OrganicResults {
+		results = append(results, SearchResult{Title: r.Title, URL: r.Link, Snippet: r.Snippet})
+	}
+	return results, nil
+}
@@ -82,3 +82,47 @@ func AdminKeyAuth(adminKeys []string) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// ClientCertAuth returns middleware that admits requests carrying a client
+// certificate the TLS listener verified against its CA bundle, as an
+// alternative to keys: requests without one are passed to fallback (e.g.
+// AdminKeyAuth). With allowedNames, only certificates whose common name or
+// a DNS name is listed get in; any other verified certificate gets 403.
+//
+// Verification itself is the listener's job (tls.Config.ClientCAs); this
+// only reads the result from c.Request.TLS.
+func ClientCertAuth(allowedNames []string, fallback gin.HandlerFunc) gin.HandlerFunc {
+	nameSet := make(map[string]struct{}, len(allowedNames))
+	for _, n := range allowedNames {
+		nameSet[n] = struct{}{}
+	}
+
+	return func(c *gin.Context) {
+		tlsState := c.Request.TLS
+		if tlsState == nil || len(tlsState.VerifiedChains) == 0 {
+			fallback(c)
+			return
+		}
+
+		// The leaf of a verified chain is the client's own certificate.
+		cert := tlsState.VerifiedChains[0][0]
+		names := append([]string{cert.Subject.CommonName}, cert.DNSNames...)
+		name, ok := names[0], len(nameSet) == 0
+		for _, n := range names {
+			if _, listed := nameSet[n]; listed {
+				name, ok = n, true
+				break
+			}
+		}
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "client certificate not allowed",
+			})
+			return
+		}
+
+		// Set like an API key, for anything keyed on the caller.
+		c.Set("api_key", "cert:"+name)
+		c.Next()
+	}
+}
@@ -1,6 +1,9 @@
 package middleware
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -115,3 +118,45 @@ func TestAdminKeyAuth_Invalid(t *testing.T) {
 		t.Errorf("expected 403, got %d", w.Code)
 	}
 }
+
+func TestClientCertAuth(t *testing.T) {
+	// withCert fakes what the TLS listener leaves on a request after
+	// verifying a client certificate.
+	withCert := func(req *http.Request, cn string, dnsNames ...string) {
+		cert := &x509.Certificate{Subject: pkix.Name{CommonName: cn}, DNSNames: dnsNames}
+		req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	}
+
+	tests := []struct {
+		name    string
+		allowed []string
+		setup   func(req *http.Request)
+		want    int
+	}{
+		{"allowed common name", []string{"ops-cron"}, func(r *http.Request) { withCert(r, "ops-cron") }, http.StatusOK},
+		{"allowed DNS name", []string{"ops.internal"}, func(r *http.Request) { withCert(r, "x", "ops.internal") }, http.StatusOK},
+		{"any name when none listed", nil, func(r *http.Request) { withCert(r, "anyone") }, http.StatusOK},
+		{"name not listed", []string{"ops-cron"}, func(r *http.Request) { withCert(r, "intruder") }, http.StatusForbidden},
+		{"no cert, valid key", []string{"ops-cron"}, func(r *http.Request) { r.Header.Set("X-API-Key", "admin-key") }, http.StatusOK},
+		{"no cert, no key", []string{"ops-cron"}, func(r *http.Request) {}, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(ClientCertAuth(tt.allowed, AdminKeyAuth([]string{"admin-key"})))
+			router.GET("/test", func(c *gin.Context) {
+				c.String(http.StatusOK, "ok")
+			})
+
+			req := httptest.NewRequest("GET", "/test", nil)
+			tt.setup(req)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Errorf("expected %d, got %d", tt.want, w.Code)
+			}
+		})
+	}
+}
@@ -2,10 +2,37 @@ package middleware
 
 import (
 	"net/http"
+	"sort"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+
+	"github.com/fleveque/logo-service/internal/origin"
 )
 
+// CORSOptions configures the CORS middleware. Patterns are validated when
+// the config is loaded (see config.validate); invalid ones never match here.
+type CORSOptions struct {
+	// AllowedOrigins lists exact origins ("https://quantic.es"), wildcard
+	// subdomains ("https://*.quantic.es") or "*".
+	AllowedOrigins []string
+
+	// AllowCredentials lets browsers send cookies and Authorization headers.
+	AllowCredentials bool
+
+	// Methods are allowed on every route; Routes override them by path prefix.
+	// OPTIONS is always added. Defaults to GET.
+	Methods []string
+	Routes  []CORSRoute
+}
+
+// CORSRoute sets the allowed methods for paths starting with PathPrefix.
+// When several prefixes match, the longest wins.
+type CORSRoute struct {
+	PathPrefix string
+	Methods    []string
+}
+
 // CORS returns middleware that sets Cross-Origin Resource Sharing headers.
 // This allows the dividend-portfolio frontend (different origin) to make
 // requests to the logo service API.
@@ -13,20 +40,52 @@ import (
 // CORS explained: browsers block cross-origin requests by default. The server
 // must explicitly allow them via these headers. For preflight OPTIONS requests,
 // we return 204 immediately (no content).
-func CORS(allowedOrigins []string) gin.HandlerFunc {
-	originSet := make(map[string]struct{}, len(allowedOrigins))
-	for _, o := range allowedOrigins {
-		originSet[o] = struct{}{}
+func CORS(opts CORSOptions) gin.HandlerFunc {
+	patterns := make([]origin.Pattern, 0, len(opts.AllowedOrigins))
+	for _, o := range opts.AllowedOrigins {
+		if p, err := origin.Parse(o); err == nil {
+			patterns = append(patterns, p)
+		}
+	}
+
+	defaultMethods := methodList(opts.Methods)
+
+	// Longest prefix first, so the first match is the most specific.
+	routes := make([]CORSRoute, len(opts.Routes))
+	copy(routes, opts.Routes)
+	sort.SliceStable(routes, func(i, j int) bool {
+		return len(routes[i].PathPrefix) > len(routes[j].PathPrefix)
+	})
+	routeMethods := make([]string, len(routes))
+	for i, r := range routes {
+		routeMethods[i] = methodList(r.Methods)
 	}
 
 	return func(c *gin.Context) {
-		origin := c.GetHeader("Origin")
+		// The response depends on the Origin header, so caches must key on it.
+		c.Header("Vary", "Origin")
 
-		if _, ok := originSet[origin]; ok {
-			c.Header("Access-Control-Allow-Origin", origin)
-			c.Header("Access-Control-Allow-Methods", "GET, OPTIONS")
+		requestOrigin := c.GetHeader("Origin")
+		if originAllowed(patterns, requestOrigin) {
+			methods := defaultMethods
+			for i, r := range routes {
+				if strings.HasPrefix(c.Request.URL.Path, r.PathPrefix) {
+					methods = routeMethods[i]
+					break
+				}
+			}
+
+			// Echo the origin rather than sending "*": with credentials,
+			// browsers reject a wildcard Allow-Origin.
+			c.Header("Access-Control-Allow-Origin", requestOrigin)
+			c.Header("Access-Control-Allow-Methods", methods)
 			c.Header("Access-Control-Allow-Headers", "X-API-Key, Content-Type")
 			c.Header("Access-Control-Max-Age", "86400")
+			// Without this, browser code can't read the rate limit and versioning headers.
+			c.Header("Access-Control-Expose-Headers", "X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset, X-RateLimit-Credits, Retry-After, X-API-Version, Deprecation, Sunset, Link")
+			if opts.AllowCredentials {
+				c.Header("Access-Control-Allow-Credentials", "true")
+			}
 		}
 
 		// Handle preflight requests
@@ -38,3 +97,28 @@ func CORS(allowedOrigins []string) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+func originAllowed(patterns []origin.Pattern, requestOrigin string) bool {
+	for _, p := range patterns {
+		if p.Match(requestOrigin) {
+			return true
+		}
+	}
+	return false
+}
+
+// methodList formats methods for Access-Control-Allow-Methods, always
+// including OPTIONS for the preflight itself.
+func methodList(methods []string) string {
+	if len(methods) == 0 {
+		methods = []string{http.MethodGet}
+	}
+	list := make([]string, 0, len(methods)+1)
+	for _, m := range methods {
+		m = strings.ToUpper(m)
+		if m != http.MethodOptions {
+			list = append(list, m)
+		}
+	}
+	return strings.Join(append(list, http.MethodOptions), ", ")
+}
@@ -10,7 +10,7 @@ import (
 
 func TestCORS_AllowedOrigin(t *testing.T) {
 	router := gin.New()
-	router.Use(CORS([]string{"http://localhost:3000", "https://quantic.es"}))
+	router.Use(CORS(CORSOptions{AllowedOrigins: []string{"http://localhost:3000", "https://quantic.es"}}))
 	router.GET("/test", func(c *gin.Context) {
 		c.String(http.StatusOK, "ok")
 	})
@@ -27,7 +27,7 @@ func TestCORS_AllowedOrigin(t *testing.T) {
 
 func TestCORS_DisallowedOrigin(t *testing.T) {
 	router := gin.New()
-	router.Use(CORS([]string{"http://localhost:3000"}))
+	router.Use(CORS(CORSOptions{AllowedOrigins: []string{"http://localhost:3000"}}))
 	router.GET("/test", func(c *gin.Context) {
 		c.String(http.StatusOK, "ok")
 	})
@@ -45,7 +45,7 @@ func TestCORS_DisallowedOrigin(t *testing.T) {
 
 func TestCORS_PreflightOptions(t *testing.T) {
 	router := gin.New()
-	router.Use(CORS([]string{"http://localhost:3000"}))
+	router.Use(CORS(CORSOptions{AllowedOrigins: []string{"http://localhost:3000"}}))
 	router.GET("/test", func(c *gin.Context) {
 		c.String(http.StatusOK, "ok")
 	})
@@ -62,3 +62,90 @@ func TestCORS_PreflightOptions(t *testing.T) {
 		t.Error("expected Access-Control-Allow-Methods header")
 	}
 }
+
+// corsRequest sends a request through the CORS middleware and returns the response.
+func corsRequest(t *testing.T, opts CORSOptions, method, path, origin string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	router := gin.New()
+	router.Use(CORS(opts))
+	router.GET("/*path", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("Origin", origin)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCORS_WildcardSubdomain(t *testing.T) {
+	opts := CORSOptions{AllowedOrigins: []string{"https://quantic.es", "https://*.quantic.es"}}
+
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"https://quantic.es", true},
+		{"https://pr-123.quantic.es", true},
+		{"https://evilquantic.es", false},
+		{"https://quantic.es.evil.com", false},
+	}
+	for _, tt := range tests {
+		w := corsRequest(t, opts, "GET", "/api/v1/logos/AAPL", tt.origin)
+		got := w.Header().Get("Access-Control-Allow-Origin")
+		if tt.allowed && got != tt.origin {
+			t.Errorf("%s: expected to be allowed, got %q", tt.origin, got)
+		}
+		if !tt.allowed && got != "" {
+			t.Errorf("%s: expected to be rejected, got %q", tt.origin, got)
+		}
+	}
+}
+
+func TestCORS_RouteMethods(t *testing.T) {
+	opts := CORSOptions{
+		AllowedOrigins: []string{"https://quantic.es"},
+		Routes: []CORSRoute{
+			{PathPrefix: "/api/v1/admin", Methods: []string{"GET", "POST"}},
+			{PathPrefix: "/api/v1/admin/import", Methods: []string{"post"}},
+		},
+	}
+
+	tests := []struct {
+		path, want string
+	}{
+		{"/api/v1/logos/AAPL", "GET, OPTIONS"},
+		{"/api/v1/admin/stats", "GET, POST, OPTIONS"},
+		{"/api/v1/admin/import", "POST, OPTIONS"}, // Longest prefix wins
+	}
+	for _, tt := range tests {
+		w := corsRequest(t, opts, "OPTIONS", tt.path, "https://quantic.es")
+		if got := w.Header().Get("Access-Control-Allow-Methods"); got != tt.want {
+			t.Errorf("%s: Allow-Methods = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestCORS_AllowCredentials(t *testing.T) {
+	w := corsRequest(t, CORSOptions{AllowedOrigins: []string{"https://quantic.es"}}, "GET", "/x", "https://quantic.es")
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Errorf("credentials should be off by default, got %q", got)
+	}
+
+	opts := CORSOptions{AllowedOrigins: []string{"https://quantic.es"}, AllowCredentials: true}
+	w = corsRequest(t, opts, "GET", "/x", "https://quantic.es")
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Errorf("expected Allow-Credentials: true, got %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://quantic.es" {
+		t.Errorf("with credentials the origin must be echoed, got %q", got)
+	}
+
+	// Disallowed origins get nothing, credentials included.
+	w = corsRequest(t, opts, "GET", "/x", "https://evil.com")
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Errorf("disallowed origin got Allow-Credentials %q", got)
+	}
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaintenanceOptions configures Maintenance.
+type MaintenanceOptions struct {
+	// Enabled reports whether maintenance is on. It's asked on every
+	// request, so it must be cheap (e.g. a flags.Set lookup).
+	Enabled    func() bool
+	Message    string
+	StatusURL  string // Added to the 503 body if set
+	RetryAfter time.Duration
+
+	// Exempt lists the routes that still change things during maintenance,
+	// as gin's full paths (e.g. "/api/v1/admin/flags/:name"): at least the
+	// one that turns it off.
+	Exempt []string
+}
+
+// Maintenance refuses the requests that would change something (any method
+// but GET, HEAD and OPTIONS) with 503 and Retry-After while maintenance is
+// on. Reads go through; what they may still do is the handler's business.
+func Maintenance(opts MaintenanceOptions) gin.HandlerFunc {
+	retryAfter := strconv.Itoa(int(opts.RetryAfter.Seconds()))
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+		if !opts.Enabled() || slices.Contains(opts.Exempt, c.FullPath()) {
+			c.Next()
+			return
+		}
+
+		c.Header("Retry-After", retryAfter)
+		body := gin.H{"error": "maintenance", "message": opts.Message}
+		if opts.StatusURL != "" {
+			body["status_url"] = opts.StatusURL
+		}
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, body)
+	}
+}
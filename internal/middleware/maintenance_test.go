@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMaintenance(t *testing.T) {
+	on := true
+	router := gin.New()
+	router.Use(Maintenance(MaintenanceOptions{
+		Enabled:    func() bool { return on },
+		Message:    "migrating storage",
+		RetryAfter: 10 * time.Minute,
+		Exempt:     []string{"/admin/flags/:name"},
+	}))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/logos/:symbol", ok)
+	router.POST("/admin/import", ok)
+	router.PUT("/admin/flags/:name", ok)
+
+	tests := []struct {
+		method, path string
+		on           bool
+		wantStatus   int
+	}{
+		{"GET", "/logos/AAPL", true, http.StatusOK},
+		{"POST", "/admin/import", true, http.StatusServiceUnavailable},
+		{"PUT", "/admin/flags/maintenance_mode", true, http.StatusOK},
+		{"POST", "/admin/import", false, http.StatusOK},
+	}
+	for _, tt := range tests {
+		on = tt.on
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s %s (maintenance %v): expected %d, got %d", tt.method, tt.path, tt.on, tt.wantStatus, w.Code)
+		}
+		if w.Code == http.StatusServiceUnavailable && w.Header().Get("Retry-After") != "600" {
+			t.Errorf("expected Retry-After 600, got %q", w.Header().Get("Retry-After"))
+		}
+	}
+}
@@ -1,26 +1,114 @@
 package middleware
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
 	"net/http"
+	"sort"
+	"strconv"
 	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/time/rate"
+
+	"github.com/fleveque/logo-service/internal/clock"
 )
 
-// RateLimit returns per-API-key rate limiting middleware using token buckets.
+// RateLimiter holds one token bucket per API key.
 //
 // Token bucket algorithm: each key gets a bucket that fills at `rps` tokens/sec
 // up to `burst` tokens. Each request consumes one token. If the bucket is empty,
-// the request is rejected with 429.
+// the request is rejected with 429 and a Retry-After header.
+//
+// The rate and burst come from the key's Tier (see SetTier); keys without one
+// get the limiter's default.
 //
 // sync.Mutex protects the map of limiters from concurrent goroutine access.
 // This is one of the few cases where Go uses traditional locks instead of channels —
 // a shared map with simple read/write is cleaner with a mutex than a channel.
+type RateLimiter struct {
+	defaultTier Tier
+	tiers       map[string]Tier // By API key
+	clock       clock.Clock
+
+	mu       sync.Mutex
+	limiters map[string]*keyLimiter
+	usage    map[string]*Usage // By API key, since the last TakeUsage
+
+	// transferred is the bytes served this month by key ID, as of the last
+	// SetTransferred, for the monthly transfer caps.
+	transferred   map[string]int64
+	transferMonth string // "2006-01" (UTC) transferred is for
+}
+
+// Tier is a rate limit plan, e.g. "free" or "partner".
+type Tier struct {
+	Name  string
+	RPS   float64
+	Burst int
+
+	// Credits are extra requests per day, spent only once the bucket is
+	// empty: a client that goes over its rate now and then is slowed down
+	// by its credits running out rather than rejected at once. They refill
+	// continuously, Credits per 24 hours. Zero means a hard limit.
+	Credits int
+
+	// MonthlyTransfer caps the bytes served to a key per calendar month
+	// (UTC); past it, every request is rejected until the month ends. Zero
+	// means no cap.
+	MonthlyTransfer int64
+}
+
+// DefaultTier names the tier of keys that weren't given one.
+const DefaultTier = "default"
+
+// keyLimiter is one key's bucket, and its credits if its tier has any.
+type keyLimiter struct {
+	tier    Tier
+	tokens  *rate.Limiter
+	credits *rate.Limiter // nil: no credits
+}
+
+// NewRateLimiter creates a limiter allowing rps requests per second per key,
+// with bursts of up to burst requests.
+func NewRateLimiter(rps float64, burst int) *RateLimiter {
+	return &RateLimiter{
+		defaultTier: Tier{Name: DefaultTier, RPS: rps, Burst: burst},
+		tiers:       make(map[string]Tier),
+		clock:       clock.System,
+		limiters:    make(map[string]*keyLimiter),
+		usage:       make(map[string]*Usage),
+	}
+}
+
+// SetClock replaces the system clock, so tests can refill buckets by
+// advancing a fake clock instead of sleeping. Call it before the limiter is in use.
+func (l *RateLimiter) SetClock(c clock.Clock) {
+	l.clock = c
+}
+
+// SetTier puts API keys on a tier. A tier named DefaultTier replaces the
+// default for every key not put on another one. Call it before the limiter
+// is in use.
+func (l *RateLimiter) SetTier(tier Tier, apiKeys ...string) {
+	if tier.Name == DefaultTier {
+		l.defaultTier = tier
+	}
+	for _, key := range apiKeys {
+		l.tiers[key] = tier
+	}
+}
+
+// RateLimit returns per-API-key rate limiting middleware using token buckets.
+// Use NewRateLimiter instead when buckets need inspecting (see Bucket).
 func RateLimit(rps float64, burst int) gin.HandlerFunc {
-	var mu sync.Mutex
-	limiters := make(map[string]*rate.Limiter)
+	return NewRateLimiter(rps, burst).Middleware()
+}
 
+// Middleware returns the gin middleware enforcing the limits.
+func (l *RateLimiter) Middleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get the API key set by auth middleware
 		key, exists := c.Get("api_key")
@@ -31,16 +119,17 @@ func RateLimit(rps float64, burst int) gin.HandlerFunc {
 		}
 
 		apiKey := key.(string) // Type assertion: interface{} → string
-
-		mu.Lock()
-		limiter, exists := limiters[apiKey]
-		if !exists {
-			limiter = rate.NewLimiter(rate.Limit(rps), burst)
-			limiters[apiKey] = limiter
+		if !l.allowTransfer(c, apiKey) {
+			l.count(apiKey, l.limiter(apiKey).tier.Name, false, false)
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error": "monthly transfer cap exceeded",
+			})
+			return
 		}
-		mu.Unlock()
+		allowed, credit, tier := l.allow(c, apiKey)
+		l.count(apiKey, tier, allowed, credit)
 
-		if !limiter.Allow() {
+		if !allowed {
 			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
 				"error": "rate limit exceeded",
 			})
@@ -48,5 +137,265 @@ func RateLimit(rps float64, burst int) gin.HandlerFunc {
 		}
 
 		c.Next()
+		l.countBytes(apiKey, c.Writer.Size())
+	}
+}
+
+// allowTransfer reports whether the key is under its tier's monthly
+// transfer cap, if it has one, and sets X-Transfer-Remaining, and
+// Retry-After (until the next month) when it isn't.
+func (l *RateLimiter) allowTransfer(c *gin.Context, apiKey string) bool {
+	limit := l.limiter(apiKey).tier.MonthlyTransfer
+	if limit <= 0 {
+		return true
+	}
+	now := l.clock.Now().UTC()
+	remaining := max(limit-l.transferUsed(apiKey, now), 0)
+	c.Header("X-Transfer-Remaining", strconv.FormatInt(remaining, 10))
+	if remaining > 0 {
+		return true
+	}
+	nextMonth := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(nextMonth.Sub(now).Seconds()))))
+	return false
+}
+
+// transferUsed is the bytes served to the key in now's month: as of the
+// last SetTransferred, plus what was counted since.
+func (l *RateLimiter) transferUsed(apiKey string, now time.Time) int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var used int64
+	if l.transferMonth == now.Format("2006-01") {
+		used = l.transferred[KeyID(apiKey)]
+	}
+	if u, ok := l.usage[apiKey]; ok {
+		used += u.Bytes
+	}
+	return used
+}
+
+// SetTransferred sets the bytes served this month by key ID ("2006-01",
+// UTC), from the saved usage of every instance, for the monthly transfer
+// caps. Bytes counted since the usage was last taken come on top. Until
+// it's called for the current month, keys start the month from zero.
+func (l *RateLimiter) SetTransferred(month string, transferred map[string]int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.transferMonth, l.transferred = month, transferred
+}
+
+// Allow takes a token from the request's key, for limits that only apply
+// to some requests of a route, decided by the handler (the middleware
+// applies to all of them). Like the middleware, it sets the rate limit
+// headers, and Retry-After when it returns false; the handler answers the
+// 429 itself. Requests without an API key are allowed. Usage isn't counted.
+func (l *RateLimiter) Allow(c *gin.Context) bool {
+	key, exists := c.Get("api_key")
+	if !exists {
+		return true
+	}
+	allowed, _, _ := l.allow(c, key.(string))
+	return allowed
+}
+
+// allow takes a token, or a credit once the bucket is empty, and sets the
+// headers. It returns whether the request may go on, whether that took a
+// credit, and the key's tier.
+func (l *RateLimiter) allow(c *gin.Context, apiKey string) (allowed, credit bool, tier string) {
+	limiter := l.limiter(apiKey)
+	t := limiter.tier
+
+	now := l.clock.Now()
+	allowed = limiter.tokens.AllowN(now, 1)
+	credit = !allowed && limiter.credits != nil && limiter.credits.AllowN(now, 1)
+	setRateLimitHeaders(c, limiter.tokens.TokensAt(now), t.RPS, t.Burst)
+	if limiter.credits != nil {
+		c.Header("X-RateLimit-Credits", strconv.Itoa(max(int(math.Floor(limiter.credits.TokensAt(now))), 0)))
+	}
+	if !allowed && !credit {
+		c.Header("Retry-After", strconv.Itoa(secondsUntil(1, limiter.tokens.TokensAt(now), t.RPS)))
+		return false, false, t.Name
+	}
+	return true, credit, t.Name
+}
+
+// limiter returns the key's bucket, creating a full one on first use.
+func (l *RateLimiter) limiter(apiKey string) *keyLimiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	limiter, exists := l.limiters[apiKey]
+	if !exists {
+		tier := l.tier(apiKey)
+		limiter = &keyLimiter{tier: tier, tokens: rate.NewLimiter(rate.Limit(tier.RPS), tier.Burst)}
+		if tier.Credits > 0 {
+			limiter.credits = rate.NewLimiter(rate.Limit(float64(tier.Credits)/(24*60*60)), tier.Credits)
+		}
+		l.limiters[apiKey] = limiter
+	}
+	return limiter
+}
+
+// tier returns the key's tier, or the default one.
+func (l *RateLimiter) tier(apiKey string) Tier {
+	if tier, ok := l.tiers[apiKey]; ok {
+		return tier
+	}
+	return l.defaultTier
+}
+
+// Usage counts one key's requests since the last TakeUsage.
+type Usage struct {
+	KeyID       string // See KeyID
+	Tier        string
+	Requests    int64 // Allowed, including those on credits
+	Rejected    int64 // Answered 429
+	CreditsUsed int64
+	Bytes       int64 // Response bodies served, errors included
+}
+
+// count adds a request to the key's usage.
+func (l *RateLimiter) count(apiKey, tier string, allowed, credit bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	u, exists := l.usage[apiKey]
+	if !exists {
+		u = &Usage{KeyID: KeyID(apiKey)}
+		l.usage[apiKey] = u
+	}
+	u.Tier = tier
+	switch {
+	case !allowed:
+		u.Rejected++
+	case credit:
+		u.Requests++
+		u.CreditsUsed++
+	default:
+		u.Requests++
+	}
+}
+
+// countBytes adds a response's size to the key's usage.
+func (l *RateLimiter) countBytes(apiKey string, size int) {
+	if size <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// TakeUsage may have run since count.
+	u, exists := l.usage[apiKey]
+	if !exists {
+		u = &Usage{KeyID: KeyID(apiKey), Tier: l.tier(apiKey).Name}
+		l.usage[apiKey] = u
+	}
+	u.Bytes += int64(size)
+}
+
+// TakeUsage returns the usage counted since the last call, sorted by KeyID,
+// and starts counting again from zero.
+func (l *RateLimiter) TakeUsage() []Usage {
+	l.mu.Lock()
+	taken := l.usage
+	l.usage = make(map[string]*Usage)
+	l.mu.Unlock()
+
+	usage := make([]Usage, 0, len(taken))
+	for _, u := range taken {
+		usage = append(usage, *u)
+	}
+	sort.Slice(usage, func(i, j int) bool { return usage[i].KeyID < usage[j].KeyID })
+	return usage
+}
+
+// KeyID returns a fingerprint of an API key (the start of its SHA-256), to
+// tell keys apart in stats without storing or showing the keys themselves.
+func KeyID(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:6])
+}
+
+// Bucket is a snapshot of one key's token bucket.
+type Bucket struct {
+	Tier          string  `json:"tier"`
+	Tokens        float64 `json:"tokens"` // Requests that can be made right now (may be fractional)
+	Burst         int     `json:"burst"`  // Bucket size
+	RatePerSecond float64 `json:"rate_per_second"`
+	ResetSeconds  int     `json:"reset_seconds"` // Until the bucket is full again
+	Credits       float64 `json:"credits"`       // Left for when the bucket is empty
+	CreditsPerDay int     `json:"credits_per_day"`
+	Active        bool    `json:"active"` // False if the key hasn't made a request since start or reset
+
+	MonthlyTransfer int64 `json:"monthly_transfer,omitempty"` // Bytes; 0: no cap
+	TransferUsed    int64 `json:"transfer_used"`              // Bytes served this month (UTC)
+}
+
+// Bucket returns the current state of a key's bucket. A key that hasn't been
+// seen (or was reset) reports a full bucket with Active false.
+func (l *RateLimiter) Bucket(apiKey string) Bucket {
+	l.mu.Lock()
+	limiter, exists := l.limiters[apiKey]
+	tier := l.tier(apiKey)
+	l.mu.Unlock()
+
+	b := Bucket{
+		Tier:          tier.Name,
+		Tokens:        float64(tier.Burst),
+		Burst:         tier.Burst,
+		RatePerSecond: tier.RPS,
+		Credits:       float64(tier.Credits),
+		CreditsPerDay: tier.Credits,
+
+		MonthlyTransfer: tier.MonthlyTransfer,
+		TransferUsed:    l.transferUsed(apiKey, l.clock.Now().UTC()),
+	}
+	if exists {
+		now := l.clock.Now()
+		b.Tokens = limiter.tokens.TokensAt(now)
+		b.ResetSeconds = secondsUntil(float64(tier.Burst), b.Tokens, tier.RPS)
+		if limiter.credits != nil {
+			b.Credits = limiter.credits.TokensAt(now)
+		}
+		b.Active = true
+	}
+	return b
+}
+
+// Reset drops a key's bucket, so its next request starts with a full one
+// (and full credits). It reports whether the key had a bucket.
+func (l *RateLimiter) Reset(apiKey string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	_, exists := l.limiters[apiKey]
+	delete(l.limiters, apiKey)
+	return exists
+}
+
+// setRateLimitHeaders tells clients where they stand, so well-behaved ones
+// can slow down before hitting a 429:
+//
+//	X-RateLimit-Limit      bucket size (the burst)
+//	X-RateLimit-Remaining  requests that can be made right now
+//	X-RateLimit-Reset      seconds until the bucket is full again
+func setRateLimitHeaders(c *gin.Context, tokens, rps float64, burst int) {
+	remaining := int(math.Floor(tokens))
+	if remaining < 0 {
+		remaining = 0
+	}
+	c.Header("X-RateLimit-Limit", strconv.Itoa(burst))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+	c.Header("X-RateLimit-Reset", strconv.Itoa(secondsUntil(float64(burst), tokens, rps)))
+}
+
+// secondsUntil returns how many whole seconds until the bucket holds `want`
+// tokens, rounded up so clients that wait that long are never rejected.
+func secondsUntil(want, tokens, rps float64) int {
+	if tokens >= want || rps <= 0 {
+		return 0
 	}
+	return int(math.Ceil((want - tokens) / rps))
 }
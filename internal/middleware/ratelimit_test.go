@@ -4,8 +4,11 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+
+	"github.com/fleveque/logo-service/internal/clock"
 )
 
 func TestRateLimit_AllowsNormalTraffic(t *testing.T) {
@@ -98,3 +101,264 @@ func TestRateLimit_PerKeyIsolation(t *testing.T) {
 		t.Errorf("key-b first request: expected 200, got %d", w.Code)
 	}
 }
+
+func TestRateLimit_Headers(t *testing.T) {
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("api_key", "test-key")
+		c.Next()
+	})
+	router.Use(RateLimit(0.5, 2)) // One token every 2s, burst of 2
+	router.GET("/test", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	tests := []struct {
+		code                         int
+		remaining, reset, retryAfter string
+	}{
+		{http.StatusOK, "1", "2", ""},
+		{http.StatusOK, "0", "4", ""},
+		{http.StatusTooManyRequests, "0", "4", "2"},
+	}
+	for i, tt := range tests {
+		req := httptest.NewRequest("GET", "/test", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != tt.code {
+			t.Fatalf("request %d: expected %d, got %d", i, tt.code, w.Code)
+		}
+		h := w.Header()
+		if got := h.Get("X-RateLimit-Limit"); got != "2" {
+			t.Errorf("request %d: X-RateLimit-Limit = %q, want 2", i, got)
+		}
+		if got := h.Get("X-RateLimit-Remaining"); got != tt.remaining {
+			t.Errorf("request %d: X-RateLimit-Remaining = %q, want %s", i, got, tt.remaining)
+		}
+		if got := h.Get("X-RateLimit-Reset"); got != tt.reset {
+			t.Errorf("request %d: X-RateLimit-Reset = %q, want %s", i, got, tt.reset)
+		}
+		if got := h.Get("Retry-After"); got != tt.retryAfter {
+			t.Errorf("request %d: Retry-After = %q, want %q", i, got, tt.retryAfter)
+		}
+	}
+}
+
+func TestRateLimiter_BucketAndReset(t *testing.T) {
+	limiter := NewRateLimiter(0.5, 2)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("api_key", "test-key")
+		c.Next()
+	})
+	router.Use(limiter.Middleware())
+	router.GET("/test", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	if b := limiter.Bucket("test-key"); b.Active || b.Tokens != 2 || b.Burst != 2 {
+		t.Errorf("unseen key: got %+v, want full inactive bucket", b)
+	}
+
+	for i := 0; i < 3; i++ {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/test", nil))
+	}
+
+	b := limiter.Bucket("test-key")
+	if !b.Active || b.Tokens >= 1 {
+		t.Errorf("after exhausting: got %+v, want active bucket with < 1 token", b)
+	}
+	if b.ResetSeconds == 0 {
+		t.Error("expected a non-zero reset time for an empty bucket")
+	}
+
+	if !limiter.Reset("test-key") {
+		t.Error("Reset should report the key had a bucket")
+	}
+	if limiter.Reset("test-key") {
+		t.Error("second Reset should report no bucket")
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/test", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("after reset: expected 200, got %d", w.Code)
+	}
+}
+
+func TestRateLimiter_RefillsOverTime(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	limiter := NewRateLimiter(0.5, 1) // One token every 2s
+	limiter.SetClock(fake)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("api_key", "test-key")
+		c.Next()
+	})
+	router.Use(limiter.Middleware())
+	router.GET("/test", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	steps := []struct {
+		advance time.Duration
+		code    int
+	}{
+		{0, http.StatusOK},
+		{0, http.StatusTooManyRequests},
+		{time.Second, http.StatusTooManyRequests}, // Half a token
+		{time.Second, http.StatusOK},              // Refilled, no sleeping
+	}
+	for i, step := range steps {
+		fake.Advance(step.advance)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/test", nil))
+		if w.Code != step.code {
+			t.Errorf("step %d: expected %d, got %d", i, step.code, w.Code)
+		}
+	}
+}
+
+func TestRateLimiter_TiersAndCredits(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	limiter := NewRateLimiter(1, 1)
+	limiter.SetClock(fake)
+	limiter.SetTier(Tier{Name: "partner", RPS: 1, Burst: 2, Credits: 2}, "partner-key")
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("api_key", c.GetHeader("X-API-Key"))
+		c.Next()
+	})
+	router.Use(limiter.Middleware())
+	router.GET("/test", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	steps := []struct {
+		key     string
+		code    int
+		credits string // X-RateLimit-Credits; only tiers with credits send it
+	}{
+		{"free-key", http.StatusOK, ""},
+		{"free-key", http.StatusTooManyRequests, ""},
+		{"partner-key", http.StatusOK, "2"},
+		{"partner-key", http.StatusOK, "2"},
+		{"partner-key", http.StatusOK, "1"}, // The bucket is empty: a credit is spent
+		{"partner-key", http.StatusOK, "0"},
+		{"partner-key", http.StatusTooManyRequests, "0"},
+	}
+	for i, step := range steps {
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.Header.Set("X-API-Key", step.key)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != step.code {
+			t.Errorf("step %d (%s): expected %d, got %d", i, step.key, step.code, w.Code)
+		}
+		if got := w.Header().Get("X-RateLimit-Credits"); got != step.credits {
+			t.Errorf("step %d (%s): X-RateLimit-Credits = %q, want %q", i, step.key, got, step.credits)
+		}
+	}
+
+	if b := limiter.Bucket("partner-key"); b.Tier != "partner" || b.Burst != 2 || b.Credits >= 1 || b.CreditsPerDay != 2 {
+		t.Errorf("partner bucket: got %+v", b)
+	}
+
+	usage := limiter.TakeUsage()
+	want := map[string]Usage{
+		KeyID("free-key"):    {KeyID: KeyID("free-key"), Tier: DefaultTier, Requests: 1, Rejected: 1, Bytes: 2},
+		KeyID("partner-key"): {KeyID: KeyID("partner-key"), Tier: "partner", Requests: 4, Rejected: 1, CreditsUsed: 2, Bytes: 8},
+	}
+	if len(usage) != len(want) {
+		t.Fatalf("expected usage for 2 keys, got %+v", usage)
+	}
+	for _, u := range usage {
+		if u != want[u.KeyID] {
+			t.Errorf("usage: got %+v, want %+v", u, want[u.KeyID])
+		}
+	}
+	if again := limiter.TakeUsage(); len(again) != 0 {
+		t.Errorf("expected TakeUsage to start over, got %+v", again)
+	}
+}
+
+func TestRateLimiter_MonthlyTransfer(t *testing.T) {
+	fake := clock.NewFake(time.Date(2025, 3, 31, 12, 0, 0, 0, time.UTC))
+	limiter := NewRateLimiter(100, 100)
+	limiter.SetClock(fake)
+	limiter.SetTier(Tier{Name: "capped", RPS: 100, Burst: 100, MonthlyTransfer: 1000}, "capped-key")
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("api_key", "capped-key")
+		c.Next()
+	})
+	router.Use(limiter.Middleware())
+	router.GET("/test", func(c *gin.Context) {
+		c.Data(http.StatusOK, "image/png", make([]byte, 300))
+	})
+	get := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/test", nil))
+		return w
+	}
+
+	// 600 bytes served by other instances, then 300 here: 100 left, so one
+	// more request goes through and overshoots.
+	limiter.SetTransferred("2025-03", map[string]int64{KeyID("capped-key"): 600})
+	for i, want := range []string{"400", "100"} {
+		if w := get(); w.Code != http.StatusOK || w.Header().Get("X-Transfer-Remaining") != want {
+			t.Errorf("request %d: got %d with %q remaining, want 200 with %q", i, w.Code, w.Header().Get("X-Transfer-Remaining"), want)
+		}
+	}
+	w := get()
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "43200" {
+		t.Errorf("over the cap: got %d, Retry-After %q; want 429 until April", w.Code, w.Header().Get("Retry-After"))
+	}
+	if b := limiter.Bucket("capped-key"); b.MonthlyTransfer != 1000 || b.TransferUsed != 1200 {
+		t.Errorf("bucket: got %+v", b)
+	}
+
+	// A new month starts from zero, even before the next SetTransferred.
+	limiter.TakeUsage()
+	fake.Advance(13 * time.Hour)
+	if w := get(); w.Code != http.StatusOK {
+		t.Errorf("new month: expected 200, got %d", w.Code)
+	}
+}
+
+func TestRateLimiter_Allow(t *testing.T) {
+	limiter := NewRateLimiter(0.5, 1)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("api_key", "test-key")
+		c.Next()
+	})
+	router.GET("/test", func(c *gin.Context) {
+		if !limiter.Allow(c) {
+			c.String(http.StatusTooManyRequests, "slow down")
+			return
+		}
+		c.String(http.StatusOK, "ok")
+	})
+
+	for i, want := range []int{http.StatusOK, http.StatusTooManyRequests} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/test", nil))
+		if w.Code != want {
+			t.Errorf("request %d: expected %d, got %d", i, want, w.Code)
+		}
+		if w.Header().Get("X-RateLimit-Limit") != "1" {
+			t.Errorf("request %d: expected the rate limit headers", i)
+		}
+		if retry := w.Header().Get("Retry-After"); (want == http.StatusTooManyRequests) != (retry != "") {
+			t.Errorf("request %d: Retry-After = %q", i, retry)
+		}
+	}
+	if usage := limiter.TakeUsage(); len(usage) != 0 {
+		t.Errorf("expected Allow not to count usage, got %+v", usage)
+	}
+}
@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The API is versioned by path (/api/v1, /api/v2). Clients that can't change
+// paths (an <img src> baked into a frontend) can ask for a version through
+// the Accept header instead:
+//
+//	Accept: application/vnd.logo-service.v2+json
+//
+// Handlers that answer differently per version read it with APIVersion.
+
+// versionKey is where the negotiated version is kept in the gin context.
+const versionKey = "api_version"
+
+// vendorPrefix is the media type prefix of versioned Accept values.
+const vendorPrefix = "application/vnd.logo-service.v"
+
+// APIVersion returns the API version negotiated for the request, or 1 when
+// the request didn't go through Version (e.g. in a handler test).
+func APIVersion(c *gin.Context) int {
+	if v, ok := c.Get(versionKey); ok {
+		return v.(int)
+	}
+	return 1
+}
+
+// Version returns middleware that settles the API version of a request: the
+// one the path stands for, unless the Accept header asks for another one.
+// Asking for a version not in supported gets 406 Not Acceptable. The result
+// is echoed in X-API-Version.
+func Version(pathVersion int, supported []int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		version := pathVersion
+		if requested, ok := acceptedVersion(c.GetHeader("Accept")); ok {
+			if !containsInt(supported, requested) {
+				c.AbortWithStatusJSON(http.StatusNotAcceptable, gin.H{
+					"error": fmt.Sprintf("API version %d is not supported", requested),
+				})
+				return
+			}
+			version = requested
+		}
+
+		c.Set(versionKey, version)
+		c.Header("X-API-Version", strconv.Itoa(version))
+		c.Next()
+	}
+}
+
+// acceptedVersion finds a vendor media type in an Accept header. Other
+// types (image/png, */*) don't select a version.
+func acceptedVersion(accept string) (int, bool) {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		rest, ok := strings.CutPrefix(mediaType, vendorPrefix)
+		if !ok {
+			continue
+		}
+		rest, _, _ = strings.Cut(rest, "+") // "2+json" → "2"
+		if n, err := strconv.Atoi(rest); err == nil && n > 0 {
+			return n, true
+		}
+	}
+	return 0, false
+}
+
+func containsInt(values []int, v int) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// DeprecationOptions describe an API version being phased out.
+type DeprecationOptions struct {
+	Since  time.Time // When the version was deprecated (required)
+	Sunset time.Time // When it stops working; zero if not decided yet
+
+	// Successor is the path prefix of the replacement, e.g. "/api/v2". The
+	// part of the path after Prefix is appended to it for the Link header.
+	Prefix    string
+	Successor string
+}
+
+// Deprecation returns middleware that marks responses of a deprecated
+// version (RFC 9745 Deprecation, RFC 8594 Sunset) and links the same route
+// in its successor. Requests that negotiated a newer version through
+// Accept aren't marked: they don't use the old version.
+func Deprecation(version int, opts DeprecationOptions) gin.HandlerFunc {
+	deprecation := "@" + strconv.FormatInt(opts.Since.Unix(), 10)
+	sunset := ""
+	if !opts.Sunset.IsZero() {
+		sunset = opts.Sunset.UTC().Format(http.TimeFormat)
+	}
+
+	return func(c *gin.Context) {
+		if APIVersion(c) != version {
+			c.Next()
+			return
+		}
+
+		c.Header("Deprecation", deprecation)
+		if sunset != "" {
+			c.Header("Sunset", sunset)
+		}
+		if rest, ok := strings.CutPrefix(c.Request.URL.Path, opts.Prefix); ok && opts.Successor != "" {
+			c.Header("Link", fmt.Sprintf("<%s%s>; rel=\"successor-version\"", opts.Successor, rest))
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestVersion_Negotiation(t *testing.T) {
+	router := gin.New()
+	router.Use(Version(1, []int{1, 2}))
+	router.GET("/api/v1/test", func(c *gin.Context) {
+		c.String(http.StatusOK, strconv.Itoa(APIVersion(c)))
+	})
+
+	tests := []struct {
+		name       string
+		accept     string
+		wantStatus int
+		wantBody   string
+	}{
+		{"no Accept", "", http.StatusOK, "1"},
+		{"other types", "image/png, */*;q=0.8", http.StatusOK, "1"},
+		{"vendor v2", "application/vnd.logo-service.v2+json", http.StatusOK, "2"},
+		{"vendor v2 among others", "image/png, application/vnd.logo-service.v2+json;q=0.9", http.StatusOK, "2"},
+		{"unsupported version", "application/vnd.logo-service.v9+json", http.StatusNotAcceptable, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/v1/test", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d", tt.wantStatus, w.Code)
+			}
+			if tt.wantBody != "" {
+				if w.Body.String() != tt.wantBody {
+					t.Errorf("expected version %s, got %s", tt.wantBody, w.Body.String())
+				}
+				if got := w.Header().Get("X-API-Version"); got != tt.wantBody {
+					t.Errorf("expected X-API-Version %s, got %q", tt.wantBody, got)
+				}
+			}
+		})
+	}
+}
+
+func TestDeprecation(t *testing.T) {
+	since := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2027, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	router := gin.New()
+	router.Use(Version(1, []int{1, 2}), Deprecation(1, DeprecationOptions{
+		Since: since, Sunset: sunset, Prefix: "/api/v1", Successor: "/api/v2",
+	}))
+	router.GET("/api/v1/logos/:symbol", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	req := httptest.NewRequest("GET", "/api/v1/logos/AAPL", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if got := w.Header().Get("Deprecation"); got != "@1793491200" {
+		t.Errorf("unexpected Deprecation header %q", got)
+	}
+	if got := w.Header().Get("Sunset"); got != "Sat, 01 May 2027 00:00:00 GMT" {
+		t.Errorf("unexpected Sunset header %q", got)
+	}
+	if got := w.Header().Get("Link"); got != `</api/v2/logos/AAPL>; rel="successor-version"` {
+		t.Errorf("unexpected Link header %q", got)
+	}
+
+	// A client that already asks for v2 isn't told v1 is going away.
+	req = httptest.NewRequest("GET", "/api/v1/logos/AAPL", nil)
+	req.Header.Set("Accept", "application/vnd.logo-service.v2+json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if got := w.Header().Get("Deprecation"); got != "" {
+		t.Errorf("expected no Deprecation header after negotiating v2, got %q", got)
+	}
+}
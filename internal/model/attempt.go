@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// Outcomes of an acquisition attempt.
+const (
+	AttemptFound       = "found"
+	AttemptMiss        = "miss"        // The provider answered: it has no (valid) logo
+	AttemptUnavailable = "unavailable" // Network error, timeout, 5xx...
+	AttemptSkipped     = "skipped"     // Not asked: circuit open, or the LLM for an unknown symbol
+	AttemptCancelled   = "cancelled"   // Given up: the client left, or another provider won a race
+)
+
+// Attempt records one provider asked for a symbol's logo on a cache miss,
+// so "why is XYZ missing?" is answered by the admin trace endpoint rather
+// than by searching the logs. The attempts of one cache miss share a Trace.
+type Attempt struct {
+	ID         int64     `db:"id" json:"id"`
+	Trace      string    `db:"trace" json:"trace"`
+	Symbol     string    `db:"symbol" json:"symbol"`
+	Provider   string    `db:"provider" json:"provider"` // index, github or llm
+	Outcome    string    `db:"outcome" json:"outcome"`
+	Error      string    `db:"error" json:"error,omitempty"`
+	Source     string    `db:"source" json:"source,omitempty"` // The logo's source, when found
+	DurationMS int64     `db:"duration_ms" json:"duration_ms"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"` // When the provider was asked
+}
@@ -0,0 +1,10 @@
+package model
+
+import "errors"
+
+// ErrLowDiskSpace is returned instead of acquiring a new logo when the
+// filesystem holding logo_dir is below the configured minimum free space.
+// Logos already on disk keep being served. It's declared here rather than
+// in storage, which reports it, so providers can stop an import on it
+// without depending on storage.
+var ErrLowDiskSpace = errors.New("low disk space")
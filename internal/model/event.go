@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+// Event types in the changes feed. Created and updated both mean "fetch
+// this logo again"; deleted means it's no longer served.
+const (
+	EventCreated = "created" // First logo for a symbol
+	EventUpdated = "updated" // A new image, or served again after review or a block
+	EventDeleted = "deleted" // No longer served: blocked, taken down or sent to review
+)
+
+// Event is one change to what the service serves for a symbol. IDs only
+// grow, so the last ID a client has seen is its cursor into the feed.
+type Event struct {
+	ID        int64     `db:"id" json:"id"`
+	Type      string    `db:"type" json:"type"`
+	Symbol    string    `db:"symbol" json:"symbol"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// DeadLetter is an event that couldn't be published to a broker after
+// every retry. The event stays in the changes feed; this records that one
+// target never got it, and why.
+type DeadLetter struct {
+	ID        int64     `db:"id" json:"id"`
+	Target    string    `db:"target" json:"target"` // e.g. "nats:logo-service.events"
+	EventID   int64     `db:"event_id" json:"event_id"`
+	Type      string    `db:"type" json:"type"`
+	Symbol    string    `db:"symbol" json:"symbol"`
+	Error     string    `db:"error" json:"error"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
@@ -42,9 +42,36 @@ const (
 	StatusPending    LogoStatus = "pending"
 	StatusProcessed  LogoStatus = "processed"
 	StatusFailed     LogoStatus = "failed"
-	StatusNotFound   LogoStatus = "not_found"
+	StatusNotFound   LogoStatus = "not_found" // No provider had one; looked for again at NextCheckAt
+	StatusReview     LogoStatus = "review" // Processed, but held back until an admin approves it
 )
 
+// Confidence levels reported by LLM providers, lowest to highest.
+const (
+	ConfidenceLow    = "low"
+	ConfidenceMedium = "medium"
+	ConfidenceHigh   = "high"
+)
+
+// confidenceRank orders confidence levels so they can be compared.
+var confidenceRank = map[string]int{
+	ConfidenceLow:    1,
+	ConfidenceMedium: 2,
+	ConfidenceHigh:   3,
+}
+
+// ValidConfidence checks if a string is a known confidence level.
+func ValidConfidence(c string) bool {
+	_, ok := confidenceRank[c]
+	return ok
+}
+
+// ConfidenceAtLeast reports whether confidence c meets the minimum level.
+// Unknown values rank below "low", so they never pass a configured minimum.
+func ConfidenceAtLeast(c, minimum string) bool {
+	return confidenceRank[c] >= confidenceRank[minimum]
+}
+
 // Logo is the main domain entity. Each field has two tags:
 //   - `db:"column_name"` — used by sqlx to scan database rows
 //   - `json:"field_name"` — used for JSON serialization (API responses)
@@ -52,8 +79,12 @@ type Logo struct {
 	ID           int64      `db:"id" json:"id"`
 	Symbol       string     `db:"symbol" json:"symbol"`
 	CompanyName  string     `db:"company_name" json:"company_name"`
+	Website      string     `db:"website" json:"website,omitempty"` // The company's, set by admins
 	Source       string     `db:"source" json:"source"`
 	OriginalURL  string     `db:"original_url" json:"original_url"`
+	Confidence   string     `db:"confidence" json:"confidence,omitempty"` // Only set for LLM-sourced logos
+	License      string     `db:"license" json:"license,omitempty"`       // As reported by the source, e.g. "MIT", "CC BY-SA 4.0"
+	Attribution  string     `db:"attribution" json:"attribution,omitempty"`
 	HasXS        bool       `db:"has_xs" json:"has_xs"`
 	HasS         bool       `db:"has_s" json:"has_s"`
 	HasM         bool       `db:"has_m" json:"has_m"`
@@ -63,6 +94,55 @@ type Logo struct {
 	ErrorMessage *string    `db:"error_message" json:"error_message,omitempty"`
 	CreatedAt    time.Time  `db:"created_at" json:"created_at"`
 	UpdatedAt    time.Time  `db:"updated_at" json:"updated_at"`
+
+	// WhitenBackground overrides the configured default for this logo;
+	// nil means "use the default". See ProcessOptions.
+	WhitenBackground *bool `db:"whiten_background" json:"whiten_background,omitempty"`
+
+	// DefaultBackground is the color (six lower-case hex digits) the logo is
+	// served on when a request doesn't ask for one, for marks that only read
+	// on their brand color, e.g. a white logo. Empty serves it transparent.
+	DefaultBackground string `db:"default_bg" json:"default_bg,omitempty"`
+
+	// QualityScore rates the processed logo from 0 (worst) to 100, nil until
+	// it has been processed; QualityNotes says what cost it points.
+	QualityScore *int   `db:"quality_score" json:"quality_score,omitempty"`
+	QualityNotes string `db:"quality_notes" json:"quality_notes,omitempty"`
+
+	// PHash is a perceptual hash of the xl size (16 hex digits, see the phash
+	// package), used to find different symbols carrying the same logo.
+	PHash string `db:"phash" json:"phash,omitempty"`
+
+	// OriginalHash identifies the stored Original the sizes were rendered
+	// from; empty for logos processed before originals were kept.
+	OriginalHash string `db:"original_hash" json:"original_hash,omitempty"`
+
+	// ETag and LastModified are the HTTP validators sent with the download
+	// the logo was processed from. Imports send them back, so an unchanged
+	// file costs a 304 instead of a download. Empty if the source sent none.
+	ETag         string `db:"etag" json:"-"`
+	LastModified string `db:"last_modified" json:"-"`
+
+	// NextCheckAt is when a not_found logo is looked for again; until then
+	// requests for it get a 404 without asking any provider.
+	NextCheckAt *time.Time `db:"next_check_at" json:"next_check_at,omitempty"`
+
+	// DeletedAt is when an admin deleted the logo. Until it's purged, the
+	// record and files are kept and the delete can be undone.
+	DeletedAt *time.Time `db:"deleted_at" json:"deleted_at,omitempty"`
+
+	// Renditions describe the stored sizes, so clients can pick one and
+	// estimate payloads without downloading. Only metadata lookups fill it.
+	Renditions []Rendition `db:"-" json:"renditions,omitempty"`
+}
+
+// Rendition is one stored size of a logo as written: what a client gets.
+// Width and Height are 0 if the file's header couldn't be read.
+type Rendition struct {
+	Size   LogoSize `db:"size" json:"size"`
+	Width  int      `db:"width" json:"width"`
+	Height int      `db:"height" json:"height"`
+	Bytes  int64    `db:"bytes" json:"bytes"`
 }
 
 // HasSize returns whether the logo has been processed at the given size.
@@ -84,6 +164,42 @@ func (l *Logo) HasSize(size LogoSize) bool {
 	}
 }
 
+// MissingSizes returns the sizes not flagged as available.
+func (l *Logo) MissingSizes() []LogoSize {
+	var missing []LogoSize
+	for _, size := range AllSizes {
+		if !l.HasSize(size) {
+			missing = append(missing, size)
+		}
+	}
+	return missing
+}
+
+// MetadataUpdate corrects a logo's descriptive fields. Nil fields are left
+// as they are; an empty string clears one.
+type MetadataUpdate struct {
+	CompanyName *string `json:"company_name"`
+	Website     *string `json:"website"`
+	License     *string `json:"license"`
+	Attribution *string `json:"attribution"`
+}
+
+// ProcessOptions tunes how a source image is turned into the stored sizes.
+type ProcessOptions struct {
+	// WhitenBackground makes a uniform white background transparent and trims
+	// the margin around the logo, so it doesn't show as a white box on dark UIs.
+	WhitenBackground bool
+}
+
+// ProcessOptions applies this logo's overrides to the configured defaults.
+func (l *Logo) ProcessOptions(defaults ProcessOptions) ProcessOptions {
+	opts := defaults
+	if l != nil && l.WhitenBackground != nil {
+		opts.WhitenBackground = *l.WhitenBackground
+	}
+	return opts
+}
+
 // LLMCall tracks each call to an LLM provider for cost monitoring.
 type LLMCall struct {
 	ID         int64     `db:"id" json:"id"`
@@ -93,5 +209,80 @@ type LLMCall struct {
 	ResultURL  *string   `db:"result_url" json:"result_url,omitempty"`
 	Success    bool      `db:"success" json:"success"`
 	DurationMs *int64    `db:"duration_ms" json:"duration_ms,omitempty"`
+	KeyID      string    `db:"key_id" json:"key_id,omitempty"` // The API key whose miss caused it, "" if none did
 	CreatedAt  time.Time `db:"created_at" json:"created_at"`
 }
+
+// DisabledLLMClient is an LLM client an admin switched off at runtime, e.g.
+// during a provider outage or a budget freeze. The LLM layer skips it until
+// it's enabled again.
+type DisabledLLMClient struct {
+	Provider   string    `db:"provider" json:"provider"`
+	Reason     string    `db:"reason" json:"reason"`
+	DisabledAt time.Time `db:"disabled_at" json:"disabled_at"`
+}
+
+// RequestedSymbol tracks demand for a symbol we couldn't serve (a 404).
+// The request count tells us which missing tickers users actually need.
+type RequestedSymbol struct {
+	Symbol         string    `db:"symbol" json:"symbol"`
+	RequestCount   int64     `db:"request_count" json:"request_count"`
+	FirstRequested time.Time `db:"first_requested" json:"first_requested"`
+	LastRequested  time.Time `db:"last_requested" json:"last_requested"`
+}
+
+// Instrument is a reference entry mapping a ticker to its company name.
+// It's filled from external datasets and used to give LLM searches a
+// company name to work with — "ABC" alone is ambiguous, "AmerisourceBergen" isn't.
+type Instrument struct {
+	Symbol    string    `db:"symbol" json:"symbol"`
+	Name      string    `db:"name" json:"name"`
+	Exchange  string    `db:"exchange" json:"exchange,omitempty"`
+	Source    string    `db:"source" json:"source"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// LogoVariant is a cached derivative of a canonical size, e.g. the m logo
+// flattened onto a white background. Variants are disposable: the cache
+// evicts the least recently used ones to stay under its quota.
+type LogoVariant struct {
+	Symbol       string    `db:"symbol" json:"symbol"`
+	Size         LogoSize  `db:"size" json:"size"`
+	Variant      string    `db:"variant" json:"variant"` // e.g. "bg_ffffff"
+	Bytes        int64     `db:"bytes" json:"bytes"`
+	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+	LastAccessed time.Time `db:"last_accessed" json:"last_accessed"`
+}
+
+// BlockedSymbol is a symbol that must never be served or acquired, e.g. a
+// delisted ticker or a logo under a legal takedown. Blocking is a soft delete:
+// the logo record and files stay, so unblocking restores them as they were.
+type BlockedSymbol struct {
+	Symbol    string    `db:"symbol" json:"symbol"`
+	Reason    string    `db:"reason" json:"reason"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// Audit actions recorded in the audit log.
+const (
+	AuditBlock      = "block"
+	AuditUnblock    = "unblock"
+	AuditTakedown   = "takedown"
+	AuditReview     = "review"     // Sent back to the review queue
+	AuditModeration = "moderation" // Held for review by the moderator
+	AuditMetadata   = "metadata"   // Descriptive fields corrected
+	AuditDelete     = "delete"     // Soft-deleted: restorable until purged
+	AuditRestore    = "restore"
+	AuditPurge      = "purge"      // Deleted for good: record and files removed
+	AuditFixStatus  = "fix_status" // Inconsistent status normalized by fix-status
+)
+
+// AuditEntry records an admin action that changed what the service serves,
+// so takedowns can be traced back later.
+type AuditEntry struct {
+	ID        int64     `db:"id" json:"id"`
+	Action    string    `db:"action" json:"action"`
+	Symbol    string    `db:"symbol" json:"symbol"`
+	Details   string    `db:"details" json:"details,omitempty"` // e.g. the takedown reason
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// Original records a source image as a provider delivered it, before any
+// processing. The bytes are kept (by content hash, see
+// storage.FileSystem.WriteOriginal) so a logo can be processed again —
+// with other options, or for sizes added later — without downloading it
+// again or paying for another LLM search.
+type Original struct {
+	ID          int64     `db:"id" json:"id"`
+	Symbol      string    `db:"symbol" json:"symbol"`
+	Hash        string    `db:"hash" json:"hash"` // SHA-256 of the bytes, hex
+	Source      string    `db:"source" json:"source"`
+	OriginalURL string    `db:"original_url" json:"original_url"`
+	Confidence  string    `db:"confidence" json:"confidence,omitempty"`
+	License     string    `db:"license" json:"license,omitempty"`
+	Attribution string    `db:"attribution" json:"attribution,omitempty"`
+	Format      string    `db:"format" json:"format"` // As detected from the bytes, e.g. "svg"
+	SizeBytes   int64     `db:"size_bytes" json:"size_bytes"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+}
@@ -0,0 +1,57 @@
+package model
+
+import "time"
+
+// Stats counts the logos in the catalog by status. The admin endpoint adds
+// per-key API usage, LLM calls per provider and the providers' outbound
+// traffic.
+type Stats struct {
+	Total     int64 `json:"total"`
+	Processed int64 `json:"processed"`
+	Pending   int64 `json:"pending"`
+	Failed    int64 `json:"failed"`
+	NotFound  int64 `json:"not_found"`
+	Review    int64 `json:"review"`
+
+	APIUsage []KeyUsage  `json:"api_usage,omitempty"` // Most rejected first
+	LLMUsage []LLMUsage  `json:"llm_usage,omitempty"` // Newest day first
+	Outbound []HostUsage `json:"outbound,omitempty"`  // By host, since the server started
+}
+
+// Activity counts what happened over a window, for the alert rules.
+// Requests are counted by whole days (UTC): usage is only kept per day.
+type Activity struct {
+	AcquisitionFailures int64 `db:"acquisition_failures"` // Misses no provider found a logo on, with one unavailable
+	Misses              int64 `db:"misses"`               // Cache misses, each asking one or more providers
+	LLMCalls            int64 `db:"llm_calls"`
+	Requests            int64 `db:"requests"`
+}
+
+// StatsSnapshot is one day (UTC, "2006-01-02") of the stats history, as
+// last recorded that day: the counts are where the catalog stood then, the
+// activity (LLM calls, requests, misses) is the day's so far.
+type StatsSnapshot struct {
+	Day       string `db:"day" json:"day"`
+	Total     int64  `db:"total" json:"total"`
+	Processed int64  `db:"processed" json:"processed"`
+	Pending   int64  `db:"pending" json:"pending"`
+	Failed    int64  `db:"failed" json:"failed"`
+	NotFound  int64  `db:"not_found" json:"not_found"`
+	Review    int64  `db:"review" json:"review"`
+
+	// BySource counts logos by the kind of provider they came from: the
+	// part of their source before ":" ("github", "llm", "index").
+	BySource map[string]int64 `db:"-" json:"by_source"`
+
+	LLMCalls      int64 `db:"llm_calls" json:"llm_calls"`
+	LLMSpendCents int64 `db:"llm_spend_cents" json:"llm_spend_cents"` // Estimated: calls × stats.llm_call_cost
+
+	// Requests are the API requests of the day, Misses the ones that had
+	// to acquire a logo. CacheHitRate is the share that didn't, nil on a
+	// day without requests.
+	Requests     int64    `db:"requests" json:"requests"`
+	Misses       int64    `db:"misses" json:"misses"`
+	CacheHitRate *float64 `db:"cache_hit_rate" json:"cache_hit_rate"`
+
+	RecordedAt time.Time `db:"recorded_at" json:"recorded_at"`
+}
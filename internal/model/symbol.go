@@ -0,0 +1,67 @@
+package model
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// ErrInvalidSymbol is returned when a symbol doesn't look like any known ticker format.
+var ErrInvalidSymbol = errors.New("invalid symbol")
+
+// AssetType classifies a symbol by its shape. We can't know for sure what a
+// ticker refers to, but the format is a strong hint — and anything that
+// matches no format is junk we don't want in the DB or on disk.
+type AssetType string
+
+const (
+	AssetEquity AssetType = "equity" // AAPL, BRK.B, BRK-B, SAN.MC, 0700.HK
+	AssetCrypto AssetType = "crypto" // BTC-USD, ETH-EUR
+	AssetIndex  AssetType = "index"  // ^GSPC, ^IBEX
+)
+
+// MaxSymbolLength bounds symbols before any regex runs.
+const MaxSymbolLength = 20
+
+// symbolPatterns are checked in order; the first match decides the asset type.
+// The equity suffix "[.-]XX" covers share classes (BRK.B), and the optional
+// ".XXXX" covers exchange suffixes (SAN.MC).
+// regexp.MustCompile panics on a bad pattern — fine for package-level constants.
+var symbolPatterns = []struct {
+	asset AssetType
+	re    *regexp.Regexp
+}{
+	{AssetCrypto, regexp.MustCompile(`^[A-Z0-9]{2,10}-[A-Z]{3,5}$`)},
+	{AssetIndex, regexp.MustCompile(`^\^[A-Z0-9]{1,10}$`)},
+	{AssetEquity, regexp.MustCompile(`^[A-Z0-9]{1,10}([.-][A-Z0-9]{1,2})?(\.[A-Z]{1,4})?$`)},
+}
+
+// NormalizeSymbol trims and upper-cases a raw symbol, then validates it.
+// Every symbol coming from outside (URLs, imports) should pass through here.
+func NormalizeSymbol(raw string) (string, error) {
+	symbol := strings.ToUpper(strings.TrimSpace(raw))
+	if _, ok := SymbolAssetType(symbol); !ok {
+		return "", ErrInvalidSymbol
+	}
+	return symbol, nil
+}
+
+// SymbolAssetType returns the asset type for an already-normalized symbol,
+// or false if it matches no known format.
+func SymbolAssetType(symbol string) (AssetType, bool) {
+	if symbol == "" || len(symbol) > MaxSymbolLength {
+		return "", false
+	}
+	for _, p := range symbolPatterns {
+		if p.re.MatchString(symbol) {
+			return p.asset, true
+		}
+	}
+	return "", false
+}
+
+// ValidSymbol checks if an already-normalized symbol is well-formed.
+func ValidSymbol(symbol string) bool {
+	_, ok := SymbolAssetType(symbol)
+	return ok
+}
@@ -0,0 +1,81 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeSymbol(t *testing.T) {
+	tests := []struct {
+		raw       string
+		want      string
+		wantAsset AssetType
+		wantErr   bool
+	}{
+		{"aapl", "AAPL", AssetEquity, false},
+		{" BRK.B ", "BRK.B", AssetEquity, false},
+		{"brk-b", "BRK-B", AssetEquity, false},
+		{"san.mc", "SAN.MC", AssetEquity, false},
+		{"0700.HK", "0700.HK", AssetEquity, false},
+		{"btc-usd", "BTC-USD", AssetCrypto, false},
+		{"^gspc", "^GSPC", AssetIndex, false},
+		{"", "", "", true},
+		{"..", "", "", true},
+		{"../etc", "", "", true},
+		{"A/B", "", "", true},
+		{"AAPL\x00", "", "", true},
+		{"ÄPPLE", "", "", true},
+		{"ABCDEFGHIJKLMNOPQRSTUVWXYZ", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := NormalizeSymbol(tt.raw)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidSymbol) {
+					t.Fatalf("expected ErrInvalidSymbol, got %q, %v", got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if asset, _ := SymbolAssetType(got); asset != tt.wantAsset {
+				t.Errorf("asset type: got %q, want %q", asset, tt.wantAsset)
+			}
+		})
+	}
+}
+
+// FuzzNormalizeSymbol checks invariants for arbitrary input: accepted symbols
+// are short, upper-case, free of path characters, and normalizing is idempotent.
+// Run with: go test ./internal/model -fuzz FuzzNormalizeSymbol
+func FuzzNormalizeSymbol(f *testing.F) {
+	for _, seed := range []string{"AAPL", "brk.b", "BTC-USD", "^GSPC", "../etc", "A/B", "", "ÄPPLE"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, raw string) {
+		symbol, err := NormalizeSymbol(raw)
+		if err != nil {
+			return
+		}
+		if len(symbol) > MaxSymbolLength {
+			t.Fatalf("accepted %q longer than %d", symbol, MaxSymbolLength)
+		}
+		if symbol != strings.ToUpper(symbol) {
+			t.Fatalf("accepted non-upper-case %q", symbol)
+		}
+		if strings.ContainsAny(symbol, "/\\\x00") || strings.Contains(symbol, "..") {
+			t.Fatalf("accepted path-like %q", symbol)
+		}
+		again, err := NormalizeSymbol(symbol)
+		if err != nil || again != symbol {
+			t.Fatalf("not idempotent: %q → %q, %v", symbol, again, err)
+		}
+	})
+}
@@ -0,0 +1,60 @@
+package model
+
+import "time"
+
+// KeyUsage counts one API key's requests on one day (UTC, "2006-01-02").
+// Keys are identified by KeyID, a fingerprint, so the database never holds
+// a usable key.
+//
+// The LLM fields are the searches the key's cache misses paid for, for
+// chargeback: a key with many failed searches is likely scanning symbols
+// at random. They're only filled in summaries.
+type KeyUsage struct {
+	KeyID       string `db:"key_id" json:"key_id"`
+	Day         string `db:"day" json:"day,omitempty"`
+	Tier        string `db:"tier" json:"tier"`
+	Requests    int64  `db:"requests" json:"requests"`
+	Rejected    int64  `db:"rejected" json:"rejected"`         // Answered 429
+	CreditsUsed int64  `db:"credits_used" json:"credits_used"` // Let through on burst credits
+	BytesServed int64  `db:"bytes_served" json:"bytes_served"` // Response bodies, errors included
+
+	LLMCalls      int64 `db:"llm_calls" json:"llm_calls"`
+	LLMFailed     int64 `db:"llm_failed" json:"llm_failed"` // Found no logo URL
+	LLMSpendCents int64 `db:"-" json:"llm_spend_cents"`     // Estimated: calls × stats.llm_call_cost
+}
+
+// PopularSymbol is one symbol's request count over a period, with the
+// status of its logo ("" if it has no record yet).
+type PopularSymbol struct {
+	Symbol        string `db:"symbol" json:"symbol"`
+	Requests      int64  `db:"requests" json:"requests"`
+	LastRequested string `db:"last_requested" json:"last_requested"` // Day, "2006-01-02"
+	Status        string `db:"status" json:"status"`
+}
+
+// LLMUsage counts one LLM provider's calls on one day (UTC, "2006-01-02").
+type LLMUsage struct {
+	Day      string `db:"day" json:"day"`
+	Provider string `db:"provider" json:"provider"`
+	Calls    int64  `db:"calls" json:"calls"`
+	Failed   int64  `db:"failed" json:"failed"`
+}
+
+// HostUsage is the traffic providers sent to one host since the server
+// started: what capacity planning and the hosts' rate limits care about.
+type HostUsage struct {
+	Host      string     `json:"host"`
+	Requests  int64      `json:"requests"`  // Retries included
+	Throttled int64      `json:"throttled"` // Answered 429
+	Bytes     int64      `json:"bytes"`     // Response bodies read
+	Quota     *HostQuota `json:"quota,omitempty"`
+}
+
+// HostQuota is a host's rate limit as its last X-RateLimit-* headers told
+// it, e.g. the GitHub API's requests left this hour.
+type HostQuota struct {
+	Limit      int64     `json:"limit"`
+	Remaining  int64     `json:"remaining"`
+	ResetAt    time.Time `json:"reset_at"`
+	ObservedAt time.Time `json:"observed_at"`
+}
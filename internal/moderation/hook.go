@@ -0,0 +1,84 @@
+package moderation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Hook asks an HTTP endpoint. Each logo is POSTed as a JSON Subject, with
+// the image base64-encoded:
+//
+//	{"symbol": "AAPL", "company_name": "Apple Inc.", "source": "llm:anthropic",
+//	 "original_url": "https://...", "image": "iVBORw0KGgo..."}
+//
+// and the endpoint answers 200 with a Verdict:
+//
+//	{"flagged": true, "reason": "matches a registered trademark of another company"}
+//
+// Any other status, or a body that isn't a verdict, is an error.
+type Hook struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// NewHook creates a moderator POSTing to url. A non-empty token is sent as
+// a Bearer Authorization header.
+func NewHook(url, token string, timeout time.Duration) *Hook {
+	return &Hook{
+		url:    url,
+		token:  token,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Name implements Moderator.
+func (h *Hook) Name() string { return "hook" }
+
+// Moderate implements Moderator.
+func (h *Hook) Moderate(ctx context.Context, subject Subject) (Verdict, error) {
+	// Go note: encoding/json writes a []byte as a base64 string.
+	body, err := json.Marshal(subject)
+	if err != nil {
+		return Verdict{}, fmt.Errorf("encoding subject: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return Verdict{}, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if h.token != "" {
+		req.Header.Set("Authorization", "Bearer "+h.token)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return Verdict{}, fmt.Errorf("calling moderation hook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode != http.StatusOK {
+		return Verdict{}, fmt.Errorf("moderation hook returned HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	// A *bool, so a body without "flagged" isn't read as a pass.
+	var verdict struct {
+		Flagged *bool  `json:"flagged"`
+		Reason  string `json:"reason"`
+	}
+	if err := json.Unmarshal(respBody, &verdict); err != nil {
+		return Verdict{}, fmt.Errorf("decoding verdict: %w", err)
+	}
+	if verdict.Flagged == nil {
+		return Verdict{}, fmt.Errorf("decoding verdict: no \"flagged\" in %s", strings.TrimSpace(string(respBody)))
+	}
+	return Verdict{Flagged: *verdict.Flagged, Reason: verdict.Reason}, nil
+}
@@ -0,0 +1,39 @@
+// Package moderation screens logos before they're served. A web search
+// sometimes comes back with another company's logo, a parody, or worse;
+// a Moderator looks at the rendered image and what's known about it and
+// flags the doubtful ones, which the service then holds in the review
+// queue with the moderator's reason instead of serving them.
+//
+// Two moderators come with it: Hook, which asks an HTTP endpoint of your
+// own (a trademark screening service, a human-in-the-loop queue), and
+// Vision, which asks a vision model.
+package moderation
+
+import (
+	"context"
+)
+
+// Subject is a logo to screen.
+type Subject struct {
+	Symbol      string `json:"symbol"`
+	CompanyName string `json:"company_name,omitempty"`
+	Source      string `json:"source"` // As in model.Logo, e.g. "llm:anthropic"
+	OriginalURL string `json:"original_url,omitempty"`
+	Image       []byte `json:"image"` // The logo as served, a PNG (base64 in JSON)
+}
+
+// Verdict is a moderator's answer. Reason says why a logo was flagged; it
+// ends up in the review queue, so it should make sense to an admin.
+type Verdict struct {
+	Flagged bool   `json:"flagged"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// Moderator decides whether a logo needs an admin's eyes before it's
+// served. An error means it couldn't tell; the service's policy decides
+// what happens to the logo then.
+type Moderator interface {
+	Moderate(ctx context.Context, subject Subject) (Verdict, error)
+	// Name identifies the moderator in logs and review reasons, e.g. "hook".
+	Name() string
+}
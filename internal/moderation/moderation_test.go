@@ -0,0 +1,103 @@
+package moderation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fleveque/logo-service/internal/llm"
+)
+
+func TestHook(t *testing.T) {
+	subject := Subject{Symbol: "AAPL", CompanyName: "Apple Inc.", Source: "llm:anthropic", Image: []byte("png")}
+
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    Verdict
+		wantErr string
+	}{
+		{"pass", http.StatusOK, `{"flagged": false}`, Verdict{}, ""},
+		{"flagged", http.StatusOK, `{"flagged": true, "reason": "another company's trademark"}`, Verdict{Flagged: true, Reason: "another company's trademark"}, ""},
+		{"no verdict", http.StatusOK, `{}`, Verdict{}, `no "flagged"`},
+		{"not json", http.StatusOK, `ok`, Verdict{}, "decoding verdict"},
+		{"server error", http.StatusBadGateway, `upstream down`, Verdict{}, "HTTP 502: upstream down"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Subject
+			var auth string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				auth = r.Header.Get("Authorization")
+				if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+					t.Errorf("decoding request: %v", err)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			verdict, err := NewHook(server.URL, "secret", time.Second).Moderate(context.Background(), subject)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Moderate: %v", err)
+			}
+			if verdict != tt.want {
+				t.Errorf("verdict = %+v, want %+v", verdict, tt.want)
+			}
+			if got.Symbol != "AAPL" || got.Source != "llm:anthropic" || !bytes.Equal(got.Image, []byte("png")) {
+				t.Errorf("unexpected subject sent: %+v", got)
+			}
+			if auth != "Bearer secret" {
+				t.Errorf("Authorization = %q", auth)
+			}
+		})
+	}
+}
+
+type fakeReviewer struct {
+	review *llm.LogoReview
+	err    error
+	got    string
+}
+
+func (f *fakeReviewer) ReviewLogo(_ context.Context, symbol, companyName string, _ []byte) (*llm.LogoReview, error) {
+	f.got = symbol + "/" + companyName
+	return f.review, f.err
+}
+
+func (f *fakeReviewer) ModelName() string { return "fake-model" }
+
+func TestVision(t *testing.T) {
+	reviewer := &fakeReviewer{review: &llm.LogoReview{Flagged: true, Reason: "this is the Microsoft logo"}}
+	v := NewVision(reviewer)
+	if v.Name() != "vision:fake-model" {
+		t.Errorf("Name = %q", v.Name())
+	}
+
+	verdict, err := v.Moderate(context.Background(), Subject{Symbol: "AAPL", CompanyName: "Apple Inc."})
+	if err != nil {
+		t.Fatalf("Moderate: %v", err)
+	}
+	if !verdict.Flagged || verdict.Reason != "this is the Microsoft logo" || reviewer.got != "AAPL/Apple Inc." {
+		t.Errorf("unexpected verdict %+v for %s", verdict, reviewer.got)
+	}
+
+	boom := errors.New("boom")
+	reviewer.err = boom
+	if _, err := v.Moderate(context.Background(), Subject{Symbol: "AAPL"}); !errors.Is(err, boom) {
+		t.Errorf("expected the reviewer's error, got %v", err)
+	}
+}
@@ -0,0 +1,38 @@
+package moderation
+
+import (
+	"context"
+
+	"github.com/fleveque/logo-service/internal/llm"
+)
+
+// Reviewer is a vision model that can judge a logo.
+// *llm.AnthropicClient satisfies it.
+type Reviewer interface {
+	ReviewLogo(ctx context.Context, symbol, companyName string, png []byte) (*llm.LogoReview, error)
+	ModelName() string
+}
+
+// Vision asks a vision model whether the logo looks like the company's
+// official one. It costs a model call per screened logo, on top of the
+// search that found it.
+type Vision struct {
+	reviewer Reviewer
+}
+
+// NewVision creates a moderator asking reviewer.
+func NewVision(reviewer Reviewer) *Vision {
+	return &Vision{reviewer: reviewer}
+}
+
+// Name implements Moderator.
+func (v *Vision) Name() string { return "vision:" + v.reviewer.ModelName() }
+
+// Moderate implements Moderator.
+func (v *Vision) Moderate(ctx context.Context, subject Subject) (Verdict, error) {
+	review, err := v.reviewer.ReviewLogo(ctx, subject.Symbol, subject.CompanyName, subject.Image)
+	if err != nil {
+		return Verdict{}, err
+	}
+	return Verdict{Flagged: review.Flagged, Reason: review.Reason}, nil
+}
@@ -0,0 +1,89 @@
+// Package origin parses and matches the origin patterns used for CORS:
+// exact origins ("https://quantic.es"), wildcard subdomains
+// ("https://*.quantic.es") and "*" for any origin.
+//
+// It has no dependencies so config can validate patterns at load time and
+// the CORS middleware can match them, without config importing gin.
+package origin
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Any is the pattern that matches every origin.
+const Any = "*"
+
+// Pattern is a parsed origin pattern.
+type Pattern struct {
+	any      bool
+	scheme   string
+	host     string // Without the "*." for wildcards
+	port     string
+	wildcard bool // Matches subdomains of host (at any depth), not host itself
+}
+
+// Parse validates and parses an origin pattern. A wildcard must be the whole
+// leftmost label ("https://*.quantic.es"), and must leave at least two labels
+// so "https://*.es" can't open up an entire TLD.
+func Parse(s string) (Pattern, error) {
+	if s == Any {
+		return Pattern{any: true}, nil
+	}
+
+	scheme, rest, ok := strings.Cut(s, "://")
+	if !ok || (scheme != "http" && scheme != "https") {
+		return Pattern{}, fmt.Errorf("origin %q: must start with http:// or https://", s)
+	}
+	if rest == "" || strings.ContainsAny(rest, "/?#@") {
+		return Pattern{}, fmt.Errorf("origin %q: must be scheme://host[:port], without a path", s)
+	}
+
+	p := Pattern{scheme: scheme}
+	host, port, hasPort := strings.Cut(rest, ":")
+	if hasPort {
+		if port == "" || strings.Trim(port, "0123456789") != "" {
+			return Pattern{}, fmt.Errorf("origin %q: invalid port", s)
+		}
+		p.port = port
+	}
+
+	if after, ok := strings.CutPrefix(host, "*."); ok {
+		p.wildcard = true
+		host = after
+		if !strings.Contains(host, ".") {
+			return Pattern{}, fmt.Errorf("origin %q: wildcard needs at least two labels after *.", s)
+		}
+	}
+	if host == "" || strings.Contains(host, "*") || strings.HasPrefix(host, ".") || strings.HasSuffix(host, ".") {
+		return Pattern{}, fmt.Errorf("origin %q: invalid host", s)
+	}
+	p.host = strings.ToLower(host)
+	return p, nil
+}
+
+// IsAny reports whether the pattern is "*".
+func (p Pattern) IsAny() bool { return p.any }
+
+// Match reports whether an Origin header value matches the pattern.
+func (p Pattern) Match(origin string) bool {
+	if origin == "" {
+		return false
+	}
+	if p.any {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil || u.Scheme != p.scheme || u.Port() != p.port || u.Path != "" || u.User != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+
+	if !p.wildcard {
+		return host == p.host
+	}
+	sub, ok := strings.CutSuffix(host, "."+p.host)
+	return ok && sub != "" && !strings.HasPrefix(sub, ".")
+}
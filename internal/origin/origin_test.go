@@ -0,0 +1,75 @@
+package origin
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		pattern string
+		wantErr bool
+	}{
+		{"*", false},
+		{"https://quantic.es", false},
+		{"http://localhost:3000", false},
+		{"https://*.quantic.es", false},
+		{"https://*.preview.quantic.es:8443", false},
+		{"quantic.es", true},              // No scheme
+		{"ftp://quantic.es", true},        // Not http(s)
+		{"https://quantic.es/", true},     // Path
+		{"https://*.es", true},            // Whole TLD
+		{"https://pr-*.quantic.es", true}, // Partial-label wildcard
+		{"https://*.*.quantic.es", true},  // Two wildcards
+		{"https://quantic.es:", true},     // Empty port
+		{"https://quantic.es:80a", true},  // Bad port
+		{"https://user@quantic.es", true}, // Userinfo
+		{"https://", true},                // No host
+		{"https://*.quantic.es.", true},   // Trailing dot
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			_, err := Parse(tt.pattern)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse(%q) error = %v, wantErr %v", tt.pattern, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern, origin string
+		want            bool
+	}{
+		{"https://quantic.es", "https://quantic.es", true},
+		{"https://quantic.es", "https://QUANTIC.es", true},
+		{"https://quantic.es", "http://quantic.es", false},
+		{"https://quantic.es", "https://quantic.es:8443", false},
+		{"https://quantic.es", "https://evilquantic.es", false},
+		{"http://localhost:3000", "http://localhost:3000", true},
+		{"http://localhost:3000", "http://localhost:3001", false},
+
+		{"https://*.quantic.es", "https://pr-42.quantic.es", true},
+		{"https://*.quantic.es", "https://a.b.quantic.es", true},
+		{"https://*.quantic.es", "https://quantic.es", false}, // Apex is listed separately
+		{"https://*.quantic.es", "https://evilquantic.es", false},
+		{"https://*.quantic.es", "https://quantic.es.evil.com", false},
+		{"https://*.quantic.es", "http://pr-42.quantic.es", false},
+		{"https://*.quantic.es", "https://pr-42.quantic.es/path", false},
+		{"https://*.quantic.es:8443", "https://pr-42.quantic.es:8443", true},
+
+		{"*", "https://anything.example", true},
+		{"*", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.origin, func(t *testing.T) {
+			p, err := Parse(tt.pattern)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.pattern, err)
+			}
+			if got := p.Match(tt.origin); got != tt.want {
+				t.Errorf("Match(%q) = %v, want %v", tt.origin, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+// Package phash computes perceptual hashes: 64-bit fingerprints that stay
+// (nearly) the same when an image is resized, recompressed or slightly
+// recolored. Two images look alike when their hashes differ in few bits,
+// so near-duplicate logos can be found by Hamming distance.
+//
+// It's the DCT hash from pHash.org: shrink to 32×32 grayscale, keep the
+// 8×8 lowest frequencies of a discrete cosine transform, and set a bit for
+// every coefficient above their median.
+package phash
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"math"
+	"math/bits"
+	"slices"
+	"strconv"
+)
+
+const (
+	sampleSize = 32 // Side of the grayscale thumbnail the DCT runs on
+	lowFreq    = 8  // Side of the block of low frequencies kept
+)
+
+// cosines[u][x] = cos((2x+1)uπ / 2N), the DCT-II basis, computed once.
+var cosines = func() [lowFreq][sampleSize]float64 {
+	var c [lowFreq][sampleSize]float64
+	for u := 0; u < lowFreq; u++ {
+		for x := 0; x < sampleSize; x++ {
+			c[u][x] = math.Cos(float64(2*x+1) * float64(u) * math.Pi / (2 * sampleSize))
+		}
+	}
+	return c
+}()
+
+// Hash is a 64-bit perceptual hash.
+type Hash uint64
+
+// Compute hashes an image. Transparent pixels count as white, so a logo
+// hashes the same whether it came with a transparent or a white background.
+func Compute(img image.Image) Hash {
+	gray := grayscale(img)
+
+	// 2D DCT restricted to the low frequencies: the transform is separable,
+	// so this is the 1D transform along each axis.
+	var coeffs [lowFreq * lowFreq]float64
+	for v := 0; v < lowFreq; v++ {
+		for u := 0; u < lowFreq; u++ {
+			var sum float64
+			for y := 0; y < sampleSize; y++ {
+				for x := 0; x < sampleSize; x++ {
+					sum += gray[y][x] * cosines[u][x] * cosines[v][y]
+				}
+			}
+			coeffs[v*lowFreq+u] = sum
+		}
+	}
+
+	// The DC term (average brightness) is far larger than the rest and
+	// would skew the median, so it's left out of it.
+	sorted := slices.Clone(coeffs[1:])
+	slices.Sort(sorted)
+	median := (sorted[len(sorted)/2] + sorted[(len(sorted)-1)/2]) / 2
+
+	var h Hash
+	for i, c := range coeffs {
+		if c > median {
+			h |= 1 << uint(i)
+		}
+	}
+	return h
+}
+
+// FromPNG decodes a PNG and hashes it.
+func FromPNG(data []byte) (Hash, error) {
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return 0, fmt.Errorf("decoding png: %w", err)
+	}
+	return Compute(img), nil
+}
+
+// grayscale shrinks img to sampleSize×sampleSize luminance values in [0, 1],
+// averaging every source pixel that falls into each cell.
+func grayscale(img image.Image) [sampleSize][sampleSize]float64 {
+	var sums, counts [sampleSize][sampleSize]float64
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	for y := 0; y < h; y++ {
+		cy := y * sampleSize / h
+		for x := 0; x < w; x++ {
+			cx := x * sampleSize / w
+			r, g, bl, a := img.At(b.Min.X+x, b.Min.Y+y).RGBA()
+			// RGBA returns alpha-premultiplied 16-bit values: adding the
+			// missing (1-a) of white composites the pixel onto white.
+			lum := (0.299*float64(r) + 0.587*float64(g) + 0.114*float64(bl) + float64(0xffff-a)) / 0xffff
+			sums[cy][cx] += lum
+			counts[cy][cx]++
+		}
+	}
+
+	// Images smaller than 32px leave some cells empty: copy the nearest
+	// filled cell so small images still hash like their larger versions.
+	var out [sampleSize][sampleSize]float64
+	for y := 0; y < sampleSize; y++ {
+		for x := 0; x < sampleSize; x++ {
+			sy, sx := y, x
+			if counts[sy][sx] == 0 && h > 0 && w > 0 {
+				sy, sx = (y*h/sampleSize)*sampleSize/h, (x*w/sampleSize)*sampleSize/w
+			}
+			if counts[sy][sx] > 0 {
+				out[y][x] = sums[sy][sx] / counts[sy][sx]
+			} else {
+				out[y][x] = 1 // Empty image: white
+			}
+		}
+	}
+	return out
+}
+
+// Distance is the number of bits two hashes differ in: 0 for identical
+// images, around 32 for unrelated ones.
+func (h Hash) Distance(other Hash) int {
+	return bits.OnesCount64(uint64(h ^ other))
+}
+
+// String formats the hash as 16 hex digits, the form stored in the database.
+func (h Hash) String() string {
+	return fmt.Sprintf("%016x", uint64(h))
+}
+
+// Parse reads a hash written by String.
+func Parse(s string) (Hash, error) {
+	if len(s) != 16 {
+		return 0, fmt.Errorf("invalid phash %q: expected 16 hex digits", s)
+	}
+	v, err := strconv.ParseUint(s, 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid phash %q: %w", s, err)
+	}
+	return Hash(v), nil
+}
@@ -0,0 +1,73 @@
+package phash
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// drawLogo renders a simple test "logo" at any size: a dark disc for
+// shape "disc", a dark diagonal band for "band", on a given background.
+func drawLogo(size int, shape string, bg color.Color) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	dark := color.NRGBA{R: 20, G: 40, B: 160, A: 255}
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			fx, fy := float64(x)/float64(size)-0.5, float64(y)/float64(size)-0.5
+			inside := false
+			switch shape {
+			case "disc":
+				inside = fx*fx+fy*fy < 0.1
+			case "band":
+				inside = fx-fy > -0.15 && fx-fy < 0.15
+			}
+			if inside {
+				img.Set(x, y, dark)
+			} else {
+				img.Set(x, y, bg)
+			}
+		}
+	}
+	return img
+}
+
+func TestCompute(t *testing.T) {
+	white := color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+	disc := Compute(drawLogo(256, "disc", white))
+
+	tests := []struct {
+		name    string
+		img     image.Image
+		maxDist int
+		minDist int
+	}{
+		{"same image", drawLogo(256, "disc", white), 0, 0},
+		{"resized", drawLogo(64, "disc", white), 4, 0},
+		{"smaller than the sample", drawLogo(16, "disc", white), 8, 0},
+		{"transparent instead of white background", drawLogo(256, "disc", color.NRGBA{}), 0, 0},
+		{"different logo", drawLogo(256, "band", white), 64, 12},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := disc.Distance(Compute(tt.img))
+			if d > tt.maxDist || d < tt.minDist {
+				t.Errorf("distance = %d, want between %d and %d", d, tt.minDist, tt.maxDist)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	h := Hash(0x00ff00ff12345678)
+	got, err := Parse(h.String())
+	if err != nil || got != h {
+		t.Errorf("Parse(%q) = %v, %v", h.String(), got, err)
+	}
+
+	for _, bad := range []string{"", "123", "zzzzzzzzzzzzzzzz", "00ff00ff123456789"} {
+		if _, err := Parse(bad); err == nil {
+			t.Errorf("Parse(%q) should fail", bad)
+		}
+	}
+}
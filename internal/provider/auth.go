@@ -0,0 +1,76 @@
+package provider
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Credentials authenticate the providers to one source that needs it: a
+// private GitHub repo, an internal asset CDN. They're added to the HTTPS
+// requests to Host whose path starts with PathPrefix — never to another
+// host, or over plain HTTP, where anyone on the way could read them.
+type Credentials struct {
+	Host       string // Exact, or "*.example.com" for example.com and its subdomains
+	PathPrefix string // Empty matches every path
+	Token      string // Sent as "Authorization: Bearer"
+	Username   string // Basic auth, with Password
+	Password   string
+	Headers    map[string]string
+}
+
+// SetCredentials sets the credentials of every provider's HTTP client.
+// Like SetOutboundLimits, it's set once at startup (from providers.auth).
+// When several match a request, the longest PathPrefix wins.
+func SetCredentials(creds []Credentials) {
+	outbound.mu.Lock()
+	defer outbound.mu.Unlock()
+	outbound.credentials = creds
+}
+
+// matches reports whether the credentials are for u.
+func (c *Credentials) matches(u *url.URL) bool {
+	if u.Scheme != "https" || !strings.HasPrefix(u.Path, c.PathPrefix) {
+		return false
+	}
+	host, want := strings.ToLower(u.Hostname()), strings.ToLower(c.Host)
+	if domain, ok := strings.CutPrefix(want, "*."); ok {
+		return host == domain || strings.HasSuffix(host, "."+domain)
+	}
+	return host == want
+}
+
+// apply adds the credentials to req.
+func (c *Credentials) apply(req *http.Request) {
+	switch {
+	case c.Token != "":
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	case c.Username != "":
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+	for name, value := range c.Headers {
+		req.Header.Set(name, value)
+	}
+}
+
+// authenticate returns req with the credentials for its URL, if any.
+//
+// Go note: a RoundTripper must not change the request it's given — the
+// caller still owns it — so the credentials go on a clone.
+func (t *politeTransport) authenticate(req *http.Request) *http.Request {
+	t.mu.Lock()
+	var match *Credentials
+	for i := range t.credentials {
+		c := &t.credentials[i]
+		if c.matches(req.URL) && (match == nil || len(c.PathPrefix) > len(match.PathPrefix)) {
+			match = c
+		}
+	}
+	t.mu.Unlock()
+	if match == nil {
+		return req
+	}
+	req = req.Clone(req.Context())
+	match.apply(req)
+	return req
+}
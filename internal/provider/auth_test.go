@@ -0,0 +1,85 @@
+package provider
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestCredentials_Matches(t *testing.T) {
+	org := Credentials{Host: "raw.githubusercontent.com", PathPrefix: "/acme/"}
+	cdn := Credentials{Host: "*.cdn.example.com"}
+
+	tests := []struct {
+		name  string
+		creds Credentials
+		url   string
+		want  bool
+	}{
+		{"host and prefix", org, "https://raw.githubusercontent.com/acme/logos/main/AAPL.png", true},
+		{"other org", org, "https://raw.githubusercontent.com/other/logos/main/AAPL.png", false},
+		{"host case", org, "https://Raw.GitHubUserContent.com/acme/logos/main/AAPL.png", true},
+		{"plain http", org, "http://raw.githubusercontent.com/acme/logos/main/AAPL.png", false},
+		{"lookalike host", org, "https://raw.githubusercontent.com.evil.test/acme/x.png", false},
+		{"wildcard subdomain", cdn, "https://eu.cdn.example.com/logo.svg", true},
+		{"wildcard domain itself", cdn, "https://cdn.example.com/logo.svg", true},
+		{"wildcard suffix only", cdn, "https://evilcdn.example.com/logo.svg", false},
+		{"port", cdn, "https://eu.cdn.example.com:8443/logo.svg", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := tt.creds.matches(u); got != tt.want {
+				t.Errorf("matches(%s) = %v, want %v", tt.url, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPoliteTransport_Credentials(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Got-Authorization", r.Header.Get("Authorization"))
+		w.Header().Set("Got-Api-Key", r.Header.Get("X-Api-Key"))
+	}))
+	defer server.Close()
+
+	transport := &politeTransport{
+		base:  server.Client().Transport,
+		hosts: make(map[string]*hostLimiter),
+		credentials: []Credentials{
+			{Host: "127.0.0.1", Token: "org-token"},
+			// Longer prefix: wins over the one above.
+			{Host: "127.0.0.1", PathPrefix: "/private/", Username: "u", Password: "p", Headers: map[string]string{"x-api-key": "k"}},
+		},
+	}
+
+	for _, tt := range []struct {
+		path, auth, apiKey string
+	}{
+		{"/logos/AAPL.png", "Bearer org-token", ""},
+		{"/private/AAPL.png", "Basic dTpw", "k"},
+	} {
+		req, err := http.NewRequest("GET", server.URL+tt.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := transport.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if got := resp.Header.Get("Got-Authorization"); got != tt.auth {
+			t.Errorf("%s: Authorization = %q, want %q", tt.path, got, tt.auth)
+		}
+		if got := resp.Header.Get("Got-Api-Key"); got != tt.apiKey {
+			t.Errorf("%s: X-Api-Key = %q, want %q", tt.path, got, tt.apiKey)
+		}
+		if req.Header.Get("Authorization") != "" {
+			t.Errorf("%s: the caller's request must not be changed", tt.path)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/fleveque/logo-service/internal/chaos"
+)
+
+// faults is the injector of chaos.providers; nil injects nothing.
+//
+// Go note: atomic.Pointer lets requests in flight read it while it's
+// swapped, without a mutex on every request.
+var faults atomic.Pointer[chaos.Injector]
+
+// SetFaultInjector makes provider calls fail or stall as inj says, for
+// resilience testing: every HTTP attempt (below the retries, so they retry
+// injected failures like real ones) and every LLM search. Like
+// SetOutboundLimits, it's set once at startup; nil turns injection off.
+func SetFaultInjector(inj *chaos.Injector) {
+	faults.Store(inj)
+}
+
+// injectFault asks the injector, if any, for a fault. An injected failure
+// wraps ErrUnavailable, as a provider that is down would.
+func injectFault(ctx context.Context) error {
+	err := faults.Load().Inject(ctx)
+	if err != nil && ctx.Err() == nil {
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+	return err
+}
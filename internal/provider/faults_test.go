@@ -0,0 +1,41 @@
+package provider
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fleveque/logo-service/internal/chaos"
+)
+
+func TestFaultInjector(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer server.Close()
+
+	SetFaultInjector(chaos.New("providers", chaos.Fault{ErrorRate: 1}))
+	defer SetFaultInjector(nil)
+
+	// Every attempt fails before reaching the host, and the retries give up
+	// as they would on a host that is down.
+	client := newHTTPClient(RetryPolicy{Timeout: 5 * time.Second, Retries: 2, Backoff: time.Millisecond})
+	_, err := client.Get(server.URL)
+	if !errors.Is(err, ErrUnavailable) || !errors.Is(err, chaos.ErrInjected) {
+		t.Fatalf("expected an injected ErrUnavailable, got %v", err)
+	}
+	if n := requests.Load(); n != 0 {
+		t.Errorf("expected no request to reach the host, got %d", n)
+	}
+
+	SetFaultInjector(nil)
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("with injection off: %v", err)
+	}
+	resp.Body.Close()
+}
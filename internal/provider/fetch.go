@@ -0,0 +1,155 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/fleveque/logo-service/internal/imagefmt"
+)
+
+// ValidationError describes a download rejected before processing: the
+// wrong Content-Type, bytes that aren't an image, too many of them, or an
+// SVG that can't be sanitized. It wraps imagefmt.ErrUnsupported,
+// imagefmt.ErrTooLarge or imagefmt.ErrUnsafeSVG, so callers can errors.Is
+// on those and errors.As for the details.
+type ValidationError struct {
+	URL         string
+	ContentType string          // As sent by the server, "" if none
+	Detected    imagefmt.Format // From the magic bytes, Unknown if not an image (or not read)
+	Reason      string
+	Err         error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%v: %s (content type %q, detected %q) from %s", e.Err, e.Reason, e.ContentType, e.Detected, e.URL)
+}
+
+func (e *ValidationError) Unwrap() error { return e.Err }
+
+// validationFields returns log fields for a ValidationError in err's
+// chain, so rejected downloads can be found by reason and content type
+// rather than by parsing messages. Nil for other errors.
+func validationFields(err error) []zap.Field {
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		return nil
+	}
+	return []zap.Field{
+		zap.String("url", verr.URL),
+		zap.String("content_type", verr.ContentType),
+		zap.String("detected_format", string(verr.Detected)),
+		zap.String("reason", verr.Reason),
+	}
+}
+
+// allowedContentTypes are the non-image media types a logo may be served
+// as: raw.githubusercontent.com says text/plain for everything, SVGs are
+// often XML, and plenty of CDNs don't know better than octet-stream.
+// image/* is always allowed. Whatever the header, the bytes must still be
+// an image.
+var allowedContentTypes = map[string]bool{
+	"application/octet-stream": true,
+	"binary/octet-stream":      true,
+	"text/plain":               true,
+	"text/xml":                 true,
+	"application/xml":          true,
+}
+
+// contentTypeAllowed reports whether a Content-Type header can be an image.
+// A missing header is allowed: the magic bytes decide.
+func contentTypeAllowed(header string) bool {
+	if header == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(header)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(mediaType, "image/") || allowedContentTypes[mediaType]
+}
+
+// readImage reads a downloaded image, at most limit bytes of it.
+//
+// A body over the limit is an error, not a truncated image: a Content-Length
+// above it fails before anything is read, and a body without one (or lying
+// about it) fails once the stream goes past it. The Content-Type must be
+// one an image can have and the bytes must start like an image, so an HTML
+// error page served with 200 fails here rather than in libvips. SVGs come
+// back sanitized (see imagefmt.SanitizeSVG), or are rejected if they can't be.
+func readImage(resp *http.Response, limit int) ([]byte, error) {
+	contentType := resp.Header.Get("Content-Type")
+	reject := func(detected imagefmt.Format, sentinel error, reason string) error {
+		verr := &ValidationError{ContentType: contentType, Detected: detected, Reason: reason, Err: sentinel}
+		if resp.Request != nil {
+			verr.URL = resp.Request.URL.String()
+		}
+		return verr
+	}
+
+	if !contentTypeAllowed(contentType) {
+		return nil, reject(imagefmt.Unknown, imagefmt.ErrUnsupported, "content type is not an image")
+	}
+
+	if resp.ContentLength > int64(limit) {
+		return nil, reject(imagefmt.Unknown, imagefmt.ErrTooLarge, fmt.Sprintf("Content-Length %d exceeds the %d byte limit", resp.ContentLength, limit))
+	}
+
+	// Go note: reading one byte past the limit is how to tell "exactly at the
+	// limit" from "cut off by LimitReader".
+	data, err := io.ReadAll(io.LimitReader(resp.Body, int64(limit)+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading body: %w", err)
+	}
+	if len(data) > limit {
+		return nil, reject(imagefmt.Detect(data), imagefmt.ErrTooLarge, fmt.Sprintf("body exceeds the %d byte limit", limit))
+	}
+
+	switch imagefmt.Detect(data) {
+	case imagefmt.Unknown:
+		head := data
+		if len(head) > 16 {
+			head = head[:16]
+		}
+		return nil, reject(imagefmt.Unknown, imagefmt.ErrUnsupported, fmt.Sprintf("body is not an image (starts with %q)", head))
+	case imagefmt.SVG:
+		// Scripts and external references never get stored, let alone rendered.
+		clean, err := imagefmt.SanitizeSVG(data)
+		if err != nil {
+			return nil, reject(imagefmt.SVG, imagefmt.ErrUnsafeSVG, strings.TrimPrefix(err.Error(), imagefmt.ErrUnsafeSVG.Error()+": "))
+		}
+		return clean, nil
+	}
+	return data, nil
+}
+
+// fetchClient downloads images for FetchImage.
+var fetchClient = newHTTPClient(defaultRetryPolicy)
+
+// FetchImage downloads a single image from rawURL, with the checks every
+// provider download gets (see readImage). It's for URLs given by hand, e.g.
+// a candidate logo an admin wants to compare with the current one. maxBytes
+// is the download limit, imagefmt.MaxBytes unless configured otherwise.
+func FetchImage(ctx context.Context, rawURL string, maxBytes int) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	resp, err := fetchClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("downloading: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d from %s", resp.StatusCode, rawURL)
+	}
+	return readImage(resp, maxBytes)
+}
@@ -0,0 +1,101 @@
+package provider
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fleveque/logo-service/internal/imagefmt"
+)
+
+func TestReadImage(t *testing.T) {
+	const limit = 64 // Keeps the bodies small
+
+	svg := `<svg xmlns="http://www.w3.org/2000/svg"></svg>` // As sanitized
+	big := `<svg xmlns="http://www.w3.org/2000/svg">` + strings.Repeat(" ", 64) + `</svg>`
+
+	tests := []struct {
+		name        string
+		contentType string // "" lets net/http sniff one
+		body        string
+		chunked     bool // No Content-Length: only the stream check can catch it
+		wantErr     error
+		wantReason  string
+	}{
+		{"image", "image/svg+xml", svg, false, nil, ""},
+		{"github raw text/plain", "text/plain; charset=utf-8", svg, false, nil, ""},
+		{"content-length over the limit", "", big, false, imagefmt.ErrTooLarge, "Content-Length"},
+		{"stream over the limit", "", big, true, imagefmt.ErrTooLarge, "body exceeds"},
+		{"html error page", "", "<html><body>Not found</body></html>", false, imagefmt.ErrUnsupported, "content type"},
+		{"html mislabelled as image", "image/png", "<html><body>Not found</body></html>", false, imagefmt.ErrUnsupported, "not an image"},
+		{"image behind a json content type", "application/json", svg, false, imagefmt.ErrUnsupported, "content type"},
+		{"svg that can't be sanitized", "image/svg+xml", `<svg><g></svg>`, false, imagefmt.ErrUnsafeSVG, "unexpected </svg>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.contentType != "" {
+					w.Header().Set("Content-Type", tt.contentType)
+				}
+				if tt.chunked {
+					w.Write([]byte(tt.body[:10]))
+					w.(http.Flusher).Flush() // Sends headers without a Content-Length
+					w.Write([]byte(tt.body[10:]))
+					return
+				}
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			resp, err := http.Get(server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			data, err := readImage(resp, limit)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("readImage() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil {
+				if string(data) != tt.body {
+					t.Errorf("readImage() = %q, want the whole body", data)
+				}
+				return
+			}
+
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("expected a *ValidationError, got %T", err)
+			}
+			if !strings.Contains(verr.Reason, tt.wantReason) || verr.URL != server.URL {
+				t.Errorf("unexpected details %+v", verr)
+			}
+		})
+	}
+}
+
+func TestReadImage_SanitizesSVG(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/svg+xml")
+		w.Write([]byte(`<svg onload="alert(1)"><script>alert(2)</script><rect width="1"/></svg>`))
+	}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	data, err := readImage(resp, imagefmt.MaxBytes)
+	if err != nil {
+		t.Fatalf("readImage: %v", err)
+	}
+	if want := `<svg><rect width="1"></rect></svg>`; string(data) != want {
+		t.Errorf("readImage() = %s, want %s", data, want)
+	}
+}
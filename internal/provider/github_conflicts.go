@@ -0,0 +1,130 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"go.uber.org/zap"
+
+	"github.com/fleveque/logo-service/internal/imagefmt"
+)
+
+// ConflictPolicy decides which repo's logo wins when several repos have
+// the same symbol (github.conflict_policy).
+type ConflictPolicy string
+
+const (
+	// KeepFirst takes the logo of the first repo in priority order.
+	KeepFirst ConflictPolicy = "keep_first"
+	// PreferHigherResolution downloads every repo's file and takes the
+	// biggest image: an SVG over any raster, then the most pixels. Ties go
+	// to priority order.
+	PreferHigherResolution ConflictPolicy = "prefer_higher_resolution"
+	// PreferAuthoritative is KeepFirst with the authoritative repos (see
+	// SetAuthoritative) ahead of the rest.
+	PreferAuthoritative ConflictPolicy = "prefer_authoritative"
+)
+
+// ConflictPolicies lists every policy.
+var ConflictPolicies = []ConflictPolicy{KeepFirst, PreferHigherResolution, PreferAuthoritative}
+
+// SetPriority sets the order repos are tried in (github.priority): the
+// repos listed come first, in that order, then the others in the order
+// they were configured.
+func (g *GitHubProvider) SetPriority(repos []string) {
+	g.priority = repos
+}
+
+// SetConflictPolicy sets how a symbol found in several repos is resolved,
+// both on demand and during imports. The default is PreferAuthoritative.
+func (g *GitHubProvider) SetConflictPolicy(policy ConflictPolicy) {
+	g.conflicts = policy
+}
+
+// order sorts repos by priority: with PreferAuthoritative the authoritative
+// ones first, then those listed in SetPriority, then the rest as given.
+func (g *GitHubProvider) order(repos []string) []string {
+	rank := func(repo string) int {
+		r := len(g.priority)
+		if i := slices.Index(g.priority, repo); i >= 0 {
+			r = i
+		}
+		if g.conflicts == PreferAuthoritative && !slices.Contains(g.authoritative, repo) {
+			r += len(g.priority) + 1
+		}
+		return r
+	}
+	ordered := slices.Clone(repos)
+	// Go note: a stable sort keeps the configured order among repos of
+	// the same rank.
+	slices.SortStableFunc(ordered, func(a, b string) int { return rank(a) - rank(b) })
+	return ordered
+}
+
+// candidate is one repo's file for a symbol.
+type candidate struct {
+	repo, path string
+}
+
+// resolve downloads the winning file among candidates, in priority order,
+// and returns its result. Under PreferHigherResolution every candidate is
+// downloaded and compared; otherwise only the first is, with the stored
+// validators when it's the only one. A repo that fails to answer doesn't
+// lose the symbol for the others: it's logged and the next one decides.
+func (g *GitHubProvider) resolve(ctx context.Context, symbol string, candidates []candidate) (*LogoResult, error) {
+	var best *LogoResult
+	bestSize := -1
+	var lastErr error
+	for _, c := range candidates {
+		fileURL := g.fileURL(ctx, c.repo, c.path)
+		var etag, lastModified string
+		if len(candidates) == 1 {
+			// With rivals, an unchanged file still has to be compared.
+			etag, lastModified = g.storedValidators(ctx, symbol, fileURL)
+		}
+		dl, err := g.downloadFile(ctx, fileURL, etag, lastModified)
+		if errors.Is(err, ErrNotModified) {
+			return nil, err
+		}
+		if fields := validationFields(err); fields != nil {
+			g.logger.Warn("rejected download from repo",
+				append([]zap.Field{zap.String("repo", c.repo), zap.String("symbol", symbol)}, fields...)...)
+		}
+		if err != nil {
+			lastErr = err
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			continue
+		}
+
+		if g.conflicts != PreferHigherResolution {
+			return g.result(ctx, symbol, c.repo, fileURL, dl), nil
+		}
+		if size := resolution(dl.data); size > bestSize {
+			best, bestSize = g.result(ctx, symbol, c.repo, fileURL, dl), size
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("download failed: %w", lastErr)
+	}
+	if len(candidates) > 1 {
+		g.logger.Debug("resolved repo conflict", zap.String("symbol", symbol), zap.String("source", best.Source))
+	}
+	return best, nil
+}
+
+// resolution ranks an image for PreferHigherResolution: an SVG renders at
+// any size, so it beats every raster; rasters rank by their pixel count.
+func resolution(data []byte) int {
+	info, err := imagefmt.Inspect(data)
+	if err != nil {
+		return 0
+	}
+	if info.Format == imagefmt.SVG {
+		return int(^uint(0) >> 1)
+	}
+	return info.Width * info.Height
+}
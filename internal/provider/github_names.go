@@ -0,0 +1,111 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+// maxNamesFileSize caps a repo's names file: a few hundred thousand
+// tickers fit, a mistake (a binary, a dump) doesn't get read whole.
+const maxNamesFileSize = 16 << 20
+
+// SetNamesFile names a file kept in the repos next to ticker_icons that maps
+// symbols to company names (github.names_file), so logos imported from them
+// aren't nameless. A .json file is an object of symbol → name; anything else
+// is read as a CSV with "symbol" and "name" columns, like an instruments
+// list. Repos without the file are fine: their logos get no name here.
+func (g *GitHubProvider) SetNamesFile(path string) {
+	g.namesFile = strings.TrimPrefix(path, "/")
+}
+
+// CompanyName returns the name repo's names file gives symbol, or "".
+func (g *GitHubProvider) CompanyName(ctx context.Context, repo, symbol string) string {
+	if g.namesFile == "" {
+		return ""
+	}
+	return g.repoNames(ctx, repo)[symbol]
+}
+
+// repoNames returns repo's names file, parsed. Like licenses, answers are
+// cached for the provider's lifetime and failed lookups are retried.
+func (g *GitHubProvider) repoNames(ctx context.Context, repo string) map[string]string {
+	g.mu.Lock()
+	names, ok := g.names[repo]
+	g.mu.Unlock()
+	if ok {
+		return names
+	}
+
+	names, err := g.fetchNames(ctx, g.fileURL(ctx, repo, g.namesFile))
+	if err != nil {
+		g.logger.Warn("reading repo names file", zap.String("repo", repo), zap.String("file", g.namesFile), zap.Error(err))
+		return nil
+	}
+
+	g.mu.Lock()
+	g.names[repo] = names
+	g.mu.Unlock()
+	return names
+}
+
+// fetchNames downloads and parses a names file. A 404 means the repo has
+// none, which is an answer rather than an error.
+func (g *GitHubProvider) fetchNames(ctx context.Context, url string) (map[string]string, error) {
+	req, err := g.newRequest(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("downloading: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return map[string]string{}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(resp, url)
+	}
+
+	return parseNames(io.LimitReader(resp.Body, maxNamesFileSize), strings.HasSuffix(g.namesFile, ".json"))
+}
+
+// parseNames reads a names file into symbol → name, with symbols
+// normalized and entries without a valid symbol or a name left out.
+func parseNames(r io.Reader, isJSON bool) (map[string]string, error) {
+	raw := map[string]string{}
+	if isJSON {
+		if err := json.NewDecoder(r).Decode(&raw); err != nil {
+			return nil, fmt.Errorf("parsing names: %w", err)
+		}
+	} else {
+		instruments, err := parseInstrumentCSV(r)
+		if err != nil {
+			return nil, fmt.Errorf("parsing names: %w", err)
+		}
+		for _, inst := range instruments {
+			raw[inst.Symbol] = inst.Name
+		}
+	}
+
+	names := make(map[string]string, len(raw))
+	for symbol, name := range raw {
+		symbol, err := model.NormalizeSymbol(symbol)
+		name = strings.TrimSpace(name)
+		if err != nil || name == "" {
+			continue
+		}
+		names[symbol] = name
+	}
+	return names, nil
+}
@@ -0,0 +1,105 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// SetToken authenticates the provider to GitHub (github.token), for private
+// repos and the API's higher rate limit. It's only sent to the provider's
+// own raw and API hosts. Private repos are read through the Contents API:
+// raw.githubusercontent.com doesn't serve them to every kind of token.
+func (g *GitHubProvider) SetToken(token string) {
+	g.token = token
+}
+
+// SetAuthoritative marks repos whose logos correct the ones already stored,
+// e.g. a design team's repo of fixed logos (github.authoritative). Their
+// results are Authoritative, which imports take as "replace", and they're
+// asked first on demand.
+func (g *GitHubProvider) SetAuthoritative(repos []string) {
+	g.authoritative = repos
+}
+
+// newRequest creates a GET for one of the provider's URLs, with the token.
+func (g *GitHubProvider) newRequest(ctx context.Context, url string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	if g.token != "" && (strings.HasPrefix(url, g.apiBaseURL+"/") || strings.HasPrefix(url, g.rawBaseURL+"/")) {
+		req.Header.Set("Authorization", "Bearer "+g.token)
+	}
+	// A file served by the API is a Contents API URL: ask for the file itself.
+	if strings.HasPrefix(url, g.apiBaseURL+"/repos/") && strings.Contains(url, "/contents/") {
+		req.Header.Set("Accept", "application/vnd.github.raw")
+	}
+	return req, nil
+}
+
+// fileURL returns where to download a file of repo from: its raw URL, or
+// for a private repo the Contents API.
+func (g *GitHubProvider) fileURL(ctx context.Context, repo, path string) string {
+	if g.isPrivate(ctx, repo) {
+		return fmt.Sprintf("%s/repos/%s/contents/%s?ref=main", g.apiBaseURL, repo, path)
+	}
+	return fmt.Sprintf("%s/%s/main/%s", g.rawBaseURL, repo, path)
+}
+
+// isPrivate reports whether repo is private. Without a token it can't be
+// read anyway, so it's never looked up. Like licenses, answers are cached
+// for the provider's lifetime and failed lookups are retried — in the
+// meantime, the raw URL is tried.
+func (g *GitHubProvider) isPrivate(ctx context.Context, repo string) bool {
+	if g.token == "" {
+		return false
+	}
+	g.mu.Lock()
+	private, ok := g.private[repo]
+	g.mu.Unlock()
+	if ok {
+		return private
+	}
+
+	private, err := g.fetchPrivate(ctx, fmt.Sprintf("%s/repos/%s", g.apiBaseURL, repo))
+	if err != nil {
+		g.logger.Warn("looking up repo visibility", zap.String("repo", repo), zap.Error(err))
+		return false
+	}
+
+	g.mu.Lock()
+	g.private[repo] = private
+	g.mu.Unlock()
+	return private
+}
+
+// fetchPrivate calls the GitHub repos API.
+func (g *GitHubProvider) fetchPrivate(ctx context.Context, url string) (bool, error) {
+	req, err := g.newRequest(ctx, url)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("fetching repo: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, statusError(resp, url)
+	}
+	var body struct {
+		Private bool `json:"private"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return false, fmt.Errorf("decoding repo: %w", err)
+	}
+	return body.Private, nil
+}
@@ -3,36 +3,107 @@ package provider
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"path"
+	"slices"
 	"strings"
-	"time"
+	"sync"
 
 	"go.uber.org/zap"
+
+	"github.com/fleveque/logo-service/internal/imagefmt"
+	"github.com/fleveque/logo-service/internal/model"
+	"github.com/fleveque/logo-service/internal/storage"
 )
 
 // GitHubProvider downloads logos from GitHub repos that store stock ticker icons.
 // Supports repos like davidepalazzo/ticker-logos and nvstly/icons which store
 // PNGs at ticker_icons/{SYMBOL}.png.
 type GitHubProvider struct {
-	repos  []string // e.g., ["davidepalazzo/ticker-logos", "nvstly/icons"]
-	client *http.Client
-	logger *zap.Logger
+	repos      []string // e.g., ["davidepalazzo/ticker-logos", "nvstly/icons"]
+	rawBaseURL string   // Serves file contents
+	apiBaseURL string   // Serves the Git Trees API
+	client     *http.Client
+	maxBytes   int             // Download limit, see SetMaxBytes
+	routing    *RoutingPolicy  // nil: every symbol uses repos
+	validators ValidatorSource // nil: imports download every file in full
+	namesFile  string          // Symbol → company name file in each repo; "" if none
+	logger     *zap.Logger
+
+	token         string         // "": anonymous, public repos only
+	authoritative []string       // Repos whose logos replace stored ones on import
+	priority      []string       // Repos tried first, in this order
+	conflicts     ConflictPolicy // Which repo wins a symbol several repos have
+
+	mu       sync.Mutex
+	licenses map[string]string            // repo → SPDX id, looked up once per repo
+	names    map[string]map[string]string // repo → symbol → company name, read once per repo
+	private  map[string]bool              // repo → private, looked up once per repo with a token
 }
 
 // NewGitHubProvider creates a provider for the given GitHub repos.
 func NewGitHubProvider(repos []string, logger *zap.Logger) *GitHubProvider {
 	return &GitHubProvider{
-		repos: repos,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-		logger: logger,
+		repos:      repos,
+		rawBaseURL: "https://raw.githubusercontent.com",
+		apiBaseURL: "https://api.github.com",
+		client:     newHTTPClient(defaultRetryPolicy),
+		maxBytes:   imagefmt.MaxBytes,
+		conflicts:  PreferAuthoritative,
+		logger:     logger,
+		licenses:   make(map[string]string),
+		names:      make(map[string]map[string]string),
+		private:    make(map[string]bool),
 	}
 }
 
+// SetBaseURLs points the provider at a different host, e.g. a mirror or the
+// fake GitHub server used in integration tests (see testutil.FakeGitHub).
+func (g *GitHubProvider) SetBaseURLs(rawBaseURL, apiBaseURL string) {
+	g.rawBaseURL = rawBaseURL
+	g.apiBaseURL = apiBaseURL
+}
+
+// SetRouting sends symbols of the policy's regions to their regional repos
+// instead of the default ones, both on demand and during bulk imports.
+func (g *GitHubProvider) SetRouting(policy *RoutingPolicy) {
+	g.routing = policy
+}
+
+// ErrNotModified is returned for a conditional download the server answered
+// with 304 Not Modified: the file is the one the logo was processed from.
+var ErrNotModified = errors.New("not modified")
+
+// ValidatorSource looks up the ETag and Last-Modified stored for the logo
+// of symbol downloaded from url; empty strings if there are none.
+// storage.LogoRepository satisfies it.
+type ValidatorSource interface {
+	GetValidators(ctx context.Context, symbol, url string) (etag, lastModified string, err error)
+}
+
+// SetRetryPolicy sets the timeout and retries of the provider's requests
+// (github.timeout_seconds and github.retries).
+func (g *GitHubProvider) SetRetryPolicy(policy RetryPolicy) {
+	g.client = newHTTPClient(policy)
+}
+
+// SetMaxBytes replaces imagefmt.MaxBytes as the largest file downloaded
+// (processing.max_fetch_size). Call it before the provider is in use.
+func (g *GitHubProvider) SetMaxBytes(n int) {
+	g.maxBytes = n
+}
+
+// SetValidators makes bulk imports send the stored validators back
+// (If-None-Match, If-Modified-Since), so a file that hasn't changed since
+// its logo was processed costs a 304 instead of a download. Those count as
+// skipped, like logos that already exist.
+func (g *GitHubProvider) SetValidators(validators ValidatorSource) {
+	g.validators = validators
+}
+
 func (g *GitHubProvider) Name() string {
 	return "github"
 }
@@ -52,14 +123,34 @@ type githubTreeResponse struct {
 	Truncated bool              `json:"truncated"`
 }
 
-// GetLogo downloads a single logo from the first repo that has it.
+// GetLogo downloads a single logo from the repo that wins it under the
+// conflict policy: the first in priority order that has it, or the one with
+// the biggest image under PreferHigherResolution.
+// Only the repos routed for the symbol's region are tried (see SetRouting).
 func (g *GitHubProvider) GetLogo(ctx context.Context, symbol string) (*LogoResult, error) {
 	symbol = strings.ToUpper(symbol)
 
-	for _, repo := range g.repos {
-		rawURL := fmt.Sprintf("https://raw.githubusercontent.com/%s/main/ticker_icons/%s.png", repo, symbol)
-
-		data, err := g.downloadFile(ctx, rawURL)
+	repos := g.order(g.routing.Repos(symbol, g.repos))
+	unavailable := 0
+	var rejected error // The first repo's file that wasn't an image
+	var best *LogoResult
+	bestSize := -1
+	for _, repo := range repos {
+		rawURL := g.fileURL(ctx, repo, "ticker_icons/"+symbol+".png")
+
+		dl, err := g.downloadFile(ctx, rawURL, "", "")
+		if fields := validationFields(err); fields != nil {
+			// Found, but not an image: worth more attention than a miss.
+			g.logger.Warn("rejected download from repo",
+				append([]zap.Field{zap.String("repo", repo), zap.String("symbol", symbol)}, fields...)...)
+			if rejected == nil {
+				rejected = err
+			}
+			continue
+		}
+		if errors.Is(err, ErrUnavailable) {
+			unavailable++
+		}
 		if err != nil {
 			g.logger.Debug("logo not found in repo",
 				zap.String("repo", repo),
@@ -69,77 +160,54 @@ func (g *GitHubProvider) GetLogo(ctx context.Context, symbol string) (*LogoResul
 			continue
 		}
 
-		return &LogoResult{
-			Symbol:      symbol,
-			ImageData:   data,
-			Source:      "github:" + repo,
-			OriginalURL: rawURL,
-		}, nil
+		if g.conflicts != PreferHigherResolution {
+			return g.result(ctx, symbol, repo, rawURL, dl), nil
+		}
+		if size := resolution(dl.data); size > bestSize {
+			best, bestSize = g.result(ctx, symbol, repo, rawURL, dl), size
+		}
+	}
+	if best != nil {
+		return best, nil
 	}
 
+	// Cancelled (e.g. another provider won a race): we don't know if it's there.
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	// No repo could even be asked: GitHub is down, not missing the logo.
+	if len(repos) > 0 && unavailable == len(repos) {
+		return nil, fmt.Errorf("%w: no GitHub repo answered for %s", ErrUnavailable, symbol)
+	}
+	// Every file found was rejected: the caller tells that from a miss.
+	if rejected != nil {
+		return nil, fmt.Errorf("logo for %s rejected by every GitHub repo that has it: %w", symbol, rejected)
+	}
 	return nil, fmt.Errorf("logo for %s not found in any GitHub repo", symbol)
 }
 
-// BulkImport iterates over all ticker_icons in each repo and calls the callback
-// for each logo found. Uses the GitHub Git Trees API to list files efficiently
-// (one API call per repo instead of paginated directory listings).
+// BulkImport lists the ticker_icons of every repo, then calls the callback
+// once per symbol with the logo of the repo that wins it under the conflict
+// policy; the other repos' files for that symbol count as skipped. Uses the
+// GitHub Git Trees API to list files efficiently (one API call per repo
+// instead of paginated directory listings).
 //
 // The callback pattern is a common Go idiom for processing large datasets —
 // instead of returning a huge slice, you call a function for each item.
-// This keeps memory usage constant regardless of how many logos exist.
+// Only the file listing is held in memory, never the images.
 func (g *GitHubProvider) BulkImport(ctx context.Context, callback func(result *LogoResult) error) (*ImportStats, error) {
 	stats := &ImportStats{}
-
-	for _, repo := range g.repos {
-		g.logger.Info("importing from GitHub repo", zap.String("repo", repo))
-
-		repoStats, err := g.importFromRepo(ctx, repo, callback)
-		if err != nil {
-			g.logger.Error("repo import failed", zap.String("repo", repo), zap.Error(err))
-			stats.Errors = append(stats.Errors, fmt.Sprintf("%s: %v", repo, err))
-			continue
-		}
-
-		stats.Total += repoStats.Total
-		stats.Imported += repoStats.Imported
-		stats.Skipped += repoStats.Skipped
-		stats.Failed += repoStats.Failed
-		stats.Errors = append(stats.Errors, repoStats.Errors...)
-	}
-
-	return stats, nil
-}
-
-func (g *GitHubProvider) importFromRepo(ctx context.Context, repo string, callback func(result *LogoResult) error) (*ImportStats, error) {
-	stats := &ImportStats{}
-
-	// Use the Git Trees API to list all files in one request.
-	// recursive=1 returns every file in the repo as a flat list.
-	treeURL := fmt.Sprintf("https://api.github.com/repos/%s/git/trees/main?recursive=1", repo)
-	entries, err := g.fetchTree(ctx, treeURL)
-	if err != nil {
-		return stats, fmt.Errorf("fetching tree: %w", err)
-	}
-
-	// Filter to ticker_icons/*.png files
-	for _, entry := range entries {
-		if entry.Type != "blob" {
-			continue
-		}
-		if !strings.HasPrefix(entry.Path, "ticker_icons/") {
-			continue
-		}
-		if !strings.HasSuffix(entry.Path, ".png") {
-			continue
+	symbols, candidates := g.listCandidates(ctx, stats)
+
+	conflicts := 0
+	for _, symbol := range symbols {
+		files := candidates[symbol]
+		stats.Total += len(files)
+		stats.Skipped += len(files) - 1
+		if len(files) > 1 {
+			conflicts++
 		}
 
-		// Extract symbol from path: "ticker_icons/AAPL.png" → "AAPL"
-		filename := path.Base(entry.Path)
-		symbol := strings.TrimSuffix(filename, ".png")
-		symbol = strings.ToUpper(symbol)
-
-		stats.Total++
-
 		// Check for context cancellation (allows graceful shutdown during import)
 		select {
 		case <-ctx.Done():
@@ -147,25 +215,25 @@ func (g *GitHubProvider) importFromRepo(ctx context.Context, repo string, callba
 		default:
 		}
 
-		// Download the raw file
-		rawURL := fmt.Sprintf("https://raw.githubusercontent.com/%s/main/%s", repo, entry.Path)
-		data, err := g.downloadFile(ctx, rawURL)
+		// Download the winning file, unless it's the one the logo was processed from
+		result, err := g.resolve(ctx, symbol, files)
+		if errors.Is(err, ErrNotModified) {
+			stats.Skipped++
+			continue
+		}
 		if err != nil {
 			stats.Failed++
-			stats.Errors = append(stats.Errors, fmt.Sprintf("%s: download failed: %v", symbol, err))
+			stats.Errors = append(stats.Errors, fmt.Sprintf("%s: %v", symbol, err))
 			continue
 		}
 
-		result := &LogoResult{
-			Symbol:      symbol,
-			ImageData:   data,
-			Source:      "github:" + repo,
-			OriginalURL: rawURL,
-		}
-
 		if err := callback(result); err != nil {
-			// If callback returns an error with "already exists", count as skipped
-			if strings.Contains(err.Error(), "already exists") {
+			if errors.Is(err, model.ErrLowDiskSpace) {
+				// Every remaining logo would fail the same way — stop here.
+				return stats, err
+			}
+			// Already imported, blocked or deleted: count as skipped
+			if strings.Contains(err.Error(), "already exists") || errors.Is(err, storage.ErrSymbolBlocked) || errors.Is(err, storage.ErrLogoDeleted) {
 				stats.Skipped++
 			} else {
 				stats.Failed++
@@ -179,31 +247,170 @@ func (g *GitHubProvider) importFromRepo(ctx context.Context, repo string, callba
 		// Log progress every 100 logos
 		if stats.Imported%100 == 0 {
 			g.logger.Info("import progress",
-				zap.String("repo", repo),
 				zap.Int("imported", stats.Imported),
 				zap.Int("total_seen", stats.Total),
 			)
 		}
 	}
 
-	g.logger.Info("repo import complete",
-		zap.String("repo", repo),
+	g.logger.Info("GitHub repos import complete",
 		zap.Int("total", stats.Total),
 		zap.Int("imported", stats.Imported),
 		zap.Int("skipped", stats.Skipped),
 		zap.Int("failed", stats.Failed),
+		zap.Int("conflicts", conflicts),
+		zap.String("conflict_policy", string(g.conflicts)),
 	)
 
 	return stats, nil
 }
 
+// listCandidates lists every repo's ticker_icons/*.png files, in priority
+// order, and groups them by symbol. symbols keeps the order symbols were
+// first seen in. Files that can't be imported count as skipped, and a repo
+// whose listing fails is recorded in stats.Errors.
+func (g *GitHubProvider) listCandidates(ctx context.Context, stats *ImportStats) (symbols []string, candidates map[string][]candidate) {
+	candidates = make(map[string][]candidate)
+	for _, repo := range g.order(g.routing.AllRepos(g.repos)) {
+		g.logger.Info("listing GitHub repo", zap.String("repo", repo))
+
+		// Use the Git Trees API to list all files in one request.
+		// recursive=1 returns every file in the repo as a flat list.
+		treeURL := fmt.Sprintf("%s/repos/%s/git/trees/main?recursive=1", g.apiBaseURL, repo)
+		entries, err := g.fetchTree(ctx, treeURL)
+		if err != nil {
+			g.logger.Error("repo import failed", zap.String("repo", repo), zap.Error(err))
+			stats.Errors = append(stats.Errors, fmt.Sprintf("%s: fetching tree: %v", repo, err))
+			continue
+		}
+
+		// Filter to ticker_icons/*.png files
+		for _, entry := range entries {
+			if entry.Type != "blob" {
+				continue
+			}
+			if !strings.HasPrefix(entry.Path, "ticker_icons/") {
+				continue
+			}
+			if !strings.HasSuffix(entry.Path, ".png") {
+				continue
+			}
+
+			// Extract symbol from path: "ticker_icons/AAPL.png" → "AAPL"
+			filename := path.Base(entry.Path)
+			symbol, err := model.NormalizeSymbol(strings.TrimSuffix(filename, ".png"))
+			if err != nil {
+				stats.Skipped++
+				continue
+			}
+
+			// The symbol's region is served by other repos: this one isn't trusted for it.
+			if !slices.Contains(g.routing.Repos(symbol, g.repos), repo) {
+				stats.Skipped++
+				continue
+			}
+
+			if _, seen := candidates[symbol]; !seen {
+				symbols = append(symbols, symbol)
+			}
+			candidates[symbol] = append(candidates[symbol], candidate{repo: repo, path: entry.Path})
+		}
+	}
+	return symbols, candidates
+}
+
+// result builds the LogoResult for a file downloaded from repo.
+func (g *GitHubProvider) result(ctx context.Context, symbol, repo, rawURL string, dl *download) *LogoResult {
+	return &LogoResult{
+		Symbol:        symbol,
+		CompanyName:   g.CompanyName(ctx, repo, symbol),
+		ImageData:     dl.data,
+		Source:        "github:" + repo,
+		OriginalURL:   rawURL,
+		License:       g.repoLicense(ctx, repo),
+		Attribution:   "github.com/" + repo,
+		ETag:          dl.etag,
+		LastModified:  dl.lastModified,
+		Authoritative: slices.Contains(g.authoritative, repo),
+	}
+}
+
+// storedValidators returns the validators to send for a file, if any. A
+// failed lookup only costs a full download, so it's logged, not returned.
+func (g *GitHubProvider) storedValidators(ctx context.Context, symbol, url string) (string, string) {
+	if g.validators == nil {
+		return "", ""
+	}
+	etag, lastModified, err := g.validators.GetValidators(ctx, symbol, url)
+	if err != nil {
+		g.logger.Debug("looking up validators", zap.String("symbol", symbol), zap.Error(err))
+		return "", ""
+	}
+	return etag, lastModified
+}
+
+// repoLicense returns the SPDX id of a repo's license ("MIT", "NOASSERTION"
+// for one GitHub can't identify), or "" if it has none or the lookup failed.
+// Answers are cached for the provider's lifetime; failed lookups are retried.
+func (g *GitHubProvider) repoLicense(ctx context.Context, repo string) string {
+	g.mu.Lock()
+	license, ok := g.licenses[repo]
+	g.mu.Unlock()
+	if ok {
+		return license
+	}
+
+	license, err := g.fetchLicense(ctx, fmt.Sprintf("%s/repos/%s/license", g.apiBaseURL, repo))
+	if err != nil {
+		g.logger.Debug("looking up repo license", zap.String("repo", repo), zap.Error(err))
+		return ""
+	}
+
+	g.mu.Lock()
+	g.licenses[repo] = license
+	g.mu.Unlock()
+	return license
+}
+
+// fetchLicense calls the GitHub license API. A 404 means the repo has no
+// license file, which is an answer rather than an error.
+func (g *GitHubProvider) fetchLicense(ctx context.Context, url string) (string, error) {
+	req, err := g.newRequest(ctx, url)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("fetching license: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("GitHub API returned %d", resp.StatusCode)
+	}
+
+	var body struct {
+		License struct {
+			SPDXID string `json:"spdx_id"`
+		} `json:"license"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("decoding license: %w", err)
+	}
+	return body.License.SPDXID, nil
+}
+
 func (g *GitHubProvider) fetchTree(ctx context.Context, url string) ([]githubTreeEntry, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	req, err := g.newRequest(ctx, url)
 	if err != nil {
-		return nil, fmt.Errorf("creating request: %w", err)
+		return nil, err
 	}
 	req.Header.Set("Accept", "application/vnd.github+json")
-	req.Header.Set("User-Agent", "logo-service/1.0")
 
 	resp, err := g.client.Do(req)
 	if err != nil {
@@ -228,12 +435,26 @@ func (g *GitHubProvider) fetchTree(ctx context.Context, url string) ([]githubTre
 	return tree.Tree, nil
 }
 
-func (g *GitHubProvider) downloadFile(ctx context.Context, url string) ([]byte, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+// download is a downloaded file and the validators it was served with.
+type download struct {
+	data         []byte
+	etag         string
+	lastModified string
+}
+
+// downloadFile fetches a raw file. With an etag or lastModified it's a
+// conditional request, and an unchanged file returns ErrNotModified.
+func (g *GitHubProvider) downloadFile(ctx context.Context, url, etag, lastModified string) (*download, error) {
+	req, err := g.newRequest(ctx, url)
 	if err != nil {
-		return nil, fmt.Errorf("creating request: %w", err)
+		return nil, err
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
 	}
-	req.Header.Set("User-Agent", "logo-service/1.0")
 
 	resp, err := g.client.Do(req)
 	if err != nil {
@@ -241,16 +462,20 @@ func (g *GitHubProvider) downloadFile(ctx context.Context, url string) ([]byte,
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, fmt.Errorf("%w: %s", ErrNotModified, url)
+	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HTTP %d for %s", resp.StatusCode, url)
+		return nil, statusError(resp, url)
 	}
 
-	// Limit read to 10MB to prevent memory issues from unexpectedly large files.
-	// io.LimitReader wraps a reader with a max byte count — a common safety pattern.
-	data, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	data, err := readImage(resp, g.maxBytes)
 	if err != nil {
-		return nil, fmt.Errorf("reading body: %w", err)
+		return nil, err
 	}
-
-	return data, nil
+	return &download{
+		data:         data,
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+	}, nil
 }
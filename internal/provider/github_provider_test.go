@@ -0,0 +1,332 @@
+package provider_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"sort"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/fleveque/logo-service/internal/imagefmt"
+	"github.com/fleveque/logo-service/internal/provider"
+	"github.com/fleveque/logo-service/internal/testutil"
+)
+
+// An external test package (provider_test): testutil imports provider, so
+// an internal test importing testutil would be an import cycle.
+
+// svg returns a minimal SVG labelled with name: downloads must look like
+// images, and the label tells them apart.
+func svg(name string) []byte {
+	return []byte(`<svg xmlns="http://www.w3.org/2000/svg"><title>` + name + `</title></svg>`)
+}
+
+// pngOf returns a blank PNG of the given size.
+func pngOf(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestGitHubProvider_Routing(t *testing.T) {
+	fake := testutil.NewFakeGitHub(t)
+	fake.AddLogo("us/logos", "AAPL", svg("us-aapl"))
+	fake.AddLogo("us/logos", "7203.T", svg("us-toyota")) // Wrong market: must not be used
+	fake.AddLogo("jp/logos", "7203.T", svg("jp-toyota"))
+	fake.AddLogo("jp/logos", "AAPL", svg("jp-aapl")) // Not a Tokyo symbol: must not be used
+
+	gh := provider.NewGitHubProvider([]string{"us/logos"}, zap.NewNop())
+	gh.SetBaseURLs(fake.RawBaseURL(), fake.APIBaseURL())
+	gh.SetRouting(provider.NewRoutingPolicy([]provider.Region{
+		{Name: "japan", Suffixes: []string{".T"}, Repos: []string{"jp/logos"}},
+	}))
+	ctx := context.Background()
+
+	for symbol, want := range map[string]string{"AAPL": "us-aapl", "7203.T": "jp-toyota"} {
+		result, err := gh.GetLogo(ctx, symbol)
+		if err != nil {
+			t.Fatalf("GetLogo(%s): %v", symbol, err)
+		}
+		if string(result.ImageData) != string(svg(want)) {
+			t.Errorf("GetLogo(%s) = %q, want %q", symbol, result.ImageData, want)
+		}
+	}
+
+	var imported []string
+	stats, err := gh.BulkImport(ctx, func(result *provider.LogoResult) error {
+		imported = append(imported, result.Source+" "+result.Symbol)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(imported)
+	want := []string{"github:jp/logos 7203.T", "github:us/logos AAPL"}
+	if len(imported) != 2 || imported[0] != want[0] || imported[1] != want[1] {
+		t.Errorf("imported %v, want %v", imported, want)
+	}
+	if stats.Skipped != 2 {
+		t.Errorf("expected the 2 misrouted logos to be skipped, got %+v", stats)
+	}
+}
+
+// validators is a ValidatorSource backed by a map, keyed by symbol and URL.
+type validators map[string]string
+
+func (v validators) GetValidators(_ context.Context, symbol, url string) (string, string, error) {
+	return v[symbol+" "+url], "", nil
+}
+
+func TestGitHubProvider_ConditionalImport(t *testing.T) {
+	fake := testutil.NewFakeGitHub(t)
+	fake.AddLogo("us/logos", "AAPL", svg("aapl"))
+	fake.AddLogo("us/logos", "MSFT", svg("msft"))
+
+	gh := provider.NewGitHubProvider([]string{"us/logos"}, zap.NewNop())
+	gh.SetBaseURLs(fake.RawBaseURL(), fake.APIBaseURL())
+	stored := validators{}
+	gh.SetValidators(stored)
+	ctx := context.Background()
+
+	// Only AAPL keeps its validators, as if MSFT had failed to process.
+	importAll := func() []string {
+		var imported []string
+		_, err := gh.BulkImport(ctx, func(result *provider.LogoResult) error {
+			imported = append(imported, result.Symbol)
+			if result.Symbol == "AAPL" {
+				stored[result.Symbol+" "+result.OriginalURL] = result.ETag
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return imported
+	}
+
+	if got := importAll(); len(got) != 2 {
+		t.Fatalf("first import: got %v", got)
+	}
+	if got := importAll(); len(got) != 1 || got[0] != "MSFT" {
+		t.Errorf("expected unchanged AAPL to be skipped, got %v", got)
+	}
+
+	fake.AddLogo("us/logos", "AAPL", svg("aapl-rebrand"))
+	if got := importAll(); len(got) != 2 {
+		t.Errorf("expected the changed AAPL to be downloaded again, got %v", got)
+	}
+}
+
+func TestGitHubProvider_NamesFile(t *testing.T) {
+	fake := testutil.NewFakeGitHub(t)
+	fake.AddLogo("csv/logos", "AAPL", svg("aapl"))
+	fake.AddLogo("csv/logos", "MSFT", svg("msft"))
+	fake.AddFile("csv/logos", "names.csv", []byte("name,symbol\nApple Inc.,aapl\n,MSFT\n"))
+	fake.AddLogo("json/logos", "TSLA", svg("tsla"))
+	fake.AddFile("json/logos", "names.json", []byte(`{"tsla": " Tesla, Inc. "}`))
+	fake.AddLogo("bare/logos", "GOOG", svg("goog")) // No names file: no names
+
+	tests := []struct {
+		repo, file string
+		want       map[string]string
+	}{
+		{"csv/logos", "names.csv", map[string]string{"AAPL": "Apple Inc.", "MSFT": ""}},
+		{"json/logos", "/names.json", map[string]string{"TSLA": "Tesla, Inc."}},
+		{"bare/logos", "names.csv", map[string]string{"GOOG": ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.repo, func(t *testing.T) {
+			gh := provider.NewGitHubProvider([]string{tt.repo}, zap.NewNop())
+			gh.SetBaseURLs(fake.RawBaseURL(), fake.APIBaseURL())
+			gh.SetNamesFile(tt.file)
+
+			got := map[string]string{}
+			_, err := gh.BulkImport(context.Background(), func(result *provider.LogoResult) error {
+				got[result.Symbol] = result.CompanyName
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("imported %v, want %v", got, tt.want)
+			}
+			for symbol, name := range tt.want {
+				if got[symbol] != name {
+					t.Errorf("%s: company name = %q, want %q", symbol, got[symbol], name)
+				}
+			}
+		})
+	}
+}
+
+func TestGitHubProvider_PrivateAuthoritativeRepo(t *testing.T) {
+	fake := testutil.NewFakeGitHub(t)
+	fake.AddLogo("public/logos", "AAPL", svg("public-aapl"))
+	fake.AddLogo("public/logos", "MSFT", svg("public-msft"))
+	fake.AddLogo("design/fixed", "AAPL", svg("fixed-aapl"))
+	fake.AddFile("design/fixed", "names.json", []byte(`{"AAPL": "Apple Inc."}`))
+	fake.SetPrivate("design/fixed", "secret")
+	fake.SetLicense("design/fixed", "NOASSERTION")
+	ctx := context.Background()
+
+	newProvider := func(token string) *provider.GitHubProvider {
+		gh := provider.NewGitHubProvider([]string{"public/logos", "design/fixed"}, zap.NewNop())
+		gh.SetBaseURLs(fake.RawBaseURL(), fake.APIBaseURL())
+		gh.SetNamesFile("names.json")
+		gh.SetAuthoritative([]string{"design/fixed"})
+		gh.SetToken(token)
+		return gh
+	}
+
+	// Without the token the private repo can't be read: the public logo is found.
+	if result, err := newProvider("").GetLogo(ctx, "AAPL"); err != nil || string(result.ImageData) != string(svg("public-aapl")) {
+		t.Fatalf("GetLogo without a token = %v, %v; want the public logo", result, err)
+	}
+
+	gh := newProvider("secret")
+	// Authoritative repos are asked first, even listed last.
+	result, err := gh.GetLogo(ctx, "AAPL")
+	if err != nil {
+		t.Fatalf("GetLogo: %v", err)
+	}
+	if string(result.ImageData) != string(svg("fixed-aapl")) || !result.Authoritative ||
+		result.CompanyName != "Apple Inc." || result.License != "NOASSERTION" {
+		t.Errorf("expected the private repo's logo, name and license, got %+v", result)
+	}
+
+	got := map[string]bool{} // Source + symbol → authoritative
+	if _, err := gh.BulkImport(ctx, func(result *provider.LogoResult) error {
+		got[result.Source+" "+result.Symbol] = result.Authoritative
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	// Only the authoritative AAPL: the public one loses the conflict.
+	want := map[string]bool{"github:public/logos MSFT": false, "github:design/fixed AAPL": true}
+	if len(got) != len(want) {
+		t.Fatalf("imported %v, want %v", got, want)
+	}
+	for key, authoritative := range want {
+		if got[key] != authoritative {
+			t.Errorf("%s: authoritative = %v, want %v", key, got[key], authoritative)
+		}
+	}
+}
+
+func TestGitHubProvider_ConflictPolicy(t *testing.T) {
+	small, large := pngOf(t, 16, 16), pngOf(t, 64, 64)
+	fake := testutil.NewFakeGitHub(t)
+	fake.AddLogo("a/logos", "AAPL", small)
+	fake.AddLogo("a/logos", "MSFT", large)
+	fake.AddLogo("b/logos", "AAPL", large)
+	fake.AddLogo("b/logos", "MSFT", small)
+	fake.AddLogo("c/logos", "AAPL", svg("c-aapl"))
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		priority []string
+		policy   provider.ConflictPolicy
+		want     map[string]string // Symbol → source
+	}{
+		{"keep first in configured order", nil, provider.KeepFirst,
+			map[string]string{"AAPL": "github:a/logos", "MSFT": "github:a/logos"}},
+		{"keep first in priority order", []string{"b/logos"}, provider.KeepFirst,
+			map[string]string{"AAPL": "github:b/logos", "MSFT": "github:b/logos"}},
+		{"authoritative first", []string{"b/logos"}, provider.PreferAuthoritative,
+			map[string]string{"AAPL": "github:c/logos", "MSFT": "github:b/logos"}},
+		{"higher resolution, SVG best", nil, provider.PreferHigherResolution,
+			map[string]string{"AAPL": "github:c/logos", "MSFT": "github:a/logos"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gh := provider.NewGitHubProvider([]string{"a/logos", "b/logos", "c/logos"}, zap.NewNop())
+			gh.SetBaseURLs(fake.RawBaseURL(), fake.APIBaseURL())
+			gh.SetAuthoritative([]string{"c/logos"})
+			gh.SetPriority(tt.priority)
+			gh.SetConflictPolicy(tt.policy)
+
+			// Single fetch and import must agree.
+			for symbol, source := range tt.want {
+				result, err := gh.GetLogo(ctx, symbol)
+				if err != nil {
+					t.Fatalf("GetLogo(%s): %v", symbol, err)
+				}
+				if result.Source != source {
+					t.Errorf("GetLogo(%s) source = %s, want %s", symbol, result.Source, source)
+				}
+			}
+
+			got := map[string]string{}
+			stats, err := gh.BulkImport(ctx, func(result *provider.LogoResult) error {
+				if _, dup := got[result.Symbol]; dup {
+					t.Errorf("%s imported twice", result.Symbol)
+				}
+				got[result.Symbol] = result.Source
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for symbol, source := range tt.want {
+				if got[symbol] != source {
+					t.Errorf("imported %s from %s, want %s", symbol, got[symbol], source)
+				}
+			}
+			if stats.Total != 5 || stats.Imported != 2 || stats.Skipped != 3 {
+				t.Errorf("stats = %+v, want 5 total, 2 imported, 3 skipped", stats)
+			}
+		})
+	}
+}
+
+func TestGitHubProvider_RejectedDownload(t *testing.T) {
+	html := []byte("<html><body>Rate limited</body></html>")
+	fake := testutil.NewFakeGitHub(t)
+	fake.AddLogo("a/logos", "AAPL", html)
+	fake.AddLogo("a/logos", "MSFT", html)
+	fake.AddLogo("b/logos", "MSFT", svg("b-msft"))
+
+	gh := provider.NewGitHubProvider([]string{"a/logos", "b/logos"}, zap.NewNop())
+	gh.SetBaseURLs(fake.RawBaseURL(), fake.APIBaseURL())
+	ctx := context.Background()
+
+	// Every file found was rejected: the rejection, not a plain miss.
+	_, err := gh.GetLogo(ctx, "AAPL")
+	var verr *provider.ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected a ValidationError, got %v", err)
+	}
+
+	// Another repo's file is used instead.
+	result, err := gh.GetLogo(ctx, "MSFT")
+	if err != nil {
+		t.Fatalf("GetLogo(MSFT): %v", err)
+	}
+	if result.Source != "github:b/logos" {
+		t.Errorf("source = %s, want github:b/logos", result.Source)
+	}
+}
+
+func TestGitHubProvider_MaxBytes(t *testing.T) {
+	logo := pngOf(t, 64, 64)
+	fake := testutil.NewFakeGitHub(t)
+	fake.AddLogo("a/logos", "AAPL", logo)
+
+	gh := provider.NewGitHubProvider([]string{"a/logos"}, zap.NewNop())
+	gh.SetBaseURLs(fake.RawBaseURL(), fake.APIBaseURL())
+	gh.SetMaxBytes(len(logo) - 1)
+
+	_, err := gh.GetLogo(context.Background(), "AAPL")
+	if !errors.Is(err, imagefmt.ErrTooLarge) {
+		t.Errorf("expected ErrTooLarge past the limit, got %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package provider
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/fleveque/logo-service/internal/version"
+)
+
+// Identity is how the providers introduce themselves to the hosts they
+// call. GitHub asks API consumers for a User-Agent naming the application,
+// Wikimedia for one with a way to reach its operator, and a host that sees
+// a bulk import can write to the contact instead of banning the IP.
+type Identity struct {
+	Product      string // Defaults to "logo-service"
+	ContactURL   string // e.g. "https://logos.example.com/about"
+	ContactEmail string // Also sent as the From header
+}
+
+// UserAgent formats the identity the way Wikimedia's policy asks, e.g.
+// "logo-service/v1.4.0 (+https://logos.example.com/about; ops@example.com)".
+func (id Identity) UserAgent() string {
+	product := id.Product
+	if product == "" {
+		product = "logo-service"
+	}
+	ua := product + "/" + version.Version
+
+	var contacts []string
+	if id.ContactURL != "" {
+		contacts = append(contacts, "+"+id.ContactURL)
+	}
+	if id.ContactEmail != "" {
+		contacts = append(contacts, id.ContactEmail)
+	}
+	if len(contacts) > 0 {
+		ua += " (" + strings.Join(contacts, "; ") + ")"
+	}
+	return ua
+}
+
+// SetIdentity sets the identity every provider's HTTP client sends. Like
+// SetOutboundLimits, it's set once at startup (from providers.user_agent
+// and providers.contact_*).
+func SetIdentity(id Identity) {
+	outbound.mu.Lock()
+	defer outbound.mu.Unlock()
+	outbound.identity = id
+}
+
+// identify returns req with the User-Agent and From headers of the
+// identity. A request that sets its own User-Agent (the SEC import, which
+// has its own) keeps it.
+func (t *politeTransport) identify(req *http.Request) *http.Request {
+	t.mu.Lock()
+	id := t.identity
+	t.mu.Unlock()
+
+	setUA := req.Header.Get("User-Agent") == ""
+	setFrom := id.ContactEmail != "" && req.Header.Get("From") == ""
+	if !setUA && !setFrom {
+		return req
+	}
+	req = req.Clone(req.Context())
+	if setUA {
+		req.Header.Set("User-Agent", id.UserAgent())
+	}
+	if setFrom {
+		req.Header.Set("From", id.ContactEmail)
+	}
+	return req
+}
@@ -0,0 +1,64 @@
+package provider
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fleveque/logo-service/internal/version"
+)
+
+func TestIdentity_UserAgent(t *testing.T) {
+	v := version.Version
+	tests := []struct {
+		id   Identity
+		want string
+	}{
+		{Identity{}, "logo-service/" + v},
+		{Identity{Product: "acme-logos", ContactURL: "https://acme.example/bot"}, "acme-logos/" + v + " (+https://acme.example/bot)"},
+		{Identity{ContactURL: "https://acme.example", ContactEmail: "ops@acme.example"}, "logo-service/" + v + " (+https://acme.example; ops@acme.example)"},
+	}
+	for _, tt := range tests {
+		if got := tt.id.UserAgent(); got != tt.want {
+			t.Errorf("%+v: got %q, want %q", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestPoliteTransport_Identity(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Got-User-Agent", r.Header.Get("User-Agent"))
+		w.Header().Set("Got-From", r.Header.Get("From"))
+	}))
+	defer server.Close()
+
+	id := Identity{ContactEmail: "ops@acme.example"}
+	transport := &politeTransport{base: http.DefaultTransport, hosts: make(map[string]*hostLimiter), identity: id}
+	client := &http.Client{Transport: transport}
+
+	for _, tt := range []struct {
+		name, ua, want string
+	}{
+		{"identity", "", id.UserAgent()},
+		{"request's own", "sec-import ops@acme.example", "sec-import ops@acme.example"},
+	} {
+		req, _ := http.NewRequest("GET", server.URL, nil)
+		if tt.ua != "" {
+			req.Header.Set("User-Agent", tt.ua)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if got := resp.Header.Get("Got-User-Agent"); got != tt.want {
+			t.Errorf("%s: User-Agent %q, want %q", tt.name, got, tt.want)
+		}
+		if got := resp.Header.Get("Got-From"); got != "ops@acme.example" {
+			t.Errorf("%s: From %q", tt.name, got)
+		}
+		if req.Header.Get("From") != "" {
+			t.Errorf("%s: the caller's request was changed", tt.name)
+		}
+	}
+}
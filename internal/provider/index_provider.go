@@ -0,0 +1,333 @@
+package provider
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/fleveque/logo-service/internal/clock"
+	"github.com/fleveque/logo-service/internal/imagefmt"
+	"github.com/fleveque/logo-service/internal/model"
+	"github.com/fleveque/logo-service/internal/storage"
+)
+
+// IndexProvider serves logos listed in a manifest: a JSON or CSV file at a
+// URL that maps symbols to image URLs. It's how hand-curated logos and
+// corrections get in without writing a provider for each place they're
+// hosted. JSON is an array of entries:
+//
+//	[{"symbol": "AAPL", "url": "https://cdn.example.com/aapl.svg",
+//	  "company_name": "Apple Inc.", "license": "CC0", "attribution": "Design team"}]
+//
+// CSV has a header row with "symbol" and "url" columns, and optionally
+// "company_name", "license" and "attribution", in any order. Relative URLs
+// are resolved against the manifest's own URL, so images can sit next to it.
+type IndexProvider struct {
+	name     string
+	url      string
+	format   string
+	refresh  time.Duration
+	client   *http.Client
+	maxBytes int // Download limit, see SetMaxBytes
+	clock    clock.Clock
+	logger   *zap.Logger
+
+	// Go note: GetLogo runs on concurrent requests, so the cached manifest
+	// is guarded by a mutex. It's held while a stale manifest is fetched
+	// again, so concurrent misses wait for one download instead of each
+	// starting their own.
+	mu        sync.Mutex
+	entries   map[string]IndexEntry
+	fetchedAt time.Time
+}
+
+// IndexEntry is one logo listed in a manifest.
+type IndexEntry struct {
+	Symbol      string `json:"symbol"`
+	URL         string `json:"url"`
+	CompanyName string `json:"company_name"`
+	License     string `json:"license"`
+	Attribution string `json:"attribution"`
+}
+
+// NewIndexProvider creates a provider for the manifest at manifestURL in
+// format "json" or "csv". name shows up in the logos' source as
+// "index:{name}"; it defaults to the manifest's host. GetLogo reads the
+// manifest again once it's older than refresh.
+func NewIndexProvider(name, manifestURL, format string, refresh time.Duration, logger *zap.Logger) (*IndexProvider, error) {
+	u, err := url.Parse(manifestURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("index: invalid manifest url %q", manifestURL)
+	}
+	if format != "json" && format != "csv" {
+		return nil, fmt.Errorf("index: unknown format %q (must be json or csv)", format)
+	}
+	if name == "" {
+		name = u.Host
+	}
+	return &IndexProvider{
+		name:     name,
+		url:      manifestURL,
+		format:   format,
+		refresh:  refresh,
+		client:   newHTTPClient(defaultRetryPolicy),
+		maxBytes: imagefmt.MaxBytes,
+		clock:    clock.System,
+		logger:   logger,
+	}, nil
+}
+
+func (p *IndexProvider) Name() string { return "index:" + p.name }
+
+// SetRetryPolicy sets the timeout and retries of the provider's requests
+// (index.timeout_seconds and index.retries).
+func (p *IndexProvider) SetRetryPolicy(policy RetryPolicy) {
+	p.client = newHTTPClient(policy)
+}
+
+// SetMaxBytes replaces imagefmt.MaxBytes as the largest image downloaded
+// (processing.max_fetch_size). Call it before the provider is in use.
+func (p *IndexProvider) SetMaxBytes(n int) {
+	p.maxBytes = n
+}
+
+// SetClock replaces the system clock that decides when the manifest is
+// stale. Call it before the provider is in use.
+func (p *IndexProvider) SetClock(c clock.Clock) {
+	p.clock = c
+}
+
+// GetLogo downloads the image the manifest lists for symbol.
+func (p *IndexProvider) GetLogo(ctx context.Context, symbol string) (*LogoResult, error) {
+	entries, err := p.manifest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	entry, ok := entries[strings.ToUpper(symbol)]
+	if !ok {
+		return nil, fmt.Errorf("%s not in %s", symbol, p.Name())
+	}
+	return p.download(ctx, entry)
+}
+
+// BulkImport downloads every logo the manifest lists. The manifest is read
+// afresh, so an import picks up edits made since the last GetLogo.
+func (p *IndexProvider) BulkImport(ctx context.Context, callback func(result *LogoResult) error) (*ImportStats, error) {
+	entries, err := p.load(ctx)
+	if err != nil {
+		return &ImportStats{}, err
+	}
+
+	stats := &ImportStats{}
+	for _, entry := range entries {
+		select {
+		case <-ctx.Done():
+			return stats, ctx.Err()
+		default:
+		}
+		stats.Total++
+
+		result, err := p.download(ctx, entry)
+		if err != nil {
+			stats.Failed++
+			stats.Errors = append(stats.Errors, fmt.Sprintf("%s: %v", entry.Symbol, err))
+			continue
+		}
+
+		if err := callback(result); err != nil {
+			if errors.Is(err, model.ErrLowDiskSpace) {
+				return stats, err
+			}
+			// Already imported, blocked or deleted: count as skipped
+			if strings.Contains(err.Error(), "already exists") || errors.Is(err, storage.ErrSymbolBlocked) || errors.Is(err, storage.ErrLogoDeleted) {
+				stats.Skipped++
+			} else {
+				stats.Failed++
+				stats.Errors = append(stats.Errors, fmt.Sprintf("%s: %v", entry.Symbol, err))
+			}
+			continue
+		}
+		stats.Imported++
+	}
+
+	p.logger.Info("index import complete",
+		zap.String("index", p.name),
+		zap.Int("total", stats.Total),
+		zap.Int("imported", stats.Imported),
+		zap.Int("skipped", stats.Skipped),
+		zap.Int("failed", stats.Failed),
+	)
+	return stats, nil
+}
+
+// manifest returns the cached manifest, reading it again when it's older
+// than the refresh interval. If that fails the old one is kept: a manifest
+// host being down shouldn't hide logos it listed a minute ago.
+func (p *IndexProvider) manifest(ctx context.Context) (map[string]IndexEntry, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.entries != nil && p.clock.Now().Sub(p.fetchedAt) < p.refresh {
+		return p.entries, nil
+	}
+	entries, err := p.load(ctx)
+	if err != nil {
+		if p.entries != nil {
+			p.logger.Warn("refreshing logo index, keeping the previous one", zap.String("index", p.name), zap.Error(err))
+			return p.entries, nil
+		}
+		return nil, err
+	}
+
+	p.entries = make(map[string]IndexEntry, len(entries))
+	for _, e := range entries {
+		p.entries[e.Symbol] = e
+	}
+	p.fetchedAt = p.clock.Now()
+	return p.entries, nil
+}
+
+// load downloads and parses the manifest. Entries with an invalid symbol or
+// no URL are logged and left out; for a symbol listed twice the last wins.
+func (p *IndexProvider) load(ctx context.Context) ([]IndexEntry, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", p.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("downloading logo index: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(resp, p.url)
+	}
+
+	var raw []IndexEntry
+	switch p.format {
+	case "json":
+		err = json.NewDecoder(resp.Body).Decode(&raw)
+	case "csv":
+		raw, err = parseIndexCSV(resp.Body)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s logo index: %w", p.format, err)
+	}
+
+	base, _ := url.Parse(p.url)
+	entries := make([]IndexEntry, 0, len(raw))
+	seen := make(map[string]int, len(raw))
+	for _, e := range raw {
+		symbol, err := model.NormalizeSymbol(e.Symbol)
+		ref, refErr := url.Parse(strings.TrimSpace(e.URL))
+		if err != nil || refErr != nil || e.URL == "" {
+			p.logger.Warn("skipping logo index entry", zap.String("index", p.name), zap.String("symbol", e.Symbol), zap.String("url", e.URL))
+			continue
+		}
+		e.Symbol, e.URL = symbol, base.ResolveReference(ref).String()
+		if i, dup := seen[symbol]; dup {
+			entries[i] = e
+			continue
+		}
+		seen[symbol] = len(entries)
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// download fetches the image of one entry.
+func (p *IndexProvider) download(ctx context.Context, entry IndexEntry) (*LogoResult, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", entry.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("downloading: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(resp, entry.URL)
+	}
+	data, err := readImage(resp, p.maxBytes)
+	if fields := validationFields(err); fields != nil {
+		p.logger.Warn("rejected download from logo index",
+			append([]zap.Field{zap.String("index", p.name), zap.String("symbol", entry.Symbol)}, fields...)...)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Curated entries carry no confidence: like GitHub, they're exact.
+	return &LogoResult{
+		Symbol:      entry.Symbol,
+		CompanyName: entry.CompanyName,
+		ImageData:   data,
+		Source:      p.Name(),
+		OriginalURL: entry.URL,
+		License:     entry.License,
+		Attribution: entry.Attribution,
+	}, nil
+}
+
+func parseIndexCSV(r io.Reader) ([]IndexEntry, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1 // Trailing optional columns may be left off
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+
+	// Locate columns by name so the CSV can have them in any order (or extra ones).
+	cols := map[string]int{}
+	for idx, name := range header {
+		cols[strings.ToLower(strings.TrimSpace(name))] = idx
+	}
+	if _, ok := cols["symbol"]; !ok {
+		return nil, errors.New(`header must contain "symbol" and "url" columns`)
+	}
+	if _, ok := cols["url"]; !ok {
+		return nil, errors.New(`header must contain "symbol" and "url" columns`)
+	}
+	field := func(record []string, name string) string {
+		if idx, ok := cols[name]; ok && idx < len(record) {
+			return strings.TrimSpace(record[idx])
+		}
+		return ""
+	}
+
+	var entries []IndexEntry
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, IndexEntry{
+			Symbol:      field(record, "symbol"),
+			URL:         field(record, "url"),
+			CompanyName: field(record, "company_name"),
+			License:     field(record, "license"),
+			Attribution: field(record, "attribution"),
+		})
+	}
+	return entries, nil
+}
@@ -0,0 +1,170 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/fleveque/logo-service/internal/clock"
+)
+
+const indexSVG = `<svg xmlns="http://www.w3.org/2000/svg"></svg>` // As sanitized
+
+// indexServer serves a manifest at /index and SVGs under /img/.
+func indexServer(t *testing.T, manifest *atomic.Value) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/index":
+			body, _ := manifest.Load().(string)
+			if body == "" {
+				http.Error(w, "gone", http.StatusInternalServerError)
+				return
+			}
+			w.Write([]byte(body))
+		case "/img/aapl.svg", "/img/msft.svg":
+			w.Header().Set("Content-Type", "image/svg+xml")
+			w.Write([]byte(indexSVG))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestIndexProvider_GetLogo(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		manifest string
+	}{
+		{"json", "json", `[{"symbol":"aapl","url":"img/aapl.svg","company_name":"Apple Inc.","license":"CC0","attribution":"Design team"}]`},
+		{"csv", "csv", "url,symbol,license,attribution,company_name\nimg/aapl.svg,AAPL,CC0,Design team,Apple Inc.\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var manifest atomic.Value
+			manifest.Store(tt.manifest)
+			server := indexServer(t, &manifest)
+
+			p, err := NewIndexProvider("", server.URL+"/index", tt.format, time.Hour, zap.NewNop())
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := p.GetLogo(context.Background(), "AAPL")
+			if err != nil {
+				t.Fatalf("GetLogo failed: %v", err)
+			}
+			if result.OriginalURL != server.URL+"/img/aapl.svg" {
+				t.Errorf("relative url not resolved: %q", result.OriginalURL)
+			}
+			if result.Source != "index:"+server.Listener.Addr().String() {
+				t.Errorf("source = %q", result.Source)
+			}
+			if result.CompanyName != "Apple Inc." || result.License != "CC0" || result.Attribution != "Design team" {
+				t.Errorf("unexpected result: %+v", result)
+			}
+
+			if _, err := p.GetLogo(context.Background(), "TSLA"); err == nil {
+				t.Error("expected an error for a symbol not in the index")
+			}
+		})
+	}
+}
+
+func TestIndexProvider_KeepsManifestWhenRefreshFails(t *testing.T) {
+	var manifest atomic.Value
+	manifest.Store(`[{"symbol":"AAPL","url":"/img/aapl.svg"}]`)
+	server := indexServer(t, &manifest)
+
+	// A zero refresh reads the manifest on every call.
+	p, err := NewIndexProvider("curated", server.URL+"/index", "json", 0, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.GetLogo(context.Background(), "AAPL"); err != nil {
+		t.Fatalf("GetLogo failed: %v", err)
+	}
+
+	manifest.Store("")
+	if _, err := p.GetLogo(context.Background(), "AAPL"); err != nil {
+		t.Errorf("expected the previous manifest to be used, got %v", err)
+	}
+}
+
+func TestIndexProvider_Refresh(t *testing.T) {
+	var manifest atomic.Value
+	manifest.Store(`[{"symbol":"AAPL","url":"/img/aapl.svg"}]`)
+	server := indexServer(t, &manifest)
+
+	p, err := NewIndexProvider("curated", server.URL+"/index", "json", time.Hour, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake := clock.NewFake(time.Now())
+	p.SetClock(fake)
+	ctx := context.Background()
+
+	if _, err := p.GetLogo(ctx, "AAPL"); err != nil {
+		t.Fatalf("GetLogo failed: %v", err)
+	}
+
+	// MSFT is added, but the manifest read a moment ago is still fresh.
+	manifest.Store(`[{"symbol":"AAPL","url":"/img/aapl.svg"},{"symbol":"MSFT","url":"/img/msft.svg"}]`)
+	fake.Advance(59 * time.Minute)
+	if _, err := p.GetLogo(ctx, "MSFT"); err == nil {
+		t.Error("expected the cached manifest before the refresh interval")
+	}
+
+	fake.Advance(time.Minute)
+	if _, err := p.GetLogo(ctx, "MSFT"); err != nil {
+		t.Errorf("expected the manifest to be read again after the refresh interval, got %v", err)
+	}
+}
+
+func TestIndexProvider_BulkImport(t *testing.T) {
+	var manifest atomic.Value
+	manifest.Store(`[
+		{"symbol":"AAPL","url":"/img/aapl.svg"},
+		{"symbol":"MSFT","url":"/img/msft.svg"},
+		{"symbol":"GOOG","url":"/img/missing.svg"},
+		{"symbol":"","url":"/img/aapl.svg"}
+	]`)
+	server := indexServer(t, &manifest)
+
+	p, err := NewIndexProvider("curated", server.URL+"/index", "json", time.Hour, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := p.BulkImport(context.Background(), func(result *LogoResult) error {
+		if result.Symbol == "MSFT" {
+			return errors.New("logo already exists")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("BulkImport failed: %v", err)
+	}
+	// The entry without a symbol is dropped before the import counts it.
+	if stats.Total != 3 || stats.Imported != 1 || stats.Skipped != 1 || stats.Failed != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestNewIndexProvider_Invalid(t *testing.T) {
+	if _, err := NewIndexProvider("", "ftp://example.com/index.json", "json", time.Hour, zap.NewNop()); err == nil {
+		t.Error("expected an error for a non-HTTP url")
+	}
+	if _, err := NewIndexProvider("", "https://example.com/index.xml", "xml", time.Hour, zap.NewNop()); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
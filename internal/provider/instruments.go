@@ -0,0 +1,198 @@
+package provider
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+// DefaultSECTickersURL is the SEC's public list of US-listed tickers and company names.
+const DefaultSECTickersURL = "https://www.sec.gov/files/company_tickers.json"
+
+// InstrumentImporter downloads a ticker reference dataset (symbol → company name).
+// Two formats are supported:
+//   - "sec": the SEC company_tickers.json file (~10k US tickers)
+//   - "csv": any CSV with a header row containing "symbol" and "name" columns
+//     (and optionally "exchange") — for non-US lists we maintain ourselves
+type InstrumentImporter struct {
+	format    string
+	url       string
+	userAgent string
+	client    *http.Client
+	logger    *zap.Logger
+}
+
+// NewInstrumentImporter creates an importer for the given format and URL.
+// An empty URL with format "sec" uses DefaultSECTickersURL.
+func NewInstrumentImporter(format, url, userAgent string, logger *zap.Logger) (*InstrumentImporter, error) {
+	switch format {
+	case "sec":
+		if url == "" {
+			url = DefaultSECTickersURL
+		}
+	case "csv":
+		if url == "" {
+			return nil, fmt.Errorf("instruments: csv format requires a url")
+		}
+	default:
+		return nil, fmt.Errorf("instruments: unknown format %q (must be sec or csv)", format)
+	}
+
+	return &InstrumentImporter{
+		format:    format,
+		url:       url,
+		userAgent: userAgent,
+		client:    newHTTPClient(RetryPolicy{Timeout: 60 * time.Second}),
+		logger:    logger,
+	}, nil
+}
+
+// Import downloads the dataset and calls the callback for each instrument.
+// Same streaming callback pattern as BulkImport — the caller decides how to store them.
+func (i *InstrumentImporter) Import(ctx context.Context, callback func(inst *model.Instrument) error) (*ImportStats, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", i.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	// The SEC rejects requests without a descriptive User-Agent. Without
+	// one of its own, the import sends the providers' (see SetIdentity).
+	if i.userAgent != "" {
+		req.Header.Set("User-Agent", i.userAgent)
+	}
+
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("downloading instruments: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d for %s", resp.StatusCode, i.url)
+	}
+
+	var instruments []*model.Instrument
+	switch i.format {
+	case "sec":
+		instruments, err = parseSECTickers(resp.Body)
+	case "csv":
+		instruments, err = parseInstrumentCSV(resp.Body)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s instruments: %w", i.format, err)
+	}
+
+	stats := &ImportStats{}
+	for _, inst := range instruments {
+		select {
+		case <-ctx.Done():
+			return stats, ctx.Err()
+		default:
+		}
+
+		stats.Total++
+		if !model.ValidSymbol(inst.Symbol) {
+			stats.Skipped++
+			continue
+		}
+		inst.Source = i.format
+		if err := callback(inst); err != nil {
+			stats.Failed++
+			stats.Errors = append(stats.Errors, fmt.Sprintf("%s: %v", inst.Symbol, err))
+			continue
+		}
+		stats.Imported++
+	}
+
+	i.logger.Info("instrument import complete",
+		zap.String("format", i.format),
+		zap.Int("total", stats.Total),
+		zap.Int("imported", stats.Imported),
+		zap.Int("failed", stats.Failed),
+	)
+
+	return stats, nil
+}
+
+// secTicker is one entry of company_tickers.json. The file is a JSON object
+// keyed by row number ("0", "1", ...) rather than an array.
+type secTicker struct {
+	CIK    int64  `json:"cik_str"`
+	Ticker string `json:"ticker"`
+	Title  string `json:"title"`
+}
+
+func parseSECTickers(r io.Reader) ([]*model.Instrument, error) {
+	var rows map[string]secTicker
+	if err := json.NewDecoder(r).Decode(&rows); err != nil {
+		return nil, err
+	}
+
+	instruments := make([]*model.Instrument, 0, len(rows))
+	for _, row := range rows {
+		if row.Ticker == "" {
+			continue
+		}
+		instruments = append(instruments, &model.Instrument{
+			Symbol: strings.ToUpper(row.Ticker),
+			Name:   row.Title,
+		})
+	}
+	return instruments, nil
+}
+
+func parseInstrumentCSV(r io.Reader) ([]*model.Instrument, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+
+	// Locate columns by name so the CSV can have them in any order (or extra ones).
+	cols := map[string]int{}
+	for idx, name := range header {
+		cols[strings.ToLower(strings.TrimSpace(name))] = idx
+	}
+	symbolCol, okSymbol := cols["symbol"]
+	nameCol, okName := cols["name"]
+	if !okSymbol || !okName {
+		return nil, errors.New(`header must contain "symbol" and "name" columns`)
+	}
+	exchangeCol, hasExchange := cols["exchange"]
+
+	var instruments []*model.Instrument
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		symbol := strings.ToUpper(strings.TrimSpace(record[symbolCol]))
+		if symbol == "" {
+			continue
+		}
+		inst := &model.Instrument{
+			Symbol: symbol,
+			Name:   strings.TrimSpace(record[nameCol]),
+		}
+		if hasExchange {
+			inst.Exchange = strings.TrimSpace(record[exchangeCol])
+		}
+		instruments = append(instruments, inst)
+	}
+	return instruments, nil
+}
@@ -0,0 +1,52 @@
+package provider
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseSECTickers(t *testing.T) {
+	body := `{"0":{"cik_str":320193,"ticker":"AAPL","title":"Apple Inc."},"1":{"cik_str":1,"ticker":"","title":"No ticker"}}`
+
+	instruments, err := parseSECTickers(strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if len(instruments) != 1 {
+		t.Fatalf("expected 1 instrument, got %d", len(instruments))
+	}
+	if instruments[0].Symbol != "AAPL" || instruments[0].Name != "Apple Inc." {
+		t.Errorf("unexpected instrument: %+v", instruments[0])
+	}
+}
+
+func TestParseInstrumentCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    int
+		wantErr bool
+	}{
+		{"with exchange", "symbol,name,exchange\nsan.mc,Banco Santander,BME\nITX.MC,Inditex,BME\n", 2, false},
+		{"any column order", "name,symbol\nAirbus,AIR.PA\n", 1, false},
+		{"blank symbols skipped", "symbol,name\n,Nothing\nBMW.DE,BMW\n", 1, false},
+		{"missing name column", "symbol,exchange\nAAPL,NASDAQ\n", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instruments, err := parseInstrumentCSV(strings.NewReader(tt.body))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(instruments) != tt.want {
+				t.Fatalf("expected %d instruments, got %d", tt.want, len(instruments))
+			}
+		})
+	}
+
+	instruments, _ := parseInstrumentCSV(strings.NewReader("symbol,name,exchange\nsan.mc,Banco Santander,BME\n"))
+	if instruments[0].Symbol != "SAN.MC" || instruments[0].Exchange != "BME" {
+		t.Errorf("unexpected instrument: %+v", instruments[0])
+	}
+}
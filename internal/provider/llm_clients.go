@@ -0,0 +1,146 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/fleveque/logo-service/internal/llm"
+	"github.com/fleveque/logo-service/internal/model"
+	"github.com/fleveque/logo-service/internal/storage"
+)
+
+// ErrDisabled is wrapped, with ErrUnavailable, in the error of an LLM layer
+// whose clients an admin all disabled: the search is incomplete, but nothing
+// failed.
+var ErrDisabled = errors.New("disabled by an admin")
+
+// ErrUnknownLLMClient is returned for a provider name no configured client has.
+var ErrUnknownLLMClient = errors.New("unknown LLM client")
+
+// LLMClientState is an LLM client as the admin API shows it.
+type LLMClientState struct {
+	Provider   string     `json:"provider"`
+	Model      string     `json:"model"`
+	Enabled    bool       `json:"enabled"`
+	Reason     string     `json:"reason,omitempty"`
+	DisabledAt *time.Time `json:"disabled_at,omitempty"`
+}
+
+// SetClientStore persists the clients disabled through DisableClient, so
+// they stay off after a restart. Without one, they're back on at the next
+// start. Call RefreshClients to load what it holds.
+func (p *LLMProvider) SetClientStore(store storage.LLMClientRepository) {
+	p.store = store
+}
+
+// RefreshClients reloads the disabled clients from the store, picking up
+// those another instance disabled. Stored names no configured client has
+// are ignored: their client may be configured again later.
+func (p *LLMProvider) RefreshClients(ctx context.Context) error {
+	if p.store == nil {
+		return nil
+	}
+	stored, err := p.store.ListDisabled(ctx)
+	if err != nil {
+		return err
+	}
+	disabled := make(map[string]model.DisabledLLMClient, len(stored))
+	for _, d := range stored {
+		if p.client(d.Provider) != nil {
+			disabled[d.Provider] = d
+		}
+	}
+	p.mu.Lock()
+	p.disabled = disabled
+	p.mu.Unlock()
+	return nil
+}
+
+// DisableClient switches a client off: FindLogo skips it until
+// EnableClient. A layer left with none enabled answers ErrDisabled.
+func (p *LLMProvider) DisableClient(ctx context.Context, name, reason string) error {
+	if p.client(name) == nil {
+		return fmt.Errorf("%w %q", ErrUnknownLLMClient, name)
+	}
+	if p.store != nil {
+		if err := p.store.Disable(ctx, name, reason); err != nil {
+			return err
+		}
+	}
+	p.mu.Lock()
+	// Disabling again keeps the original date, like the store.
+	disabledAt := time.Now().UTC()
+	if d, ok := p.disabled[name]; ok {
+		disabledAt = d.DisabledAt
+	}
+	p.disabled[name] = model.DisabledLLMClient{Provider: name, Reason: reason, DisabledAt: disabledAt}
+	p.mu.Unlock()
+	p.logger.Warn("LLM client disabled", zap.String("provider", name), zap.String("reason", reason))
+	return nil
+}
+
+// EnableClient switches a disabled client back on. It returns
+// storage.ErrNotFound if the client wasn't disabled.
+func (p *LLMProvider) EnableClient(ctx context.Context, name string) error {
+	if p.client(name) == nil {
+		return fmt.Errorf("%w %q", ErrUnknownLLMClient, name)
+	}
+	if p.store != nil {
+		if err := p.store.Enable(ctx, name); err != nil {
+			return err
+		}
+	}
+	p.mu.Lock()
+	_, wasDisabled := p.disabled[name]
+	delete(p.disabled, name)
+	p.mu.Unlock()
+	if p.store == nil && !wasDisabled {
+		return storage.ErrNotFound
+	}
+	p.logger.Info("LLM client enabled", zap.String("provider", name))
+	return nil
+}
+
+// Clients returns every configured client in the order they're tried,
+// with whether it's enabled.
+func (p *LLMProvider) Clients() []LLMClientState {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	states := make([]LLMClientState, 0, len(p.clients))
+	for _, c := range p.clients {
+		state := LLMClientState{Provider: c.ProviderName(), Model: c.ModelName(), Enabled: true}
+		if d, ok := p.disabled[c.ProviderName()]; ok {
+			state.Enabled, state.Reason = false, d.Reason
+			state.DisabledAt = &d.DisabledAt
+		}
+		states = append(states, state)
+	}
+	return states
+}
+
+// enabledClients returns the clients FindLogo may try, in order.
+func (p *LLMProvider) enabledClients() []llm.Client {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	enabled := make([]llm.Client, 0, len(p.clients))
+	for _, c := range p.clients {
+		if _, off := p.disabled[c.ProviderName()]; !off {
+			enabled = append(enabled, c)
+		}
+	}
+	return enabled
+}
+
+// client returns the configured client of a provider, nil if there's none.
+func (p *LLMProvider) client(name string) llm.Client {
+	for _, c := range p.clients {
+		if c.ProviderName() == name {
+			return c
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/fleveque/logo-service/internal/llm"
+	"github.com/fleveque/logo-service/internal/storage"
+)
+
+// missClient is an LLM client that never finds anything, counting its calls.
+type missClient struct {
+	name  string
+	calls int
+}
+
+func (c *missClient) FindLogoURL(context.Context, string, string) (*llm.LogoSearchResult, error) {
+	c.calls++
+	return nil, errors.New("no logo found")
+}
+func (c *missClient) ProviderName() string { return c.name }
+func (c *missClient) ModelName() string    { return c.name + "-model" }
+
+func TestLLMProvider_DisableClient(t *testing.T) {
+	db, err := storage.NewDatabase(storage.MemoryDatabase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	ctx := context.Background()
+
+	anthropic, openai := &missClient{name: "anthropic"}, &missClient{name: "openai"}
+	newProvider := func() *LLMProvider {
+		p := NewLLMProvider([]llm.Client{anthropic, openai}, 6000, storage.NewLLMCallRepository(db), zap.NewNop())
+		p.SetClientStore(storage.NewLLMClientRepository(db))
+		if err := p.RefreshClients(ctx); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	p := newProvider()
+
+	if err := p.DisableClient(ctx, "gemini", "nope"); !errors.Is(err, ErrUnknownLLMClient) {
+		t.Errorf("disabling an unconfigured client: expected ErrUnknownLLMClient, got %v", err)
+	}
+	if err := p.DisableClient(ctx, "anthropic", "outage"); err != nil {
+		t.Fatal(err)
+	}
+	p.FindLogo(ctx, "AAPL", "")
+	if anthropic.calls != 0 || openai.calls != 1 {
+		t.Errorf("expected only openai asked, got anthropic=%d openai=%d", anthropic.calls, openai.calls)
+	}
+
+	// Another instance, or this one after a restart, starts with it off.
+	restarted := newProvider()
+	states := restarted.Clients()
+	if len(states) != 2 || states[0].Enabled || states[0].Reason != "outage" || states[0].DisabledAt == nil || !states[1].Enabled {
+		t.Errorf("expected anthropic disabled after a restart, got %+v", states)
+	}
+
+	if err := restarted.DisableClient(ctx, "openai", "budget freeze"); err != nil {
+		t.Fatal(err)
+	}
+	_, err = restarted.FindLogo(ctx, "AAPL", "")
+	if !errors.Is(err, ErrDisabled) || !errors.Is(err, ErrUnavailable) {
+		t.Errorf("with every client disabled, expected ErrDisabled and ErrUnavailable, got %v", err)
+	}
+	if openai.calls != 1 {
+		t.Errorf("a disabled client must not be called, got %d calls", openai.calls)
+	}
+
+	if err := restarted.EnableClient(ctx, "anthropic"); err != nil {
+		t.Fatal(err)
+	}
+	if err := restarted.EnableClient(ctx, "anthropic"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("enabling an enabled client: expected ErrNotFound, got %v", err)
+	}
+	// The first instance picks it up at its next refresh.
+	if err := p.RefreshClients(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if states := p.Clients(); !states[0].Enabled || states[1].Enabled {
+		t.Errorf("expected anthropic on and openai off after a refresh, got %+v", states)
+	}
+}
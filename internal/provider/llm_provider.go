@@ -4,12 +4,16 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
+	"net/url"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
 	"golang.org/x/time/rate"
 
+	"github.com/fleveque/logo-service/internal/imagefmt"
 	"github.com/fleveque/logo-service/internal/llm"
 	"github.com/fleveque/logo-service/internal/model"
 	"github.com/fleveque/logo-service/internal/storage"
@@ -28,7 +32,17 @@ type LLMProvider struct {
 	limiter     *rate.Limiter
 	llmCallRepo storage.LLMCallRepository
 	httpClient  *http.Client
+	maxBytes    int // Download limit, see SetMaxBytes
 	logger      *zap.Logger
+
+	// wikimediaAPIURL overrides the api.php endpoint derived from the image
+	// host, so tests can point it at a local server.
+	wikimediaAPIURL string
+
+	// The clients an admin switched off (see DisableClient), by provider.
+	mu       sync.RWMutex
+	disabled map[string]model.DisabledLLMClient
+	store    storage.LLMClientRepository // nil: disabled until the next start
 }
 
 // NewLLMProvider creates a provider with an ordered list of LLM clients.
@@ -48,38 +62,63 @@ func NewLLMProvider(
 		clients:     clients,
 		limiter:     rate.NewLimiter(rps, 1), // burst of 1 — strict rate limiting
 		llmCallRepo: llmCallRepo,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-		logger: logger,
+		httpClient:  newHTTPClient(defaultRetryPolicy),
+		maxBytes:    imagefmt.MaxBytes,
+		logger:      logger,
+		disabled:    make(map[string]model.DisabledLLMClient),
 	}
 }
 
 func (p *LLMProvider) Name() string { return "llm" }
 
+// SetRetryPolicy sets the timeout and retries of image downloads
+// (llm.download_timeout_seconds and llm.download_retries). The LLM APIs
+// have their own timeouts.
+func (p *LLMProvider) SetRetryPolicy(policy RetryPolicy) {
+	p.httpClient = newHTTPClient(policy)
+}
+
+// SetMaxBytes replaces imagefmt.MaxBytes as the largest image downloaded
+// (processing.max_fetch_size). Call it before the provider is in use.
+func (p *LLMProvider) SetMaxBytes(n int) {
+	p.maxBytes = n
+}
+
 // GetLogo asks LLM providers (in configured order) to find a logo URL, then downloads it.
+// It satisfies LogoProvider; callers that know the company name should use FindLogo.
 func (p *LLMProvider) GetLogo(ctx context.Context, symbol string) (*LogoResult, error) {
+	return p.FindLogo(ctx, symbol, "")
+}
+
+// FindLogo is GetLogo with a company name hint. Tickers are ambiguous
+// ("ABC", "ON") — a known company name makes the search far more accurate.
+// An empty companyName means the LLM has to work it out from the symbol alone.
+func (p *LLMProvider) FindLogo(ctx context.Context, symbol string, companyName string) (*LogoResult, error) {
 	if len(p.clients) == 0 {
 		return nil, fmt.Errorf("no LLM providers configured")
 	}
+	clients := p.enabledClients()
+	if len(clients) == 0 {
+		return nil, fmt.Errorf("%w: every LLM client is %w", ErrUnavailable, ErrDisabled)
+	}
 
 	var lastErr error
 
 	// Try each provider in order. The order is set by config: llm.provider_order
-	for i, client := range p.clients {
+	for i, client := range clients {
 		// Rate limit — blocks until a token is available or context is cancelled.
 		if err := p.limiter.Wait(ctx); err != nil {
 			return nil, fmt.Errorf("rate limit wait: %w", err)
 		}
 
-		result, err := p.tryProvider(ctx, client, symbol)
+		result, err := p.tryProvider(ctx, client, symbol, companyName)
 		if err == nil {
 			return result, nil
 		}
 
 		lastErr = err
 
-		if i < len(p.clients)-1 {
+		if i < len(clients)-1 {
 			p.logger.Warn("LLM provider failed, trying next",
 				zap.String("symbol", symbol),
 				zap.String("provider", client.ProviderName()),
@@ -97,14 +136,19 @@ func (p *LLMProvider) BulkImport(_ context.Context, _ func(result *LogoResult) e
 	return &ImportStats{}, fmt.Errorf("LLM provider does not support bulk import")
 }
 
-func (p *LLMProvider) tryProvider(ctx context.Context, client llm.Client, symbol string) (*LogoResult, error) {
+func (p *LLMProvider) tryProvider(ctx context.Context, client llm.Client, symbol string, companyName string) (*LogoResult, error) {
 	if client == nil {
 		return nil, fmt.Errorf("LLM client not configured")
 	}
 
+	// An injected fault replaces the search, unrecorded: it cost nothing.
+	if err := injectFault(ctx); err != nil {
+		return nil, err
+	}
+
 	start := time.Now()
 
-	searchResult, err := client.FindLogoURL(ctx, symbol, "")
+	searchResult, err := client.FindLogoURL(ctx, symbol, companyName)
 	duration := time.Since(start).Milliseconds()
 
 	// Record the LLM call for cost tracking
@@ -115,26 +159,73 @@ func (p *LLMProvider) tryProvider(ctx context.Context, client llm.Client, symbol
 	}
 
 	// Download the image from the URL the LLM found
-	imageData, err := p.downloadImage(ctx, searchResult.LogoURL)
+	imageData, imageURL, err := p.downloadImage(ctx, searchResult.LogoURL)
+	if fields := validationFields(err); fields != nil {
+		p.logger.Warn("LLM result is not a usable image",
+			append([]zap.Field{zap.String("symbol", symbol), zap.String("provider", client.ProviderName())}, fields...)...)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("downloading logo from %s: %w", searchResult.LogoURL, err)
 	}
 
+	// A missing or unrecognized confidence counts as low: the result is only
+	// served as-is when llm.min_confidence is low too.
+	confidence := searchResult.Confidence
+	if !model.ValidConfidence(confidence) {
+		confidence = model.ConfidenceLow
+	}
+
+	// Prefer the name the LLM confirmed; fall back to the hint we gave it.
+	resultName := searchResult.CompanyName
+	if resultName == "" {
+		resultName = companyName
+	}
+
+	license, attribution := p.sourceLicense(ctx, imageURL)
+
 	return &LogoResult{
 		Symbol:      symbol,
-		CompanyName: searchResult.CompanyName,
+		CompanyName: resultName,
 		ImageData:   imageData,
 		Source:      fmt.Sprintf("llm:%s", client.ProviderName()),
-		OriginalURL: searchResult.LogoURL,
+		OriginalURL: imageURL,
+		Confidence:  confidence,
+		License:     license,
+		Attribution: attribution,
 	}, nil
 }
 
+// sourceLicense looks up the license of an image found by the LLM. Only
+// Wikimedia publishes one in a machine-readable way; for other sites the
+// license is unknown and the attribution is the site itself.
+// A failed lookup is logged and never fails the acquisition.
+func (p *LLMProvider) sourceLicense(ctx context.Context, logoURL string) (license, attribution string) {
+	host, title, ok := wikimediaFile(logoURL)
+	if !ok {
+		if u, err := url.Parse(logoURL); err == nil {
+			attribution = u.Host
+		}
+		return "", attribution
+	}
+
+	license, artist, err := wikimediaLicense(ctx, p.httpClient, p.wikimediaAPI(host), title)
+	if err != nil {
+		p.logger.Warn("looking up Wikimedia license", zap.String("url", logoURL), zap.Error(err))
+		return "", host
+	}
+	if artist == "" {
+		return license, host
+	}
+	return license, artist + " (" + host + ")"
+}
+
 func (p *LLMProvider) recordCall(ctx context.Context, client llm.Client, symbol string, result *llm.LogoSearchResult, callErr error, durationMs int64) {
 	call := &model.LLMCall{
 		Symbol:   symbol,
 		Provider: client.ProviderName(),
 		Model:    client.ModelName(),
 		Success:  callErr == nil,
+		KeyID:    requester(ctx),
 	}
 	call.DurationMs = &durationMs
 	if result != nil {
@@ -146,27 +237,93 @@ func (p *LLMProvider) recordCall(ctx context.Context, client llm.Client, symbol
 	}
 }
 
-func (p *LLMProvider) downloadImage(ctx context.Context, url string) ([]byte, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+// downloadImage fetches the logo the LLM pointed at. LLMs don't always
+// answer with the image itself, so before giving up it:
+//
+//   - asks the MediaWiki API for the file behind a Wikipedia or Commons file page,
+//   - follows redirects (Special:FilePath, CDNs, http → https),
+//   - follows the og:image of an HTML landing page, one level deep.
+//
+// Files on Wikimedia that are much bigger than needed come as a thumbnail
+// (see wikimediaImage). imageURL is where the bytes finally came from, for
+// OriginalURL and the license lookup.
+func (p *LLMProvider) downloadImage(ctx context.Context, rawURL string) (data []byte, imageURL string, err error) {
+	host, title, isPage := wikimediaFilePage(rawURL)
+	isFile := false
+	if !isPage {
+		host, title, isFile = wikimediaFile(rawURL)
+	}
+
+	if isPage || isFile {
+		fileURL, err := wikimediaImage(ctx, p.httpClient, p.wikimediaAPI(host), title)
+		switch {
+		case err == nil:
+			p.logger.Debug("resolved Wikimedia file", zap.String("url", rawURL), zap.String("file", fileURL))
+			rawURL = fileURL
+		case isPage:
+			return nil, rawURL, fmt.Errorf("resolving %s: %w", title, err)
+		default:
+			// An upload URL downloads without the API, only without the thumbnail.
+			p.logger.Warn("looking up Wikimedia file", zap.String("url", rawURL), zap.Error(err))
+		}
+	}
+	return p.fetch(ctx, rawURL, true)
+}
+
+// fetch downloads rawURL. With followPage, an HTML response is searched for
+// the image it presents (see landingPageImage) and that is fetched instead.
+func (p *LLMProvider) fetch(ctx context.Context, rawURL string, followPage bool) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
 	if err != nil {
-		return nil, fmt.Errorf("creating request: %w", err)
+		return nil, rawURL, fmt.Errorf("creating request: %w", err)
 	}
-	req.Header.Set("User-Agent", "logo-service/1.0")
 
 	resp, err := p.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("downloading: %w", err)
+		return nil, rawURL, fmt.Errorf("downloading: %w", err)
 	}
 	defer resp.Body.Close()
 
+	// Go note: http.Client follows redirects itself; resp.Request is the
+	// last request of the chain, so its URL is where we ended up.
+	finalURL := resp.Request.URL
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HTTP %d for %s", resp.StatusCode, url)
+		return nil, finalURL.String(), statusError(resp, finalURL.String())
 	}
 
-	data, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20)) // 10MB limit
-	if err != nil {
-		return nil, fmt.Errorf("reading body: %w", err)
+	contentType := resp.Header.Get("Content-Type")
+	if followPage && isHTML(contentType) {
+		page, err := io.ReadAll(io.LimitReader(resp.Body, maxLandingPageBytes))
+		if err != nil {
+			return nil, finalURL.String(), fmt.Errorf("reading page: %w", err)
+		}
+		imageURL, ok := landingPageImage(page, finalURL)
+		if !ok {
+			return nil, finalURL.String(), &ValidationError{
+				URL:         finalURL.String(),
+				ContentType: contentType,
+				Reason:      "HTML page names no og:image",
+				Err:         imagefmt.ErrUnsupported,
+			}
+		}
+		p.logger.Debug("following landing page image", zap.String("page", finalURL.String()), zap.String("image", imageURL))
+		return p.fetch(ctx, imageURL, false)
 	}
 
-	return data, nil
+	data, err := readImage(resp, p.maxBytes)
+	return data, finalURL.String(), err
+}
+
+// isHTML reports whether a Content-Type is a web page.
+func isHTML(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType == "text/html" || mediaType == "application/xhtml+xml"
+}
+
+// wikimediaAPI returns the api.php endpoint of a Wikimedia wiki.
+func (p *LLMProvider) wikimediaAPI(host string) string {
+	if p.wikimediaAPIURL != "" {
+		return p.wikimediaAPIURL
+	}
+	return "https://" + host + "/w/api.php"
 }
@@ -0,0 +1,130 @@
+package provider
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// OutboundLimits cap the traffic providers send to any one host, so a bulk
+// import downloading thousands of files from raw.githubusercontent.com
+// doesn't look like abuse and get the server's IP banned.
+type OutboundLimits struct {
+	RPS         float64 // Requests per second per host; 0 is unlimited
+	Concurrency int     // Requests in flight per host; 0 is unlimited
+}
+
+// SetOutboundLimits sets the limits of every provider's HTTP client. It's
+// set once at startup (from providers.outbound_*); requests already waiting
+// keep the limits they started with.
+func SetOutboundLimits(limits OutboundLimits) {
+	outbound.mu.Lock()
+	defer outbound.mu.Unlock()
+	outbound.limits = limits
+	outbound.hosts = make(map[string]*hostLimiter)
+}
+
+// outbound is the transport shared by every provider's client, so the
+// limits hold per host across providers: the GitHub import and on-demand
+// lookups draw on the same budget.
+var outbound = &politeTransport{base: http.DefaultTransport, hosts: make(map[string]*hostLimiter)}
+
+// politeTransport is an http.RoundTripper that waits for its turn before
+// sending a request: a free slot among the host's in-flight requests, then
+// a token from the host's rate limiter. It also adds the User-Agent (see
+// SetIdentity) and the host's credentials, if it has some (see
+// SetCredentials).
+//
+// Go note: a RoundTripper is the layer under http.Client that sends one
+// request and returns its response. Wrapping the default one is how you add
+// behavior to every request of a client — the callers don't change.
+type politeTransport struct {
+	base http.RoundTripper
+
+	mu      sync.Mutex
+	limits  OutboundLimits
+	hosts   map[string]*hostLimiter
+	traffic map[string]*hostUsage // See OutboundUsage
+
+	credentials []Credentials // See SetCredentials
+	identity    Identity      // See SetIdentity
+}
+
+// hostLimiter holds one host's budget. Nil fields mean no limit.
+type hostLimiter struct {
+	rate  *rate.Limiter
+	slots chan struct{} // A buffered channel used as a semaphore
+}
+
+func (t *politeTransport) host(name string) *hostLimiter {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	h, ok := t.hosts[name]
+	if !ok {
+		h = &hostLimiter{}
+		if t.limits.RPS > 0 {
+			h.rate = rate.NewLimiter(rate.Limit(t.limits.RPS), max(1, int(t.limits.RPS)))
+		}
+		if t.limits.Concurrency > 0 {
+			h.slots = make(chan struct{}, t.limits.Concurrency)
+		}
+		t.hosts[name] = h
+	}
+	return h
+}
+
+func (t *politeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	h := t.host(req.URL.Host)
+	ctx := req.Context()
+
+	release := func() {}
+	if h.slots != nil {
+		select {
+		case h.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		release = sync.OnceFunc(func() { <-h.slots })
+	}
+	if h.rate != nil {
+		if err := h.rate.Wait(ctx); err != nil {
+			release()
+			return nil, err
+		}
+	}
+
+	// An injected fault counts against the host like the request it replaces.
+	if err := injectFault(ctx); err != nil {
+		release()
+		return nil, err
+	}
+
+	usage := t.usage(req.URL.Host)
+	usage.requests.Add(1)
+	resp, err := t.base.RoundTrip(t.authenticate(t.identify(req)))
+	if err != nil {
+		release()
+		return nil, err
+	}
+	usage.observe(resp, time.Now())
+	// The slot is held until the body is closed: a download still being
+	// read is still in flight.
+	resp.Body = &releasingBody{ReadCloser: &countingBody{ReadCloser: resp.Body, usage: usage}, release: release}
+	return resp, nil
+}
+
+// releasingBody frees the request's slot when the body is closed.
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
@@ -0,0 +1,125 @@
+package provider
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+func TestPoliteTransport_Concurrency(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	SetOutboundLimits(OutboundLimits{Concurrency: 2})
+	t.Cleanup(func() { SetOutboundLimits(OutboundLimits{}) })
+	client := newHTTPClient(RetryPolicy{Timeout: 5 * time.Second})
+
+	var wg sync.WaitGroup
+	for range 6 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Get(server.URL)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+
+	if got := peak.Load(); got > 2 {
+		t.Errorf("expected at most 2 requests in flight, saw %d", got)
+	}
+}
+
+func TestPoliteTransport_Rate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	SetOutboundLimits(OutboundLimits{RPS: 20})
+	t.Cleanup(func() { SetOutboundLimits(OutboundLimits{}) })
+	client := newHTTPClient(RetryPolicy{Timeout: 5 * time.Second})
+
+	// A burst of 20 goes through at once; the next 10 take half a second.
+	start := time.Now()
+	for range 30 {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("expected requests to be spread out, 30 took %v", elapsed)
+	}
+
+	// A request waiting for its turn gives up with its context.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", server.URL, nil)
+	if _, err := client.Do(req); err == nil {
+		t.Error("expected a cancelled request to fail")
+	}
+}
+
+func TestOutboundUsage(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("X-RateLimit-Limit", "60")
+		w.Header().Set("X-RateLimit-Remaining", "57")
+		w.Header().Set("X-RateLimit-Reset", "1767225600")
+		w.Write([]byte("twelve bytes"))
+	}))
+	defer server.Close()
+
+	client := newHTTPClient(RetryPolicy{Timeout: 5 * time.Second, Retries: 1, Backoff: time.Millisecond})
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	host := server.Listener.Addr().String()
+	var got *model.HostUsage
+	for _, u := range OutboundUsage() {
+		if u.Host == host {
+			got = &u
+		}
+	}
+	if got == nil {
+		t.Fatalf("no usage for %s", host)
+	}
+	if got.Requests != 2 || got.Throttled != 1 || got.Bytes != int64(len("twelve bytes")) {
+		t.Errorf("expected 2 requests, 1 throttled and the body's bytes, got %+v", got)
+	}
+	if got.Quota == nil || got.Quota.Limit != 60 || got.Quota.Remaining != 57 ||
+		!got.Quota.ResetAt.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected the quota of the last response, got %+v", got.Quota)
+	}
+}
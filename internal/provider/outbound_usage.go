@@ -0,0 +1,105 @@
+package provider
+
+import (
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+// hostUsage counts the traffic to one host.
+//
+// Go note: atomic counters let every request add to them without taking
+// a lock; only the quota, which is several fields, needs one.
+type hostUsage struct {
+	requests  atomic.Int64
+	throttled atomic.Int64
+	bytes     atomic.Int64
+
+	mu    sync.Mutex
+	quota *model.HostQuota
+}
+
+// usage returns the counters of a host, creating them on first use. Unlike
+// the limiters, they're never reset: SetOutboundLimits doesn't lose them.
+func (t *politeTransport) usage(host string) *hostUsage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.traffic == nil {
+		t.traffic = make(map[string]*hostUsage)
+	}
+	u, ok := t.traffic[host]
+	if !ok {
+		u = &hostUsage{}
+		t.traffic[host] = u
+	}
+	return u
+}
+
+// observe records a response: a 429, and the quota its X-RateLimit-*
+// headers announce, as the GitHub API sends them.
+func (u *hostUsage) observe(resp *http.Response, now time.Time) {
+	if resp.StatusCode == http.StatusTooManyRequests {
+		u.throttled.Add(1)
+	}
+	remaining, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Remaining"), 10, 64)
+	if err != nil {
+		return
+	}
+	quota := &model.HostQuota{Remaining: remaining, ObservedAt: now.UTC()}
+	quota.Limit, _ = strconv.ParseInt(resp.Header.Get("X-RateLimit-Limit"), 10, 64)
+	if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		quota.ResetAt = time.Unix(reset, 0).UTC()
+	}
+	u.mu.Lock()
+	u.quota = quota
+	u.mu.Unlock()
+}
+
+// OutboundUsage returns the traffic providers sent to each host since the
+// server started, by host. The LLM APIs aren't in it: their SDKs have
+// clients of their own, and llm_calls counts them.
+func OutboundUsage() []model.HostUsage {
+	outbound.mu.Lock()
+	hosts := make(map[string]*hostUsage, len(outbound.traffic))
+	for host, u := range outbound.traffic {
+		hosts[host] = u
+	}
+	outbound.mu.Unlock()
+
+	usage := make([]model.HostUsage, 0, len(hosts))
+	for host, u := range hosts {
+		h := model.HostUsage{
+			Host:      host,
+			Requests:  u.requests.Load(),
+			Throttled: u.throttled.Load(),
+			Bytes:     u.bytes.Load(),
+		}
+		u.mu.Lock()
+		if u.quota != nil {
+			quota := *u.quota
+			h.Quota = &quota
+		}
+		u.mu.Unlock()
+		usage = append(usage, h)
+	}
+	sort.Slice(usage, func(i, j int) bool { return usage[i].Host < usage[j].Host })
+	return usage
+}
+
+// countingBody adds the bytes read from a response body to its host's count.
+type countingBody struct {
+	io.ReadCloser
+	usage *hostUsage
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.usage.bytes.Add(int64(n))
+	return n, err
+}
@@ -11,6 +11,18 @@ type LogoResult struct {
 	ImageData   []byte // Raw image bytes (PNG/SVG/JPG/WebP)
 	Source      string // e.g., "github:davidepalazzo/ticker-logos"
 	OriginalURL string // Where the image was downloaded from
+	Confidence  string // "high", "medium", "low" — set by LLM providers only
+	License     string // e.g. "MIT" for a GitHub repo, "CC BY-SA 4.0" on Wikimedia; empty if unknown
+	Attribution string // Who to credit: the repo, or the author on Wikimedia
+
+	// HTTP validators of the download, stored so a later import can ask
+	// for the file only if it changed. Set by the GitHub provider.
+	ETag         string
+	LastModified string
+
+	// Authoritative results come from a source that corrects what's stored
+	// (an authoritative GitHub repo): imports replace the logo with them.
+	Authoritative bool
 }
 
 // ImportStats tracks the results of a bulk import operation.
@@ -0,0 +1,20 @@
+package provider
+
+import "context"
+
+// requesterKey is the context key of the requesting API key's fingerprint.
+type requesterKey struct{}
+
+// WithRequester returns a context whose LLM searches are recorded as caused
+// by keyID (see middleware.KeyID), so their cost shows in that key's usage.
+// The HTTP server sets it on every authenticated request; searches started
+// by an admin or the CLI are left unattributed.
+func WithRequester(ctx context.Context, keyID string) context.Context {
+	return context.WithValue(ctx, requesterKey{}, keyID)
+}
+
+// requester returns the context's requesting key, "" if none.
+func requester(ctx context.Context) string {
+	keyID, _ := ctx.Value(requesterKey{}).(string)
+	return keyID
+}
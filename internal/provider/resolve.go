@@ -0,0 +1,59 @@
+package provider
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// maxLandingPageBytes caps how much of an HTML page is searched for its
+// image. The <meta> tags are in <head>, well within it.
+const maxLandingPageBytes = 1 << 20
+
+var (
+	// Go note: regexps aren't an HTML parser, but <meta> and <link> tags
+	// are flat and well-formed on the pages worth following, and the
+	// standard library has no HTML parser to reach for.
+	htmlTagPattern = regexp.MustCompile(`(?is)<(?:meta|link)\s[^>]*>`)
+	htmlAttr       = regexp.MustCompile(`(?is)([a-z][a-z0-9:_-]*)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+)
+
+// landingPageImage finds the image an HTML page presents itself with:
+// og:image (the Open Graph tag sites set for link previews), then
+// twitter:image, then <link rel="image_src">. Relative URLs are resolved
+// against pageURL. ok is false if the page names none.
+func landingPageImage(page []byte, pageURL *url.URL) (imageURL string, ok bool) {
+	// Lower number wins; the first tag of the best kind is kept.
+	ranks := map[string]int{"og:image": 1, "og:image:url": 1, "og:image:secure_url": 2, "twitter:image": 3, "twitter:image:src": 3, "image_src": 4}
+	best, bestRank := "", len(ranks)+1
+
+	for _, tag := range htmlTagPattern.FindAll(page, -1) {
+		attrs := map[string]string{}
+		for _, m := range htmlAttr.FindAllSubmatch(tag, -1) {
+			attrs[strings.ToLower(string(m[1]))] = html.UnescapeString(string(m[2]) + string(m[3]) + string(m[4]))
+		}
+
+		key, value := attrs["property"], attrs["content"]
+		if key == "" {
+			key = attrs["name"]
+		}
+		if strings.EqualFold(attrs["rel"], "image_src") {
+			key, value = "image_src", attrs["href"]
+		}
+		rank, known := ranks[strings.ToLower(key)]
+		if !known || strings.TrimSpace(value) == "" || rank >= bestRank {
+			continue
+		}
+		best, bestRank = strings.TrimSpace(value), rank
+	}
+
+	if best == "" {
+		return "", false
+	}
+	resolved, err := pageURL.Parse(best)
+	if err != nil || (resolved.Scheme != "http" && resolved.Scheme != "https") {
+		return "", false
+	}
+	return resolved.String(), true
+}
@@ -0,0 +1,102 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/fleveque/logo-service/internal/imagefmt"
+)
+
+func TestLandingPageImage(t *testing.T) {
+	page, _ := url.Parse("https://www.acme.com/about/brand")
+
+	tests := []struct {
+		name   string
+		html   string
+		want   string
+		wantOK bool
+	}{
+		{"og:image", `<head><meta property="og:image" content="https://cdn.acme.com/logo.png"></head>`, "https://cdn.acme.com/logo.png", true},
+		{"relative and escaped", `<meta content='/img/logo.png?v=1&amp;s=2' property='og:image'/>`, "https://www.acme.com/img/logo.png?v=1&s=2", true},
+		{"og:image beats twitter:image", `<meta name="twitter:image" content="/t.png"><meta property="og:image" content="/og.png">`, "https://www.acme.com/og.png", true},
+		{"twitter:image", `<META NAME="twitter:image" CONTENT="/t.png">`, "https://www.acme.com/t.png", true},
+		{"image_src link", `<link rel="image_src" href="logo.svg">`, "https://www.acme.com/about/logo.svg", true},
+		{"none", `<meta name="description" content="Acme"><img src="/logo.png">`, "", false},
+		{"not http", `<meta property="og:image" content="javascript:alert(1)">`, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := landingPageImage([]byte(tt.html), page)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("landingPageImage() = %q, %v; want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestLLMProvider_DownloadImage(t *testing.T) {
+	logo := `<svg xmlns="http://www.w3.org/2000/svg"></svg>` // As sanitized
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/logo.svg":
+			w.Header().Set("Content-Type", "image/svg+xml")
+			w.Write([]byte(logo))
+		case "/old-logo":
+			http.Redirect(w, r, "/logo.svg", http.StatusMovedPermanently)
+		case "/brand":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte(`<html><head><meta property="og:image" content="/old-logo"></head></html>`))
+		case "/about":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<html><head><title>About</title></head></html>`))
+		case "/w/api.php":
+			if r.URL.Query().Get("titles") != "File:Acme_logo.svg" {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write([]byte(`{"query":{"pages":{"1":{"imageinfo":[{"url":"` + server.URL + `/logo.svg","size":420,"mime":"image/svg+xml","width":100,"height":50}]}}}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	p := NewLLMProvider(nil, 60, nil, zap.NewNop())
+	p.wikimediaAPIURL = server.URL + "/w/api.php"
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		url     string
+		wantErr error
+	}{
+		{"image", server.URL + "/logo.svg", nil},
+		{"redirect", server.URL + "/old-logo", nil},
+		{"landing page, then redirect", server.URL + "/brand", nil},
+		{"wikipedia file page", "https://en.wikipedia.org/wiki/File:Acme_logo.svg", nil},
+		{"page without image", server.URL + "/about", imagefmt.ErrUnsupported},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, imageURL, err := p.downloadImage(ctx, tt.url)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("downloadImage() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if string(data) != logo || imageURL != server.URL+"/logo.svg" {
+				t.Errorf("downloadImage() = %q from %q", data, imageURL)
+			}
+		})
+	}
+}
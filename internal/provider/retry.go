@@ -0,0 +1,127 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/fleveque/logo-service/internal/clock"
+)
+
+// ErrUnavailable marks a failure of the source itself — a network error, a
+// timeout, a 429 or 5xx that outlasted the retries — as opposed to a miss.
+// The service counts these to skip a dead provider (see service.CircuitPolicy).
+var ErrUnavailable = errors.New("provider unavailable")
+
+// RetryPolicy is how a provider's HTTP client deals with slow and failing
+// hosts. Set it with the provider's SetRetryPolicy.
+type RetryPolicy struct {
+	Timeout time.Duration // Per attempt, reading the body included; 0 waits forever
+	Retries int           // Extra attempts after a network error, timeout, 429 or 5xx
+	Backoff time.Duration // Before the first retry, doubling after each; 0 uses defaultBackoff
+}
+
+// defaultRetryPolicy is what providers use until told otherwise: the fixed
+// 30s timeout they've always had, and no retries.
+var defaultRetryPolicy = RetryPolicy{Timeout: 30 * time.Second}
+
+const defaultBackoff = 500 * time.Millisecond
+
+// newHTTPClient returns a client that applies policy and goes through the
+// outbound limits.
+//
+// Go note: the timeout isn't http.Client.Timeout, which would cover every
+// attempt and the waits between them. The transport gives each attempt its
+// own deadline instead.
+func newHTTPClient(policy RetryPolicy) *http.Client {
+	return &http.Client{Transport: &retryTransport{
+		base:   outbound,
+		policy: policy,
+		clock:  clock.System,
+		random: clock.Global,
+	}}
+}
+
+// retryTransport is an http.RoundTripper that sends a GET again when the
+// attempt failed in a way that may pass: a network error, a timeout, or a
+// 429 or 5xx status. Anything else (a 404, a 200) is returned at once.
+type retryTransport struct {
+	base   http.RoundTripper
+	policy RetryPolicy
+	clock  clock.Clock
+	random clock.Random
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	backoff := t.policy.Backoff
+	if backoff <= 0 {
+		backoff = defaultBackoff
+	}
+	// Only requests without a body can be sent twice as they are.
+	retries := t.policy.Retries
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		retries = 0
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := t.attempt(req)
+		if ctx.Err() != nil {
+			// The caller gave up: that says nothing about the provider.
+			if resp != nil {
+				resp.Body.Close()
+			}
+			return nil, ctx.Err()
+		}
+		if err == nil && !retryableStatus(resp.StatusCode) {
+			return resp, nil
+		}
+		if attempt >= retries {
+			if err != nil {
+				return nil, fmt.Errorf("%w: %w", ErrUnavailable, err)
+			}
+			return resp, nil // The caller reports the status (see statusError)
+		}
+
+		if resp != nil {
+			// Drained so the connection can be reused for the next attempt.
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+		if err := t.clock.Sleep(ctx, clock.Jitter(backoff<<attempt, 0.2, t.random)); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// attempt sends req once, under the per-attempt timeout. The deadline keeps
+// running while the body is read, and is released when it's closed.
+func (t *retryTransport) attempt(req *http.Request) (*http.Response, error) {
+	if t.policy.Timeout <= 0 {
+		return t.base.RoundTrip(req)
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), t.policy.Timeout)
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: cancel}
+	return resp, nil
+}
+
+func retryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= 500
+}
+
+// statusError describes a response that isn't a 200. Statuses the retries
+// couldn't get past wrap ErrUnavailable; others (a 404) are plain misses.
+func statusError(resp *http.Response, url string) error {
+	if retryableStatus(resp.StatusCode) {
+		return fmt.Errorf("%w: HTTP %d for %s", ErrUnavailable, resp.StatusCode, url)
+	}
+	return fmt.Errorf("HTTP %d for %s", resp.StatusCode, url)
+}
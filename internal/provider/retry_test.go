@@ -0,0 +1,92 @@
+package provider
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fleveque/logo-service/internal/clock"
+)
+
+// testClient is newHTTPClient on a fake clock, so backoffs don't sleep.
+func testClient(policy RetryPolicy) (*http.Client, *clock.Fake) {
+	fake := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	return &http.Client{Transport: &retryTransport{
+		base:   http.DefaultTransport,
+		policy: policy,
+		clock:  fake,
+		random: clock.NewRandom(1),
+	}}, fake
+}
+
+func TestRetryTransport(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     int // Answered with 503 before a 200
+		status       int // The failing status
+		retries      int
+		wantStatus   int
+		wantRequests int32
+	}{
+		{"success first time", 0, 503, 2, 200, 1},
+		{"recovers within the retries", 2, 503, 2, 200, 3},
+		{"rate limited, then served", 1, 429, 1, 200, 2},
+		{"retries exhausted", 3, 503, 2, 503, 3},
+		{"not found is not retried", 3, 404, 2, 404, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if int(requests.Add(1)) <= tt.failures {
+					w.WriteHeader(tt.status)
+				}
+			}))
+			defer server.Close()
+
+			client, fake := testClient(RetryPolicy{Timeout: 5 * time.Second, Retries: tt.retries, Backoff: time.Second})
+			resp, err := client.Get(server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if got := requests.Load(); got != tt.wantRequests {
+				t.Errorf("%d requests, want %d", got, tt.wantRequests)
+			}
+			// Backoffs double, give or take the jitter.
+			for i, d := range fake.Slept() {
+				want := time.Second << i
+				if d < want*8/10 || d > want*12/10 {
+					t.Errorf("backoff %d was %v, want about %v", i, d, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryTransport_TimeoutIsUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+
+	client, fake := testClient(RetryPolicy{Timeout: 20 * time.Millisecond, Retries: 1})
+	_, err := client.Get(server.URL)
+	if !errors.Is(err, ErrUnavailable) {
+		t.Errorf("expected ErrUnavailable, got %v", err)
+	}
+	if len(fake.Slept()) != 1 {
+		t.Errorf("expected one retry, slept %v", fake.Slept())
+	}
+}
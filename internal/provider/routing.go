@@ -0,0 +1,81 @@
+package provider
+
+import (
+	"slices"
+	"strings"
+)
+
+// Region routes symbols listed on one exchange (or group of exchanges) to
+// the GitHub repos that cover it. The default repos are mostly US tickers;
+// a Tokyo symbol like 7203.T is better served by a Japanese repo, and a
+// US repo's "7203.T" icon, if any, is more likely wrong than right.
+type Region struct {
+	Name     string   // For logs only, e.g. "japan"
+	Suffixes []string // Exchange suffixes, e.g. [".T"] for Tokyo
+	Repos    []string // Replace the default repos for these symbols
+}
+
+// RoutingPolicy decides which repos may supply a symbol's logo.
+// The zero value routes everything to the default repos.
+type RoutingPolicy struct {
+	regions []Region
+}
+
+// NewRoutingPolicy creates a policy from regions. Suffixes are matched case-insensitively.
+func NewRoutingPolicy(regions []Region) *RoutingPolicy {
+	p := &RoutingPolicy{regions: make([]Region, len(regions))}
+	for i, r := range regions {
+		r.Suffixes = slices.Clone(r.Suffixes)
+		for j, s := range r.Suffixes {
+			r.Suffixes[j] = strings.ToUpper(s)
+		}
+		p.regions[i] = r
+	}
+	return p
+}
+
+// Region returns the region a symbol belongs to. When several suffixes
+// match, the longest wins, so ".TO" (Toronto) beats ".O".
+func (p *RoutingPolicy) Region(symbol string) (Region, bool) {
+	if p == nil {
+		return Region{}, false
+	}
+	symbol = strings.ToUpper(symbol)
+
+	var best Region
+	bestLen := 0
+	for _, r := range p.regions {
+		for _, s := range r.Suffixes {
+			if len(s) > bestLen && strings.HasSuffix(symbol, s) {
+				best, bestLen = r, len(s)
+			}
+		}
+	}
+	return best, bestLen > 0
+}
+
+// Repos returns the repos to try for a symbol, in order: its region's repos
+// if it has one, otherwise the defaults.
+func (p *RoutingPolicy) Repos(symbol string, defaults []string) []string {
+	if r, ok := p.Region(symbol); ok {
+		return r.Repos
+	}
+	return defaults
+}
+
+// AllRepos returns the defaults plus every regional repo, without duplicates:
+// everything a bulk import has to walk.
+func (p *RoutingPolicy) AllRepos(defaults []string) []string {
+	repos := slices.Clone(defaults)
+	if p == nil {
+		return repos
+	}
+	for _, r := range p.regions {
+		for _, repo := range r.Repos {
+			if !slices.Contains(repos, repo) {
+				repos = append(repos, repo)
+			}
+		}
+	}
+	return repos
+}
@@ -0,0 +1,45 @@
+package provider
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRoutingPolicy_Repos(t *testing.T) {
+	defaults := []string{"us/logos", "misc/icons"}
+	policy := NewRoutingPolicy([]Region{
+		{Name: "japan", Suffixes: []string{".t"}, Repos: []string{"jp/logos"}},
+		{Name: "canada", Suffixes: []string{".TO", ".V"}, Repos: []string{"ca/logos", "misc/icons"}},
+		{Name: "nasdaq-otc", Suffixes: []string{".O"}, Repos: []string{"otc/logos"}},
+	})
+
+	tests := []struct {
+		symbol string
+		want   []string
+	}{
+		{"7203.T", []string{"jp/logos"}},
+		{"SHOP.TO", []string{"ca/logos", "misc/icons"}}, // Longest suffix wins over ".O"
+		{"AAPL.O", []string{"otc/logos"}},
+		{"AAPL", defaults},
+		{"BRK.B", defaults},
+		{"TSLA", defaults},
+	}
+	for _, tt := range tests {
+		t.Run(tt.symbol, func(t *testing.T) {
+			if got := policy.Repos(tt.symbol, defaults); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Repos(%s) = %v, want %v", tt.symbol, got, tt.want)
+			}
+		})
+	}
+
+	want := []string{"us/logos", "misc/icons", "jp/logos", "ca/logos", "otc/logos"}
+	if got := policy.AllRepos(defaults); !reflect.DeepEqual(got, want) {
+		t.Errorf("AllRepos = %v, want %v", got, want)
+	}
+
+	// A nil policy routes everything to the defaults.
+	var none *RoutingPolicy
+	if got := none.Repos("7203.T", defaults); !reflect.DeepEqual(got, defaults) {
+		t.Errorf("nil policy: Repos = %v, want defaults", got)
+	}
+}
@@ -0,0 +1,240 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+// LLM searches often land on Wikimedia, where every file page states a
+// license and an author. Both are looked up through the MediaWiki API, so a
+// takedown request can be answered with where the image came from. The same
+// API turns a file's description page into the address of the file, and
+// renders thumbnails of files too big to download whole.
+
+// wikimediaFile maps an upload.wikimedia.org URL to the wiki that hosts the
+// file and its page title:
+//
+//	https://upload.wikimedia.org/wikipedia/commons/a/ab/Apple_logo.svg
+//	https://upload.wikimedia.org/wikipedia/en/thumb/a/ab/Foo.png/200px-Foo.png
+//
+// give ("commons.wikimedia.org", "File:Apple_logo.svg") and ("en.wikipedia.org", "File:Foo.png").
+func wikimediaFile(rawURL string) (host, title string, ok bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host != "upload.wikimedia.org" {
+		return "", "", false
+	}
+
+	// ["wikipedia", project, (thumb,) hash1, hash2, filename, (thumb name)]
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 5 || parts[0] != "wikipedia" {
+		return "", "", false
+	}
+	project, rest := parts[1], parts[2:]
+	if rest[0] == "thumb" {
+		rest = rest[1:]
+	}
+	if len(rest) < 3 {
+		return "", "", false
+	}
+
+	host = project + ".wikipedia.org"
+	if project == "commons" {
+		host = "commons.wikimedia.org"
+	}
+	name, err := url.PathUnescape(rest[2])
+	if err != nil {
+		return "", "", false
+	}
+	return host, "File:" + name, true
+}
+
+// htmlTag strips markup: the API returns the artist as HTML, usually a link.
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+// wikimediaFilePage maps a Wikipedia or Commons page that shows a file,
+// rather than the file itself, to its wiki and title. LLMs often answer
+// with one of these:
+//
+//	https://commons.wikimedia.org/wiki/File:Apple_logo_black.svg
+//	https://en.wikipedia.org/w/index.php?title=File:Apple_logo_black.svg
+//	https://en.wikipedia.org/wiki/Apple_Inc.#/media/File:Apple_logo_black.svg
+//
+// all give (host, "File:Apple_logo_black.svg"). Special:FilePath URLs aren't
+// matched: they redirect to the file, which the download follows anyway.
+func wikimediaFilePage(rawURL string) (host, title string, ok bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (!strings.HasSuffix(u.Host, ".wikipedia.org") && u.Host != "commons.wikimedia.org") {
+		return "", "", false
+	}
+
+	// The media viewer keeps the file in the fragment of the article URL.
+	if name, found := strings.CutPrefix(u.Fragment, "/media/"); found {
+		title = name
+	} else if u.Path == "/w/index.php" {
+		title = u.Query().Get("title")
+	} else {
+		title = strings.TrimPrefix(u.EscapedPath(), "/wiki/")
+		if title, err = url.PathUnescape(title); err != nil {
+			return "", "", false
+		}
+	}
+
+	// "Image:" is the old name of the namespace and still redirects.
+	for _, prefix := range []string{"File:", "Image:"} {
+		if name, found := strings.CutPrefix(title, prefix); found && name != "" {
+			return u.Host, "File:" + strings.ReplaceAll(name, " ", "_"), true
+		}
+	}
+	return "", "", false
+}
+
+// wikimediaImageInfo is the one imageinfo entry the MediaWiki API returns
+// for a file title, with the properties we ask for.
+type wikimediaImageInfo struct {
+	URL      string `json:"url"`
+	ThumbURL string `json:"thumburl"` // Only when a thumbnail width was asked for
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+	Size     int    `json:"size"` // Bytes
+	Mime     string `json:"mime"`
+
+	ExtMetadata struct {
+		LicenseShortName struct {
+			Value string `json:"value"`
+		} `json:"LicenseShortName"`
+		Artist struct {
+			Value string `json:"value"`
+		} `json:"Artist"`
+	} `json:"extmetadata"`
+}
+
+// queryImageInfo asks a Wikimedia wiki's api.php for a file's imageinfo.
+// params add to the query: iiprop selects what's filled in ("url",
+// "extmetadata", ...), iiurlwidth asks for a thumbnail.
+func queryImageInfo(ctx context.Context, client *http.Client, apiURL, title string, params url.Values) (*wikimediaImageInfo, error) {
+	query := url.Values{
+		"action": {"query"},
+		"prop":   {"imageinfo"},
+		"titles": {title},
+		"format": {"json"},
+	}
+	for k, v := range params {
+		query[k] = v
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	// Wikimedia asks API clients to identify themselves.
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("querying Wikimedia: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Wikimedia API returned %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Query struct {
+			// Pages are keyed by page id, which we don't know up front.
+			Pages map[string]struct {
+				ImageInfo []wikimediaImageInfo `json:"imageinfo"`
+			} `json:"pages"`
+		} `json:"query"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decoding Wikimedia response: %w", err)
+	}
+
+	for _, page := range body.Query.Pages {
+		if len(page.ImageInfo) > 0 {
+			return &page.ImageInfo[0], nil
+		}
+	}
+	return nil, fmt.Errorf("no image info for %s", title)
+}
+
+// wikimediaLicense returns the license and author of a file on a Wikimedia wiki.
+// apiURL is the wiki's api.php endpoint.
+func wikimediaLicense(ctx context.Context, client *http.Client, apiURL, title string) (license, artist string, err error) {
+	info, err := queryImageInfo(ctx, client, apiURL, title, url.Values{"iiprop": {"extmetadata"}})
+	if err != nil {
+		return "", "", err
+	}
+	meta := info.ExtMetadata
+	artist = strings.TrimSpace(html.UnescapeString(htmlTag.ReplaceAllString(meta.Artist.Value, "")))
+	return meta.LicenseShortName.Value, artist, nil
+}
+
+// Wikimedia keeps originals as uploaded: 5000px PNGs and multi-megabyte
+// SVGs aren't rare among logos. Past these limits we download a thumbnail
+// rendered by Wikimedia instead, which is far smaller and, for SVGs, saves
+// rasterizing the whole drawing here.
+const (
+	maxWikimediaRasterSide = 1024      // Shorter side of a raster original
+	maxWikimediaSVGBytes   = 256 << 10 // Size of an SVG original
+	maxWikimediaBytes      = 2 << 20   // Size of any original
+)
+
+// wikimediaThumbSide is the shorter side of the thumbnails we ask for:
+// twice the largest stored size, so nothing is upscaled and the quality
+// score doesn't see a low-resolution source.
+var wikimediaThumbSide = 2 * model.SizePixels[model.SizeXL]
+
+// thumbnailWidth returns the width to ask for so the thumbnail's shorter
+// side is wikimediaThumbSide. ok is false if the original is fine as is.
+func (info *wikimediaImageInfo) thumbnailWidth() (width int, ok bool) {
+	oversized := info.Size > maxWikimediaBytes
+	if info.Mime == "image/svg+xml" {
+		oversized = oversized || info.Size > maxWikimediaSVGBytes
+	} else {
+		oversized = oversized || min(info.Width, info.Height) > maxWikimediaRasterSide
+	}
+	if !oversized || info.Width <= 0 || info.Height <= 0 {
+		return 0, false
+	}
+
+	// The API sizes thumbnails by width; a wide logo needs a wider one to
+	// keep its height at wikimediaThumbSide.
+	width = wikimediaThumbSide
+	if info.Width > info.Height {
+		width = wikimediaThumbSide * info.Width / info.Height
+	}
+	return width, true
+}
+
+// wikimediaImage returns the URL to download a Wikimedia file from: the
+// original, or a thumbnail if the original is oversized (see thumbnailWidth).
+// Thumbnails of SVGs are PNGs.
+func wikimediaImage(ctx context.Context, client *http.Client, apiURL, title string) (string, error) {
+	info, err := queryImageInfo(ctx, client, apiURL, title, url.Values{"iiprop": {"url|size|mime"}})
+	if err != nil {
+		return "", err
+	}
+	if info.URL == "" {
+		return "", fmt.Errorf("no file URL for %s", title)
+	}
+
+	width, ok := info.thumbnailWidth()
+	if !ok {
+		return info.URL, nil
+	}
+	thumb, err := queryImageInfo(ctx, client, apiURL, title, url.Values{"iiprop": {"url"}, "iiurlwidth": {strconv.Itoa(width)}})
+	if err != nil || thumb.ThumbURL == "" {
+		// The original still works, just slower.
+		return info.URL, nil
+	}
+	return thumb.ThumbURL, nil
+}
@@ -0,0 +1,147 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestWikimediaFile(t *testing.T) {
+	tests := []struct {
+		url       string
+		wantHost  string
+		wantTitle string
+		wantOK    bool
+	}{
+		{"https://upload.wikimedia.org/wikipedia/commons/f/fa/Apple_logo_black.svg", "commons.wikimedia.org", "File:Apple_logo_black.svg", true},
+		{"https://upload.wikimedia.org/wikipedia/commons/thumb/f/fa/Apple_logo_black.svg/200px-Apple_logo_black.svg.png", "commons.wikimedia.org", "File:Apple_logo_black.svg", true},
+		{"https://upload.wikimedia.org/wikipedia/en/a/a9/Banco_Santander_Logotipo%C3%A9.svg", "en.wikipedia.org", "File:Banco_Santander_Logotipoé.svg", true},
+		{"https://upload.wikimedia.org/wikipedia/commons/logo.svg", "", "", false},
+		{"https://www.apple.com/logo.svg", "", "", false},
+		{"not a url\x7f", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			host, title, ok := wikimediaFile(tt.url)
+			if host != tt.wantHost || title != tt.wantTitle || ok != tt.wantOK {
+				t.Errorf("wikimediaFile(%q) = %q, %q, %v; want %q, %q, %v",
+					tt.url, host, title, ok, tt.wantHost, tt.wantTitle, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestLLMProvider_SourceLicense(t *testing.T) {
+	var gotTitle string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotTitle = r.URL.Query().Get("titles")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"query":{"pages":{"123":{"imageinfo":[{"extmetadata":{
+			"LicenseShortName":{"value":"CC BY-SA 4.0"},
+			"Artist":{"value":"<a href=\"//commons.wikimedia.org/wiki/User:Jane\">Jane &amp; Co</a>"}
+		}}]}}}}`))
+	}))
+	defer server.Close()
+
+	p := NewLLMProvider(nil, 60, nil, zap.NewNop())
+	p.wikimediaAPIURL = server.URL
+	ctx := context.Background()
+
+	license, attribution := p.sourceLicense(ctx, "https://upload.wikimedia.org/wikipedia/commons/a/ab/Acme_logo.svg")
+	if gotTitle != "File:Acme_logo.svg" {
+		t.Errorf("queried title %q", gotTitle)
+	}
+	if license != "CC BY-SA 4.0" || attribution != "Jane & Co (commons.wikimedia.org)" {
+		t.Errorf("got license %q, attribution %q", license, attribution)
+	}
+
+	// Elsewhere the license is unknown and the site is credited.
+	license, attribution = p.sourceLicense(ctx, "https://www.acme.com/img/logo.png")
+	if license != "" || attribution != "www.acme.com" {
+		t.Errorf("non-Wikimedia: got license %q, attribution %q", license, attribution)
+	}
+}
+
+func TestWikimediaFilePage(t *testing.T) {
+	tests := []struct {
+		url       string
+		wantHost  string
+		wantTitle string
+		wantOK    bool
+	}{
+		{"https://commons.wikimedia.org/wiki/File:Apple_logo_black.svg", "commons.wikimedia.org", "File:Apple_logo_black.svg", true},
+		{"https://en.wikipedia.org/wiki/File:Banco_Santander_Logotipo%C3%A9.svg", "en.wikipedia.org", "File:Banco_Santander_Logotipoé.svg", true},
+		{"https://en.wikipedia.org/w/index.php?title=File:Apple logo black.svg", "en.wikipedia.org", "File:Apple_logo_black.svg", true},
+		{"https://en.wikipedia.org/wiki/Apple_Inc.#/media/File:Apple_logo_black.svg", "en.wikipedia.org", "File:Apple_logo_black.svg", true},
+		{"https://de.wikipedia.org/wiki/Image:Siemens-logo.svg", "de.wikipedia.org", "File:Siemens-logo.svg", true},
+		{"https://en.wikipedia.org/wiki/Apple_Inc.", "", "", false},
+		{"https://upload.wikimedia.org/wikipedia/commons/f/fa/Apple_logo_black.svg", "", "", false},
+		{"https://www.apple.com/wiki/File:logo.svg", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			host, title, ok := wikimediaFilePage(tt.url)
+			if host != tt.wantHost || title != tt.wantTitle || ok != tt.wantOK {
+				t.Errorf("wikimediaFilePage(%q) = %q, %q, %v; want %q, %q, %v",
+					tt.url, host, title, ok, tt.wantHost, tt.wantTitle, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestWikimediaImageInfo_ThumbnailWidth(t *testing.T) {
+	side := wikimediaThumbSide
+	tests := []struct {
+		name      string
+		info      wikimediaImageInfo
+		wantWidth int
+		wantOK    bool
+	}{
+		{"small png", wikimediaImageInfo{Mime: "image/png", Width: 800, Height: 600, Size: 40 << 10}, 0, false},
+		{"5000px png", wikimediaImageInfo{Mime: "image/png", Width: 5000, Height: 5000, Size: 900 << 10}, side, true},
+		{"wide png keeps its height", wikimediaImageInfo{Mime: "image/png", Width: 8000, Height: 2000, Size: 900 << 10}, side * 4, true},
+		{"tall png", wikimediaImageInfo{Mime: "image/png", Width: 2000, Height: 8000, Size: 900 << 10}, side, true},
+		{"small svg", wikimediaImageInfo{Mime: "image/svg+xml", Width: 5000, Height: 5000, Size: 8 << 10}, 0, false},
+		{"massive svg", wikimediaImageInfo{Mime: "image/svg+xml", Width: 300, Height: 100, Size: 3 << 20}, side * 3, true},
+		{"heavy jpeg", wikimediaImageInfo{Mime: "image/jpeg", Width: 1000, Height: 1000, Size: 5 << 20}, side, true},
+		{"no dimensions", wikimediaImageInfo{Mime: "image/svg+xml", Size: 3 << 20}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			width, ok := tt.info.thumbnailWidth()
+			if width != tt.wantWidth || ok != tt.wantOK {
+				t.Errorf("thumbnailWidth() = %d, %v; want %d, %v", width, ok, tt.wantWidth, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestWikimediaImage_Thumbnail(t *testing.T) {
+	const original = "https://upload.wikimedia.org/wikipedia/commons/a/ab/Acme_logo.svg"
+	var gotWidth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if gotWidth = r.URL.Query().Get("iiurlwidth"); gotWidth != "" {
+			w.Write([]byte(`{"query":{"pages":{"1":{"imageinfo":[{"url":"` + original + `",
+				"thumburl":"https://upload.wikimedia.org/wikipedia/commons/thumb/a/ab/Acme_logo.svg/1024px-Acme_logo.svg.png"}]}}}}`))
+			return
+		}
+		w.Write([]byte(`{"query":{"pages":{"1":{"imageinfo":[{"url":"` + original + `",
+			"mime":"image/svg+xml","size":4000000,"width":1000,"height":500}]}}}}`))
+	}))
+	defer server.Close()
+
+	got, err := wikimediaImage(context.Background(), server.Client(), server.URL, "File:Acme_logo.svg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(got, "/1024px-Acme_logo.svg.png") || gotWidth != "1024" {
+		t.Errorf("expected the 1024px thumbnail, got %q (asked for width %q)", got, gotWidth)
+	}
+}
@@ -0,0 +1,68 @@
+package publish
+
+import (
+	"crypto/ed25519"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"strings"
+)
+
+// NATS nkeys are ed25519 keys, base32-encoded with a prefix byte naming
+// their role and a CRC-16 checksum. A seed's two leading bytes carry both
+// the seed prefix and the role of its key pair.
+const (
+	nkeyPrefixSeed = 18 << 3 // "S"
+	nkeyPrefixUser = 20 << 3 // "U"
+)
+
+var nkeyEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// nkey is a NATS user key pair, for signing the nonce the server sends in
+// its INFO.
+type nkey struct {
+	public  string
+	private ed25519.PrivateKey
+}
+
+// parseNKeySeed reads a user seed, as written by nsc or nk ("SU...").
+func parseNKeySeed(seed string) (*nkey, error) {
+	raw, err := nkeyEncoding.DecodeString(strings.TrimSpace(seed))
+	if err != nil || len(raw) != 2+ed25519.SeedSize+2 {
+		return nil, errors.New("invalid nkey seed")
+	}
+	body := raw[:len(raw)-2]
+	if crc16(body) != binary.LittleEndian.Uint16(raw[len(raw)-2:]) {
+		return nil, errors.New("invalid nkey seed: bad checksum")
+	}
+	if body[0]&0xf8 != nkeyPrefixSeed || (body[0]&7)<<5|body[1]>>3 != nkeyPrefixUser {
+		return nil, errors.New("invalid nkey seed: not a user seed")
+	}
+
+	private := ed25519.NewKeyFromSeed(body[2:])
+	public := append([]byte{nkeyPrefixUser}, private.Public().(ed25519.PublicKey)...)
+	public = binary.LittleEndian.AppendUint16(public, crc16(public))
+	return &nkey{public: nkeyEncoding.EncodeToString(public), private: private}, nil
+}
+
+// sign signs the server's nonce, encoded as CONNECT's sig field wants it.
+func (k *nkey) sign(nonce string) string {
+	return base64.RawURLEncoding.EncodeToString(ed25519.Sign(k.private, []byte(nonce)))
+}
+
+// crc16 is the CRC-16/XMODEM checksum nkeys end with.
+func crc16(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for range 8 {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
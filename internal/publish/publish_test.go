@@ -0,0 +1,270 @@
+package publish
+
+import (
+	"bufio"
+	"context"
+	"crypto/ed25519"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+// natsServer configures fakeNATS.
+type natsServer struct {
+	reject   string // A payload the stream refuses with a JetStream error
+	noStream bool   // Answer every publish with the no-responders status
+	nonce    string
+	tls      *tls.Config
+}
+
+// fakeNATS accepts one connection and speaks enough of the protocol for a
+// JetStream publisher: it acks every publish on its reply subject and sends
+// the stored "subject msg-id payload" to msgs. The CONNECT options go to
+// connects.
+func fakeNATS(t *testing.T, srv natsServer) (addr string, msgs <-chan string, connects <-chan map[string]any) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	ch := make(chan string, 10)
+	connCh := make(chan map[string]any, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		info, _ := json.Marshal(map[string]any{"server_id": "fake", "headers": true, "nonce": srv.nonce, "tls_required": srv.tls != nil})
+		fmt.Fprintf(conn, "INFO %s\r\n", info)
+		if srv.tls != nil {
+			tlsConn := tls.Server(conn, srv.tls)
+			if tlsConn.Handshake() != nil {
+				return
+			}
+			conn = tlsConn
+		}
+		r := bufio.NewReader(conn)
+		seq, ponged := 0, false
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			fields := strings.Fields(line)
+			switch {
+			case strings.HasPrefix(line, "CONNECT "):
+				var opts map[string]any
+				json.Unmarshal([]byte(strings.TrimPrefix(line, "CONNECT ")), &opts)
+				connCh <- opts
+				// Ping, as a real server may, to check the client answers.
+				conn.Write([]byte("PING\r\n"))
+			case strings.HasPrefix(line, "PONG"):
+				ponged = true
+			case strings.HasPrefix(line, "PING"):
+				conn.Write([]byte("PONG\r\n"))
+			case strings.HasPrefix(line, "HPUB "):
+				headerSize, _ := strconv.Atoi(fields[3])
+				total, _ := strconv.Atoi(fields[4])
+				body := make([]byte, total+2)
+				io.ReadFull(r, body)
+				header, payload := string(body[:headerSize]), string(body[headerSize:total])
+				reply := fields[2]
+				if !ponged {
+					return
+				}
+
+				var ack string
+				switch {
+				case srv.noStream:
+					status := "NATS/1.0 503\r\n\r\n"
+					fmt.Fprintf(conn, "HMSG %s 1 %d %d\r\n%s\r\n", reply, len(status), len(status), status)
+					continue
+				case payload == srv.reject:
+					ack = `{"error":{"code":503,"description":"insufficient resources"}}`
+				default:
+					seq++
+					ack = fmt.Sprintf(`{"stream":"EVENTS","seq":%d}`, seq)
+					msgID := strings.TrimSpace(strings.SplitN(strings.Split(header, "Nats-Msg-Id:")[1], "\r\n", 2)[0])
+					ch <- fields[1] + " " + msgID + " " + payload
+				}
+				// A late ack of an earlier publish first: it must be ignored.
+				fmt.Fprintf(conn, "MSG %s.0 1 2\r\n{}\r\n", reply[:strings.LastIndex(reply, ".")])
+				fmt.Fprintf(conn, "MSG %s 1 %d\r\n%s\r\n", reply, len(ack), ack)
+			}
+		}
+	}()
+	return ln.Addr().String(), ch, connCh
+}
+
+func TestNATS_Publish(t *testing.T) {
+	event := model.Event{ID: 7, Type: model.EventCreated, Symbol: "AAPL"}
+	rejected, _ := json.Marshal(model.Event{ID: 8, Type: model.EventDeleted, Symbol: "MSFT"})
+	addr, msgs, connects := fakeNATS(t, natsServer{reject: string(rejected)})
+
+	pub, err := New("nats", "nats://user:secret@"+addr, Options{Subject: "logos.events", Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pub.Close()
+	if pub.Target() != "nats:logos.events" {
+		t.Errorf("unexpected target %q", pub.Target())
+	}
+
+	if err := pub.Publish(context.Background(), event); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	got := <-msgs
+	want, _ := json.Marshal(event)
+	if got != "logos.events 7 "+string(want) {
+		t.Errorf("unexpected message %q", got)
+	}
+	if opts := <-connects; opts["user"] != "user" || opts["pass"] != "secret" || opts["headers"] != true {
+		t.Errorf("unexpected CONNECT options %v", opts)
+	}
+
+	err = pub.Publish(context.Background(), model.Event{ID: 8, Type: model.EventDeleted, Symbol: "MSFT"})
+	if err == nil || !strings.Contains(err.Error(), "insufficient resources") {
+		t.Errorf("expected the stream's error, got %v", err)
+	}
+}
+
+func TestNATS_PublishWithoutStream(t *testing.T) {
+	addr, _, _ := fakeNATS(t, natsServer{noStream: true})
+	pub, err := NewNATS("nats://"+addr, Options{Subject: "logos.events", Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pub.Close()
+
+	// Nothing stored it: the relay must not move past the event.
+	if err := pub.Publish(context.Background(), model.Event{ID: 1}); !errors.Is(err, ErrNoStream) {
+		t.Errorf("expected ErrNoStream, got %v", err)
+	}
+}
+
+func TestNATS_TokenOverTLS(t *testing.T) {
+	// httptest's certificate is valid for 127.0.0.1, and its client trusts it.
+	https := httptest.NewTLSServer(http.NotFoundHandler())
+	defer https.Close()
+	addr, msgs, connects := fakeNATS(t, natsServer{tls: https.TLS})
+
+	pub, err := NewNATS("tls://s3cret@"+addr, Options{Subject: "logos.events", Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pub.Close()
+	pub.tlsConfig = https.Client().Transport.(*http.Transport).TLSClientConfig
+
+	if err := pub.Publish(context.Background(), model.Event{ID: 3}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	<-msgs
+	if opts := <-connects; opts["auth_token"] != "s3cret" || opts["user"] != nil {
+		t.Errorf("expected token auth, got %v", opts)
+	}
+}
+
+func TestNATS_NKey(t *testing.T) {
+	// A user key pair from the NATS documentation.
+	const seed = "SUACSSL3UAHUDXKFSNVUZRF5UHPMWZ6BFDTJ7M6USDXIEDNPPQYYYCU3VY"
+	const public = "UDXU4RCSJNZOIQHZNWXHXORDPRTGNJAHAHFRGZNEEJCPQTT2M7NLCNF4"
+	addr, msgs, connects := fakeNATS(t, natsServer{nonce: "PXoWU7zWAMt75FY"})
+
+	pub, err := NewNATS("nats://"+addr, Options{Subject: "logos.events", Timeout: time.Second, NKeySeed: seed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pub.Close()
+	if err := pub.Publish(context.Background(), model.Event{ID: 4}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	<-msgs
+
+	opts := <-connects
+	if opts["nkey"] != public {
+		t.Fatalf("expected public key %s, got %v", public, opts["nkey"])
+	}
+	raw, _ := nkeyEncoding.DecodeString(public)
+	sig, err := base64.RawURLEncoding.DecodeString(opts["sig"].(string))
+	if err != nil || !ed25519.Verify(raw[1:len(raw)-2], []byte("PXoWU7zWAMt75FY"), sig) {
+		t.Errorf("nonce signature doesn't verify: %v", err)
+	}
+}
+
+func TestNewNATS_Invalid(t *testing.T) {
+	for _, u := range []string{"http://localhost:4222", "nats://", "::"} {
+		if _, err := NewNATS(u, Options{Subject: "events", Timeout: time.Second}); err == nil {
+			t.Errorf("expected an error for %q", u)
+		}
+	}
+	for _, seed := range []string{"not-a-seed", "SUACSSM3UAHUDXKFSNVUZRF5UHPMWZ6BFDTJ7M6USDXIEDNPPQYYYCU3VY"} {
+		if _, err := NewNATS("nats://localhost", Options{Subject: "events", NKeySeed: seed}); err == nil {
+			t.Errorf("expected an error for seed %q", seed)
+		}
+	}
+}
+
+func TestKafka_Publish(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr bool
+	}{
+		{"acknowledged", http.StatusOK, `{"offsets":[{"partition":0,"offset":12}]}`, false},
+		{"proxy error", http.StatusNotFound, `{"error_code":40401,"message":"Topic not found"}`, true},
+		{"record refused", http.StatusOK, `{"offsets":[{"error_code":1,"error":"leader not available"}]}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var path, contentType string
+			var records struct {
+				Records []struct {
+					Key   string      `json:"key"`
+					Value model.Event `json:"value"`
+				} `json:"records"`
+			}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				path, contentType = r.URL.Path, r.Header.Get("Content-Type")
+				body, _ := io.ReadAll(r.Body)
+				json.Unmarshal(body, &records)
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			pub, err := New("kafka", server.URL+"/", Options{Subject: "logo-events", Timeout: time.Second})
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = pub.Publish(context.Background(), model.Event{ID: 3, Type: model.EventUpdated, Symbol: "NVDA"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Publish() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if path != "/topics/logo-events" || contentType != "application/vnd.kafka.json.v2+json" {
+				t.Errorf("unexpected request to %s (%s)", path, contentType)
+			}
+			if len(records.Records) != 1 || records.Records[0].Key != "NVDA" || records.Records[0].Value.ID != 3 {
+				t.Errorf("unexpected records %+v", records)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package publish
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/fleveque/logo-service/internal/clock"
+	"github.com/fleveque/logo-service/internal/model"
+	"github.com/fleveque/logo-service/internal/storage"
+)
+
+// batchSize is how many events the relay reads from the feed at a time.
+const batchSize = 100
+
+// RelayOptions tune a Relay. Zero values get the defaults noted.
+type RelayOptions struct {
+	PollInterval time.Duration // How often to look for new events (default 5s)
+	MaxAttempts  int           // Tries per event before it's dead-lettered (default 5)
+	BaseBackoff  time.Duration // Wait after the first failure, doubled after each (default 1s)
+}
+
+// Relay follows the changes feed and publishes each event, in order.
+type Relay struct {
+	events     storage.EventRepository
+	deliveries storage.DeliveryRepository
+	publisher  Publisher
+	opts       RelayOptions
+	clock      clock.Clock
+	random     clock.Random
+	logger     *zap.Logger
+}
+
+// NewRelay creates a relay from events to publisher. The cursor and dead
+// letters are kept in deliveries under the publisher's Target.
+func NewRelay(events storage.EventRepository, deliveries storage.DeliveryRepository, publisher Publisher, opts RelayOptions, logger *zap.Logger) *Relay {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 5 * time.Second
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 5
+	}
+	if opts.BaseBackoff <= 0 {
+		opts.BaseBackoff = time.Second
+	}
+	return &Relay{
+		events:     events,
+		deliveries: deliveries,
+		publisher:  publisher,
+		opts:       opts,
+		clock:      clock.System,
+		random:     clock.Global,
+		logger:     logger,
+	}
+}
+
+// SetClock replaces the system clock and random source, so tests can run
+// the backoff without waiting. Call it before Run.
+func (r *Relay) SetClock(c clock.Clock, random clock.Random) {
+	r.clock, r.random = c, random
+}
+
+// Run relays events until ctx is cancelled.
+func (r *Relay) Run(ctx context.Context) {
+	for {
+		if err := r.Drain(ctx); err != nil && ctx.Err() == nil {
+			r.logger.Error("relaying events", zap.String("target", r.publisher.Target()), zap.Error(err))
+		}
+		if r.clock.Sleep(ctx, r.opts.PollInterval) != nil {
+			return
+		}
+	}
+}
+
+// Drain publishes every event after the cursor, then returns. Errors are
+// from the database; broker failures end in the dead-letter log instead.
+func (r *Relay) Drain(ctx context.Context) error {
+	target := r.publisher.Target()
+	cursor, err := r.deliveries.Cursor(ctx, target)
+	if err != nil {
+		return err
+	}
+
+	for {
+		events, err := r.events.ListSince(ctx, cursor, batchSize)
+		if err != nil {
+			return err
+		}
+		for _, event := range events {
+			if err := r.deliver(ctx, event); err != nil {
+				return err // Cancelled: the cursor stays before this event
+			}
+			// Saved after every event: at worst one event is sent twice.
+			if err := r.deliveries.SetCursor(ctx, target, event.ID); err != nil {
+				return err
+			}
+			cursor = event.ID
+		}
+		if len(events) < batchSize {
+			return nil
+		}
+	}
+}
+
+// deliver publishes one event, retrying with exponential backoff. After
+// MaxAttempts it records a dead letter and returns nil so the relay moves
+// on; it only returns an error when ctx is cancelled.
+func (r *Relay) deliver(ctx context.Context, event model.Event) error {
+	backoff := r.opts.BaseBackoff
+	var err error
+	for attempt := 1; attempt <= r.opts.MaxAttempts; attempt++ {
+		if err = r.publisher.Publish(ctx, event); err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		r.logger.Warn("publishing event failed",
+			zap.String("target", r.publisher.Target()),
+			zap.Int64("event_id", event.ID),
+			zap.Int("attempt", attempt),
+			zap.Error(err),
+		)
+		if attempt < r.opts.MaxAttempts {
+			if sleepErr := r.clock.Sleep(ctx, clock.Jitter(backoff, 0.2, r.random)); sleepErr != nil {
+				return sleepErr
+			}
+			backoff *= 2
+		}
+	}
+
+	letter := &model.DeadLetter{
+		Target:  r.publisher.Target(),
+		EventID: event.ID,
+		Type:    event.Type,
+		Symbol:  event.Symbol,
+		Error:   err.Error(),
+	}
+	r.logger.Error("event dead-lettered",
+		zap.String("target", letter.Target),
+		zap.Int64("event_id", event.ID),
+		zap.String("symbol", event.Symbol),
+		zap.Error(err),
+	)
+	if recordErr := r.deliveries.RecordDeadLetter(ctx, letter); recordErr != nil {
+		// Not fatal either: the log line above still has it.
+		r.logger.Error("recording dead letter", zap.Error(recordErr))
+	}
+	return nil
+}
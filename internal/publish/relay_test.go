@@ -0,0 +1,113 @@
+package publish
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/fleveque/logo-service/internal/clock"
+	"github.com/fleveque/logo-service/internal/model"
+	"github.com/fleveque/logo-service/internal/storage"
+	"github.com/fleveque/logo-service/internal/testutil"
+)
+
+// fakePublisher records what it published and fails for symbols in failFor.
+type fakePublisher struct {
+	published []string
+	attempts  int
+	failFor   map[string]bool
+}
+
+func (p *fakePublisher) Publish(_ context.Context, e model.Event) error {
+	p.attempts++
+	if p.failFor[e.Symbol] {
+		return errors.New("broker unavailable")
+	}
+	p.published = append(p.published, e.Symbol)
+	return nil
+}
+
+func (p *fakePublisher) Target() string { return "fake:events" }
+func (p *fakePublisher) Close() error   { return nil }
+
+func TestRelay_Drain(t *testing.T) {
+	db := testutil.NewDB(t)
+	events := storage.NewEventRepository(db)
+	deliveries := storage.NewDeliveryRepository(db)
+	ctx := context.Background()
+
+	for _, symbol := range []string{"AAPL", "BAD", "MSFT"} {
+		if err := events.Record(ctx, &model.Event{Type: model.EventCreated, Symbol: symbol}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pub := &fakePublisher{failFor: map[string]bool{"BAD": true}}
+	relay := NewRelay(events, deliveries, pub, RelayOptions{MaxAttempts: 3, BaseBackoff: time.Second}, zap.NewNop())
+	fake := clock.NewFake(time.Now())
+	relay.SetClock(fake, clock.NewRandom(1))
+
+	if err := relay.Drain(ctx); err != nil {
+		t.Fatalf("Drain: %v", err)
+	}
+
+	// The failing event doesn't hold up the ones behind it.
+	if len(pub.published) != 2 || pub.published[0] != "AAPL" || pub.published[1] != "MSFT" {
+		t.Errorf("expected AAPL and MSFT published in order, got %v", pub.published)
+	}
+	if pub.attempts != 5 {
+		t.Errorf("expected 3 attempts for BAD plus 2 successes, got %d", pub.attempts)
+	}
+
+	// Two backoffs between three attempts, roughly doubling.
+	slept := fake.Slept()
+	if len(slept) != 2 || slept[0] < 800*time.Millisecond || slept[1] < 1600*time.Millisecond {
+		t.Errorf("expected two growing backoffs, got %v", slept)
+	}
+
+	letters, _ := deliveries.ListDeadLetters(ctx, 10)
+	if len(letters) != 1 || letters[0].Symbol != "BAD" || letters[0].Target != "fake:events" || letters[0].Error != "broker unavailable" {
+		t.Errorf("expected one dead letter for BAD, got %+v", letters)
+	}
+
+	// The cursor is past everything, so a second drain publishes nothing.
+	pub.published = nil
+	if err := relay.Drain(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(pub.published) != 0 {
+		t.Errorf("expected nothing republished, got %v", pub.published)
+	}
+}
+
+func TestRelay_ResumesFromCursor(t *testing.T) {
+	db := testutil.NewDB(t)
+	events := storage.NewEventRepository(db)
+	deliveries := storage.NewDeliveryRepository(db)
+	ctx := context.Background()
+
+	var first model.Event
+	for i, symbol := range []string{"AAPL", "MSFT"} {
+		e := model.Event{Type: model.EventCreated, Symbol: symbol}
+		if err := events.Record(ctx, &e); err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			first = e
+		}
+	}
+	if err := deliveries.SetCursor(ctx, "fake:events", first.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	pub := &fakePublisher{}
+	if err := NewRelay(events, deliveries, pub, RelayOptions{}, zap.NewNop()).Drain(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(pub.published) != 1 || pub.published[0] != "MSFT" {
+		t.Errorf("expected only MSFT, got %v", pub.published)
+	}
+}
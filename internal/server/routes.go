@@ -2,42 +2,337 @@
 package server
 
 import (
+	"net/http"
+	"strconv"
+	"strings"
+
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 
 	"github.com/fleveque/logo-service/internal/config"
+	"github.com/fleveque/logo-service/internal/flags"
 	"github.com/fleveque/logo-service/internal/handler"
 	"github.com/fleveque/logo-service/internal/middleware"
+	"github.com/fleveque/logo-service/internal/provider"
+	"github.com/fleveque/logo-service/internal/service"
 )
 
-// RegisterRoutes sets up all HTTP routes on the Gin engine.
+// RegisterRoutes sets up all HTTP routes. Admin routes and /metrics go on
+// admin, which is r itself unless server.admin_port gives them a listener
+// of their own.
 // In Go, we pass dependencies explicitly — no DI container, no magic.
 // Each handler gets exactly the dependencies it needs.
-func RegisterRoutes(r *gin.Engine, cfg *config.Config, deps Deps, logger *zap.Logger) {
-	healthHandler := handler.NewHealthHandler()
+func RegisterRoutes(r, admin *gin.Engine, cfg *config.Config, deps Deps, logger *zap.Logger) {
+	flagSet := deps.Flags
+	if flagSet == nil {
+		flagSet, _ = flags.New(nil, nil)
+	}
+	inMaintenance := func() bool { return flagSet.Enabled(flags.MaintenanceMode) }
+	maintenance := handler.MaintenanceResponse{
+		Message:    cfg.Server.Maintenance.Message,
+		StatusURL:  cfg.Server.Maintenance.StatusURL,
+		RetryAfter: cfg.Server.Maintenance.RetryAfter,
+	}
+
+	healthHandler := handler.NewHealthHandler(deps.DiskMonitor)
+	healthHandler.SetMaintenance(inMaintenance, maintenance)
 	logoHandler := handler.NewLogoHandler(deps.LogoService, logger)
-	adminHandler := handler.NewAdminHandler(deps.LogoRepo, deps.LLMCallRepo, deps.GitHubProvider, deps.LogoService, logger)
+	logoHandler.SetPendingResponse(handler.PendingResponse{
+		Placeholder: cfg.Server.PendingResponse == "placeholder",
+		RetryAfter:  cfg.Server.PendingRetryAfter,
+	})
+	logoHandler.SetMaintenanceResponse(maintenance)
+	logoHandler.SetFormatPolicy(handler.FormatPolicy{
+		Default:   cfg.Serve.DefaultFormat,
+		Negotiate: cfg.Serve.AutoNegotiate,
+	})
+	logoHandler.SetPopularity(deps.Popularity)
+	adminHandler := handler.NewAdminHandler(deps.LogoRepo, deps.LLMCallRepo, deps.RequestedRepo, deps.UsageRepo, deps.GitHubProvider, deps.InstImporter, deps.IndexProvider, deps.LogoService, logger)
+	// Uploads are held to the download limit: both end up in the processor.
+	if size := cfg.Processing.MaxFetchSize; size > 0 {
+		logoHandler.SetMaxImageBytes(int(size))
+		adminHandler.SetMaxImageBytes(int(size))
+	}
+
+	// One limiter shared by the middleware and the admin endpoints that inspect it.
+	limiter := deps.RateLimiter
+	if limiter == nil {
+		limiter = NewRateLimiter(cfg.RateLimit)
+	}
+	limiters := routeLimiters{
+		api:     limiter,
+		misses:  newRouteLimiter(cfg.RateLimit.Misses),
+		imports: newRouteLimiter(cfg.RateLimit.Imports),
+		scans:   deps.ScanGuard,
+	}
+	rateLimitHandler := handler.NewRateLimitHandler(limiter, logger)
+	deliveryHandler := handler.NewDeliveryHandler(deps.Deliveries, logger)
+	flagHandler := handler.NewFlagHandler(flagSet, logger)
+	llmClientHandler := handler.NewLLMClientHandler(deps.LLMProvider, logger)
+	popularityHandler := handler.NewPopularityHandler(deps.PopularityRepo, logger)
+
+	// Everything lives under server.base_path, health checks included: a
+	// gateway that routes by prefix only forwards what's under it.
+	root := r.Group(cfg.Server.BasePath)
+	adminRoot := root
+	if admin != r {
+		adminRoot = admin.Group(cfg.Server.BasePath)
+		// Probes of the admin port see the same health as the public one.
+		adminRoot.GET("/healthz", healthHandler.Healthz)
+		adminRoot.GET("/readyz", healthHandler.Readyz)
+		adminRoot.GET("/version", healthHandler.Version)
+	}
 
 	// Public endpoints (no auth)
-	r.GET("/healthz", healthHandler.Healthz)
+	root.GET("/healthz", healthHandler.Healthz)
+	root.GET("/readyz", healthHandler.Readyz)
+	root.GET("/version", healthHandler.Version)
+	adminRoot.GET("/metrics", healthHandler.Metrics)
+
+	handlers := apiHandlers{
+		logo:       logoHandler,
+		admin:      adminHandler,
+		rateLimit:  rateLimitHandler,
+		delivery:   deliveryHandler,
+		flags:      flagHandler,
+		llmClients: llmClientHandler,
+		popularity: popularityHandler,
+	}
+	cors := middleware.CORS(corsOptions(cfg.CORS, cfg.Server.BasePath))
 
-	// CORS middleware applies to the entire API group.
-	api := r.Group("/api/v1")
-	api.Use(middleware.CORS(cfg.CORS.AllowedOrigins))
+	// /api/v1 and /api/v2 serve the same handlers; handlers that answer
+	// differently per version check middleware.APIVersion. Either version
+	// can also be asked for through Accept (see middleware.Version).
+	for _, version := range apiVersions {
+		api := versionGroup(root, version, cfg, cors)
+		registerPublicAPI(api, cfg, limiters, handlers)
+		if adminRoot != root {
+			api = versionGroup(adminRoot, version, cfg, cors)
+		}
+		registerAdminAPI(api, cfg, limiters, handlers, middleware.MaintenanceOptions{
+			Enabled:    inMaintenance,
+			Message:    maintenance.Message,
+			StatusURL:  maintenance.StatusURL,
+			RetryAfter: maintenance.RetryAfter,
+		})
+	}
+}
+
+// NewRateLimiter builds the per-key limiter for rate_limit, with its tiers.
+func NewRateLimiter(cfg config.RateLimitConfig) *middleware.RateLimiter {
+	limiter := middleware.NewRateLimiter(cfg.RequestsPerSecond, cfg.Burst)
+	for _, t := range cfg.Tiers {
+		limiter.SetTier(middleware.Tier{
+			Name:            t.Name,
+			RPS:             t.RequestsPerSecond,
+			Burst:           t.Burst,
+			Credits:         t.Credits,
+			MonthlyTransfer: int64(t.MonthlyTransfer),
+		}, t.Keys...)
+	}
+	return limiter
+}
+
+// routeLimiters are the per-key limiters of the route groups: api for every
+// authenticated request, misses and imports (nil when unlimited) for the
+// costly ones on top of it, and scans (nil when off) for the misses of
+// callers scanning for symbols.
+type routeLimiters struct {
+	api     *middleware.RateLimiter
+	misses  *middleware.RateLimiter
+	imports *middleware.RateLimiter
+	scans   *service.ScanGuard
+}
+
+// newRouteLimiter builds the limiter of a route group, or nil if it has none.
+func newRouteLimiter(cfg config.RouteLimitConfig) *middleware.RateLimiter {
+	if cfg.RequestsPerSecond <= 0 {
+		return nil
+	}
+	return middleware.NewRateLimiter(cfg.RequestsPerSecond, cfg.Burst)
+}
 
+// limitMisses makes the requests of a group that miss the cache take a
+// token from misses before a logo is acquired for them. Whether a request
+// misses is only known deep in the service, so the limit rides along in the
+// request context (see service.WithAcquireGate) rather than deciding here.
+func limitMisses(misses *middleware.RateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := service.WithAcquireGate(c.Request.Context(), func() bool { return misses.Allow(c) })
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// attributeLLMCalls records the LLM searches a request's cache misses cause
+// against its API key (see provider.WithRequester), so they show in the
+// key's usage.
+func attributeLLMCalls(c *gin.Context) {
+	if key, ok := c.Get("api_key"); ok {
+		ctx := provider.WithRequester(c.Request.Context(), middleware.KeyID(key.(string)))
+		c.Request = c.Request.WithContext(ctx)
+	}
+	c.Next()
+}
+
+// guardScans binds the request's misses to guard (see
+// service.WithScanGuard), by its API key and, with byIP, its client IP.
+func guardScans(guard *service.ScanGuard, byIP bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var callers []string
+		if key, ok := c.Get("api_key"); ok {
+			callers = append(callers, "key:"+middleware.KeyID(key.(string)))
+		}
+		if byIP {
+			callers = append(callers, "ip:"+c.ClientIP())
+		}
+		ctx := service.WithScanGuard(c.Request.Context(), guard, callers...)
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// limitRoute is limiter.Middleware for a route group's own limit: the same
+// 429, without counting the requests again in the API usage stats.
+func limitRoute(limiter *middleware.RateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !limiter.Allow(c) {
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// versionGroup creates the /api/v{version} group under root, with the
+// middleware every route of that version shares.
+func versionGroup(root *gin.RouterGroup, version int, cfg *config.Config, cors gin.HandlerFunc) *gin.RouterGroup {
+	prefix := "/api/v" + strconv.Itoa(version)
+	api := root.Group(prefix)
+	api.Use(cors, middleware.Version(version, apiVersions))
+	if deprecated, sunset, _ := cfg.API.V1Retirement(); version == 1 && !deprecated.IsZero() {
+		api.Use(middleware.Deprecation(1, middleware.DeprecationOptions{
+			Since:     deprecated,
+			Sunset:    sunset,
+			Prefix:    cfg.Server.BasePath + prefix,
+			Successor: cfg.Server.BasePath + "/api/v2",
+		}))
+	}
+	return api
+}
+
+// apiVersions are the API versions served.
+var apiVersions = []int{1, 2}
+
+// apiHandlers are the handlers behind the versioned API.
+type apiHandlers struct {
+	logo       *handler.LogoHandler
+	admin      *handler.AdminHandler
+	rateLimit  *handler.RateLimitHandler
+	delivery   *handler.DeliveryHandler
+	flags      *handler.FlagHandler
+	llmClients *handler.LLMClientHandler
+	popularity *handler.PopularityHandler
+}
+
+// registerPublicAPI registers the endpoints for API keys on one version's group.
+func registerPublicAPI(api *gin.RouterGroup, cfg *config.Config, limiters routeLimiters, h apiHandlers) {
 	// Authenticated API endpoints
 	authed := api.Group("")
 	authed.Use(middleware.APIKeyAuth(cfg.Auth.APIKeys))
-	authed.Use(middleware.RateLimit(cfg.RateLimit.RequestsPerSecond, cfg.RateLimit.Burst))
+	authed.Use(limiters.api.Middleware(), attributeLLMCalls)
+	if limiters.scans != nil {
+		authed.Use(guardScans(limiters.scans, cfg.RateLimit.Scanning.ByIP))
+	}
 	{
-		authed.GET("/logos/:symbol", logoHandler.GetLogo)
+		// Go note: gin matches the static "archive" and "sprite" segments before :symbol.
+		authed.GET("/logos/archive", h.logo.GetArchive)
+		authed.GET("/logos/sprite", h.logo.GetSprite)
+		if limiters.misses != nil {
+			authed.GET("/logos/:symbol", limitMisses(limiters.misses), h.logo.GetLogo)
+		} else {
+			authed.GET("/logos/:symbol", h.logo.GetLogo)
+		}
+		authed.GET("/logos/:symbol/metadata", h.logo.GetMetadata)
+		authed.GET("/changes", h.logo.GetChanges)
+		authed.POST("/transform", h.logo.Transform)
 	}
+}
 
+// registerAdminAPI registers the endpoints for admin keys on one version's
+// group. During maintenance, their changes are refused, but for the flags
+// (one of them turns maintenance off).
+func registerAdminAPI(api *gin.RouterGroup, cfg *config.Config, limiters routeLimiters, h apiHandlers, maintenance middleware.MaintenanceOptions) {
 	// Admin endpoints (separate auth with admin keys)
 	admin := api.Group("/admin")
-	admin.Use(middleware.AdminKeyAuth(cfg.Auth.AdminKeys))
+	keyAuth := middleware.AdminKeyAuth(cfg.Auth.AdminKeys)
+	if cfg.Server.AdminTLS.ClientCAFile != "" {
+		admin.Use(middleware.ClientCertAuth(cfg.Server.AdminTLS.ClientNames, keyAuth))
+	} else {
+		admin.Use(keyAuth)
+	}
+	maintenance.Exempt = []string{admin.BasePath() + "/flags/:name"}
+	admin.Use(middleware.Maintenance(maintenance))
 	{
-		admin.GET("/stats", adminHandler.Stats)
-		admin.POST("/import", adminHandler.Import)
+		admin.GET("/stats", h.admin.Stats)
+		admin.GET("/stats/history", h.admin.StatsHistory)
+		if limiters.imports != nil {
+			admin.POST("/import", limitRoute(limiters.imports), h.admin.Import)
+		} else {
+			admin.POST("/import", h.admin.Import)
+		}
+		admin.GET("/missing", h.admin.Missing)
+		admin.GET("/popular", h.popularity.List)
+		admin.GET("/not-found", h.admin.ListNotFound)
+		admin.POST("/not-found/:symbol/requeue", h.admin.Requeue)
+		admin.GET("/review", h.admin.ListReview)
+		admin.POST("/review/:symbol", h.admin.SendToReview)
+		admin.GET("/quality", h.admin.ListByQuality)
+		admin.GET("/duplicates", h.admin.Duplicates)
+		admin.POST("/review/:symbol/approve", h.admin.ApproveReview)
+		admin.POST("/review/:symbol/reject", h.admin.RejectReview)
+		admin.GET("/blocklist", h.admin.ListBlocked)
+		admin.PUT("/blocklist/:symbol", h.admin.Block)
+		admin.DELETE("/blocklist/:symbol", h.admin.Unblock)
+		admin.POST("/takedown/:symbol", h.admin.Takedown)
+		admin.GET("/audit", h.admin.Audit)
+		admin.GET("/llm-calls", h.admin.ListLLMCalls)
+		admin.GET("/logos", h.admin.ListLogos)
+		admin.PATCH("/logos", h.admin.UpdateMetadata)
+		admin.DELETE("/logos/:symbol", h.admin.DeleteLogo)
+		admin.POST("/logos/:symbol/restore", h.admin.RestoreLogo)
+		admin.PUT("/logos/:symbol/processing", h.admin.SetProcessing)
+		admin.PUT("/logos/:symbol/background", h.admin.SetBackground)
+		admin.POST("/logos/:symbol/reprocess", h.admin.Reprocess)
+		admin.GET("/logos/:symbol/originals", h.admin.Originals)
+		admin.POST("/logos/:symbol/compare", h.admin.Compare)
+		admin.GET("/logos/:symbol/trace", h.admin.Trace)
+		admin.POST("/maintenance/fix-status", h.admin.FixStatus)
+		admin.GET("/ratelimits/:key", h.rateLimit.Get)
+		admin.DELETE("/ratelimits/:key", h.rateLimit.Reset)
+		admin.GET("/dead-letters", h.delivery.DeadLetters)
+		admin.GET("/flags", h.flags.List)
+		admin.PUT("/flags/:name", h.flags.Override)
+		admin.DELETE("/flags/:name", h.flags.ClearOverride)
+		admin.GET("/llm-clients", h.llmClients.List)
+		admin.PUT("/llm-clients/:name", h.llmClients.Set)
+	}
+}
+
+// corsOptions converts the CORS config into middleware options. Route
+// prefixes in the config are relative to server.base_path.
+func corsOptions(cfg config.CORSConfig, basePath string) middleware.CORSOptions {
+	opts := middleware.CORSOptions{
+		AllowedOrigins:   cfg.AllowedOrigins,
+		AllowCredentials: cfg.AllowCredentials,
+		Methods:          cfg.Methods,
+	}
+	for _, r := range cfg.Routes {
+		opts.Routes = append(opts.Routes, middleware.CORSRoute{PathPrefix: basePath + r.PathPrefix, Methods: r.Methods})
+		// A rule for a v1 path holds for the same path in v2.
+		if rest, ok := strings.CutPrefix(r.PathPrefix, "/api/v1"); ok {
+			opts.Routes = append(opts.Routes, middleware.CORSRoute{PathPrefix: basePath + "/api/v2" + rest, Methods: r.Methods})
+		}
 	}
+	return opts
 }
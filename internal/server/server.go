@@ -2,14 +2,21 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 
 	"github.com/fleveque/logo-service/internal/config"
+	"github.com/fleveque/logo-service/internal/flags"
+	"github.com/fleveque/logo-service/internal/handler"
+	"github.com/fleveque/logo-service/internal/middleware"
 	"github.com/fleveque/logo-service/internal/provider"
 	"github.com/fleveque/logo-service/internal/service"
 	"github.com/fleveque/logo-service/internal/storage"
@@ -21,11 +28,22 @@ import (
 type Deps struct {
 	LogoRepo       storage.LogoRepository
 	LLMCallRepo    storage.LLMCallRepository
+	RequestedRepo  storage.RequestedSymbolRepository
+	UsageRepo      storage.UsageRepository // nil: stats without API usage
+	Deliveries     storage.DeliveryRepository
 	FileSystem     *storage.FileSystem
+	DiskMonitor    *storage.DiskMonitor // nil: no disk space reporting
 	GitHubProvider *provider.GitHubProvider
-	LLMProvider    *provider.LLMProvider // nil if no LLM keys configured
+	InstImporter   *provider.InstrumentImporter // nil if instruments are misconfigured
+	IndexProvider  *provider.IndexProvider      // nil if no index is configured
+	LLMProvider    *provider.LLMProvider        // nil if no LLM keys configured
 	ImageProcessor *service.ImageProcessor
 	LogoService    *service.LogoService
+	RateLimiter    *middleware.RateLimiter // nil: one is built from rate_limit
+	Flags          *flags.Set              // nil: flags at their defaults, not overridable
+	Popularity     *service.Popularity     // nil: logo requests aren't counted
+	PopularityRepo storage.PopularityRepository
+	ScanGuard      *service.ScanGuard // nil: symbol scanning isn't throttled
 }
 
 // Server wraps the HTTP server and its dependencies.
@@ -37,10 +55,16 @@ type Server struct {
 	router *gin.Engine
 	logger *zap.Logger
 	http   *http.Server
+
+	adminHTTP *http.Server // nil: admin routes are served by http
 }
 
 // New creates and configures a new Server.
-func New(cfg *config.Config, logger *zap.Logger, deps Deps) *Server {
+func New(cfg *config.Config, logger *zap.Logger, deps Deps) (*Server, error) {
+	if err := handler.RegisterValidators(); err != nil {
+		return nil, err
+	}
+
 	// Set Gin mode based on log level
 	if cfg.Log.Level == "debug" {
 		gin.SetMode(gin.DebugMode)
@@ -53,40 +77,115 @@ func New(cfg *config.Config, logger *zap.Logger, deps Deps) *Server {
 	// Recovery middleware catches panics and returns 500 instead of crashing.
 	router.Use(gin.Recovery())
 
+	// With server.admin_port, admin routes get an engine and listener of
+	// their own, which the public load balancer never routes to.
+	adminRouter := router
+	if cfg.Server.AdminPort != 0 {
+		adminRouter = gin.New()
+		adminRouter.Use(gin.Recovery())
+	}
+
 	// Register routes with config, deps, and logger
-	RegisterRoutes(router, cfg, deps, logger)
+	RegisterRoutes(router, adminRouter, cfg, deps, logger)
 
 	s := &Server{
 		cfg:    cfg,
 		deps:   deps,
 		router: router,
 		logger: logger,
-		http: &http.Server{
-			Addr:         cfg.Server.Address(),
-			Handler:      router,
-			ReadTimeout:  10 * time.Second,
-			WriteTimeout: 30 * time.Second,
-			IdleTimeout:  60 * time.Second,
-		},
+		http:   newHTTPServer(cfg.Server.Address(), router),
+	}
+	if adminRouter != router {
+		s.adminHTTP = newHTTPServer(cfg.Server.AdminAddress(), adminRouter)
 	}
 
-	return s
+	return s, nil
 }
 
-// Start begins listening for HTTP requests. This blocks until the server stops.
+// newHTTPServer creates an http.Server with the service's timeouts.
+func newHTTPServer(addr string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:         addr,
+		Handler:      handler,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 30 * time.Second,
+		IdleTimeout:  60 * time.Second,
+	}
+}
+
+// Start begins listening for HTTP requests, on the admin port too if there
+// is one. This blocks until the server stops or either listener fails.
 func (s *Server) Start() error {
-	s.logger.Info("starting server", zap.String("address", s.cfg.Server.Address()))
-	if err := s.http.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		return fmt.Errorf("server listen: %w", err)
+	// Go note: the channel is buffered for both listeners, so the one still
+	// running when Start returns can send its result without blocking forever.
+	errs := make(chan error, 2)
+	s.logger.Info("starting server", zap.String("address", s.http.Addr))
+	go func() { errs <- listen(s.http, "server", "", "") }()
+	if s.adminHTTP != nil {
+		adminTLS := s.cfg.Server.AdminTLS
+		if adminTLS.CertFile != "" {
+			tlsConfig, err := clientCertConfig(adminTLS.ClientCAFile)
+			if err != nil {
+				return err
+			}
+			s.adminHTTP.TLSConfig = tlsConfig
+		}
+		s.logger.Info("starting admin server",
+			zap.String("address", s.adminHTTP.Addr),
+			zap.Bool("tls", adminTLS.CertFile != ""),
+			zap.Bool("client_certs", adminTLS.ClientCAFile != ""),
+		)
+		go func() { errs <- listen(s.adminHTTP, "admin server", adminTLS.CertFile, adminTLS.KeyFile) }()
+	}
+	return <-errs
+}
+
+// listen serves srv until it's shut down, over TLS if certFile is set.
+func listen(srv *http.Server, name, certFile, keyFile string) error {
+	var err error
+	if certFile != "" {
+		err = srv.ListenAndServeTLS(certFile, keyFile)
+	} else {
+		err = srv.ListenAndServe()
+	}
+	if err != nil && err != http.ErrServerClosed {
+		return fmt.Errorf("%s listen: %w", name, err)
 	}
 	return nil
 }
 
+// clientCertConfig returns the TLS settings of the admin listener. With a
+// CA bundle, clients are asked for a certificate and any they present must
+// be signed by one of its CAs; clients without one can still use a key
+// (see middleware.ClientCertAuth).
+func clientCertConfig(caFile string) (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile == "" {
+		return config, nil
+	}
+
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading admin client CA bundle: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates in admin client CA bundle %s", caFile)
+	}
+	config.ClientCAs = pool
+	config.ClientAuth = tls.VerifyClientCertIfGiven
+	return config, nil
+}
+
 // Shutdown gracefully stops the server, waiting for in-flight requests to complete.
 // context.Context is Go's way of handling cancellation and timeouts — you'll see it everywhere.
 func (s *Server) Shutdown(ctx context.Context) error {
 	s.logger.Info("shutting down server")
-	return s.http.Shutdown(ctx)
+	err := s.http.Shutdown(ctx)
+	if s.adminHTTP != nil {
+		err = errors.Join(err, s.adminHTTP.Shutdown(ctx))
+	}
+	return err
 }
 
 // Router returns the underlying Gin engine (useful for testing).
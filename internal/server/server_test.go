@@ -0,0 +1,172 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/fleveque/logo-service/internal/config"
+	"github.com/fleveque/logo-service/internal/provider"
+	"github.com/fleveque/logo-service/internal/service"
+	"github.com/fleveque/logo-service/internal/storage"
+	"github.com/fleveque/logo-service/internal/testutil"
+)
+
+// newTestServer builds a Server from a YAML config, with AAPL's logo cached.
+func newTestServer(t *testing.T, yaml string) *Server {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(yaml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	db := testutil.NewDB(t)
+	fs := testutil.NewFileSystem(t)
+	logoRepo := storage.NewLogoRepository(db)
+	requestedRepo := storage.NewRequestedSymbolRepository(db)
+	logoService := service.NewLogoService(logoRepo, requestedRepo, storage.NewInstrumentRepository(db),
+		fs, &testutil.FakeProcessor{FS: fs}, testutil.NewFakeProvider())
+	result := &provider.LogoResult{Symbol: "AAPL", ImageData: []byte("apple png"), Source: "github:test"}
+	if err := logoService.ProcessAndStore(context.Background(), result); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := New(cfg, zap.NewNop(), Deps{
+		LogoRepo:      logoRepo,
+		LLMCallRepo:   storage.NewLLMCallRepository(db),
+		RequestedRepo: requestedRepo,
+		FileSystem:    fs,
+		LogoService:   logoService,
+	})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return s
+}
+
+// get runs a GET against handler, with the API or admin key of the test
+// config.
+func get(handler http.Handler, target string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	req.Header.Set("X-API-Key", "test-key")
+	if strings.Contains(target, "/admin/") {
+		req.Header.Set("X-API-Key", "admin-key")
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestBasePath(t *testing.T) {
+	s := newTestServer(t, `
+server:
+  base_path: /logo-service
+auth:
+  api_keys: [test-key]
+  admin_keys: [admin-key]
+api:
+  v1_deprecated_at: "2025-01-01"
+`)
+	router := s.Router()
+
+	// Under the prefix, every route answers; without it, none does.
+	for _, path := range []string{
+		"/healthz",
+		"/version",
+		"/metrics",
+		"/api/v1/logos/AAPL",
+		"/api/v2/logos/AAPL",
+		"/api/v2/logos/AAPL/metadata",
+		"/api/v2/admin/stats",
+	} {
+		if w := get(router, "/logo-service"+path); w.Code != http.StatusOK {
+			t.Errorf("GET /logo-service%s: expected 200, got %d: %s", path, w.Code, w.Body)
+		}
+		if w := get(router, path); w.Code != http.StatusNotFound {
+			t.Errorf("GET %s: expected 404 without the base path, got %d", path, w.Code)
+		}
+	}
+	if w := get(router, "/logo-service/api/v2/logos/AAPL"); w.Body.String() != "apple png" {
+		t.Errorf("expected AAPL's logo, got %q", w.Body)
+	}
+
+	// URLs the service hands out carry the prefix.
+	w := get(router, "/logo-service/api/v1/logos/AAPL")
+	if got, want := w.Header().Get("Link"), `</logo-service/api/v2/logos/AAPL>; rel="successor-version"`; got != want {
+		t.Errorf("expected Link %s, got %s", want, got)
+	}
+	w = get(router, "/logo-service/api/v2/logos/sprite?symbols=AAPL&format=css")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `url("/logo-service/api/v2/logos/sprite?`) {
+		t.Errorf("expected the sprite CSS to point under the base path, got %d: %s", w.Code, w.Body)
+	}
+}
+
+func TestAdminListener(t *testing.T) {
+	s := newTestServer(t, `
+server:
+  port: 8080
+  admin_port: 9091
+  admin_host: 127.0.0.1
+auth:
+  api_keys: [test-key]
+  admin_keys: [admin-key]
+`)
+	if s.adminHTTP == nil {
+		t.Fatal("expected an admin listener")
+	}
+	if s.http.Addr != "0.0.0.0:8080" || s.adminHTTP.Addr != "127.0.0.1:9091" {
+		t.Errorf("expected 0.0.0.0:8080 and 127.0.0.1:9091, got %s and %s", s.http.Addr, s.adminHTTP.Addr)
+	}
+	public, admin := s.http.Handler, s.adminHTTP.Handler
+	if public != s.Router() {
+		t.Error("expected the public listener to serve Router()")
+	}
+
+	tests := []struct {
+		path          string
+		public, admin int
+	}{
+		{"/api/v1/admin/stats", http.StatusNotFound, http.StatusOK},
+		{"/api/v2/admin/stats", http.StatusNotFound, http.StatusOK},
+		{"/api/v2/admin/flags", http.StatusNotFound, http.StatusOK},
+		{"/metrics", http.StatusNotFound, http.StatusOK},
+		{"/api/v2/logos/AAPL", http.StatusOK, http.StatusNotFound},
+		{"/healthz", http.StatusOK, http.StatusOK},
+		{"/readyz", http.StatusOK, http.StatusOK},
+	}
+	for _, tt := range tests {
+		if w := get(public, tt.path); w.Code != tt.public {
+			t.Errorf("public GET %s: expected %d, got %d", tt.path, tt.public, w.Code)
+		}
+		if w := get(admin, tt.path); w.Code != tt.admin {
+			t.Errorf("admin GET %s: expected %d, got %d", tt.path, tt.admin, w.Code)
+		}
+	}
+}
+
+func TestAdminListener_Default(t *testing.T) {
+	s := newTestServer(t, `
+auth:
+  api_keys: [test-key]
+  admin_keys: [admin-key]
+`)
+	if s.adminHTTP != nil {
+		t.Fatal("expected no admin listener without server.admin_port")
+	}
+	for _, path := range []string{"/api/v2/admin/stats", "/metrics", "/api/v2/logos/AAPL"} {
+		if w := get(s.Router(), path); w.Code != http.StatusOK {
+			t.Errorf("GET %s: expected 200, got %d", path, w.Code)
+		}
+	}
+}
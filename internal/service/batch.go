@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+// CachedLogo is one symbol's result in GetCachedLogos: its bytes, or the
+// error GetCachedLogo gave for it.
+type CachedLogo struct {
+	Symbol string
+	Data   []byte
+	Err    error
+}
+
+// GetCachedLogos returns GetCachedLogo's result for each symbol, in order:
+// the reads behind archives and sprite sheets. With WithBatchCoalescing,
+// identical batches (same symbols in the same order, same size) share one
+// fan-out while it runs and for the window after it. Data may then be
+// shared with other callers, so it must not be modified.
+func (s *LogoService) GetCachedLogos(ctx context.Context, symbols []string, size model.LogoSize) []CachedLogo {
+	fetch := func(ctx context.Context) ([]CachedLogo, error) {
+		logos := make([]CachedLogo, len(symbols))
+		for i, symbol := range symbols {
+			data, err := s.GetCachedLogo(ctx, symbol, size)
+			logos[i] = CachedLogo{Symbol: symbol, Data: data, Err: err}
+		}
+		return logos, nil
+	}
+	if s.batches == nil {
+		logos, _ := fetch(ctx)
+		return logos
+	}
+
+	logos, err := s.batches.Do(ctx, string(size)+":"+strings.Join(symbols, ","), fetch)
+	if err != nil {
+		// Only ctx ending while waiting on another caller's batch.
+		logos = make([]CachedLogo, len(symbols))
+		for i, symbol := range symbols {
+			logos[i] = CachedLogo{Symbol: symbol, Err: err}
+		}
+		return logos
+	}
+	return slices.Clone(logos)
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fleveque/logo-service/internal/clock"
+	"github.com/fleveque/logo-service/internal/model"
+	"github.com/fleveque/logo-service/internal/provider"
+	"github.com/fleveque/logo-service/internal/storage"
+	"github.com/fleveque/logo-service/internal/testutil"
+)
+
+func TestGetCachedLogos_Coalescing(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	github := testutil.NewFakeProvider(
+		&provider.LogoResult{Symbol: "AAPL", ImageData: []byte("aapl"), Source: "github:test"},
+		&provider.LogoResult{Symbol: "MSFT", ImageData: []byte("msft"), Source: "github:test"},
+	)
+	d := newTestService(t, github, nil, AcceptancePolicy{}, WithClock(fake), WithBatchCoalescing(time.Second))
+	ctx := context.Background()
+
+	if _, err := d.svc.GetLogo(ctx, "AAPL", model.SizeM); err != nil {
+		t.Fatalf("GetLogo failed: %v", err)
+	}
+	batch := func() []CachedLogo {
+		t.Helper()
+		logos := d.svc.GetCachedLogos(ctx, []string{"MSFT", "AAPL"}, model.SizeM)
+		if len(logos) != 2 || logos[0].Symbol != "MSFT" || logos[1].Symbol != "AAPL" {
+			t.Fatalf("expected MSFT and AAPL in order, got %+v", logos)
+		}
+		if logos[1].Err != nil || len(logos[1].Data) == 0 {
+			t.Errorf("expected AAPL's bytes, got %v", logos[1].Err)
+		}
+		return logos
+	}
+	if logos := batch(); !errors.Is(logos[0].Err, storage.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for MSFT, got %v", logos[0].Err)
+	}
+
+	// The batch is reused within the window, then read again.
+	if _, err := d.svc.GetLogo(ctx, "MSFT", model.SizeM); err != nil {
+		t.Fatalf("GetLogo failed: %v", err)
+	}
+	if logos := batch(); logos[0].Err == nil {
+		t.Error("expected the batch read before MSFT was cached, within the window")
+	}
+	fake.Advance(2 * time.Second)
+	if logos := batch(); logos[0].Err != nil {
+		t.Errorf("expected MSFT after the window, got %v", logos[0].Err)
+	}
+}
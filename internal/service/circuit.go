@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/fleveque/logo-service/internal/provider"
+)
+
+// errCircuitOpen is wrapped (with provider.ErrUnavailable) in the error of
+// a provider skipped because its circuit is open.
+var errCircuitOpen = errors.New("circuit open")
+
+// CircuitPolicy decides when a provider that keeps failing is skipped. Only
+// failures of the provider itself count (provider.ErrUnavailable: network
+// errors, timeouts, 5xx); a miss is an answer, and resets the count.
+type CircuitPolicy struct {
+	// Failures is how many consecutive failures open the circuit. Zero
+	// never opens it.
+	Failures int
+
+	// Cooldown is how long an open circuit skips the provider. After it,
+	// one request is let through: success closes the circuit, another
+	// failure opens it for a new cooldown.
+	Cooldown time.Duration
+}
+
+// circuit is the breaker of one provider layer.
+type circuit struct {
+	name   string
+	policy CircuitPolicy
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time // Zero while closed
+	probing   bool      // A request is testing a provider past its cooldown
+}
+
+// allow reports whether the provider may be asked now, and whether the call
+// is the probe of a circuit past its cooldown. The caller passes probe back
+// to record.
+func (c *circuit) allow(now time.Time) (ok, probe bool) {
+	if c == nil {
+		return true, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.openUntil.IsZero() {
+		return true, false
+	}
+	if now.Before(c.openUntil) || c.probing {
+		return false, false
+	}
+	c.probing = true // Half open: this request finds out if it's back
+	return true, true
+}
+
+// record counts the outcome of a call let through by allow. It returns true
+// when the call opened the circuit, so the caller can log it once.
+//
+// Only the probe ends the half-open state: a call allowed before the
+// circuit opened may return while the probe is still in flight, and mustn't
+// let a second probe through or reopen the circuit on the probe's behalf.
+func (c *circuit) record(now time.Time, probe bool, err error) (opened bool) {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if probe {
+		c.probing = false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, provider.ErrDisabled) {
+		return false // The caller gave up, or an admin did: no news about the provider
+	}
+	if !errors.Is(err, provider.ErrUnavailable) {
+		c.failures = 0
+		c.openUntil = time.Time{}
+		return false
+	}
+
+	c.failures++
+	if probe || c.failures >= c.policy.Failures {
+		c.openUntil = now.Add(c.policy.Cooldown)
+		return true
+	}
+	return false
+}
+
+// newCircuit returns a breaker for a provider layer, or nil (always closed)
+// if the policy never opens one.
+func newCircuit(name string, policy CircuitPolicy) *circuit {
+	if policy.Failures <= 0 {
+		return nil
+	}
+	return &circuit{name: name, policy: policy}
+}
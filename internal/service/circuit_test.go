@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/fleveque/logo-service/internal/clock"
+	"github.com/fleveque/logo-service/internal/model"
+	"github.com/fleveque/logo-service/internal/provider"
+	"github.com/fleveque/logo-service/internal/testutil"
+)
+
+func TestCircuit(t *testing.T) {
+	c := newCircuit("github", CircuitPolicy{Failures: 2, Cooldown: time.Minute})
+	now := time.Now()
+	down := fmt.Errorf("%w: connection refused", provider.ErrUnavailable)
+
+	// Misses are answers: they don't count.
+	c.record(now, false, errors.New("not found"))
+	if c.record(now, false, down) {
+		t.Fatal("opened after one failure")
+	}
+	if !c.record(now, false, down) {
+		t.Fatal("expected the second failure in a row to open the circuit")
+	}
+	if ok, _ := c.allow(now.Add(30 * time.Second)); ok {
+		t.Error("expected the provider to be skipped during the cooldown")
+	}
+
+	// After the cooldown, one probe goes through; a failure reopens at once.
+	later := now.Add(time.Minute)
+	ok, probe := c.allow(later)
+	if !ok || !probe {
+		t.Fatalf("expected a probe after the cooldown, got ok %v, probe %v", ok, probe)
+	}
+	if ok, _ := c.allow(later); ok {
+		t.Error("expected a single probe at a time")
+	}
+	if !c.record(later, probe, down) {
+		t.Error("expected a failed probe to reopen the circuit")
+	}
+
+	// A successful probe closes it.
+	ok, probe = c.allow(later.Add(time.Minute))
+	if !ok || !probe {
+		t.Fatal("expected a probe after the second cooldown")
+	}
+	c.record(later.Add(time.Minute), probe, nil)
+	if ok, probe := c.allow(later.Add(time.Minute)); !ok || probe {
+		t.Errorf("expected a closed circuit after a successful probe, got ok %v, probe %v", ok, probe)
+	}
+
+	if newCircuit("github", CircuitPolicy{}) != nil {
+		t.Error("expected no circuit when Failures is 0")
+	}
+}
+
+func TestCircuit_CallInFlightDuringProbe(t *testing.T) {
+	c := newCircuit("github", CircuitPolicy{Failures: 2, Cooldown: time.Minute})
+	now := time.Now()
+	down := fmt.Errorf("%w: connection refused", provider.ErrUnavailable)
+
+	// A slow call starts while the circuit is closed, then two others
+	// open it.
+	_, slow := c.allow(now)
+	c.record(now, false, down)
+	c.record(now, false, down)
+
+	later := now.Add(time.Minute)
+	ok, probe := c.allow(later)
+	if !ok || !probe {
+		t.Fatal("expected a probe after the cooldown")
+	}
+
+	// The slow call fails while the probe is in flight: the probe is still
+	// the only one let through.
+	c.record(later, slow, down)
+	if ok, _ := c.allow(later.Add(time.Minute)); ok {
+		t.Error("expected no second probe while the first is in flight")
+	}
+
+	// The probe's own outcome decides: success closes the circuit.
+	c.record(later, probe, nil)
+	if ok, probe := c.allow(later); !ok || probe {
+		t.Errorf("expected the probe to close the circuit, got ok %v, probe %v", ok, probe)
+	}
+}
+
+func TestGetLogo_CircuitSkipsFailingProvider(t *testing.T) {
+	github := testutil.NewFakeProvider()
+	github.Err = fmt.Errorf("%w: timeout", provider.ErrUnavailable)
+	fake := clock.NewFake(time.Now())
+	d := newTestService(t, github, nil, AcceptancePolicy{NotFoundRecheck: time.Hour},
+		WithCircuitBreaker(CircuitPolicy{Failures: 2, Cooldown: time.Minute}),
+		WithClock(fake))
+	ctx := context.Background()
+
+	for _, symbol := range []string{"AAA", "BBB", "CCC"} {
+		_, err := d.svc.GetLogo(ctx, symbol, model.SizeM)
+		if !errors.Is(err, provider.ErrUnavailable) {
+			t.Fatalf("GetLogo(%s): expected ErrUnavailable, got %v", symbol, err)
+		}
+	}
+	// The third request didn't wait for GitHub.
+	if calls := github.Calls(); len(calls) != 2 {
+		t.Errorf("expected 2 calls before the circuit opened, got %v", calls)
+	}
+	// An incomplete search isn't remembered as not_found.
+	if logo, err := d.logoRepo.GetBySymbol(ctx, "AAA"); err == nil && logo.Status == model.StatusNotFound {
+		t.Error("expected AAA not to be marked not_found while GitHub is down")
+	}
+
+	// Back after the cooldown.
+	github.Err = nil
+	github.Results["DDD"] = &provider.LogoResult{Symbol: "DDD", ImageData: []byte("img"), Source: "github:test"}
+	fake.Advance(time.Minute)
+	if _, err := d.svc.GetLogo(ctx, "DDD", model.SizeM); err != nil {
+		t.Fatalf("GetLogo after the cooldown: %v", err)
+	}
+}
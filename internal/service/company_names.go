@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+// CompanyNamer names symbols from a repo's own list: GitHubProvider with a
+// names file (github.names_file). BackfillCompanyNames asks the GitHub
+// provider for it with a type assertion, so fakes needn't implement it.
+type CompanyNamer interface {
+	CompanyName(ctx context.Context, repo, symbol string) string
+}
+
+// NameFill is a company name BackfillCompanyNames gave a logo, or would have.
+type NameFill struct {
+	Symbol      string `json:"symbol"`
+	CompanyName string `json:"company_name"`
+	From        string `json:"from"` // "names_file" or "instruments"
+}
+
+// NameBackfillReport is what BackfillCompanyNames found.
+type NameBackfillReport struct {
+	Checked int        `json:"checked"` // Logos without a company name
+	Filled  []NameFill `json:"filled"`
+	DryRun  bool       `json:"dry_run"`
+}
+
+// BackfillCompanyNames names logos that have no company name, as GitHub
+// imports used to leave them: from the names file of the repo a logo came
+// from, else from the instruments table. Logos neither knows stay unnamed.
+// Each name is set like an admin correction, so it's in the audit log.
+func (s *LogoService) BackfillCompanyNames(ctx context.Context, dryRun bool) (*NameBackfillReport, error) {
+	logos, err := s.logoRepo.ListUnnamed(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report := &NameBackfillReport{Checked: len(logos), Filled: []NameFill{}, DryRun: dryRun}
+
+	namer, _ := s.ghProvider.(CompanyNamer)
+	for _, logo := range logos {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		fill := NameFill{Symbol: logo.Symbol}
+		if repo, ok := strings.CutPrefix(logo.Source, "github:"); ok && namer != nil {
+			fill.CompanyName, fill.From = namer.CompanyName(ctx, repo, logo.Symbol), "names_file"
+		}
+		if fill.CompanyName == "" {
+			if inst, err := s.instrumentRepo.GetBySymbol(ctx, logo.Symbol); err == nil && inst.Name != "" {
+				fill.CompanyName, fill.From = inst.Name, "instruments"
+			}
+		}
+		if fill.CompanyName == "" {
+			continue
+		}
+
+		if !dryRun {
+			if _, err := s.UpdateMetadata(ctx, logo.Symbol, model.MetadataUpdate{CompanyName: &fill.CompanyName}); err != nil {
+				return report, err
+			}
+		}
+		report.Filled = append(report.Filled, fill)
+	}
+	return report, nil
+}
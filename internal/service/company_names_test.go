@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/fleveque/logo-service/internal/model"
+	"github.com/fleveque/logo-service/internal/testutil"
+)
+
+// namingProvider is a GitHub fake with a names file.
+type namingProvider struct {
+	*testutil.FakeProvider
+	names map[string]string // repo + " " + symbol → name
+}
+
+func (p *namingProvider) CompanyName(_ context.Context, repo, symbol string) string {
+	return p.names[repo+" "+symbol]
+}
+
+func TestBackfillCompanyNames(t *testing.T) {
+	d := newTestService(t, testutil.NewFakeProvider(), nil, AcceptancePolicy{})
+	github := &namingProvider{FakeProvider: d.github, names: map[string]string{"acme/logos MSFT": "Microsoft (repo)"}}
+	d.svc.ghProvider = github
+	ctx := context.Background()
+
+	for _, logo := range []*model.Logo{
+		{Symbol: "AAPL", Source: "github:acme/logos"}, // Not in the names file: instruments
+		{Symbol: "MSFT", Source: "github:acme/logos"}, // Names file wins over instruments
+		{Symbol: "TSLA", Source: "llm:anthropic"},     // Not from GitHub: instruments
+		{Symbol: "NOPE", Source: "github:acme/logos"}, // Nobody knows it
+		{Symbol: "GOOG", Source: "github:acme/logos", CompanyName: "Alphabet Inc."},
+	} {
+		logo.Status = model.StatusProcessed
+		if err := d.logoRepo.Create(ctx, logo); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for symbol, name := range map[string]string{"AAPL": "Apple Inc.", "MSFT": "Microsoft Corp", "TSLA": "Tesla, Inc.", "GOOG": "Google"} {
+		if err := d.instrumentRepo.Upsert(ctx, &model.Instrument{Symbol: symbol, Name: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []NameFill{
+		{"AAPL", "Apple Inc.", "instruments"},
+		{"MSFT", "Microsoft (repo)", "names_file"},
+		{"TSLA", "Tesla, Inc.", "instruments"},
+	}
+
+	report, err := d.svc.BackfillCompanyNames(ctx, true)
+	if err != nil {
+		t.Fatalf("BackfillCompanyNames: %v", err)
+	}
+	if report.Checked != 4 || !reflect.DeepEqual(report.Filled, want) {
+		t.Errorf("dry run report = %+v, want 4 checked and %+v", report, want)
+	}
+	if logo, _ := d.logoRepo.GetBySymbol(ctx, "AAPL"); logo.CompanyName != "" {
+		t.Error("a dry run must not change anything")
+	}
+
+	if _, err := d.svc.BackfillCompanyNames(ctx, false); err != nil {
+		t.Fatalf("BackfillCompanyNames: %v", err)
+	}
+	for _, fill := range append(want, NameFill{Symbol: "GOOG", CompanyName: "Alphabet Inc."}, NameFill{Symbol: "NOPE"}) {
+		logo, err := d.logoRepo.GetBySymbol(ctx, fill.Symbol)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if logo.CompanyName != fill.CompanyName {
+			t.Errorf("%s: company name = %q, want %q", fill.Symbol, logo.CompanyName, fill.CompanyName)
+		}
+	}
+	entries, err := d.audit.List(ctx, "", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != len(want) || entries[0].Action != model.AuditMetadata {
+		t.Errorf("expected one metadata audit entry per name, got %+v", entries)
+	}
+
+	// Only NOPE is left.
+	if report, _ := d.svc.BackfillCompanyNames(ctx, false); report.Checked != 1 || len(report.Filled) != 0 {
+		t.Errorf("expected only NOPE left unnamed, got %+v", report)
+	}
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"os"
+
+	"go.uber.org/zap"
+
+	"github.com/fleveque/logo-service/internal/imagefmt"
+	"github.com/fleveque/logo-service/internal/model"
+	"github.com/fleveque/logo-service/internal/phash"
+	"github.com/fleveque/logo-service/internal/storage"
+)
+
+// ImageSummary describes one side of a Comparison.
+type ImageSummary struct {
+	Format    string `json:"format"`
+	Width     int    `json:"width,omitempty"` // 0 for SVG: vectors have no pixel size
+	Height    int    `json:"height,omitempty"`
+	SizeBytes int    `json:"size_bytes,omitempty"`
+	// FromRendering is set when the current logo's original wasn't kept:
+	// format and dimensions are then those of its stored xl size.
+	FromRendering bool   `json:"from_rendering,omitempty"`
+	QualityScore  *int   `json:"quality_score,omitempty"` // See AssessQuality
+	QualityNotes  string `json:"quality_notes,omitempty"`
+	PHash         string `json:"phash,omitempty"` // "" if it couldn't be hashed
+}
+
+// Comparison sets a candidate image beside a symbol's current logo, for a
+// reviewer deciding whether to replace it.
+type Comparison struct {
+	Symbol    string        `json:"symbol"`
+	Status    string        `json:"status"`
+	Current   *ImageSummary `json:"current"` // nil: the symbol has no image yet
+	Candidate ImageSummary  `json:"candidate"`
+	// PHashDistance is how many of the 64 perceptual-hash bits differ: 0 is
+	// the same picture, more than ~10 a different one. nil when either side
+	// couldn't be hashed.
+	PHashDistance *int `json:"phash_distance"`
+	// SameImage is set when the candidate's bytes are the current original's.
+	SameImage bool `json:"same_image"`
+}
+
+// CompareCandidate compares a candidate image with a symbol's current logo:
+// format, dimensions, quality score and perceptual-hash distance. Nothing
+// is stored. The candidate is scored and hashed here in pure Go, from a
+// square copy as the stored sizes are rendered; formats Go can't decode
+// (SVG, WebP, TIFF, HEIC) are scored without the transparency check and
+// not hashed.
+//
+// Returns storage.ErrNotFound if the symbol has no record, and an error
+// wrapping one of imagefmt's errors if the candidate isn't a usable image.
+func (s *LogoService) CompareCandidate(ctx context.Context, symbol string, candidate []byte) (*Comparison, error) {
+	info, err := imagefmt.Inspect(candidate)
+	if err != nil {
+		return nil, fmt.Errorf("candidate: %w", err)
+	}
+	if info.Format == imagefmt.SVG {
+		if candidate, err = imagefmt.SanitizeSVG(candidate); err != nil {
+			return nil, fmt.Errorf("candidate: %w", err)
+		}
+	}
+
+	logo, err := s.logoRepo.GetBySymbol(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	cmp := &Comparison{
+		Symbol: logo.Symbol,
+		Status: string(logo.Status),
+		Candidate: ImageSummary{
+			Format:    string(info.Format),
+			Width:     info.Width,
+			Height:    info.Height,
+			SizeBytes: len(candidate),
+		},
+		Current:   s.currentSummary(logo),
+		SameImage: logo.OriginalHash != "" && logo.OriginalHash == storage.HashOriginal(candidate),
+	}
+
+	preview := squarePreview(candidate, info.Format)
+	score, notes := AssessQuality(candidate, preview)
+	cmp.Candidate.QualityScore, cmp.Candidate.QualityNotes = &score, notes
+	if h, err := phash.FromPNG(preview); err == nil {
+		cmp.Candidate.PHash = h.String()
+		if cmp.Current != nil {
+			if current, err := phash.Parse(cmp.Current.PHash); err == nil {
+				d := h.Distance(current)
+				cmp.PHashDistance = &d
+			}
+		}
+	}
+	return cmp, nil
+}
+
+// currentSummary describes a logo's current image, from its original when
+// kept and its stored xl size otherwise. nil if it has neither.
+func (s *LogoService) currentSummary(logo *model.Logo) *ImageSummary {
+	summary := &ImageSummary{
+		QualityScore: logo.QualityScore,
+		QualityNotes: logo.QualityNotes,
+		PHash:        logo.PHash,
+	}
+
+	if logo.OriginalHash != "" {
+		original, err := s.fs.ReadOriginal(logo.OriginalHash)
+		if err == nil {
+			if info, err := imagefmt.Inspect(original); err == nil {
+				summary.Format, summary.Width, summary.Height = string(info.Format), info.Width, info.Height
+				summary.SizeBytes = len(original)
+				return summary
+			}
+		} else if !errors.Is(err, os.ErrNotExist) {
+			s.logger.Warn("reading original", zap.String("symbol", logo.Symbol), zap.Error(err))
+		}
+	}
+
+	rendered, err := s.fs.Read(logo.Symbol, model.SizeXL)
+	if err != nil {
+		return nil
+	}
+	info, err := imagefmt.Inspect(rendered)
+	if err != nil {
+		return nil
+	}
+	summary.Format, summary.Width, summary.Height = string(info.Format), info.Width, info.Height
+	summary.SizeBytes = len(rendered)
+	summary.FromRendering = true
+	return summary
+}
+
+// squarePreview decodes an image and centers it on a transparent square,
+// the way the stored sizes are rendered, so its perceptual hash compares
+// with theirs. Returns it as a PNG, or nil for formats Go can't decode.
+// Call it after imagefmt.Inspect, which has already bounded the dimensions.
+func squarePreview(data []byte, format imagefmt.Format) []byte {
+	switch format {
+	case imagefmt.ICO, imagefmt.BMP:
+		converted, err := imagefmt.ToPNG(data, format)
+		if err != nil {
+			return nil
+		}
+		data = converted
+	case imagefmt.PNG, imagefmt.JPEG, imagefmt.GIF:
+	default:
+		return nil
+	}
+
+	// Go note: image.Decode picks the decoder registered for the format;
+	// imagefmt's blank imports register PNG, JPEG and GIF.
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+	b := img.Bounds()
+	side := max(b.Dx(), b.Dy())
+	square := image.NewNRGBA(image.Rect(0, 0, side, side))
+	offset := image.Pt((side-b.Dx())/2, (side-b.Dy())/2)
+	draw.Draw(square, b.Sub(b.Min).Add(offset), img, b.Min, draw.Src)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, square); err != nil {
+		return nil
+	}
+	return buf.Bytes()
+}
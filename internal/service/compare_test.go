@@ -0,0 +1,96 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/fleveque/logo-service/internal/imagefmt"
+	"github.com/fleveque/logo-service/internal/provider"
+	"github.com/fleveque/logo-service/internal/storage"
+	"github.com/fleveque/logo-service/internal/testutil"
+)
+
+// halfPNG returns an opaque width×height PNG, black on the left half and
+// white on the right, or the other way round when flipped.
+func halfPNG(t *testing.T, width, height int, flipped bool) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if (x < width/2) != flipped {
+				img.Set(x, y, color.Black)
+			} else {
+				img.Set(x, y, color.White)
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestCompareCandidate(t *testing.T) {
+	d := newTestService(t, testutil.NewFakeProvider(), nil, AcceptancePolicy{})
+	ctx := context.Background()
+
+	current := halfPNG(t, 64, 64, false)
+	if err := d.svc.ProcessAndStore(ctx, &provider.LogoResult{Symbol: "ABC", ImageData: current, Source: "test"}); err != nil {
+		t.Fatalf("ProcessAndStore: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		candidate []byte
+		same      bool
+		closeTo   bool // pHash distance at most 4
+	}{
+		{"identical", current, true, true},
+		{"same picture, smaller", halfPNG(t, 32, 32, false), false, true},
+		{"different picture", halfPNG(t, 256, 256, true), false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmp, err := d.svc.CompareCandidate(ctx, "ABC", tt.candidate)
+			if err != nil {
+				t.Fatalf("CompareCandidate: %v", err)
+			}
+			if cmp.Current == nil || cmp.Current.Width != 64 || cmp.Current.FromRendering {
+				t.Fatalf("current = %+v, want the 64px original", cmp.Current)
+			}
+			info, _ := imagefmt.Inspect(tt.candidate)
+			if cmp.Candidate.Width != info.Width || cmp.Candidate.QualityScore == nil {
+				t.Errorf("candidate = %+v, want %dpx and a quality score", cmp.Candidate, info.Width)
+			}
+			if cmp.SameImage != tt.same {
+				t.Errorf("same image = %v, want %v", cmp.SameImage, tt.same)
+			}
+			if cmp.PHashDistance == nil {
+				t.Fatal("expected a pHash distance")
+			}
+			if close := *cmp.PHashDistance <= 4; close != tt.closeTo {
+				t.Errorf("pHash distance = %d, want close: %v", *cmp.PHashDistance, tt.closeTo)
+			}
+		})
+	}
+
+	// A bigger source loses fewer points for upscaling.
+	small, _ := d.svc.CompareCandidate(ctx, "ABC", halfPNG(t, 32, 32, false))
+	large, _ := d.svc.CompareCandidate(ctx, "ABC", halfPNG(t, 256, 256, false))
+	if *large.Candidate.QualityScore <= *small.Candidate.QualityScore {
+		t.Errorf("expected 256px to score above 32px, got %d and %d", *large.Candidate.QualityScore, *small.Candidate.QualityScore)
+	}
+
+	if _, err := d.svc.CompareCandidate(ctx, "ABC", []byte("<html>nope</html>")); !errors.Is(err, imagefmt.ErrUnsupported) {
+		t.Errorf("expected ErrUnsupported for a non-image, got %v", err)
+	}
+	if _, err := d.svc.CompareCandidate(ctx, "NOPE", current); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for an unknown symbol, got %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fleveque/logo-service/internal/flags"
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+// DefaultDeleteRetention is how long a deleted logo can be restored.
+const DefaultDeleteRetention = 30 * 24 * time.Hour
+
+// DeleteLogo soft-deletes a logo: it's no longer served (requests get an
+// error wrapping storage.ErrLogoDeleted), listed or acquired again, but its
+// record and files are kept, so RestoreLogo can bring it back until
+// PurgeDeleted removes it for good. Returns storage.ErrNotFound if there's
+// no such logo.
+func (s *LogoService) DeleteLogo(ctx context.Context, symbol, reason string) error {
+	if err := s.logoRepo.SoftDelete(ctx, symbol, s.clock.Now()); err != nil {
+		return err
+	}
+	// Variants are only a cache: a restored logo renders them again.
+	s.purgeVariants(ctx, symbol)
+	s.recordAudit(ctx, model.AuditDelete, symbol, reason)
+	s.recordEvent(ctx, model.EventDeleted, symbol)
+	return nil
+}
+
+// RestoreLogo undoes DeleteLogo, serving the logo again as it was. Returns
+// storage.ErrNotFound if the logo isn't deleted, or its retention is over.
+func (s *LogoService) RestoreLogo(ctx context.Context, symbol string) error {
+	if err := s.logoRepo.Restore(ctx, symbol, s.clock.Now().Add(-s.retention)); err != nil {
+		return err
+	}
+	s.recordAudit(ctx, model.AuditRestore, symbol, "")
+	if logo, err := s.logoRepo.GetBySymbol(ctx, symbol); err == nil && logo.Status == model.StatusProcessed {
+		s.recordEvent(ctx, model.EventUpdated, symbol) // Served again as it was
+	}
+	return nil
+}
+
+// PurgeDeleted removes the logos deleted longer than the retention ago:
+// files, variants, originals no other logo shares, and the record, which
+// frees the symbol to be acquired again. It returns how many it purged.
+// A logo that fails stops the run; the next one starts with it again.
+// While the purge_deleted flag is off, or maintenance_mode on, it purges
+// nothing.
+func (s *LogoService) PurgeDeleted(ctx context.Context) (int, error) {
+	if !s.enabled(flags.PurgeDeleted) || s.enabled(flags.MaintenanceMode) {
+		return 0, nil
+	}
+	logos, err := s.logoRepo.ListDeleted(ctx, s.clock.Now().Add(-s.retention))
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, logo := range logos {
+		if err := ctx.Err(); err != nil {
+			return purged, err
+		}
+		// The record goes last: while it's there, a failed purge is retried.
+		if err := s.fs.DeleteSymbol(logo.Symbol); err != nil {
+			return purged, fmt.Errorf("deleting files of %s: %w", logo.Symbol, err)
+		}
+		s.purgeVariants(ctx, logo.Symbol)
+		if err := s.purgeOriginals(ctx, logo.Symbol); err != nil {
+			return purged, fmt.Errorf("deleting originals of %s: %w", logo.Symbol, err)
+		}
+		if err := s.logoRepo.Purge(ctx, logo.Symbol); err != nil {
+			return purged, err
+		}
+		s.recordAudit(ctx, model.AuditPurge, logo.Symbol, "")
+		purged++
+	}
+	return purged, nil
+}
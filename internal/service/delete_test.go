@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fleveque/logo-service/internal/clock"
+	"github.com/fleveque/logo-service/internal/model"
+	"github.com/fleveque/logo-service/internal/provider"
+	"github.com/fleveque/logo-service/internal/storage"
+	"github.com/fleveque/logo-service/internal/testutil"
+)
+
+func TestDeleteLogo_RestoreWithinRetention(t *testing.T) {
+	github := testutil.NewFakeProvider(&provider.LogoResult{Symbol: "AAPL", ImageData: []byte("aapl"), Source: "github:test"})
+	d := newTestService(t, github, nil, AcceptancePolicy{})
+	ctx := context.Background()
+
+	if _, err := d.svc.GetLogo(ctx, "AAPL", model.SizeM); err != nil {
+		t.Fatalf("GetLogo failed: %v", err)
+	}
+	if err := d.svc.DeleteLogo(ctx, "AAPL", "wrong company"); err != nil {
+		t.Fatalf("DeleteLogo failed: %v", err)
+	}
+	if err := d.svc.DeleteLogo(ctx, "AAPL", ""); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected ErrNotFound deleting twice, got %v", err)
+	}
+
+	// Not served, and not acquired again either.
+	if _, err := d.svc.GetLogo(ctx, "AAPL", model.SizeM); !errors.Is(err, storage.ErrLogoDeleted) {
+		t.Fatalf("expected ErrLogoDeleted, got %v", err)
+	}
+	if calls := len(github.Calls()); calls != 1 {
+		t.Errorf("expected no acquisition for a deleted logo, got %d provider calls", calls)
+	}
+	if !d.fs.Exists("AAPL", model.SizeM) {
+		t.Error("expected files to be kept until purged")
+	}
+
+	if err := d.svc.RestoreLogo(ctx, "AAPL"); err != nil {
+		t.Fatalf("RestoreLogo failed: %v", err)
+	}
+	if _, err := d.svc.GetLogo(ctx, "AAPL", model.SizeM); err != nil {
+		t.Errorf("expected the restored logo to be served, got %v", err)
+	}
+
+	entries, err := d.svc.ListAudit(ctx, "AAPL", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Action != model.AuditRestore || entries[1].Action != model.AuditDelete || entries[1].Details != "wrong company" {
+		t.Errorf("unexpected audit log: %+v", entries)
+	}
+}
+
+func TestPurgeDeleted(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	github := testutil.NewFakeProvider(
+		&provider.LogoResult{Symbol: "AAPL", ImageData: []byte("aapl"), Source: "github:test"},
+		&provider.LogoResult{Symbol: "MSFT", ImageData: []byte("msft"), Source: "github:test"},
+	)
+	d := newTestService(t, github, nil, AcceptancePolicy{}, WithClock(fake), WithDeleteRetention(24*time.Hour))
+	ctx := context.Background()
+
+	for _, symbol := range []string{"AAPL", "MSFT"} {
+		if _, err := d.svc.GetLogo(ctx, symbol, model.SizeM); err != nil {
+			t.Fatalf("GetLogo %s failed: %v", symbol, err)
+		}
+	}
+	if err := d.svc.DeleteLogo(ctx, "AAPL", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// Within the retention nothing is purged.
+	if purged, err := d.svc.PurgeDeleted(ctx); err != nil || purged != 0 {
+		t.Fatalf("PurgeDeleted within retention: purged=%d err=%v", purged, err)
+	}
+
+	fake.Advance(25 * time.Hour)
+	if err := d.svc.RestoreLogo(ctx, "AAPL"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected ErrNotFound restoring after the retention, got %v", err)
+	}
+	if purged, err := d.svc.PurgeDeleted(ctx); err != nil || purged != 1 {
+		t.Fatalf("PurgeDeleted after retention: purged=%d err=%v", purged, err)
+	}
+	if d.fs.Exists("AAPL", model.SizeM) {
+		t.Error("expected the purged logo's files to be deleted")
+	}
+	if !d.fs.Exists("MSFT", model.SizeM) {
+		t.Error("expected other logos' files to be kept")
+	}
+
+	// The symbol is free again: the next request acquires it anew.
+	if _, err := d.logoRepo.GetBySymbol(ctx, "AAPL"); !errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrLogoDeleted) {
+		t.Fatalf("expected the record to be gone, got %v", err)
+	}
+	if _, err := d.svc.GetLogo(ctx, "AAPL", model.SizeM); err != nil {
+		t.Errorf("expected a purged symbol to be acquired again, got %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/fleveque/logo-service/internal/model"
+	"github.com/fleveque/logo-service/internal/phash"
+)
+
+// DuplicateGroup is a set of symbols of different companies whose logos
+// look alike. Usually one of them is right and the others got its logo by
+// mistake (an LLM search that landed on the wrong company's site).
+type DuplicateGroup struct {
+	// MaxDistance is the largest perceptual-hash distance between two
+	// linked logos in the group: 0 means pixel-for-pixel lookalikes.
+	MaxDistance int          `json:"max_distance"`
+	Logos       []model.Logo `json:"logos"`
+}
+
+// FindDuplicates groups served logos whose perceptual hashes are at most
+// maxDistance bits apart. Symbols of the same company (GOOG and GOOGL,
+// BRK.A and BRK.B) share a logo legitimately and are never linked.
+// Groups are transitive: if A looks like B and B like C, all three are
+// one group. Larger groups come first.
+//
+// Every pair is compared, which is fine for an admin report over tens of
+// thousands of logos — a Hamming distance is one XOR and a popcount.
+func (s *LogoService) FindDuplicates(ctx context.Context, maxDistance int) ([]DuplicateGroup, error) {
+	logos, err := s.logoRepo.ListHashed(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := make([]phash.Hash, 0, len(logos))
+	valid := logos[:0]
+	for _, l := range logos {
+		h, err := phash.Parse(l.PHash)
+		if err != nil {
+			s.logger.Warn("skipping logo with invalid phash", zap.String("symbol", l.Symbol), zap.Error(err))
+			continue
+		}
+		hashes = append(hashes, h)
+		valid = append(valid, l)
+	}
+	logos = valid
+
+	// Union-find: parent[i] leads to the representative of i's group.
+	parent := make([]int, len(logos))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	worst := make(map[int]int) // representative → largest linking distance
+	for i := range logos {
+		for j := i + 1; j < len(logos); j++ {
+			d := hashes[i].Distance(hashes[j])
+			if d > maxDistance || sameCompany(logos[i], logos[j]) {
+				continue
+			}
+			ri, rj := find(i), find(j)
+			parent[rj] = ri
+			worst[ri] = max(worst[ri], worst[rj], d)
+			if ri != rj {
+				delete(worst, rj)
+			}
+		}
+	}
+
+	members := make(map[int][]model.Logo)
+	for i, l := range logos {
+		if _, linked := worst[find(i)]; linked {
+			members[find(i)] = append(members[find(i)], l)
+		}
+	}
+
+	groups := make([]DuplicateGroup, 0, len(members))
+	for root, ls := range members {
+		groups = append(groups, DuplicateGroup{MaxDistance: worst[root], Logos: ls})
+	}
+	sort.Slice(groups, func(a, b int) bool {
+		if len(groups[a].Logos) != len(groups[b].Logos) {
+			return len(groups[a].Logos) > len(groups[b].Logos)
+		}
+		return groups[a].Logos[0].Symbol < groups[b].Logos[0].Symbol
+	})
+	return groups, nil
+}
+
+// sameCompany reports whether two logos belong to the same company, going
+// by the company name. Unknown names never match.
+func sameCompany(a, b model.Logo) bool {
+	return a.CompanyName != "" && strings.EqualFold(a.CompanyName, b.CompanyName)
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fleveque/logo-service/internal/model"
+	"github.com/fleveque/logo-service/internal/storage"
+	"github.com/fleveque/logo-service/internal/testutil"
+)
+
+func TestFindDuplicates(t *testing.T) {
+	d := newTestService(t, testutil.NewFakeProvider(), nil, AcceptancePolicy{})
+	ctx := context.Background()
+
+	logos := []struct {
+		symbol, company, phash string
+		status                 model.LogoStatus
+	}{
+		{"GOOG", "Alphabet Inc.", "00000000000000ff", model.StatusProcessed},
+		{"GOOGL", "alphabet inc.", "00000000000000ff", model.StatusProcessed},   // Same company: fine
+		{"ABC", "AmerisourceBergen", "00000000000000fe", model.StatusProcessed}, // 1 bit from GOOG
+		{"XYZ", "Other Co", "ffffffff00000000", model.StatusProcessed},
+		{"REV", "In Review Co", "00000000000000ff", model.StatusReview}, // Not served: ignored
+	}
+	for _, l := range logos {
+		logo := &model.Logo{Symbol: l.symbol, CompanyName: l.company, Source: "test", Status: l.status}
+		if err := d.logoRepo.Create(ctx, logo); err != nil {
+			t.Fatalf("creating %s: %v", l.symbol, err)
+		}
+		if err := d.logoRepo.SetPHash(ctx, l.symbol, l.phash); err != nil {
+			t.Fatalf("setting phash: %v", err)
+		}
+	}
+
+	groups, err := d.svc.FindDuplicates(ctx, 4)
+	if err != nil {
+		t.Fatalf("FindDuplicates: %v", err)
+	}
+	if len(groups) != 1 {
+		t.Fatalf("expected 1 group, got %+v", groups)
+	}
+	var symbols []string
+	for _, l := range groups[0].Logos {
+		symbols = append(symbols, l.Symbol)
+	}
+	// GOOG and GOOGL aren't linked to each other, but both look like ABC.
+	if len(symbols) != 3 || symbols[0] != "ABC" || symbols[1] != "GOOG" || symbols[2] != "GOOGL" {
+		t.Errorf("expected [ABC GOOG GOOGL], got %v", symbols)
+	}
+	if groups[0].MaxDistance != 1 {
+		t.Errorf("expected max distance 1, got %d", groups[0].MaxDistance)
+	}
+
+	if groups, _ := d.svc.FindDuplicates(ctx, 0); len(groups) != 0 {
+		t.Errorf("expected no exact duplicates across companies, got %+v", groups)
+	}
+}
+
+func TestSendToReview(t *testing.T) {
+	d := newTestService(t, testutil.NewFakeProvider(), nil, AcceptancePolicy{})
+	ctx := context.Background()
+
+	if err := d.logoRepo.Create(ctx, &model.Logo{Symbol: "ABC", Source: "llm:test", Status: model.StatusProcessed}); err != nil {
+		t.Fatalf("creating logo: %v", err)
+	}
+
+	if err := d.svc.SendToReview(ctx, "ABC"); err != nil {
+		t.Fatalf("SendToReview: %v", err)
+	}
+	if _, err := d.svc.GetLogo(ctx, "ABC", model.SizeM); !errors.Is(err, ErrPendingReview) {
+		t.Errorf("expected ErrPendingReview once in review, got %v", err)
+	}
+	if err := d.svc.SendToReview(ctx, "ABC"); err == nil {
+		t.Error("expected an error sending a logo that's already in review")
+	}
+	if err := d.svc.SendToReview(ctx, "NOPE"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	entries, err := d.svc.ListAudit(ctx, "ABC", 10)
+	if err != nil || len(entries) != 1 || entries[0].Action != model.AuditReview {
+		t.Errorf("expected one review audit entry, got %+v (%v)", entries, err)
+	}
+}
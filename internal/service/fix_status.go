@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+// DefaultStalePending is how long a logo can stay pending before FixStatus
+// considers its acquisition abandoned (a crash, a restart mid-import).
+const DefaultStalePending = 7 * 24 * time.Hour
+
+// FixStatusOptions tunes FixStatus.
+type FixStatusOptions struct {
+	PendingAge time.Duration // Pending longer than this is stale; DefaultStalePending if zero
+	DryRun     bool          // Report what would change without changing it
+}
+
+// StatusFix is one record FixStatus normalized, or would have.
+type StatusFix struct {
+	Symbol string           `json:"symbol"`
+	From   model.LogoStatus `json:"from"`
+	To     model.LogoStatus `json:"to"`
+	Reason string           `json:"reason"`
+}
+
+// StatusReport is what FixStatus found.
+type StatusReport struct {
+	Checked int         `json:"checked"` // Processed, pending and failed logos looked at
+	Fixes   []StatusFix `json:"fixes"`
+	DryRun  bool        `json:"dry_run"`
+}
+
+// FixStatus finds records whose status contradicts the rest of the record
+// and normalizes them, each with a reason:
+//
+//   - processed with no size on record: nothing can be served, so it's
+//     marked failed and the next request acquires it again
+//   - pending for longer than opts.PendingAge: its acquisition died with the
+//     process that ran it, and until it's failed every request gets a 202
+//   - failed without an error message: it gets one saying so
+//
+// Every change is recorded in the audit log. Logos being acquired by this
+// process right now are left alone, whatever their age.
+func (s *LogoService) FixStatus(ctx context.Context, opts FixStatusOptions) (*StatusReport, error) {
+	if opts.PendingAge <= 0 {
+		opts.PendingAge = DefaultStalePending
+	}
+	report := &StatusReport{Fixes: []StatusFix{}, DryRun: opts.DryRun}
+
+	checks := []struct {
+		status model.LogoStatus
+		fix    func(logo *model.Logo) (reason string)
+	}{
+		{model.StatusProcessed, func(logo *model.Logo) string {
+			if len(logo.MissingSizes()) < len(model.AllSizes) {
+				return ""
+			}
+			return "processed without any size on record"
+		}},
+		{model.StatusPending, func(logo *model.Logo) string {
+			cutoff := s.clock.Now().Add(-opts.PendingAge)
+			if !logo.UpdatedAt.Before(cutoff) || s.isAcquiring(logo.Symbol) {
+				return ""
+			}
+			return fmt.Sprintf("pending since %s, acquisition never finished", logo.UpdatedAt.UTC().Format(time.RFC3339))
+		}},
+		{model.StatusFailed, func(logo *model.Logo) string {
+			if logo.ErrorMessage != nil && *logo.ErrorMessage != "" {
+				return ""
+			}
+			return "failed without an error message"
+		}},
+	}
+
+	for _, check := range checks {
+		logos, err := s.logoRepo.ListByStatus(ctx, check.status, math.MaxInt32)
+		if err != nil {
+			return report, err
+		}
+		for i := range logos {
+			if err := ctx.Err(); err != nil {
+				return report, err
+			}
+			logo := &logos[i]
+			report.Checked++
+
+			reason := check.fix(logo)
+			if reason == "" {
+				continue
+			}
+			fix := StatusFix{Symbol: logo.Symbol, From: logo.Status, To: model.StatusFailed, Reason: reason}
+			report.Fixes = append(report.Fixes, fix)
+			if opts.DryRun {
+				continue
+			}
+			if err := s.logoRepo.SetStatus(ctx, logo.Symbol, fix.To, "normalized: "+reason); err != nil {
+				return report, err
+			}
+			s.recordAudit(ctx, model.AuditFixStatus, logo.Symbol, fmt.Sprintf("%s → %s: %s", fix.From, fix.To, reason))
+			if fix.From == model.StatusProcessed {
+				s.recordEvent(ctx, model.EventDeleted, logo.Symbol)
+			}
+		}
+	}
+	return report, nil
+}
+
+// isAcquiring reports whether this process is acquiring symbol right now.
+func (s *LogoService) isAcquiring(symbol string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.acquiring[symbol]
+}
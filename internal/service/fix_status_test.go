@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fleveque/logo-service/internal/clock"
+	"github.com/fleveque/logo-service/internal/model"
+	"github.com/fleveque/logo-service/internal/testutil"
+)
+
+func TestFixStatus(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	d := newTestService(t, testutil.NewFakeProvider(), nil, AcceptancePolicy{}, WithClock(fake))
+	ctx := context.Background()
+
+	for symbol, status := range map[string]model.LogoStatus{
+		"EMPTY":  model.StatusProcessed,
+		"AAPL":   model.StatusProcessed,
+		"STUCK":  model.StatusPending,
+		"BUSY":   model.StatusPending,
+		"SILENT": model.StatusFailed,
+		"LOUD":   model.StatusFailed,
+	} {
+		if err := d.logoRepo.Create(ctx, &model.Logo{Symbol: symbol, Status: status}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	errMsg := "download failed"
+	if err := d.logoRepo.SetSizeAvailable(ctx, "AAPL", model.SizeM); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.logoRepo.SetStatus(ctx, "LOUD", model.StatusFailed, errMsg); err != nil {
+		t.Fatal(err)
+	}
+	fake.Advance(2 * time.Hour)
+	// Acquired by this process right now: never stale.
+	d.svc.startAcquiring("BUSY")
+	defer d.svc.doneAcquiring("BUSY")
+
+	want := map[string]model.LogoStatus{"EMPTY": model.StatusProcessed, "STUCK": model.StatusPending, "SILENT": model.StatusFailed}
+
+	// A dry run reports without changing anything.
+	report, err := d.svc.FixStatus(ctx, FixStatusOptions{PendingAge: time.Hour, DryRun: true})
+	if err != nil {
+		t.Fatalf("FixStatus: %v", err)
+	}
+	if report.Checked != 6 || !report.DryRun {
+		t.Errorf("unexpected report %+v", report)
+	}
+	got := map[string]model.LogoStatus{}
+	for _, fix := range report.Fixes {
+		got[fix.Symbol] = fix.From
+		if fix.To != model.StatusFailed || fix.Reason == "" {
+			t.Errorf("unexpected fix %+v", fix)
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("fixes = %v, want %v", got, want)
+	}
+	if logo, _ := d.logoRepo.GetBySymbol(ctx, "STUCK"); logo.Status != model.StatusPending {
+		t.Error("a dry run must not change anything")
+	}
+
+	report, err = d.svc.FixStatus(ctx, FixStatusOptions{PendingAge: time.Hour})
+	if err != nil {
+		t.Fatalf("FixStatus: %v", err)
+	}
+	if len(report.Fixes) != len(want) {
+		t.Errorf("expected %d fixes, got %+v", len(want), report.Fixes)
+	}
+	for symbol := range want {
+		logo, err := d.logoRepo.GetBySymbol(ctx, symbol)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if logo.Status != model.StatusFailed || logo.ErrorMessage == nil || *logo.ErrorMessage == "" {
+			t.Errorf("%s not normalized: %s, %v", symbol, logo.Status, logo.ErrorMessage)
+		}
+	}
+	if logo, _ := d.logoRepo.GetBySymbol(ctx, "LOUD"); *logo.ErrorMessage != errMsg {
+		t.Errorf("a failed logo with a message must keep it, got %q", *logo.ErrorMessage)
+	}
+	entries, err := d.audit.List(ctx, "", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != len(want) || entries[0].Action != model.AuditFixStatus {
+		t.Errorf("expected one audit entry per fix, got %+v", entries)
+	}
+
+	// Normalized records are consistent: nothing left to fix.
+	if report, _ := d.svc.FixStatus(ctx, FixStatusOptions{PendingAge: time.Hour}); len(report.Fixes) != 0 {
+		t.Errorf("expected nothing left to fix, got %+v", report.Fixes)
+	}
+}
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrAcquisitionLimited is returned for a cache miss turned away by the
+// request's acquisition gate (see WithAcquireGate): the logo may well
+// exist, the caller is just asking for too many uncached ones.
+var ErrAcquisitionLimited = errors.New("too many acquisitions")
+
+// acquireGateKey is the context key of the gate. An unexported type can't
+// collide with keys set by other packages.
+type acquireGateKey struct{}
+
+// WithAcquireGate returns a context under which GetLogo asks gate before
+// acquiring a logo — only then, so cache hits never do. The HTTP server
+// uses it to rate limit misses per API key more tightly than reads.
+//
+// Go note: a context value fits here because the gate belongs to one
+// request (its API key), not to the service.
+func WithAcquireGate(ctx context.Context, gate func() bool) context.Context {
+	return context.WithValue(ctx, acquireGateKey{}, gate)
+}
+
+// admitAcquisition asks the context's gate, if any, for an acquisition.
+func admitAcquisition(ctx context.Context) bool {
+	gate, ok := ctx.Value(acquireGateKey{}).(func() bool)
+	return !ok || gate()
+}
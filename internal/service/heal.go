@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"go.uber.org/zap"
+
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+// heal serves a size whose has_* flag says it's there but whose file is gone
+// (deleted by hand, lost with a disk, half-restored from a backup). The
+// flag is cleared first, so the record stops claiming the file, then the
+// sizes are rendered again from the stored original. Without an original,
+// the error sends GetLogo on to acquire the logo again, which processes a
+// logo with missing sizes as if it were new.
+func (s *LogoService) heal(ctx context.Context, logo *model.Logo, size model.LogoSize) ([]byte, error) {
+	s.logger.Warn("logo file missing, rendering it again",
+		zap.String("symbol", logo.Symbol),
+		zap.String("size", string(size)),
+	)
+	if err := s.logoRepo.SetSizeUnavailable(ctx, logo.Symbol, size); err != nil {
+		return nil, err
+	}
+	if _, err := s.renderFromOriginal(ctx, logo); err != nil {
+		return nil, fmt.Errorf("size %s of %s is missing and can't be rendered again: %w", size, logo.Symbol, err)
+	}
+	return s.fs.Read(logo.Symbol, size)
+}
+
+// VerifyStats reports what VerifyStorage found.
+type VerifyStats struct {
+	Checked     int `json:"checked"`     // Processed logos looked at
+	Flagged     int `json:"flagged"`     // Sizes on disk whose flag was missing
+	Cleared     int `json:"cleared"`     // Flags cleared because their file was missing
+	Regenerated int `json:"regenerated"` // Logos rendered again from their original
+	Missing     int `json:"missing"`     // Logos still missing sizes: no original to render them from
+}
+
+// VerifyStorage checks every processed logo's has_* flags against the files
+// on disk and corrects them, then renders the logos with missing sizes again
+// from their stored originals. Logos without one stay incomplete until
+// they're requested, which acquires them again (see heal).
+func (s *LogoService) VerifyStorage(ctx context.Context) (*VerifyStats, error) {
+	logos, err := s.logoRepo.ListByStatus(ctx, model.StatusProcessed, math.MaxInt32)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &VerifyStats{}
+	for i := range logos {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+		logo := &logos[i]
+		stats.Checked++
+
+		incomplete := false
+		for _, size := range model.AllSizes {
+			onDisk := s.fs.Exists(logo.Symbol, size)
+			switch {
+			case onDisk && !logo.HasSize(size):
+				if err := s.logoRepo.SetSizeAvailable(ctx, logo.Symbol, size); err != nil {
+					return stats, err
+				}
+				stats.Flagged++
+			case !onDisk && logo.HasSize(size):
+				if err := s.logoRepo.SetSizeUnavailable(ctx, logo.Symbol, size); err != nil {
+					return stats, err
+				}
+				stats.Cleared++
+			}
+			incomplete = incomplete || !onDisk
+		}
+		if !incomplete {
+			continue
+		}
+
+		if _, err := s.renderFromOriginal(ctx, logo); err != nil {
+			s.logger.Warn("logo is missing sizes",
+				zap.String("symbol", logo.Symbol),
+				zap.Error(err),
+			)
+			stats.Missing++
+			continue
+		}
+		stats.Regenerated++
+	}
+	return stats, nil
+}
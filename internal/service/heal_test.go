@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fleveque/logo-service/internal/model"
+	"github.com/fleveque/logo-service/internal/provider"
+	"github.com/fleveque/logo-service/internal/testutil"
+)
+
+func TestGetLogo_HealsMissingFiles(t *testing.T) {
+	aapl := &provider.LogoResult{Symbol: "AAPL", ImageData: []byte("github"), Source: "github:test"}
+	d := newTestService(t, testutil.NewFakeProvider(aapl), nil, AcceptancePolicy{})
+	ctx := context.Background()
+
+	if err := d.svc.ProcessAndStore(ctx, aapl); err != nil {
+		t.Fatalf("ProcessAndStore: %v", err)
+	}
+	if err := d.fs.DeleteSymbol("AAPL"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Rendered again from the original, without asking GitHub.
+	data, err := d.svc.GetLogo(ctx, "AAPL", model.SizeM)
+	if err != nil {
+		t.Fatalf("GetLogo with the file gone: %v", err)
+	}
+	if string(data) != "github" {
+		t.Errorf("unexpected data: %q", data)
+	}
+	if calls := d.github.Calls(); len(calls) != 0 {
+		t.Errorf("expected no provider call, got %v", calls)
+	}
+	if !d.fs.Exists("AAPL", model.SizeXL) {
+		t.Error("expected every size to be rendered again")
+	}
+
+	// Without an original, the logo is acquired again.
+	if err := d.logoRepo.SetOriginalHash(ctx, "AAPL", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.fs.DeleteSymbol("AAPL"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.svc.GetLogo(ctx, "AAPL", model.SizeS); err != nil {
+		t.Fatalf("GetLogo with no original: %v", err)
+	}
+	if calls := d.github.Calls(); len(calls) != 1 {
+		t.Errorf("expected GitHub to be asked once, got %v", calls)
+	}
+	logo, err := d.logoRepo.GetBySymbol(ctx, "AAPL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if missing := logo.MissingSizes(); len(missing) != 0 || logo.Status != model.StatusProcessed {
+		t.Errorf("expected a complete processed logo, got status %s missing %v", logo.Status, missing)
+	}
+}
+
+func TestVerifyStorage(t *testing.T) {
+	d := newTestService(t, testutil.NewFakeProvider(), nil, AcceptancePolicy{})
+	ctx := context.Background()
+
+	for _, symbol := range []string{"AAPL", "MSFT", "GOOG"} {
+		if err := d.svc.ProcessAndStore(ctx, &provider.LogoResult{Symbol: symbol, ImageData: []byte(symbol), Source: "github:test"}); err != nil {
+			t.Fatalf("ProcessAndStore(%s): %v", symbol, err)
+		}
+	}
+	// MSFT lost its files but has its original; GOOG has neither.
+	if err := d.fs.DeleteSymbol("MSFT"); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.logoRepo.SetOriginalHash(ctx, "GOOG", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.fs.DeleteSymbol("GOOG"); err != nil {
+		t.Fatal(err)
+	}
+	// AAPL's L is on disk but not flagged.
+	if err := d.logoRepo.SetSizeUnavailable(ctx, "AAPL", model.SizeL); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := d.svc.VerifyStorage(ctx)
+	if err != nil {
+		t.Fatalf("VerifyStorage: %v", err)
+	}
+	want := VerifyStats{Checked: 3, Flagged: 1, Cleared: 10, Regenerated: 1, Missing: 1}
+	if *stats != want {
+		t.Errorf("got %+v, want %+v", *stats, want)
+	}
+
+	for symbol, complete := range map[string]bool{"AAPL": true, "MSFT": true, "GOOG": false} {
+		logo, err := d.logoRepo.GetBySymbol(ctx, symbol)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := len(logo.MissingSizes()) == 0; got != complete {
+			t.Errorf("%s: complete = %v, want %v (missing %v)", symbol, got, complete, logo.MissingSizes())
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/h2non/bimg"
 
+	"github.com/fleveque/logo-service/internal/imagefmt"
 	"github.com/fleveque/logo-service/internal/model"
 	"github.com/fleveque/logo-service/internal/storage"
 )
@@ -15,20 +16,35 @@ import (
 // It uses bimg (Go bindings for libvips) — a C library that's extremely fast
 // at image manipulation. The trade-off: requires libvips as a system dependency.
 type ImageProcessor struct {
-	fs *storage.FileSystem
+	fs       *storage.FileSystem
+	maxBytes int // Largest input, see SetMaxBytes
 }
 
 // NewImageProcessor creates a new ImageProcessor.
 func NewImageProcessor(fs *storage.FileSystem) *ImageProcessor {
-	return &ImageProcessor{fs: fs}
+	return &ImageProcessor{fs: fs, maxBytes: imagefmt.MaxBytes}
 }
 
-// ProcessAll takes raw image bytes (any format bimg supports: PNG, JPEG, SVG, WebP)
-// and creates resized PNGs for all sizes, saving them to the filesystem.
+// SetMaxBytes replaces imagefmt.MaxBytes as the largest input processed,
+// to match the download limit (processing.max_fetch_size): nothing fetched
+// is rejected afterwards for its size. Call it before the processor is in use.
+func (p *ImageProcessor) SetMaxBytes(n int) {
+	p.maxBytes = n
+}
+
+// ProcessAll takes raw image bytes (PNG, JPEG, GIF, SVG, WebP, TIFF, ICO, BMP,
+// and HEIC where libvips supports it) and creates resized PNGs for all sizes,
+// saving them to the filesystem. opts.WhitenBackground removes a white
+// background first (see whitenBackground).
 //
 // Go note: returning a map lets the caller know which sizes succeeded.
 // We process all sizes even if some fail, collecting errors along the way.
-func (p *ImageProcessor) ProcessAll(symbol string, imageData []byte) (map[model.LogoSize]bool, error) {
+func (p *ImageProcessor) ProcessAll(symbol string, imageData []byte, opts model.ProcessOptions) (map[model.LogoSize]bool, error) {
+	imageData, err := prepare(imageData, p.maxBytes, opts)
+	if err != nil {
+		return nil, err
+	}
+
 	results := make(map[model.LogoSize]bool)
 	var errs []string
 
@@ -57,6 +73,60 @@ func (p *ImageProcessor) ProcessAll(symbol string, imageData []byte) (map[model.
 	return results, nil
 }
 
+// prepare readies raw image bytes for resizing: validated and converted
+// (see prepareInput), then whitened if opts asks for it.
+func prepare(imageData []byte, maxBytes int, opts model.ProcessOptions) ([]byte, error) {
+	// Reject junk (HTML error pages, truncated downloads, decompression bombs)
+	// in pure Go — libvips is C, and a bad input there can take the process down.
+	imageData, err := prepareInput(imageData, maxBytes)
+	if err != nil {
+		return nil, fmt.Errorf("rejecting image: %w", err)
+	}
+
+	if opts.WhitenBackground {
+		if imageData, err = whitenBackground(imageData); err != nil {
+			return nil, fmt.Errorf("whitening background: %w", err)
+		}
+	}
+	return imageData, nil
+}
+
+// prepareInput validates an image and converts it to something libvips can
+// load. The errors end up in the logo's error_message, so they say what was
+// wrong with the input rather than surfacing an opaque libvips failure.
+// Inputs over maxBytes are rejected.
+func prepareInput(data []byte, maxBytes int) ([]byte, error) {
+	format, err := imagefmt.Validate(data, maxBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case imagefmt.SVG:
+		// Originals kept before sanitizing existed may still need it.
+		clean, err := imagefmt.SanitizeSVG(data)
+		if err != nil {
+			return nil, err
+		}
+		return clean, nil
+	case imagefmt.ICO, imagefmt.BMP:
+		converted, err := imagefmt.ToPNG(data, format)
+		if err != nil {
+			return nil, fmt.Errorf("converting %s: %w", format, err)
+		}
+		// An embedded PNG entry hasn't been checked yet.
+		if _, err := imagefmt.Validate(converted, maxBytes); err != nil {
+			return nil, fmt.Errorf("converted %s: %w", format, err)
+		}
+		return converted, nil
+	case imagefmt.HEIC:
+		if !bimg.IsTypeSupported(bimg.HEIF) {
+			return nil, fmt.Errorf("%w: heic (libvips was built without HEIF support)", imagefmt.ErrUnsupported)
+		}
+	}
+	return data, nil
+}
+
 // resizeToSquarePNG resizes an image to a square PNG of the given pixel size.
 // bimg.Options is a struct with many fields — this is Go's alternative to
 // builder patterns or method chaining. You set only the fields you need.
@@ -85,7 +155,8 @@ func resizeToSquarePNG(imageData []byte, pixels int) ([]byte, error) {
 
 // ApplyBackground takes a PNG and flattens the alpha channel onto a solid
 // background color. This is used at request time when the `bg` query param
-// is provided — the cached transparent PNG gets a background on the fly.
+// is provided — the cached transparent PNG gets a background on the fly, and
+// LogoService.GetLogoWithBackground caches the result as a variant.
 //
 // Go note: hex color parsing is done manually here. In Go, you often write
 // small utility functions instead of pulling in a library for simple tasks.
@@ -103,6 +174,14 @@ func ApplyBackground(imageData []byte, hexColor string) ([]byte, error) {
 	})
 }
 
+// EncodeWebP re-encodes a PNG as WebP, losslessly: the same pixels and
+// transparency in fewer bytes, for clients that accept it. Logos are
+// stored as PNG only, so this runs on every WebP response; HTTP caches
+// (Vary: Accept) are what keep it cheap.
+func EncodeWebP(png []byte) ([]byte, error) {
+	return bimg.NewImage(png).Process(bimg.Options{Type: bimg.WEBP, Lossless: true})
+}
+
 // parseHexColor converts a hex color string (with or without #) to RGB values.
 // Go's fmt.Sscanf is like C's scanf — it parses formatted strings.
 func parseHexColor(hex string) (uint8, uint8, uint8, error) {
@@ -112,6 +191,14 @@ func parseHexColor(hex string) (uint8, uint8, uint8, error) {
 		return 0, 0, 0, fmt.Errorf("invalid hex color: %q (expected 6 characters)", hex)
 	}
 
+	// Sscanf alone is too lenient: "%02x" skips leading spaces and accepts
+	// signs, so " 1ffff" would parse. Require exactly six hex digits first.
+	for _, c := range hex {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return 0, 0, 0, fmt.Errorf("invalid hex color: %q (non-hex character %q)", hex, c)
+		}
+	}
+
 	var r, g, b uint8
 	_, err := fmt.Sscanf(hex, "%02x%02x%02x", &r, &g, &b)
 	if err != nil {
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fleveque/logo-service/internal/model"
+	"github.com/fleveque/logo-service/internal/storage"
+)
+
+// Golden-image tests pin down what ImageProcessor produces for real-world
+// tricky inputs, so a libvips (or bimg) upgrade can't silently change renditions.
+//
+// Fixtures live in testdata/fixtures; expected outputs in testdata/golden.
+// After an intentional rendering change, regenerate the goldens with:
+//
+//	go test ./internal/service -run TestProcessAll_Golden -update
+//
+// Go note: flag.Bool in a test file registers a flag on the test binary;
+// `go test` passes unknown flags after the package list through to it.
+var updateGolden = flag.Bool("update", false, "rewrite golden images in testdata/golden")
+
+// goldenTolerance is the mean per-channel difference (0–255) we accept between
+// a rendition and its golden. Resampling kernels shift edge pixels slightly
+// between libvips releases; anything above this is a visible change.
+const goldenTolerance = 3.0
+
+// pixelCheck asserts a color property at a relative position (0–1) in the m rendition.
+type pixelCheck struct {
+	x, y  float64
+	check func(r, g, b, a uint8) bool
+	desc  string
+}
+
+var processorFixtures = []struct {
+	file   string
+	checks []pixelCheck
+}{
+	{
+		// Adam7-interlaced PNGs decode through a different libpng path.
+		file: "interlaced.png",
+		checks: []pixelCheck{
+			{0.5, 0.5, func(r, g, b, a uint8) bool { return r > 150 && g < 100 && a > 200 }, "opaque red center"},
+		},
+	},
+	{
+		// Palette PNG whose transparency lives in a tRNS chunk, not an alpha channel.
+		file: "palette_transparent.png",
+		checks: []pixelCheck{
+			{0, 0, func(r, g, b, a uint8) bool { return a < 16 }, "transparent corner"},
+			{0.7, 0.5, func(r, g, b, a uint8) bool { return r > 200 && g > 150 && b < 80 && a > 200 }, "opaque yellow"},
+		},
+	},
+	{
+		// CMYK JPEG with an Adobe marker (inverted ink values). The classic bug
+		// is rendering it as a color negative.
+		file: "cmyk.jpg",
+		checks: []pixelCheck{
+			{0.5, 0.5, func(r, g, b, a uint8) bool { return r > 150 && b < 100 }, "orange, not inverted"},
+		},
+	},
+	{
+		// SVG with only a viewBox — no intrinsic width/height.
+		file: "viewbox_only.svg",
+		checks: []pixelCheck{
+			{0.5, 0.5, func(r, g, b, a uint8) bool { return r > 200 && g > 200 && b > 200 && a > 200 }, "white circle center"},
+		},
+	},
+	{
+		// 1×1 transparent GIF — what "logo" URLs sometimes turn out to be.
+		// No color checks: it only has to come out well-formed.
+		file: "tracking_pixel.gif",
+	},
+}
+
+func TestProcessAll_Golden(t *testing.T) {
+	for _, fx := range processorFixtures {
+		t.Run(fx.file, func(t *testing.T) {
+			input, err := os.ReadFile(filepath.Join("testdata", "fixtures", fx.file))
+			if err != nil {
+				t.Fatalf("reading fixture: %v", err)
+			}
+
+			fs, err := storage.NewFileSystem(t.TempDir(), storage.LayoutHash)
+			if err != nil {
+				t.Fatalf("creating filesystem: %v", err)
+			}
+
+			results, err := NewImageProcessor(fs).ProcessAll("FIXTURE", input, model.ProcessOptions{})
+			if err != nil {
+				t.Fatalf("ProcessAll failed: %v", err)
+			}
+
+			name := strings.TrimSuffix(fx.file, filepath.Ext(fx.file))
+			for _, size := range model.AllSizes {
+				if !results[size] {
+					t.Errorf("size %s failed", size)
+					continue
+				}
+				data, err := fs.Read("FIXTURE", size)
+				if err != nil {
+					t.Fatalf("reading size %s: %v", size, err)
+				}
+
+				img := decodeRendition(t, data, size)
+				if size == model.SizeM {
+					for _, c := range fx.checks {
+						r, g, b, a := pixelAt(img, c.x, c.y)
+						if !c.check(r, g, b, a) {
+							t.Errorf("%s: got rgba(%d,%d,%d,%d) at (%.1f,%.1f)", c.desc, r, g, b, a, c.x, c.y)
+						}
+					}
+				}
+
+				compareGolden(t, filepath.Join("testdata", "golden", fmt.Sprintf("%s_%s.png", name, size)), data, img)
+			}
+		})
+	}
+}
+
+// decodeRendition checks the contract every rendition must meet: a PNG,
+// exactly the requested square size. Decoded with the standard library, not
+// libvips, so we're not asking libvips to vouch for its own output.
+func decodeRendition(t *testing.T, data []byte, size model.LogoSize) image.Image {
+	t.Helper()
+
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("size %s is not a valid PNG: %v", size, err)
+	}
+	px := model.SizePixels[size]
+	if b := img.Bounds(); b.Dx() != px || b.Dy() != px {
+		t.Fatalf("size %s: expected %dx%d, got %dx%d", size, px, px, b.Dx(), b.Dy())
+	}
+	return img
+}
+
+// compareGolden compares a rendition to its golden file, or rewrites the golden with -update.
+// A missing golden fails: otherwise deleting one would make a changed
+// rendition pass. New fixtures land with their goldens.
+func compareGolden(t *testing.T, goldenPath string, data []byte, img image.Image) {
+	t.Helper()
+
+	if *updateGolden {
+		if err := os.MkdirAll(filepath.Dir(goldenPath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(goldenPath, data, 0644); err != nil {
+			t.Fatalf("writing golden: %v", err)
+		}
+		return
+	}
+
+	golden, err := os.ReadFile(goldenPath)
+	if os.IsNotExist(err) {
+		t.Fatalf("no golden at %s — run with -update to create it", goldenPath)
+	}
+	if err != nil {
+		t.Fatalf("reading golden: %v", err)
+	}
+
+	want, err := png.Decode(bytes.NewReader(golden))
+	if err != nil {
+		t.Fatalf("decoding golden %s: %v", goldenPath, err)
+	}
+
+	if diff := meanChannelDiff(img, want); diff > goldenTolerance {
+		t.Errorf("%s: rendition differs from golden by %.2f (tolerance %.1f)", goldenPath, diff, goldenTolerance)
+	}
+}
+
+// meanChannelDiff returns the mean absolute difference across all RGBA channels
+// of two same-sized images, on a 0–255 scale. Different sizes count as maximal.
+func meanChannelDiff(a, b image.Image) float64 {
+	ab, bb := a.Bounds(), b.Bounds()
+	if ab.Dx() != bb.Dx() || ab.Dy() != bb.Dy() {
+		return 255
+	}
+
+	var total float64
+	for y := 0; y < ab.Dy(); y++ {
+		for x := 0; x < ab.Dx(); x++ {
+			r1, g1, b1, a1 := a.At(ab.Min.X+x, ab.Min.Y+y).RGBA()
+			r2, g2, b2, a2 := b.At(bb.Min.X+x, bb.Min.Y+y).RGBA()
+			// RGBA() returns 16-bit values; >>8 brings them back to 0–255.
+			total += absDiff(r1>>8, r2>>8) + absDiff(g1>>8, g2>>8) + absDiff(b1>>8, b2>>8) + absDiff(a1>>8, a2>>8)
+		}
+	}
+	return total / float64(ab.Dx()*ab.Dy()*4)
+}
+
+func absDiff(a, b uint32) float64 {
+	if a > b {
+		return float64(a - b)
+	}
+	return float64(b - a)
+}
+
+// pixelAt returns the non-premultiplied color at a relative position.
+func pixelAt(img image.Image, fx, fy float64) (r, g, b, a uint8) {
+	bounds := img.Bounds()
+	x := bounds.Min.X + int(fx*float64(bounds.Dx()-1))
+	y := bounds.Min.Y + int(fy*float64(bounds.Dy()-1))
+	c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+	return c.R, c.G, c.B, c.A
+}
@@ -2,13 +2,20 @@ package service
 
 import (
 	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
 	"image"
 	"image/color"
 	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/h2non/bimg"
 
+	"github.com/fleveque/logo-service/internal/imagefmt"
 	"github.com/fleveque/logo-service/internal/model"
 	"github.com/fleveque/logo-service/internal/storage"
 )
@@ -33,7 +40,7 @@ func createTestPNG(width, height int, c color.Color) []byte {
 
 func TestProcessAll(t *testing.T) {
 	tmpDir := t.TempDir()
-	fs, err := storage.NewFileSystem(tmpDir)
+	fs, err := storage.NewFileSystem(tmpDir, storage.LayoutHash)
 	if err != nil {
 		t.Fatalf("creating filesystem: %v", err)
 	}
@@ -43,7 +50,7 @@ func TestProcessAll(t *testing.T) {
 	// Create a 256x256 red test image
 	testImage := createTestPNG(256, 256, color.RGBA{R: 255, G: 0, B: 0, A: 255})
 
-	results, err := processor.ProcessAll("TEST", testImage)
+	results, err := processor.ProcessAll("TEST", testImage, model.ProcessOptions{})
 	if err != nil {
 		t.Fatalf("ProcessAll failed: %v", err)
 	}
@@ -83,7 +90,7 @@ func TestProcessAll(t *testing.T) {
 
 func TestProcessAll_NonSquareImage(t *testing.T) {
 	tmpDir := t.TempDir()
-	fs, err := storage.NewFileSystem(tmpDir)
+	fs, err := storage.NewFileSystem(tmpDir, storage.LayoutHash)
 	if err != nil {
 		t.Fatalf("creating filesystem: %v", err)
 	}
@@ -93,7 +100,7 @@ func TestProcessAll_NonSquareImage(t *testing.T) {
 	// Create a rectangular image (wider than tall)
 	testImage := createTestPNG(400, 200, color.RGBA{R: 0, G: 0, B: 255, A: 255})
 
-	results, err := processor.ProcessAll("RECT", testImage)
+	results, err := processor.ProcessAll("RECT", testImage, model.ProcessOptions{})
 	if err != nil {
 		t.Fatalf("ProcessAll failed: %v", err)
 	}
@@ -140,6 +147,45 @@ func TestApplyBackground_WithHash(t *testing.T) {
 	}
 }
 
+// TestPrepareInput covers the pure-Go conversions that run before libvips.
+func TestPrepareInput(t *testing.T) {
+	logo := createTestPNG(48, 48, color.NRGBA{R: 255, A: 255})
+
+	// A one-entry favicon embedding the PNG.
+	ico := []byte{0, 0, 1, 0, 1, 0, 48, 48, 0, 0, 1, 0, 32, 0}
+	ico = binary.LittleEndian.AppendUint32(ico, uint32(len(logo)))
+	ico = binary.LittleEndian.AppendUint32(ico, 22)
+	ico = append(ico, logo...)
+
+	got, err := prepareInput(ico, imagefmt.MaxBytes)
+	if err != nil {
+		t.Fatalf("prepareInput(ico): %v", err)
+	}
+	if !bytes.Equal(got, logo) {
+		t.Error("expected the ICO's PNG entry")
+	}
+
+	if got, err := prepareInput(logo, imagefmt.MaxBytes); err != nil || !bytes.Equal(got, logo) {
+		t.Errorf("PNG should pass through unchanged, got err %v", err)
+	}
+
+	_, err = prepareInput([]byte("<html>Not Found</html>"), imagefmt.MaxBytes)
+	if !errors.Is(err, imagefmt.ErrUnsupported) {
+		t.Errorf("expected ErrUnsupported for HTML, got %v", err)
+	}
+
+	got, err = prepareInput([]byte(`<svg><script>alert(1)</script></svg>`), imagefmt.MaxBytes)
+	if err != nil || string(got) != "<svg></svg>" {
+		t.Errorf("expected the SVG sanitized, got %q, %v", got, err)
+	}
+	if _, err := prepareInput([]byte(`<svg><g></svg>`), imagefmt.MaxBytes); !errors.Is(err, imagefmt.ErrUnsafeSVG) {
+		t.Errorf("expected ErrUnsafeSVG, got %v", err)
+	}
+	if _, err := prepareInput(logo, len(logo)-1); !errors.Is(err, imagefmt.ErrTooLarge) {
+		t.Errorf("expected ErrTooLarge past the limit, got %v", err)
+	}
+}
+
 func TestParseHexColor(t *testing.T) {
 	// Go table-driven tests: define test cases as a slice of structs,
 	// then loop over them. This is the idiomatic way to test multiple inputs.
@@ -179,3 +225,96 @@ func TestParseHexColor(t *testing.T) {
 		})
 	}
 }
+
+// FuzzParseHexColor checks that any accepted color round-trips exactly:
+// whatever parseHexColor accepts must be six hex digits, nothing looser.
+// Run with: go test ./internal/service -fuzz FuzzParseHexColor
+func FuzzParseHexColor(f *testing.F) {
+	for _, seed := range []string{"ffffff", "#00ff00", "aaBBcc", "fff", "gggggg", "+1ffff", " 1ffff"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, hex string) {
+		r, g, b, err := parseHexColor(hex)
+		if err != nil {
+			return
+		}
+		want := strings.ToLower(strings.TrimPrefix(hex, "#"))
+		if got := fmt.Sprintf("%02x%02x%02x", r, g, b); got != want {
+			t.Fatalf("parseHexColor(%q) accepted, but (%d,%d,%d) formats as %q", hex, r, g, b, got)
+		}
+	})
+}
+
+// FuzzProcessAll throws arbitrary bytes at the processor entry point. Inputs
+// imagefmt rejects must fail before libvips is called and leave nothing on disk;
+// everything else must be handled by libvips without crashing the process.
+// Run with: go test ./internal/service -fuzz FuzzProcessAll
+func FuzzProcessAll(f *testing.F) {
+	f.Add(createTestPNG(8, 8, color.RGBA{R: 255, A: 255}))
+	f.Add([]byte("<html>Not Found</html>"))
+	f.Add([]byte("\x89PNG\r\n\x1a\n"))
+	if fixtures, err := filepath.Glob(filepath.Join("testdata", "fixtures", "*")); err == nil {
+		for _, path := range fixtures {
+			if data, err := os.ReadFile(path); err == nil {
+				f.Add(data)
+			}
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		fs, err := storage.NewFileSystem(t.TempDir(), storage.LayoutHash)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = NewImageProcessor(fs).ProcessAll("FUZZ", data, model.ProcessOptions{})
+		if _, invalid := imagefmt.Validate(data, imagefmt.MaxBytes); invalid != nil {
+			if err == nil {
+				t.Fatal("ProcessAll accepted input imagefmt rejects")
+			}
+			if fs.Exists("FUZZ", model.SizeM) {
+				t.Fatal("rejected input left files on disk")
+			}
+		}
+	})
+}
+
+// Benchmarks: go test ./internal/service -run '^$' -bench . -benchmem
+//
+// Go note: b.Loop (Go 1.24) runs the body b.N times and keeps setup above it
+// out of the measurement, replacing the older `for i := 0; i < b.N; i++`.
+func BenchmarkProcessAll(b *testing.B) {
+	fs, err := storage.NewFileSystem(b.TempDir(), storage.LayoutHash)
+	if err != nil {
+		b.Fatalf("creating filesystem: %v", err)
+	}
+	processor := NewImageProcessor(fs)
+	input := createTestPNG(512, 512, color.NRGBA{R: 200, G: 80, B: 20, A: 255})
+
+	for b.Loop() {
+		if _, err := processor.ProcessAll("BENCH", input, model.ProcessOptions{}); err != nil {
+			b.Fatalf("ProcessAll failed: %v", err)
+		}
+	}
+}
+
+func BenchmarkApplyBackground(b *testing.B) {
+	input := createTestPNG(256, 256, color.NRGBA{R: 255, A: 128})
+
+	for b.Loop() {
+		if _, err := ApplyBackground(input, "ffffff"); err != nil {
+			b.Fatalf("ApplyBackground failed: %v", err)
+		}
+	}
+}
+
+func TestEncodeWebP(t *testing.T) {
+	webp, err := EncodeWebP(createTestPNG(64, 64, color.NRGBA{R: 255, A: 128}))
+	if err != nil {
+		t.Fatalf("EncodeWebP failed: %v", err)
+	}
+	if format := imagefmt.Detect(webp); format != imagefmt.WebP {
+		t.Errorf("expected webp, got %q", format)
+	}
+}
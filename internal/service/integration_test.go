@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"image/color"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/fleveque/logo-service/internal/llm"
+	"github.com/fleveque/logo-service/internal/model"
+	"github.com/fleveque/logo-service/internal/provider"
+	"github.com/fleveque/logo-service/internal/storage"
+	"github.com/fleveque/logo-service/internal/testutil"
+)
+
+// These tests run the real pipeline — GitHubProvider, LLMProvider with the
+// OpenAI client, ImageProcessor (libvips), SQLite and the filesystem — against
+// fake GitHub and OpenAI servers, so no network access or API keys are needed.
+
+const testRepo = "acme/ticker-logos"
+
+type pipeline struct {
+	svc      *LogoService
+	gh       *provider.GitHubProvider
+	github   *testutil.FakeGitHub
+	openai   *testutil.FakeOpenAI
+	logoRepo storage.LogoRepository
+}
+
+func newPipeline(t *testing.T) *pipeline {
+	t.Helper()
+
+	logger := zap.NewNop()
+	db := testutil.NewDB(t)
+	fs := testutil.NewFileSystem(t)
+
+	p := &pipeline{
+		github:   testutil.NewFakeGitHub(t),
+		openai:   testutil.NewFakeOpenAI(t),
+		logoRepo: storage.NewLogoRepository(db),
+	}
+
+	p.gh = provider.NewGitHubProvider([]string{testRepo}, logger)
+	p.gh.SetBaseURLs(p.github.RawBaseURL(), p.github.APIBaseURL())
+
+	client := llm.NewOpenAIClient("test-key", "gpt-test", llm.OpenAIOptions{BaseURL: p.openai.BaseURL()})
+	llmProvider := provider.NewLLMProvider([]llm.Client{client}, 6000, storage.NewLLMCallRepository(db), logger)
+
+	p.svc = NewLogoService(p.logoRepo, storage.NewRequestedSymbolRepository(db), storage.NewInstrumentRepository(db),
+		fs, NewImageProcessor(fs), p.gh, WithLLMProvider(llmProvider), WithLogger(logger))
+	return p
+}
+
+func TestPipeline_GetLogoFromGitHub(t *testing.T) {
+	p := newPipeline(t)
+	p.github.AddLogo(testRepo, "AAPL", createTestPNG(300, 300, color.NRGBA{R: 255, A: 255}))
+	p.github.SetLicense(testRepo, "MIT")
+	ctx := context.Background()
+
+	data, err := p.svc.GetLogo(ctx, "AAPL", model.SizeS)
+	if err != nil {
+		t.Fatalf("GetLogo failed: %v", err)
+	}
+	if len(data) == 0 {
+		t.Fatal("expected image data")
+	}
+	if p.openai.Requests() != 0 {
+		t.Errorf("LLM should not be asked on a GitHub hit, got %d requests", p.openai.Requests())
+	}
+
+	logo, err := p.logoRepo.GetBySymbol(ctx, "AAPL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if logo.Source != "github:"+testRepo || !logo.HasXL {
+		t.Errorf("unexpected record: source=%s has_xl=%v", logo.Source, logo.HasXL)
+	}
+	if logo.License != "MIT" || logo.Attribution != "github.com/"+testRepo {
+		t.Errorf("unexpected license: license=%q attribution=%q", logo.License, logo.Attribution)
+	}
+}
+
+func TestPipeline_GetLogoFallsBackToLLM(t *testing.T) {
+	p := newPipeline(t)
+	p.openai.AddLogo("SAN.MC", "Banco Santander", model.ConfidenceHigh, createTestPNG(200, 100, color.NRGBA{B: 255, A: 255}))
+	ctx := context.Background()
+
+	if _, err := p.svc.GetLogo(ctx, "SAN.MC", model.SizeM); err != nil {
+		t.Fatalf("GetLogo failed: %v", err)
+	}
+	if p.openai.Requests() != 1 {
+		t.Errorf("expected 1 LLM request, got %d", p.openai.Requests())
+	}
+
+	logo, err := p.logoRepo.GetBySymbol(ctx, "SAN.MC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if logo.CompanyName != "Banco Santander" || logo.Confidence != model.ConfidenceHigh {
+		t.Errorf("unexpected record: name=%q confidence=%q", logo.CompanyName, logo.Confidence)
+	}
+}
+
+func TestPipeline_GetLogoNotFoundAnywhere(t *testing.T) {
+	p := newPipeline(t)
+
+	if _, err := p.svc.GetLogo(context.Background(), "NOPE", model.SizeM); err == nil {
+		t.Fatal("expected an error when neither GitHub nor the LLM has the logo")
+	}
+}
+
+func TestPipeline_BulkImport(t *testing.T) {
+	p := newPipeline(t)
+	for _, symbol := range []string{"AAPL", "MSFT", "BRK.B"} {
+		p.github.AddLogo(testRepo, symbol, createTestPNG(64, 64, color.NRGBA{G: 255, A: 255}))
+	}
+	p.github.AddFile(testRepo, "README.md", []byte("not a logo"))
+	ctx := context.Background()
+
+	stats, err := p.gh.BulkImport(ctx, func(result *provider.LogoResult) error {
+		return p.svc.ProcessAndStore(ctx, result)
+	})
+	if err != nil {
+		t.Fatalf("BulkImport failed: %v", err)
+	}
+	if stats.Imported != 3 || stats.Failed != 0 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+
+	for _, symbol := range []string{"AAPL", "MSFT", "BRK.B"} {
+		if _, err := p.svc.GetLogo(ctx, symbol, model.SizeXS); err != nil {
+			t.Errorf("%s not served after import: %v", symbol, err)
+		}
+	}
+}
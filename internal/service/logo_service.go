@@ -10,47 +10,240 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
 	"errors"
 	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
 
 	"go.uber.org/zap"
 
+	"github.com/fleveque/logo-service/internal/clock"
+	"github.com/fleveque/logo-service/internal/flags"
+	"github.com/fleveque/logo-service/internal/flight"
+	"github.com/fleveque/logo-service/internal/imagefmt"
 	"github.com/fleveque/logo-service/internal/model"
+	"github.com/fleveque/logo-service/internal/moderation"
+	"github.com/fleveque/logo-service/internal/phash"
 	"github.com/fleveque/logo-service/internal/provider"
 	"github.com/fleveque/logo-service/internal/storage"
 )
 
+// ErrPendingReview is returned when a logo exists but is held in the review
+// queue — it has been processed but isn't served until an admin approves it.
+var ErrPendingReview = errors.New("logo is pending review")
+
+// ErrWrongStatus is returned by the review and requeue actions for a logo
+// whose status doesn't allow them, e.g. approving one that isn't in review.
+var ErrWrongStatus = errors.New("logo is in the wrong status")
+
+// ErrInvalidMetadata is returned when a metadata correction has a value that
+// can't be stored, e.g. a website that isn't a URL.
+var ErrInvalidMetadata = errors.New("invalid metadata")
+
+// ErrLowConfidence is returned when a provider result is rejected because its
+// confidence is below the configured minimum.
+var ErrLowConfidence = errors.New("logo confidence below minimum")
+
+// ErrInvalidLogo is returned when a provider result is rejected by the
+// service's LogoValidator (see WithValidator).
+var ErrInvalidLogo = errors.New("logo failed validation")
+
+// ErrAcquisitionPending is returned while a logo is being acquired, either by
+// a concurrent request or an import: it exists, just not yet. Clients should
+// retry shortly rather than treat it as missing.
+var ErrAcquisitionPending = errors.New("logo acquisition in progress")
+
+// ErrAcquisitionDisabled is returned for a cache miss while the
+// on_demand_acquisition flag is off: only logos already in the catalog are
+// served until it's turned back on.
+var ErrAcquisitionDisabled = errors.New("on-demand acquisition is disabled")
+
+// ErrMaintenance is returned for a cache miss while the maintenance_mode
+// flag is on: cached logos are served, nothing new is acquired or stored.
+var ErrMaintenance = errors.New("service is under maintenance")
+
+// pendingTimeout is how long a pending record counts as "being acquired".
+// Older ones were left behind by a crash, and are acquired again.
+const pendingTimeout = 10 * time.Minute
+
+// ErrLogoNotFound is returned when no provider has a logo for the symbol,
+// and, until its next check, for every request after that.
+var ErrLogoNotFound = errors.New("no provider found a logo")
+
+// ErrUnchanged is returned by ReplaceLogo when the logo is already
+// processed from the very same image. Its message says "already exists", so
+// bulk imports count it as skipped.
+var ErrUnchanged = errors.New("logo already exists with this image")
+
+// ErrInvalidColor is returned for a background color that isn't six hex digits.
+var ErrInvalidColor = errors.New("invalid background color")
+
+// Actions for results below the minimum confidence.
+const (
+	LowConfidenceReview = "review" // Process it, but hold it in the review queue
+	LowConfidenceReject = "reject" // Discard it and mark the logo as failed
+)
+
+// AcceptancePolicy decides what happens to provider results based on their
+// reported confidence. Results without a confidence (e.g. GitHub) always pass.
+type AcceptancePolicy struct {
+	MinConfidence       string // "low", "medium" or "high"
+	LowConfidenceAction string // LowConfidenceReview or LowConfidenceReject
+
+	// RequireKnownSymbol skips the (paid) LLM layer for symbols that aren't in
+	// the instruments reference table. Ignored until the table has been imported.
+	RequireKnownSymbol bool
+
+	// NotFoundRecheck is how long a symbol no provider had a logo for is
+	// answered with ErrLogoNotFound before the providers are asked again.
+	// Zero asks them on every request.
+	NotFoundRecheck time.Duration
+
+	// InvalidRecheck is the same for a symbol whose image was found but
+	// rejected or failed processing; zero acquires it again on every request.
+	InvalidRecheck time.Duration
+
+	// BudgetRecheck is the same for a symbol the free layers didn't have
+	// while every LLM client was disabled (the budget freeze, see
+	// provider.ErrDisabled). Zero doesn't remember those misses at all.
+	BudgetRecheck time.Duration
+}
+
+// accepts reports whether a result with the given confidence can go live.
+func (p AcceptancePolicy) accepts(confidence string) bool {
+	if confidence == "" || p.MinConfidence == "" {
+		return true
+	}
+	return model.ConfidenceAtLeast(confidence, p.MinConfidence)
+}
+
+// LogoFetcher is the GitHub layer: a free, exact lookup by symbol.
+// *provider.GitHubProvider satisfies it.
+//
+// Go note: interfaces are declared where they're used, not where they're
+// implemented. The service asks for only the method it calls, so tests can
+// swap in a fake without touching the provider package.
+type LogoFetcher interface {
+	GetLogo(ctx context.Context, symbol string) (*provider.LogoResult, error)
+}
+
+// LogoSearcher is the LLM layer: a paid search that benefits from the company name.
+// *provider.LLMProvider satisfies it.
+type LogoSearcher interface {
+	FindLogo(ctx context.Context, symbol, companyName string) (*provider.LogoResult, error)
+}
+
+// LogoProcessor renders a source image into every size and stores the files.
+// *ImageProcessor satisfies it.
+type LogoProcessor interface {
+	ProcessAll(symbol string, imageData []byte, opts model.ProcessOptions) (map[model.LogoSize]bool, error)
+}
+
+// SpaceChecker reports whether there's room to store new logos.
+// *storage.DiskMonitor satisfies it.
+type SpaceChecker interface {
+	Check() error
+}
+
+// LogoValidator checks a provider result before it's processed and stored,
+// e.g. against a minimum resolution or sources a deployment doesn't trust.
+// An error rejects the result: the logo is marked failed with it.
+type LogoValidator interface {
+	ValidateLogo(ctx context.Context, result *provider.LogoResult) error
+}
+
+// FlagChecker tells which risky behaviors are switched on.
+// *flags.Set satisfies it.
+type FlagChecker interface {
+	Enabled(name flags.Name) bool
+}
+
 // LogoService is the main entry point for logo retrieval.
 // It implements a "try cache first, then acquire" pattern that's common
 // in Go services — check the fast path (local cache), fall back to slower
 // external calls only when needed.
 type LogoService struct {
-	logoRepo    storage.LogoRepository
-	fs          *storage.FileSystem
-	processor   *ImageProcessor
-	ghProvider  *provider.GitHubProvider
-	llmProvider *provider.LLMProvider // nil if no LLM keys configured
-	logger      *zap.Logger
+	logoRepo       storage.LogoRepository
+	requestedRepo  storage.RequestedSymbolRepository
+	instrumentRepo storage.InstrumentRepository
+	fs             *storage.FileSystem
+	processor      LogoProcessor
+	ghProvider     LogoFetcher
+	indexProvider  LogoFetcher  // nil: no curated index configured
+	llmProvider    LogoSearcher // nil if no LLM keys configured
+	policy         AcceptancePolicy
+	validator      LogoValidator        // nil: results are only checked by the processor
+	moderator      moderation.Moderator // nil: logos go live unscreened
+	moderation     ModerationPolicy
+	space          SpaceChecker                // nil: never refuse for lack of space
+	variants       *VariantCache               // nil: variants are rendered on every request
+	blocklist      storage.BlocklistRepository // nil: nothing is blocked
+	audit          storage.AuditRepository     // nil: admin actions aren't recorded
+	events         storage.EventRepository     // nil: no changes feed
+	catalog        *storage.Catalog            // nil: every lookup queries the database
+	batches        *flight.Group[[]CachedLogo] // nil: every batch reads each logo
+	originals      storage.OriginalRepository  // nil: source images aren't kept
+	processing     model.ProcessOptions        // Defaults; a logo's own settings override them
+	circuitPolicy  CircuitPolicy               // Zero: providers are always asked
+	strategy       string                      // StrategySequential (also "") or StrategyRace
+	attempts       storage.AttemptRepository   // nil: acquisition attempts aren't recorded
+	flags          FlagChecker                 // nil: every flag at its default
+	retention      time.Duration               // How long deleted logos can be restored
+	clock          clock.Clock
+	logger         *zap.Logger
+
+	// statsHistory keeps daily stats snapshots (nil: none), LLM calls
+	// counted at llmCallCents each.
+	statsHistory storage.StatsHistoryRepository
+	llmCallCents int64
+
+	// circuits skip provider layers that keep failing; nil ones never open.
+	circuits struct{ index, github, llm *circuit }
+
+	// acquiring holds the symbols being acquired right now, so concurrent
+	// requests for one get ErrAcquisitionPending instead of paying twice.
+	mu        sync.Mutex
+	acquiring map[string]bool
 }
 
-// NewLogoService creates a service with all acquisition layers wired up.
-// llmProvider can be nil — the service gracefully skips LLM if unconfigured.
+// NewLogoService creates a service around the dependencies every
+// deployment has: storage, the image processor and the free GitHub layer.
+// Everything optional — the LLM layer, policies, caches — comes as Options.
 func NewLogoService(
 	logoRepo storage.LogoRepository,
+	requestedRepo storage.RequestedSymbolRepository,
+	instrumentRepo storage.InstrumentRepository,
 	fs *storage.FileSystem,
-	processor *ImageProcessor,
-	ghProvider *provider.GitHubProvider,
-	llmProvider *provider.LLMProvider,
-	logger *zap.Logger,
+	processor LogoProcessor,
+	ghProvider LogoFetcher,
+	opts ...Option,
 ) *LogoService {
-	return &LogoService{
-		logoRepo:    logoRepo,
-		fs:          fs,
-		processor:   processor,
-		ghProvider:  ghProvider,
-		llmProvider: llmProvider,
-		logger:      logger,
+	s := &LogoService{
+		logoRepo:       logoRepo,
+		requestedRepo:  requestedRepo,
+		instrumentRepo: instrumentRepo,
+		fs:             fs,
+		processor:      processor,
+		ghProvider:     ghProvider,
+		retention:      DefaultDeleteRetention,
+		clock:          clock.System,
+		logger:         zap.NewNop(),
+		acquiring:      make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	if s.batches != nil {
+		s.batches.SetClock(s.clock)
+	}
+	s.circuits.index = newCircuit("index", s.circuitPolicy)
+	s.circuits.github = newCircuit("github", s.circuitPolicy)
+	s.circuits.llm = newCircuit("llm", s.circuitPolicy)
+	return s
 }
 
 // GetLogo returns the PNG bytes for a logo at the requested size.
@@ -60,12 +253,56 @@ func NewLogoService(
 //  3. Process to all sizes, store in cache
 //  4. Return the requested size
 func (s *LogoService) GetLogo(ctx context.Context, symbol string, size model.LogoSize) ([]byte, error) {
+	// Never let junk reach the DB, the providers (which cost money) or the disk.
+	if !model.ValidSymbol(symbol) {
+		return nil, model.ErrInvalidSymbol
+	}
+
+	// Blocked symbols are refused before anything else, cached or not.
+	if err := s.checkBlocked(ctx, symbol); err != nil {
+		return nil, err
+	}
+
 	// Layer 1: Cache hit — fast path
 	data, err := s.fromCache(ctx, symbol, size)
 	if err == nil {
 		return data, nil
 	}
 
+	// Held for review — re-acquiring would just find the same doubtful logo
+	// again. Pending — someone else is already acquiring it. Not found —
+	// nobody had it, and it isn't time to look again. Deleted — an admin
+	// took it down, and may still restore it.
+	if errors.Is(err, ErrPendingReview) || errors.Is(err, ErrAcquisitionPending) || errors.Is(err, ErrLogoNotFound) || errors.Is(err, storage.ErrLogoDeleted) {
+		return nil, err
+	}
+
+	// No room to store the result — don't pay for a search we can't keep.
+	if err := s.checkSpace(); err != nil {
+		return nil, err
+	}
+
+	if s.enabled(flags.MaintenanceMode) {
+		return nil, fmt.Errorf("%w: %s", ErrMaintenance, symbol)
+	}
+	if !s.enabled(flags.OnDemandAcquisition) {
+		return nil, fmt.Errorf("%w: %s", ErrAcquisitionDisabled, symbol)
+	}
+
+	if !s.startAcquiring(symbol) {
+		return nil, fmt.Errorf("%w: %s", ErrAcquisitionPending, symbol)
+	}
+	defer s.doneAcquiring(symbol)
+
+	// Only now: a miss waiting on another request's acquisition costs nothing.
+	scan := scanOf(ctx)
+	if !scan.guard.Allow(scan.callers...) {
+		return nil, fmt.Errorf("%w: %s", ErrScanThrottled, symbol)
+	}
+	if !admitAcquisition(ctx) {
+		return nil, fmt.Errorf("%w: %s", ErrAcquisitionLimited, symbol)
+	}
+
 	// Cache miss — acquire from external providers
 	s.logger.Info("cache miss, acquiring logo",
 		zap.String("symbol", symbol),
@@ -73,31 +310,565 @@ func (s *LogoService) GetLogo(ctx context.Context, symbol string, size model.Log
 
 	result, err := s.acquire(ctx, symbol)
 	if err != nil {
+		s.recordMiss(ctx, symbol)
+		// A canceled request may have cut the providers short: only a
+		// complete search is worth remembering.
+		if errors.Is(err, ErrLogoNotFound) && ctx.Err() == nil {
+			var verr *provider.ValidationError
+			if errors.As(err, &verr) {
+				s.markFailed(ctx, symbol, "rejected: "+verr.Error())
+			} else {
+				s.markNotFound(ctx, symbol, s.policy.NotFoundRecheck)
+			}
+			scan.guard.Miss(symbol, scan.callers...)
+		} else if errors.Is(err, provider.ErrDisabled) && ctx.Err() == nil && s.policy.BudgetRecheck > 0 {
+			s.markNotFound(ctx, symbol, s.policy.BudgetRecheck)
+		}
 		return nil, fmt.Errorf("acquiring logo for %s: %w", symbol, err)
 	}
 
 	// Process and cache for future requests
-	if err := s.processAndStore(ctx, result); err != nil {
+	if err := s.processAndStore(ctx, result, false); err != nil {
 		return nil, fmt.Errorf("processing logo for %s: %w", symbol, err)
 	}
 
-	// Read the now-cached size
-	return s.fs.Read(symbol, size)
+	// Read the now-cached size. Going through fromCache (rather than reading the
+	// file directly) respects the status — a logo sent to review isn't served.
+	return s.fromCache(ctx, symbol, size)
+}
+
+// GetLogoDefault is GetLogo for a request that didn't name a background:
+// a logo with a default background (see SetDefaultBackground) is served
+// on it, any other as is.
+func (s *LogoService) GetLogoDefault(ctx context.Context, symbol string, size model.LogoSize) ([]byte, error) {
+	if model.ValidSymbol(symbol) {
+		if logo, err := s.lookup(ctx, symbol); err == nil && logo.DefaultBackground != "" {
+			return s.GetLogoWithBackground(ctx, symbol, size, logo.DefaultBackground)
+		}
+	}
+	return s.GetLogo(ctx, symbol, size)
+}
+
+// GetLogoWithBackground returns a logo flattened onto a solid background
+// color (hex, with or without #). Rendered variants are cached when a
+// VariantCache is set; a miss goes through GetLogo as usual.
+func (s *LogoService) GetLogoWithBackground(ctx context.Context, symbol string, size model.LogoSize, hexColor string) ([]byte, error) {
+	r, g, b, err := parseHexColor(hexColor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidColor, err)
+	}
+	// Normalized, so "#FFF000" and "fff000" share one cached file.
+	variant := fmt.Sprintf("bg_%02x%02x%02x", r, g, b)
+
+	// A cached variant would otherwise bypass GetLogo's blocklist check.
+	if err := s.checkBlocked(ctx, symbol); err != nil {
+		return nil, err
+	}
+
+	if s.variants != nil && model.ValidSymbol(symbol) {
+		if data, ok := s.variants.Get(ctx, symbol, size, variant); ok {
+			return data, nil
+		}
+	}
+
+	data, err := s.GetLogo(ctx, symbol, size)
+	if err != nil {
+		return nil, err
+	}
+	data, err = ApplyBackground(data, hexColor)
+	if err != nil {
+		return nil, fmt.Errorf("applying background: %w", err)
+	}
+
+	// Caching is an optimization: when it fails, or space is low, just serve.
+	if s.variants != nil && s.checkSpace() == nil {
+		if err := s.variants.Put(ctx, symbol, size, variant, data); err != nil {
+			s.logger.Warn("caching variant", zap.String("symbol", symbol), zap.String("variant", variant), zap.Error(err))
+		}
+	}
+	return data, nil
+}
+
+// purgeVariants drops a symbol's cached variants after its canonical sizes changed.
+func (s *LogoService) purgeVariants(ctx context.Context, symbol string) {
+	if s.variants == nil {
+		return
+	}
+	if err := s.variants.Purge(ctx, symbol); err != nil {
+		s.logger.Error("purging variants", zap.String("symbol", symbol), zap.Error(err))
+	}
+}
+
+// GetMetadata returns the stored record for a symbol, without the image.
+func (s *LogoService) GetMetadata(ctx context.Context, symbol string) (*model.Logo, error) {
+	if err := s.checkBlocked(ctx, symbol); err != nil {
+		return nil, err
+	}
+	logo, err := s.logoRepo.GetBySymbol(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+	// The record is the point; renditions are a bonus.
+	if logo.Renditions, err = s.renditions(ctx, logo); err != nil {
+		s.logger.Warn("getting renditions", zap.String("symbol", symbol), zap.Error(err))
+	}
+	return logo, nil
+}
+
+// BlockSymbol puts a symbol on the blocklist. Its record and files are kept,
+// so UnblockSymbol serves it again without re-acquiring.
+func (s *LogoService) BlockSymbol(ctx context.Context, symbol, reason string) error {
+	if s.blocklist == nil {
+		return fmt.Errorf("no blocklist configured")
+	}
+	if err := s.blocklist.Block(ctx, symbol, reason); err != nil {
+		return err
+	}
+	s.recordAudit(ctx, model.AuditBlock, symbol, reason)
+	s.recordEvent(ctx, model.EventDeleted, symbol)
+	return nil
+}
+
+// UnblockSymbol removes a symbol from the blocklist.
+// Returns storage.ErrNotFound if it wasn't blocked.
+func (s *LogoService) UnblockSymbol(ctx context.Context, symbol string) error {
+	if s.blocklist == nil {
+		return storage.ErrNotFound
+	}
+	if err := s.blocklist.Unblock(ctx, symbol); err != nil {
+		return err
+	}
+	s.recordAudit(ctx, model.AuditUnblock, symbol, "")
+	if logo, err := s.logoRepo.GetBySymbol(ctx, symbol); err == nil && logo.Status == model.StatusProcessed {
+		s.recordEvent(ctx, model.EventUpdated, symbol) // Served again as it was
+	}
+	return nil
+}
+
+// Takedown handles a request to stop serving a logo, e.g. a trademark
+// complaint: the symbol is blocked, its files, variants and originals are deleted, and
+// the reason goes to the audit log. The record is kept with its source and
+// license, so the takedown can be traced later. Unblocking afterwards makes
+// the next request acquire the logo again.
+func (s *LogoService) Takedown(ctx context.Context, symbol, reason string) error {
+	if s.blocklist == nil {
+		return fmt.Errorf("no blocklist configured")
+	}
+	// Block first: if anything below fails, the logo is at least no longer served.
+	if err := s.blocklist.Block(ctx, symbol, reason); err != nil {
+		return err
+	}
+
+	if err := s.fs.DeleteSymbol(symbol); err != nil {
+		return fmt.Errorf("deleting files: %w", err)
+	}
+	s.purgeVariants(ctx, symbol)
+	if err := s.purgeOriginals(ctx, symbol); err != nil {
+		return fmt.Errorf("deleting originals: %w", err)
+	}
+
+	logo, err := s.logoRepo.GetBySymbol(ctx, symbol)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return err
+	}
+	if logo != nil {
+		logo.HasXS, logo.HasS, logo.HasM, logo.HasL, logo.HasXL = false, false, false, false, false
+		msg := "taken down: " + reason
+		logo.Status = model.StatusFailed
+		logo.ErrorMessage = &msg
+		if err := s.logoRepo.Update(ctx, logo); err != nil {
+			return err
+		}
+	}
+
+	s.recordAudit(ctx, model.AuditTakedown, symbol, reason)
+	s.recordEvent(ctx, model.EventDeleted, symbol)
+	return nil
+}
+
+// ListAudit returns recent audit log entries, newest first, optionally for one symbol.
+func (s *LogoService) ListAudit(ctx context.Context, symbol string, limit int) ([]model.AuditEntry, error) {
+	if s.audit == nil {
+		return nil, nil
+	}
+	return s.audit.List(ctx, symbol, limit)
+}
+
+// recordAudit appends to the audit log. The action itself already happened,
+// so a failure is logged rather than returned.
+func (s *LogoService) recordAudit(ctx context.Context, action, symbol, details string) {
+	if s.audit == nil {
+		return
+	}
+	entry := &model.AuditEntry{Action: action, Symbol: symbol, Details: details}
+	if err := s.audit.Record(ctx, entry); err != nil {
+		s.logger.Error("recording audit entry",
+			zap.String("action", action),
+			zap.String("symbol", symbol),
+			zap.Error(err),
+		)
+	}
+}
+
+// ListChanges returns up to limit events after the since cursor (an event
+// ID; 0 for the start of the feed), oldest first.
+func (s *LogoService) ListChanges(ctx context.Context, since int64, limit int) ([]model.Event, error) {
+	if s.events == nil {
+		return []model.Event{}, nil
+	}
+	return s.events.ListSince(ctx, since, limit)
+}
+
+// recordEvent appends to the changes feed. Like recordAudit, the change
+// already happened, so a failure is logged rather than returned.
+func (s *LogoService) recordEvent(ctx context.Context, eventType, symbol string) {
+	if s.events == nil {
+		return
+	}
+	if err := s.events.Record(ctx, &model.Event{Type: eventType, Symbol: symbol}); err != nil {
+		s.logger.Error("recording event",
+			zap.String("type", eventType),
+			zap.String("symbol", symbol),
+			zap.Error(err),
+		)
+	}
+}
+
+// ListBlocked returns every blocked symbol.
+func (s *LogoService) ListBlocked(ctx context.Context) ([]model.BlockedSymbol, error) {
+	if s.blocklist == nil {
+		return nil, nil
+	}
+	return s.blocklist.List(ctx)
+}
+
+// SetWhitenBackground overrides processing.whiten_background for one logo;
+// nil goes back to the default. It applies the next time the logo is
+// processed. Returns storage.ErrNotFound if there's no record for the symbol.
+func (s *LogoService) SetWhitenBackground(ctx context.Context, symbol string, whiten *bool) error {
+	logo, err := s.logoRepo.GetBySymbol(ctx, symbol)
+	if err != nil {
+		return err
+	}
+	logo.WhitenBackground = whiten
+	return s.logoRepo.Update(ctx, logo)
+}
+
+// SetDefaultBackground sets the background color a logo is served on when
+// a request doesn't ask for one (see GetLogoDefault); "" clears it. Colors
+// are hex, with or without #, and stored normalized. Returns
+// storage.ErrNotFound if there's no record for the symbol.
+func (s *LogoService) SetDefaultBackground(ctx context.Context, symbol, hexColor string) error {
+	logo, err := s.logoRepo.GetBySymbol(ctx, symbol)
+	if err != nil {
+		return err
+	}
+	logo.DefaultBackground = ""
+	if hexColor != "" {
+		r, g, b, err := parseHexColor(hexColor)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidColor, err)
+		}
+		logo.DefaultBackground = fmt.Sprintf("%02x%02x%02x", r, g, b)
+	}
+	if err := s.logoRepo.Update(ctx, logo); err != nil {
+		return err
+	}
+	// Other instances serve from their catalog, which syncs on events.
+	s.recordEvent(ctx, model.EventUpdated, symbol)
+	return nil
+}
+
+// UpdateMetadata corrects a logo's descriptive fields and records what
+// changed in the audit log. It reports whether anything did. Values are
+// trimmed; a website must be an http(s) URL.
+//
+// Returns storage.ErrNotFound if there's no record, and an error wrapping
+// ErrInvalidMetadata for a value that can't be stored.
+func (s *LogoService) UpdateMetadata(ctx context.Context, symbol string, update model.MetadataUpdate) (bool, error) {
+	logo, err := s.logoRepo.GetBySymbol(ctx, symbol)
+	if err != nil {
+		return false, err
+	}
+
+	var changes []string
+	for _, f := range []struct {
+		name         string
+		field, value *string
+	}{
+		{"company_name", &logo.CompanyName, update.CompanyName},
+		{"website", &logo.Website, update.Website},
+		{"license", &logo.License, update.License},
+		{"attribution", &logo.Attribution, update.Attribution},
+	} {
+		if f.value == nil {
+			continue
+		}
+		v := strings.TrimSpace(*f.value)
+		if f.name == "website" && v != "" {
+			if u, err := url.Parse(v); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return false, fmt.Errorf("%w: website %q is not an http(s) URL", ErrInvalidMetadata, v)
+			}
+		}
+		if v != *f.field {
+			changes = append(changes, fmt.Sprintf("%s: %q -> %q", f.name, *f.field, v))
+			*f.field = v
+		}
+	}
+	if len(changes) == 0 {
+		return false, nil
+	}
+
+	if err := s.logoRepo.Update(ctx, logo); err != nil {
+		return false, err
+	}
+	s.recordAudit(ctx, model.AuditMetadata, symbol, strings.Join(changes, "; "))
+	return true, nil
+}
+
+// ListByQuality returns scored logos at or below maxScore, worst first.
+func (s *LogoService) ListByQuality(ctx context.Context, maxScore, limit int) ([]model.Logo, error) {
+	return s.logoRepo.ListByQuality(ctx, maxScore, limit)
+}
+
+// ListReview returns a page of logos waiting in the review queue, most
+// recently updated first by default, and the cursor of the next page.
+func (s *LogoService) ListReview(ctx context.Context, opts storage.ListOptions) ([]model.Logo, string, error) {
+	return s.logoRepo.List(ctx, model.StatusReview, opts.WithSort("-updated_at"))
+}
+
+// ApproveReview releases a logo from the review queue so it's served.
+func (s *LogoService) ApproveReview(ctx context.Context, symbol string) error {
+	logo, err := s.logoRepo.GetBySymbol(ctx, symbol)
+	if err != nil {
+		return err
+	}
+	if logo.Status != model.StatusReview {
+		return fmt.Errorf("%w: %s is %s, not in review", ErrWrongStatus, symbol, logo.Status)
+	}
+	if err := s.logoRepo.SetStatus(ctx, symbol, model.StatusProcessed, ""); err != nil {
+		return err
+	}
+	s.recordEvent(ctx, model.EventUpdated, symbol)
+	return nil
+}
+
+// SendToReview pulls a served logo back into the review queue, e.g. when
+// the duplicates report suggests it's another company's logo. It stays on
+// disk, so approving it serves it again and rejecting it deletes it.
+func (s *LogoService) SendToReview(ctx context.Context, symbol string) error {
+	logo, err := s.logoRepo.GetBySymbol(ctx, symbol)
+	if err != nil {
+		return err
+	}
+	if logo.Status != model.StatusProcessed {
+		return fmt.Errorf("%w: %s is %s, not processed", ErrWrongStatus, symbol, logo.Status)
+	}
+	if err := s.logoRepo.SetStatus(ctx, symbol, model.StatusReview, ""); err != nil {
+		return err
+	}
+	s.purgeVariants(ctx, symbol)
+	s.recordAudit(ctx, model.AuditReview, symbol, "")
+	s.recordEvent(ctx, model.EventDeleted, symbol)
+	return nil
+}
+
+// RejectReview discards a logo from the review queue: its files are deleted
+// and the record is marked failed, so the next request can try again.
+func (s *LogoService) RejectReview(ctx context.Context, symbol string) error {
+	logo, err := s.logoRepo.GetBySymbol(ctx, symbol)
+	if err != nil {
+		return err
+	}
+	if logo.Status != model.StatusReview {
+		return fmt.Errorf("%w: %s is %s, not in review", ErrWrongStatus, symbol, logo.Status)
+	}
+
+	if err := s.fs.DeleteSymbol(symbol); err != nil {
+		return fmt.Errorf("deleting files: %w", err)
+	}
+	s.purgeVariants(ctx, symbol)
+
+	// Clear size flags along with the status — the files are gone.
+	logo.HasXS, logo.HasS, logo.HasM, logo.HasL, logo.HasXL = false, false, false, false, false
+	msg := "rejected in review"
+	logo.Status = model.StatusFailed
+	logo.ErrorMessage = &msg
+	return s.logoRepo.Update(ctx, logo)
+}
+
+// Stats counts the logos in the catalog by status.
+func (s *LogoService) Stats(ctx context.Context) (*model.Stats, error) {
+	total, err := s.logoRepo.Count(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("counting logos: %w", err)
+	}
+	stats := &model.Stats{Total: total}
+
+	// Go note: a slice of pointers lets one loop fill several struct fields.
+	for _, c := range []struct {
+		status model.LogoStatus
+		count  *int64
+	}{
+		{model.StatusProcessed, &stats.Processed},
+		{model.StatusPending, &stats.Pending},
+		{model.StatusFailed, &stats.Failed},
+		{model.StatusNotFound, &stats.NotFound},
+		{model.StatusReview, &stats.Review},
+	} {
+		if *c.count, err = s.logoRepo.CountByStatus(ctx, c.status); err != nil {
+			return nil, fmt.Errorf("counting %s logos: %w", c.status, err)
+		}
+	}
+	return stats, nil
+}
+
+// GetCachedLogo returns a logo only if it's already stored and servable:
+// unlike GetLogo, it never asks a provider. Read-only tools use it.
+func (s *LogoService) GetCachedLogo(ctx context.Context, symbol string, size model.LogoSize) ([]byte, error) {
+	if !model.ValidSymbol(symbol) {
+		return nil, model.ErrInvalidSymbol
+	}
+	if err := s.checkBlocked(ctx, symbol); err != nil {
+		return nil, err
+	}
+	return s.fromCache(ctx, symbol, size)
+}
+
+// ListNotFound returns a page of symbols no provider had a logo for, most
+// recently checked first by default, and the cursor of the next page.
+func (s *LogoService) ListNotFound(ctx context.Context, opts storage.ListOptions) ([]model.Logo, string, error) {
+	return s.logoRepo.List(ctx, model.StatusNotFound, opts.WithSort("-updated_at"))
+}
+
+// ListLogos returns a page of logos, in one status or all of them if status
+// is empty, and the cursor of the next page.
+func (s *LogoService) ListLogos(ctx context.Context, status model.LogoStatus, opts storage.ListOptions) ([]model.Logo, string, error) {
+	return s.logoRepo.List(ctx, status, opts)
+}
+
+// Requeue makes a not_found logo due for a check now, instead of at its
+// next_check_at: the next request asks the providers again. Useful once a
+// logo has been added upstream, e.g. to one of the GitHub repos.
+func (s *LogoService) Requeue(ctx context.Context, symbol string) error {
+	logo, err := s.logoRepo.GetBySymbol(ctx, symbol)
+	if err != nil {
+		return err
+	}
+	if logo.Status != model.StatusNotFound {
+		return fmt.Errorf("%w: %s is %s, not not_found", ErrWrongStatus, symbol, logo.Status)
+	}
+	return s.logoRepo.MarkNotFound(ctx, symbol, s.clock.Now())
 }
 
 // ProcessAndStore takes a provider result and processes it into all sizes.
 // Exported so the admin handler can reuse it during bulk imports.
 func (s *LogoService) ProcessAndStore(ctx context.Context, result *provider.LogoResult) error {
-	return s.processAndStore(ctx, result)
+	return s.processAndStore(ctx, result, false)
+}
+
+// ReplaceLogo is ProcessAndStore for corrections: a logo that's already
+// processed is replaced by the result's image too, unless it was processed
+// from the same image, which returns ErrUnchanged. Used for imports from the
+// curated index, whose entries exist to override what other providers found.
+func (s *LogoService) ReplaceLogo(ctx context.Context, result *provider.LogoResult) error {
+	return s.processAndStore(ctx, result, true)
+}
+
+// markNotFound remembers that no provider had a logo for the symbol, so
+// requests for the next recheck are answered without asking them again.
+// Like recordMiss, failures are only logged.
+func (s *LogoService) markNotFound(ctx context.Context, symbol string, recheck time.Duration) {
+	if err := s.logoRepo.MarkNotFound(ctx, symbol, s.clock.Now().Add(recheck)); err != nil {
+		s.logger.Error("marking logo not found",
+			zap.String("symbol", symbol),
+			zap.Error(err),
+		)
+	}
+}
+
+// markFailed records that the image found for the symbol was rejected or
+// couldn't be processed. With an InvalidRecheck, requests until then are
+// answered with ErrLogoNotFound instead of acquiring the same image again.
+// Failures are ignored: the caller is already returning an error.
+func (s *LogoService) markFailed(ctx context.Context, symbol, msg string) {
+	if s.policy.InvalidRecheck > 0 {
+		_ = s.logoRepo.MarkFailed(ctx, symbol, msg, s.clock.Now().Add(s.policy.InvalidRecheck))
+		return
+	}
+	_ = s.logoRepo.SetStatus(ctx, symbol, model.StatusFailed, msg)
+}
+
+// recordMiss counts a request we couldn't serve, so admins can see which
+// missing symbols are in demand. Failures are logged, never surfaced —
+// demand tracking must not turn a 404 into a 500.
+func (s *LogoService) recordMiss(ctx context.Context, symbol string) {
+	if err := s.requestedRepo.RecordMiss(ctx, symbol); err != nil {
+		s.logger.Error("recording missing symbol",
+			zap.String("symbol", symbol),
+			zap.Error(err),
+		)
+	}
+}
+
+// checkBlocked returns an error wrapping storage.ErrSymbolBlocked for a
+// blocked symbol. A failed lookup is returned as is: for a legal takedown,
+// serving by mistake is worse than an error.
+func (s *LogoService) checkBlocked(ctx context.Context, symbol string) error {
+	if s.blocklist == nil {
+		return nil
+	}
+	blocked, known := s.catalog.Blocked(symbol)
+	if !known {
+		var err error
+		if blocked, err = s.blocklist.IsBlocked(ctx, symbol); err != nil {
+			return err
+		}
+	}
+	if blocked {
+		return fmt.Errorf("%w: %s", storage.ErrSymbolBlocked, symbol)
+	}
+	return nil
+}
+
+// checkSpace returns the space checker's verdict, or nil if there is none.
+func (s *LogoService) checkSpace() error {
+	if s.space == nil {
+		return nil
+	}
+	return s.space.Check()
+}
+
+// enabled reports whether a flag is on, its default without a checker.
+func (s *LogoService) enabled(name flags.Name) bool {
+	if s.flags == nil {
+		return flags.Default(name)
+	}
+	return s.flags.Enabled(name)
 }
 
 // fromCache checks if we already have this logo at the requested size.
 func (s *LogoService) fromCache(ctx context.Context, symbol string, size model.LogoSize) ([]byte, error) {
-	logo, err := s.logoRepo.GetBySymbol(ctx, symbol)
+	logo, err := s.lookup(ctx, symbol)
 	if err != nil {
 		return nil, err
 	}
 
+	if logo.Status == model.StatusReview {
+		return nil, ErrPendingReview
+	}
+
+	if logo.Status == model.StatusPending && s.clock.Now().Sub(logo.UpdatedAt) < pendingTimeout {
+		return nil, ErrAcquisitionPending
+	}
+
+	if logo.Status == model.StatusNotFound && logo.NextCheckAt != nil && s.clock.Now().Before(*logo.NextCheckAt) {
+		return nil, fmt.Errorf("%w: %s", ErrLogoNotFound, symbol)
+	}
+	if logo.Status == model.StatusFailed && logo.NextCheckAt != nil && s.clock.Now().Before(*logo.NextCheckAt) {
+		return nil, fmt.Errorf("%w: %s (last image failed)", ErrLogoNotFound, symbol)
+	}
+
 	if logo.Status != model.StatusProcessed {
 		return nil, fmt.Errorf("logo status is %s", logo.Status)
 	}
@@ -106,28 +877,115 @@ func (s *LogoService) fromCache(ctx context.Context, symbol string, size model.L
 		return nil, fmt.Errorf("size %s not available", size)
 	}
 
-	return s.fs.Read(symbol, size)
+	data, err := s.fs.Read(symbol, size)
+	if errors.Is(err, os.ErrNotExist) {
+		// Healing renders from the original, which takes the whole record.
+		if logo, err = s.logoRepo.GetBySymbol(ctx, symbol); err != nil {
+			return nil, err
+		}
+		return s.heal(ctx, logo, size)
+	}
+	return data, err
+}
+
+// lookup returns what fromCache needs to know about a logo: from the
+// catalog when it has the symbol (only status, sizes and timestamps are
+// set then), else the record from the database.
+func (s *LogoService) lookup(ctx context.Context, symbol string) (*model.Logo, error) {
+	if logo, ok := s.catalog.Lookup(symbol); ok {
+		return logo, nil
+	}
+	return s.logoRepo.GetBySymbol(ctx, symbol)
+}
+
+// startAcquiring marks a symbol as being acquired. It returns false if it
+// already was, in which case the caller must not acquire it too.
+func (s *LogoService) startAcquiring(symbol string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.acquiring[symbol] {
+		return false
+	}
+	s.acquiring[symbol] = true
+	return true
+}
+
+func (s *LogoService) doneAcquiring(symbol string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.acquiring, symbol)
 }
 
-// acquire tries providers in order: GitHub first (free, fast), then LLM (paid, slow).
+// Acquisition strategies for the free provider layers (the index and GitHub).
+const (
+	StrategySequential = "sequential" // In order, each asked only if the previous missed
+	StrategyRace       = "race"       // All at once; the first logo found wins
+)
+
+// layer is a free provider layer of the acquisition chain.
+type layer struct {
+	name    string
+	circuit *circuit
+	fetch   func(ctx context.Context, symbol string) (*provider.LogoResult, error)
+}
+
+// freeLayers returns the layers asked before the LLM, in order: the curated
+// index first (it's there to correct the others), then GitHub.
+func (s *LogoService) freeLayers() []layer {
+	var layers []layer
+	if s.indexProvider != nil {
+		layers = append(layers, layer{"index", s.circuits.index, s.indexProvider.GetLogo})
+	}
+	return append(layers, layer{"github", s.circuits.github, s.ghProvider.GetLogo})
+}
+
+// acquire tries the free layers (see freeLayers), in order or racing them
+// (WithStrategy), then the LLM (paid, slow), which is never raced: asking
+// it on every miss would pay for searches GitHub answers anyway.
+// If a layer failed or was skipped by its circuit breaker and none found a
+// logo, the search wasn't complete: the error wraps provider.ErrUnavailable
+// rather than ErrLogoNotFound, so the symbol isn't marked not_found.
+// If every image found was rejected on download (see rejected), the
+// ErrLogoNotFound wraps the first rejection, so the symbol is marked
+// failed instead, until InvalidRecheck.
+// Every provider asked (or skipped) is recorded under one trace (see
+// WithAttemptLog).
 func (s *LogoService) acquire(ctx context.Context, symbol string) (*provider.LogoResult, error) {
-	// Layer 2: GitHub repos
-	result, err := s.ghProvider.GetLogo(ctx, symbol)
-	if err == nil {
-		s.logger.Info("found logo via GitHub",
-			zap.String("symbol", symbol),
-			zap.String("source", result.Source),
-		)
+	trace := rand.Text()
+	var result *provider.LogoResult
+	var incomplete bool
+	var rejection error
+	if s.strategy == StrategyRace {
+		result, incomplete, rejection = s.race(ctx, trace, symbol, s.freeLayers())
+	} else {
+		result, incomplete, rejection = s.inOrder(ctx, trace, symbol, s.freeLayers())
+	}
+	if result != nil {
 		return result, nil
 	}
-	s.logger.Debug("GitHub provider miss",
-		zap.String("symbol", symbol),
-		zap.Error(err),
-	)
 
-	// Layer 3: LLM web search
+	// Layer 3: LLM web search, with the company name if we know it
+	if s.llmProvider != nil && s.policy.RequireKnownSymbol && !s.isKnownSymbol(ctx, symbol) {
+		s.logger.Info("skipping LLM search for unknown symbol", zap.String("symbol", symbol))
+		s.recordAttempt(ctx, &model.Attempt{Trace: trace, Symbol: symbol, Provider: "llm",
+			Outcome: model.AttemptSkipped, Error: "not a known instrument", CreatedAt: s.clock.Now()})
+		if incomplete {
+			return nil, fmt.Errorf("%w: a provider failed looking for %s", provider.ErrUnavailable, symbol)
+		}
+		if rejection != nil {
+			return nil, fmt.Errorf("%w for %s: %w", ErrLogoNotFound, symbol, rejection)
+		}
+		return nil, fmt.Errorf("%w for %s (not a known instrument)", ErrLogoNotFound, symbol)
+	}
+
+	freeIncomplete := incomplete
+	var llmErr error
 	if s.llmProvider != nil {
-		result, err = s.llmProvider.GetLogo(ctx, symbol)
+		started := s.clock.Now()
+		result, err := s.ask(ctx, s.circuits.llm, func() (*provider.LogoResult, error) {
+			return s.llmProvider.FindLogo(ctx, symbol, s.knownCompanyName(ctx, symbol))
+		})
+		s.recordOutcome(ctx, trace, symbol, "llm", started, result, err)
 		if err == nil {
 			s.logger.Info("found logo via LLM",
 				zap.String("symbol", symbol),
@@ -135,23 +993,274 @@ func (s *LogoService) acquire(ctx context.Context, symbol string) (*provider.Log
 			)
 			return result, nil
 		}
+		incomplete = incomplete || errors.Is(err, provider.ErrUnavailable)
+		if rejection == nil && rejected(err) {
+			rejection = err
+		}
+		llmErr = err
 		s.logger.Warn("LLM provider miss",
 			zap.String("symbol", symbol),
 			zap.Error(err),
 		)
 	}
 
-	return nil, fmt.Errorf("no provider found a logo for %s", symbol)
+	if incomplete && !freeIncomplete && errors.Is(llmErr, provider.ErrDisabled) {
+		// Only the frozen LLM layer is missing: a budget miss, see BudgetRecheck.
+		return nil, fmt.Errorf("looking for %s: %w", symbol, llmErr)
+	}
+	if incomplete {
+		return nil, fmt.Errorf("%w: a provider failed looking for %s", provider.ErrUnavailable, symbol)
+	}
+	if rejection != nil {
+		return nil, fmt.Errorf("%w for %s: %w", ErrLogoNotFound, symbol, rejection)
+	}
+	return nil, fmt.Errorf("%w for %s", ErrLogoNotFound, symbol)
+}
+
+// rejected reports whether err is a provider's download refused as no
+// usable image (provider.ValidationError: not an image, too large, an SVG
+// that can't be sanitized) rather than a miss.
+func rejected(err error) bool {
+	var verr *provider.ValidationError
+	return errors.As(err, &verr)
+}
+
+// inOrder asks the layers one after the other and returns the first logo
+// found. incomplete reports whether a layer failed rather than missed, and
+// rejection is the first layer's download that was rejected (see rejected).
+func (s *LogoService) inOrder(ctx context.Context, trace, symbol string, layers []layer) (result *provider.LogoResult, incomplete bool, rejection error) {
+	for _, l := range layers {
+		result, err := s.tryLayer(ctx, trace, symbol, l)
+		if err == nil {
+			return result, false, nil
+		}
+		incomplete = incomplete || errors.Is(err, provider.ErrUnavailable)
+		if rejection == nil && rejected(err) {
+			rejection = err
+		}
+	}
+	return nil, incomplete, rejection
+}
+
+// race asks every layer at once and returns the first logo found; the
+// others are cancelled. Its other results are inOrder's. Unlike inOrder, a
+// faster GitHub can win over the index — run an index import to apply its
+// corrections to those.
+//
+// Go note: the channel is buffered for every layer, so the losers can
+// send their (cancelled) results and exit even though nobody reads them.
+func (s *LogoService) race(ctx context.Context, trace, symbol string, layers []layer) (*provider.LogoResult, bool, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type answer struct {
+		result *provider.LogoResult
+		err    error
+	}
+	answers := make(chan answer, len(layers))
+	for _, l := range layers {
+		go func() {
+			result, err := s.tryLayer(ctx, trace, symbol, l)
+			answers <- answer{result, err}
+		}()
+	}
+
+	incomplete := false
+	var rejection error
+	for range layers {
+		a := <-answers
+		if a.err == nil {
+			return a.result, false, nil
+		}
+		incomplete = incomplete || errors.Is(a.err, provider.ErrUnavailable)
+		if rejection == nil && rejected(a.err) {
+			rejection = a.err
+		}
+	}
+	return nil, incomplete, rejection
+}
+
+// tryLayer asks one layer through its circuit breaker and logs the outcome.
+func (s *LogoService) tryLayer(ctx context.Context, trace, symbol string, l layer) (*provider.LogoResult, error) {
+	started := s.clock.Now()
+	result, err := s.ask(ctx, l.circuit, func() (*provider.LogoResult, error) {
+		return l.fetch(ctx, symbol)
+	})
+	s.recordOutcome(ctx, trace, symbol, l.name, started, result, err)
+	if err != nil {
+		s.logger.Debug("provider miss",
+			zap.String("provider", l.name),
+			zap.String("symbol", symbol),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	s.logger.Info("found logo",
+		zap.String("provider", l.name),
+		zap.String("symbol", symbol),
+		zap.String("source", result.Source),
+	)
+	return result, nil
+}
+
+// ask calls a provider layer through its circuit breaker. While the circuit
+// is open the layer isn't called, and the error wraps provider.ErrUnavailable
+// like the failures that opened it.
+func (s *LogoService) ask(ctx context.Context, c *circuit, call func() (*provider.LogoResult, error)) (*provider.LogoResult, error) {
+	ok, probe := c.allow(s.clock.Now())
+	if !ok {
+		return nil, fmt.Errorf("%w: %s %w", provider.ErrUnavailable, c.name, errCircuitOpen)
+	}
+	result, err := call()
+	if c.record(s.clock.Now(), probe, err) {
+		s.logger.Warn("provider failing, skipping it for a while",
+			zap.String("provider", c.name),
+			zap.Duration("cooldown", c.policy.Cooldown),
+			zap.Error(err),
+		)
+	}
+	return result, err
+}
+
+// recordOutcome records the outcome of asking a provider, started at started.
+func (s *LogoService) recordOutcome(ctx context.Context, trace, symbol, providerName string, started time.Time, result *provider.LogoResult, err error) {
+	attempt := &model.Attempt{
+		Trace:      trace,
+		Symbol:     symbol,
+		Provider:   providerName,
+		DurationMS: s.clock.Now().Sub(started).Milliseconds(),
+		CreatedAt:  started,
+	}
+	switch {
+	case err == nil:
+		attempt.Outcome = model.AttemptFound
+		attempt.Source = result.Source
+	case errors.Is(err, errCircuitOpen):
+		attempt.Outcome = model.AttemptSkipped
+	case ctx.Err() != nil: // Not the provider's own timeout, which is unavailable
+		attempt.Outcome = model.AttemptCancelled
+	case errors.Is(err, provider.ErrUnavailable):
+		attempt.Outcome = model.AttemptUnavailable
+	default:
+		attempt.Outcome = model.AttemptMiss
+	}
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+	s.recordAttempt(ctx, attempt)
+}
+
+// recordAttempt appends to the attempt log. Like recordAudit, a failure is
+// logged rather than returned: the trace is for debugging, not serving.
+//
+// Go note: context.WithoutCancel keeps the context's values but not its
+// cancellation, so the attempts of a provider that lost a race (or of a
+// client that went away) are still recorded — that's when they're useful.
+func (s *LogoService) recordAttempt(ctx context.Context, attempt *model.Attempt) {
+	if s.attempts == nil {
+		return
+	}
+	if err := s.attempts.Record(context.WithoutCancel(ctx), attempt); err != nil {
+		s.logger.Error("recording acquisition attempt",
+			zap.String("symbol", attempt.Symbol),
+			zap.String("provider", attempt.Provider),
+			zap.Error(err),
+		)
+	}
+}
+
+// ListAttempts returns a symbol's latest acquisition attempts, newest first
+// (see WithAttemptLog).
+func (s *LogoService) ListAttempts(ctx context.Context, symbol string, limit int) ([]model.Attempt, error) {
+	if s.attempts == nil {
+		return []model.Attempt{}, nil
+	}
+	return s.attempts.ListBySymbol(ctx, symbol, limit)
+}
+
+// isKnownSymbol reports whether the symbol is in the instruments table.
+// An empty table (never imported) can't tell us anything, so everything is "known".
+func (s *LogoService) isKnownSymbol(ctx context.Context, symbol string) bool {
+	count, err := s.instrumentRepo.Count(ctx)
+	if err != nil || count == 0 {
+		return true
+	}
+	_, err = s.instrumentRepo.GetBySymbol(ctx, symbol)
+	return !errors.Is(err, storage.ErrNotFound)
+}
+
+// ImportInstruments loads a ticker reference dataset into the instruments table.
+func (s *LogoService) ImportInstruments(ctx context.Context, importer *provider.InstrumentImporter) (*provider.ImportStats, error) {
+	return importer.Import(ctx, func(inst *model.Instrument) error {
+		return s.instrumentRepo.Upsert(ctx, inst)
+	})
+}
+
+// knownCompanyName looks up a company name for the symbol: first from an existing
+// logo record (GitHub import, earlier LLM run), then from the instruments table.
+// Returns "" when nothing is known — lookups are best-effort hints, never errors.
+func (s *LogoService) knownCompanyName(ctx context.Context, symbol string) string {
+	if logo, err := s.logoRepo.GetBySymbol(ctx, symbol); err == nil && logo.CompanyName != "" {
+		return logo.CompanyName
+	}
+	if inst, err := s.instrumentRepo.GetBySymbol(ctx, symbol); err == nil {
+		return inst.Name
+	}
+	return ""
 }
 
 // processAndStore creates the DB record, resizes the image to all sizes,
 // and marks it as processed. This is the shared logic used by both the
-// on-demand pipeline (GetLogo) and bulk import (admin handler).
-func (s *LogoService) processAndStore(ctx context.Context, result *provider.LogoResult) error {
-	// Upsert: create if new, skip if already processed
+// on-demand pipeline (GetLogo) and bulk import (admin handler). A processed
+// logo is left alone unless replace is set.
+func (s *LogoService) processAndStore(ctx context.Context, result *provider.LogoResult, replace bool) error {
+	// Bulk imports come through here too, so this is what keeps them from
+	// re-importing a blocked symbol.
+	if err := s.checkBlocked(ctx, result.Symbol); err != nil {
+		return err
+	}
+
+	// Downloads come sanitized (see provider.readImage), imports don't. First
+	// thing, so the original is compared and kept as it will be stored.
+	if imagefmt.Detect(result.ImageData) == imagefmt.SVG {
+		clean, err := imagefmt.SanitizeSVG(result.ImageData)
+		if err != nil {
+			return fmt.Errorf("rejecting image for %s: %w", result.Symbol, err)
+		}
+		sanitized := *result
+		sanitized.ImageData = clean
+		result = &sanitized
+	}
+
+	// Upsert: create if new, skip if already processed (unless sizes went
+	// missing, see heal)
 	existing, err := s.logoRepo.GetBySymbol(ctx, result.Symbol)
+	if errors.Is(err, storage.ErrLogoDeleted) {
+		return err // Its symbol stays taken until the delete is purged
+	}
 	if err == nil && existing.Status == model.StatusProcessed {
-		return nil // Already done
+		sameImage := existing.OriginalHash == storage.HashOriginal(result.ImageData)
+		if sameImage && existing.OriginalURL == result.OriginalURL {
+			// Logos processed before validators were kept get them on the
+			// next import, so the one after that can skip the download.
+			s.setValidators(ctx, result)
+		}
+		if !replace && len(existing.MissingSizes()) == 0 {
+			return nil // Already done
+		}
+		if replace && sameImage {
+			return fmt.Errorf("%w: %s", ErrUnchanged, result.Symbol)
+		}
+	}
+
+	// Checked before any record is touched, so a bulk import stops cleanly
+	// instead of leaving half-written logos marked failed.
+	if err := s.checkSpace(); err != nil {
+		return err
+	}
+
+	if result.CompanyName == "" {
+		result.CompanyName = s.knownCompanyName(ctx, result.Symbol)
 	}
 
 	if errors.Is(err, storage.ErrNotFound) {
@@ -160,32 +1269,129 @@ func (s *LogoService) processAndStore(ctx context.Context, result *provider.Logo
 			CompanyName: result.CompanyName,
 			Source:      result.Source,
 			OriginalURL: result.OriginalURL,
+			Confidence:  result.Confidence,
+			License:     result.License,
+			Attribution: result.Attribution,
 			Status:      model.StatusPending,
 		}
 		if err := s.logoRepo.Create(ctx, logo); err != nil {
 			return fmt.Errorf("creating record: %w", err)
 		}
+	} else if existing != nil {
+		// Re-acquiring a failed or in-review logo, or replacing one: record
+		// where the new image came from.
+		existing.Source = result.Source
+		existing.OriginalURL = result.OriginalURL
+		existing.Confidence = result.Confidence
+		existing.License = result.License
+		existing.Attribution = result.Attribution
+		if existing.CompanyName == "" {
+			existing.CompanyName = result.CompanyName
+		}
+		existing.NextCheckAt = nil // Found after all
+		if err := s.logoRepo.Update(ctx, existing); err != nil {
+			return fmt.Errorf("updating record: %w", err)
+		}
+	}
+
+	accepted := s.policy.accepts(result.Confidence)
+	if !accepted && s.policy.LowConfidenceAction == LowConfidenceReject {
+		msg := fmt.Sprintf("rejected: confidence %q below minimum %q", result.Confidence, s.policy.MinConfidence)
+		s.markFailed(ctx, result.Symbol, msg)
+		return fmt.Errorf("%w: %s", ErrLowConfidence, result.Symbol)
+	}
+	if s.validator != nil {
+		if err := s.validator.ValidateLogo(ctx, result); err != nil {
+			s.markFailed(ctx, result.Symbol, "rejected: "+err.Error())
+			return fmt.Errorf("%w: %s: %w", ErrInvalidLogo, result.Symbol, err)
+		}
 	}
 
-	// Resize to all 5 sizes
-	sizes, err := s.processor.ProcessAll(result.Symbol, result.ImageData)
+	// Kept before processing: an image the processor fails on today can
+	// be reprocessed once it doesn't.
+	originalHash := s.saveOriginal(ctx, result)
+
+	// Resize to all 5 sizes. existing is nil for a new logo, which has no
+	// overrides yet — ProcessOptions handles the nil receiver.
+	sizes, err := s.processor.ProcessAll(result.Symbol, result.ImageData, existing.ProcessOptions(s.processing))
 	if err != nil {
-		_ = s.logoRepo.SetStatus(ctx, result.Symbol, model.StatusFailed, err.Error())
+		s.markFailed(ctx, result.Symbol, err.Error())
 		return fmt.Errorf("processing: %w", err)
 	}
+	s.purgeVariants(ctx, result.Symbol)
 
 	// Mark each successful size in the DB
-	for size, ok := range sizes {
-		if ok {
-			if err := s.logoRepo.SetSizeAvailable(ctx, result.Symbol, size); err != nil {
-				s.logger.Error("setting size available",
-					zap.String("symbol", result.Symbol),
-					zap.String("size", string(size)),
-					zap.Error(err),
-				)
-			}
+	if err := StoreSizes(ctx, s.fs, s.logoRepo, result.Symbol, sizes); err != nil {
+		s.logger.Error("storing sizes", zap.String("symbol", result.Symbol), zap.Error(err))
+	}
+
+	if originalHash != "" {
+		if err := s.logoRepo.SetOriginalHash(ctx, result.Symbol, originalHash); err != nil {
+			s.logger.Error("setting original hash", zap.String("symbol", result.Symbol), zap.Error(err))
 		}
 	}
+	s.setValidators(ctx, result)
+	s.analyze(ctx, result.Symbol, result.ImageData)
+
+	if !accepted {
+		s.logger.Info("low-confidence logo sent to review",
+			zap.String("symbol", result.Symbol),
+			zap.String("confidence", result.Confidence),
+		)
+		return s.logoRepo.SetStatus(ctx, result.Symbol, model.StatusReview, "")
+	}
+
+	if reason := s.moderate(ctx, result); reason != "" {
+		s.logger.Info("logo held for review by moderation",
+			zap.String("symbol", result.Symbol),
+			zap.String("reason", reason),
+		)
+		s.recordAudit(ctx, model.AuditModeration, result.Symbol, reason)
+		return s.logoRepo.SetStatus(ctx, result.Symbol, model.StatusReview, reason)
+	}
+
+	if err := s.logoRepo.SetStatus(ctx, result.Symbol, model.StatusProcessed, ""); err != nil {
+		return err
+	}
+	// Records from not_found or pending never had an image; every processed
+	// logo got a perceptual hash, so an empty one means this is the first.
+	// A replaced logo was being served, hashed or not.
+	eventType := model.EventUpdated
+	if existing == nil || (existing.PHash == "" && existing.Status != model.StatusProcessed) {
+		eventType = model.EventCreated
+	}
+	s.recordEvent(ctx, eventType, result.Symbol)
+	return nil
+}
+
+// setValidators records the HTTP validators of the download a logo was
+// processed from, clearing those of the previous one if it has none. Without
+// them the next import downloads the file again, so failures are only logged.
+func (s *LogoService) setValidators(ctx context.Context, result *provider.LogoResult) {
+	if err := s.logoRepo.SetValidators(ctx, result.Symbol, result.ETag, result.LastModified); err != nil {
+		s.logger.Error("setting validators", zap.String("symbol", result.Symbol), zap.Error(err))
+	}
+}
+
+// analyze scores a freshly processed logo and hashes it for duplicate
+// detection. The logo is usable either way, so failures are logged, not returned.
+func (s *LogoService) analyze(ctx context.Context, symbol string, source []byte) {
+	rendered, err := s.fs.Read(symbol, model.SizeXL)
+	if err != nil {
+		rendered = nil // Scored without the transparency check, and not hashed
+	}
+	score, notes := AssessQuality(source, rendered)
+	if err := s.logoRepo.SetQuality(ctx, symbol, score, notes); err != nil {
+		s.logger.Error("setting quality score", zap.String("symbol", symbol), zap.Error(err))
+	}
 
-	return s.logoRepo.SetStatus(ctx, result.Symbol, model.StatusProcessed, "")
+	// Cleared when the new rendering can't be hashed, so a stale hash of
+	// the previous image never reports a duplicate.
+	var hash string
+	if h, err := phash.FromPNG(rendered); err == nil {
+		hash = h.String()
+	}
+	if err := s.logoRepo.SetPHash(ctx, symbol, hash); err != nil {
+		s.logger.Error("setting phash", zap.String("symbol", symbol), zap.Error(err))
+	}
 }
@@ -0,0 +1,1086 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"github.com/fleveque/logo-service/internal/clock"
+	"github.com/fleveque/logo-service/internal/flags"
+	"github.com/fleveque/logo-service/internal/imagefmt"
+	"github.com/fleveque/logo-service/internal/model"
+	"github.com/fleveque/logo-service/internal/provider"
+	"github.com/fleveque/logo-service/internal/storage"
+	"github.com/fleveque/logo-service/internal/testutil"
+)
+
+// serviceDeps bundles a LogoService with the fakes behind it, so tests can
+// both drive the service and inspect what it did.
+type serviceDeps struct {
+	svc            *LogoService
+	github         *testutil.FakeProvider
+	llm            *testutil.FakeProvider
+	logoRepo       storage.LogoRepository
+	requestedRepo  storage.RequestedSymbolRepository
+	instrumentRepo storage.InstrumentRepository
+	blocklist      storage.BlocklistRepository
+	audit          storage.AuditRepository
+	events         storage.EventRepository
+	originals      storage.OriginalRepository
+	attempts       storage.AttemptRepository
+	fs             *storage.FileSystem
+	processor      *testutil.FakeProcessor
+	db             *sqlx.DB
+}
+
+func newTestService(t *testing.T, github, llm *testutil.FakeProvider, policy AcceptancePolicy, extra ...Option) *serviceDeps {
+	t.Helper()
+
+	db := testutil.NewDB(t)
+	fs := testutil.NewFileSystem(t)
+	d := &serviceDeps{
+		github:         github,
+		llm:            llm,
+		logoRepo:       storage.NewLogoRepository(db),
+		requestedRepo:  storage.NewRequestedSymbolRepository(db),
+		instrumentRepo: storage.NewInstrumentRepository(db),
+		blocklist:      storage.NewBlocklistRepository(db),
+		audit:          storage.NewAuditRepository(db),
+		events:         storage.NewEventRepository(db),
+		originals:      storage.NewOriginalRepository(db),
+		attempts:       storage.NewAttemptRepository(db),
+		fs:             fs,
+		processor:      &testutil.FakeProcessor{FS: fs},
+		db:             db,
+	}
+
+	opts := []Option{WithPolicy(policy), WithBlocklist(d.blocklist), WithAuditLog(d.audit), WithEventLog(d.events), WithOriginals(d.originals), WithAttemptLog(d.attempts)}
+	if llm != nil {
+		opts = append(opts, WithLLMProvider(llm))
+	}
+	d.svc = NewLogoService(d.logoRepo, d.requestedRepo, d.instrumentRepo, fs,
+		d.processor, github, append(opts, extra...)...)
+	return d
+}
+
+func TestGetLogo_GitHubHitSkipsLLM(t *testing.T) {
+	logoData := []byte("github-image")
+	d := newTestService(t,
+		testutil.NewFakeProvider(&provider.LogoResult{Symbol: "AAPL", ImageData: logoData, Source: "github:test"}),
+		testutil.NewFakeProvider(),
+		AcceptancePolicy{},
+	)
+
+	data, err := d.svc.GetLogo(context.Background(), "AAPL", model.SizeM)
+	if err != nil {
+		t.Fatalf("GetLogo failed: %v", err)
+	}
+	if string(data) != string(logoData) {
+		t.Errorf("unexpected data: %q", data)
+	}
+	if calls := d.llm.Calls(); len(calls) != 0 {
+		t.Errorf("LLM should not be called on a GitHub hit, got %v", calls)
+	}
+
+	// Second request is served from cache — no provider calls at all.
+	if _, err := d.svc.GetLogo(context.Background(), "AAPL", model.SizeS); err != nil {
+		t.Fatalf("cached GetLogo failed: %v", err)
+	}
+	if calls := d.github.Calls(); len(calls) != 1 {
+		t.Errorf("expected 1 GitHub call, got %v", calls)
+	}
+
+	// Every processed logo gets scored (this fake image scores 0).
+	logo, err := d.logoRepo.GetBySymbol(context.Background(), "AAPL")
+	if err != nil {
+		t.Fatalf("GetBySymbol: %v", err)
+	}
+	if logo.QualityScore == nil {
+		t.Error("expected a quality score after processing")
+	}
+}
+
+func TestGetLogo_FallsBackToLLMWithCompanyName(t *testing.T) {
+	d := newTestService(t,
+		testutil.NewFakeProvider(),
+		testutil.NewFakeProvider(&provider.LogoResult{Symbol: "SAN.MC", ImageData: []byte("llm"), Source: "llm:test", Confidence: model.ConfidenceHigh}),
+		AcceptancePolicy{},
+	)
+	ctx := context.Background()
+
+	if err := d.instrumentRepo.Upsert(ctx, &model.Instrument{Symbol: "SAN.MC", Name: "Banco Santander"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := d.svc.GetLogo(ctx, "SAN.MC", model.SizeM); err != nil {
+		t.Fatalf("GetLogo failed: %v", err)
+	}
+	if got := d.llm.CompanyNames(); !reflect.DeepEqual(got, []string{"Banco Santander"}) {
+		t.Errorf("expected company name hint, got %v", got)
+	}
+
+	logo, err := d.logoRepo.GetBySymbol(ctx, "SAN.MC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if logo.Status != model.StatusProcessed || logo.Source != "llm:test" {
+		t.Errorf("unexpected record: status=%s source=%s", logo.Status, logo.Source)
+	}
+}
+
+func TestGetLogo_IndexBeforeGitHub(t *testing.T) {
+	index := testutil.NewFakeProvider(&provider.LogoResult{Symbol: "AAPL", ImageData: []byte("curated"), Source: "index:test"})
+	d := newTestService(t,
+		testutil.NewFakeProvider(
+			&provider.LogoResult{Symbol: "AAPL", ImageData: []byte("github"), Source: "github:test"},
+			&provider.LogoResult{Symbol: "MSFT", ImageData: []byte("github"), Source: "github:test"},
+		),
+		nil,
+		AcceptancePolicy{},
+		WithIndexProvider(index),
+	)
+	ctx := context.Background()
+
+	data, err := d.svc.GetLogo(ctx, "AAPL", model.SizeM)
+	if err != nil {
+		t.Fatalf("GetLogo failed: %v", err)
+	}
+	if string(data) != "curated" {
+		t.Errorf("expected the curated image, got %q", data)
+	}
+	if calls := d.github.Calls(); len(calls) != 0 {
+		t.Errorf("expected GitHub not to be asked, got %v", calls)
+	}
+
+	// Symbols the index doesn't list fall through to GitHub.
+	if _, err := d.svc.GetLogo(ctx, "MSFT", model.SizeM); err != nil {
+		t.Fatalf("GetLogo failed: %v", err)
+	}
+	if calls := d.github.Calls(); len(calls) != 1 || calls[0] != "MSFT" {
+		t.Errorf("expected GitHub to be asked for MSFT, got %v", calls)
+	}
+}
+
+func TestGetLogo_RaceStrategy(t *testing.T) {
+	// The index never answers: racing, GitHub's logo wins without waiting.
+	index := testutil.NewFakeProvider()
+	index.Hang = true
+	d := newTestService(t,
+		testutil.NewFakeProvider(&provider.LogoResult{Symbol: "AAPL", ImageData: []byte("github"), Source: "github:test"}),
+		nil,
+		AcceptancePolicy{},
+		WithIndexProvider(index),
+		WithStrategy(StrategyRace),
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	data, err := d.svc.GetLogo(ctx, "AAPL", model.SizeM)
+	if err != nil {
+		t.Fatalf("GetLogo failed: %v", err)
+	}
+	if string(data) != "github" {
+		t.Errorf("expected GitHub's image, got %q", data)
+	}
+
+	// When every layer misses, it's still a plain miss.
+	d = newTestService(t, testutil.NewFakeProvider(), nil, AcceptancePolicy{},
+		WithIndexProvider(testutil.NewFakeProvider()),
+		WithStrategy(StrategyRace),
+	)
+	if _, err := d.svc.GetLogo(ctx, "MSFT", model.SizeM); !errors.Is(err, ErrLogoNotFound) {
+		t.Errorf("expected ErrLogoNotFound, got %v", err)
+	}
+}
+
+func TestGetLogo_RecordsAttempts(t *testing.T) {
+	github := testutil.NewFakeProvider()
+	github.Err = fmt.Errorf("%w: timeout", provider.ErrUnavailable)
+	d := newTestService(t, github,
+		testutil.NewFakeProvider(&provider.LogoResult{Symbol: "XYZ", ImageData: []byte("llm"), Source: "llm:test", Confidence: model.ConfidenceHigh}),
+		AcceptancePolicy{},
+		WithIndexProvider(testutil.NewFakeProvider()),
+	)
+	ctx := context.Background()
+
+	if _, err := d.svc.GetLogo(ctx, "XYZ", model.SizeM); err != nil {
+		t.Fatalf("GetLogo failed: %v", err)
+	}
+	attempts, err := d.svc.ListAttempts(ctx, "XYZ", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Newest first: the LLM found it after the index missed and GitHub failed.
+	want := []struct{ provider, outcome string }{
+		{"llm", model.AttemptFound},
+		{"github", model.AttemptUnavailable},
+		{"index", model.AttemptMiss},
+	}
+	if len(attempts) != len(want) {
+		t.Fatalf("expected %d attempts, got %+v", len(want), attempts)
+	}
+	for i, w := range want {
+		a := attempts[i]
+		if a.Provider != w.provider || a.Outcome != w.outcome {
+			t.Errorf("attempt %d: got %s/%s, want %s/%s", i, a.Provider, a.Outcome, w.provider, w.outcome)
+		}
+		if a.Trace != attempts[0].Trace {
+			t.Errorf("attempt %d: expected one trace for the miss, got %q and %q", i, a.Trace, attempts[0].Trace)
+		}
+	}
+	if attempts[0].Source != "llm:test" || attempts[1].Error == "" {
+		t.Errorf("expected the source of the hit and the error of the failure, got %+v", attempts)
+	}
+
+	// Served from the cache: nothing more is asked, nothing more recorded.
+	if _, err := d.svc.GetLogo(ctx, "XYZ", model.SizeS); err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := d.svc.ListAttempts(ctx, "XYZ", 10); len(again) != len(want) {
+		t.Errorf("expected no new attempts for a cache hit, got %d", len(again))
+	}
+}
+
+func TestReplaceLogo(t *testing.T) {
+	d := newTestService(t, testutil.NewFakeProvider(), nil, AcceptancePolicy{})
+	ctx := context.Background()
+
+	if err := d.svc.ProcessAndStore(ctx, &provider.LogoResult{Symbol: "AAPL", ImageData: []byte("github"), Source: "github:test"}); err != nil {
+		t.Fatalf("ProcessAndStore: %v", err)
+	}
+
+	correction := &provider.LogoResult{Symbol: "AAPL", ImageData: []byte("curated"), Source: "index:test", License: "CC0"}
+	if err := d.svc.ReplaceLogo(ctx, correction); err != nil {
+		t.Fatalf("ReplaceLogo: %v", err)
+	}
+	logo, err := d.logoRepo.GetBySymbol(ctx, "AAPL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if logo.Status != model.StatusProcessed || logo.Source != "index:test" || logo.License != "CC0" {
+		t.Errorf("unexpected record: %+v", logo)
+	}
+	if data, _ := d.fs.Read("AAPL", model.SizeM); string(data) != "curated" {
+		t.Errorf("expected the curated image on disk, got %q", data)
+	}
+	events, err := d.events.ListSince(ctx, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := events[len(events)-1]; last.Type != model.EventUpdated {
+		t.Errorf("expected an updated event, got %+v", last)
+	}
+
+	// The same image again changes nothing.
+	if err := d.svc.ReplaceLogo(ctx, correction); !errors.Is(err, ErrUnchanged) {
+		t.Errorf("expected ErrUnchanged, got %v", err)
+	}
+}
+
+func TestGetLogo_AllProvidersMissRecordsDemand(t *testing.T) {
+	d := newTestService(t, testutil.NewFakeProvider(), testutil.NewFakeProvider(), AcceptancePolicy{})
+	ctx := context.Background()
+
+	if _, err := d.svc.GetLogo(ctx, "NOPE", model.SizeM); err == nil {
+		t.Fatal("expected an error when no provider has the logo")
+	}
+
+	missing, err := d.requestedRepo.ListMissing(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(missing) != 1 || missing[0].Symbol != "NOPE" {
+		t.Errorf("expected NOPE to be recorded as missing, got %+v", missing)
+	}
+}
+
+func TestGetLogo_NilLLMProvider(t *testing.T) {
+	d := newTestService(t, testutil.NewFakeProvider(), nil, AcceptancePolicy{})
+
+	if _, err := d.svc.GetLogo(context.Background(), "NOPE", model.SizeM); err == nil {
+		t.Fatal("expected an error without any provider hit")
+	}
+}
+
+func TestGetLogo_LowConfidencePolicy(t *testing.T) {
+	tests := []struct {
+		name       string
+		action     string
+		wantErr    error
+		wantStatus model.LogoStatus
+	}{
+		{"review", LowConfidenceReview, ErrPendingReview, model.StatusReview},
+		{"reject", LowConfidenceReject, ErrLowConfidence, model.StatusFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newTestService(t,
+				testutil.NewFakeProvider(),
+				testutil.NewFakeProvider(&provider.LogoResult{Symbol: "ACME", ImageData: []byte("x"), Confidence: model.ConfidenceLow}),
+				AcceptancePolicy{MinConfidence: model.ConfidenceMedium, LowConfidenceAction: tt.action},
+			)
+			ctx := context.Background()
+
+			_, err := d.svc.GetLogo(ctx, "ACME", model.SizeM)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+
+			logo, err := d.logoRepo.GetBySymbol(ctx, "ACME")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if logo.Status != tt.wantStatus {
+				t.Errorf("expected status %s, got %s", tt.wantStatus, logo.Status)
+			}
+		})
+	}
+}
+
+func TestGetLogo_RequireKnownSymbolSkipsLLM(t *testing.T) {
+	d := newTestService(t,
+		testutil.NewFakeProvider(),
+		testutil.NewFakeProvider(&provider.LogoResult{Symbol: "JUNK", ImageData: []byte("x")}),
+		AcceptancePolicy{RequireKnownSymbol: true},
+	)
+	ctx := context.Background()
+
+	// Once any instrument is imported, unknown symbols no longer reach the LLM.
+	if err := d.instrumentRepo.Upsert(ctx, &model.Instrument{Symbol: "AAPL", Name: "Apple Inc."}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := d.svc.GetLogo(ctx, "JUNK", model.SizeM); err == nil {
+		t.Fatal("expected an error for an unknown symbol")
+	}
+	if calls := d.llm.Calls(); len(calls) != 0 {
+		t.Errorf("LLM should not be called for unknown symbols, got %v", calls)
+	}
+}
+
+func TestGetLogo_InvalidSymbol(t *testing.T) {
+	d := newTestService(t, testutil.NewFakeProvider(), testutil.NewFakeProvider(), AcceptancePolicy{})
+
+	_, err := d.svc.GetLogo(context.Background(), "../etc", model.SizeM)
+	if !errors.Is(err, model.ErrInvalidSymbol) {
+		t.Fatalf("expected ErrInvalidSymbol, got %v", err)
+	}
+	if calls := d.github.Calls(); len(calls) != 0 {
+		t.Errorf("providers should not be called for invalid symbols, got %v", calls)
+	}
+}
+
+func TestGetLogo_AcquireGate(t *testing.T) {
+	d := newTestService(t,
+		testutil.NewFakeProvider(&provider.LogoResult{Symbol: "AAPL", ImageData: []byte("img"), Source: "github:test"}),
+		nil, AcceptancePolicy{})
+	open, asked := false, 0
+	ctx := WithAcquireGate(context.Background(), func() bool {
+		asked++
+		return open
+	})
+
+	_, err := d.svc.GetLogo(ctx, "AAPL", model.SizeM)
+	if !errors.Is(err, ErrAcquisitionLimited) {
+		t.Fatalf("expected ErrAcquisitionLimited, got %v", err)
+	}
+	if calls := d.github.Calls(); len(calls) != 0 {
+		t.Errorf("expected no provider calls when the gate is shut, got %v", calls)
+	}
+
+	open = true
+	if _, err := d.svc.GetLogo(ctx, "AAPL", model.SizeM); err != nil {
+		t.Fatalf("GetLogo: %v", err)
+	}
+	// Cache hits don't ask the gate.
+	open = false
+	if _, err := d.svc.GetLogo(ctx, "AAPL", model.SizeS); err != nil {
+		t.Fatalf("cached GetLogo: %v", err)
+	}
+	if asked != 2 {
+		t.Errorf("expected the gate to be asked for the 2 misses only, got %d", asked)
+	}
+}
+
+func TestGetLogo_AcquisitionFlagOff(t *testing.T) {
+	set, err := flags.New(map[string]bool{string(flags.OnDemandAcquisition): false}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := newTestService(t,
+		testutil.NewFakeProvider(&provider.LogoResult{Symbol: "AAPL", ImageData: []byte("img"), Source: "github:test"}),
+		nil, AcceptancePolicy{}, WithFlags(set))
+	ctx := context.Background()
+
+	if _, err := d.svc.GetLogo(ctx, "AAPL", model.SizeM); !errors.Is(err, ErrAcquisitionDisabled) {
+		t.Fatalf("expected ErrAcquisitionDisabled, got %v", err)
+	}
+	if calls := d.github.Calls(); len(calls) != 0 {
+		t.Errorf("expected no provider calls with the flag off, got %v", calls)
+	}
+
+	// Turned back on at runtime, the next miss is acquired.
+	if err := set.Override(ctx, string(flags.OnDemandAcquisition), true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.svc.GetLogo(ctx, "AAPL", model.SizeM); err != nil {
+		t.Fatalf("GetLogo with the flag on: %v", err)
+	}
+}
+
+func TestGetLogo_MaintenanceMode(t *testing.T) {
+	set, err := flags.New(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := newTestService(t,
+		testutil.NewFakeProvider(&provider.LogoResult{Symbol: "AAPL", ImageData: []byte("img"), Source: "github:test"}),
+		nil, AcceptancePolicy{}, WithFlags(set))
+	ctx := context.Background()
+	if _, err := d.svc.GetLogo(ctx, "AAPL", model.SizeM); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := set.Override(ctx, string(flags.MaintenanceMode), true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.svc.GetLogo(ctx, "AAPL", model.SizeM); err != nil {
+		t.Errorf("expected cached logos to be served during maintenance, got %v", err)
+	}
+	if _, err := d.svc.GetLogo(ctx, "MSFT", model.SizeM); !errors.Is(err, ErrMaintenance) {
+		t.Errorf("expected ErrMaintenance for a miss, got %v", err)
+	}
+	if calls := d.github.Calls(); len(calls) != 1 {
+		t.Errorf("expected no provider call during maintenance, got %v", calls)
+	}
+}
+
+func TestGetLogo_FromCatalog(t *testing.T) {
+	d := newTestService(t,
+		testutil.NewFakeProvider(&provider.LogoResult{Symbol: "AAPL", ImageData: []byte("img"), Source: "github:test"}),
+		nil, AcceptancePolicy{})
+	ctx := context.Background()
+	if _, err := d.svc.GetLogo(ctx, "AAPL", model.SizeM); err != nil {
+		t.Fatal(err)
+	}
+
+	catalog := storage.NewCatalog(d.logoRepo, d.blocklist)
+	if err := catalog.Load(ctx, d.events); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewLogoService(catalog.Logos(), d.requestedRepo, d.instrumentRepo, d.fs, d.processor, d.github,
+		WithBlocklist(catalog.Blocklist()), WithEventLog(d.events), WithCatalog(catalog))
+
+	// A change behind the catalog's back (no event) isn't seen: the status
+	// came from memory, not from the database.
+	if err := d.logoRepo.SetStatus(ctx, "AAPL", model.StatusReview, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.GetLogo(ctx, "AAPL", model.SizeM); err != nil {
+		t.Fatalf("expected AAPL served from the catalog, got %v", err)
+	}
+
+	// The service's own writes go through it.
+	if err := svc.BlockSymbol(ctx, "AAPL", "test"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.GetLogo(ctx, "AAPL", model.SizeM); !errors.Is(err, storage.ErrSymbolBlocked) {
+		t.Errorf("expected ErrSymbolBlocked after BlockSymbol, got %v", err)
+	}
+}
+
+// lowSpace is a SpaceChecker whose verdict the test controls.
+type lowSpace struct{ low bool }
+
+func (l *lowSpace) Check() error {
+	if l.low {
+		return storage.ErrLowDiskSpace
+	}
+	return nil
+}
+
+func TestGetLogo_LowDiskSpaceServesCacheButRefusesAcquisition(t *testing.T) {
+	space := &lowSpace{}
+	d := newTestService(t,
+		testutil.NewFakeProvider(
+			&provider.LogoResult{Symbol: "AAPL", ImageData: []byte("aapl"), Source: "github:test"},
+			&provider.LogoResult{Symbol: "MSFT", ImageData: []byte("msft"), Source: "github:test"},
+		),
+		testutil.NewFakeProvider(),
+		AcceptancePolicy{},
+		WithSpaceChecker(space),
+	)
+	ctx := context.Background()
+
+	if _, err := d.svc.GetLogo(ctx, "AAPL", model.SizeM); err != nil {
+		t.Fatalf("GetLogo failed: %v", err)
+	}
+
+	space.low = true
+
+	if _, err := d.svc.GetLogo(ctx, "AAPL", model.SizeS); err != nil {
+		t.Errorf("cached logo should still be served, got %v", err)
+	}
+
+	_, err := d.svc.GetLogo(ctx, "MSFT", model.SizeM)
+	if !errors.Is(err, storage.ErrLowDiskSpace) {
+		t.Fatalf("expected ErrLowDiskSpace, got %v", err)
+	}
+	if calls := d.github.Calls(); len(calls) != 1 {
+		t.Errorf("providers should not be called while space is low, got %v", calls)
+	}
+	if _, err := d.logoRepo.GetBySymbol(ctx, "MSFT"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("no record should be created while space is low, got %v", err)
+	}
+}
+
+func TestGetLogo_BlockedSymbol(t *testing.T) {
+	d := newTestService(t,
+		testutil.NewFakeProvider(
+			&provider.LogoResult{Symbol: "AAPL", ImageData: []byte("aapl"), Source: "github:test"},
+			&provider.LogoResult{Symbol: "ENRN", ImageData: []byte("enrn"), Source: "github:test"},
+		),
+		nil,
+		AcceptancePolicy{},
+	)
+	ctx := context.Background()
+
+	if _, err := d.svc.GetLogo(ctx, "AAPL", model.SizeM); err != nil {
+		t.Fatalf("GetLogo failed: %v", err)
+	}
+	if err := d.svc.BlockSymbol(ctx, "AAPL", "legal takedown"); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.svc.BlockSymbol(ctx, "ENRN", "delisted"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Cached or not, blocked symbols are refused and never acquired.
+	for _, symbol := range []string{"AAPL", "ENRN"} {
+		if _, err := d.svc.GetLogo(ctx, symbol, model.SizeM); !errors.Is(err, storage.ErrSymbolBlocked) {
+			t.Errorf("GetLogo(%s): expected ErrSymbolBlocked, got %v", symbol, err)
+		}
+	}
+	if _, err := d.svc.GetMetadata(ctx, "AAPL"); !errors.Is(err, storage.ErrSymbolBlocked) {
+		t.Errorf("GetMetadata: expected ErrSymbolBlocked, got %v", err)
+	}
+	if calls := d.github.Calls(); !reflect.DeepEqual(calls, []string{"AAPL"}) {
+		t.Errorf("blocked symbols should not be acquired, got calls %v", calls)
+	}
+
+	// Imports go through ProcessAndStore and must skip it too.
+	err := d.svc.ProcessAndStore(ctx, &provider.LogoResult{Symbol: "ENRN", ImageData: []byte("enrn"), Source: "github:test"})
+	if !errors.Is(err, storage.ErrSymbolBlocked) {
+		t.Errorf("ProcessAndStore: expected ErrSymbolBlocked, got %v", err)
+	}
+	if _, err := d.logoRepo.GetBySymbol(ctx, "ENRN"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("blocked import should not create a record, got %v", err)
+	}
+
+	// Blocking is a soft delete: unblocking serves the kept logo without re-acquiring.
+	if err := d.svc.UnblockSymbol(ctx, "AAPL"); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := d.svc.GetLogo(ctx, "AAPL", model.SizeM); err != nil || string(data) != "aapl" {
+		t.Errorf("after unblock: got %q, %v", data, err)
+	}
+	if calls := d.github.Calls(); len(calls) != 1 {
+		t.Errorf("unblocked logo should come from cache, got calls %v", calls)
+	}
+}
+
+func TestTakedown(t *testing.T) {
+	d := newTestService(t,
+		testutil.NewFakeProvider(&provider.LogoResult{
+			Symbol: "AAPL", ImageData: []byte("aapl"), Source: "github:test",
+			License: "MIT", Attribution: "github.com/test",
+		}),
+		nil,
+		AcceptancePolicy{},
+	)
+	ctx := context.Background()
+
+	if _, err := d.svc.GetLogo(ctx, "AAPL", model.SizeM); err != nil {
+		t.Fatalf("GetLogo failed: %v", err)
+	}
+	if err := d.svc.Takedown(ctx, "AAPL", "trademark complaint"); err != nil {
+		t.Fatalf("Takedown failed: %v", err)
+	}
+
+	if _, err := d.svc.GetLogo(ctx, "AAPL", model.SizeM); !errors.Is(err, storage.ErrSymbolBlocked) {
+		t.Errorf("expected ErrSymbolBlocked after takedown, got %v", err)
+	}
+	if d.fs.Exists("AAPL", model.SizeM) {
+		t.Error("expected files to be deleted")
+	}
+
+	// The record survives with its provenance, so the takedown can be traced.
+	logo, err := d.logoRepo.GetBySymbol(ctx, "AAPL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if logo.HasM || logo.Status != model.StatusFailed || logo.License != "MIT" || logo.Attribution != "github.com/test" {
+		t.Errorf("unexpected record after takedown: %+v", logo)
+	}
+
+	entries, err := d.svc.ListAudit(ctx, "AAPL", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Action != model.AuditTakedown || entries[0].Details != "trademark complaint" {
+		t.Errorf("unexpected audit log: %+v", entries)
+	}
+}
+
+func TestUpdateMetadata(t *testing.T) {
+	d := newTestService(t, testutil.NewFakeProvider(), nil, AcceptancePolicy{})
+	ctx := context.Background()
+	if err := d.svc.ProcessAndStore(ctx, &provider.LogoResult{Symbol: "AAPL", ImageData: []byte("img"), Source: "github:test", License: "MIT"}); err != nil {
+		t.Fatal(err)
+	}
+	str := func(s string) *string { return &s }
+
+	changed, err := d.svc.UpdateMetadata(ctx, "AAPL", model.MetadataUpdate{
+		CompanyName: str(" Apple Inc. "),
+		Website:     str("https://apple.com"),
+		License:     str(""),
+	})
+	if err != nil || !changed {
+		t.Fatalf("UpdateMetadata: changed=%v err=%v", changed, err)
+	}
+	logo, err := d.logoRepo.GetBySymbol(ctx, "AAPL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if logo.CompanyName != "Apple Inc." || logo.Website != "https://apple.com" || logo.License != "" || logo.Source != "github:test" {
+		t.Errorf("unexpected record: %+v", logo)
+	}
+	entries, _ := d.audit.List(ctx, "AAPL", 10)
+	if len(entries) != 1 || entries[0].Action != model.AuditMetadata {
+		t.Errorf("expected one metadata audit entry, got %+v", entries)
+	}
+
+	// The same values again change nothing and aren't audited.
+	if changed, err := d.svc.UpdateMetadata(ctx, "AAPL", model.MetadataUpdate{CompanyName: str("Apple Inc.")}); err != nil || changed {
+		t.Errorf("expected no change, got changed=%v err=%v", changed, err)
+	}
+	if entries, _ := d.audit.List(ctx, "AAPL", 10); len(entries) != 1 {
+		t.Errorf("expected no new audit entry, got %d", len(entries))
+	}
+
+	if _, err := d.svc.UpdateMetadata(ctx, "AAPL", model.MetadataUpdate{Website: str("apple.com")}); !errors.Is(err, ErrInvalidMetadata) {
+		t.Errorf("expected ErrInvalidMetadata for a bare domain, got %v", err)
+	}
+	if _, err := d.svc.UpdateMetadata(ctx, "MSFT", model.MetadataUpdate{Website: str("")}); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestListChanges(t *testing.T) {
+	d := newTestService(t,
+		testutil.NewFakeProvider(&provider.LogoResult{Symbol: "AAPL", ImageData: []byte("aapl"), Source: "github:test"}),
+		nil,
+		AcceptancePolicy{},
+	)
+	ctx := context.Background()
+
+	if _, err := d.svc.GetLogo(ctx, "AAPL", model.SizeM); err != nil {
+		t.Fatalf("GetLogo failed: %v", err)
+	}
+	// Served from cache: nothing changed, so no event.
+	if _, err := d.svc.GetLogo(ctx, "AAPL", model.SizeS); err != nil {
+		t.Fatalf("cached GetLogo failed: %v", err)
+	}
+	if err := d.svc.SendToReview(ctx, "AAPL"); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.svc.ApproveReview(ctx, "AAPL"); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := d.svc.ListChanges(ctx, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range events {
+		got = append(got, e.Type+" "+e.Symbol)
+	}
+	want := []string{"created AAPL", "deleted AAPL", "updated AAPL"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+
+	rest, err := d.svc.ListChanges(ctx, events[len(events)-1].ID, 10)
+	if err != nil || len(rest) != 0 {
+		t.Errorf("expected nothing after the last event, got %v, %v", rest, err)
+	}
+}
+
+func TestProcessAndStore_WhitenBackgroundOverride(t *testing.T) {
+	d := newTestService(t, testutil.NewFakeProvider(), nil, AcceptancePolicy{},
+		WithProcessDefaults(model.ProcessOptions{WhitenBackground: true}))
+	ctx := context.Background()
+	result := &provider.LogoResult{Symbol: "AAPL", ImageData: []byte("img"), Source: "github:test"}
+
+	// A new logo has no override: the default applies.
+	if err := d.svc.ProcessAndStore(ctx, result); err != nil {
+		t.Fatalf("ProcessAndStore: %v", err)
+	}
+	if !d.processor.Options.WhitenBackground {
+		t.Error("expected the default whiten_background=true for a new logo")
+	}
+
+	off := false
+	if err := d.svc.SetWhitenBackground(ctx, "AAPL", &off); err != nil {
+		t.Fatalf("SetWhitenBackground: %v", err)
+	}
+	// Processed logos are skipped; fail it so the next result is processed.
+	if err := d.logoRepo.SetStatus(ctx, "AAPL", model.StatusFailed, "test"); err != nil {
+		t.Fatalf("SetStatus: %v", err)
+	}
+	if err := d.svc.ProcessAndStore(ctx, result); err != nil {
+		t.Fatalf("ProcessAndStore: %v", err)
+	}
+	if d.processor.Options.WhitenBackground {
+		t.Error("expected the logo's override whiten_background=false to win")
+	}
+
+	if err := d.svc.SetWhitenBackground(ctx, "MSFT", nil); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a missing logo, got %v", err)
+	}
+}
+
+func TestGetLogoDefault_AppliesDefaultBackground(t *testing.T) {
+	// Rendering needs libvips: seed the variant cache instead.
+	cache := NewVariantCache(storage.NewVariantRepository(testutil.NewDB(t)), testutil.NewFileSystem(t), 1<<20, zap.NewNop())
+	d := newTestService(t, testutil.NewFakeProvider(), nil, AcceptancePolicy{}, WithCache(cache))
+	ctx := context.Background()
+	result := &provider.LogoResult{Symbol: "AAPL", ImageData: []byte("img"), Source: "github:test"}
+	if err := d.svc.ProcessAndStore(ctx, result); err != nil {
+		t.Fatalf("ProcessAndStore: %v", err)
+	}
+	plain, err := d.svc.GetLogo(ctx, "AAPL", model.SizeM)
+	if err != nil {
+		t.Fatalf("GetLogo: %v", err)
+	}
+
+	if err := d.svc.SetDefaultBackground(ctx, "AAPL", "#FFAA00"); err != nil {
+		t.Fatalf("SetDefaultBackground: %v", err)
+	}
+	logo, err := d.logoRepo.GetBySymbol(ctx, "AAPL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if logo.DefaultBackground != "ffaa00" {
+		t.Errorf("expected the color stored normalized as ffaa00, got %q", logo.DefaultBackground)
+	}
+
+	if err := cache.Put(ctx, "AAPL", model.SizeM, "bg_ffaa00", []byte("on-orange")); err != nil {
+		t.Fatal(err)
+	}
+	data, err := d.svc.GetLogoDefault(ctx, "AAPL", model.SizeM)
+	if err != nil {
+		t.Fatalf("GetLogoDefault: %v", err)
+	}
+	if string(data) != "on-orange" {
+		t.Errorf("expected the logo on its default background, got %q", data)
+	}
+
+	// Cleared: served as stored again.
+	if err := d.svc.SetDefaultBackground(ctx, "AAPL", ""); err != nil {
+		t.Fatalf("clearing: %v", err)
+	}
+	data, err = d.svc.GetLogoDefault(ctx, "AAPL", model.SizeM)
+	if err != nil {
+		t.Fatalf("GetLogoDefault: %v", err)
+	}
+	if !bytes.Equal(data, plain) {
+		t.Errorf("expected the plain logo once cleared, got %q", data)
+	}
+
+	if err := d.svc.SetDefaultBackground(ctx, "AAPL", "blue"); !errors.Is(err, ErrInvalidColor) {
+		t.Errorf("expected ErrInvalidColor, got %v", err)
+	}
+	if err := d.svc.SetDefaultBackground(ctx, "MSFT", "ffffff"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a missing logo, got %v", err)
+	}
+}
+
+func TestProcessAndStore_SanitizesSVG(t *testing.T) {
+	d := newTestService(t, testutil.NewFakeProvider(), nil, AcceptancePolicy{})
+	ctx := context.Background()
+
+	svg := &provider.LogoResult{Symbol: "AAPL", ImageData: []byte(`<svg onload="x()"><rect width="1"/></svg>`), Source: "import"}
+	if err := d.svc.ProcessAndStore(ctx, svg); err != nil {
+		t.Fatalf("ProcessAndStore: %v", err)
+	}
+	if data, _ := d.fs.Read("AAPL", model.SizeM); string(data) != `<svg><rect width="1"></rect></svg>` {
+		t.Errorf("expected the sanitized SVG to be processed, got %q", data)
+	}
+	if string(svg.ImageData) != `<svg onload="x()"><rect width="1"/></svg>` {
+		t.Error("the caller's result must not be modified")
+	}
+
+	broken := &provider.LogoResult{Symbol: "MSFT", ImageData: []byte(`<svg><g></svg>`), Source: "import"}
+	if err := d.svc.ProcessAndStore(ctx, broken); !errors.Is(err, imagefmt.ErrUnsafeSVG) {
+		t.Errorf("expected ErrUnsafeSVG, got %v", err)
+	}
+	if _, err := d.logoRepo.GetBySymbol(ctx, "MSFT"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected no record for a rejected image, got %v", err)
+	}
+}
+
+func TestGetLogo_AcquisitionPending(t *testing.T) {
+	d := newTestService(t,
+		testutil.NewFakeProvider(&provider.LogoResult{Symbol: "AAPL", ImageData: []byte("img"), Source: "github:test"}),
+		nil,
+		AcceptancePolicy{},
+	)
+	ctx := context.Background()
+
+	// Another request is acquiring it: don't acquire (and pay) twice.
+	d.svc.startAcquiring("AAPL")
+	if _, err := d.svc.GetLogo(ctx, "AAPL", model.SizeM); !errors.Is(err, ErrAcquisitionPending) {
+		t.Fatalf("expected ErrAcquisitionPending, got %v", err)
+	}
+	if calls := d.github.Calls(); len(calls) != 0 {
+		t.Errorf("expected no provider calls while acquiring, got %v", calls)
+	}
+	d.svc.doneAcquiring("AAPL")
+
+	// A fresh pending record (an import mid-way) is pending too.
+	if err := d.logoRepo.Create(ctx, &model.Logo{Symbol: "AAPL", Source: "github:test", Status: model.StatusPending}); err != nil {
+		t.Fatalf("creating record: %v", err)
+	}
+	if _, err := d.svc.GetLogo(ctx, "AAPL", model.SizeM); !errors.Is(err, ErrAcquisitionPending) {
+		t.Fatalf("expected ErrAcquisitionPending for a pending record, got %v", err)
+	}
+}
+
+func TestGetLogo_StalePendingIsAcquiredAgain(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	d := newTestService(t,
+		testutil.NewFakeProvider(&provider.LogoResult{Symbol: "AAPL", ImageData: []byte("img"), Source: "github:test"}),
+		nil,
+		AcceptancePolicy{},
+		WithClock(fake),
+	)
+	ctx := context.Background()
+
+	logo := &model.Logo{Symbol: "AAPL", Source: "github:test", Status: model.StatusPending}
+	if err := d.logoRepo.Create(ctx, logo); err != nil {
+		t.Fatalf("creating record: %v", err)
+	}
+	// Left behind by a crash long ago.
+	fake.Advance(time.Hour)
+
+	if _, err := d.svc.GetLogo(ctx, "AAPL", model.SizeM); err != nil {
+		t.Fatalf("expected a stale pending logo to be acquired again, got %v", err)
+	}
+}
+
+func TestGetLogo_NotFoundUntilNextCheck(t *testing.T) {
+	github := testutil.NewFakeProvider()
+	d := newTestService(t, github, nil, AcceptancePolicy{NotFoundRecheck: time.Hour})
+	ctx := context.Background()
+
+	if _, err := d.svc.GetLogo(ctx, "NOPE", model.SizeM); !errors.Is(err, ErrLogoNotFound) {
+		t.Fatalf("expected ErrLogoNotFound, got %v", err)
+	}
+	logo, err := d.logoRepo.GetBySymbol(ctx, "NOPE")
+	if err != nil {
+		t.Fatalf("expected a not_found record: %v", err)
+	}
+	if logo.Status != model.StatusNotFound || logo.NextCheckAt == nil {
+		t.Fatalf("expected not_found with a next check, got %s / %v", logo.Status, logo.NextCheckAt)
+	}
+	if until := time.Until(*logo.NextCheckAt); until < 59*time.Minute || until > time.Hour {
+		t.Errorf("expected the next check in about an hour, got %v", until)
+	}
+
+	// Before the next check: answered from the database, providers untouched.
+	if _, err := d.svc.GetLogo(ctx, "NOPE", model.SizeM); !errors.Is(err, ErrLogoNotFound) {
+		t.Fatalf("expected ErrLogoNotFound again, got %v", err)
+	}
+	if calls := github.Calls(); len(calls) != 1 {
+		t.Errorf("expected a single provider call, got %v", calls)
+	}
+
+	// The logo turns up upstream and an admin requeues the symbol.
+	github.Results["NOPE"] = &provider.LogoResult{Symbol: "NOPE", ImageData: []byte("img"), Source: "github:test"}
+	if err := d.svc.Requeue(ctx, "NOPE"); err != nil {
+		t.Fatalf("Requeue: %v", err)
+	}
+	if _, err := d.svc.GetLogo(ctx, "NOPE", model.SizeM); err != nil {
+		t.Fatalf("expected the requeued logo to be acquired, got %v", err)
+	}
+	logo, err = d.logoRepo.GetBySymbol(ctx, "NOPE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if logo.Status != model.StatusProcessed || logo.NextCheckAt != nil {
+		t.Errorf("expected processed without a next check, got %s / %v", logo.Status, logo.NextCheckAt)
+	}
+
+	// Only not_found logos can be requeued.
+	if err := d.svc.Requeue(ctx, "NOPE"); err == nil {
+		t.Error("expected an error requeueing a processed logo")
+	}
+}
+
+func TestGetLogo_NotFoundIsRecheckedWhenDue(t *testing.T) {
+	github := testutil.NewFakeProvider()
+	d := newTestService(t, github, nil, AcceptancePolicy{})
+	ctx := context.Background()
+
+	// A zero recheck interval makes every request search again.
+	for i := 0; i < 2; i++ {
+		if _, err := d.svc.GetLogo(ctx, "NOPE", model.SizeM); !errors.Is(err, ErrLogoNotFound) {
+			t.Fatalf("expected ErrLogoNotFound, got %v", err)
+		}
+	}
+	if calls := github.Calls(); len(calls) != 2 {
+		t.Errorf("expected a provider call per request, got %v", calls)
+	}
+}
+
+func TestGetLogo_NotFoundIsRecheckedAfterInterval(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	github := testutil.NewFakeProvider()
+	d := newTestService(t, github, nil, AcceptancePolicy{NotFoundRecheck: 24 * time.Hour}, WithClock(fake))
+	ctx := context.Background()
+
+	steps := []struct {
+		advance time.Duration
+		calls   int
+	}{
+		{0, 1},              // First miss: providers asked
+		{23 * time.Hour, 1}, // Before the recheck: answered from the record
+		{2 * time.Hour, 2},  // Due: asked again
+		{time.Hour, 2},      // And remembered for another interval
+	}
+	for i, step := range steps {
+		fake.Advance(step.advance)
+		if _, err := d.svc.GetLogo(ctx, "NOPE", model.SizeM); !errors.Is(err, ErrLogoNotFound) {
+			t.Fatalf("step %d: expected ErrLogoNotFound, got %v", i, err)
+		}
+		if calls := len(github.Calls()); calls != step.calls {
+			t.Errorf("step %d: expected %d provider calls, got %d", i, step.calls, calls)
+		}
+	}
+}
+
+func TestGetLogo_InvalidUntilNextCheck(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	llm := testutil.NewFakeProvider(&provider.LogoResult{Symbol: "ACME", ImageData: []byte("x"), Confidence: model.ConfidenceLow})
+	d := newTestService(t, testutil.NewFakeProvider(), llm,
+		AcceptancePolicy{MinConfidence: model.ConfidenceMedium, LowConfidenceAction: LowConfidenceReject, InvalidRecheck: time.Hour},
+		WithClock(fake))
+	ctx := context.Background()
+
+	if _, err := d.svc.GetLogo(ctx, "ACME", model.SizeM); !errors.Is(err, ErrLowConfidence) {
+		t.Fatalf("expected ErrLowConfidence, got %v", err)
+	}
+	logo, err := d.logoRepo.GetBySymbol(ctx, "ACME")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if logo.Status != model.StatusFailed || logo.NextCheckAt == nil {
+		t.Fatalf("expected failed with a next check, got %s / %v", logo.Status, logo.NextCheckAt)
+	}
+
+	// Before the next check: the same image isn't fetched again.
+	if _, err := d.svc.GetLogo(ctx, "ACME", model.SizeM); !errors.Is(err, ErrLogoNotFound) {
+		t.Fatalf("expected ErrLogoNotFound, got %v", err)
+	}
+	if calls := llm.Calls(); len(calls) != 1 {
+		t.Errorf("expected a single LLM call, got %v", calls)
+	}
+
+	fake.Advance(2 * time.Hour)
+	if _, err := d.svc.GetLogo(ctx, "ACME", model.SizeM); !errors.Is(err, ErrLowConfidence) {
+		t.Fatalf("expected the symbol to be acquired again when due, got %v", err)
+	}
+	if calls := llm.Calls(); len(calls) != 2 {
+		t.Errorf("expected a second LLM call, got %v", calls)
+	}
+}
+
+func TestGetLogo_RejectedDownloadUntilInvalidRecheck(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	github := testutil.NewFakeProvider()
+	github.Err = fmt.Errorf("logo for ACME rejected by every GitHub repo that has it: %w", &provider.ValidationError{
+		URL: "https://example.com/ACME.png", ContentType: "text/html", Reason: "content type is not an image", Err: imagefmt.ErrUnsupported,
+	})
+	d := newTestService(t, github, nil,
+		AcceptancePolicy{NotFoundRecheck: 24 * time.Hour, InvalidRecheck: time.Hour}, WithClock(fake))
+	ctx := context.Background()
+
+	if _, err := d.svc.GetLogo(ctx, "ACME", model.SizeM); !errors.Is(err, ErrLogoNotFound) || !errors.Is(err, imagefmt.ErrUnsupported) {
+		t.Fatalf("expected ErrLogoNotFound with the rejection, got %v", err)
+	}
+	logo, err := d.logoRepo.GetBySymbol(ctx, "ACME")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if logo.Status != model.StatusFailed || logo.ErrorMessage == nil || !strings.Contains(*logo.ErrorMessage, "content type is not an image") {
+		t.Errorf("expected failed with the rejection, got %s / %v", logo.Status, logo.ErrorMessage)
+	}
+	if logo.NextCheckAt == nil || !logo.NextCheckAt.Equal(fake.Now().Add(time.Hour)) {
+		t.Errorf("expected the invalid recheck, not the not-found one, got %v", logo.NextCheckAt)
+	}
+}
+
+func TestGetLogo_BudgetMissUntilNextCheck(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	github := testutil.NewFakeProvider()
+	llm := testutil.NewFakeProvider()
+	llm.Err = fmt.Errorf("%w: every LLM client is %w", provider.ErrUnavailable, provider.ErrDisabled)
+
+	tests := []struct {
+		name      string
+		recheck   time.Duration
+		wantCalls int
+	}{
+		{"remembered", 10 * time.Minute, 1},
+		{"not remembered", 0, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newTestService(t, github, llm,
+				AcceptancePolicy{NotFoundRecheck: 24 * time.Hour, BudgetRecheck: tt.recheck}, WithClock(fake))
+			ctx := context.Background()
+			before := len(github.Calls())
+
+			if _, err := d.svc.GetLogo(ctx, "NOPE", model.SizeM); !errors.Is(err, provider.ErrUnavailable) {
+				t.Fatalf("expected ErrUnavailable, got %v", err)
+			}
+			d.svc.GetLogo(ctx, "NOPE", model.SizeM)
+			if calls := len(github.Calls()) - before; calls != tt.wantCalls {
+				t.Errorf("expected %d provider calls, got %d", tt.wantCalls, calls)
+			}
+			if tt.recheck == 0 {
+				return
+			}
+
+			logo, err := d.logoRepo.GetBySymbol(ctx, "NOPE")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if logo.NextCheckAt == nil || !logo.NextCheckAt.Equal(fake.Now().Add(tt.recheck)) {
+				t.Errorf("expected the budget recheck, not the not-found one, got %v", logo.NextCheckAt)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/fleveque/logo-service/internal/flags"
+	"github.com/fleveque/logo-service/internal/model"
+	"github.com/fleveque/logo-service/internal/moderation"
+	"github.com/fleveque/logo-service/internal/provider"
+)
+
+// ModerationPolicy says which new logos the moderator screens, and what
+// happens to one when it can't answer.
+type ModerationPolicy struct {
+	// Sources are the kinds of provider screened: the part of a logo's
+	// source before ":" ("llm", "github"), or an index's name.
+	Sources []string
+
+	// ServeOnError serves a logo the moderator failed on. By default it's
+	// held for review, like a flagged one.
+	ServeOnError bool
+}
+
+// screens reports whether logos from source are moderated.
+func (p ModerationPolicy) screens(source string) bool {
+	kind, _, _ := strings.Cut(source, ":")
+	return slices.Contains(p.Sources, kind)
+}
+
+// moderate screens a freshly processed logo and returns why it must be held
+// for review, or "" to serve it. The moderator sees the logo as it will be
+// served: the xl size, a PNG. With the moderation flag off, every logo is
+// served.
+func (s *LogoService) moderate(ctx context.Context, result *provider.LogoResult) string {
+	if s.moderator == nil || !s.moderation.screens(result.Source) || !s.enabled(flags.Moderation) {
+		return ""
+	}
+
+	verdict, err := s.askModerator(ctx, result)
+	if err != nil {
+		s.logger.Warn("moderation failed",
+			zap.String("symbol", result.Symbol),
+			zap.String("moderator", s.moderator.Name()),
+			zap.Error(err),
+		)
+		if s.moderation.ServeOnError {
+			return ""
+		}
+		return fmt.Sprintf("moderation (%s) failed: %v", s.moderator.Name(), err)
+	}
+	if !verdict.Flagged {
+		return ""
+	}
+	reason := verdict.Reason
+	if reason == "" {
+		reason = "no reason given"
+	}
+	return fmt.Sprintf("flagged by moderation (%s): %s", s.moderator.Name(), reason)
+}
+
+func (s *LogoService) askModerator(ctx context.Context, result *provider.LogoResult) (moderation.Verdict, error) {
+	image, err := s.fs.Read(result.Symbol, model.SizeXL)
+	if err != nil {
+		return moderation.Verdict{}, fmt.Errorf("reading the rendered logo: %w", err)
+	}
+	return s.moderator.Moderate(ctx, moderation.Subject{
+		Symbol:      result.Symbol,
+		CompanyName: result.CompanyName,
+		Source:      result.Source,
+		OriginalURL: result.OriginalURL,
+		Image:       image,
+	})
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/fleveque/logo-service/internal/flags"
+	"github.com/fleveque/logo-service/internal/model"
+	"github.com/fleveque/logo-service/internal/moderation"
+	"github.com/fleveque/logo-service/internal/provider"
+	"github.com/fleveque/logo-service/internal/testutil"
+)
+
+type fakeModerator struct {
+	verdict moderation.Verdict
+	err     error
+	asked   []moderation.Subject
+}
+
+func (f *fakeModerator) Moderate(_ context.Context, subject moderation.Subject) (moderation.Verdict, error) {
+	f.asked = append(f.asked, subject)
+	return f.verdict, f.err
+}
+
+func (f *fakeModerator) Name() string { return "fake" }
+
+func TestProcessAndStore_Moderation(t *testing.T) {
+	tests := []struct {
+		name        string
+		source      string
+		verdict     moderation.Verdict
+		err         error
+		serveOnErr  bool
+		wantAsked   bool
+		wantStatus  model.LogoStatus
+		wantMessage string
+	}{
+		{"passed", "llm:anthropic", moderation.Verdict{}, nil, false, true, model.StatusProcessed, ""},
+		{"flagged", "llm:anthropic", moderation.Verdict{Flagged: true, Reason: "Microsoft's logo"}, nil, false, true, model.StatusReview, "flagged by moderation (fake): Microsoft's logo"},
+		{"source not screened", "github:acme/logos", moderation.Verdict{Flagged: true}, nil, false, false, model.StatusProcessed, ""},
+		{"error holds for review", "llm:openai", moderation.Verdict{}, errors.New("hook down"), false, true, model.StatusReview, "moderation (fake) failed: hook down"},
+		{"error serves if configured", "llm:openai", moderation.Verdict{}, errors.New("hook down"), true, true, model.StatusProcessed, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			moderator := &fakeModerator{verdict: tt.verdict, err: tt.err}
+			d := newTestService(t, testutil.NewFakeProvider(), nil, AcceptancePolicy{},
+				WithModeration(moderator, ModerationPolicy{Sources: []string{"llm"}, ServeOnError: tt.serveOnErr}))
+			ctx := context.Background()
+
+			result := &provider.LogoResult{Symbol: "AAPL", CompanyName: "Apple Inc.", ImageData: []byte("img"), Source: tt.source}
+			if err := d.svc.ProcessAndStore(ctx, result); err != nil {
+				t.Fatalf("ProcessAndStore: %v", err)
+			}
+
+			if asked := len(moderator.asked) > 0; asked != tt.wantAsked {
+				t.Fatalf("moderator asked = %v, want %v", asked, tt.wantAsked)
+			}
+			if tt.wantAsked {
+				// The fake processor stores the source bytes as every size.
+				if got := moderator.asked[0]; got.Symbol != "AAPL" || got.CompanyName != "Apple Inc." || string(got.Image) != "img" {
+					t.Errorf("unexpected subject %+v", got)
+				}
+			}
+
+			logo, err := d.logoRepo.GetBySymbol(ctx, "AAPL")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if logo.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", logo.Status, tt.wantStatus)
+			}
+			var message string
+			if logo.ErrorMessage != nil {
+				message = *logo.ErrorMessage
+			}
+			if message != tt.wantMessage {
+				t.Errorf("message = %q, want %q", message, tt.wantMessage)
+			}
+
+			entries, err := d.audit.List(ctx, "AAPL", 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			held := len(entries) > 0 && entries[0].Action == model.AuditModeration && strings.Contains(entries[0].Details, tt.wantMessage)
+			if held != (tt.wantStatus == model.StatusReview) {
+				t.Errorf("expected an audit entry only when held, got %+v", entries)
+			}
+		})
+	}
+}
+
+func TestProcessAndStore_ModerationFlagOff(t *testing.T) {
+	set, err := flags.New(map[string]bool{string(flags.Moderation): false}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	moderator := &fakeModerator{verdict: moderation.Verdict{Flagged: true, Reason: "off-brand"}}
+	d := newTestService(t, testutil.NewFakeProvider(), nil, AcceptancePolicy{},
+		WithModeration(moderator, ModerationPolicy{Sources: []string{"llm"}}), WithFlags(set))
+	ctx := context.Background()
+
+	result := &provider.LogoResult{Symbol: "AAPL", ImageData: []byte("img"), Source: "llm:anthropic"}
+	if err := d.svc.ProcessAndStore(ctx, result); err != nil {
+		t.Fatalf("ProcessAndStore: %v", err)
+	}
+	if len(moderator.asked) != 0 {
+		t.Errorf("expected the moderator not to be asked, got %d calls", len(moderator.asked))
+	}
+	logo, err := d.logoRepo.GetBySymbol(ctx, "AAPL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if logo.Status != model.StatusProcessed {
+		t.Errorf("status = %s, want %s", logo.Status, model.StatusProcessed)
+	}
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/fleveque/logo-service/internal/clock"
+	"github.com/fleveque/logo-service/internal/flight"
+	"github.com/fleveque/logo-service/internal/model"
+	"github.com/fleveque/logo-service/internal/moderation"
+	"github.com/fleveque/logo-service/internal/storage"
+)
+
+// Option configures an optional part of a LogoService at construction.
+//
+// Go note: this is the "functional options" pattern. Each option is a
+// function that sets one field, and NewLogoService applies them in order.
+// Adding a feature means adding a WithX function — the constructor's
+// signature, and every call site, stay the same.
+type Option func(*LogoService)
+
+// WithLLMProvider enables the paid LLM layer. Without it, the service
+// gracefully skips LLM search and only GitHub is asked.
+//
+// Go note: don't pass a nil *provider.LLMProvider. An interface holding a
+// typed nil pointer is itself non-nil, so the service would think it has
+// an LLM and the first call would panic. Leave the option out instead.
+func WithLLMProvider(llm LogoSearcher) Option {
+	return func(s *LogoService) { s.llmProvider = llm }
+}
+
+// WithIndexProvider adds a curated index (see provider.IndexProvider),
+// asked before GitHub: its entries are corrections, so they win.
+//
+// Go note: like WithLLMProvider, leave the option out rather than passing
+// a nil *provider.IndexProvider.
+func WithIndexProvider(index LogoFetcher) Option {
+	return func(s *LogoService) { s.indexProvider = index }
+}
+
+// WithPolicy sets what happens to provider results based on their
+// confidence, and to symbols no provider has. The zero policy serves
+// everything and asks the providers again on every miss.
+func WithPolicy(policy AcceptancePolicy) Option {
+	return func(s *LogoService) { s.policy = policy }
+}
+
+// WithValidator rejects the provider results validator refuses before
+// they're processed, on top of the processor's own format checks.
+func WithValidator(validator LogoValidator) Option {
+	return func(s *LogoService) { s.validator = validator }
+}
+
+// WithModeration screens new logos from the policy's sources with
+// moderator before they're served; flagged ones go to the review queue,
+// with the moderator's reason as their message.
+func WithModeration(moderator moderation.Moderator, policy ModerationPolicy) Option {
+	return func(s *LogoService) {
+		s.moderator = moderator
+		s.moderation = policy
+	}
+}
+
+// WithCircuitBreaker skips a provider layer (index, GitHub, LLM) after
+// policy.Failures consecutive failures, for policy.Cooldown, instead of
+// waiting for it to time out on every cache miss.
+func WithCircuitBreaker(policy CircuitPolicy) Option {
+	return func(s *LogoService) { s.circuitPolicy = policy }
+}
+
+// WithAttemptLog records every provider asked on a cache miss, with its
+// outcome, error and duration, for the admin trace endpoint.
+func WithAttemptLog(attempts storage.AttemptRepository) Option {
+	return func(s *LogoService) { s.attempts = attempts }
+}
+
+// WithStrategy sets how the free provider layers are asked on a cache miss:
+// StrategySequential (the default) or StrategyRace, which asks them all at
+// once to cut the latency of misses that would otherwise fall through.
+func WithStrategy(strategy string) Option {
+	return func(s *LogoService) { s.strategy = strategy }
+}
+
+// WithCatalog serves cached logos using the catalog's in-memory index
+// instead of querying the database on every request. The service's logo
+// repository and blocklist must be the catalog's (Logos and Blocklist), so
+// its writes keep the index current.
+func WithCatalog(catalog *storage.Catalog) Option {
+	return func(s *LogoService) { s.catalog = catalog }
+}
+
+// WithBatchCoalescing makes GetCachedLogos share identical batches between
+// concurrent callers, and reuse one for window after it was read: many
+// dashboards refreshing the same archive or sprite at once then cost one
+// pass over the symbols.
+func WithBatchCoalescing(window time.Duration) Option {
+	return func(s *LogoService) { s.batches = flight.NewGroup[[]CachedLogo](window) }
+}
+
+// WithSpaceChecker makes the service refuse new acquisitions (with an error
+// wrapping storage.ErrLowDiskSpace) while space is low. Cached logos are
+// still served.
+func WithSpaceChecker(space SpaceChecker) Option {
+	return func(s *LogoService) { s.space = space }
+}
+
+// WithCache caches background variants on disk instead of rendering them
+// on every request.
+func WithCache(variants *VariantCache) Option {
+	return func(s *LogoService) { s.variants = variants }
+}
+
+// WithBlocklist makes the service refuse blocked symbols with an error
+// wrapping storage.ErrSymbolBlocked, both when serving and when acquiring.
+func WithBlocklist(blocklist storage.BlocklistRepository) Option {
+	return func(s *LogoService) { s.blocklist = blocklist }
+}
+
+// WithAuditLog records blocks, unblocks, takedowns and sends to review in
+// the audit log.
+func WithAuditLog(audit storage.AuditRepository) Option {
+	return func(s *LogoService) { s.audit = audit }
+}
+
+// WithEventLog records every change to what is served (a logo created,
+// replaced or withdrawn) in the changes feed.
+func WithEventLog(events storage.EventRepository) Option {
+	return func(s *LogoService) { s.events = events }
+}
+
+// WithOriginals keeps every source image a provider delivers (see
+// SaveOriginal), so Reprocess can render a logo again without asking the
+// providers. Takedowns delete them along with the sizes.
+func WithOriginals(originals storage.OriginalRepository) Option {
+	return func(s *LogoService) { s.originals = originals }
+}
+
+// WithStatsHistory keeps a daily snapshot of the stats (see RecordStats).
+// LLM spend is estimated at llmCallCents per LLM call.
+func WithStatsHistory(history storage.StatsHistoryRepository, llmCallCents int64) Option {
+	return func(s *LogoService) { s.statsHistory, s.llmCallCents = history, llmCallCents }
+}
+
+// WithProcessDefaults sets the processing options used for logos that don't
+// override them, e.g. processing.whiten_background from the config.
+func WithProcessDefaults(opts model.ProcessOptions) Option {
+	return func(s *LogoService) { s.processing = opts }
+}
+
+// WithDeleteRetention sets how long a deleted logo can be restored before
+// PurgeDeleted removes it. The default is DefaultDeleteRetention.
+func WithDeleteRetention(retention time.Duration) Option {
+	return func(s *LogoService) { s.retention = retention }
+}
+
+// WithFlags switches risky behaviors per the given flags: on-demand
+// acquisition (off, cache misses get ErrAcquisitionDisabled) and purging
+// deleted logos. Without it every flag is at its default.
+func WithFlags(f FlagChecker) Option {
+	return func(s *LogoService) { s.flags = f }
+}
+
+// WithLogger sets the logger. The default logs nothing.
+func WithLogger(logger *zap.Logger) Option {
+	return func(s *LogoService) { s.logger = logger }
+}
+
+// WithClock sets the clock the service reads for its time-based policies
+// (pending timeout, not-found rechecks). The default is the system clock;
+// tests pass a *clock.Fake.
+func WithClock(c clock.Clock) Option {
+	return func(s *LogoService) { s.clock = c }
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/fleveque/logo-service/internal/clock"
+	"github.com/fleveque/logo-service/internal/flags"
+	"github.com/fleveque/logo-service/internal/model"
+	"github.com/fleveque/logo-service/internal/provider"
+	"github.com/fleveque/logo-service/internal/storage"
+	"github.com/fleveque/logo-service/internal/testutil"
+)
+
+type fakeValidator struct{ err error }
+
+func (f fakeValidator) ValidateLogo(context.Context, *provider.LogoResult) error { return f.err }
+
+type fakeSpace struct{}
+
+func (fakeSpace) Check() error { return nil }
+
+type fakeFlags struct{}
+
+func (fakeFlags) Enabled(flags.Name) bool { return true }
+
+func TestOptions(t *testing.T) {
+	db := testutil.NewDB(t)
+	fs := testutil.NewFileSystem(t)
+	llm := testutil.NewFakeProvider()
+	index := testutil.NewFakeProvider()
+	validator := fakeValidator{}
+	moderator := &fakeModerator{}
+	attempts := storage.NewAttemptRepository(db)
+	catalog := storage.NewCatalog(storage.NewLogoRepository(db), storage.NewBlocklistRepository(db))
+	variants := NewVariantCache(storage.NewVariantRepository(db), fs, 1<<20, zap.NewNop())
+	blocklist := storage.NewBlocklistRepository(db)
+	audit := storage.NewAuditRepository(db)
+	events := storage.NewEventRepository(db)
+	originals := storage.NewOriginalRepository(db)
+	history := storage.NewStatsHistoryRepository(db)
+	logger := zap.NewExample()
+	fake := clock.NewFake(time.Now())
+
+	// Each option against the zero or default value it replaces.
+	tests := []struct {
+		name string
+		opt  Option
+		set  func(s *LogoService) bool
+	}{
+		{"WithLLMProvider", WithLLMProvider(llm), func(s *LogoService) bool { return s.llmProvider == llm }},
+		{"WithIndexProvider", WithIndexProvider(index), func(s *LogoService) bool { return s.indexProvider == index }},
+		{"WithPolicy", WithPolicy(AcceptancePolicy{MinConfidence: model.ConfidenceHigh}), func(s *LogoService) bool {
+			return s.policy.MinConfidence == model.ConfidenceHigh
+		}},
+		{"WithValidator", WithValidator(validator), func(s *LogoService) bool { return s.validator == validator }},
+		{"WithModeration", WithModeration(moderator, ModerationPolicy{Sources: []string{"llm"}}), func(s *LogoService) bool {
+			return s.moderator == moderator && len(s.moderation.Sources) == 1
+		}},
+		{"WithCircuitBreaker", WithCircuitBreaker(CircuitPolicy{Failures: 3}), func(s *LogoService) bool {
+			return s.circuitPolicy.Failures == 3 && s.circuits.llm != nil
+		}},
+		{"WithAttemptLog", WithAttemptLog(attempts), func(s *LogoService) bool { return s.attempts == attempts }},
+		{"WithStrategy", WithStrategy(StrategyRace), func(s *LogoService) bool { return s.strategy == StrategyRace }},
+		{"WithCatalog", WithCatalog(catalog), func(s *LogoService) bool { return s.catalog == catalog }},
+		{"WithBatchCoalescing", WithBatchCoalescing(time.Second), func(s *LogoService) bool { return s.batches != nil }},
+		{"WithSpaceChecker", WithSpaceChecker(fakeSpace{}), func(s *LogoService) bool { return s.space == fakeSpace{} }},
+		{"WithCache", WithCache(variants), func(s *LogoService) bool { return s.variants == variants }},
+		{"WithBlocklist", WithBlocklist(blocklist), func(s *LogoService) bool { return s.blocklist == blocklist }},
+		{"WithAuditLog", WithAuditLog(audit), func(s *LogoService) bool { return s.audit == audit }},
+		{"WithEventLog", WithEventLog(events), func(s *LogoService) bool { return s.events == events }},
+		{"WithOriginals", WithOriginals(originals), func(s *LogoService) bool { return s.originals == originals }},
+		{"WithStatsHistory", WithStatsHistory(history, 5), func(s *LogoService) bool {
+			return s.statsHistory == history && s.llmCallCents == 5
+		}},
+		{"WithProcessDefaults", WithProcessDefaults(model.ProcessOptions{WhitenBackground: true}), func(s *LogoService) bool {
+			return s.processing.WhitenBackground
+		}},
+		{"WithDeleteRetention", WithDeleteRetention(time.Hour), func(s *LogoService) bool { return s.retention == time.Hour }},
+		{"WithFlags", WithFlags(fakeFlags{}), func(s *LogoService) bool { return s.flags == fakeFlags{} }},
+		{"WithLogger", WithLogger(logger), func(s *LogoService) bool { return s.logger == logger }},
+		{"WithClock", WithClock(fake), func(s *LogoService) bool { return s.clock == fake }},
+	}
+	newService := func(opts ...Option) *LogoService {
+		return NewLogoService(nil, nil, nil, fs, nil, testutil.NewFakeProvider(), opts...)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.set(newService()) {
+				t.Fatal("expected the default to differ from the option's value")
+			}
+			if !tt.set(newService(tt.opt)) {
+				t.Error("expected the option to override the default")
+			}
+		})
+	}
+}
+
+func TestOptions_Defaults(t *testing.T) {
+	s := NewLogoService(nil, nil, nil, nil, nil, testutil.NewFakeProvider())
+	if s.retention != DefaultDeleteRetention || s.clock != clock.System || s.logger == nil {
+		t.Errorf("unexpected defaults: retention %v, clock %v, logger %v", s.retention, s.clock, s.logger)
+	}
+}
+
+func TestProcessAndStore_Validator(t *testing.T) {
+	rejection := errors.New("too small: 8x8")
+	d := newTestService(t,
+		testutil.NewFakeProvider(&provider.LogoResult{Symbol: "ACME", ImageData: []byte("x"), Source: "github:test"}),
+		nil, AcceptancePolicy{}, WithValidator(fakeValidator{err: rejection}))
+	ctx := context.Background()
+
+	_, err := d.svc.GetLogo(ctx, "ACME", model.SizeM)
+	if !errors.Is(err, ErrInvalidLogo) || !errors.Is(err, rejection) {
+		t.Fatalf("expected ErrInvalidLogo wrapping the validator's error, got %v", err)
+	}
+	logo, err := d.logoRepo.GetBySymbol(ctx, "ACME")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if logo.Status != model.StatusFailed || logo.ErrorMessage == nil || *logo.ErrorMessage != "rejected: too small: 8x8" {
+		t.Errorf("expected failed with the validator's reason, got %s / %v", logo.Status, logo.ErrorMessage)
+	}
+	if d.fs.Exists("ACME", model.SizeM) {
+		t.Error("expected a rejected logo not to be processed")
+	}
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"go.uber.org/zap"
+
+	"github.com/fleveque/logo-service/internal/imagefmt"
+	"github.com/fleveque/logo-service/internal/model"
+	"github.com/fleveque/logo-service/internal/provider"
+	"github.com/fleveque/logo-service/internal/storage"
+)
+
+// ErrNoOriginal is returned by Reprocess for a logo whose source image
+// wasn't kept: processed before originals were, or without WithOriginals.
+// Only acquiring it again (e.g. reject, then request) renders it anew.
+var ErrNoOriginal = errors.New("no stored original for logo")
+
+// SaveOriginal stores the bytes a provider delivered and records where they
+// came from, returning their hash. Identical bytes are stored once, however
+// many symbols or downloads they came from.
+//
+// Exported so the CLI import, which processes logos itself, keeps them too.
+func SaveOriginal(ctx context.Context, fs *storage.FileSystem, originals storage.OriginalRepository, result *provider.LogoResult) (string, error) {
+	hash, err := fs.WriteOriginal(result.ImageData)
+	if err != nil {
+		return "", err
+	}
+	err = originals.Record(ctx, &model.Original{
+		Symbol:      result.Symbol,
+		Hash:        hash,
+		Source:      result.Source,
+		OriginalURL: result.OriginalURL,
+		Confidence:  result.Confidence,
+		License:     result.License,
+		Attribution: result.Attribution,
+		Format:      string(imagefmt.Detect(result.ImageData)),
+		SizeBytes:   int64(len(result.ImageData)),
+	})
+	if err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+// saveOriginal is SaveOriginal for the service's own acquisitions. A logo
+// is still processed when its original can't be kept, so failures are
+// logged and give an empty hash.
+func (s *LogoService) saveOriginal(ctx context.Context, result *provider.LogoResult) string {
+	if s.originals == nil {
+		return ""
+	}
+	hash, err := SaveOriginal(ctx, s.fs, s.originals, result)
+	if err != nil {
+		s.logger.Error("saving original", zap.String("symbol", result.Symbol), zap.Error(err))
+		return ""
+	}
+	return hash
+}
+
+// ListOriginals returns the source images downloaded for a symbol, newest
+// first. The logo's OriginalHash says which one it's rendered from.
+func (s *LogoService) ListOriginals(ctx context.Context, symbol string) ([]model.Original, error) {
+	if s.originals == nil {
+		return []model.Original{}, nil
+	}
+	return s.originals.ListBySymbol(ctx, symbol)
+}
+
+// Reprocess renders a logo's sizes again from its stored original, without
+// asking any provider: after its processing options changed, or after the
+// processor itself did. The status doesn't change; a served logo shows up
+// as updated in the changes feed.
+//
+// Returns storage.ErrNotFound if there's no record, ErrNoOriginal if its
+// source image wasn't kept, and an error wrapping storage.ErrSymbolBlocked
+// for a blocked symbol.
+func (s *LogoService) Reprocess(ctx context.Context, symbol string) error {
+	if err := s.checkBlocked(ctx, symbol); err != nil {
+		return err
+	}
+	logo, err := s.logoRepo.GetBySymbol(ctx, symbol)
+	if err != nil {
+		return err
+	}
+	source, err := s.renderFromOriginal(ctx, logo)
+	if err != nil {
+		return err
+	}
+	s.purgeVariants(ctx, symbol)
+	s.analyze(ctx, symbol, source)
+
+	if logo.Status == model.StatusProcessed {
+		s.recordEvent(ctx, model.EventUpdated, symbol)
+	}
+	return nil
+}
+
+// renderFromOriginal renders a logo's sizes again from its stored original
+// and flags the ones written as available. It returns the original's bytes,
+// or an error wrapping ErrNoOriginal if it wasn't kept.
+func (s *LogoService) renderFromOriginal(ctx context.Context, logo *model.Logo) ([]byte, error) {
+	if logo.OriginalHash == "" || s.originals == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoOriginal, logo.Symbol)
+	}
+	source, err := s.fs.ReadOriginal(logo.OriginalHash)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s (%s is missing from storage)", ErrNoOriginal, logo.Symbol, logo.OriginalHash)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sizes, err := s.processor.ProcessAll(logo.Symbol, source, logo.ProcessOptions(s.processing))
+	if err != nil {
+		return nil, fmt.Errorf("processing: %w", err)
+	}
+	if err := StoreSizes(ctx, s.fs, s.logoRepo, logo.Symbol, sizes); err != nil {
+		s.logger.Error("storing sizes", zap.String("symbol", logo.Symbol), zap.Error(err))
+	}
+	return source, nil
+}
+
+// purgeOriginals forgets a symbol's originals and deletes the bytes no
+// other symbol shares, so a takedown leaves no copy behind.
+func (s *LogoService) purgeOriginals(ctx context.Context, symbol string) error {
+	if s.originals == nil {
+		return nil
+	}
+	hashes, err := s.originals.DeleteBySymbol(ctx, symbol)
+	if err != nil {
+		return err
+	}
+	for _, hash := range hashes {
+		shared, err := s.originals.IsReferenced(ctx, hash)
+		if err != nil {
+			return err
+		}
+		if !shared {
+			if err := s.fs.DeleteOriginal(hash); err != nil {
+				return err
+			}
+		}
+	}
+	return s.logoRepo.SetOriginalHash(ctx, symbol, "")
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fleveque/logo-service/internal/model"
+	"github.com/fleveque/logo-service/internal/provider"
+	"github.com/fleveque/logo-service/internal/storage"
+	"github.com/fleveque/logo-service/internal/testutil"
+)
+
+func TestReprocess(t *testing.T) {
+	d := newTestService(t,
+		testutil.NewFakeProvider(&provider.LogoResult{Symbol: "AAPL", ImageData: []byte("aapl"), Source: "github:test", OriginalURL: "https://example.com/aapl.svg"}),
+		nil,
+		AcceptancePolicy{},
+	)
+	ctx := context.Background()
+
+	if _, err := d.svc.GetLogo(ctx, "AAPL", model.SizeM); err != nil {
+		t.Fatalf("GetLogo failed: %v", err)
+	}
+	logo, err := d.logoRepo.GetBySymbol(ctx, "AAPL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if logo.OriginalHash != storage.HashOriginal([]byte("aapl")) {
+		t.Fatalf("expected the original's hash on the logo, got %q", logo.OriginalHash)
+	}
+	list, err := d.svc.ListOriginals(ctx, "AAPL")
+	if err != nil || len(list) != 1 || list[0].Source != "github:test" || list[0].OriginalURL != "https://example.com/aapl.svg" {
+		t.Fatalf("unexpected originals: %+v (%v)", list, err)
+	}
+
+	// Reprocessing applies a changed option without asking the provider again.
+	on := true
+	if err := d.svc.SetWhitenBackground(ctx, "AAPL", &on); err != nil {
+		t.Fatalf("SetWhitenBackground: %v", err)
+	}
+	if err := d.svc.Reprocess(ctx, "AAPL"); err != nil {
+		t.Fatalf("Reprocess: %v", err)
+	}
+	if !d.processor.Options.WhitenBackground {
+		t.Error("expected the logo's override to apply on reprocess")
+	}
+	if calls := d.github.Calls(); len(calls) != 1 {
+		t.Errorf("expected a single provider call, got %v", calls)
+	}
+	events, err := d.events.ListSince(ctx, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := events[len(events)-1]; last.Type != model.EventUpdated {
+		t.Errorf("expected an updated event, got %+v", last)
+	}
+
+	if err := d.svc.Reprocess(ctx, "MSFT"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a missing logo, got %v", err)
+	}
+}
+
+func TestReprocess_NoOriginal(t *testing.T) {
+	d := newTestService(t,
+		testutil.NewFakeProvider(&provider.LogoResult{Symbol: "AAPL", ImageData: []byte("aapl"), Source: "github:test"}),
+		nil,
+		AcceptancePolicy{},
+		WithOriginals(nil),
+	)
+	ctx := context.Background()
+
+	if _, err := d.svc.GetLogo(ctx, "AAPL", model.SizeM); err != nil {
+		t.Fatalf("GetLogo failed: %v", err)
+	}
+	if err := d.svc.Reprocess(ctx, "AAPL"); !errors.Is(err, ErrNoOriginal) {
+		t.Errorf("expected ErrNoOriginal without WithOriginals, got %v", err)
+	}
+}
+
+func TestTakedown_PurgesOriginals(t *testing.T) {
+	// GOOG and GOOGL come from the same file, which is stored once.
+	d := newTestService(t,
+		testutil.NewFakeProvider(
+			&provider.LogoResult{Symbol: "GOOG", ImageData: []byte("google"), Source: "github:test"},
+			&provider.LogoResult{Symbol: "GOOGL", ImageData: []byte("google"), Source: "github:test"},
+			&provider.LogoResult{Symbol: "AAPL", ImageData: []byte("aapl"), Source: "github:test"},
+		),
+		nil,
+		AcceptancePolicy{},
+	)
+	ctx := context.Background()
+
+	for _, symbol := range []string{"GOOG", "GOOGL", "AAPL"} {
+		if _, err := d.svc.GetLogo(ctx, symbol, model.SizeM); err != nil {
+			t.Fatalf("GetLogo(%s) failed: %v", symbol, err)
+		}
+	}
+	for _, symbol := range []string{"GOOG", "AAPL"} {
+		if err := d.svc.Takedown(ctx, symbol, "trademark complaint"); err != nil {
+			t.Fatalf("Takedown(%s) failed: %v", symbol, err)
+		}
+	}
+
+	if _, err := d.fs.ReadOriginal(storage.HashOriginal([]byte("google"))); err != nil {
+		t.Errorf("expected GOOGL's original to survive GOOG's takedown: %v", err)
+	}
+	if _, err := d.fs.ReadOriginal(storage.HashOriginal([]byte("aapl"))); err == nil {
+		t.Error("expected AAPL's original to be deleted")
+	}
+	if list, _ := d.svc.ListOriginals(ctx, "AAPL"); len(list) != 0 {
+		t.Errorf("expected no originals recorded for AAPL, got %+v", list)
+	}
+	if logo, err := d.logoRepo.GetBySymbol(ctx, "AAPL"); err != nil || logo.OriginalHash != "" {
+		t.Errorf("expected the original hash to be cleared, got %+v (%v)", logo, err)
+	}
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"sync"
+
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+// placeholderColor is a light neutral gray that reads as "loading" on both
+// light and dark backgrounds.
+var placeholderColor = color.NRGBA{R: 0xd1, G: 0xd5, B: 0xdb, A: 0xff}
+
+var (
+	placeholderOnce sync.Once
+	placeholders    map[model.LogoSize][]byte
+)
+
+// PlaceholderPNG returns a neutral image (a gray disc on transparency) at
+// the given size, served while a logo is still being acquired. The images
+// are rendered once, in pure Go — no libvips round trip per request.
+func PlaceholderPNG(size model.LogoSize) []byte {
+	// Go note: sync.Once runs the function exactly once, even when many
+	// goroutines call Do at the same time; later calls wait for it to finish.
+	placeholderOnce.Do(func() {
+		placeholders = make(map[model.LogoSize][]byte, len(model.AllSizes))
+		for _, s := range model.AllSizes {
+			placeholders[s] = renderPlaceholder(model.SizePixels[s])
+		}
+	})
+	return placeholders[size]
+}
+
+func renderPlaceholder(pixels int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, pixels, pixels))
+	center := float64(pixels) / 2
+	radius := center * 0.8
+	for y := 0; y < pixels; y++ {
+		for x := 0; x < pixels; x++ {
+			dx, dy := float64(x)+0.5-center, float64(y)+0.5-center
+			if dx*dx+dy*dy <= radius*radius {
+				img.SetNRGBA(x, y, placeholderColor)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		panic(err) // Encoding an in-memory NRGBA can't fail
+	}
+	return buf.Bytes()
+}
+
+// transparentPNG is a fully transparent 1×1 image, encoded once.
+var transparentPNG = func() []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 1, 1))); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}()
+
+// TransparentPNG returns a fully transparent 1×1 PNG, served instead of an
+// error for an <img> that should show nothing rather than a broken icon.
+func TransparentPNG() []byte {
+	return transparentPNG
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+func TestPlaceholderPNG(t *testing.T) {
+	for _, size := range model.AllSizes {
+		img, err := png.Decode(bytes.NewReader(PlaceholderPNG(size)))
+		if err != nil {
+			t.Fatalf("%s: decoding placeholder: %v", size, err)
+		}
+		want := model.SizePixels[size]
+		if b := img.Bounds(); b.Dx() != want || b.Dy() != want {
+			t.Errorf("%s: expected %dx%d, got %v", size, want, want, b)
+		}
+		// Transparent corners, so it sits on any background.
+		if _, _, _, a := img.At(0, 0).RGBA(); a != 0 {
+			t.Errorf("%s: expected a transparent corner", size)
+		}
+	}
+}
+
+func TestTransparentPNG(t *testing.T) {
+	img, err := png.Decode(bytes.NewReader(TransparentPNG()))
+	if err != nil {
+		t.Fatalf("decoding: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 1 || b.Dy() != 1 {
+		t.Errorf("expected 1x1, got %v", b)
+	}
+	if _, _, _, a := img.At(0, 0).RGBA(); a != 0 {
+		t.Error("expected a transparent pixel")
+	}
+}
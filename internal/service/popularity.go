@@ -0,0 +1,66 @@
+package service
+
+import (
+	"math"
+	"sync"
+
+	"github.com/fleveque/logo-service/internal/clock"
+)
+
+// maxPopularSymbols bounds how many symbols Popularity holds between two
+// Takes, so a client requesting random symbols can't grow it without end.
+// Past it, symbols already counted still are; new ones are dropped.
+const maxPopularSymbols = 100_000
+
+// Popularity counts logo requests per symbol in memory, until Take hands
+// them over to be written in one batch (see storage.PopularityRepository).
+//
+// Counting every request would take a lock on the hottest path, so with a
+// sample rate below 1 only that fraction of requests is counted, each one
+// weighing 1/rate: at 0.1, one request in ten adds 10. Popular symbols come
+// out right; a symbol requested a few times may show 0 or 10.
+type Popularity struct {
+	rate   float64
+	weight int64
+	random clock.Random
+
+	mu      sync.Mutex
+	counts  map[string]int64
+	dropped int64 // Requests not counted since the last Take: too many symbols
+}
+
+// NewPopularity creates a Popularity sampling requests at rate, in (0, 1].
+func NewPopularity(rate float64) *Popularity {
+	return &Popularity{
+		rate:   rate,
+		weight: int64(math.Round(1 / rate)),
+		random: clock.Global,
+		counts: make(map[string]int64),
+	}
+}
+
+// Record counts a request for symbol, if it's sampled. Safe on a nil
+// Popularity, which counts nothing.
+func (p *Popularity) Record(symbol string) {
+	if p == nil || (p.rate < 1 && p.random.Float64() >= p.rate) {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, tracked := p.counts[symbol]; !tracked && len(p.counts) >= maxPopularSymbols {
+		p.dropped += p.weight
+		return
+	}
+	p.counts[symbol] += p.weight
+}
+
+// Take returns the counts since the last call (symbol → requests), and how
+// many requests were dropped for lack of room, and starts again from zero.
+func (p *Popularity) Take() (counts map[string]int64, dropped int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	counts, dropped = p.counts, p.dropped
+	p.counts, p.dropped = make(map[string]int64), 0
+	return counts, dropped
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/fleveque/logo-service/internal/clock"
+)
+
+func TestPopularity(t *testing.T) {
+	t.Run("every request", func(t *testing.T) {
+		p := NewPopularity(1)
+		for _, s := range []string{"AAPL", "AAPL", "MSFT"} {
+			p.Record(s)
+		}
+		counts, dropped := p.Take()
+		if counts["AAPL"] != 2 || counts["MSFT"] != 1 || dropped != 0 {
+			t.Errorf("got %v (%d dropped), want AAPL 2, MSFT 1", counts, dropped)
+		}
+		if counts, _ := p.Take(); len(counts) != 0 {
+			t.Errorf("expected Take to start again from zero, got %v", counts)
+		}
+	})
+
+	t.Run("sampled", func(t *testing.T) {
+		p := NewPopularity(0.1)
+		p.random = clock.NewRandom(1)
+		for range 10_000 {
+			p.Record("AAPL")
+		}
+		counts, _ := p.Take()
+		// Each sampled request weighs 10: the estimate is within a few percent.
+		if n := counts["AAPL"]; n%10 != 0 || n < 9_000 || n > 11_000 {
+			t.Errorf("estimated %d requests, want about 10000 in steps of 10", n)
+		}
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		var p *Popularity
+		p.Record("AAPL") // Must not panic
+	})
+}
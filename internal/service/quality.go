@@ -0,0 +1,93 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"math"
+	"strings"
+
+	"github.com/fleveque/logo-service/internal/imagefmt"
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+// Points a logo loses for each quality problem. The score starts at 100,
+// and the penalties are rough on purpose: the score ranks logos for a
+// curator to look at, it doesn't grade them.
+const (
+	maxUpscalePenalty     = 50 // 25 per doubling; a 64px source for the 256px size loses 50
+	jpegPenalty           = 5  // Lossy at any quality
+	maxJPEGQualityPenalty = 25 // Plus half of every quality point below 90
+	gifPenalty            = 10 // 256 colors, 1-bit transparency
+	opaquePenalty         = 15 // Shows as a box on any background but its own
+)
+
+// AssessQuality scores a processed logo from 0 to 100 and explains the
+// deductions. source is the image as downloaded; rendered is the stored
+// xl size (nil if it couldn't be read), which shows whether the result
+// has any transparency.
+//
+// Exported so the CLI import, which processes logos itself, scores them too.
+func AssessQuality(source, rendered []byte) (score int, notes string) {
+	info, err := imagefmt.Inspect(source)
+	if err != nil {
+		return 0, "unreadable source: " + err.Error()
+	}
+
+	score = 100
+	var reasons []string
+
+	// Resolution: vectors render sharp at any size; rasters smaller than the
+	// largest size are upscaled, and every doubling blurs them further.
+	if info.Format != imagefmt.SVG {
+		target := model.SizePixels[model.SizeXL]
+		if side := min(info.Width, info.Height); side < target {
+			factor := float64(target) / float64(side)
+			score -= min(maxUpscalePenalty, int(math.Round(25*math.Log2(factor))))
+			reasons = append(reasons, fmt.Sprintf("upscaled %.1fx from %dx%d", factor, info.Width, info.Height))
+		}
+	}
+
+	// Compression artifacts: lossy and palette formats.
+	switch info.Format {
+	case imagefmt.JPEG:
+		penalty := jpegPenalty
+		if q, ok := imagefmt.JPEGQuality(source); ok {
+			penalty += min(maxJPEGQualityPenalty, max(0, (90-q)/2))
+			reasons = append(reasons, fmt.Sprintf("jpeg quality ~%d", q))
+		} else {
+			reasons = append(reasons, "jpeg")
+		}
+		score -= penalty
+	case imagefmt.GIF:
+		score -= gifPenalty
+		reasons = append(reasons, "gif palette")
+	}
+
+	if opaque, ok := isOpaque(rendered); ok && opaque {
+		score -= opaquePenalty
+		reasons = append(reasons, "no transparency")
+	}
+
+	return max(0, score), strings.Join(reasons, "; ")
+}
+
+// isOpaque reports whether every pixel of a PNG is fully opaque.
+// ok is false if data isn't a decodable PNG.
+func isOpaque(data []byte) (opaque, ok bool) {
+	if len(data) == 0 {
+		return false, false
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return false, false
+	}
+
+	// Go note: an anonymous interface in a type assertion asks "does this
+	// value have an Opaque method?" — every image type png.Decode returns does.
+	o, hasOpaque := img.(interface{ Opaque() bool })
+	if !hasOpaque {
+		return false, false
+	}
+	return o.Opaque(), true
+}
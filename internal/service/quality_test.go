@@ -0,0 +1,58 @@
+package service
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"strings"
+	"testing"
+)
+
+func TestAssessQuality(t *testing.T) {
+	jpegOf := func(side, quality int) []byte {
+		var buf bytes.Buffer
+		img := image.NewRGBA(image.Rect(0, 0, side, side))
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+			t.Fatalf("encoding jpeg: %v", err)
+		}
+		return buf.Bytes()
+	}
+	transparent := createTestPNG(8, 8, color.NRGBA{})
+	opaque := createTestPNG(8, 8, color.NRGBA{R: 255, A: 255})
+
+	tests := []struct {
+		name      string
+		source    []byte
+		rendered  []byte
+		wantScore int
+		wantNotes []string
+	}{
+		{"large transparent PNG", createTestPNG(512, 512, color.NRGBA{}), transparent, 100, nil},
+		{"SVG is never upscaled", []byte(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 1 1"/>`), transparent, 100, nil},
+		{"2x upscale", createTestPNG(128, 256, color.NRGBA{}), transparent, 75, []string{"upscaled 2.0x from 128x256"}},
+		{"upscale penalty is capped", createTestPNG(16, 16, color.NRGBA{}), transparent, 50, []string{"upscaled 16.0x"}},
+		{"opaque result", createTestPNG(256, 256, color.NRGBA{}), opaque, 85, []string{"no transparency"}},
+		{"unknown rendering skips the transparency check", createTestPNG(256, 256, color.NRGBA{}), []byte("not a png"), 100, nil},
+		{"good JPEG", jpegOf(256, 95), opaque, 80, []string{"jpeg quality ~95", "no transparency"}},
+		{"poor JPEG", jpegOf(256, 40), opaque, 55, []string{"jpeg quality ~40"}},
+		{"junk", []byte("<html>"), nil, 0, []string{"unreadable source"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, notes := AssessQuality(tt.source, tt.rendered)
+			if score != tt.wantScore {
+				t.Errorf("score = %d, want %d (notes %q)", score, tt.wantScore, notes)
+			}
+			for _, want := range tt.wantNotes {
+				if !strings.Contains(notes, want) {
+					t.Errorf("notes %q should mention %q", notes, want)
+				}
+			}
+			if tt.wantNotes == nil && notes != "" {
+				t.Errorf("expected no notes, got %q", notes)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/fleveque/logo-service/internal/imagefmt"
+	"github.com/fleveque/logo-service/internal/model"
+	"github.com/fleveque/logo-service/internal/storage"
+)
+
+// StoreSizes flags the sizes ProcessAll wrote as available and records each
+// one's byte count and dimensions, read back from the FileSystem. Like
+// SaveOriginal, it's exported for the CLI import, which processes logos
+// without a LogoService. A size that fails doesn't stop the others; the
+// error joins every failure.
+func StoreSizes(ctx context.Context, fs *storage.FileSystem, logoRepo storage.LogoRepository, symbol string, sizes map[model.LogoSize]bool) error {
+	var errs []error
+	for _, size := range model.AllSizes {
+		if !sizes[size] {
+			continue
+		}
+		if err := logoRepo.SetSizeAvailable(ctx, symbol, size); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := storeRendition(ctx, fs, logoRepo, symbol, size); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// storeRendition records the rendition of one stored size.
+func storeRendition(ctx context.Context, fs *storage.FileSystem, logoRepo storage.LogoRepository, symbol string, size model.LogoSize) error {
+	data, err := fs.Read(symbol, size)
+	if err != nil {
+		return fmt.Errorf("reading %s for its rendition: %w", size, err)
+	}
+	// The processor writes PNGs; a header it can't read just leaves the
+	// dimensions unknown.
+	info, _ := imagefmt.Inspect(data)
+	return logoRepo.SetRendition(ctx, symbol, model.Rendition{
+		Size:   size,
+		Width:  info.Width,
+		Height: info.Height,
+		Bytes:  int64(len(data)),
+	})
+}
+
+// renditions returns the renditions of a logo's available sizes. Sizes
+// processed before renditions were recorded get theirs now.
+func (s *LogoService) renditions(ctx context.Context, logo *model.Logo) ([]model.Rendition, error) {
+	recorded, err := s.logoRepo.ListRenditions(ctx, logo.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[model.LogoSize]bool, len(recorded))
+	for _, r := range recorded {
+		known[r.Size] = true
+	}
+
+	backfilled := false
+	for _, size := range model.AllSizes {
+		if !logo.HasSize(size) || known[size] {
+			continue
+		}
+		err := storeRendition(ctx, s.fs, s.logoRepo, logo.Symbol, size)
+		if errors.Is(err, os.ErrNotExist) {
+			continue // Its next read heals it
+		}
+		if err != nil {
+			return nil, err
+		}
+		backfilled = true
+	}
+	if !backfilled {
+		return recorded, nil
+	}
+	return s.logoRepo.ListRenditions(ctx, logo.Symbol)
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"image/color"
+	"testing"
+
+	"github.com/fleveque/logo-service/internal/model"
+	"github.com/fleveque/logo-service/internal/provider"
+	"github.com/fleveque/logo-service/internal/testutil"
+)
+
+func TestGetMetadata_Renditions(t *testing.T) {
+	d := newTestService(t, testutil.NewFakeProvider(), nil, AcceptancePolicy{})
+	ctx := context.Background()
+
+	// FakeProcessor stores the source as every size, so each is 40x20.
+	source := createTestPNG(40, 20, color.Black)
+	if err := d.svc.ProcessAndStore(ctx, &provider.LogoResult{Symbol: "AAPL", ImageData: source, Source: "github:test"}); err != nil {
+		t.Fatalf("ProcessAndStore: %v", err)
+	}
+
+	logo, err := d.svc.GetMetadata(ctx, "AAPL")
+	if err != nil {
+		t.Fatalf("GetMetadata: %v", err)
+	}
+	if len(logo.Renditions) != len(model.AllSizes) {
+		t.Fatalf("expected a rendition per size, got %+v", logo.Renditions)
+	}
+	for i, r := range logo.Renditions {
+		if r.Size != model.AllSizes[i] || r.Width != 40 || r.Height != 20 || r.Bytes != int64(len(source)) {
+			t.Errorf("rendition %d: got %+v", i, r)
+		}
+	}
+
+	// Logos processed before renditions were recorded get them on demand.
+	if _, err := d.db.Exec(`DELETE FROM logo_renditions`); err != nil {
+		t.Fatal(err)
+	}
+	if logo, err = d.svc.GetMetadata(ctx, "AAPL"); err != nil {
+		t.Fatal(err)
+	}
+	if len(logo.Renditions) != len(model.AllSizes) {
+		t.Errorf("expected renditions to be backfilled, got %+v", logo.Renditions)
+	}
+
+	// A size gone missing is no longer described.
+	if err := d.logoRepo.SetSizeUnavailable(ctx, "AAPL", model.SizeXL); err != nil {
+		t.Fatal(err)
+	}
+	if logo, _ = d.svc.GetMetadata(ctx, "AAPL"); len(logo.Renditions) != len(model.AllSizes)-1 {
+		t.Errorf("expected %d renditions, got %+v", len(model.AllSizes)-1, logo.Renditions)
+	}
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/fleveque/logo-service/internal/clock"
+)
+
+// ErrScanThrottled is returned for a cache miss of a caller throttled by the
+// request's ScanGuard (see WithScanGuard). It wraps ErrAcquisitionLimited:
+// like the misses limit, it turns away acquisitions, never cached reads.
+var ErrScanThrottled = fmt.Errorf("%w: too many symbols not found", ErrAcquisitionLimited)
+
+// maxScanCallers bounds how many callers a ScanGuard tracks. Past it, the
+// callers with nothing recent are forgotten first; if all are recent, new
+// ones aren't tracked until some are.
+const maxScanCallers = 100_000
+
+// ScanPolicy is when a ScanGuard throttles a caller: once it has asked for
+// MaxMisses distinct symbols that no provider had within Window, its
+// acquisitions are turned away for Cooldown. Zero MaxMisses never throttles.
+type ScanPolicy struct {
+	MaxMisses int
+	Window    time.Duration
+	Cooldown  time.Duration
+}
+
+// ScanAlert describes a caller a ScanGuard just throttled.
+type ScanAlert struct {
+	Caller string    `json:"caller"` // e.g. "key:3f9a0c12b4de" or "ip:203.0.113.7"
+	Misses int       `json:"misses"` // Distinct symbols not found within the window
+	Window string    `json:"window"`
+	Until  time.Time `json:"until"`
+}
+
+// ScanGuard spots callers (API keys, client IPs) requesting many distinct
+// symbols that don't exist: someone scanning tickers at random, where every
+// miss is a search of each provider, the LLM's paid for. A caller found
+// scanning (see ScanPolicy) has its acquisitions turned away for a while;
+// its cached logos are still served.
+//
+// Only complete searches that found nothing count: a symbol already known
+// to be missing is answered from the database and costs nothing.
+type ScanGuard struct {
+	policy     ScanPolicy
+	onThrottle func(ScanAlert) // nil: throttled silently
+	clock      clock.Clock
+
+	mu      sync.Mutex
+	callers map[string]*scanState
+}
+
+// scanState is what a ScanGuard knows of one caller.
+type scanState struct {
+	misses         map[string]time.Time // Symbol → when its search found nothing
+	throttledUntil time.Time
+}
+
+// NewScanGuard creates a ScanGuard. onThrottle, if not nil, is called each
+// time a caller is throttled, e.g. to send an alert; it runs on the request
+// that tipped the caller over, so it shouldn't block.
+func NewScanGuard(policy ScanPolicy, onThrottle func(ScanAlert)) *ScanGuard {
+	return &ScanGuard{
+		policy:     policy,
+		onThrottle: onThrottle,
+		clock:      clock.System,
+		callers:    make(map[string]*scanState),
+	}
+}
+
+// SetClock replaces the system clock, for tests.
+func (g *ScanGuard) SetClock(c clock.Clock) {
+	g.clock = c
+}
+
+// Allow reports whether callers may acquire a logo: false while any of them
+// is throttled. Safe on a nil ScanGuard, which allows everything.
+func (g *ScanGuard) Allow(callers ...string) bool {
+	if g == nil {
+		return true
+	}
+	now := g.clock.Now()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, caller := range callers {
+		if st, ok := g.callers[caller]; ok && now.Before(st.throttledUntil) {
+			return false
+		}
+	}
+	return true
+}
+
+// Miss records that callers asked for symbol and no provider had it, and
+// throttles those that reach the policy's MaxMisses. Safe on a nil
+// ScanGuard.
+func (g *ScanGuard) Miss(symbol string, callers ...string) {
+	if g == nil || g.policy.MaxMisses <= 0 {
+		return
+	}
+	now := g.clock.Now()
+
+	var alerts []ScanAlert
+	g.mu.Lock()
+	for _, caller := range callers {
+		st := g.state(caller, now)
+		if st == nil {
+			continue
+		}
+		for s, at := range st.misses {
+			if now.Sub(at) >= g.policy.Window {
+				delete(st.misses, s)
+			}
+		}
+		st.misses[symbol] = now
+		if len(st.misses) >= g.policy.MaxMisses {
+			st.throttledUntil = now.Add(g.policy.Cooldown)
+			alerts = append(alerts, ScanAlert{
+				Caller: caller,
+				Misses: len(st.misses),
+				Window: g.policy.Window.String(),
+				Until:  st.throttledUntil,
+			})
+			clear(st.misses)
+		}
+	}
+	g.mu.Unlock()
+
+	if g.onThrottle != nil {
+		for _, a := range alerts {
+			g.onThrottle(a)
+		}
+	}
+}
+
+// state returns the caller's state, creating it on first use, or nil if
+// there's no room for another caller. Call with g.mu held.
+func (g *ScanGuard) state(caller string, now time.Time) *scanState {
+	if st, ok := g.callers[caller]; ok {
+		return st
+	}
+	if len(g.callers) >= maxScanCallers {
+		g.forgetIdle(now)
+		if len(g.callers) >= maxScanCallers {
+			return nil
+		}
+	}
+	st := &scanState{misses: make(map[string]time.Time)}
+	g.callers[caller] = st
+	return st
+}
+
+// forgetIdle drops the callers that are neither throttled nor have a miss
+// within the window. Call with g.mu held.
+func (g *ScanGuard) forgetIdle(now time.Time) {
+	for caller, st := range g.callers {
+		if now.Before(st.throttledUntil) {
+			continue
+		}
+		recent := false
+		for _, at := range st.misses {
+			if now.Sub(at) < g.policy.Window {
+				recent = true
+				break
+			}
+		}
+		if !recent {
+			delete(g.callers, caller)
+		}
+	}
+}
+
+// scanGuardKey is the context key of the request's scan guard and callers.
+type scanGuardKey struct{}
+
+// scanScope is a ScanGuard bound to the callers of one request.
+type scanScope struct {
+	guard   *ScanGuard
+	callers []string
+}
+
+// WithScanGuard returns a context under which GetLogo asks guard before
+// acquiring a logo for callers, and tells it when the search found nothing.
+// The HTTP server passes the request's API key and client IP.
+func WithScanGuard(ctx context.Context, guard *ScanGuard, callers ...string) context.Context {
+	return context.WithValue(ctx, scanGuardKey{}, scanScope{guard: guard, callers: callers})
+}
+
+// scanOf returns the context's scan guard and callers; the guard is nil if
+// there is none.
+func scanOf(ctx context.Context) scanScope {
+	scope, _ := ctx.Value(scanGuardKey{}).(scanScope)
+	return scope
+}
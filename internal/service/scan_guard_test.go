@@ -0,0 +1,53 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fleveque/logo-service/internal/clock"
+)
+
+func TestScanGuard(t *testing.T) {
+	var alerts []ScanAlert
+	g := NewScanGuard(ScanPolicy{MaxMisses: 3, Window: time.Minute, Cooldown: time.Hour}, func(a ScanAlert) {
+		alerts = append(alerts, a)
+	})
+	fake := clock.NewFake(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	g.SetClock(fake)
+
+	// The same symbol twice is one miss; the first one drops out of the window.
+	g.Miss("AAA", "key:a", "ip:1")
+	fake.Advance(time.Minute)
+	g.Miss("BBB", "key:a", "ip:1")
+	g.Miss("BBB", "key:a", "ip:1")
+	g.Miss("CCC", "key:a", "ip:2")
+	if !g.Allow("key:a", "ip:1") || len(alerts) != 0 {
+		t.Fatalf("throttled before 3 distinct misses within the window (alerts %+v)", alerts)
+	}
+
+	g.Miss("DDD", "key:a", "ip:2")
+	if g.Allow("key:a") || g.Allow("ip:9", "key:a") {
+		t.Error("expected key:a to be throttled, whatever its IP")
+	}
+	if !g.Allow("key:b", "ip:1") {
+		t.Error("expected other callers to be allowed")
+	}
+	if len(alerts) != 1 || alerts[0].Caller != "key:a" || alerts[0].Misses != 3 {
+		t.Errorf("expected one alert for key:a with 3 misses, got %+v", alerts)
+	}
+
+	fake.Advance(time.Hour)
+	if !g.Allow("key:a") {
+		t.Error("expected key:a to be allowed again after the cooldown")
+	}
+
+	var nilGuard *ScanGuard
+	nilGuard.Miss("AAA", "key:a")
+	if !nilGuard.Allow("key:a") {
+		t.Error("expected a nil guard to allow everything")
+	}
+	if !errors.Is(ErrScanThrottled, ErrAcquisitionLimited) {
+		t.Error("expected ErrScanThrottled to be an ErrAcquisitionLimited")
+	}
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+// ErrNoStatsHistory is returned by the stats history methods of a service
+// built without WithStatsHistory.
+var ErrNoStatsHistory = errors.New("stats history is not enabled")
+
+// RecordStats snapshots today's stats (UTC) into the history, replacing
+// the snapshot taken earlier today: run every hour or so, the history's
+// last point is never far behind, and each past day keeps its last one.
+func (s *LogoService) RecordStats(ctx context.Context) (*model.StatsSnapshot, error) {
+	if s.statsHistory == nil {
+		return nil, ErrNoStatsHistory
+	}
+	snapshot, err := s.statsHistory.Collect(ctx, s.clock.Now().UTC().Format(time.DateOnly))
+	if err != nil {
+		return nil, err
+	}
+	snapshot.LLMSpendCents = s.LLMSpendCents(snapshot.LLMCalls)
+	if snapshot.Requests > 0 {
+		// Usage is flushed every few seconds, misses right away: early in a
+		// day the misses can be ahead.
+		hits := max(snapshot.Requests-snapshot.Misses, 0)
+		rate := float64(hits) / float64(snapshot.Requests)
+		snapshot.CacheHitRate = &rate
+	}
+	if err := s.statsHistory.Record(ctx, snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// LLMSpendCents estimates what calls LLM searches cost, at the rate given
+// to WithStatsHistory: zero without it.
+func (s *LogoService) LLMSpendCents(calls int64) int64 {
+	return calls * s.llmCallCents
+}
+
+// StatsHistory returns the snapshots of the last days days, today
+// included, oldest first. Days nothing was recorded on are left out.
+func (s *LogoService) StatsHistory(ctx context.Context, days int) ([]model.StatsSnapshot, error) {
+	if s.statsHistory == nil {
+		return nil, ErrNoStatsHistory
+	}
+	since := s.clock.Now().UTC().AddDate(0, 0, 1-days).Format(time.DateOnly)
+	return s.statsHistory.List(ctx, since)
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fleveque/logo-service/internal/clock"
+	"github.com/fleveque/logo-service/internal/model"
+	"github.com/fleveque/logo-service/internal/storage"
+	"github.com/fleveque/logo-service/internal/testutil"
+)
+
+func TestRecordStats(t *testing.T) {
+	if _, err := newTestService(t, testutil.NewFakeProvider(), nil, AcceptancePolicy{}).svc.RecordStats(context.Background()); !errors.Is(err, ErrNoStatsHistory) {
+		t.Fatalf("expected ErrNoStatsHistory without a history, got %v", err)
+	}
+
+	fake := clock.NewFake(time.Date(2025, 3, 1, 23, 0, 0, 0, time.UTC))
+	d := newTestService(t, testutil.NewFakeProvider(), nil, AcceptancePolicy{}, WithClock(fake))
+	// The history reads the service's tables, so it needs its database.
+	WithStatsHistory(storage.NewStatsHistoryRepository(d.db), 5)(d.svc)
+	ctx := context.Background()
+
+	if err := d.logoRepo.Create(ctx, &model.Logo{Symbol: "AAPL", Source: "llm:anthropic", Status: model.StatusProcessed}); err != nil {
+		t.Fatal(err)
+	}
+	usage := storage.NewUsageRepository(d.db)
+	if err := usage.Add(ctx, []model.KeyUsage{{KeyID: "a", Day: "2025-03-01", Requests: 8}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, trace := range []string{"t1", "t2"} {
+		if err := d.attempts.Record(ctx, &model.Attempt{Trace: trace, Symbol: "AAPL", Provider: "github", Outcome: "miss", CreatedAt: fake.Now()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	snapshot, err := d.svc.RecordStats(ctx)
+	if err != nil {
+		t.Fatalf("RecordStats: %v", err)
+	}
+	if snapshot.Day != "2025-03-01" || snapshot.Processed != 1 || snapshot.BySource["llm"] != 1 {
+		t.Errorf("unexpected snapshot %+v", snapshot)
+	}
+	if snapshot.CacheHitRate == nil || *snapshot.CacheHitRate != 0.75 {
+		t.Errorf("expected a hit rate of 6/8, got %v", snapshot.CacheHitRate)
+	}
+
+	// The next day's snapshot doesn't replace this one.
+	fake.Advance(2 * time.Hour)
+	if _, err := d.svc.RecordStats(ctx); err != nil {
+		t.Fatal(err)
+	}
+	snapshots, err := d.svc.StatsHistory(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 2 || snapshots[0].Day != "2025-03-01" || snapshots[1].Day != "2025-03-02" {
+		t.Fatalf("expected 2 days, got %+v", snapshots)
+	}
+	if snapshots[1].Requests != 0 || snapshots[1].CacheHitRate != nil {
+		t.Errorf("a day without requests has no hit rate, got %+v", snapshots[1])
+	}
+	if snapshots, _ := d.svc.StatsHistory(ctx, 1); len(snapshots) != 1 {
+		t.Errorf("expected only today, got %+v", snapshots)
+	}
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"go.uber.org/zap"
+
+	"github.com/fleveque/logo-service/internal/model"
+	"github.com/fleveque/logo-service/internal/storage"
+)
+
+// CopyStats reports what CopyStorage did.
+type CopyStats struct {
+	Logos     int               `json:"logos"`     // Logos whose files were copied, soft-deleted ones included
+	Files     storage.CopyStats `json:"files"`     // Sizes and cached variants
+	Originals storage.CopyStats `json:"originals"` // Source images
+	Cleared   int               `json:"cleared"`   // Flags cleared because the source had no file either
+}
+
+// CopyStorage copies every logo's files and originals from the service's
+// FileSystem to dst, verifying each copy, so the server can keep serving
+// from the old store while it runs and cut over to dst afterwards (see
+// storage.FileSystem.SetCutover). Files already in dst with the same bytes
+// are skipped, so an interrupted run resumes where it stopped, and a last
+// run just before the cutover only copies what changed since.
+//
+// A size flagged available whose file the source doesn't have either gets
+// its flag cleared, as VerifyStorage would: the record stops claiming it
+// on the new store too, and the next request renders it again.
+func (s *LogoService) CopyStorage(ctx context.Context, dst *storage.FileSystem) (*CopyStats, error) {
+	live, err := s.logoRepo.ListStates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Deleted logos keep their files until they're purged, to be restorable.
+	deleted, err := s.logoRepo.ListDeleted(ctx, s.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &CopyStats{}
+	copiedOriginals := make(map[string]bool)
+	for _, logo := range append(live, deleted...) {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+		files, err := s.fs.CopySymbol(dst, logo.Symbol)
+		if err != nil {
+			return stats, err
+		}
+		stats.Logos++
+		stats.Files.Copied += files.Copied
+		stats.Files.Skipped += files.Skipped
+
+		for _, size := range model.AllSizes {
+			if !logo.HasSize(size) || dst.Exists(logo.Symbol, size) {
+				continue
+			}
+			if err := s.logoRepo.SetSizeUnavailable(ctx, logo.Symbol, size); err != nil {
+				return stats, err
+			}
+			stats.Cleared++
+		}
+
+		if err := s.copyOriginals(ctx, dst, logo.Symbol, copiedOriginals, stats); err != nil {
+			return stats, err
+		}
+		if stats.Logos%1000 == 0 {
+			s.logger.Info("copying storage", zap.Int("logos", stats.Logos), zap.Int("files_copied", stats.Files.Copied))
+		}
+	}
+	return stats, nil
+}
+
+// copyOriginals copies the originals recorded for symbol that weren't
+// copied yet; logos often share one.
+func (s *LogoService) copyOriginals(ctx context.Context, dst *storage.FileSystem, symbol string, done map[string]bool, stats *CopyStats) error {
+	if s.originals == nil {
+		return nil
+	}
+	originals, err := s.originals.ListBySymbol(ctx, symbol)
+	if err != nil {
+		return err
+	}
+	for _, original := range originals {
+		if done[original.Hash] {
+			continue
+		}
+		done[original.Hash] = true
+		copied, err := s.fs.CopyOriginal(dst, original.Hash)
+		if errors.Is(err, os.ErrNotExist) {
+			// Nothing to lose: it was already gone from the old store.
+			s.logger.Warn("original missing, not copied", zap.String("symbol", symbol), zap.String("hash", original.Hash))
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", symbol, err)
+		}
+		if copied {
+			stats.Originals.Copied++
+		} else {
+			stats.Originals.Skipped++
+		}
+	}
+	return nil
+}
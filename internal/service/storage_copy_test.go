@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fleveque/logo-service/internal/model"
+	"github.com/fleveque/logo-service/internal/provider"
+	"github.com/fleveque/logo-service/internal/storage"
+	"github.com/fleveque/logo-service/internal/testutil"
+)
+
+func TestCopyStorage(t *testing.T) {
+	aapl := &provider.LogoResult{Symbol: "AAPL", ImageData: []byte("aapl"), Source: "github:test"}
+	msft := &provider.LogoResult{Symbol: "MSFT", ImageData: []byte("msft"), Source: "github:test"}
+	d := newTestService(t, testutil.NewFakeProvider(aapl, msft), nil, AcceptancePolicy{})
+	ctx := context.Background()
+
+	for _, result := range []*provider.LogoResult{aapl, msft} {
+		if err := d.svc.ProcessAndStore(ctx, result); err != nil {
+			t.Fatalf("ProcessAndStore %s: %v", result.Symbol, err)
+		}
+	}
+	// A file lost before the migration: its flag is cleared.
+	if err := d.fs.DeleteSymbol("MSFT"); err != nil {
+		t.Fatal(err)
+	}
+
+	dst, err := storage.NewMemoryFileSystem(storage.LayoutPrefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats, err := d.svc.CopyStorage(ctx, dst)
+	if err != nil {
+		t.Fatalf("CopyStorage: %v", err)
+	}
+	sizes := len(model.AllSizes)
+	if stats.Logos != 2 || stats.Files.Copied != sizes || stats.Originals.Copied != 2 || stats.Cleared != sizes {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if data, err := dst.Read("AAPL", model.SizeM); err != nil || string(data) != "aapl" {
+		t.Errorf("expected AAPL in the new store, got %q (%v)", data, err)
+	}
+	logo, err := d.logoRepo.GetBySymbol(ctx, "MSFT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if missing := logo.MissingSizes(); len(missing) != sizes {
+		t.Errorf("expected every MSFT flag cleared, still missing only %v", missing)
+	}
+
+	// Run again, nothing is left to copy.
+	stats, err = d.svc.CopyStorage(ctx, dst)
+	if err != nil {
+		t.Fatalf("CopyStorage again: %v", err)
+	}
+	if stats.Files.Copied != 0 || stats.Files.Skipped != sizes || stats.Originals.Skipped != 2 || stats.Cleared != 0 {
+		t.Errorf("expected a resumed copy to skip everything, got %+v", stats)
+	}
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+// Transform runs an image through the same pipeline as ProcessAll and
+// returns the PNG of each of sizes, flattened onto background (a hex
+// color) if it's set. Nothing is stored: it's the processor as a service,
+// for tools that want logo-style resizing of their own images. Unlike
+// ProcessAll, any size failing fails the whole call. Images over maxBytes
+// are rejected, as ProcessAll rejects them.
+func Transform(imageData []byte, maxBytes int, sizes []model.LogoSize, background string, opts model.ProcessOptions) (map[model.LogoSize][]byte, error) {
+	imageData, err := prepare(imageData, maxBytes, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	renditions := make(map[model.LogoSize][]byte, len(sizes))
+	for _, size := range sizes {
+		data, err := resizeToSquarePNG(imageData, model.SizePixels[size])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", size, err)
+		}
+		if background != "" {
+			if data, err = ApplyBackground(data, background); err != nil {
+				return nil, fmt.Errorf("%s background: %w", size, err)
+			}
+		}
+		renditions[size] = data
+	}
+	return renditions, nil
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"errors"
+	"image/color"
+	"testing"
+
+	"github.com/h2non/bimg"
+
+	"github.com/fleveque/logo-service/internal/imagefmt"
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+func TestTransform(t *testing.T) {
+	testImage := createTestPNG(256, 128, color.RGBA{R: 255, A: 255})
+
+	renditions, err := Transform(testImage, imagefmt.MaxBytes, []model.LogoSize{model.SizeS, model.SizeL}, "ffffff", model.ProcessOptions{})
+	if err != nil {
+		t.Fatalf("Transform failed: %v", err)
+	}
+	if len(renditions) != 2 {
+		t.Fatalf("expected 2 renditions, got %d", len(renditions))
+	}
+	for size, data := range renditions {
+		imgSize, err := bimg.NewImage(data).Size()
+		if err != nil {
+			t.Fatalf("reading %s: %v", size, err)
+		}
+		if want := model.SizePixels[size]; imgSize.Width != want || imgSize.Height != want {
+			t.Errorf("%s: expected %dx%d, got %dx%d", size, want, want, imgSize.Width, imgSize.Height)
+		}
+	}
+
+	if _, err := Transform([]byte("<html>not found</html>"), imagefmt.MaxBytes, model.AllSizes, "", model.ProcessOptions{}); !errors.Is(err, imagefmt.ErrUnsupported) {
+		t.Errorf("expected ErrUnsupported for HTML, got %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/fleveque/logo-service/internal/model"
+	"github.com/fleveque/logo-service/internal/storage"
+)
+
+// VariantCache stores derived renditions (e.g. a size flattened onto a
+// background color) on disk, under a total byte quota. When a write pushes
+// the total over the quota, the least recently served variants are evicted.
+//
+// Only variants go through the cache — canonical sizes are written by
+// ImageProcessor and never evicted. A variant can always be re-derived from
+// its canonical size, which is what makes evicting it safe.
+type VariantCache struct {
+	repo     storage.VariantRepository
+	fs       *storage.FileSystem
+	maxBytes int64
+	logger   *zap.Logger
+
+	// evictMu serializes eviction, so two writers over quota at once don't
+	// both pick (and double-count) the same victims.
+	evictMu sync.Mutex
+}
+
+// NewVariantCache creates a cache that keeps variants under maxBytes in total.
+func NewVariantCache(repo storage.VariantRepository, fs *storage.FileSystem, maxBytes int64, logger *zap.Logger) *VariantCache {
+	return &VariantCache{repo: repo, fs: fs, maxBytes: maxBytes, logger: logger}
+}
+
+// Get returns a cached variant and records the access. ok is false on a miss.
+func (c *VariantCache) Get(ctx context.Context, symbol string, size model.LogoSize, variant string) (data []byte, ok bool) {
+	data, err := c.fs.ReadVariant(symbol, size, variant)
+	if err != nil {
+		return nil, false
+	}
+	if err := c.repo.Touch(ctx, symbol, size, variant); err != nil {
+		c.logger.Warn("touching variant", zap.String("symbol", symbol), zap.Error(err))
+	}
+	return data, true
+}
+
+// Put stores a variant, then evicts older ones if the quota is exceeded.
+// A variant larger than the whole quota isn't cached at all.
+func (c *VariantCache) Put(ctx context.Context, symbol string, size model.LogoSize, variant string, data []byte) error {
+	if int64(len(data)) > c.maxBytes {
+		return nil
+	}
+
+	if err := c.fs.WriteVariant(symbol, size, variant, data); err != nil {
+		return err
+	}
+	err := c.repo.Record(ctx, &model.LogoVariant{Symbol: symbol, Size: size, Variant: variant, Bytes: int64(len(data))})
+	if err != nil {
+		// An untracked file would never be evicted — don't leave it behind.
+		_ = c.fs.DeleteVariant(symbol, size, variant)
+		return err
+	}
+
+	_, err = c.evict(ctx)
+	return err
+}
+
+// Purge drops every cached variant of a symbol — call it when the symbol's
+// canonical sizes change, or its variants would show the old image.
+func (c *VariantCache) Purge(ctx context.Context, symbol string) error {
+	if err := c.fs.DeleteVariants(symbol); err != nil {
+		return err
+	}
+	return c.repo.DeleteBySymbol(ctx, symbol)
+}
+
+// evictBatch is how many eviction candidates are loaded per query.
+const evictBatch = 100
+
+// evict deletes least recently used variants until the total is within the
+// quota, and returns how many it deleted.
+func (c *VariantCache) evict(ctx context.Context) (int, error) {
+	c.evictMu.Lock()
+	defer c.evictMu.Unlock()
+
+	total, err := c.repo.TotalBytes(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	evicted := 0
+	for total > c.maxBytes {
+		victims, err := c.repo.LeastRecentlyUsed(ctx, evictBatch)
+		if err != nil {
+			return evicted, err
+		}
+		if len(victims) == 0 {
+			break
+		}
+
+		for _, v := range victims {
+			if total <= c.maxBytes {
+				break
+			}
+			// File first: a row without a file is harmless (Get misses and the
+			// next Put re-records it), a file without a row would never be evicted.
+			if err := c.fs.DeleteVariant(v.Symbol, v.Size, v.Variant); err != nil {
+				return evicted, fmt.Errorf("evicting %s/%s/%s: %w", v.Symbol, v.Size, v.Variant, err)
+			}
+			if err := c.repo.Delete(ctx, v.Symbol, v.Size, v.Variant); err != nil {
+				return evicted, err
+			}
+			total -= v.Bytes
+			evicted++
+		}
+	}
+
+	if evicted > 0 {
+		c.logger.Debug("evicted variants", zap.Int("count", evicted), zap.Int64("total_bytes", total))
+	}
+	return evicted, nil
+}
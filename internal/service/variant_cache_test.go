@@ -0,0 +1,85 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/fleveque/logo-service/internal/model"
+	"github.com/fleveque/logo-service/internal/storage"
+	"github.com/fleveque/logo-service/internal/testutil"
+)
+
+func TestVariantCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	db := testutil.NewDB(t)
+	fs := testutil.NewFileSystem(t)
+	cache := NewVariantCache(storage.NewVariantRepository(db), fs, 250, zap.NewNop())
+	ctx := context.Background()
+
+	// A canonical size: must survive any amount of eviction.
+	if err := fs.Write("AAPL", model.SizeM, bytes.Repeat([]byte("c"), 1000)); err != nil {
+		t.Fatal(err)
+	}
+
+	variant := bytes.Repeat([]byte("v"), 100)
+	for _, bg := range []string{"bg_000000", "bg_111111"} {
+		if err := cache.Put(ctx, "AAPL", model.SizeM, bg, variant); err != nil {
+			t.Fatalf("Put %s: %v", bg, err)
+		}
+	}
+
+	// Serve the older one, so the newer one becomes the eviction candidate.
+	if _, ok := cache.Get(ctx, "AAPL", model.SizeM, "bg_000000"); !ok {
+		t.Fatal("expected a cache hit")
+	}
+
+	// 300 bytes > 250: one variant has to go.
+	if err := cache.Put(ctx, "AAPL", model.SizeM, "bg_222222", variant); err != nil {
+		t.Fatal(err)
+	}
+
+	for bg, want := range map[string]bool{"bg_000000": true, "bg_111111": false, "bg_222222": true} {
+		if _, ok := cache.Get(ctx, "AAPL", model.SizeM, bg); ok != want {
+			t.Errorf("%s cached = %v, want %v", bg, ok, want)
+		}
+	}
+	if !fs.Exists("AAPL", model.SizeM) {
+		t.Error("canonical size must never be evicted")
+	}
+}
+
+func TestVariantCache_SkipsOversizedAndPurges(t *testing.T) {
+	db := testutil.NewDB(t)
+	fs := testutil.NewFileSystem(t)
+	repo := storage.NewVariantRepository(db)
+	cache := NewVariantCache(repo, fs, 100, zap.NewNop())
+	ctx := context.Background()
+
+	if err := cache.Put(ctx, "MSFT", model.SizeL, "bg_ffffff", make([]byte, 101)); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.Get(ctx, "MSFT", model.SizeL, "bg_ffffff"); ok {
+		t.Error("a variant larger than the quota should not be cached")
+	}
+
+	if err := fs.Write("MSFT", model.SizeL, []byte("canonical")); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.Put(ctx, "MSFT", model.SizeL, "bg_ffffff", []byte("variant")); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.Purge(ctx, "MSFT"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.Get(ctx, "MSFT", model.SizeL, "bg_ffffff"); ok {
+		t.Error("variant should be gone after Purge")
+	}
+	if total, _ := repo.TotalBytes(ctx); total != 0 {
+		t.Errorf("TotalBytes after Purge = %d, want 0", total)
+	}
+	if !fs.Exists("MSFT", model.SizeL) {
+		t.Error("Purge must keep canonical sizes")
+	}
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+
+	// Registered with image.Decode, so JPEG and GIF sources skip libvips.
+	_ "image/gif"
+	_ "image/jpeg"
+
+	"github.com/h2non/bimg"
+)
+
+const (
+	// whiteThreshold is how bright every channel of a pixel must be to count
+	// as background white. JPEG artifacts make "white" anything but 255.
+	whiteThreshold = 240
+
+	// minWhiteBorder is the share of border pixels that must be white for the
+	// background to count as uniform. A logo touching the edge is fine; a
+	// photo with a bright sky is not.
+	minWhiteBorder = 0.9
+)
+
+// whitenBackground makes a uniform white background transparent and trims
+// the margin around the logo. Inputs without one (already transparent,
+// colored or busy backgrounds) are returned unchanged.
+//
+// The pixel work is pure Go, so anything Go can't decode is first converted
+// to PNG by libvips.
+func whitenBackground(data []byte) ([]byte, error) {
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		converted, convErr := bimg.NewImage(data).Convert(bimg.PNG)
+		if convErr != nil {
+			return nil, fmt.Errorf("converting to png: %w", convErr)
+		}
+		if src, err = png.Decode(bytes.NewReader(converted)); err != nil {
+			return nil, fmt.Errorf("decoding converted png: %w", err)
+		}
+	}
+
+	out, ok := whiteToAlpha(src)
+	if !ok {
+		return data, nil
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, out); err != nil {
+		return nil, fmt.Errorf("encoding png: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// whiteToAlpha does the work for whitenBackground: it flood-fills the white
+// connected to the border with transparency, softens the anti-aliased edge
+// next to it, then crops to the pixels left visible. Flood filling rather
+// than replacing every white pixel keeps white inside the logo (letters,
+// counters of an "O") opaque.
+//
+// ok is false when there's no uniform white background to remove.
+func whiteToAlpha(src image.Image) (img image.Image, ok bool) {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w < 3 || h < 3 {
+		return src, false
+	}
+
+	// Go note: draw.Draw converts any image.Image into a concrete NRGBA, so
+	// the loops below index Pix directly instead of calling At per pixel.
+	rgba := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(rgba, rgba.Bounds(), src, b.Min, draw.Src)
+
+	isWhite := func(i int) bool {
+		p := rgba.Pix[i*4 : i*4+4]
+		return p[0] >= whiteThreshold && p[1] >= whiteThreshold && p[2] >= whiteThreshold && p[3] == 0xff
+	}
+
+	// Check the border first: any transparency means the logo already has an
+	// alpha background, and too little white means there's no uniform one.
+	edge := make([]int, 0, 2*(w+h))
+	for x := 0; x < w; x++ {
+		edge = append(edge, x, (h-1)*w+x)
+	}
+	for y := 1; y < h-1; y++ {
+		edge = append(edge, y*w, y*w+w-1)
+	}
+	var queue []int
+	for _, i := range edge {
+		if rgba.Pix[i*4+3] != 0xff {
+			return src, false
+		}
+		if isWhite(i) {
+			queue = append(queue, i)
+		}
+	}
+	if float64(len(queue)) < minWhiteBorder*float64(len(edge)) {
+		return src, false
+	}
+
+	// Breadth-first flood fill from the white border pixels, 4-connected.
+	background := make([]bool, w*h)
+	for _, i := range queue {
+		background[i] = true
+	}
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		x, y := i%w, i/w
+		for _, n := range [4][2]int{{x - 1, y}, {x + 1, y}, {x, y - 1}, {x, y + 1}} {
+			if n[0] < 0 || n[0] >= w || n[1] < 0 || n[1] >= h {
+				continue
+			}
+			j := n[1]*w + n[0]
+			if !background[j] && isWhite(j) {
+				background[j] = true
+				queue = append(queue, j)
+			}
+		}
+	}
+
+	for i, bg := range background {
+		if bg {
+			rgba.Pix[i*4+3] = 0
+		}
+	}
+
+	// Pixels bordering the background are usually the logo blended with
+	// white by anti-aliasing; undo the blend so no light halo is left.
+	for i, bg := range background {
+		if bg {
+			continue
+		}
+		x, y := i%w, i/w
+		if (x > 0 && background[i-1]) || (x < w-1 && background[i+1]) ||
+			(y > 0 && background[i-w]) || (y < h-1 && background[i+w]) {
+			unblendWhite(rgba.Pix[i*4 : i*4+4])
+		}
+	}
+
+	visible := image.Rectangle{}
+	for i, bg := range background {
+		if !bg && rgba.Pix[i*4+3] > 0 {
+			x, y := i%w, i/w
+			visible = visible.Union(image.Rect(x, y, x+1, y+1))
+		}
+	}
+	// All white: nothing that looks like a logo, leave it alone.
+	if visible.Empty() {
+		return src, false
+	}
+	return rgba.SubImage(visible), true
+}
+
+// unblendWhite reverses alpha blending onto white for one NRGBA pixel:
+// the lowest channel tells how much white was mixed in, which becomes
+// transparency, and the color is rescaled to what it was before mixing.
+func unblendWhite(p []byte) {
+	lowest := min(p[0], p[1], p[2])
+	alpha := 255 - int(lowest)
+	if alpha == 0 {
+		p[3] = 0
+		return
+	}
+	for c := 0; c < 3; c++ {
+		// c = orig*a + 255*(1-a)  →  orig = (c - 255 + a) / a
+		p[c] = uint8((int(p[c]) - 255 + alpha) * 255 / alpha)
+	}
+	p[3] = uint8(alpha * int(p[3]) / 255)
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+// logoOn draws a 20×20 "logo" ring (red, with a white hole in the middle)
+// centered on a 40×40 background.
+func logoOn(bg color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 40, 40))
+	for y := 0; y < 40; y++ {
+		for x := 0; x < 40; x++ {
+			c := bg
+			if x >= 10 && x < 30 && y >= 10 && y < 30 {
+				c = color.NRGBA{R: 200, A: 255}
+				if x >= 18 && x < 22 && y >= 18 && y < 22 {
+					c = color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+				}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestWhiteToAlpha(t *testing.T) {
+	white := color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+
+	tests := []struct {
+		name   string
+		src    *image.NRGBA
+		wantOK bool
+	}{
+		{"white background", logoOn(white), true},
+		{"near-white JPEG-ish background", logoOn(color.NRGBA{R: 250, G: 247, B: 252, A: 255}), true},
+		{"already transparent", logoOn(color.NRGBA{}), false},
+		{"colored background", logoOn(color.NRGBA{B: 255, A: 255}), false},
+		{"all white", func() *image.NRGBA {
+			img := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+			for i := range img.Pix {
+				img.Pix[i] = 0xff
+			}
+			return img
+		}(), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, ok := whiteToAlpha(tt.src)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				if out != image.Image(tt.src) {
+					t.Error("expected the source back unchanged")
+				}
+				return
+			}
+
+			// Trimmed to the logo itself.
+			if b := out.Bounds(); b.Dx() != 20 || b.Dy() != 20 {
+				t.Errorf("expected a 20x20 trim, got %v", b)
+			}
+			origin := out.Bounds().Min
+			if _, _, _, a := out.At(origin.X+5, origin.Y+5).RGBA(); a != 0xffff {
+				t.Error("logo pixels must stay opaque")
+			}
+			// The hole isn't connected to the border, so it stays white.
+			if _, _, _, a := out.At(origin.X+10, origin.Y+10).RGBA(); a != 0xffff {
+				t.Error("white enclosed by the logo must stay opaque")
+			}
+		})
+	}
+}
+
+func TestWhitenBackground_PNGRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, logoOn(color.NRGBA{R: 255, G: 255, B: 255, A: 255})); err != nil {
+		t.Fatalf("encoding: %v", err)
+	}
+
+	out, err := whitenBackground(buf.Bytes())
+	if err != nil {
+		t.Fatalf("whitenBackground: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("decoding result: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 20 || b.Dy() != 20 {
+		t.Errorf("expected a 20x20 trimmed PNG, got %v", b)
+	}
+
+	// No white background: the input comes back byte for byte.
+	red := createTestPNG(16, 16, color.NRGBA{R: 255, A: 255})
+	out, err = whitenBackground(red)
+	if err != nil {
+		t.Fatalf("whitenBackground: %v", err)
+	}
+	if !bytes.Equal(out, red) {
+		t.Error("expected an image without a white background to be left alone")
+	}
+}
@@ -0,0 +1,139 @@
+// Package sprite composes logos into a sprite sheet: one PNG holding many
+// logos on a grid, plus the coordinates of each, so a dashboard showing
+// hundreds of tiny logos makes one HTTP request instead of hundreds.
+//
+// A logo's place depends only on its index in the requested list, never on
+// which logos were available. The image, JSON map and CSS can therefore be
+// fetched separately and still agree, even if a logo got cached in between.
+package sprite
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"math"
+	"strings"
+)
+
+// MaxPixels caps a sheet at 4096×4096 (64 MB while it's being drawn).
+const MaxPixels = 4096 * 4096
+
+// ErrTooLarge is returned when the symbols wouldn't fit in MaxPixels at the
+// requested size. Ask for fewer symbols or a smaller size.
+var ErrTooLarge = errors.New("sprite sheet too large")
+
+// Cell is where one logo sits in the sheet, in pixels from the top left.
+type Cell struct {
+	Symbol string `json:"symbol"`
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+}
+
+// Sheet is a composed sprite. Logos lists the cells that hold a logo;
+// cells of Missing symbols are left transparent.
+type Sheet struct {
+	CellSize int      `json:"cell_size"` // Side of each square cell, the logo size in px
+	Columns  int      `json:"columns"`
+	Width    int      `json:"width"`
+	Height   int      `json:"height"`
+	Logos    []Cell   `json:"logos"`
+	Missing  []string `json:"missing,omitempty"`
+
+	img *image.RGBA
+}
+
+// Build lays symbols out on a grid of cellSize-pixel squares, as square as
+// possible, and draws the PNG that get returns for each. A symbol whose get
+// fails, or returns something that isn't a PNG, ends up in Missing.
+//
+// Go note: get is a plain function rather than an interface, so callers
+// pass whatever already reads a logo — a service method, a client call —
+// wrapped in a closure.
+func Build(symbols []string, cellSize int, get func(symbol string) ([]byte, error)) (*Sheet, error) {
+	if len(symbols) == 0 {
+		return nil, errors.New("no symbols")
+	}
+	columns := int(math.Ceil(math.Sqrt(float64(len(symbols)))))
+	rows := (len(symbols) + columns - 1) / columns
+	if columns*rows*cellSize*cellSize > MaxPixels {
+		return nil, fmt.Errorf("%w: %d logos at %dpx", ErrTooLarge, len(symbols), cellSize)
+	}
+
+	s := &Sheet{
+		CellSize: cellSize,
+		Columns:  columns,
+		Width:    columns * cellSize,
+		Height:   rows * cellSize,
+		Logos:    []Cell{},
+		img:      image.NewRGBA(image.Rect(0, 0, columns*cellSize, rows*cellSize)),
+	}
+	for i, symbol := range symbols {
+		cell := Cell{Symbol: symbol, X: i % columns * cellSize, Y: i / columns * cellSize}
+
+		data, err := get(symbol)
+		if err != nil {
+			s.Missing = append(s.Missing, symbol)
+			continue
+		}
+		logo, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			s.Missing = append(s.Missing, symbol)
+			continue
+		}
+
+		// A logo bigger than the cell is clipped rather than spilling over its neighbours.
+		r := image.Rect(cell.X, cell.Y, cell.X+cellSize, cell.Y+cellSize)
+		draw.Draw(s.img, r, logo, logo.Bounds().Min, draw.Src)
+		s.Logos = append(s.Logos, cell)
+	}
+	return s, nil
+}
+
+// PNG encodes the sheet.
+func (s *Sheet) PNG() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, s.img); err != nil {
+		return nil, fmt.Errorf("encoding sprite: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// CSS returns a stylesheet for the sheet served at imageURL: a .logo class
+// sized to one cell, and a .logo-{SYMBOL} class per logo positioning the
+// background, used as <span class="logo logo-AAPL"></span>.
+func (s *Sheet) CSS(imageURL string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, ".logo{display:inline-block;width:%dpx;height:%dpx;background-image:url(%q);background-repeat:no-repeat}\n",
+		s.CellSize, s.CellSize, imageURL)
+	for _, c := range s.Logos {
+		fmt.Fprintf(&b, ".logo-%s{background-position:-%dpx -%dpx}\n", cssEscape(c.Symbol), c.X, c.Y)
+	}
+	return b.String()
+}
+
+// cssEscape backslash-escapes the characters a class selector can't hold
+// as-is, e.g. "BRK.B" → "BRK\.B" and "^GSPC" → "\^GSPC".
+func cssEscape(symbol string) string {
+	var b strings.Builder
+	for i := 0; i < len(symbol); i++ {
+		c := symbol[i]
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c == '-', c == '_':
+			b.WriteByte(c)
+		case c >= '0' && c <= '9':
+			if i == 0 {
+				// A leading digit can only be escaped by its code point: "1" → "\31 ".
+				fmt.Fprintf(&b, "\\3%c ", c)
+			} else {
+				b.WriteByte(c)
+			}
+		default:
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
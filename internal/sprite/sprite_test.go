@@ -0,0 +1,104 @@
+package sprite
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func solidPNG(t *testing.T, px int, c color.RGBA) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, px, px))
+	for y := 0; y < px; y++ {
+		for x := 0; x < px; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestBuild(t *testing.T) {
+	red := color.RGBA{0xff, 0, 0, 0xff}
+	logos := map[string][]byte{
+		"AAPL":  solidPNG(t, 16, red),
+		"BRK.B": solidPNG(t, 16, red),
+		"BAD":   []byte("not a png"),
+	}
+	get := func(symbol string) ([]byte, error) {
+		if data, ok := logos[symbol]; ok {
+			return data, nil
+		}
+		return nil, errors.New("not cached")
+	}
+
+	// Five symbols → a 3×2 grid; MSFT and BAD leave holes but keep their cells.
+	sheet, err := Build([]string{"AAPL", "MSFT", "BAD", "BRK.B", "TSLA"}, 16, get)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if sheet.Columns != 3 || sheet.Width != 48 || sheet.Height != 32 {
+		t.Errorf("grid = %d columns, %dx%d; want 3 columns, 48x32", sheet.Columns, sheet.Width, sheet.Height)
+	}
+	want := []Cell{{"AAPL", 0, 0}, {"BRK.B", 0, 16}}
+	if len(sheet.Logos) != len(want) || sheet.Logos[0] != want[0] || sheet.Logos[1] != want[1] {
+		t.Errorf("Logos = %v, want %v", sheet.Logos, want)
+	}
+	if got := strings.Join(sheet.Missing, ","); got != "MSFT,BAD,TSLA" {
+		t.Errorf("Missing = %s, want MSFT,BAD,TSLA", got)
+	}
+
+	data, err := sheet.PNG()
+	if err != nil {
+		t.Fatalf("PNG: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("decoding sheet: %v", err)
+	}
+	if _, _, _, a := img.At(20, 4).RGBA(); a != 0 {
+		t.Error("missing logo's cell is not transparent")
+	}
+	if r, _, _, a := img.At(4, 20).RGBA(); r != 0xffff || a != 0xffff {
+		t.Error("BRK.B not drawn in its cell")
+	}
+
+	css := sheet.CSS("/sprite.png")
+	for _, rule := range []string{"width:16px", ".logo-AAPL{background-position:-0px -0px}", `.logo-BRK\.B{background-position:-0px -16px}`} {
+		if !strings.Contains(css, rule) {
+			t.Errorf("CSS missing %q:\n%s", rule, css)
+		}
+	}
+}
+
+func TestBuild_TooLarge(t *testing.T) {
+	symbols := make([]string, 300)
+	for i := range symbols {
+		symbols[i] = "AAPL"
+	}
+	_, err := Build(symbols, 256, func(string) ([]byte, error) { return nil, errors.New("unused") })
+	if !errors.Is(err, ErrTooLarge) {
+		t.Errorf("err = %v, want ErrTooLarge", err)
+	}
+}
+
+func TestCSSEscape(t *testing.T) {
+	tests := []struct{ symbol, want string }{
+		{"AAPL", "AAPL"},
+		{"BRK.B", `BRK\.B`},
+		{"^GSPC", `\^GSPC`},
+		{"7203.T", `\37 203\.T`},
+	}
+	for _, tt := range tests {
+		if got := cssEscape(tt.symbol); got != tt.want {
+			t.Errorf("cssEscape(%q) = %q, want %q", tt.symbol, got, tt.want)
+		}
+	}
+}
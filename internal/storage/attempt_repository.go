@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+// attemptsPerSymbol is how many acquisition attempts are kept per symbol.
+// Recording one deletes the symbol's older ones, so a symbol that keeps
+// missing doesn't grow the table forever.
+const attemptsPerSymbol = 100
+
+// AttemptRepository logs which providers were asked for a symbol's logo,
+// with their outcome (see model.Attempt).
+type AttemptRepository interface {
+	Record(ctx context.Context, attempt *model.Attempt) error
+	ListBySymbol(ctx context.Context, symbol string, limit int) ([]model.Attempt, error)
+}
+
+type sqliteAttemptRepository struct {
+	handles
+}
+
+// NewAttemptRepository creates a new SQLite-backed AttemptRepository.
+func NewAttemptRepository(db *sqlx.DB, reader ...*sqlx.DB) AttemptRepository {
+	return &sqliteAttemptRepository{handles: newHandles(db, reader)}
+}
+
+// Record appends an attempt and drops the symbol's attempts beyond the
+// latest attemptsPerSymbol.
+func (r *sqliteAttemptRepository) Record(ctx context.Context, attempt *model.Attempt) error {
+	// In UTC, so time ranges compare as text (see StatsHistoryRepository).
+	attempt.CreatedAt = attempt.CreatedAt.UTC()
+	result, err := r.db.NamedExecContext(ctx, `
+		INSERT INTO acquisition_attempts (trace, symbol, provider, outcome, error, source, duration_ms, created_at)
+		VALUES (:trace, :symbol, :provider, :outcome, :error, :source, :duration_ms, :created_at)
+	`, attempt)
+	if err != nil {
+		return fmt.Errorf("recording attempt for %s: %w", attempt.Symbol, err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("getting last insert id: %w", err)
+	}
+	attempt.ID = id
+
+	_, err = r.db.ExecContext(ctx, `
+		DELETE FROM acquisition_attempts
+		WHERE symbol = ? AND id NOT IN (
+			SELECT id FROM acquisition_attempts WHERE symbol = ? ORDER BY id DESC LIMIT ?
+		)
+	`, attempt.Symbol, attempt.Symbol, attemptsPerSymbol)
+	if err != nil {
+		return fmt.Errorf("pruning attempts for %s: %w", attempt.Symbol, err)
+	}
+	return nil
+}
+
+// ListBySymbol returns up to limit attempts for a symbol, newest first.
+func (r *sqliteAttemptRepository) ListBySymbol(ctx context.Context, symbol string, limit int) ([]model.Attempt, error) {
+	attempts := []model.Attempt{}
+	err := r.read.SelectContext(ctx, &attempts,
+		`SELECT * FROM acquisition_attempts WHERE symbol = ? ORDER BY id DESC LIMIT ?`, symbol, limit)
+	if err != nil {
+		return nil, fmt.Errorf("listing attempts for %s: %w", symbol, err)
+	}
+	return attempts, nil
+}
@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+func TestAttemptRepository_RecordAndList(t *testing.T) {
+	deps := setupTestDB(t)
+	ctx := context.Background()
+	repo := deps.attemptRepo
+	asked := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, a := range []model.Attempt{
+		{Trace: "t1", Symbol: "XYZ", Provider: "github", Outcome: model.AttemptUnavailable, Error: "timeout", DurationMS: 30000},
+		{Trace: "t1", Symbol: "XYZ", Provider: "llm", Outcome: model.AttemptMiss, Error: "no logo found", DurationMS: 4000},
+		{Trace: "t2", Symbol: "AAPL", Provider: "github", Outcome: model.AttemptFound, Source: "github:test", DurationMS: 120},
+	} {
+		a.CreatedAt = asked
+		if err := repo.Record(ctx, &a); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+		if a.ID == 0 {
+			t.Error("expected Record to set the ID")
+		}
+	}
+
+	attempts, err := repo.ListBySymbol(ctx, "XYZ", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(attempts) != 2 || attempts[0].Provider != "llm" || attempts[1].Error != "timeout" {
+		t.Errorf("expected XYZ's attempts newest first, got %+v", attempts)
+	}
+	if !attempts[0].CreatedAt.Equal(asked) {
+		t.Errorf("expected created_at %v, got %v", asked, attempts[0].CreatedAt)
+	}
+	if none, _ := repo.ListBySymbol(ctx, "MSFT", 10); none == nil || len(none) != 0 {
+		t.Errorf("expected an empty list, got %#v", none)
+	}
+}
+
+func TestAttemptRepository_KeepsLatestPerSymbol(t *testing.T) {
+	deps := setupTestDB(t)
+	ctx := context.Background()
+	repo := deps.attemptRepo
+
+	for i := range attemptsPerSymbol + 5 {
+		a := &model.Attempt{Trace: "t", Symbol: "XYZ", Provider: "github", Outcome: model.AttemptMiss, DurationMS: int64(i), CreatedAt: time.Now()}
+		if err := repo.Record(ctx, a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := repo.Record(ctx, &model.Attempt{Trace: "t", Symbol: "AAPL", Provider: "github", Outcome: model.AttemptMiss, CreatedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+
+	attempts, err := repo.ListBySymbol(ctx, "XYZ", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(attempts) != attemptsPerSymbol || attempts[len(attempts)-1].DurationMS != 5 {
+		t.Errorf("expected the latest %d attempts, got %d (oldest %d)", attemptsPerSymbol, len(attempts), attempts[len(attempts)-1].DurationMS)
+	}
+	if other, _ := repo.ListBySymbol(ctx, "AAPL", 10); len(other) != 1 {
+		t.Errorf("expected other symbols' attempts to be kept, got %d", len(other))
+	}
+}
@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+// AuditRepository is an append-only log of admin actions.
+type AuditRepository interface {
+	Record(ctx context.Context, entry *model.AuditEntry) error
+	List(ctx context.Context, symbol string, limit int) ([]model.AuditEntry, error)
+}
+
+type sqliteAuditRepository struct {
+	handles
+}
+
+// NewAuditRepository creates a new SQLite-backed AuditRepository.
+func NewAuditRepository(db *sqlx.DB, reader ...*sqlx.DB) AuditRepository {
+	return &sqliteAuditRepository{handles: newHandles(db, reader)}
+}
+
+// Record appends an entry to the log.
+func (r *sqliteAuditRepository) Record(ctx context.Context, entry *model.AuditEntry) error {
+	result, err := r.db.NamedExecContext(ctx, `
+		INSERT INTO audit_log (action, symbol, details)
+		VALUES (:action, :symbol, :details)
+	`, entry)
+	if err != nil {
+		return fmt.Errorf("recording audit entry: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("getting last insert id: %w", err)
+	}
+	entry.ID = id
+	return nil
+}
+
+// List returns up to limit entries, newest first. An empty symbol lists all of them.
+// Ordering by id rather than created_at keeps entries from the same second in order.
+func (r *sqliteAuditRepository) List(ctx context.Context, symbol string, limit int) ([]model.AuditEntry, error) {
+	var entries []model.AuditEntry
+	var err error
+	if symbol == "" {
+		err = r.read.SelectContext(ctx, &entries,
+			`SELECT * FROM audit_log ORDER BY id DESC LIMIT ?`, limit)
+	} else {
+		err = r.read.SelectContext(ctx, &entries,
+			`SELECT * FROM audit_log WHERE symbol = ? ORDER BY id DESC LIMIT ?`, symbol, limit)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("listing audit log: %w", err)
+	}
+	return entries, nil
+}
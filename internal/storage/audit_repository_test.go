@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+func TestAuditRepository_RecordAndList(t *testing.T) {
+	deps := setupTestDB(t)
+	ctx := context.Background()
+	repo := deps.auditRepo
+
+	for _, e := range []model.AuditEntry{
+		{Action: model.AuditBlock, Symbol: "ENRN", Details: "delisted"},
+		{Action: model.AuditTakedown, Symbol: "AAPL", Details: "trademark complaint"},
+		{Action: model.AuditUnblock, Symbol: "ENRN"},
+	} {
+		if err := repo.Record(ctx, &e); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+		if e.ID == 0 {
+			t.Error("expected Record to set the ID")
+		}
+	}
+
+	all, err := repo.List(ctx, "", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 || all[0].Action != model.AuditUnblock || all[2].Action != model.AuditBlock {
+		t.Errorf("expected newest first, got %+v", all)
+	}
+
+	enrn, err := repo.List(ctx, "ENRN", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(enrn) != 2 {
+		t.Errorf("expected 2 entries for ENRN, got %+v", enrn)
+	}
+
+	if limited, _ := repo.List(ctx, "", 1); len(limited) != 1 {
+		t.Errorf("expected limit to apply, got %d entries", len(limited))
+	}
+}
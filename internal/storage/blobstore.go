@@ -0,0 +1,174 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// BlobStore holds the bytes behind a FileSystem: logo and variant PNGs,
+// addressed by slash-separated keys like "3f/a9/AAPL/m.png". FileSystem
+// decides the keys (layout, symbol encoding, validation); a BlobStore only
+// stores bytes, which keeps new backends (memory, S3, ...) small.
+//
+// Go note: the disk implementation is what production uses, the memory one
+// lets tests and the demo mode run without touching the disk. Both satisfy
+// the interface implicitly — neither mentions BlobStore.
+type BlobStore interface {
+	// Get returns a blob's bytes. The error wraps fs.ErrNotExist if there is none.
+	Get(key string) ([]byte, error)
+	// Put creates or replaces a blob.
+	Put(key string, data []byte) error
+	// Exists reports whether a blob is stored under key.
+	Exists(key string) bool
+	// Delete removes a blob. Deleting one that isn't there is not an error.
+	Delete(key string) error
+	// DeleteDir removes every blob under dir ("AA/AAPL" removes "AA/AAPL/m.png").
+	DeleteDir(dir string) error
+	// List returns the names of the blobs directly under dir ("m.png",
+	// not "AA/AAPL/m.png"), sorted. A dir with no blobs gives an empty list.
+	List(dir string) ([]string, error)
+}
+
+// diskBlobStore stores each blob as a file under root.
+type diskBlobStore struct {
+	root string
+}
+
+// NewDiskBlobStore creates a BlobStore in root, creating the directory if needed.
+func NewDiskBlobStore(root string) (BlobStore, error) {
+	// MkdirAll creates the directory and all parents (like mkdir -p).
+	// 0755 is the Unix permission mode: owner rwx, group rx, others rx.
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, fmt.Errorf("creating logo directory: %w", err)
+	}
+	return &diskBlobStore{root: root}, nil
+}
+
+func (s *diskBlobStore) path(key string) string {
+	return filepath.Join(s.root, filepath.FromSlash(key))
+}
+
+func (s *diskBlobStore) Get(key string) ([]byte, error) {
+	// In Go, file I/O returns []byte (byte slice) — the fundamental type for binary data.
+	return os.ReadFile(s.path(key))
+}
+
+func (s *diskBlobStore) Put(key string, data []byte) error {
+	p := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	// WriteFile creates or truncates the file.
+	// 0644: owner rw, group r, others r — standard for non-executable files.
+	return os.WriteFile(p, data, 0644)
+}
+
+func (s *diskBlobStore) Exists(key string) bool {
+	_, err := os.Stat(s.path(key))
+	return err == nil
+}
+
+func (s *diskBlobStore) Delete(key string) error {
+	if err := os.Remove(s.path(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *diskBlobStore) DeleteDir(dir string) error {
+	return os.RemoveAll(s.path(dir))
+}
+
+func (s *diskBlobStore) List(dir string) ([]string, error) {
+	entries, err := os.ReadDir(s.path(dir))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() {
+			names = append(names, e.Name()) // ReadDir already sorts by name
+		}
+	}
+	return names, nil
+}
+
+// memoryBlobStore keeps blobs in a map. Nothing survives the process.
+type memoryBlobStore struct {
+	mu    sync.RWMutex
+	blobs map[string][]byte
+}
+
+// NewMemoryBlobStore creates an empty in-memory BlobStore, safe for concurrent use.
+func NewMemoryBlobStore() BlobStore {
+	return &memoryBlobStore{blobs: make(map[string][]byte)}
+}
+
+func (s *memoryBlobStore) Get(key string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	data, ok := s.blobs[key]
+	if !ok {
+		// Same error as os.ReadFile, so callers can't tell the stores apart.
+		return nil, &fs.PathError{Op: "open", Path: key, Err: fs.ErrNotExist}
+	}
+	// Copies in both directions: a caller modifying its slice must not
+	// change the stored blob.
+	return append([]byte(nil), data...), nil
+}
+
+func (s *memoryBlobStore) Put(key string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blobs[key] = append([]byte(nil), data...)
+	return nil
+}
+
+func (s *memoryBlobStore) Exists(key string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.blobs[key]
+	return ok
+}
+
+func (s *memoryBlobStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.blobs, key)
+	return nil
+}
+
+func (s *memoryBlobStore) DeleteDir(dir string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prefix := dir + "/"
+	for key := range s.blobs {
+		if strings.HasPrefix(key, prefix) {
+			delete(s.blobs, key)
+		}
+	}
+	return nil
+}
+
+func (s *memoryBlobStore) List(dir string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var names []string
+	for key := range s.blobs {
+		if path.Dir(key) == dir {
+			names = append(names, path.Base(key))
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
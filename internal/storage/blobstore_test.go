@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"errors"
+	"io/fs"
+	"reflect"
+	"testing"
+)
+
+// Both implementations must behave the same; FileSystem relies on it.
+func TestBlobStores(t *testing.T) {
+	stores := []struct {
+		name string
+		new  func(t *testing.T) BlobStore
+	}{
+		{"disk", func(t *testing.T) BlobStore {
+			s, err := NewDiskBlobStore(t.TempDir())
+			if err != nil {
+				t.Fatalf("NewDiskBlobStore: %v", err)
+			}
+			return s
+		}},
+		{"memory", func(t *testing.T) BlobStore { return NewMemoryBlobStore() }},
+	}
+
+	for _, tt := range stores {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.new(t)
+
+			if _, err := s.Get("AA/AAPL/m.png"); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("Get missing: err = %v, want fs.ErrNotExist", err)
+			}
+			if names, err := s.List("AA/AAPL"); err != nil || len(names) != 0 {
+				t.Errorf("List missing dir = %v, %v; want empty", names, err)
+			}
+
+			for _, key := range []string{"AA/AAPL/m.png", "AA/AAPL/xl.png", "AA/AAPL/m_bg_ffffff.png", "AA/AAL/m.png"} {
+				if err := s.Put(key, []byte(key)); err != nil {
+					t.Fatalf("Put %s: %v", key, err)
+				}
+			}
+
+			data, err := s.Get("AA/AAPL/m.png")
+			if err != nil || string(data) != "AA/AAPL/m.png" {
+				t.Errorf("Get = %q, %v", data, err)
+			}
+			if !s.Exists("AA/AAPL/xl.png") || s.Exists("AA/AAPL/s.png") {
+				t.Error("Exists disagrees with what was stored")
+			}
+
+			names, err := s.List("AA/AAPL")
+			if err != nil {
+				t.Fatalf("List: %v", err)
+			}
+			if want := []string{"m.png", "m_bg_ffffff.png", "xl.png"}; !reflect.DeepEqual(names, want) {
+				t.Errorf("List = %v, want %v", names, want)
+			}
+
+			if err := s.Delete("AA/AAPL/m_bg_ffffff.png"); err != nil {
+				t.Errorf("Delete: %v", err)
+			}
+			if err := s.Delete("AA/AAPL/m_bg_ffffff.png"); err != nil {
+				t.Errorf("Delete twice: %v", err)
+			}
+
+			// AAL shares a prefix with AAPL's directory name but not the directory.
+			if err := s.DeleteDir("AA/AAPL"); err != nil {
+				t.Fatalf("DeleteDir: %v", err)
+			}
+			if s.Exists("AA/AAPL/xl.png") {
+				t.Error("DeleteDir left a blob behind")
+			}
+			if !s.Exists("AA/AAL/m.png") {
+				t.Error("DeleteDir removed a sibling directory")
+			}
+		})
+	}
+}
+
+func TestMemoryBlobStore_CopiesData(t *testing.T) {
+	s := NewMemoryBlobStore()
+	data := []byte("png")
+	_ = s.Put("k", data)
+	data[0] = 'x'
+
+	got, _ := s.Get("k")
+	got[1] = 'x'
+	if again, _ := s.Get("k"); string(again) != "png" {
+		t.Errorf("stored blob changed to %q", again)
+	}
+}
@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+// ErrSymbolBlocked is returned for a symbol on the blocklist: it's neither
+// served nor acquired. Bulk imports count it as skipped.
+var ErrSymbolBlocked = errors.New("symbol is blocked")
+
+// BlocklistRepository stores the symbols that must never be served.
+type BlocklistRepository interface {
+	Block(ctx context.Context, symbol, reason string) error
+	Unblock(ctx context.Context, symbol string) error
+	IsBlocked(ctx context.Context, symbol string) (bool, error)
+	List(ctx context.Context) ([]model.BlockedSymbol, error)
+}
+
+type sqliteBlocklistRepository struct {
+	handles
+}
+
+// NewBlocklistRepository creates a new SQLite-backed BlocklistRepository.
+func NewBlocklistRepository(db *sqlx.DB, reader ...*sqlx.DB) BlocklistRepository {
+	return &sqliteBlocklistRepository{handles: newHandles(db, reader)}
+}
+
+// Block adds a symbol to the blocklist. Blocking it again updates the reason
+// but keeps the original date.
+func (r *sqliteBlocklistRepository) Block(ctx context.Context, symbol, reason string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO blocked_symbols (symbol, reason) VALUES (?, ?)
+		ON CONFLICT(symbol) DO UPDATE SET reason = excluded.reason
+	`, symbol, reason)
+	if err != nil {
+		return fmt.Errorf("blocking %s: %w", symbol, err)
+	}
+	return nil
+}
+
+// Unblock removes a symbol from the blocklist. Returns ErrNotFound if it wasn't blocked.
+func (r *sqliteBlocklistRepository) Unblock(ctx context.Context, symbol string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM blocked_symbols WHERE symbol = ?`, symbol)
+	if err != nil {
+		return fmt.Errorf("unblocking %s: %w", symbol, err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking rows affected: %w", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// IsBlocked reports whether a symbol is on the blocklist.
+func (r *sqliteBlocklistRepository) IsBlocked(ctx context.Context, symbol string) (bool, error) {
+	var one int
+	err := r.read.GetContext(ctx, &one, `SELECT 1 FROM blocked_symbols WHERE symbol = ?`, symbol)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("checking blocklist for %s: %w", symbol, err)
+	}
+	return true, nil
+}
+
+// List returns every blocked symbol, most recently blocked first.
+func (r *sqliteBlocklistRepository) List(ctx context.Context) ([]model.BlockedSymbol, error) {
+	var blocked []model.BlockedSymbol
+	err := r.read.SelectContext(ctx, &blocked,
+		`SELECT symbol, reason, created_at FROM blocked_symbols ORDER BY created_at DESC, symbol`)
+	if err != nil {
+		return nil, fmt.Errorf("listing blocked symbols: %w", err)
+	}
+	return blocked, nil
+}
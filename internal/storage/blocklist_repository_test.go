@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestBlocklistRepository(t *testing.T) {
+	deps := setupTestDB(t)
+	ctx := context.Background()
+	repo := deps.blocklistRepo
+
+	if blocked, err := repo.IsBlocked(ctx, "ENRN"); err != nil || blocked {
+		t.Fatalf("IsBlocked before Block = %v, %v; want false", blocked, err)
+	}
+
+	if err := repo.Block(ctx, "ENRN", "delisted"); err != nil {
+		t.Fatal(err)
+	}
+	// Blocking again only updates the reason.
+	if err := repo.Block(ctx, "ENRN", "legal takedown"); err != nil {
+		t.Fatal(err)
+	}
+	if blocked, err := repo.IsBlocked(ctx, "ENRN"); err != nil || !blocked {
+		t.Fatalf("IsBlocked after Block = %v, %v; want true", blocked, err)
+	}
+
+	list, err := repo.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Symbol != "ENRN" || list[0].Reason != "legal takedown" {
+		t.Errorf("unexpected blocklist: %+v", list)
+	}
+
+	if err := repo.Unblock(ctx, "ENRN"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Unblock(ctx, "ENRN"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("second Unblock: expected ErrNotFound, got %v", err)
+	}
+	if blocked, _ := repo.IsBlocked(ctx, "ENRN"); blocked {
+		t.Error("symbol still blocked after Unblock")
+	}
+}
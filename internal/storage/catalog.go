@@ -0,0 +1,330 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+// catalogSyncBatch is how many events Sync reads per query.
+const catalogSyncBatch = 500
+
+// Catalog is an in-memory index of every logo's status and stored sizes,
+// and of the blocklist, so serving a cached logo needs no database query:
+// only the file is read. Metadata lookups and writes still go to SQLite.
+//
+// Writes made through Logos and Blocklist update the index as they happen.
+// Writes made by another process sharing the database only show up if it
+// records an event for them in the changes feed, which Sync follows: the
+// CLI's imports do for every logo they store. One that records none, like
+// an import marking a download failed (nothing served changes), is seen
+// when the symbol is next written here or at the next Load. Until Load,
+// and for symbols it doesn't know, the catalog answers nothing and callers
+// ask the database as usual.
+type Catalog struct {
+	logos     LogoRepository
+	blocklist BlocklistRepository
+
+	// writeMu serializes index refreshes with the writes that cause them,
+	// so a slow refresh can't put back an older state over a newer one.
+	writeMu sync.Mutex
+
+	mu      sync.RWMutex
+	loaded  bool
+	entries map[string]catalogEntry
+	blocked map[string]bool
+	cursor  int64 // Last event Sync applied
+}
+
+// catalogEntry is what serving needs to know about a logo: far smaller than
+// a model.Logo, so a large catalog fits in memory.
+type catalogEntry struct {
+	status      model.LogoStatus
+	sizes       uint8 // Bit i set: model.AllSizes[i] is stored
+	updatedAt   time.Time
+	nextCheckAt *time.Time
+	defaultBG   string
+}
+
+// NewCatalog creates an empty catalog over the repositories; Load fills it.
+func NewCatalog(logos LogoRepository, blocklist BlocklistRepository) *Catalog {
+	return &Catalog{
+		logos:     logos,
+		blocklist: blocklist,
+		entries:   make(map[string]catalogEntry),
+		blocked:   make(map[string]bool),
+	}
+}
+
+// Load reads every logo's state and the blocklist into memory. Events
+// recorded from now on are applied by Sync.
+func (c *Catalog) Load(ctx context.Context, events EventRepository) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	// The cursor first: a change made while loading is applied again by
+	// Sync rather than missed.
+	cursor, err := events.LatestID(ctx)
+	if err != nil {
+		return err
+	}
+	logos, err := c.logos.ListStates(ctx)
+	if err != nil {
+		return err
+	}
+	blocked, err := c.blocklist.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	entries := make(map[string]catalogEntry, len(logos))
+	for i := range logos {
+		entries[logos[i].Symbol] = newCatalogEntry(&logos[i])
+	}
+	blockedSet := make(map[string]bool, len(blocked))
+	for _, b := range blocked {
+		blockedSet[b.Symbol] = true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries, c.blocked, c.cursor, c.loaded = entries, blockedSet, cursor, true
+	return nil
+}
+
+// Len returns how many logos the catalog holds.
+func (c *Catalog) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.entries)
+}
+
+// Lookup returns a logo with only symbol, status, the has_* flags,
+// updated_at, next_check_at and default_bg set, and false if the catalog doesn't know
+// the symbol (or isn't loaded): ask the database then. A nil catalog knows
+// nothing.
+func (c *Catalog) Lookup(symbol string) (*model.Logo, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.RLock()
+	entry, ok := c.entries[symbol]
+	c.mu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+
+	logo := &model.Logo{
+		Symbol:      symbol,
+		Status:      entry.status,
+		UpdatedAt:   entry.updatedAt,
+		NextCheckAt: entry.nextCheckAt,
+
+		DefaultBackground: entry.defaultBG,
+	}
+	// Go note: pointers to the fields let one loop set them all.
+	for i, has := range []*bool{&logo.HasXS, &logo.HasS, &logo.HasM, &logo.HasL, &logo.HasXL} {
+		*has = entry.sizes&(1<<i) != 0
+	}
+	return logo, true
+}
+
+// Blocked reports whether a symbol is blocked, and false for known if the
+// catalog isn't loaded (or is nil).
+func (c *Catalog) Blocked(symbol string) (blocked, known bool) {
+	if c == nil {
+		return false, false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.blocked[symbol], c.loaded
+}
+
+// Sync applies the events recorded since the last Load or Sync, reading
+// each changed symbol back from the database.
+func (c *Catalog) Sync(ctx context.Context, events EventRepository) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	c.mu.RLock()
+	cursor, loaded := c.cursor, c.loaded
+	c.mu.RUnlock()
+	if !loaded {
+		return nil
+	}
+
+	for {
+		batch, err := events.ListSince(ctx, cursor, catalogSyncBatch)
+		if err != nil {
+			return err
+		}
+		refreshed := make(map[string]bool)
+		for _, event := range batch {
+			if !refreshed[event.Symbol] {
+				if err := c.refresh(ctx, event.Symbol); err != nil {
+					return err
+				}
+				refreshed[event.Symbol] = true
+			}
+			cursor = event.ID
+		}
+		c.mu.Lock()
+		c.cursor = cursor
+		c.mu.Unlock()
+		if len(batch) < catalogSyncBatch {
+			return nil
+		}
+	}
+}
+
+// refresh reads a symbol's logo and block back from the database. The
+// caller holds writeMu. If that fails, the symbol is dropped, so lookups
+// go to the database instead of trusting a stale entry.
+func (c *Catalog) refresh(ctx context.Context, symbol string) error {
+	c.mu.RLock()
+	loaded := c.loaded
+	c.mu.RUnlock()
+	if !loaded {
+		return nil
+	}
+
+	logo, err := c.logos.GetBySymbol(ctx, symbol)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		c.forget(symbol)
+		return fmt.Errorf("refreshing catalog entry for %s: %w", symbol, err)
+	}
+	blocked, blockErr := c.blocklist.IsBlocked(ctx, symbol)
+	if blockErr != nil {
+		c.forget(symbol)
+		return fmt.Errorf("refreshing catalog entry for %s: %w", symbol, blockErr)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if logo != nil {
+		c.entries[symbol] = newCatalogEntry(logo)
+	} else {
+		delete(c.entries, symbol)
+	}
+	if blocked {
+		c.blocked[symbol] = true
+	} else {
+		delete(c.blocked, symbol)
+	}
+	return nil
+}
+
+// forget drops a symbol's entry. Its block status is kept: the catalog
+// would rather refuse a symbol a moment longer than serve a blocked one.
+func (c *Catalog) forget(symbol string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, symbol)
+}
+
+// write runs a write for symbol, then refreshes its entry. A failed
+// refresh only drops the entry: the write itself succeeded.
+func (c *Catalog) write(ctx context.Context, symbol string, fn func() error) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if err := fn(); err != nil {
+		return err
+	}
+	_ = c.refresh(ctx, symbol)
+	return nil
+}
+
+// newCatalogEntry packs the serving state of a logo.
+func newCatalogEntry(logo *model.Logo) catalogEntry {
+	entry := catalogEntry{status: logo.Status, updatedAt: logo.UpdatedAt, nextCheckAt: logo.NextCheckAt, defaultBG: logo.DefaultBackground}
+	for i, size := range model.AllSizes {
+		if logo.HasSize(size) {
+			entry.sizes |= 1 << i
+		}
+	}
+	return entry
+}
+
+// Logos returns the catalog's LogoRepository: the underlying one, with the
+// writes that change what's served keeping the catalog up to date.
+func (c *Catalog) Logos() LogoRepository {
+	return &catalogLogos{LogoRepository: c.logos, catalog: c}
+}
+
+// Blocklist returns the catalog's BlocklistRepository, which keeps the
+// catalog's blocked symbols up to date.
+func (c *Catalog) Blocklist() BlocklistRepository {
+	return &catalogBlocklist{BlocklistRepository: c.blocklist, catalog: c}
+}
+
+// catalogLogos wraps the writes of a LogoRepository that change a logo's
+// status, sizes or timestamps, or whether it exists. Everything else goes
+// straight through.
+//
+// Go note: embedding the interface provides every method; the type only
+// declares the ones it changes.
+type catalogLogos struct {
+	LogoRepository
+	catalog *Catalog
+}
+
+func (r *catalogLogos) Create(ctx context.Context, logo *model.Logo) error {
+	return r.catalog.write(ctx, logo.Symbol, func() error { return r.LogoRepository.Create(ctx, logo) })
+}
+
+func (r *catalogLogos) Update(ctx context.Context, logo *model.Logo) error {
+	return r.catalog.write(ctx, logo.Symbol, func() error { return r.LogoRepository.Update(ctx, logo) })
+}
+
+func (r *catalogLogos) SetSizeAvailable(ctx context.Context, symbol string, size model.LogoSize) error {
+	return r.catalog.write(ctx, symbol, func() error { return r.LogoRepository.SetSizeAvailable(ctx, symbol, size) })
+}
+
+func (r *catalogLogos) SetSizeUnavailable(ctx context.Context, symbol string, size model.LogoSize) error {
+	return r.catalog.write(ctx, symbol, func() error { return r.LogoRepository.SetSizeUnavailable(ctx, symbol, size) })
+}
+
+func (r *catalogLogos) SetStatus(ctx context.Context, symbol string, status model.LogoStatus, errMsg string) error {
+	return r.catalog.write(ctx, symbol, func() error { return r.LogoRepository.SetStatus(ctx, symbol, status, errMsg) })
+}
+
+func (r *catalogLogos) MarkNotFound(ctx context.Context, symbol string, nextCheck time.Time) error {
+	return r.catalog.write(ctx, symbol, func() error { return r.LogoRepository.MarkNotFound(ctx, symbol, nextCheck) })
+}
+
+func (r *catalogLogos) MarkFailed(ctx context.Context, symbol, errMsg string, nextCheck time.Time) error {
+	return r.catalog.write(ctx, symbol, func() error { return r.LogoRepository.MarkFailed(ctx, symbol, errMsg, nextCheck) })
+}
+
+func (r *catalogLogos) SoftDelete(ctx context.Context, symbol string, at time.Time) error {
+	return r.catalog.write(ctx, symbol, func() error { return r.LogoRepository.SoftDelete(ctx, symbol, at) })
+}
+
+func (r *catalogLogos) Restore(ctx context.Context, symbol string, deletedAfter time.Time) error {
+	return r.catalog.write(ctx, symbol, func() error { return r.LogoRepository.Restore(ctx, symbol, deletedAfter) })
+}
+
+func (r *catalogLogos) Purge(ctx context.Context, symbol string) error {
+	return r.catalog.write(ctx, symbol, func() error { return r.LogoRepository.Purge(ctx, symbol) })
+}
+
+// catalogBlocklist wraps the writes of a BlocklistRepository.
+type catalogBlocklist struct {
+	BlocklistRepository
+	catalog *Catalog
+}
+
+func (r *catalogBlocklist) Block(ctx context.Context, symbol, reason string) error {
+	return r.catalog.write(ctx, symbol, func() error { return r.BlocklistRepository.Block(ctx, symbol, reason) })
+}
+
+func (r *catalogBlocklist) Unblock(ctx context.Context, symbol string) error {
+	return r.catalog.write(ctx, symbol, func() error { return r.BlocklistRepository.Unblock(ctx, symbol) })
+}
@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+func TestCatalog_LoadAndLookup(t *testing.T) {
+	deps := setupTestDB(t)
+	ctx := context.Background()
+	if err := deps.logoRepo.Create(ctx, &model.Logo{Symbol: "AAPL", Status: model.StatusProcessed}); err != nil {
+		t.Fatal(err)
+	}
+	deps.logoRepo.SetSizeAvailable(ctx, "AAPL", model.SizeS)
+	deps.logoRepo.SetSizeAvailable(ctx, "AAPL", model.SizeXL)
+	deps.blocklistRepo.Block(ctx, "EVIL", "test")
+
+	catalog := NewCatalog(deps.logoRepo, deps.blocklistRepo)
+	if _, ok := catalog.Lookup("AAPL"); ok {
+		t.Error("expected an unloaded catalog to know nothing")
+	}
+	if _, known := catalog.Blocked("EVIL"); known {
+		t.Error("expected an unloaded catalog not to know the blocklist")
+	}
+	if err := catalog.Load(ctx, deps.eventRepo); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	logo, ok := catalog.Lookup("AAPL")
+	if !ok {
+		t.Fatal("expected AAPL in the catalog")
+	}
+	if logo.Status != model.StatusProcessed || !logo.HasS || !logo.HasXL || logo.HasM {
+		t.Errorf("unexpected entry: %+v", logo)
+	}
+	if _, ok := catalog.Lookup("MSFT"); ok {
+		t.Error("expected MSFT to be unknown")
+	}
+	if blocked, known := catalog.Blocked("EVIL"); !blocked || !known {
+		t.Errorf("expected EVIL blocked, got blocked=%v known=%v", blocked, known)
+	}
+	if catalog.Len() != 1 {
+		t.Errorf("expected 1 logo, got %d", catalog.Len())
+	}
+
+	var nilCatalog *Catalog
+	if _, ok := nilCatalog.Lookup("AAPL"); ok {
+		t.Error("expected a nil catalog to know nothing")
+	}
+}
+
+func TestCatalog_WritesKeepItCurrent(t *testing.T) {
+	deps := setupTestDB(t)
+	ctx := context.Background()
+	catalog := NewCatalog(deps.logoRepo, deps.blocklistRepo)
+	if err := catalog.Load(ctx, deps.eventRepo); err != nil {
+		t.Fatal(err)
+	}
+	logos, blocklist := catalog.Logos(), catalog.Blocklist()
+
+	if err := logos.Create(ctx, &model.Logo{Symbol: "AAPL", Status: model.StatusPending}); err != nil {
+		t.Fatal(err)
+	}
+	if logo, ok := catalog.Lookup("AAPL"); !ok || logo.Status != model.StatusPending {
+		t.Fatalf("expected a pending AAPL after Create, got %+v", logo)
+	}
+
+	logos.SetSizeAvailable(ctx, "AAPL", model.SizeM)
+	logos.SetStatus(ctx, "AAPL", model.StatusProcessed, "")
+	if logo, _ := catalog.Lookup("AAPL"); logo.Status != model.StatusProcessed || !logo.HasM {
+		t.Errorf("expected a processed AAPL with size m, got %+v", logo)
+	}
+
+	stored, _ := logos.GetBySymbol(ctx, "AAPL")
+	stored.DefaultBackground = "1a1a1a"
+	if err := logos.Update(ctx, stored); err != nil {
+		t.Fatal(err)
+	}
+	if logo, _ := catalog.Lookup("AAPL"); logo.DefaultBackground != "1a1a1a" {
+		t.Errorf("expected default_bg 1a1a1a after Update, got %q", logo.DefaultBackground)
+	}
+
+	next := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	logos.Create(ctx, &model.Logo{Symbol: "XYZ", Status: model.StatusPending})
+	logos.MarkNotFound(ctx, "XYZ", next)
+	if logo, _ := catalog.Lookup("XYZ"); logo.Status != model.StatusNotFound || logo.NextCheckAt == nil || !logo.NextCheckAt.Equal(next) {
+		t.Errorf("expected XYZ not_found until %v, got %+v", next, logo)
+	}
+
+	blocklist.Block(ctx, "AAPL", "test")
+	if blocked, _ := catalog.Blocked("AAPL"); !blocked {
+		t.Error("expected AAPL blocked after Block")
+	}
+	blocklist.Unblock(ctx, "AAPL")
+	if blocked, _ := catalog.Blocked("AAPL"); blocked {
+		t.Error("expected AAPL unblocked after Unblock")
+	}
+}
+
+func TestCatalog_SyncAppliesOtherWriters(t *testing.T) {
+	deps := setupTestDB(t)
+	ctx := context.Background()
+	catalog := NewCatalog(deps.logoRepo, deps.blocklistRepo)
+	if err := catalog.Load(ctx, deps.eventRepo); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another process writes straight to the database and records an event.
+	deps.logoRepo.Create(ctx, &model.Logo{Symbol: "AAPL", Status: model.StatusProcessed})
+	if _, ok := catalog.Lookup("AAPL"); ok {
+		t.Fatal("expected the catalog not to see another writer before Sync")
+	}
+	deps.eventRepo.Record(ctx, &model.Event{Type: model.EventCreated, Symbol: "AAPL"})
+
+	if err := catalog.Sync(ctx, deps.eventRepo); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if logo, ok := catalog.Lookup("AAPL"); !ok || logo.Status != model.StatusProcessed {
+		t.Errorf("expected AAPL after Sync, got %+v", logo)
+	}
+
+	// Events already applied aren't read again.
+	deps.logoRepo.SetStatus(ctx, "AAPL", model.StatusReview, "")
+	if err := catalog.Sync(ctx, deps.eventRepo); err != nil {
+		t.Fatal(err)
+	}
+	if logo, _ := catalog.Lookup("AAPL"); logo.Status != model.StatusProcessed {
+		t.Errorf("expected no change without an event, got %s", logo.Status)
+	}
+}
@@ -0,0 +1,184 @@
+package storage
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/fleveque/logo-service/internal/flight"
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+// CoalesceReads returns repo with the reads of one symbol coalesced (see
+// package flight): concurrent GetBySymbol or ListRenditions calls for a
+// symbol share one query, and its result is reused for window. Writes
+// through the returned repository forget the symbol, so this process
+// reads its own writes; others' writes show within window. A window of 0
+// returns repo itself.
+func CoalesceReads(repo LogoRepository, window time.Duration) LogoRepository {
+	if window <= 0 {
+		return repo
+	}
+	return &coalescingLogoRepository{
+		repo:       repo,
+		logos:      flight.NewGroup[*model.Logo](window),
+		renditions: flight.NewGroup[[]model.Rendition](window),
+	}
+}
+
+// coalescingLogoRepository shares the reads by symbol; everything else
+// passes through, the writes forgetting what they change. It forwards each
+// method itself rather than embedding repo, so a method added to
+// LogoRepository doesn't compile until it's sorted into a read or a write.
+type coalescingLogoRepository struct {
+	repo       LogoRepository
+	logos      *flight.Group[*model.Logo]
+	renditions *flight.Group[[]model.Rendition]
+}
+
+func (r *coalescingLogoRepository) GetBySymbol(ctx context.Context, symbol string) (*model.Logo, error) {
+	logo, err := r.logos.Do(ctx, symbol, func(ctx context.Context) (*model.Logo, error) {
+		return r.repo.GetBySymbol(ctx, symbol)
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Callers fill in fields (Renditions), so each gets its own copy.
+	logo2 := *logo
+	return &logo2, nil
+}
+
+func (r *coalescingLogoRepository) ListRenditions(ctx context.Context, symbol string) ([]model.Rendition, error) {
+	renditions, err := r.renditions.Do(ctx, symbol, func(ctx context.Context) ([]model.Rendition, error) {
+		return r.repo.ListRenditions(ctx, symbol)
+	})
+	return slices.Clone(renditions), err
+}
+
+// The other reads aren't by symbol, or are rare: they pass through.
+
+func (r *coalescingLogoRepository) GetValidators(ctx context.Context, symbol, url string) (etag, lastModified string, err error) {
+	return r.repo.GetValidators(ctx, symbol, url)
+}
+
+func (r *coalescingLogoRepository) Count(ctx context.Context) (int64, error) {
+	return r.repo.Count(ctx)
+}
+
+func (r *coalescingLogoRepository) CountByStatus(ctx context.Context, status model.LogoStatus) (int64, error) {
+	return r.repo.CountByStatus(ctx, status)
+}
+
+func (r *coalescingLogoRepository) List(ctx context.Context, status model.LogoStatus, opts ListOptions) ([]model.Logo, string, error) {
+	return r.repo.List(ctx, status, opts)
+}
+
+func (r *coalescingLogoRepository) ListPending(ctx context.Context, opts ListOptions) ([]model.Logo, string, error) {
+	return r.repo.ListPending(ctx, opts)
+}
+
+func (r *coalescingLogoRepository) ListByStatus(ctx context.Context, status model.LogoStatus, limit int) ([]model.Logo, error) {
+	return r.repo.ListByStatus(ctx, status, limit)
+}
+
+func (r *coalescingLogoRepository) ListByQuality(ctx context.Context, maxScore, limit int) ([]model.Logo, error) {
+	return r.repo.ListByQuality(ctx, maxScore, limit)
+}
+
+func (r *coalescingLogoRepository) ListHashed(ctx context.Context) ([]model.Logo, error) {
+	return r.repo.ListHashed(ctx)
+}
+
+func (r *coalescingLogoRepository) ListUnnamed(ctx context.Context) ([]model.Logo, error) {
+	return r.repo.ListUnnamed(ctx)
+}
+
+func (r *coalescingLogoRepository) ListStates(ctx context.Context) ([]model.Logo, error) {
+	return r.repo.ListStates(ctx)
+}
+
+func (r *coalescingLogoRepository) ListDeleted(ctx context.Context, deletedBefore time.Time) ([]model.Logo, error) {
+	return r.repo.ListDeleted(ctx, deletedBefore)
+}
+
+// forget drops a symbol's shared reads after a write to it, successful or
+// not: a failed write may still have changed something.
+func (r *coalescingLogoRepository) forget(symbol string) {
+	r.logos.Forget(symbol)
+	r.renditions.Forget(symbol)
+}
+
+func (r *coalescingLogoRepository) Create(ctx context.Context, logo *model.Logo) error {
+	defer r.forget(logo.Symbol)
+	return r.repo.Create(ctx, logo)
+}
+
+func (r *coalescingLogoRepository) Update(ctx context.Context, logo *model.Logo) error {
+	defer r.forget(logo.Symbol)
+	return r.repo.Update(ctx, logo)
+}
+
+func (r *coalescingLogoRepository) SetSizeAvailable(ctx context.Context, symbol string, size model.LogoSize) error {
+	defer r.forget(symbol)
+	return r.repo.SetSizeAvailable(ctx, symbol, size)
+}
+
+func (r *coalescingLogoRepository) SetSizeUnavailable(ctx context.Context, symbol string, size model.LogoSize) error {
+	defer r.forget(symbol)
+	return r.repo.SetSizeUnavailable(ctx, symbol, size)
+}
+
+func (r *coalescingLogoRepository) SetRendition(ctx context.Context, symbol string, rendition model.Rendition) error {
+	defer r.forget(symbol)
+	return r.repo.SetRendition(ctx, symbol, rendition)
+}
+
+func (r *coalescingLogoRepository) SetStatus(ctx context.Context, symbol string, status model.LogoStatus, errMsg string) error {
+	defer r.forget(symbol)
+	return r.repo.SetStatus(ctx, symbol, status, errMsg)
+}
+
+func (r *coalescingLogoRepository) SetQuality(ctx context.Context, symbol string, score int, notes string) error {
+	defer r.forget(symbol)
+	return r.repo.SetQuality(ctx, symbol, score, notes)
+}
+
+func (r *coalescingLogoRepository) SetPHash(ctx context.Context, symbol, phash string) error {
+	defer r.forget(symbol)
+	return r.repo.SetPHash(ctx, symbol, phash)
+}
+
+func (r *coalescingLogoRepository) SetOriginalHash(ctx context.Context, symbol, hash string) error {
+	defer r.forget(symbol)
+	return r.repo.SetOriginalHash(ctx, symbol, hash)
+}
+
+func (r *coalescingLogoRepository) SetValidators(ctx context.Context, symbol, etag, lastModified string) error {
+	defer r.forget(symbol)
+	return r.repo.SetValidators(ctx, symbol, etag, lastModified)
+}
+
+func (r *coalescingLogoRepository) MarkNotFound(ctx context.Context, symbol string, nextCheck time.Time) error {
+	defer r.forget(symbol)
+	return r.repo.MarkNotFound(ctx, symbol, nextCheck)
+}
+
+func (r *coalescingLogoRepository) MarkFailed(ctx context.Context, symbol, errMsg string, nextCheck time.Time) error {
+	defer r.forget(symbol)
+	return r.repo.MarkFailed(ctx, symbol, errMsg, nextCheck)
+}
+
+func (r *coalescingLogoRepository) SoftDelete(ctx context.Context, symbol string, at time.Time) error {
+	defer r.forget(symbol)
+	return r.repo.SoftDelete(ctx, symbol, at)
+}
+
+func (r *coalescingLogoRepository) Restore(ctx context.Context, symbol string, deletedAfter time.Time) error {
+	defer r.forget(symbol)
+	return r.repo.Restore(ctx, symbol, deletedAfter)
+}
+
+func (r *coalescingLogoRepository) Purge(ctx context.Context, symbol string) error {
+	defer r.forget(symbol)
+	return r.repo.Purge(ctx, symbol)
+}
@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+func TestCoalesceReads(t *testing.T) {
+	deps := setupTestDB(t)
+	ctx := context.Background()
+
+	if repo := CoalesceReads(deps.logoRepo, 0); repo != deps.logoRepo {
+		t.Error("expected the repository itself without a window")
+	}
+
+	repo := CoalesceReads(deps.logoRepo, time.Hour)
+	if err := repo.Create(ctx, &model.Logo{Symbol: "AAPL", Status: model.StatusPending}); err != nil {
+		t.Fatal(err)
+	}
+	first, err := repo.GetBySymbol(ctx, "AAPL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	first.CompanyName = "changed by the caller"
+
+	// A write behind the decorator's back isn't seen within the window...
+	if err := deps.logoRepo.SetStatus(ctx, "AAPL", model.StatusFailed, "boom"); err != nil {
+		t.Fatal(err)
+	}
+	logo, err := repo.GetBySymbol(ctx, "AAPL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if logo.Status != model.StatusPending || logo.CompanyName != "" {
+		t.Errorf("expected the shared, unmodified pending logo, got %q (%q)", logo.Status, logo.CompanyName)
+	}
+
+	// ...but one through it is, at once.
+	if err := repo.SetStatus(ctx, "AAPL", model.StatusProcessed, ""); err != nil {
+		t.Fatal(err)
+	}
+	if logo, err = repo.GetBySymbol(ctx, "AAPL"); err != nil || logo.Status != model.StatusProcessed {
+		t.Errorf("expected processed after a write, got %v, %v", logo, err)
+	}
+
+	// Misses aren't kept: the logo shows as soon as it's created.
+	if _, err := repo.GetBySymbol(ctx, "MSFT"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if err := deps.logoRepo.Create(ctx, &model.Logo{Symbol: "MSFT", Status: model.StatusPending}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.GetBySymbol(ctx, "MSFT"); err != nil {
+		t.Errorf("expected MSFT after it was created, got %v", err)
+	}
+}
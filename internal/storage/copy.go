@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+)
+
+// SetCutover makes fs hand every logo, variant and original operation to
+// next once cutover reports true, e.g. when the storage_cutover flag flips
+// after logo-cli migrate-storage copied everything over. Until then, fs
+// serves from its own store. cutover is asked on every operation, so it
+// must be cheap. Call it before fs is shared.
+func (fs *FileSystem) SetCutover(next *FileSystem, cutover func() bool) {
+	fs.next, fs.cutover = next, cutover
+}
+
+// active is the FileSystem operations go to: next once cut over, fs before.
+func (fs *FileSystem) active() *FileSystem {
+	if fs.next != nil && fs.cutover() {
+		return fs.next
+	}
+	return fs
+}
+
+// CopyStats counts what a copy did. Skipped blobs were already in the
+// destination with the same bytes, from an earlier (interrupted) run.
+type CopyStats struct {
+	Copied  int `json:"copied"`
+	Skipped int `json:"skipped"`
+}
+
+func (s *CopyStats) add(copied bool) {
+	if copied {
+		s.Copied++
+	} else {
+		s.Skipped++
+	}
+}
+
+// CopySymbol copies a symbol's files, canonical sizes and cached variants,
+// from fs to dst, which may use another layout. Each copy is read back and
+// compared before it counts. Both sides are used as they are, whatever
+// SetCutover says.
+func (fs *FileSystem) CopySymbol(dst *FileSystem, symbol string) (CopyStats, error) {
+	var stats CopyStats
+	if err := checkSymbol(symbol); err != nil {
+		return stats, err
+	}
+	from, to := fs.symbolKey(symbol), dst.symbolKey(symbol)
+	names, err := fs.blobs.List(from)
+	if err != nil {
+		return stats, fmt.Errorf("listing %s: %w", symbol, err)
+	}
+	for _, name := range names {
+		copied, err := copyBlob(fs.blobs, path.Join(from, name), dst.blobs, path.Join(to, name))
+		if err != nil {
+			return stats, fmt.Errorf("copying %s/%s: %w", symbol, name, err)
+		}
+		stats.add(copied)
+	}
+	return stats, nil
+}
+
+// CopyOriginal copies the source image stored under hash from fs to dst,
+// verified like CopySymbol. It reports whether it had to copy; an original
+// fs doesn't have is an error wrapping os.ErrNotExist.
+func (fs *FileSystem) CopyOriginal(dst *FileSystem, hash string) (bool, error) {
+	key, err := originalKey(hash)
+	if err != nil {
+		return false, err
+	}
+	copied, err := copyBlob(fs.blobs, key, dst.blobs, key)
+	if err != nil {
+		return false, fmt.Errorf("copying original %s: %w", hash, err)
+	}
+	return copied, nil
+}
+
+// copyBlob copies one blob unless dst already holds the same bytes, then
+// reads the copy back to make sure it's intact.
+func copyBlob(src BlobStore, srcKey string, dst BlobStore, dstKey string) (bool, error) {
+	data, err := src.Get(srcKey)
+	if err != nil {
+		return false, err
+	}
+	existing, err := dst.Get(dstKey)
+	if err == nil && bytes.Equal(existing, data) {
+		return false, nil
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return false, err
+	}
+
+	if err := dst.Put(dstKey, data); err != nil {
+		return false, err
+	}
+	written, err := dst.Get(dstKey)
+	if err != nil {
+		return false, fmt.Errorf("reading the copy back: %w", err)
+	}
+	if !bytes.Equal(written, data) {
+		return false, fmt.Errorf("copy differs from the source (%d bytes, want %d)", len(written), len(data))
+	}
+	return true, nil
+}
@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// DefaultCopyBatch is how many rows CopyDatabase inserts per statement
+// unless told otherwise.
+const DefaultCopyBatch = 500
+
+// CopyOptions tunes CopyDatabase.
+type CopyOptions struct {
+	BatchSize int  // Rows per INSERT; DefaultCopyBatch if zero
+	DryRun    bool // Count what would be copied without writing anything
+}
+
+// TableCopy is what CopyDatabase did with one table.
+type TableCopy struct {
+	Table   string `json:"table"`
+	Rows    int64  `json:"rows"`              // In the source
+	Target  int64  `json:"target"`            // In the target afterwards (before, on a dry run)
+	Missing bool   `json:"missing,omitempty"` // Dry run: the target has no such table yet
+}
+
+// CopyDatabase copies every table of a database NewDatabase opened into
+// dst, another database of any driver sqlx knows whose tables already
+// exist, with the same names and columns, and are empty (for Postgres,
+// see PostgresSchema). Tables and
+// columns are read from the source's schema, so tables added later are
+// copied without changes here. Rows go over in batches, one INSERT per
+// batch, and each table's row count is compared on both sides at the
+// end: nothing should write to the source meanwhile.
+//
+// Values go over as SQLite has them, except into a target of another
+// driver, which gets booleans and times as such (see postgresValue).
+// Rows keep their ids. A target that numbers them with a sequence must
+// have it moved past the copied ids afterwards (ResetPostgresSequences).
+func CopyDatabase(ctx context.Context, src, dst *sqlx.DB, opts CopyOptions) ([]TableCopy, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultCopyBatch
+	}
+	tables, err := sqliteTables(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+
+	copies := make([]TableCopy, 0, len(tables))
+	for _, table := range tables {
+		tc := TableCopy{Table: table}
+		if err := src.GetContext(ctx, &tc.Rows, "SELECT COUNT(*) FROM "+table); err != nil {
+			return copies, fmt.Errorf("counting %s: %w", table, err)
+		}
+		if err := dst.GetContext(ctx, &tc.Target, "SELECT COUNT(*) FROM "+table); err != nil {
+			if !opts.DryRun {
+				return copies, fmt.Errorf("counting %s in the target: %w", table, err)
+			}
+			// Likely not created yet, which the copy itself would do first.
+			tc.Missing = true
+		}
+		if opts.DryRun {
+			copies = append(copies, tc)
+			continue
+		}
+		if tc.Target != 0 {
+			return copies, fmt.Errorf("table %s already has %d rows in the target", table, tc.Target)
+		}
+
+		if err := copyTable(ctx, src, dst, table, opts.BatchSize); err != nil {
+			return copies, err
+		}
+		if err := dst.GetContext(ctx, &tc.Target, "SELECT COUNT(*) FROM "+table); err != nil {
+			return copies, fmt.Errorf("counting %s in the target: %w", table, err)
+		}
+		copies = append(copies, tc)
+		if tc.Target != tc.Rows {
+			return copies, fmt.Errorf("table %s: copied %d rows, the source has %d", table, tc.Target, tc.Rows)
+		}
+	}
+	return copies, nil
+}
+
+// copyTable copies one table's rows in rowid order, batchSize at a time.
+func copyTable(ctx context.Context, src, dst *sqlx.DB, table string, batchSize int) error {
+	cols, err := sqliteColumns(ctx, src, table)
+	if err != nil {
+		return err
+	}
+	columns := make([]string, len(cols))
+	for i, col := range cols {
+		columns[i] = col.Name
+	}
+	convert := dst.DriverName() != "sqlite3"
+	list := strings.Join(columns, ", ")
+	row := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ") + ")"
+
+	var after int64
+	for {
+		rows, err := src.QueryxContext(ctx,
+			fmt.Sprintf("SELECT rowid, %s FROM %s WHERE rowid > ? ORDER BY rowid LIMIT ?", list, table),
+			after, batchSize)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", table, err)
+		}
+		var args []any
+		n := 0
+		for rows.Next() {
+			values, err := rows.SliceScan()
+			if err != nil {
+				rows.Close()
+				return fmt.Errorf("reading %s: %w", table, err)
+			}
+			after = values[0].(int64)
+			for i, v := range values[1:] {
+				if convert {
+					if v, err = postgresValue(cols[i].Type, v); err != nil {
+						rows.Close()
+						return fmt.Errorf("reading %s.%s: %w", table, cols[i].Name, err)
+					}
+				}
+				args = append(args, v)
+			}
+			n++
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", table, err)
+		}
+		if n == 0 {
+			return nil
+		}
+
+		query := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s", table, list,
+			strings.TrimSuffix(strings.Repeat(row+", ", n), ", "))
+		if _, err := dst.ExecContext(ctx, dst.Rebind(query), args...); err != nil {
+			return fmt.Errorf("writing %s: %w", table, err)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+func TestCopyDatabase(t *testing.T) {
+	ctx := context.Background()
+	open := func(name string) *sqlx.DB {
+		db, err := NewDatabase(filepath.Join(t.TempDir(), name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		return db
+	}
+	src, dst := open("src.db"), open("dst.db")
+
+	logos := NewLogoRepository(src)
+	for _, symbol := range []string{"AAPL", "MSFT", "GOOG", "AMZN", "NVDA"} {
+		if err := logos.Create(ctx, &model.Logo{Symbol: symbol, Source: "test", Status: model.StatusProcessed}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := logos.SoftDelete(ctx, "NVDA", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewLLMCallRepository(src).Create(ctx, &model.LLMCall{Symbol: "AAPL", Provider: "test", Model: "m", KeyID: "k1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A dry run only counts.
+	copies, err := CopyDatabase(ctx, src, dst, CopyOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if got := rowsOf(copies, "logos"); got.Rows != 5 || got.Target != 0 {
+		t.Errorf("dry run: expected 5 logos to copy and none in the target, got %+v", got)
+	}
+
+	// Batches smaller than the table still copy all of it.
+	copies, err = CopyDatabase(ctx, src, dst, CopyOptions{BatchSize: 2})
+	if err != nil {
+		t.Fatalf("CopyDatabase: %v", err)
+	}
+	if got := rowsOf(copies, "logos"); got.Target != 5 {
+		t.Errorf("expected 5 logos in the target, got %+v", got)
+	}
+	if got := rowsOf(copies, "llm_calls"); got.Target != 1 {
+		t.Errorf("expected the LLM call in the target, got %+v", got)
+	}
+
+	copied := NewLogoRepository(dst)
+	if logo, err := copied.GetBySymbol(ctx, "MSFT"); err != nil || logo.Status != model.StatusProcessed {
+		t.Errorf("expected MSFT in the target, got %+v (%v)", logo, err)
+	}
+	if deleted, err := copied.ListDeleted(ctx, time.Now().Add(time.Minute)); err != nil || len(deleted) != 1 {
+		t.Errorf("expected NVDA to stay deleted, got %v (%v)", deleted, err)
+	}
+
+	// A target with rows already is refused rather than merged.
+	if _, err := CopyDatabase(ctx, src, dst, CopyOptions{}); err == nil {
+		t.Error("expected copying into a non-empty target to fail")
+	}
+}
+
+func rowsOf(copies []TableCopy, table string) TableCopy {
+	for _, tc := range copies {
+		if tc.Table == table {
+			return tc
+		}
+	}
+	return TableCopy{}
+}
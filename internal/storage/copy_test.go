@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+func TestFileSystem_CopySymbol(t *testing.T) {
+	src, _ := NewMemoryFileSystem(LayoutFlat)
+	dst, _ := NewMemoryFileSystem(LayoutHash)
+	if err := src.Write("BRK.B", model.SizeM, []byte("m")); err != nil {
+		t.Fatal(err)
+	}
+	if err := src.WriteVariant("BRK.B", model.SizeM, "bg_ffffff", []byte("variant")); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := src.WriteOriginal([]byte("original"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := src.CopySymbol(dst, "BRK.B")
+	if err != nil {
+		t.Fatalf("CopySymbol: %v", err)
+	}
+	if stats != (CopyStats{Copied: 2}) {
+		t.Errorf("expected 2 files copied, got %+v", stats)
+	}
+	if data, err := dst.ReadVariant("BRK.B", model.SizeM, "bg_ffffff"); err != nil || string(data) != "variant" {
+		t.Errorf("expected the variant in the new layout, got %q (%v)", data, err)
+	}
+	if copied, err := src.CopyOriginal(dst, hash); err != nil || !copied {
+		t.Errorf("CopyOriginal: copied %v (%v)", copied, err)
+	}
+
+	// A second run resumes: only what changed is copied again.
+	if err := src.Write("BRK.B", model.SizeM, []byte("m2")); err != nil {
+		t.Fatal(err)
+	}
+	stats, err = src.CopySymbol(dst, "BRK.B")
+	if err != nil {
+		t.Fatalf("CopySymbol again: %v", err)
+	}
+	if stats != (CopyStats{Copied: 1, Skipped: 1}) {
+		t.Errorf("expected 1 copied and 1 skipped, got %+v", stats)
+	}
+	if copied, err := src.CopyOriginal(dst, hash); err != nil || copied {
+		t.Errorf("expected the original to be skipped, got copied %v (%v)", copied, err)
+	}
+}
+
+func TestFileSystem_Cutover(t *testing.T) {
+	old, _ := NewMemoryFileSystem(LayoutHash)
+	next, _ := NewMemoryFileSystem(LayoutHash)
+	if err := old.Write("AAPL", model.SizeM, []byte("old")); err != nil {
+		t.Fatal(err)
+	}
+	if err := next.Write("AAPL", model.SizeM, []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	cutover := false
+	old.SetCutover(next, func() bool { return cutover })
+
+	for _, tt := range []struct {
+		cutover bool
+		want    string
+	}{{false, "old"}, {true, "new"}} {
+		cutover = tt.cutover
+		if data, err := old.Read("AAPL", model.SizeM); err != nil || string(data) != tt.want {
+			t.Errorf("cutover %v: expected %q, got %q (%v)", tt.cutover, tt.want, data, err)
+		}
+	}
+
+	// Writes follow too; copies always read the store they're called on.
+	if err := old.Write("MSFT", model.SizeM, []byte("msft")); err != nil {
+		t.Fatal(err)
+	}
+	if !next.Exists("MSFT", model.SizeM) {
+		t.Error("expected a write after the cutover to reach the new store")
+	}
+	if stats, err := old.CopySymbol(next, "AAPL"); err != nil || stats.Copied != 1 {
+		t.Errorf("expected CopySymbol to copy from the old store, got %+v (%v)", stats, err)
+	}
+}
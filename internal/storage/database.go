@@ -17,8 +17,22 @@ CREATE TABLE IF NOT EXISTS logos (
     id            INTEGER PRIMARY KEY AUTOINCREMENT,
     symbol        TEXT NOT NULL UNIQUE,
     company_name  TEXT NOT NULL DEFAULT '',
+    website       TEXT NOT NULL DEFAULT '',
     source        TEXT NOT NULL DEFAULT 'unknown',
     original_url  TEXT NOT NULL DEFAULT '',
+    confidence    TEXT NOT NULL DEFAULT '',
+    license       TEXT NOT NULL DEFAULT '',
+    attribution   TEXT NOT NULL DEFAULT '',
+    whiten_background BOOLEAN,
+    default_bg    TEXT NOT NULL DEFAULT '',
+    quality_score INTEGER,
+    quality_notes TEXT NOT NULL DEFAULT '',
+    phash         TEXT NOT NULL DEFAULT '',
+    original_hash TEXT NOT NULL DEFAULT '',
+    etag          TEXT NOT NULL DEFAULT '',
+    last_modified TEXT NOT NULL DEFAULT '',
+    next_check_at DATETIME,
+    deleted_at    DATETIME,
     has_xs        BOOLEAN NOT NULL DEFAULT 0,
     has_s         BOOLEAN NOT NULL DEFAULT 0,
     has_m         BOOLEAN NOT NULL DEFAULT 0,
@@ -38,14 +52,204 @@ CREATE TABLE IF NOT EXISTS llm_calls (
     result_url  TEXT,
     success     BOOLEAN NOT NULL DEFAULT 0,
     duration_ms INTEGER,
+    key_id      TEXT NOT NULL DEFAULT '',
     created_at  DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
 );
 
+CREATE TABLE IF NOT EXISTS requested_symbols (
+    symbol          TEXT PRIMARY KEY,
+    request_count   INTEGER NOT NULL DEFAULT 1,
+    first_requested DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_requested  DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS instruments (
+    symbol     TEXT PRIMARY KEY,
+    name       TEXT NOT NULL DEFAULT '',
+    exchange   TEXT NOT NULL DEFAULT '',
+    source     TEXT NOT NULL DEFAULT '',
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS logo_variants (
+    symbol        TEXT NOT NULL,
+    size          TEXT NOT NULL,
+    variant       TEXT NOT NULL,
+    bytes         INTEGER NOT NULL,
+    created_at    DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_accessed DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (symbol, size, variant)
+);
+
+CREATE TABLE IF NOT EXISTS blocked_symbols (
+    symbol     TEXT PRIMARY KEY,
+    reason     TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS audit_log (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    action     TEXT NOT NULL,
+    symbol     TEXT NOT NULL,
+    details    TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS events (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    type       TEXT NOT NULL,
+    symbol     TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS delivery_cursors (
+    target        TEXT PRIMARY KEY,
+    last_event_id INTEGER NOT NULL,
+    updated_at    DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS dead_letters (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    target     TEXT NOT NULL,
+    event_id   INTEGER NOT NULL,
+    type       TEXT NOT NULL,
+    symbol     TEXT NOT NULL,
+    error      TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS originals (
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    symbol       TEXT NOT NULL,
+    hash         TEXT NOT NULL,
+    source       TEXT NOT NULL DEFAULT '',
+    original_url TEXT NOT NULL DEFAULT '',
+    confidence   TEXT NOT NULL DEFAULT '',
+    license      TEXT NOT NULL DEFAULT '',
+    attribution  TEXT NOT NULL DEFAULT '',
+    format       TEXT NOT NULL DEFAULT '',
+    size_bytes   INTEGER NOT NULL DEFAULT 0,
+    created_at   DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(symbol, hash)
+);
+
+CREATE TABLE IF NOT EXISTS logo_renditions (
+    symbol     TEXT NOT NULL,
+    size       TEXT NOT NULL,
+    width      INTEGER NOT NULL DEFAULT 0,
+    height     INTEGER NOT NULL DEFAULT 0,
+    bytes      INTEGER NOT NULL DEFAULT 0,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (symbol, size)
+);
+
+CREATE TABLE IF NOT EXISTS acquisition_attempts (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    trace       TEXT NOT NULL,
+    symbol      TEXT NOT NULL,
+    provider    TEXT NOT NULL,
+    outcome     TEXT NOT NULL,
+    error       TEXT NOT NULL DEFAULT '',
+    source      TEXT NOT NULL DEFAULT '',
+    duration_ms INTEGER NOT NULL DEFAULT 0,
+    created_at  DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS api_usage (
+    key_id       TEXT NOT NULL,
+    day          TEXT NOT NULL,
+    tier         TEXT NOT NULL DEFAULT '',
+    requests     INTEGER NOT NULL DEFAULT 0,
+    rejected     INTEGER NOT NULL DEFAULT 0,
+    credits_used INTEGER NOT NULL DEFAULT 0,
+    bytes_served INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (key_id, day)
+);
+
+-- Requests per symbol and day (sampled, see service.Popularity), for the
+-- most requested symbols: what to warm first and what to curate.
+CREATE TABLE IF NOT EXISTS symbol_requests (
+    symbol   TEXT NOT NULL,
+    day      TEXT NOT NULL,
+    requests INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (symbol, day)
+);
+CREATE INDEX IF NOT EXISTS idx_symbol_requests_day ON symbol_requests(day);
+
+CREATE TABLE IF NOT EXISTS stats_history (
+    day             TEXT PRIMARY KEY,
+    total           INTEGER NOT NULL DEFAULT 0,
+    processed       INTEGER NOT NULL DEFAULT 0,
+    pending         INTEGER NOT NULL DEFAULT 0,
+    failed          INTEGER NOT NULL DEFAULT 0,
+    not_found       INTEGER NOT NULL DEFAULT 0,
+    review          INTEGER NOT NULL DEFAULT 0,
+    llm_calls       INTEGER NOT NULL DEFAULT 0,
+    llm_spend_cents INTEGER NOT NULL DEFAULT 0,
+    requests        INTEGER NOT NULL DEFAULT 0,
+    misses          INTEGER NOT NULL DEFAULT 0,
+    cache_hit_rate  REAL,
+    recorded_at     DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS stats_history_sources (
+    day    TEXT NOT NULL,
+    source TEXT NOT NULL,
+    logos  INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (day, source)
+);
+
+CREATE TABLE IF NOT EXISTS flag_overrides (
+    name       TEXT PRIMARY KEY,
+    enabled    BOOLEAN NOT NULL,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS disabled_llm_clients (
+    provider    TEXT PRIMARY KEY,
+    reason      TEXT NOT NULL DEFAULT '',
+    disabled_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
 CREATE INDEX IF NOT EXISTS idx_logos_symbol ON logos(symbol);
 CREATE INDEX IF NOT EXISTS idx_logos_status ON logos(status);
 CREATE INDEX IF NOT EXISTS idx_llm_calls_symbol ON llm_calls(symbol);
+CREATE INDEX IF NOT EXISTS idx_requested_symbols_count ON requested_symbols(request_count);
+CREATE INDEX IF NOT EXISTS idx_logo_variants_last_accessed ON logo_variants(last_accessed);
+CREATE INDEX IF NOT EXISTS idx_audit_log_symbol ON audit_log(symbol);
+CREATE INDEX IF NOT EXISTS idx_acquisition_attempts_symbol ON acquisition_attempts(symbol);
 `
 
+// columnMigrations adds columns introduced after a table was first created.
+// CREATE TABLE IF NOT EXISTS leaves existing tables untouched, so databases
+// created by an older build need an ALTER TABLE for each new column.
+// New columns go both here and in the CREATE TABLE above.
+var columnMigrations = []struct {
+	table, column, definition string
+}{
+	{"logos", "confidence", "TEXT NOT NULL DEFAULT ''"},
+	{"logos", "license", "TEXT NOT NULL DEFAULT ''"},
+	{"logos", "attribution", "TEXT NOT NULL DEFAULT ''"},
+	{"logos", "whiten_background", "BOOLEAN"},
+	{"logos", "quality_score", "INTEGER"},
+	{"logos", "quality_notes", "TEXT NOT NULL DEFAULT ''"},
+	{"logos", "phash", "TEXT NOT NULL DEFAULT ''"},
+	{"logos", "next_check_at", "DATETIME"},
+	{"logos", "original_hash", "TEXT NOT NULL DEFAULT ''"},
+	{"logos", "etag", "TEXT NOT NULL DEFAULT ''"},
+	{"logos", "last_modified", "TEXT NOT NULL DEFAULT ''"},
+	{"logos", "website", "TEXT NOT NULL DEFAULT ''"},
+	{"logos", "deleted_at", "DATETIME"},
+	{"logos", "default_bg", "TEXT NOT NULL DEFAULT ''"},
+	{"llm_calls", "key_id", "TEXT NOT NULL DEFAULT ''"},
+	{"api_usage", "bytes_served", "INTEGER NOT NULL DEFAULT 0"},
+}
+
+// MemoryDatabase is the database path for a database that lives in memory
+// and disappears on Close. It works because NewDatabase keeps a single
+// connection: each SQLite connection to ":memory:" is its own database.
+const MemoryDatabase = ":memory:"
+
 // NewDatabase creates a new SQLite connection and runs migrations.
 // sqlx wraps database/sql with convenience methods like StructScan and NamedExec.
 //
@@ -78,5 +282,89 @@ func NewDatabase(dbPath string) (*sqlx.DB, error) {
 		return nil, fmt.Errorf("running migrations: %w", err)
 	}
 
+	if err := addMissingColumns(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("running column migrations: %w", err)
+	}
+
+	return db, nil
+}
+
+// NewReadDatabase opens a read-only pool of up to conns connections on the
+// database NewDatabase opened, for repositories to read from (see handles).
+// NewDatabase's single connection serializes everything: without a pool,
+// a request reading a logo waits behind an import writing hundreds. In WAL
+// mode, readers don't block the writer, nor it them, and a read sees every
+// write committed before it started.
+func NewReadDatabase(dbPath string, conns int) (*sqlx.DB, error) {
+	dsn := fmt.Sprintf("%s?_busy_timeout=5000&_query_only=true", dbPath)
+
+	db, err := sqlx.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("opening read database: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("pinging read database: %w", err)
+	}
+	db.SetMaxOpenConns(conns)
 	return db, nil
 }
+
+// handles are a repository's database handles. Writes go to db, and so do
+// reads that decide a write (a delivery cursor, whether an original is
+// already stored). Other reads — Get, Count, List — go to read, which is
+// db unless the constructor was given a reader.
+//
+// Consistency: the service reads records back right after writing them
+// (GetBySymbol after processing a logo, say), so a reader must see what
+// the writer committed. A NewReadDatabase pool on the same file does. A
+// replica that lags, like an asynchronous Postgres one, would need those
+// read-after-write paths pointed at the writer first; until then, it can
+// only take the listing and counting queries.
+type handles struct {
+	db   *sqlx.DB
+	read *sqlx.DB
+}
+
+// newHandles reads from the first reader, if one is given and not nil.
+//
+// Go note: the variadic parameter makes the reader optional, so callers
+// with a single database (tests, the CLI) don't change.
+func newHandles(db *sqlx.DB, reader []*sqlx.DB) handles {
+	h := handles{db: db, read: db}
+	if len(reader) > 0 && reader[0] != nil {
+		h.read = reader[0]
+	}
+	return h
+}
+
+// addMissingColumns applies columnMigrations, skipping columns that already exist.
+// PRAGMA table_info lists a table's columns — SQLite has no ADD COLUMN IF NOT EXISTS.
+func addMissingColumns(db *sqlx.DB) error {
+	for _, m := range columnMigrations {
+		var columns []struct {
+			Name string `db:"name"`
+		}
+		if err := db.Select(&columns, fmt.Sprintf("SELECT name FROM pragma_table_info('%s')", m.table)); err != nil {
+			return fmt.Errorf("inspecting %s: %w", m.table, err)
+		}
+
+		exists := false
+		for _, c := range columns {
+			if c.Name == m.column {
+				exists = true
+				break
+			}
+		}
+		if exists {
+			continue
+		}
+
+		query := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", m.table, m.column, m.definition)
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("adding %s.%s: %w", m.table, m.column, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/fleveque/logo-service/internal/model"
+	"github.com/jmoiron/sqlx"
+)
+
+func TestNewDatabase_AddsMissingColumns(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "old.db")
+
+	// Simulate a database created by an older build: logos without newer columns.
+	old, err := sqlx.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("opening old database: %v", err)
+	}
+	if _, err := old.Exec(`CREATE TABLE logos (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		symbol TEXT NOT NULL UNIQUE,
+		company_name TEXT NOT NULL DEFAULT '',
+		source TEXT NOT NULL DEFAULT 'unknown',
+		original_url TEXT NOT NULL DEFAULT '',
+		has_xs BOOLEAN NOT NULL DEFAULT 0,
+		has_s BOOLEAN NOT NULL DEFAULT 0,
+		has_m BOOLEAN NOT NULL DEFAULT 0,
+		has_l BOOLEAN NOT NULL DEFAULT 0,
+		has_xl BOOLEAN NOT NULL DEFAULT 0,
+		status TEXT NOT NULL DEFAULT 'pending',
+		error_message TEXT,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`); err != nil {
+		t.Fatalf("creating old schema: %v", err)
+	}
+	old.Close()
+
+	// Opening twice proves the migration is idempotent.
+	for i := 0; i < 2; i++ {
+		db, err := NewDatabase(dbPath)
+		if err != nil {
+			t.Fatalf("open %d: %v", i, err)
+		}
+
+		for _, m := range columnMigrations {
+			var count int
+			query := "SELECT COUNT(*) FROM pragma_table_info('" + m.table + "') WHERE name = ?"
+			if err := db.Get(&count, query, m.column); err != nil {
+				t.Fatalf("inspecting %s: %v", m.table, err)
+			}
+			if count != 1 {
+				t.Errorf("open %d: expected column %s.%s to exist", i, m.table, m.column)
+			}
+		}
+		db.Close()
+	}
+}
+
+func TestNewDatabase_InMemory(t *testing.T) {
+	db, err := NewDatabase(MemoryDatabase)
+	if err != nil {
+		t.Fatalf("NewDatabase: %v", err)
+	}
+	defer db.Close()
+
+	// Every query must see the same database, not a fresh empty one.
+	if _, err := db.Exec("INSERT INTO logos (symbol) VALUES ('AAPL')"); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	var n int
+	if err := db.Get(&n, "SELECT COUNT(*) FROM logos"); err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("count = %d, want 1", n)
+	}
+}
+
+func TestNewReadDatabase(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "logos.db")
+	db, err := NewDatabase(dbPath)
+	if err != nil {
+		t.Fatalf("NewDatabase: %v", err)
+	}
+	defer db.Close()
+	reader, err := NewReadDatabase(dbPath, 2)
+	if err != nil {
+		t.Fatalf("NewReadDatabase: %v", err)
+	}
+	defer reader.Close()
+
+	// Writes through the repository land on the writer and read back
+	// through the pool.
+	repo := NewLogoRepository(db, reader)
+	if err := repo.Create(context.Background(), &model.Logo{Symbol: "AAPL", Status: model.StatusPending}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := repo.GetBySymbol(context.Background(), "AAPL"); err != nil {
+		t.Errorf("GetBySymbol through reader: %v", err)
+	}
+
+	if _, err := reader.Exec("INSERT INTO logos (symbol) VALUES ('MSFT')"); err == nil {
+		t.Error("expected the read pool to reject writes")
+	}
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+// DeliveryRepository tracks how far each publishing target has got through
+// the changes feed, and the events it gave up on.
+type DeliveryRepository interface {
+	Cursor(ctx context.Context, target string) (int64, error)
+	SetCursor(ctx context.Context, target string, eventID int64) error
+	RecordDeadLetter(ctx context.Context, letter *model.DeadLetter) error
+	ListDeadLetters(ctx context.Context, limit int) ([]model.DeadLetter, error)
+}
+
+type sqliteDeliveryRepository struct {
+	handles
+}
+
+// NewDeliveryRepository creates a new SQLite-backed DeliveryRepository.
+func NewDeliveryRepository(db *sqlx.DB, reader ...*sqlx.DB) DeliveryRepository {
+	return &sqliteDeliveryRepository{handles: newHandles(db, reader)}
+}
+
+// Cursor returns the last event ID delivered to target, 0 if none was.
+func (r *sqliteDeliveryRepository) Cursor(ctx context.Context, target string) (int64, error) {
+	var id int64
+	err := r.db.GetContext(ctx, &id, `SELECT last_event_id FROM delivery_cursors WHERE target = ?`, target)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("reading delivery cursor: %w", err)
+	}
+	return id, nil
+}
+
+// SetCursor records that every event up to eventID has been dealt with.
+func (r *sqliteDeliveryRepository) SetCursor(ctx context.Context, target string, eventID int64) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO delivery_cursors (target, last_event_id) VALUES (?, ?)
+		ON CONFLICT(target) DO UPDATE SET last_event_id = excluded.last_event_id, updated_at = CURRENT_TIMESTAMP
+	`, target, eventID)
+	if err != nil {
+		return fmt.Errorf("saving delivery cursor: %w", err)
+	}
+	return nil
+}
+
+// RecordDeadLetter appends to the dead-letter log.
+func (r *sqliteDeliveryRepository) RecordDeadLetter(ctx context.Context, letter *model.DeadLetter) error {
+	result, err := r.db.NamedExecContext(ctx, `
+		INSERT INTO dead_letters (target, event_id, type, symbol, error)
+		VALUES (:target, :event_id, :type, :symbol, :error)
+	`, letter)
+	if err != nil {
+		return fmt.Errorf("recording dead letter: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("getting last insert id: %w", err)
+	}
+	letter.ID = id
+	return nil
+}
+
+// ListDeadLetters returns up to limit dead letters, newest first.
+func (r *sqliteDeliveryRepository) ListDeadLetters(ctx context.Context, limit int) ([]model.DeadLetter, error) {
+	letters := []model.DeadLetter{}
+	err := r.read.SelectContext(ctx, &letters, `SELECT * FROM dead_letters ORDER BY id DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("listing dead letters: %w", err)
+	}
+	return letters, nil
+}
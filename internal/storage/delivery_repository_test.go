@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+func TestDeliveryRepository_Cursor(t *testing.T) {
+	deps := setupTestDB(t)
+	ctx := context.Background()
+	repo := deps.deliveryRepo
+
+	if id, err := repo.Cursor(ctx, "nats:events"); err != nil || id != 0 {
+		t.Fatalf("expected 0 for a new target, got %d (%v)", id, err)
+	}
+
+	for _, id := range []int64{3, 7} {
+		if err := repo.SetCursor(ctx, "nats:events", id); err != nil {
+			t.Fatalf("SetCursor: %v", err)
+		}
+	}
+	if err := repo.SetCursor(ctx, "kafka:events", 1); err != nil {
+		t.Fatalf("SetCursor: %v", err)
+	}
+
+	if id, _ := repo.Cursor(ctx, "nats:events"); id != 7 {
+		t.Errorf("expected cursor 7, got %d", id)
+	}
+	if id, _ := repo.Cursor(ctx, "kafka:events"); id != 1 {
+		t.Errorf("expected targets to have separate cursors, got %d", id)
+	}
+}
+
+func TestDeliveryRepository_DeadLetters(t *testing.T) {
+	deps := setupTestDB(t)
+	ctx := context.Background()
+	repo := deps.deliveryRepo
+
+	for _, symbol := range []string{"AAPL", "MSFT"} {
+		letter := &model.DeadLetter{Target: "nats:events", EventID: 1, Type: model.EventCreated, Symbol: symbol, Error: "timeout"}
+		if err := repo.RecordDeadLetter(ctx, letter); err != nil {
+			t.Fatalf("RecordDeadLetter: %v", err)
+		}
+		if letter.ID == 0 {
+			t.Error("expected ID to be set")
+		}
+	}
+
+	letters, err := repo.ListDeadLetters(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(letters) != 2 || letters[0].Symbol != "MSFT" || letters[0].Error != "timeout" {
+		t.Errorf("expected newest first, got %+v", letters)
+	}
+}
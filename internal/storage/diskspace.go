@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/fleveque/logo-service/internal/clock"
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+// ErrLowDiskSpace is model.ErrLowDiskSpace, which Check wraps.
+var ErrLowDiskSpace = model.ErrLowDiskSpace
+
+// DiskStatus is a snapshot of the filesystem holding logo_dir.
+type DiskStatus struct {
+	FreeBytes    uint64    `json:"free_bytes"`
+	TotalBytes   uint64    `json:"total_bytes"`
+	MinFreeBytes uint64    `json:"min_free_bytes"`
+	Low          bool      `json:"low"`
+	CheckedAt    time.Time `json:"checked_at"`
+}
+
+// DiskMonitor tracks free space under a directory. Readings are cached for
+// maxAge — statfs is cheap, but GetLogo calls Check on every cache miss.
+//
+// Go note: the zero value of sync.Mutex is an unlocked mutex, so it needs
+// no initialization. Embedding it by value (not pointer) also means a
+// DiskMonitor must never be copied — always pass *DiskMonitor.
+type DiskMonitor struct {
+	path     string
+	minFree  uint64
+	maxAge   time.Duration
+	onChange func(DiskStatus)
+	clock    clock.Clock
+
+	mu     sync.Mutex
+	status DiskStatus
+	err    error
+}
+
+// NewDiskMonitor creates a monitor for the filesystem holding path.
+// minFreeBytes of 0 disables the low-space check (space is still reported).
+func NewDiskMonitor(path string, minFreeBytes uint64, maxAge time.Duration) *DiskMonitor {
+	return &DiskMonitor{path: path, minFree: minFreeBytes, maxAge: maxAge, clock: clock.System}
+}
+
+// SetClock replaces the system clock that decides when a reading is stale.
+// Call it before the monitor is in use.
+func (m *DiskMonitor) SetClock(c clock.Clock) {
+	m.clock = c
+}
+
+// OnChange registers fn to be called whenever free space crosses the minimum,
+// in either direction. It runs on its own goroutine so a slow alert webhook
+// never holds up a request. Call it before the monitor is in use.
+func (m *DiskMonitor) OnChange(fn func(DiskStatus)) {
+	m.onChange = fn
+}
+
+// Status returns the latest reading, refreshing it if it's older than maxAge.
+func (m *DiskMonitor) Status() (DiskStatus, error) {
+	m.mu.Lock()
+	if m.clock.Now().Sub(m.status.CheckedAt) < m.maxAge {
+		defer m.mu.Unlock()
+		return m.status, m.err
+	}
+	wasLow := m.status.Low
+
+	free, total, err := diskUsage(m.path)
+	m.status = DiskStatus{
+		FreeBytes:    free,
+		TotalBytes:   total,
+		MinFreeBytes: m.minFree,
+		Low:          err == nil && m.minFree > 0 && free < m.minFree,
+		CheckedAt:    m.clock.Now(),
+	}
+	m.err = err
+	status := m.status
+	m.mu.Unlock()
+
+	if status.Low != wasLow && m.onChange != nil {
+		go m.onChange(status)
+	}
+	return status, err
+}
+
+// Check returns an error wrapping ErrLowDiskSpace when free space is below
+// the minimum. If free space can't be read at all, Check lets writes through:
+// a broken monitor shouldn't take acquisitions down with it.
+func (m *DiskMonitor) Check() error {
+	status, err := m.Status()
+	if err != nil || !status.Low {
+		return nil
+	}
+	return fmt.Errorf("%w: %d MB free in %s, minimum is %d MB",
+		ErrLowDiskSpace, status.FreeBytes>>20, m.path, status.MinFreeBytes>>20)
+}
+
+// Run refreshes the reading every maxAge until ctx is cancelled, so OnChange
+// fires (and alerts go out) even when no requests are coming in.
+func (m *DiskMonitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.maxAge)
+	defer ticker.Stop()
+
+	_, _ = m.Status()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, _ = m.Status()
+		}
+	}
+}
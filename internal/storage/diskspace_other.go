@@ -0,0 +1,11 @@
+//go:build !(linux || darwin || freebsd)
+
+package storage
+
+import "errors"
+
+// diskUsage isn't implemented on this system; the monitor reports the error
+// and never blocks acquisitions.
+func diskUsage(path string) (free, total uint64, err error) {
+	return 0, 0, errors.New("disk usage not supported on this platform")
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/fleveque/logo-service/internal/clock"
+)
+
+func TestDiskMonitor_ReportsSpace(t *testing.T) {
+	m := NewDiskMonitor(t.TempDir(), 0, time.Minute)
+
+	status, err := m.Status()
+	if err != nil {
+		t.Skipf("disk usage not available here: %v", err)
+	}
+	if status.TotalBytes == 0 || status.FreeBytes > status.TotalBytes {
+		t.Errorf("implausible reading: %+v", status)
+	}
+	if status.Low {
+		t.Error("a minimum of 0 should never be low")
+	}
+	if err := m.Check(); err != nil {
+		t.Errorf("Check: %v", err)
+	}
+}
+
+func TestDiskMonitor_LowSpace(t *testing.T) {
+	// No filesystem has MaxUint64 bytes free, so this is always low.
+	m := NewDiskMonitor(t.TempDir(), math.MaxUint64, time.Minute)
+
+	changed := make(chan DiskStatus, 1)
+	m.OnChange(func(s DiskStatus) { changed <- s })
+
+	if _, err := m.Status(); err != nil {
+		t.Skipf("disk usage not available here: %v", err)
+	}
+
+	if err := m.Check(); !errors.Is(err, ErrLowDiskSpace) {
+		t.Fatalf("expected ErrLowDiskSpace, got %v", err)
+	}
+
+	select {
+	case s := <-changed:
+		if !s.Low {
+			t.Errorf("OnChange got a status that isn't low: %+v", s)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("OnChange was not called")
+	}
+}
+
+func TestDiskMonitor_CachesReadingsForMaxAge(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	m := NewDiskMonitor(t.TempDir(), 0, time.Minute)
+	m.SetClock(fake)
+
+	first, err := m.Status()
+	if err != nil {
+		t.Skipf("disk usage not available here: %v", err)
+	}
+
+	fake.Advance(30 * time.Second)
+	if s, _ := m.Status(); !s.CheckedAt.Equal(first.CheckedAt) {
+		t.Errorf("expected the cached reading within maxAge, got one checked at %v", s.CheckedAt)
+	}
+
+	fake.Advance(time.Minute)
+	if s, _ := m.Status(); !s.CheckedAt.Equal(fake.Now()) {
+		t.Errorf("expected a fresh reading after maxAge, got one checked at %v", s.CheckedAt)
+	}
+}
@@ -0,0 +1,20 @@
+//go:build linux || darwin || freebsd
+
+package storage
+
+import "syscall"
+
+// diskUsage returns the bytes available to unprivileged users and the total
+// size of the filesystem holding path.
+//
+// Go note: the //go:build line above is a build constraint — this file only
+// compiles on the listed systems. diskspace_other.go covers the rest, so the
+// package builds everywhere. Statfs_t field types differ between systems,
+// hence the uint64 conversions.
+func diskUsage(path string) (free, total uint64, err error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, 0, err
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize), uint64(st.Blocks) * uint64(st.Bsize), nil
+}
@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+// EventRepository is the append-only changes feed, read by cursor.
+type EventRepository interface {
+	Record(ctx context.Context, event *model.Event) error
+	ListSince(ctx context.Context, since int64, limit int) ([]model.Event, error)
+	LatestID(ctx context.Context) (int64, error)
+}
+
+type sqliteEventRepository struct {
+	handles
+}
+
+// NewEventRepository creates a new SQLite-backed EventRepository.
+func NewEventRepository(db *sqlx.DB, reader ...*sqlx.DB) EventRepository {
+	return &sqliteEventRepository{handles: newHandles(db, reader)}
+}
+
+// Record appends an event and sets its ID.
+func (r *sqliteEventRepository) Record(ctx context.Context, event *model.Event) error {
+	result, err := r.db.NamedExecContext(ctx, `
+		INSERT INTO events (type, symbol)
+		VALUES (:type, :symbol)
+	`, event)
+	if err != nil {
+		return fmt.Errorf("recording event: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("getting last insert id: %w", err)
+	}
+	event.ID = id
+	return nil
+}
+
+// ListSince returns up to limit events with an ID above since, oldest first.
+// AUTOINCREMENT guarantees IDs are never reused, so a cursor stays valid.
+func (r *sqliteEventRepository) ListSince(ctx context.Context, since int64, limit int) ([]model.Event, error) {
+	events := []model.Event{}
+	err := r.read.SelectContext(ctx, &events,
+		`SELECT * FROM events WHERE id > ? ORDER BY id LIMIT ?`, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("listing events: %w", err)
+	}
+	return events, nil
+}
+
+// LatestID returns the ID of the newest event, 0 if there is none: a cursor
+// that skips the feed's history.
+func (r *sqliteEventRepository) LatestID(ctx context.Context) (int64, error) {
+	var id int64
+	if err := r.read.GetContext(ctx, &id, `SELECT COALESCE(MAX(id), 0) FROM events`); err != nil {
+		return 0, fmt.Errorf("getting latest event id: %w", err)
+	}
+	return id, nil
+}
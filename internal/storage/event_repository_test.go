@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+func TestEventRepository_ListSince(t *testing.T) {
+	deps := setupTestDB(t)
+	ctx := context.Background()
+	repo := deps.eventRepo
+
+	var ids []int64
+	for _, e := range []model.Event{
+		{Type: model.EventCreated, Symbol: "AAPL"},
+		{Type: model.EventCreated, Symbol: "MSFT"},
+		{Type: model.EventDeleted, Symbol: "AAPL"},
+	} {
+		if err := repo.Record(ctx, &e); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+		ids = append(ids, e.ID)
+	}
+
+	all, err := repo.ListSince(ctx, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 || all[0].Symbol != "AAPL" || all[2].Type != model.EventDeleted {
+		t.Errorf("expected oldest first, got %+v", all)
+	}
+
+	// Paging with the last ID seen picks up exactly where the previous page ended.
+	page, err := repo.ListSince(ctx, ids[0], 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 1 || page[0].ID != ids[1] {
+		t.Errorf("expected only event %d, got %+v", ids[1], page)
+	}
+
+	if rest, _ := repo.ListSince(ctx, ids[2], 10); rest == nil || len(rest) != 0 {
+		t.Errorf("expected an empty, non-nil list at the end, got %#v", rest)
+	}
+}
@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/fleveque/logo-service/internal/chaos"
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+// InjectFaults returns repo with faults injected into the operations a
+// logo request goes through — the lookup, and the writes of an acquisition
+// — for resilience testing (chaos.storage). The rest pass straight
+// through. A nil inj returns repo itself.
+func InjectFaults(repo LogoRepository, inj *chaos.Injector) LogoRepository {
+	if inj == nil {
+		return repo
+	}
+	return &faultyLogoRepository{LogoRepository: repo, faults: inj}
+}
+
+// faultyLogoRepository asks its injector before each faulted operation.
+//
+// Go note: embedding the interface gives the struct every method of
+// LogoRepository, delegating to the embedded value; the methods defined
+// below take precedence over the embedded ones.
+type faultyLogoRepository struct {
+	LogoRepository
+	faults *chaos.Injector
+}
+
+func (r *faultyLogoRepository) GetBySymbol(ctx context.Context, symbol string) (*model.Logo, error) {
+	if err := r.faults.Inject(ctx); err != nil {
+		return nil, err
+	}
+	return r.LogoRepository.GetBySymbol(ctx, symbol)
+}
+
+func (r *faultyLogoRepository) Create(ctx context.Context, logo *model.Logo) error {
+	if err := r.faults.Inject(ctx); err != nil {
+		return err
+	}
+	return r.LogoRepository.Create(ctx, logo)
+}
+
+func (r *faultyLogoRepository) Update(ctx context.Context, logo *model.Logo) error {
+	if err := r.faults.Inject(ctx); err != nil {
+		return err
+	}
+	return r.LogoRepository.Update(ctx, logo)
+}
+
+func (r *faultyLogoRepository) SetStatus(ctx context.Context, symbol string, status model.LogoStatus, errMsg string) error {
+	if err := r.faults.Inject(ctx); err != nil {
+		return err
+	}
+	return r.LogoRepository.SetStatus(ctx, symbol, status, errMsg)
+}
+
+func (r *faultyLogoRepository) SetSizeAvailable(ctx context.Context, symbol string, size model.LogoSize) error {
+	if err := r.faults.Inject(ctx); err != nil {
+		return err
+	}
+	return r.LogoRepository.SetSizeAvailable(ctx, symbol, size)
+}
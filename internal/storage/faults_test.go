@@ -0,0 +1,32 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fleveque/logo-service/internal/chaos"
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+func TestInjectFaults(t *testing.T) {
+	deps := setupTestDB(t)
+	ctx := context.Background()
+
+	if repo := InjectFaults(deps.logoRepo, nil); repo != deps.logoRepo {
+		t.Error("expected the repository itself without an injector")
+	}
+
+	repo := InjectFaults(deps.logoRepo, chaos.New("storage", chaos.Fault{ErrorRate: 1}))
+	err := repo.Create(ctx, &model.Logo{Symbol: "AAPL", Status: model.StatusPending})
+	if !errors.Is(err, chaos.ErrInjected) {
+		t.Fatalf("Create: expected ErrInjected, got %v", err)
+	}
+	if _, err := repo.GetBySymbol(ctx, "AAPL"); !errors.Is(err, chaos.ErrInjected) {
+		t.Errorf("GetBySymbol: expected ErrInjected, got %v", err)
+	}
+	// The write never happened, and operations that aren't faulted pass through.
+	if n, err := repo.Count(ctx); err != nil || n != 0 {
+		t.Errorf("Count = %d, %v; want 0", n, err)
+	}
+}
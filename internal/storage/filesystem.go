@@ -1,78 +1,313 @@
 package storage
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 
 	"github.com/fleveque/logo-service/internal/model"
 )
 
-// FileSystem handles reading and writing logo image files on disk.
-// Logos are stored at: {baseDir}/{SYMBOL}/{size}.png
+// Layout decides where under baseDir a symbol's directory lives.
+// Symbol directory names are always made filesystem-safe by encodeSymbol.
+type Layout string
+
+const (
+	LayoutFlat   Layout = "flat"   // {baseDir}/AAPL/m.png
+	LayoutPrefix Layout = "prefix" // {baseDir}/AA/AAPL/m.png — easy to browse by hand
+	LayoutHash   Layout = "hash"   // {baseDir}/3f/a9/AAPL/m.png — evenly spread (default)
+)
+
+// ValidLayout checks if a string is a supported Layout.
+func ValidLayout(s string) bool {
+	switch Layout(s) {
+	case LayoutFlat, LayoutPrefix, LayoutHash:
+		return true
+	}
+	return false
+}
+
+// FileSystem handles reading and writing logo image files.
+// Logos are stored at: {baseDir}/{shard}/{encoded SYMBOL}/{size}.png, where
+// the shard depends on the Layout (see shardDir). The bytes live in a
+// BlobStore: files under baseDir in production, memory in tests and demos.
 type FileSystem struct {
-	baseDir string
+	baseDir string // "" when the store isn't on disk
+	layout  Layout
+	blobs   BlobStore
+
+	// next takes over once cutover reports true (see SetCutover).
+	next    *FileSystem
+	cutover func() bool
 }
 
 // NewFileSystem creates a new FileSystem storage, ensuring the base directory exists.
-func NewFileSystem(baseDir string) (*FileSystem, error) {
-	// MkdirAll creates the directory and all parents (like mkdir -p).
-	// 0755 is the Unix permission mode: owner rwx, group rx, others rx.
-	if err := os.MkdirAll(baseDir, 0755); err != nil {
-		return nil, fmt.Errorf("creating logo directory: %w", err)
+// Changing the layout of an existing directory requires MigrateLayout.
+func NewFileSystem(baseDir string, layout Layout) (*FileSystem, error) {
+	if !ValidLayout(string(layout)) {
+		return nil, fmt.Errorf("unknown storage layout: %q", layout)
+	}
+	blobs, err := NewDiskBlobStore(baseDir)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSystem{baseDir: baseDir, layout: layout, blobs: blobs}, nil
+}
+
+// NewMemoryFileSystem creates a FileSystem whose logos only live in memory.
+func NewMemoryFileSystem(layout Layout) (*FileSystem, error) {
+	return NewFileSystemOn(NewMemoryBlobStore(), layout)
+}
+
+// NewFileSystemOn creates a FileSystem on any BlobStore. MigrateLayout only
+// works on disk, and the path methods return keys rather than file paths.
+func NewFileSystemOn(blobs BlobStore, layout Layout) (*FileSystem, error) {
+	if !ValidLayout(string(layout)) {
+		return nil, fmt.Errorf("unknown storage layout: %q", layout)
 	}
-	return &FileSystem{baseDir: baseDir}, nil
+	return &FileSystem{layout: layout, blobs: blobs}, nil
+}
+
+// symbolKey is the BlobStore directory of a symbol, e.g. "3f/a9/AAPL".
+// Symbols are upper-cased first: on case-insensitive filesystems (macOS,
+// Windows) "aapl" and "AAPL" would otherwise be the same directory anyway.
+func (fs *FileSystem) symbolKey(symbol string) string {
+	symbol = strings.ToUpper(symbol)
+	encoded := encodeSymbol(symbol)
+	return path.Join(shardDir(fs.layout, symbol, encoded), encoded)
+}
+
+func (fs *FileSystem) logoKey(symbol string, size model.LogoSize) string {
+	return path.Join(fs.symbolKey(symbol), string(size)+".png")
+}
+
+func (fs *FileSystem) variantKey(symbol string, size model.LogoSize, variant string) string {
+	return path.Join(fs.symbolKey(symbol), string(size)+"_"+variant+".png")
+}
+
+// diskPath turns a key into a path under baseDir.
+func (fs *FileSystem) diskPath(key string) string {
+	return filepath.Join(fs.baseDir, filepath.FromSlash(key))
 }
 
 // LogoPath returns the filesystem path for a logo at a given size.
 func (fs *FileSystem) LogoPath(symbol string, size model.LogoSize) string {
-	return filepath.Join(fs.baseDir, symbol, string(size)+".png")
+	fs = fs.active()
+	return fs.diskPath(fs.logoKey(symbol, size))
 }
 
 // SymbolDir returns the directory for a symbol's logos.
 func (fs *FileSystem) SymbolDir(symbol string) string {
-	return filepath.Join(fs.baseDir, symbol)
+	fs = fs.active()
+	return fs.diskPath(fs.symbolKey(symbol))
 }
 
-// Read reads a logo file from disk. Returns the raw PNG bytes.
-// In Go, file I/O returns []byte (byte slice) — the fundamental type for binary data.
+// checkSymbol guards every disk operation: a symbol is used as a directory
+// name, so anything that isn't a well-formed ticker (e.g. "..", "a/b") could
+// escape baseDir. Handlers already validate, this is defense in depth.
+func checkSymbol(symbol string) error {
+	if !model.ValidSymbol(strings.ToUpper(symbol)) {
+		return fmt.Errorf("%w: %q", model.ErrInvalidSymbol, symbol)
+	}
+	return nil
+}
+
+// Read reads a logo file. Returns the raw PNG bytes. The error wraps
+// os.ErrNotExist when there's no such file.
 func (fs *FileSystem) Read(symbol string, size model.LogoSize) ([]byte, error) {
-	path := fs.LogoPath(symbol, size)
-	data, err := os.ReadFile(path)
+	fs = fs.active()
+	if err := checkSymbol(symbol); err != nil {
+		return nil, err
+	}
+	data, err := fs.blobs.Get(fs.logoKey(symbol, size))
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("logo file not found: %s/%s", symbol, size)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("logo file not found: %s/%s: %w", symbol, size, err)
 		}
 		return nil, fmt.Errorf("reading logo file: %w", err)
 	}
 	return data, nil
 }
 
-// Write saves a logo PNG to disk, creating the symbol directory if needed.
+// Write saves a logo PNG, creating the symbol directory if needed.
 func (fs *FileSystem) Write(symbol string, size model.LogoSize, data []byte) error {
-	dir := fs.SymbolDir(symbol)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("creating symbol directory: %w", err)
+	fs = fs.active()
+	if err := checkSymbol(symbol); err != nil {
+		return err
 	}
-
-	path := fs.LogoPath(symbol, size)
-	// WriteFile atomically writes the file (creates or truncates).
-	// 0644: owner rw, group r, others r — standard for non-executable files.
-	if err := os.WriteFile(path, data, 0644); err != nil {
+	if err := fs.blobs.Put(fs.logoKey(symbol, size), data); err != nil {
 		return fmt.Errorf("writing logo file: %w", err)
 	}
 	return nil
 }
 
-// Exists checks if a logo file exists on disk.
+// Exists checks if a logo file exists.
 func (fs *FileSystem) Exists(symbol string, size model.LogoSize) bool {
-	path := fs.LogoPath(symbol, size)
-	_, err := os.Stat(path)
-	return err == nil
+	fs = fs.active()
+	if checkSymbol(symbol) != nil {
+		return false
+	}
+	return fs.blobs.Exists(fs.logoKey(symbol, size))
 }
 
 // DeleteSymbol removes all logo files for a symbol.
 func (fs *FileSystem) DeleteSymbol(symbol string) error {
-	dir := fs.SymbolDir(symbol)
-	return os.RemoveAll(dir)
+	fs = fs.active()
+	if err := checkSymbol(symbol); err != nil {
+		return err
+	}
+	return fs.blobs.DeleteDir(fs.symbolKey(symbol))
+}
+
+// originalsDir holds source images by content hash. The leading underscore
+// keeps it apart from symbol directories and shards, which never start
+// with one, and MigrateLayout ignores it: it holds no PNGs named by size.
+const originalsDir = "_originals"
+
+// originalKey is where the bytes with a given hash live, e.g.
+// "_originals/3f/3fa9…". Two hex digits of sharding keep directories small.
+func originalKey(hash string) (string, error) {
+	if len(hash) != 64 || strings.Trim(hash, "0123456789abcdef") != "" {
+		return "", fmt.Errorf("invalid original hash: %q", hash)
+	}
+	return path.Join(originalsDir, hash[:2], hash), nil
+}
+
+// WriteOriginal stores a source image under its content hash (see
+// HashOriginal) and returns the hash. Writing the same bytes again is a no-op.
+func (fs *FileSystem) WriteOriginal(data []byte) (string, error) {
+	fs = fs.active()
+	hash := HashOriginal(data)
+	key, _ := originalKey(hash)
+	if fs.blobs.Exists(key) {
+		return hash, nil
+	}
+	if err := fs.blobs.Put(key, data); err != nil {
+		return "", fmt.Errorf("writing original: %w", err)
+	}
+	return hash, nil
+}
+
+// ReadOriginal returns the source image stored under hash. The error wraps
+// os.ErrNotExist when there's none.
+func (fs *FileSystem) ReadOriginal(hash string) ([]byte, error) {
+	fs = fs.active()
+	key, err := originalKey(hash)
+	if err != nil {
+		return nil, err
+	}
+	data, err := fs.blobs.Get(key)
+	if err != nil {
+		return nil, fmt.Errorf("reading original: %w", err)
+	}
+	return data, nil
+}
+
+// DeleteOriginal removes a stored source image. Deleting one that isn't
+// there is not an error.
+func (fs *FileSystem) DeleteOriginal(hash string) error {
+	fs = fs.active()
+	key, err := originalKey(hash)
+	if err != nil {
+		return err
+	}
+	if err := fs.blobs.Delete(key); err != nil {
+		return fmt.Errorf("deleting original: %w", err)
+	}
+	return nil
+}
+
+// VariantPath returns the path of a cached variant of a size, e.g.
+// {symbol dir}/m_bg_ffffff.png. Variants live next to the canonical sizes,
+// so MigrateLayout and DeleteSymbol handle them without special cases.
+func (fs *FileSystem) VariantPath(symbol string, size model.LogoSize, variant string) string {
+	fs = fs.active()
+	return fs.diskPath(fs.variantKey(symbol, size, variant))
+}
+
+// checkVariant keeps variant names to characters that are safe in a file name.
+func checkVariant(variant string) error {
+	if variant == "" {
+		return fmt.Errorf("empty variant name")
+	}
+	for _, c := range variant {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '_' || c == '-') {
+			return fmt.Errorf("invalid variant name: %q", variant)
+		}
+	}
+	return nil
+}
+
+// ReadVariant reads a cached variant. The error wraps os.ErrNotExist when
+// it isn't cached.
+func (fs *FileSystem) ReadVariant(symbol string, size model.LogoSize, variant string) ([]byte, error) {
+	fs = fs.active()
+	if err := checkSymbol(symbol); err != nil {
+		return nil, err
+	}
+	if err := checkVariant(variant); err != nil {
+		return nil, err
+	}
+	data, err := fs.blobs.Get(fs.variantKey(symbol, size, variant))
+	if err != nil {
+		return nil, fmt.Errorf("reading variant file: %w", err)
+	}
+	return data, nil
+}
+
+// WriteVariant saves a variant next to the symbol's canonical sizes.
+func (fs *FileSystem) WriteVariant(symbol string, size model.LogoSize, variant string, data []byte) error {
+	fs = fs.active()
+	if err := checkSymbol(symbol); err != nil {
+		return err
+	}
+	if err := checkVariant(variant); err != nil {
+		return err
+	}
+	if err := fs.blobs.Put(fs.variantKey(symbol, size, variant), data); err != nil {
+		return fmt.Errorf("writing variant file: %w", err)
+	}
+	return nil
+}
+
+// DeleteVariant removes a cached variant. Deleting one that isn't there is not an error.
+func (fs *FileSystem) DeleteVariant(symbol string, size model.LogoSize, variant string) error {
+	fs = fs.active()
+	if err := checkSymbol(symbol); err != nil {
+		return err
+	}
+	if err := checkVariant(variant); err != nil {
+		return err
+	}
+	if err := fs.blobs.Delete(fs.variantKey(symbol, size, variant)); err != nil {
+		return fmt.Errorf("deleting variant file: %w", err)
+	}
+	return nil
+}
+
+// DeleteVariants removes every cached variant of a symbol, leaving the
+// canonical sizes in place.
+func (fs *FileSystem) DeleteVariants(symbol string) error {
+	fs = fs.active()
+	if err := checkSymbol(symbol); err != nil {
+		return err
+	}
+	dir := fs.symbolKey(symbol)
+	names, err := fs.blobs.List(dir)
+	if err != nil {
+		return fmt.Errorf("listing symbol directory: %w", err)
+	}
+	for _, name := range names {
+		// Canonical files are "{size}.png"; anything with an underscore is a variant.
+		if !strings.Contains(name, "_") {
+			continue
+		}
+		if err := fs.blobs.Delete(path.Join(dir, name)); err != nil {
+			return fmt.Errorf("deleting variant file: %w", err)
+		}
+	}
+	return nil
 }
@@ -1,6 +1,12 @@
 package storage
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/fleveque/logo-service/internal/model"
@@ -8,7 +14,7 @@ import (
 
 func TestFileSystem_WriteAndRead(t *testing.T) {
 	tmpDir := t.TempDir()
-	fs, err := NewFileSystem(tmpDir)
+	fs, err := NewFileSystem(tmpDir, LayoutHash)
 	if err != nil {
 		t.Fatalf("creating filesystem: %v", err)
 	}
@@ -43,7 +49,7 @@ func TestFileSystem_WriteAndRead(t *testing.T) {
 
 func TestFileSystem_Exists_NotFound(t *testing.T) {
 	tmpDir := t.TempDir()
-	fs, err := NewFileSystem(tmpDir)
+	fs, err := NewFileSystem(tmpDir, LayoutHash)
 	if err != nil {
 		t.Fatalf("creating filesystem: %v", err)
 	}
@@ -55,7 +61,7 @@ func TestFileSystem_Exists_NotFound(t *testing.T) {
 
 func TestFileSystem_Read_NotFound(t *testing.T) {
 	tmpDir := t.TempDir()
-	fs, err := NewFileSystem(tmpDir)
+	fs, err := NewFileSystem(tmpDir, LayoutHash)
 	if err != nil {
 		t.Fatalf("creating filesystem: %v", err)
 	}
@@ -68,7 +74,7 @@ func TestFileSystem_Read_NotFound(t *testing.T) {
 
 func TestFileSystem_DeleteSymbol(t *testing.T) {
 	tmpDir := t.TempDir()
-	fs, err := NewFileSystem(tmpDir)
+	fs, err := NewFileSystem(tmpDir, LayoutHash)
 	if err != nil {
 		t.Fatalf("creating filesystem: %v", err)
 	}
@@ -95,10 +101,164 @@ func TestFileSystem_DeleteSymbol(t *testing.T) {
 }
 
 func TestFileSystem_LogoPath(t *testing.T) {
-	fs := &FileSystem{baseDir: "/data/logos"}
-	path := fs.LogoPath("AAPL", model.SizeM)
-	expected := "/data/logos/AAPL/m.png"
-	if path != expected {
-		t.Errorf("expected path %s, got %s", expected, path)
+	sum := sha256.Sum256([]byte("AAPL"))
+	h := hex.EncodeToString(sum[:2])
+
+	tests := []struct {
+		layout   Layout
+		expected string
+	}{
+		{LayoutFlat, "/data/logos/AAPL/m.png"},
+		{LayoutPrefix, "/data/logos/AA/AAPL/m.png"},
+		{LayoutHash, "/data/logos/" + h[:2] + "/" + h[2:4] + "/AAPL/m.png"},
+	}
+
+	for _, tt := range tests {
+		fs := &FileSystem{baseDir: "/data/logos", layout: tt.layout}
+		if path := fs.LogoPath("AAPL", model.SizeM); path != tt.expected {
+			t.Errorf("%s: expected path %s, got %s", tt.layout, tt.expected, path)
+		}
+
+		// Case differences must not produce different paths.
+		if lower := fs.LogoPath("aapl", model.SizeM); lower != tt.expected {
+			t.Errorf("%s: expected %s for lower-case symbol, got %s", tt.layout, tt.expected, lower)
+		}
+	}
+}
+
+func TestFileSystem_RejectsInvalidSymbols(t *testing.T) {
+	tmpDir := t.TempDir()
+	fs, err := NewFileSystem(tmpDir, LayoutHash)
+	if err != nil {
+		t.Fatalf("creating filesystem: %v", err)
+	}
+
+	for _, symbol := range []string{"", "..", "../AAPL", "A/B"} {
+		if err := fs.Write(symbol, model.SizeM, []byte("x")); !errors.Is(err, model.ErrInvalidSymbol) {
+			t.Errorf("Write(%q): expected ErrInvalidSymbol, got %v", symbol, err)
+		}
+		if err := fs.DeleteSymbol(symbol); !errors.Is(err, model.ErrInvalidSymbol) {
+			t.Errorf("DeleteSymbol(%q): expected ErrInvalidSymbol, got %v", symbol, err)
+		}
+	}
+
+	// The base directory itself must survive a DeleteSymbol("..")-style attempt.
+	if _, err := os.Stat(tmpDir); err != nil {
+		t.Errorf("base directory was removed: %v", err)
+	}
+}
+
+func TestEncodeSymbol_RoundTrip(t *testing.T) {
+	tests := []struct {
+		symbol  string
+		encoded string
+	}{
+		{"AAPL", "AAPL"},
+		{"BRK.B", "BRK_B"},
+		{"BRK-B", "BRK-B"},
+		{"^GSPC", "%5EGSPC"},
+		{"..", "__"},
+		{"A/B", "A%2FB"},
+		{"É", "%C3%89"},
+		{"CON", "%43ON"},
+		{"COM1", "%43OM1"},
+	}
+
+	for _, tt := range tests {
+		got := encodeSymbol(tt.symbol)
+		if got != tt.encoded {
+			t.Errorf("encodeSymbol(%q) = %q, want %q", tt.symbol, got, tt.encoded)
+		}
+		if strings.ContainsAny(got, `./\`) {
+			t.Errorf("encodeSymbol(%q) = %q contains path characters", tt.symbol, got)
+		}
+		back, err := decodeSymbol(got)
+		if err != nil || back != tt.symbol {
+			t.Errorf("decodeSymbol(%q) = %q, %v; want %q", got, back, err, tt.symbol)
+		}
+	}
+}
+
+func TestFileSystem_MigrateLayout(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Logos written by older builds: flat raw and flat encoded names,
+	// plus one already stored with the prefix layout.
+	for _, dir := range []string{"BRK.B", "%5EGSPC", filepath.Join("AA", "AAPL")} {
+		legacy := filepath.Join(tmpDir, dir)
+		if err := os.MkdirAll(legacy, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(legacy, "m.png"), []byte("png"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fs, err := NewFileSystem(tmpDir, LayoutHash)
+	if err != nil {
+		t.Fatalf("creating filesystem: %v", err)
+	}
+
+	for i, want := range []int{3, 0} { // second run is a no-op
+		n, err := fs.MigrateLayout()
+		if err != nil {
+			t.Fatalf("run %d: %v", i, err)
+		}
+		if n != want {
+			t.Errorf("run %d: moved %d, want %d", i, n, want)
+		}
+	}
+
+	for _, symbol := range []string{"BRK.B", "^GSPC", "AAPL"} {
+		if !fs.Exists(symbol, model.SizeM) {
+			t.Errorf("expected migrated %s logo to be readable via the new layout", symbol)
+		}
+	}
+
+	// The emptied prefix shard is cleaned up.
+	if _, err := os.Stat(filepath.Join(tmpDir, "AA")); !os.IsNotExist(err) {
+		t.Errorf("expected empty shard directory to be removed, got %v", err)
+	}
+}
+
+func TestNewFileSystem_UnknownLayout(t *testing.T) {
+	if _, err := NewFileSystem(t.TempDir(), Layout("nested")); err == nil {
+		t.Error("expected error for unknown layout")
+	}
+}
+
+func TestFileSystem_Originals(t *testing.T) {
+	fs, err := NewFileSystem(t.TempDir(), LayoutHash)
+	if err != nil {
+		t.Fatalf("creating filesystem: %v", err)
+	}
+
+	data := []byte("<svg xmlns=\"http://www.w3.org/2000/svg\"/>")
+	hash, err := fs.WriteOriginal(data)
+	if err != nil {
+		t.Fatalf("WriteOriginal: %v", err)
+	}
+	if hash != HashOriginal(data) {
+		t.Errorf("expected the content hash, got %s", hash)
+	}
+	if again, err := fs.WriteOriginal(data); err != nil || again != hash {
+		t.Errorf("expected writing the same bytes to give the same hash, got %s (%v)", again, err)
+	}
+
+	got, err := fs.ReadOriginal(hash)
+	if err != nil || string(got) != string(data) {
+		t.Fatalf("ReadOriginal: %q (%v)", got, err)
+	}
+
+	if err := fs.DeleteOriginal(hash); err != nil {
+		t.Fatalf("DeleteOriginal: %v", err)
+	}
+	if _, err := fs.ReadOriginal(hash); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected os.ErrNotExist after delete, got %v", err)
+	}
+
+	// Hashes end up in paths, so anything else is refused
+	if _, err := fs.ReadOriginal("../../etc/passwd"); err == nil || errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected an invalid hash error, got %v", err)
 	}
 }
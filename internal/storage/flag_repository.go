@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// FlagRepository stores the feature flag overrides set through the admin
+// API. They live in the database rather than in memory so they survive a
+// restart and reach every instance sharing it.
+type FlagRepository interface {
+	List(ctx context.Context) (map[string]bool, error)
+	Set(ctx context.Context, name string, enabled bool) error
+	Delete(ctx context.Context, name string) error
+}
+
+type sqliteFlagRepository struct {
+	handles
+}
+
+// NewFlagRepository creates a new SQLite-backed FlagRepository.
+func NewFlagRepository(db *sqlx.DB, reader ...*sqlx.DB) FlagRepository {
+	return &sqliteFlagRepository{handles: newHandles(db, reader)}
+}
+
+// List returns every override, by flag name.
+func (r *sqliteFlagRepository) List(ctx context.Context) (map[string]bool, error) {
+	var rows []struct {
+		Name    string `db:"name"`
+		Enabled bool   `db:"enabled"`
+	}
+	if err := r.read.SelectContext(ctx, &rows, `SELECT name, enabled FROM flag_overrides`); err != nil {
+		return nil, fmt.Errorf("listing flag overrides: %w", err)
+	}
+	overrides := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		overrides[row.Name] = row.Enabled
+	}
+	return overrides, nil
+}
+
+// Set overrides a flag, replacing any earlier override.
+func (r *sqliteFlagRepository) Set(ctx context.Context, name string, enabled bool) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO flag_overrides (name, enabled) VALUES (?, ?)
+		ON CONFLICT(name) DO UPDATE SET enabled = excluded.enabled, updated_at = CURRENT_TIMESTAMP
+	`, name, enabled)
+	if err != nil {
+		return fmt.Errorf("overriding flag %s: %w", name, err)
+	}
+	return nil
+}
+
+// Delete removes a flag's override, so the configured value applies again.
+// Returns ErrNotFound if it had none.
+func (r *sqliteFlagRepository) Delete(ctx context.Context, name string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM flag_overrides WHERE name = ?`, name)
+	if err != nil {
+		return fmt.Errorf("clearing flag override %s: %w", name, err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking rows affected: %w", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestFlagRepository(t *testing.T) {
+	deps := setupTestDB(t)
+	ctx := context.Background()
+	repo := deps.flagRepo
+
+	if overrides, err := repo.List(ctx); err != nil || len(overrides) != 0 {
+		t.Fatalf("List before Set = %v, %v; want none", overrides, err)
+	}
+
+	if err := repo.Set(ctx, "on_demand_acquisition", true); err != nil {
+		t.Fatal(err)
+	}
+	// Setting again replaces the override.
+	if err := repo.Set(ctx, "on_demand_acquisition", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Set(ctx, "purge_deleted", true); err != nil {
+		t.Fatal(err)
+	}
+
+	overrides, err := repo.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(overrides) != 2 || overrides["on_demand_acquisition"] || !overrides["purge_deleted"] {
+		t.Errorf("unexpected overrides: %v", overrides)
+	}
+
+	if err := repo.Delete(ctx, "on_demand_acquisition"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Delete(ctx, "on_demand_acquisition"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("second Delete: expected ErrNotFound, got %v", err)
+	}
+	if overrides, _ := repo.List(ctx); len(overrides) != 1 {
+		t.Errorf("expected one override left, got %v", overrides)
+	}
+}
@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+// InstrumentRepository stores the symbol → company name reference table.
+type InstrumentRepository interface {
+	GetBySymbol(ctx context.Context, symbol string) (*model.Instrument, error)
+	Upsert(ctx context.Context, instrument *model.Instrument) error
+	Count(ctx context.Context) (int64, error)
+}
+
+type sqliteInstrumentRepository struct {
+	handles
+}
+
+// NewInstrumentRepository creates a new SQLite-backed InstrumentRepository.
+func NewInstrumentRepository(db *sqlx.DB, reader ...*sqlx.DB) InstrumentRepository {
+	return &sqliteInstrumentRepository{handles: newHandles(db, reader)}
+}
+
+func (r *sqliteInstrumentRepository) GetBySymbol(ctx context.Context, symbol string) (*model.Instrument, error) {
+	var inst model.Instrument
+	err := r.read.GetContext(ctx, &inst, "SELECT * FROM instruments WHERE symbol = ?", symbol)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting instrument %s: %w", symbol, err)
+	}
+	return &inst, nil
+}
+
+// Upsert inserts an instrument or refreshes its name, exchange and source.
+func (r *sqliteInstrumentRepository) Upsert(ctx context.Context, instrument *model.Instrument) error {
+	_, err := r.db.NamedExecContext(ctx, `
+		INSERT INTO instruments (symbol, name, exchange, source)
+		VALUES (:symbol, :name, :exchange, :source)
+		ON CONFLICT(symbol) DO UPDATE SET
+			name = excluded.name,
+			exchange = excluded.exchange,
+			source = excluded.source,
+			updated_at = CURRENT_TIMESTAMP
+	`, instrument)
+	if err != nil {
+		return fmt.Errorf("upserting instrument %s: %w", instrument.Symbol, err)
+	}
+	return nil
+}
+
+func (r *sqliteInstrumentRepository) Count(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.read.GetContext(ctx, &count, "SELECT COUNT(*) FROM instruments")
+	return count, err
+}
@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// DefaultListLimit is the page size when ListOptions.Limit is zero.
+const DefaultListLimit = 100
+
+var (
+	// ErrInvalidCursor is returned for a cursor that doesn't parse, or was
+	// issued by a different sort.
+	ErrInvalidCursor = errors.New("invalid cursor")
+	// ErrInvalidSort is returned for a sort the list doesn't support.
+	ErrInvalidSort = errors.New("unsupported sort")
+)
+
+// ListOptions selects one page of a list. Lists are paginated by keyset,
+// not OFFSET: the cursor holds the last row's sort key and id, and the next
+// page starts right after it, so page 1000 costs what page 1 does and rows
+// inserted meanwhile never shift a page.
+type ListOptions struct {
+	Cursor string // The previous page's next cursor; empty for the first page
+	Limit  int    // Page size; DefaultListLimit if zero
+	Sort   string // One of the list's sorts, "-" prefixed for descending; empty for its default
+}
+
+// cursor is what a next cursor encodes. Sort is checked on decoding, so a
+// cursor can't be replayed against another order.
+type cursor struct {
+	Sort string `json:"s"`
+	Key  string `json:"k"`
+	ID   int64  `json:"i"`
+}
+
+// encodeCursor renders a cursor as URL-safe base64 of its JSON: opaque to
+// clients, and the same string for the same row every time.
+func encodeCursor(c cursor) string {
+	data, _ := json.Marshal(c) // Go note: a struct of strings and ints always marshals.
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (cursor, error) {
+	var c cursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// sortKey is one order a list supports.
+type sortKey[T any] struct {
+	expr  string         // SQL expression ordered on
+	value func(T) string // The expression's value for a row, as SQL compares it
+}
+
+// pager runs a list query a page at a time. Rows are ordered by the sort
+// key, then by id, which makes the order total: two rows with the same key
+// still have a fixed place, so none is skipped or repeated across pages.
+//
+// Go note: pager is generic over the row type — the same code pages logos
+// and LLM calls.
+type pager[T any] struct {
+	sorts map[string]sortKey[T] // By name; "-name" sorts descending
+	id    func(T) int64
+}
+
+// page selects one page of selectFrom ("SELECT ... FROM table") filtered by
+// conds, and returns the cursor of the next one, or "" on the last page.
+func (p pager[T]) page(ctx context.Context, db *sqlx.DB, selectFrom string, conds []string, args []any, opts ListOptions) ([]T, string, error) {
+	name, desc := strings.CutPrefix(opts.Sort, "-")
+	key, ok := p.sorts[name]
+	if !ok {
+		return nil, "", fmt.Errorf("%w: %q", ErrInvalidSort, opts.Sort)
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+	cmp, dir := ">", "ASC"
+	if desc {
+		cmp, dir = "<", "DESC"
+	}
+
+	// Go note: slices.Clip makes append copy instead of writing into
+	// spare capacity of the caller's slices.
+	conds, args = slices.Clip(conds), slices.Clip(args)
+	if opts.Cursor != "" {
+		after, err := decodeCursor(opts.Cursor)
+		if err != nil || after.Sort != opts.Sort {
+			return nil, "", ErrInvalidCursor
+		}
+		conds = append(conds, fmt.Sprintf("(%[1]s %[2]s ? OR (%[1]s = ? AND id %[2]s ?))", key.expr, cmp))
+		args = append(args, after.Key, after.Key, after.ID)
+	}
+
+	query := selectFrom
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	// One row past the page tells whether there's another page.
+	query += fmt.Sprintf(" ORDER BY %s %s, id %s LIMIT ?", key.expr, dir, dir)
+	args = append(args, limit+1)
+
+	var items []T
+	if err := db.SelectContext(ctx, &items, query, args...); err != nil {
+		return nil, "", err
+	}
+	if len(items) <= limit {
+		return items, "", nil
+	}
+	items = items[:limit]
+	last := items[limit-1]
+	return items, encodeCursor(cursor{Sort: opts.Sort, Key: key.value(last), ID: p.id(last)}), nil
+}
+
+// timeKey sorts on a DATETIME column. SQLite stores those as text, written
+// in more than one format (CURRENT_TIMESTAMP's, and the driver's for Go
+// times), so both the order and the cursor use datetime()'s normalized
+// UTC form.
+func timeKey[T any](column string, value func(T) time.Time) sortKey[T] {
+	return sortKey[T]{
+		expr:  "datetime(" + column + ")",
+		value: func(row T) string { return value(row).UTC().Format(time.DateTime) },
+	}
+}
+
+// WithSort returns opts with sort filled in if none was asked for.
+func (opts ListOptions) WithSort(sort string) ListOptions {
+	if opts.Sort == "" {
+		opts.Sort = sort
+	}
+	return opts
+}
@@ -0,0 +1,136 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+func TestLogoRepository_ListPages(t *testing.T) {
+	deps := setupTestDB(t)
+	ctx := context.Background()
+
+	// Created within the same second, so created_at ties and only the id
+	// keeps the order total.
+	symbols := []string{"MSFT", "AAPL", "TSLA", "GOOG", "AMZN"}
+	for _, symbol := range symbols {
+		if err := deps.logoRepo.Create(ctx, &model.Logo{Symbol: symbol, Source: "test", Status: model.StatusProcessed}); err != nil {
+			t.Fatalf("creating logo %s: %v", symbol, err)
+		}
+	}
+	if err := deps.logoRepo.Create(ctx, &model.Logo{Symbol: "NVDA", Source: "test", Status: model.StatusFailed}); err != nil {
+		t.Fatalf("creating logo NVDA: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		status model.LogoStatus
+		sort   string
+		want   []string
+	}{
+		{"by symbol", model.StatusProcessed, "", []string{"AAPL", "AMZN", "GOOG", "MSFT", "TSLA"}},
+		{"by symbol descending", model.StatusProcessed, "-symbol", []string{"TSLA", "MSFT", "GOOG", "AMZN", "AAPL"}},
+		{"by created_at", model.StatusProcessed, "created_at", symbols},
+		{"by created_at descending", model.StatusProcessed, "-created_at", []string{"AMZN", "GOOG", "TSLA", "AAPL", "MSFT"}},
+		{"every status", "", "symbol", []string{"AAPL", "AMZN", "GOOG", "MSFT", "NVDA", "TSLA"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Two per page: walk the cursors to the end.
+			var got []string
+			opts := ListOptions{Limit: 2, Sort: tt.sort}
+			for pages := 0; ; pages++ {
+				if pages > len(tt.want) {
+					t.Fatal("cursors never reached the last page")
+				}
+				logos, next, err := deps.logoRepo.List(ctx, tt.status, opts)
+				if err != nil {
+					t.Fatalf("List: %v", err)
+				}
+				for _, l := range logos {
+					got = append(got, l.Symbol)
+				}
+				if next == "" {
+					break
+				}
+				opts.Cursor = next
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestLogoRepository_ListInvalidOptions(t *testing.T) {
+	deps := setupTestDB(t)
+	ctx := context.Background()
+	for _, symbol := range []string{"AAPL", "MSFT"} {
+		if err := deps.logoRepo.Create(ctx, &model.Logo{Symbol: symbol, Source: "test", Status: model.StatusPending}); err != nil {
+			t.Fatalf("creating logo %s: %v", symbol, err)
+		}
+	}
+	_, next, err := deps.logoRepo.List(ctx, "", ListOptions{Limit: 1})
+	if err != nil || next == "" {
+		t.Fatalf("List: next = %q, err = %v", next, err)
+	}
+
+	tests := []struct {
+		name string
+		opts ListOptions
+		want error
+	}{
+		{"unknown sort", ListOptions{Sort: "company_name"}, ErrInvalidSort},
+		{"garbage cursor", ListOptions{Cursor: "not a cursor"}, ErrInvalidCursor},
+		{"cursor from another sort", ListOptions{Cursor: next, Sort: "-symbol"}, ErrInvalidCursor},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := deps.logoRepo.List(ctx, "", tt.opts); !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLLMCallRepository_List(t *testing.T) {
+	deps := setupTestDB(t)
+	ctx := context.Background()
+
+	for _, symbol := range []string{"AAPL", "MSFT", "AAPL"} {
+		if err := deps.llmCallRepo.Create(ctx, &model.LLMCall{Symbol: symbol, Provider: "test", Model: "m"}); err != nil {
+			t.Fatalf("creating llm call: %v", err)
+		}
+	}
+
+	// Newest first by default.
+	calls, next, err := deps.llmCallRepo.List(ctx, "AAPL", ListOptions{Limit: 1})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(calls) != 1 || calls[0].ID != 3 || next == "" {
+		t.Fatalf("first page = %+v, next = %q", calls, next)
+	}
+	calls, next, err = deps.llmCallRepo.List(ctx, "AAPL", ListOptions{Limit: 1, Cursor: next})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(calls) != 1 || calls[0].ID != 1 || next != "" {
+		t.Errorf("second page = %+v, next = %q", calls, next)
+	}
+
+	all, _, err := deps.llmCallRepo.List(ctx, "", ListOptions{})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(all) != 3 {
+		t.Errorf("expected 3 calls, got %d", len(all))
+	}
+}
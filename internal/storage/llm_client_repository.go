@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+// LLMClientRepository stores the LLM clients an admin disabled. Like the
+// flag overrides, they live in the database so they survive a restart and
+// reach every instance sharing it.
+type LLMClientRepository interface {
+	// Disable switches a client off. Disabling it again updates the reason
+	// but keeps the original date.
+	Disable(ctx context.Context, provider, reason string) error
+	// Enable switches a client back on. Returns ErrNotFound if it wasn't off.
+	Enable(ctx context.Context, provider string) error
+	ListDisabled(ctx context.Context) ([]model.DisabledLLMClient, error)
+}
+
+type sqliteLLMClientRepository struct {
+	handles
+}
+
+// NewLLMClientRepository creates a new SQLite-backed LLMClientRepository.
+func NewLLMClientRepository(db *sqlx.DB, reader ...*sqlx.DB) LLMClientRepository {
+	return &sqliteLLMClientRepository{handles: newHandles(db, reader)}
+}
+
+func (r *sqliteLLMClientRepository) Disable(ctx context.Context, provider, reason string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO disabled_llm_clients (provider, reason) VALUES (?, ?)
+		ON CONFLICT(provider) DO UPDATE SET reason = excluded.reason
+	`, provider, reason)
+	if err != nil {
+		return fmt.Errorf("disabling LLM client %s: %w", provider, err)
+	}
+	return nil
+}
+
+func (r *sqliteLLMClientRepository) Enable(ctx context.Context, provider string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM disabled_llm_clients WHERE provider = ?`, provider)
+	if err != nil {
+		return fmt.Errorf("enabling LLM client %s: %w", provider, err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking rows affected: %w", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListDisabled returns every disabled client, by provider name.
+func (r *sqliteLLMClientRepository) ListDisabled(ctx context.Context) ([]model.DisabledLLMClient, error) {
+	var disabled []model.DisabledLLMClient
+	if err := r.read.SelectContext(ctx, &disabled,
+		`SELECT provider, reason, disabled_at FROM disabled_llm_clients ORDER BY provider`); err != nil {
+		return nil, fmt.Errorf("listing disabled LLM clients: %w", err)
+	}
+	return disabled, nil
+}
@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestLLMClientRepository(t *testing.T) {
+	deps := setupTestDB(t)
+	ctx := context.Background()
+	repo := deps.llmClientRepo
+
+	if disabled, err := repo.ListDisabled(ctx); err != nil || len(disabled) != 0 {
+		t.Fatalf("ListDisabled before Disable = %v, %v; want none", disabled, err)
+	}
+
+	if err := repo.Disable(ctx, "openai", "budget freeze"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Disable(ctx, "anthropic", "outage"); err != nil {
+		t.Fatal(err)
+	}
+	// Disabling again updates the reason.
+	if err := repo.Disable(ctx, "anthropic", "outage, see status page"); err != nil {
+		t.Fatal(err)
+	}
+
+	disabled, err := repo.ListDisabled(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(disabled) != 2 || disabled[0].Provider != "anthropic" || disabled[0].Reason != "outage, see status page" ||
+		disabled[0].DisabledAt.IsZero() || disabled[1].Provider != "openai" {
+		t.Errorf("unexpected disabled clients: %+v", disabled)
+	}
+
+	if err := repo.Enable(ctx, "anthropic"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Enable(ctx, "anthropic"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("second Enable: expected ErrNotFound, got %v", err)
+	}
+	if disabled, _ := repo.ListDisabled(ctx); len(disabled) != 1 {
+		t.Errorf("expected one disabled client left, got %+v", disabled)
+	}
+}
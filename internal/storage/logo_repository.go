@@ -5,6 +5,8 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"slices"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 
@@ -16,6 +18,10 @@ import (
 // Callers check with errors.Is(err, ErrNotFound).
 var ErrNotFound = errors.New("logo not found")
 
+// ErrLogoDeleted is returned by GetBySymbol for a soft-deleted logo. It
+// wraps ErrNotFound: to most callers a deleted logo simply isn't there.
+var ErrLogoDeleted = fmt.Errorf("%w: deleted", ErrNotFound)
+
 // LogoRepository defines the interface for logo persistence.
 // Go interfaces are implicit — any struct that has these methods satisfies it.
 // This makes testing easy: you can create a mock that implements this interface
@@ -25,43 +31,72 @@ type LogoRepository interface {
 	Create(ctx context.Context, logo *model.Logo) error
 	Update(ctx context.Context, logo *model.Logo) error
 	SetSizeAvailable(ctx context.Context, symbol string, size model.LogoSize) error
+	SetSizeUnavailable(ctx context.Context, symbol string, size model.LogoSize) error
+	SetRendition(ctx context.Context, symbol string, rendition model.Rendition) error
+	ListRenditions(ctx context.Context, symbol string) ([]model.Rendition, error)
 	SetStatus(ctx context.Context, symbol string, status model.LogoStatus, errMsg string) error
+	SetQuality(ctx context.Context, symbol string, score int, notes string) error
+	SetPHash(ctx context.Context, symbol, phash string) error
+	SetOriginalHash(ctx context.Context, symbol, hash string) error
+	SetValidators(ctx context.Context, symbol, etag, lastModified string) error
+	GetValidators(ctx context.Context, symbol, url string) (etag, lastModified string, err error)
+	MarkNotFound(ctx context.Context, symbol string, nextCheck time.Time) error
+	MarkFailed(ctx context.Context, symbol, errMsg string, nextCheck time.Time) error
 	Count(ctx context.Context) (int64, error)
 	CountByStatus(ctx context.Context, status model.LogoStatus) (int64, error)
-	ListPending(ctx context.Context, limit int) ([]model.Logo, error)
+	List(ctx context.Context, status model.LogoStatus, opts ListOptions) ([]model.Logo, string, error)
+	ListPending(ctx context.Context, opts ListOptions) ([]model.Logo, string, error)
+	ListByStatus(ctx context.Context, status model.LogoStatus, limit int) ([]model.Logo, error)
+	ListByQuality(ctx context.Context, maxScore, limit int) ([]model.Logo, error)
+	ListHashed(ctx context.Context) ([]model.Logo, error)
+	ListUnnamed(ctx context.Context) ([]model.Logo, error)
+	ListStates(ctx context.Context) ([]model.Logo, error)
+	SoftDelete(ctx context.Context, symbol string, at time.Time) error
+	Restore(ctx context.Context, symbol string, deletedAfter time.Time) error
+	ListDeleted(ctx context.Context, deletedBefore time.Time) ([]model.Logo, error)
+	Purge(ctx context.Context, symbol string) error
 }
 
 // sqliteLogoRepository is the SQLite implementation of LogoRepository.
 // The struct is unexported (lowercase first letter) — only the interface is public.
 // This is a common Go pattern: export the interface, hide the implementation.
 type sqliteLogoRepository struct {
-	db *sqlx.DB
+	handles
 }
 
-// NewLogoRepository creates a new SQLite-backed LogoRepository.
-func NewLogoRepository(db *sqlx.DB) LogoRepository {
-	return &sqliteLogoRepository{db: db}
+// NewLogoRepository creates a new SQLite-backed LogoRepository. Like the
+// other repositories, it reads from reader when one is given (see handles).
+//
+// Soft-deleted logos (deleted_at set) are left out of every lookup, count
+// and list; only ListDeleted, Restore and Purge see them.
+func NewLogoRepository(db *sqlx.DB, reader ...*sqlx.DB) LogoRepository {
+	return &sqliteLogoRepository{handles: newHandles(db, reader)}
 }
 
 func (r *sqliteLogoRepository) GetBySymbol(ctx context.Context, symbol string) (*model.Logo, error) {
 	var logo model.Logo
 	// sqlx.GetContext scans the result row directly into the struct using `db:` tags.
 	// context.Context carries request-scoped data like deadlines and cancellation.
-	err := r.db.GetContext(ctx, &logo, "SELECT * FROM logos WHERE symbol = ?", symbol)
+	err := r.read.GetContext(ctx, &logo, "SELECT * FROM logos WHERE symbol = ?", symbol)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, ErrNotFound
 	}
 	if err != nil {
 		return nil, fmt.Errorf("getting logo by symbol %s: %w", symbol, err)
 	}
+	// Told apart from a missing row: the symbol is taken until it's purged,
+	// so the caller mustn't create it again.
+	if logo.DeletedAt != nil {
+		return nil, fmt.Errorf("%w: %s", ErrLogoDeleted, symbol)
+	}
 	return &logo, nil
 }
 
 func (r *sqliteLogoRepository) Create(ctx context.Context, logo *model.Logo) error {
 	// NamedExecContext uses the struct's `db:` tags to map fields to :named placeholders.
 	result, err := r.db.NamedExecContext(ctx, `
-		INSERT INTO logos (symbol, company_name, source, original_url, status)
-		VALUES (:symbol, :company_name, :source, :original_url, :status)
+		INSERT INTO logos (symbol, company_name, source, original_url, confidence, license, attribution, whiten_background, status)
+		VALUES (:symbol, :company_name, :source, :original_url, :confidence, :license, :attribution, :whiten_background, :status)
 	`, logo)
 	if err != nil {
 		return fmt.Errorf("creating logo: %w", err)
@@ -79,8 +114,14 @@ func (r *sqliteLogoRepository) Update(ctx context.Context, logo *model.Logo) err
 	_, err := r.db.NamedExecContext(ctx, `
 		UPDATE logos SET
 			company_name = :company_name,
+			website = :website,
 			source = :source,
 			original_url = :original_url,
+			confidence = :confidence,
+			license = :license,
+			attribution = :attribution,
+			whiten_background = :whiten_background,
+			default_bg = :default_bg,
 			has_xs = :has_xs,
 			has_s = :has_s,
 			has_m = :has_m,
@@ -88,6 +129,7 @@ func (r *sqliteLogoRepository) Update(ctx context.Context, logo *model.Logo) err
 			has_xl = :has_xl,
 			status = :status,
 			error_message = :error_message,
+			next_check_at = :next_check_at,
 			updated_at = CURRENT_TIMESTAMP
 		WHERE id = :id
 	`, logo)
@@ -97,25 +139,76 @@ func (r *sqliteLogoRepository) Update(ctx context.Context, logo *model.Logo) err
 	return nil
 }
 
+// sizeColumns maps each size to its has_* column.
+var sizeColumns = map[model.LogoSize]string{
+	model.SizeXS: "has_xs",
+	model.SizeS:  "has_s",
+	model.SizeM:  "has_m",
+	model.SizeL:  "has_l",
+	model.SizeXL: "has_xl",
+}
+
 // SetSizeAvailable marks a specific size as available for a logo.
-// This uses a technique to dynamically set a column — but since SQL column names
-// can't be parameterized, we validate the column name via a map.
 func (r *sqliteLogoRepository) SetSizeAvailable(ctx context.Context, symbol string, size model.LogoSize) error {
+	return r.setSizeFlag(ctx, symbol, size, true)
+}
+
+// SetSizeUnavailable clears a size's flag, e.g. when its file turned out to
+// be missing, and forgets its rendition.
+func (r *sqliteLogoRepository) SetSizeUnavailable(ctx context.Context, symbol string, size model.LogoSize) error {
+	if err := r.setSizeFlag(ctx, symbol, size, false); err != nil {
+		return err
+	}
+	_, err := r.db.ExecContext(ctx, `DELETE FROM logo_renditions WHERE symbol = ? AND size = ?`, symbol, size)
+	if err != nil {
+		return fmt.Errorf("deleting rendition %s for %s: %w", size, symbol, err)
+	}
+	return nil
+}
+
+// SetRendition records what a stored size looks like, replacing what was
+// recorded for it before.
+func (r *sqliteLogoRepository) SetRendition(ctx context.Context, symbol string, rendition model.Rendition) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO logo_renditions (symbol, size, width, height, bytes)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(symbol, size) DO UPDATE SET
+			width = excluded.width,
+			height = excluded.height,
+			bytes = excluded.bytes,
+			updated_at = CURRENT_TIMESTAMP
+	`, symbol, rendition.Size, rendition.Width, rendition.Height, rendition.Bytes)
+	if err != nil {
+		return fmt.Errorf("setting rendition %s for %s: %w", rendition.Size, symbol, err)
+	}
+	return nil
+}
+
+// ListRenditions returns the recorded renditions of a symbol, smallest first.
+func (r *sqliteLogoRepository) ListRenditions(ctx context.Context, symbol string) ([]model.Rendition, error) {
+	renditions := []model.Rendition{}
+	err := r.read.SelectContext(ctx, &renditions,
+		`SELECT size, width, height, bytes FROM logo_renditions WHERE symbol = ?`, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("listing renditions for %s: %w", symbol, err)
+	}
+	slices.SortFunc(renditions, func(a, b model.Rendition) int {
+		return model.SizePixels[a.Size] - model.SizePixels[b.Size]
+	})
+	return renditions, nil
+}
+
+// setSizeFlag uses a technique to dynamically set a column — but since SQL
+// column names can't be parameterized, we validate the column name via a map.
+func (r *sqliteLogoRepository) setSizeFlag(ctx context.Context, symbol string, size model.LogoSize, available bool) error {
 	// Map size to column name (prevents SQL injection since we control the values)
-	columnMap := map[model.LogoSize]string{
-		model.SizeXS: "has_xs",
-		model.SizeS:  "has_s",
-		model.SizeM:  "has_m",
-		model.SizeL:  "has_l",
-		model.SizeXL: "has_xl",
-	}
-	col, ok := columnMap[size]
+	col, ok := sizeColumns[size]
 	if !ok {
 		return fmt.Errorf("invalid size: %s", size)
 	}
 
-	query := fmt.Sprintf("UPDATE logos SET %s = 1, updated_at = CURRENT_TIMESTAMP WHERE symbol = ?", col)
-	_, err := r.db.ExecContext(ctx, query, symbol)
+	query := fmt.Sprintf("UPDATE logos SET %s = ?, updated_at = CURRENT_TIMESTAMP WHERE symbol = ?", col)
+	_, err := r.db.ExecContext(ctx, query, available, symbol)
 	if err != nil {
 		return fmt.Errorf("setting size %s for %s: %w", size, symbol, err)
 	}
@@ -139,48 +232,322 @@ func (r *sqliteLogoRepository) SetStatus(ctx context.Context, symbol string, sta
 	return nil
 }
 
+// SetQuality stores the quality score computed after processing.
+func (r *sqliteLogoRepository) SetQuality(ctx context.Context, symbol string, score int, notes string) error {
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE logos SET quality_score = ?, quality_notes = ?, updated_at = CURRENT_TIMESTAMP WHERE symbol = ?",
+		score, notes, symbol)
+	if err != nil {
+		return fmt.Errorf("setting quality for %s: %w", symbol, err)
+	}
+	return nil
+}
+
+// SetPHash stores the perceptual hash of a processed logo.
+func (r *sqliteLogoRepository) SetPHash(ctx context.Context, symbol, phash string) error {
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE logos SET phash = ?, updated_at = CURRENT_TIMESTAMP WHERE symbol = ?",
+		phash, symbol)
+	if err != nil {
+		return fmt.Errorf("setting phash for %s: %w", symbol, err)
+	}
+	return nil
+}
+
+// SetOriginalHash records which stored original a logo was rendered from.
+func (r *sqliteLogoRepository) SetOriginalHash(ctx context.Context, symbol, hash string) error {
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE logos SET original_hash = ?, updated_at = CURRENT_TIMESTAMP WHERE symbol = ?",
+		hash, symbol)
+	if err != nil {
+		return fmt.Errorf("setting original hash for %s: %w", symbol, err)
+	}
+	return nil
+}
+
+// SetValidators records the ETag and Last-Modified of the download a logo
+// was processed from.
+func (r *sqliteLogoRepository) SetValidators(ctx context.Context, symbol, etag, lastModified string) error {
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE logos SET etag = ?, last_modified = ? WHERE symbol = ?",
+		etag, lastModified, symbol)
+	if err != nil {
+		return fmt.Errorf("setting validators for %s: %w", symbol, err)
+	}
+	return nil
+}
+
+// GetValidators returns the validators recorded for a processed logo
+// downloaded from url. Anything else — another URL, a logo that's failed,
+// rejected or gone — has none, so the file is downloaded in full: a 304
+// for a logo we no longer have would leave it missing.
+func (r *sqliteLogoRepository) GetValidators(ctx context.Context, symbol, url string) (string, string, error) {
+	var v struct {
+		ETag         string `db:"etag"`
+		LastModified string `db:"last_modified"`
+	}
+	err := r.read.GetContext(ctx, &v,
+		"SELECT etag, last_modified FROM logos WHERE symbol = ? AND original_url = ? AND status = ?",
+		symbol, url, model.StatusProcessed)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("getting validators for %s: %w", symbol, err)
+	}
+	return v.ETag, v.LastModified, nil
+}
+
+// MarkNotFound records that no provider has a logo for the symbol, creating
+// the record if there's none, and schedules the next look at nextCheck.
+//
+// Go note: ON CONFLICT ... DO UPDATE is SQLite's upsert — one statement, so a
+// concurrent Create can't slip in between a lookup and an insert.
+func (r *sqliteLogoRepository) MarkNotFound(ctx context.Context, symbol string, nextCheck time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO logos (symbol, status, next_check_at) VALUES (?, ?, ?)
+		ON CONFLICT(symbol) DO UPDATE SET
+			status = excluded.status,
+			next_check_at = excluded.next_check_at,
+			error_message = NULL,
+			updated_at = CURRENT_TIMESTAMP
+	`, symbol, model.StatusNotFound, nextCheck.UTC())
+	if err != nil {
+		return fmt.Errorf("marking %s not found: %w", symbol, err)
+	}
+	return nil
+}
+
+// MarkFailed is SetStatus to failed that also schedules the next look at
+// nextCheck, for an image that was found but rejected. Like MarkNotFound,
+// it creates the record if there's none: a download rejected by every
+// provider never got one.
+func (r *sqliteLogoRepository) MarkFailed(ctx context.Context, symbol, errMsg string, nextCheck time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO logos (symbol, status, error_message, next_check_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(symbol) DO UPDATE SET
+			status = excluded.status,
+			error_message = excluded.error_message,
+			next_check_at = excluded.next_check_at,
+			updated_at = CURRENT_TIMESTAMP
+	`, symbol, model.StatusFailed, errMsg, nextCheck.UTC())
+	if err != nil {
+		return fmt.Errorf("marking %s failed: %w", symbol, err)
+	}
+	return nil
+}
+
 func (r *sqliteLogoRepository) Count(ctx context.Context) (int64, error) {
 	var count int64
-	err := r.db.GetContext(ctx, &count, "SELECT COUNT(*) FROM logos")
+	err := r.read.GetContext(ctx, &count, "SELECT COUNT(*) FROM logos WHERE deleted_at IS NULL")
 	return count, err
 }
 
 func (r *sqliteLogoRepository) CountByStatus(ctx context.Context, status model.LogoStatus) (int64, error) {
 	var count int64
-	err := r.db.GetContext(ctx, &count, "SELECT COUNT(*) FROM logos WHERE status = ?", status)
+	err := r.read.GetContext(ctx, &count, "SELECT COUNT(*) FROM logos WHERE status = ? AND deleted_at IS NULL", status)
 	return count, err
 }
 
-func (r *sqliteLogoRepository) ListPending(ctx context.Context, limit int) ([]model.Logo, error) {
+// logoPager pages logos by symbol, created_at or updated_at.
+var logoPager = pager[model.Logo]{
+	sorts: map[string]sortKey[model.Logo]{
+		"symbol":     {expr: "symbol", value: func(l model.Logo) string { return l.Symbol }},
+		"created_at": timeKey("created_at", func(l model.Logo) time.Time { return l.CreatedAt }),
+		"updated_at": timeKey("updated_at", func(l model.Logo) time.Time { return l.UpdatedAt }),
+	},
+	id: func(l model.Logo) int64 { return l.ID },
+}
+
+// List returns a page of logos, in the given status or all of them if
+// status is empty, by symbol unless opts says otherwise, and the cursor of
+// the next page.
+func (r *sqliteLogoRepository) List(ctx context.Context, status model.LogoStatus, opts ListOptions) ([]model.Logo, string, error) {
+	conds := []string{"deleted_at IS NULL"}
+	var args []any
+	if status != "" {
+		conds, args = append(conds, "status = ?"), append(args, status)
+	}
+	logos, next, err := logoPager.page(ctx, r.read, "SELECT * FROM logos", conds, args, opts.WithSort("symbol"))
+	if err != nil {
+		return nil, "", fmt.Errorf("listing logos: %w", err)
+	}
+	return logos, next, nil
+}
+
+// ListPending returns a page of pending logos, oldest first unless opts
+// says otherwise.
+func (r *sqliteLogoRepository) ListPending(ctx context.Context, opts ListOptions) ([]model.Logo, string, error) {
+	logos, next, err := logoPager.page(ctx, r.read, "SELECT * FROM logos",
+		[]string{"status = ?", "deleted_at IS NULL"}, []any{model.StatusPending}, opts.WithSort("created_at"))
+	if err != nil {
+		return nil, "", fmt.Errorf("listing pending logos: %w", err)
+	}
+	return logos, next, nil
+}
+
+// ListByStatus returns logos in the given status, most recently updated first.
+func (r *sqliteLogoRepository) ListByStatus(ctx context.Context, status model.LogoStatus, limit int) ([]model.Logo, error) {
+	var logos []model.Logo
+	err := r.read.SelectContext(ctx, &logos,
+		"SELECT * FROM logos WHERE status = ? AND deleted_at IS NULL ORDER BY updated_at DESC LIMIT ?",
+		status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("listing %s logos: %w", status, err)
+	}
+	return logos, nil
+}
+
+// ListByQuality returns scored logos at or below maxScore, worst first —
+// the order curators work through them in. Unscored logos are left out.
+func (r *sqliteLogoRepository) ListByQuality(ctx context.Context, maxScore, limit int) ([]model.Logo, error) {
+	var logos []model.Logo
+	err := r.read.SelectContext(ctx, &logos,
+		"SELECT * FROM logos WHERE quality_score IS NOT NULL AND quality_score <= ? AND deleted_at IS NULL ORDER BY quality_score ASC, symbol ASC LIMIT ?",
+		maxScore, limit)
+	if err != nil {
+		return nil, fmt.Errorf("listing logos by quality: %w", err)
+	}
+	return logos, nil
+}
+
+// ListHashed returns every served logo that has a perceptual hash.
+func (r *sqliteLogoRepository) ListHashed(ctx context.Context) ([]model.Logo, error) {
+	var logos []model.Logo
+	err := r.read.SelectContext(ctx, &logos,
+		"SELECT * FROM logos WHERE status = ? AND phash != '' AND deleted_at IS NULL ORDER BY symbol",
+		model.StatusProcessed)
+	if err != nil {
+		return nil, fmt.Errorf("listing hashed logos: %w", err)
+	}
+	return logos, nil
+}
+
+// ListUnnamed returns every logo without a company name, in any status.
+func (r *sqliteLogoRepository) ListUnnamed(ctx context.Context) ([]model.Logo, error) {
+	var logos []model.Logo
+	err := r.read.SelectContext(ctx, &logos,
+		"SELECT * FROM logos WHERE company_name = '' AND deleted_at IS NULL ORDER BY symbol")
+	if err != nil {
+		return nil, fmt.Errorf("listing unnamed logos: %w", err)
+	}
+	return logos, nil
+}
+
+// ListStates returns every logo with only what serving needs set: symbol,
+// status, the has_* flags, updated_at, next_check_at and default_bg (see
+// Catalog).
+func (r *sqliteLogoRepository) ListStates(ctx context.Context) ([]model.Logo, error) {
+	var logos []model.Logo
+	err := r.read.SelectContext(ctx, &logos, `
+		SELECT symbol, status, has_xs, has_s, has_m, has_l, has_xl, updated_at, next_check_at, default_bg
+		FROM logos WHERE deleted_at IS NULL ORDER BY symbol
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("listing logo states: %w", err)
+	}
+	return logos, nil
+}
+
+// SoftDelete marks a logo deleted at the given time. Its record, files and
+// symbol are kept until Purge. Returns ErrNotFound if there's no such logo,
+// or it was already deleted.
+func (r *sqliteLogoRepository) SoftDelete(ctx context.Context, symbol string, at time.Time) error {
+	result, err := r.db.ExecContext(ctx,
+		"UPDATE logos SET deleted_at = ?, updated_at = CURRENT_TIMESTAMP WHERE symbol = ? AND deleted_at IS NULL",
+		at.UTC(), symbol)
+	if err != nil {
+		return fmt.Errorf("deleting logo %s: %w", symbol, err)
+	}
+	return requireRow(result, symbol)
+}
+
+// Restore undoes SoftDelete for a logo deleted after deletedAfter. Returns
+// ErrNotFound if the logo isn't deleted, or was deleted too long ago.
+func (r *sqliteLogoRepository) Restore(ctx context.Context, symbol string, deletedAfter time.Time) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE logos SET deleted_at = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE symbol = ? AND deleted_at IS NOT NULL AND datetime(deleted_at) > datetime(?)
+	`, symbol, deletedAfter.UTC())
+	if err != nil {
+		return fmt.Errorf("restoring logo %s: %w", symbol, err)
+	}
+	return requireRow(result, symbol)
+}
+
+// ListDeleted returns the logos deleted before deletedBefore, oldest first:
+// the ones Purge is due for.
+func (r *sqliteLogoRepository) ListDeleted(ctx context.Context, deletedBefore time.Time) ([]model.Logo, error) {
 	var logos []model.Logo
-	err := r.db.SelectContext(ctx, &logos,
-		"SELECT * FROM logos WHERE status = ? ORDER BY created_at ASC LIMIT ?",
-		model.StatusPending, limit)
+	err := r.read.SelectContext(ctx, &logos,
+		"SELECT * FROM logos WHERE deleted_at IS NOT NULL AND datetime(deleted_at) <= datetime(?) ORDER BY deleted_at",
+		deletedBefore.UTC())
 	if err != nil {
-		return nil, fmt.Errorf("listing pending logos: %w", err)
+		return nil, fmt.Errorf("listing deleted logos: %w", err)
 	}
 	return logos, nil
 }
 
+// Purge removes a soft-deleted logo's record and renditions for good, which
+// frees its symbol. Returns ErrNotFound if the logo isn't deleted: Purge
+// never removes a live one.
+//
+// Go note: the transaction makes both deletes land, or neither.
+func (r *sqliteLogoRepository) Purge(ctx context.Context, symbol string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("purging logo %s: %w", symbol, err)
+	}
+	defer tx.Rollback() // No-op after Commit
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM logos WHERE symbol = ? AND deleted_at IS NOT NULL", symbol)
+	if err != nil {
+		return fmt.Errorf("purging logo %s: %w", symbol, err)
+	}
+	if err := requireRow(result, symbol); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM logo_renditions WHERE symbol = ?", symbol); err != nil {
+		return fmt.Errorf("purging renditions of %s: %w", symbol, err)
+	}
+	return tx.Commit()
+}
+
+// requireRow returns ErrNotFound if a statement changed no row.
+func requireRow(result sql.Result, symbol string) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking rows for %s: %w", symbol, err)
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
 // LLMCallRepository handles persistence of LLM call tracking.
 type LLMCallRepository interface {
 	Create(ctx context.Context, call *model.LLMCall) error
 	CountBySymbol(ctx context.Context, symbol string) (int64, error)
+	List(ctx context.Context, symbol string, opts ListOptions) ([]model.LLMCall, string, error)
+	// Usage counts the calls per provider per day, from day since
+	// ("2006-01-02") on, newest day first.
+	Usage(ctx context.Context, since string) ([]model.LLMUsage, error)
 }
 
 type sqliteLLMCallRepository struct {
-	db *sqlx.DB
+	handles
 }
 
 // NewLLMCallRepository creates a new SQLite-backed LLMCallRepository.
-func NewLLMCallRepository(db *sqlx.DB) LLMCallRepository {
-	return &sqliteLLMCallRepository{db: db}
+func NewLLMCallRepository(db *sqlx.DB, reader ...*sqlx.DB) LLMCallRepository {
+	return &sqliteLLMCallRepository{handles: newHandles(db, reader)}
 }
 
 func (r *sqliteLLMCallRepository) Create(ctx context.Context, call *model.LLMCall) error {
 	result, err := r.db.NamedExecContext(ctx, `
-		INSERT INTO llm_calls (symbol, provider, model, result_url, success, duration_ms)
-		VALUES (:symbol, :provider, :model, :result_url, :success, :duration_ms)
+		INSERT INTO llm_calls (symbol, provider, model, result_url, success, duration_ms, key_id)
+		VALUES (:symbol, :provider, :model, :result_url, :success, :duration_ms, :key_id)
 	`, call)
 	if err != nil {
 		return fmt.Errorf("creating llm call record: %w", err)
@@ -196,6 +563,44 @@ func (r *sqliteLLMCallRepository) Create(ctx context.Context, call *model.LLMCal
 
 func (r *sqliteLLMCallRepository) CountBySymbol(ctx context.Context, symbol string) (int64, error) {
 	var count int64
-	err := r.db.GetContext(ctx, &count, "SELECT COUNT(*) FROM llm_calls WHERE symbol = ?", symbol)
+	err := r.read.GetContext(ctx, &count, "SELECT COUNT(*) FROM llm_calls WHERE symbol = ?", symbol)
 	return count, err
 }
+
+// llmCallPager pages LLM calls by when they were made.
+var llmCallPager = pager[model.LLMCall]{
+	sorts: map[string]sortKey[model.LLMCall]{
+		"created_at": timeKey("created_at", func(c model.LLMCall) time.Time { return c.CreatedAt }),
+	},
+	id: func(c model.LLMCall) int64 { return c.ID },
+}
+
+// List returns a page of LLM calls, for one symbol or all of them if symbol
+// is empty, newest first unless opts says otherwise, and the cursor of the
+// next page.
+func (r *sqliteLLMCallRepository) List(ctx context.Context, symbol string, opts ListOptions) ([]model.LLMCall, string, error) {
+	var conds []string
+	var args []any
+	if symbol != "" {
+		conds, args = append(conds, "symbol = ?"), append(args, symbol)
+	}
+	calls, next, err := llmCallPager.page(ctx, r.read, "SELECT * FROM llm_calls", conds, args, opts.WithSort("-created_at"))
+	if err != nil {
+		return nil, "", fmt.Errorf("listing llm calls: %w", err)
+	}
+	return calls, next, nil
+}
+
+func (r *sqliteLLMCallRepository) Usage(ctx context.Context, since string) ([]model.LLMUsage, error) {
+	usage := []model.LLMUsage{}
+	err := r.read.SelectContext(ctx, &usage, `
+		SELECT date(created_at) AS day, provider, COUNT(*) AS calls,
+			COUNT(CASE WHEN NOT success THEN 1 END) AS failed
+		FROM llm_calls WHERE created_at >= ?
+		GROUP BY 1, 2 ORDER BY 1 DESC, 2
+	`, since)
+	if err != nil {
+		return nil, fmt.Errorf("summarizing llm calls: %w", err)
+	}
+	return usage, nil
+}
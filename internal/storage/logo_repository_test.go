@@ -7,9 +7,12 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/fleveque/logo-service/internal/model"
 )
@@ -17,7 +20,9 @@ import (
 // setupTestDB creates a temporary SQLite database for testing.
 // Go's testing.T has a TempDir() method that creates a temp directory
 // automatically cleaned up after the test — no manual teardown needed.
-func setupTestDB(t *testing.T) *testDeps {
+// It takes testing.TB — the interface shared by *testing.T and *testing.B —
+// so benchmarks can use it too.
+func setupTestDB(t testing.TB) *testDeps {
 	t.Helper() // marks this as a helper so error line numbers point to the caller
 
 	tmpDir := t.TempDir()
@@ -36,14 +41,42 @@ func setupTestDB(t *testing.T) *testDeps {
 	})
 
 	return &testDeps{
-		logoRepo:    NewLogoRepository(db),
-		llmCallRepo: NewLLMCallRepository(db),
+		logoRepo:       NewLogoRepository(db),
+		llmCallRepo:    NewLLMCallRepository(db),
+		requestedRepo:  NewRequestedSymbolRepository(db),
+		instrumentRepo: NewInstrumentRepository(db),
+		variantRepo:    NewVariantRepository(db),
+		blocklistRepo:  NewBlocklistRepository(db),
+		auditRepo:      NewAuditRepository(db),
+		eventRepo:      NewEventRepository(db),
+		deliveryRepo:   NewDeliveryRepository(db),
+		originalRepo:   NewOriginalRepository(db),
+		attemptRepo:    NewAttemptRepository(db),
+		usageRepo:      NewUsageRepository(db),
+		flagRepo:       NewFlagRepository(db),
+		statsRepo:      NewStatsHistoryRepository(db),
+		llmClientRepo:  NewLLMClientRepository(db),
+		popularityRepo: NewPopularityRepository(db),
 	}
 }
 
 type testDeps struct {
-	logoRepo    LogoRepository
-	llmCallRepo LLMCallRepository
+	logoRepo       LogoRepository
+	llmCallRepo    LLMCallRepository
+	requestedRepo  RequestedSymbolRepository
+	instrumentRepo InstrumentRepository
+	variantRepo    VariantRepository
+	blocklistRepo  BlocklistRepository
+	auditRepo      AuditRepository
+	eventRepo      EventRepository
+	deliveryRepo   DeliveryRepository
+	originalRepo   OriginalRepository
+	attemptRepo    AttemptRepository
+	usageRepo      UsageRepository
+	flagRepo       FlagRepository
+	statsRepo      StatsHistoryRepository
+	llmClientRepo  LLMClientRepository
+	popularityRepo PopularityRepository
 }
 
 func TestLogoRepository_CreateAndGet(t *testing.T) {
@@ -115,6 +148,8 @@ func TestLogoRepository_Update(t *testing.T) {
 	logo.CompanyName = "Microsoft Corporation"
 	logo.Status = model.StatusProcessed
 	logo.HasM = true
+	whiten := false
+	logo.WhitenBackground = &whiten
 	if err := deps.logoRepo.Update(ctx, logo); err != nil {
 		t.Fatalf("updating logo: %v", err)
 	}
@@ -133,6 +168,10 @@ func TestLogoRepository_Update(t *testing.T) {
 	if !got.HasM {
 		t.Error("expected has_m to be true")
 	}
+	// false is an override, distinct from NULL (use the default).
+	if got.WhitenBackground == nil || *got.WhitenBackground {
+		t.Errorf("expected whiten_background override false, got %v", got.WhitenBackground)
+	}
 }
 
 func TestLogoRepository_SetSizeAvailable(t *testing.T) {
@@ -209,13 +248,150 @@ func TestLogoRepository_CountAndListPending(t *testing.T) {
 	}
 
 	// List pending
-	pending, err := deps.logoRepo.ListPending(ctx, 10)
+	pending, next, err := deps.logoRepo.ListPending(ctx, ListOptions{Limit: 10})
 	if err != nil {
 		t.Fatalf("listing pending logos: %v", err)
 	}
 	if len(pending) != 2 {
 		t.Errorf("expected 2 pending logos, got %d", len(pending))
 	}
+	if next != "" {
+		t.Errorf("expected no next cursor on the last page, got %q", next)
+	}
+}
+
+func TestLogoRepository_ListByQuality(t *testing.T) {
+	deps := setupTestDB(t)
+	ctx := context.Background()
+
+	scores := map[string]int{"AAPL": 90, "MSFT": 35, "GOOG": 60}
+	for _, symbol := range []string{"AAPL", "MSFT", "GOOG", "TSLA"} {
+		if err := deps.logoRepo.Create(ctx, &model.Logo{Symbol: symbol, Source: "test", Status: model.StatusProcessed}); err != nil {
+			t.Fatalf("creating logo %s: %v", symbol, err)
+		}
+		// TSLA stays unscored.
+		if score, ok := scores[symbol]; ok {
+			if err := deps.logoRepo.SetQuality(ctx, symbol, score, "notes"); err != nil {
+				t.Fatalf("setting quality for %s: %v", symbol, err)
+			}
+		}
+	}
+
+	logos, err := deps.logoRepo.ListByQuality(ctx, 60, 10)
+	if err != nil {
+		t.Fatalf("listing by quality: %v", err)
+	}
+	var got []string
+	for _, l := range logos {
+		got = append(got, l.Symbol)
+	}
+	if len(got) != 2 || got[0] != "MSFT" || got[1] != "GOOG" {
+		t.Errorf("expected [MSFT GOOG] worst first, got %v", got)
+	}
+	if logos[0].QualityScore == nil || *logos[0].QualityScore != 35 || logos[0].QualityNotes != "notes" {
+		t.Errorf("unexpected quality on MSFT: %v %q", logos[0].QualityScore, logos[0].QualityNotes)
+	}
+}
+
+func TestLogoRepository_MarkNotFound(t *testing.T) {
+	deps := setupTestDB(t)
+	ctx := context.Background()
+	next := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+
+	// No record yet: one is created.
+	if err := deps.logoRepo.MarkNotFound(ctx, "NOPE", next); err != nil {
+		t.Fatalf("marking new symbol: %v", err)
+	}
+	logo, err := deps.logoRepo.GetBySymbol(ctx, "NOPE")
+	if err != nil {
+		t.Fatalf("getting logo: %v", err)
+	}
+	if logo.Status != model.StatusNotFound || logo.NextCheckAt == nil || !logo.NextCheckAt.Equal(next) {
+		t.Errorf("expected not_found until %v, got %s / %v", next, logo.Status, logo.NextCheckAt)
+	}
+
+	// An existing failed record is updated in place.
+	if err := deps.logoRepo.Create(ctx, &model.Logo{Symbol: "FAIL", Source: "test", Status: model.StatusPending}); err != nil {
+		t.Fatalf("creating logo: %v", err)
+	}
+	if err := deps.logoRepo.SetStatus(ctx, "FAIL", model.StatusFailed, "boom"); err != nil {
+		t.Fatalf("setting status: %v", err)
+	}
+	if err := deps.logoRepo.MarkNotFound(ctx, "FAIL", next); err != nil {
+		t.Fatalf("marking existing symbol: %v", err)
+	}
+	logo, err = deps.logoRepo.GetBySymbol(ctx, "FAIL")
+	if err != nil {
+		t.Fatalf("getting logo: %v", err)
+	}
+	if logo.Status != model.StatusNotFound || logo.ErrorMessage != nil || logo.Source != "test" {
+		t.Errorf("expected not_found keeping the source, got %+v", logo)
+	}
+}
+
+func TestLogoRepository_MarkFailed(t *testing.T) {
+	deps := setupTestDB(t)
+	ctx := context.Background()
+	next := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	if err := deps.logoRepo.Create(ctx, &model.Logo{Symbol: "BAD", Source: "test", Status: model.StatusPending}); err != nil {
+		t.Fatalf("creating logo: %v", err)
+	}
+	if err := deps.logoRepo.MarkFailed(ctx, "BAD", "not an image", next); err != nil {
+		t.Fatalf("marking failed: %v", err)
+	}
+	logo, err := deps.logoRepo.GetBySymbol(ctx, "BAD")
+	if err != nil {
+		t.Fatalf("getting logo: %v", err)
+	}
+	if logo.Status != model.StatusFailed || logo.ErrorMessage == nil || *logo.ErrorMessage != "not an image" {
+		t.Errorf("expected failed with its message, got %+v", logo)
+	}
+	if logo.NextCheckAt == nil || !logo.NextCheckAt.Equal(next) {
+		t.Errorf("expected next check at %v, got %v", next, logo.NextCheckAt)
+	}
+
+	// A symbol without a record gets one.
+	if err := deps.logoRepo.MarkFailed(ctx, "NEW", "not an image", next); err != nil {
+		t.Fatalf("marking a new symbol failed: %v", err)
+	}
+	if logo, err := deps.logoRepo.GetBySymbol(ctx, "NEW"); err != nil || logo.Status != model.StatusFailed {
+		t.Errorf("expected a failed record for NEW, got %+v, %v", logo, err)
+	}
+}
+
+func TestLogoRepository_Validators(t *testing.T) {
+	deps := setupTestDB(t)
+	ctx := context.Background()
+	url := "https://raw.githubusercontent.com/org/logos/main/ticker_icons/AAPL.png"
+
+	if err := deps.logoRepo.Create(ctx, &model.Logo{Symbol: "AAPL", Source: "github:org/logos", OriginalURL: url, Status: model.StatusPending}); err != nil {
+		t.Fatalf("creating logo: %v", err)
+	}
+	if err := deps.logoRepo.SetValidators(ctx, "AAPL", `"abc"`, "Mon, 02 Jan 2006 15:04:05 GMT"); err != nil {
+		t.Fatalf("setting validators: %v", err)
+	}
+
+	// Not processed yet: a 304 would leave it without a logo.
+	if etag, _, err := deps.logoRepo.GetValidators(ctx, "AAPL", url); err != nil || etag != "" {
+		t.Errorf("expected no validators for a pending logo, got %q (%v)", etag, err)
+	}
+
+	if err := deps.logoRepo.SetStatus(ctx, "AAPL", model.StatusProcessed, ""); err != nil {
+		t.Fatalf("setting status: %v", err)
+	}
+	etag, lastModified, err := deps.logoRepo.GetValidators(ctx, "AAPL", url)
+	if err != nil || etag != `"abc"` || lastModified != "Mon, 02 Jan 2006 15:04:05 GMT" {
+		t.Errorf("got %q, %q (%v)", etag, lastModified, err)
+	}
+
+	// Another URL, or a symbol without a record, has none.
+	if etag, _, err := deps.logoRepo.GetValidators(ctx, "AAPL", url+"?other"); err != nil || etag != "" {
+		t.Errorf("expected no validators for another url, got %q (%v)", etag, err)
+	}
+	if etag, _, err := deps.logoRepo.GetValidators(ctx, "MSFT", url); err != nil || etag != "" {
+		t.Errorf("expected no validators for a missing logo, got %q (%v)", etag, err)
+	}
 }
 
 func TestLLMCallRepository_Create(t *testing.T) {
@@ -251,3 +427,254 @@ func TestLLMCallRepository_Create(t *testing.T) {
 		t.Errorf("expected 1 llm call, got %d", count)
 	}
 }
+
+func TestLLMCallRepository_Usage(t *testing.T) {
+	deps := setupTestDB(t)
+	ctx := context.Background()
+
+	for _, call := range []model.LLMCall{
+		{Symbol: "AAPL", Provider: "anthropic", Model: "m", Success: true},
+		{Symbol: "MSFT", Provider: "anthropic", Model: "m"},
+		{Symbol: "TSLA", Provider: "openai", Model: "m", Success: true},
+	} {
+		if err := deps.llmCallRepo.Create(ctx, &call); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Calls are stamped by the database: they're all today's.
+	today := time.Now().UTC().Format(time.DateOnly)
+	usage, err := deps.llmCallRepo.Usage(ctx, today)
+	if err != nil {
+		t.Fatalf("Usage: %v", err)
+	}
+	want := []model.LLMUsage{
+		{Day: today, Provider: "anthropic", Calls: 2, Failed: 1},
+		{Day: today, Provider: "openai", Calls: 1},
+	}
+	if !reflect.DeepEqual(usage, want) {
+		t.Errorf("Usage = %+v, want %+v", usage, want)
+	}
+
+	tomorrow := time.Now().UTC().AddDate(0, 0, 1).Format(time.DateOnly)
+	if usage, _ := deps.llmCallRepo.Usage(ctx, tomorrow); len(usage) != 0 {
+		t.Errorf("expected no usage from tomorrow on, got %+v", usage)
+	}
+}
+
+func TestRequestedSymbolRepository_RecordAndListMissing(t *testing.T) {
+	deps := setupTestDB(t)
+	ctx := context.Background()
+
+	// NVDA requested 3 times, XYZ once, AAPL twice (but AAPL gets processed)
+	for _, symbol := range []string{"NVDA", "XYZ", "NVDA", "AAPL", "NVDA", "AAPL"} {
+		if err := deps.requestedRepo.RecordMiss(ctx, symbol); err != nil {
+			t.Fatalf("recording miss for %s: %v", symbol, err)
+		}
+	}
+
+	logo := &model.Logo{Symbol: "AAPL", Source: "test", Status: model.StatusProcessed}
+	if err := deps.logoRepo.Create(ctx, logo); err != nil {
+		t.Fatalf("creating logo: %v", err)
+	}
+
+	missing, err := deps.requestedRepo.ListMissing(ctx, 10)
+	if err != nil {
+		t.Fatalf("listing missing: %v", err)
+	}
+
+	// AAPL is processed now, so only NVDA and XYZ remain — sorted by demand
+	if len(missing) != 2 {
+		t.Fatalf("expected 2 missing symbols, got %d", len(missing))
+	}
+	if missing[0].Symbol != "NVDA" || missing[0].RequestCount != 3 {
+		t.Errorf("expected NVDA with 3 requests first, got %s with %d", missing[0].Symbol, missing[0].RequestCount)
+	}
+	if missing[1].Symbol != "XYZ" || missing[1].RequestCount != 1 {
+		t.Errorf("expected XYZ with 1 request second, got %s with %d", missing[1].Symbol, missing[1].RequestCount)
+	}
+}
+
+func TestInstrumentRepository_UpsertAndGet(t *testing.T) {
+	deps := setupTestDB(t)
+	ctx := context.Background()
+
+	inst := &model.Instrument{Symbol: "ABC", Name: "AmerisourceBergen", Source: "test"}
+	if err := deps.instrumentRepo.Upsert(ctx, inst); err != nil {
+		t.Fatalf("upserting instrument: %v", err)
+	}
+
+	// A second upsert renames it rather than failing on the primary key
+	inst.Name = "Cencora Inc."
+	if err := deps.instrumentRepo.Upsert(ctx, inst); err != nil {
+		t.Fatalf("re-upserting instrument: %v", err)
+	}
+
+	got, err := deps.instrumentRepo.GetBySymbol(ctx, "ABC")
+	if err != nil {
+		t.Fatalf("getting instrument: %v", err)
+	}
+	if got.Name != "Cencora Inc." {
+		t.Errorf("expected updated name, got %q", got.Name)
+	}
+
+	count, err := deps.instrumentRepo.Count(ctx)
+	if err != nil {
+		t.Fatalf("counting instruments: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected 1 instrument, got %d", count)
+	}
+
+	if _, err := deps.instrumentRepo.GetBySymbol(ctx, "NOPE"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+// Benchmarks for the queries on the request path: every GetLogo looks the
+// symbol up, and every miss records demand.
+//
+//	go test ./internal/storage -run '^$' -bench . -benchmem
+func BenchmarkLogoRepository_GetBySymbol(b *testing.B) {
+	deps := setupTestDB(b)
+	ctx := context.Background()
+
+	logo := &model.Logo{Symbol: "AAPL", CompanyName: "Apple Inc.", Source: "github", Status: model.StatusProcessed}
+	if err := deps.logoRepo.Create(ctx, logo); err != nil {
+		b.Fatalf("creating logo: %v", err)
+	}
+
+	for b.Loop() {
+		if _, err := deps.logoRepo.GetBySymbol(ctx, "AAPL"); err != nil {
+			b.Fatalf("GetBySymbol failed: %v", err)
+		}
+	}
+}
+
+func BenchmarkLogoRepository_GetBySymbol_NotFound(b *testing.B) {
+	deps := setupTestDB(b)
+	ctx := context.Background()
+
+	for b.Loop() {
+		if _, err := deps.logoRepo.GetBySymbol(ctx, "NOPE"); !errors.Is(err, ErrNotFound) {
+			b.Fatalf("expected ErrNotFound, got %v", err)
+		}
+	}
+}
+
+func BenchmarkRequestedSymbolRepository_RecordMiss(b *testing.B) {
+	deps := setupTestDB(b)
+	ctx := context.Background()
+
+	for b.Loop() {
+		if err := deps.requestedRepo.RecordMiss(ctx, "NOPE"); err != nil {
+			b.Fatalf("RecordMiss failed: %v", err)
+		}
+	}
+}
+
+func TestLogoRepository_Renditions(t *testing.T) {
+	deps := setupTestDB(t)
+	ctx := context.Background()
+	repo := deps.logoRepo
+
+	if err := repo.Create(ctx, &model.Logo{Symbol: "AAPL", Status: model.StatusProcessed}); err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range []model.Rendition{
+		{Size: model.SizeXL, Width: 512, Height: 512, Bytes: 40000},
+		{Size: model.SizeXS, Width: 16, Height: 16, Bytes: 500},
+		{Size: model.SizeM, Width: 1, Height: 1, Bytes: 1},
+		{Size: model.SizeM, Width: 128, Height: 128, Bytes: 6000}, // Reprocessed
+	} {
+		if err := repo.SetRendition(ctx, "AAPL", r); err != nil {
+			t.Fatalf("SetRendition: %v", err)
+		}
+	}
+
+	renditions, err := repo.ListRenditions(ctx, "AAPL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []model.Rendition{
+		{Size: model.SizeXS, Width: 16, Height: 16, Bytes: 500},
+		{Size: model.SizeM, Width: 128, Height: 128, Bytes: 6000},
+		{Size: model.SizeXL, Width: 512, Height: 512, Bytes: 40000},
+	}
+	if !reflect.DeepEqual(renditions, want) {
+		t.Errorf("got %+v, want %+v", renditions, want)
+	}
+
+	if err := repo.SetSizeUnavailable(ctx, "AAPL", model.SizeXS); err != nil {
+		t.Fatal(err)
+	}
+	if renditions, _ = repo.ListRenditions(ctx, "AAPL"); len(renditions) != 2 {
+		t.Errorf("expected the unavailable size's rendition to go, got %+v", renditions)
+	}
+}
+
+func TestLogoRepository_SoftDelete(t *testing.T) {
+	deps := setupTestDB(t)
+	repo := deps.logoRepo
+	ctx := context.Background()
+
+	for _, symbol := range []string{"AAPL", "MSFT"} {
+		if err := repo.Create(ctx, &model.Logo{Symbol: symbol, Source: "test", Status: model.StatusProcessed}); err != nil {
+			t.Fatalf("creating logo %s: %v", symbol, err)
+		}
+	}
+	deletedAt := time.Now().Add(-48 * time.Hour)
+	if err := repo.SoftDelete(ctx, "AAPL", deletedAt); err != nil {
+		t.Fatalf("SoftDelete: %v", err)
+	}
+	if err := repo.SoftDelete(ctx, "AAPL", deletedAt); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound deleting twice, got %v", err)
+	}
+
+	// Left out of lookups, counts and lists.
+	if _, err := repo.GetBySymbol(ctx, "AAPL"); !errors.Is(err, ErrLogoDeleted) || !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrLogoDeleted wrapping ErrNotFound, got %v", err)
+	}
+	if n, _ := repo.Count(ctx); n != 1 {
+		t.Errorf("Count = %d, want 1", n)
+	}
+	if logos, _, _ := repo.List(ctx, "", ListOptions{}); len(logos) != 1 || logos[0].Symbol != "MSFT" {
+		t.Errorf("List = %+v, want only MSFT", logos)
+	}
+	if states, _ := repo.ListStates(ctx); len(states) != 1 {
+		t.Errorf("ListStates = %+v, want only MSFT", states)
+	}
+
+	// Only deletes older than the cutoff are due for purging.
+	if deleted, _ := repo.ListDeleted(ctx, time.Now().Add(-72*time.Hour)); len(deleted) != 0 {
+		t.Errorf("ListDeleted before the delete = %+v, want none", deleted)
+	}
+	if deleted, _ := repo.ListDeleted(ctx, time.Now()); len(deleted) != 1 || deleted[0].DeletedAt == nil {
+		t.Errorf("ListDeleted = %+v, want AAPL", deleted)
+	}
+
+	// A delete older than the restore window can't be undone.
+	if err := repo.Restore(ctx, "AAPL", time.Now().Add(-24*time.Hour)); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound restoring an expired delete, got %v", err)
+	}
+	if err := repo.Restore(ctx, "AAPL", time.Now().Add(-72*time.Hour)); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if _, err := repo.GetBySymbol(ctx, "AAPL"); err != nil {
+		t.Errorf("GetBySymbol after Restore: %v", err)
+	}
+
+	// Purge never touches a live logo.
+	if err := repo.Purge(ctx, "MSFT"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound purging a live logo, got %v", err)
+	}
+	if err := repo.SoftDelete(ctx, "MSFT", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Purge(ctx, "MSFT"); err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+	if err := repo.Create(ctx, &model.Logo{Symbol: "MSFT", Source: "test", Status: model.StatusPending}); err != nil {
+		t.Errorf("expected a purged symbol to be free again: %v", err)
+	}
+}
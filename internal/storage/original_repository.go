@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+// OriginalRepository records which source images were downloaded for each
+// symbol. The bytes themselves are in the FileSystem (see WriteOriginal).
+type OriginalRepository interface {
+	Record(ctx context.Context, original *model.Original) error
+	Get(ctx context.Context, symbol, hash string) (*model.Original, error)
+	ListBySymbol(ctx context.Context, symbol string) ([]model.Original, error)
+	DeleteBySymbol(ctx context.Context, symbol string) ([]string, error)
+	IsReferenced(ctx context.Context, hash string) (bool, error)
+}
+
+type sqliteOriginalRepository struct {
+	handles
+}
+
+// NewOriginalRepository creates a new SQLite-backed OriginalRepository.
+func NewOriginalRepository(db *sqlx.DB, reader ...*sqlx.DB) OriginalRepository {
+	return &sqliteOriginalRepository{handles: newHandles(db, reader)}
+}
+
+// HashOriginal returns the content hash an original is stored under.
+func HashOriginal(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Record adds an original for a symbol. Recording the same bytes for the
+// same symbol again keeps the first record, which says where they were
+// found first.
+func (r *sqliteOriginalRepository) Record(ctx context.Context, original *model.Original) error {
+	_, err := r.db.NamedExecContext(ctx, `
+		INSERT INTO originals (symbol, hash, source, original_url, confidence, license, attribution, format, size_bytes)
+		VALUES (:symbol, :hash, :source, :original_url, :confidence, :license, :attribution, :format, :size_bytes)
+		ON CONFLICT(symbol, hash) DO NOTHING
+	`, original)
+	if err != nil {
+		return fmt.Errorf("recording original for %s: %w", original.Symbol, err)
+	}
+	return nil
+}
+
+// Get returns the record of one original of a symbol. Returns ErrNotFound
+// if that symbol never had those bytes.
+func (r *sqliteOriginalRepository) Get(ctx context.Context, symbol, hash string) (*model.Original, error) {
+	var original model.Original
+	err := r.db.GetContext(ctx, &original, `SELECT * FROM originals WHERE symbol = ? AND hash = ?`, symbol, hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting original for %s: %w", symbol, err)
+	}
+	return &original, nil
+}
+
+// ListBySymbol returns every original downloaded for a symbol, newest first.
+func (r *sqliteOriginalRepository) ListBySymbol(ctx context.Context, symbol string) ([]model.Original, error) {
+	originals := []model.Original{}
+	err := r.read.SelectContext(ctx, &originals, `SELECT * FROM originals WHERE symbol = ? ORDER BY id DESC`, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("listing originals for %s: %w", symbol, err)
+	}
+	return originals, nil
+}
+
+// DeleteBySymbol forgets a symbol's originals and returns their hashes,
+// so the caller can delete the bytes no other symbol refers to.
+func (r *sqliteOriginalRepository) DeleteBySymbol(ctx context.Context, symbol string) ([]string, error) {
+	var hashes []string
+	if err := r.db.SelectContext(ctx, &hashes, `SELECT hash FROM originals WHERE symbol = ?`, symbol); err != nil {
+		return nil, fmt.Errorf("listing originals for %s: %w", symbol, err)
+	}
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM originals WHERE symbol = ?`, symbol); err != nil {
+		return nil, fmt.Errorf("deleting originals for %s: %w", symbol, err)
+	}
+	return hashes, nil
+}
+
+// IsReferenced reports whether any symbol still has an original with this hash.
+func (r *sqliteOriginalRepository) IsReferenced(ctx context.Context, hash string) (bool, error) {
+	var one int
+	err := r.db.GetContext(ctx, &one, `SELECT 1 FROM originals WHERE hash = ? LIMIT 1`, hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("checking original %s: %w", hash, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+func TestOriginalRepository_RecordAndList(t *testing.T) {
+	deps := setupTestDB(t)
+	ctx := context.Background()
+	repo := deps.originalRepo
+
+	first := HashOriginal([]byte("first"))
+	second := HashOriginal([]byte("second"))
+	for _, o := range []*model.Original{
+		{Symbol: "AAPL", Hash: first, Source: "github:nvstly/icons", Format: "svg", SizeBytes: 5},
+		{Symbol: "AAPL", Hash: second, Source: "llm:anthropic", Confidence: model.ConfidenceHigh, Format: "png", SizeBytes: 6},
+		// Same bytes again: the first record wins
+		{Symbol: "AAPL", Hash: first, Source: "llm:openai"},
+	} {
+		if err := repo.Record(ctx, o); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+
+	got, err := repo.Get(ctx, "AAPL", first)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Source != "github:nvstly/icons" || got.Format != "svg" || got.SizeBytes != 5 {
+		t.Errorf("unexpected record: %+v", got)
+	}
+	if _, err := repo.Get(ctx, "MSFT", first); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	list, err := repo.ListBySymbol(ctx, "AAPL")
+	if err != nil {
+		t.Fatalf("ListBySymbol: %v", err)
+	}
+	if len(list) != 2 || list[0].Hash != second {
+		t.Errorf("expected 2 originals, newest first, got %+v", list)
+	}
+
+	empty, err := repo.ListBySymbol(ctx, "MSFT")
+	if err != nil || empty == nil || len(empty) != 0 {
+		t.Errorf("expected an empty, non-nil list, got %v (%v)", empty, err)
+	}
+}
+
+func TestOriginalRepository_DeleteBySymbol(t *testing.T) {
+	deps := setupTestDB(t)
+	ctx := context.Background()
+	repo := deps.originalRepo
+
+	// GOOG and GOOGL share the same bytes
+	shared := HashOriginal([]byte("google"))
+	own := HashOriginal([]byte("goog only"))
+	for _, o := range []*model.Original{
+		{Symbol: "GOOG", Hash: shared},
+		{Symbol: "GOOG", Hash: own},
+		{Symbol: "GOOGL", Hash: shared},
+	} {
+		if err := repo.Record(ctx, o); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+
+	hashes, err := repo.DeleteBySymbol(ctx, "GOOG")
+	if err != nil {
+		t.Fatalf("DeleteBySymbol: %v", err)
+	}
+	if len(hashes) != 2 {
+		t.Errorf("expected 2 hashes, got %v", hashes)
+	}
+
+	tests := []struct {
+		hash string
+		want bool
+	}{
+		{shared, true},
+		{own, false},
+	}
+	for _, tt := range tests {
+		got, err := repo.IsReferenced(ctx, tt.hash)
+		if err != nil {
+			t.Fatalf("IsReferenced: %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("IsReferenced(%s) = %v, want %v", tt.hash[:8], got, tt.want)
+		}
+	}
+}
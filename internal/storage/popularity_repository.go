@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+// PopularityRepository keeps daily request counts per symbol, for the list
+// of the most requested ones.
+type PopularityRepository interface {
+	// Add adds counts (symbol → requests) to each symbol's row for day
+	// ("2006-01-02"), creating the row if needed.
+	Add(ctx context.Context, day string, counts map[string]int64) error
+	// Top returns the most requested symbols from day since on, most first.
+	// With missing, only those without a processed logo.
+	Top(ctx context.Context, since string, missing bool, limit int) ([]model.PopularSymbol, error)
+	// Prune deletes the counts of days before day, returning how many rows went.
+	Prune(ctx context.Context, before string) (int64, error)
+}
+
+type sqlitePopularityRepository struct {
+	handles
+}
+
+// NewPopularityRepository creates a new SQLite-backed PopularityRepository.
+func NewPopularityRepository(db *sqlx.DB, reader ...*sqlx.DB) PopularityRepository {
+	return &sqlitePopularityRepository{handles: newHandles(db, reader)}
+}
+
+// Add upserts every symbol's row in one transaction: a flush writes
+// thousands of symbols, and one commit is far cheaper than one each.
+func (r *sqlitePopularityRepository) Add(ctx context.Context, day string, counts map[string]int64) error {
+	if len(counts) == 0 {
+		return nil
+	}
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("adding symbol requests: %w", err)
+	}
+	defer tx.Rollback() // No-op after Commit
+
+	stmt, err := tx.PreparexContext(ctx, `
+		INSERT INTO symbol_requests (symbol, day, requests) VALUES (?, ?, ?)
+		ON CONFLICT(symbol, day) DO UPDATE SET requests = requests + excluded.requests
+	`)
+	if err != nil {
+		return fmt.Errorf("adding symbol requests: %w", err)
+	}
+	defer stmt.Close()
+	for symbol, n := range counts {
+		if _, err := stmt.ExecContext(ctx, symbol, day, n); err != nil {
+			return fmt.Errorf("adding requests for %s: %w", symbol, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// Top joins the logos table for each symbol's status; deleted logos count
+// as missing.
+func (r *sqlitePopularityRepository) Top(ctx context.Context, since string, missing bool, limit int) ([]model.PopularSymbol, error) {
+	popular := []model.PopularSymbol{}
+	err := r.read.SelectContext(ctx, &popular, `
+		SELECT sr.symbol, SUM(sr.requests) AS requests, MAX(sr.day) AS last_requested,
+			COALESCE(l.status, '') AS status
+		FROM symbol_requests sr
+		LEFT JOIN logos l ON l.symbol = sr.symbol AND l.deleted_at IS NULL
+		WHERE sr.day >= ? AND (? = 0 OR l.status IS NULL OR l.status != ?)
+		GROUP BY sr.symbol
+		ORDER BY requests DESC, last_requested DESC, sr.symbol
+		LIMIT ?
+	`, since, missing, model.StatusProcessed, limit)
+	if err != nil {
+		return nil, fmt.Errorf("listing popular symbols: %w", err)
+	}
+	return popular, nil
+}
+
+func (r *sqlitePopularityRepository) Prune(ctx context.Context, before string) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM symbol_requests WHERE day < ?`, before)
+	if err != nil {
+		return 0, fmt.Errorf("pruning symbol requests: %w", err)
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+func TestPopularityRepository(t *testing.T) {
+	deps := setupTestDB(t)
+	ctx := context.Background()
+	repo := deps.popularityRepo
+
+	for _, l := range []struct {
+		symbol string
+		status model.LogoStatus
+	}{{"AAPL", model.StatusProcessed}, {"ZZZ", model.StatusNotFound}} {
+		if err := deps.logoRepo.Create(ctx, &model.Logo{Symbol: l.symbol, Source: "test", Status: l.status}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Two flushes on the same day add up.
+	for _, batch := range []struct {
+		day    string
+		counts map[string]int64
+	}{
+		{"2025-01-01", map[string]int64{"AAPL": 10, "ZZZ": 4, "NEW": 1}},
+		{"2025-01-01", map[string]int64{"AAPL": 5, "NEW": 1}},
+		{"2025-01-02", map[string]int64{"ZZZ": 8, "NEW": 1}},
+		{"2024-12-01", map[string]int64{"OLD": 99}},
+	} {
+		if err := repo.Add(ctx, batch.day, batch.counts); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		missing bool
+		limit   int
+		want    []model.PopularSymbol
+	}{
+		{"all", false, 10, []model.PopularSymbol{
+			{Symbol: "AAPL", Requests: 15, LastRequested: "2025-01-01", Status: "processed"},
+			{Symbol: "ZZZ", Requests: 12, LastRequested: "2025-01-02", Status: "not_found"},
+			{Symbol: "NEW", Requests: 3, LastRequested: "2025-01-02", Status: ""},
+		}},
+		{"missing only", true, 10, []model.PopularSymbol{
+			{Symbol: "ZZZ", Requests: 12, LastRequested: "2025-01-02", Status: "not_found"},
+			{Symbol: "NEW", Requests: 3, LastRequested: "2025-01-02", Status: ""},
+		}},
+		{"limited", false, 1, []model.PopularSymbol{
+			{Symbol: "AAPL", Requests: 15, LastRequested: "2025-01-01", Status: "processed"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.Top(ctx, "2025-01-01", tt.missing, tt.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("row %d: got %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+
+	pruned, err := repo.Prune(ctx, "2025-01-01")
+	if err != nil || pruned != 1 {
+		t.Fatalf("Prune = %d, %v; want the one row of 2024-12-01", pruned, err)
+	}
+	if got, _ := repo.Top(ctx, "2000-01-01", false, 10); len(got) != 3 {
+		t.Errorf("expected 3 symbols left after pruning, got %+v", got)
+	}
+}
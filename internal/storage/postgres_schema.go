@@ -0,0 +1,235 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// postgresTypes maps the column types the SQLite schema declares to
+// Postgres ones. A type missing here makes PostgresSchema fail rather than
+// guess.
+var postgresTypes = map[string]string{
+	"INTEGER":  "BIGINT",
+	"TEXT":     "TEXT",
+	"BOOLEAN":  "BOOLEAN",
+	"DATETIME": "TIMESTAMPTZ",
+	"REAL":     "DOUBLE PRECISION",
+	"BLOB":     "BYTEA",
+}
+
+// sqliteColumn is a row of SQLite's pragma_table_info.
+type sqliteColumn struct {
+	Name    string  `db:"name"`
+	Type    string  `db:"type"`
+	NotNull bool    `db:"notnull"`
+	Default *string `db:"dflt_value"`
+	PK      int     `db:"pk"` // Position in the primary key, 0 if not in it
+}
+
+// PostgresSchema returns the statements creating, in Postgres, the tables
+// and indexes of a database NewDatabase opened. They're read from the
+// source's schema, like CopyDatabase's tables, so tables and columns added
+// later need no changes here. Each statement is idempotent (IF NOT EXISTS).
+//
+// AUTOINCREMENT ids become identity columns, which accept the copied ids;
+// ResetPostgresSequences moves them past those afterwards.
+func PostgresSchema(ctx context.Context, src *sqlx.DB) ([]string, error) {
+	tables, err := sqliteTables(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+
+	var statements, indexes []string
+	for _, table := range tables {
+		columns, err := sqliteColumns(ctx, src, table)
+		if err != nil {
+			return nil, err
+		}
+		var createSQL string
+		if err := src.GetContext(ctx, &createSQL, "SELECT sql FROM sqlite_master WHERE type = 'table' AND name = ?", table); err != nil {
+			return nil, fmt.Errorf("reading the schema of %s: %w", table, err)
+		}
+		identity := strings.Contains(strings.ToUpper(createSQL), "AUTOINCREMENT")
+
+		var defs, pk []string
+		for _, col := range columns {
+			def, err := postgresColumn(col, identity && col.PK == 1 && primaryKeySize(columns) == 1)
+			if err != nil {
+				return nil, fmt.Errorf("table %s: %w", table, err)
+			}
+			defs = append(defs, def)
+		}
+		for pos := 1; pos <= primaryKeySize(columns); pos++ {
+			for _, col := range columns {
+				if col.PK == pos {
+					pk = append(pk, col.Name)
+				}
+			}
+		}
+		if len(pk) > 0 {
+			defs = append(defs, "PRIMARY KEY ("+strings.Join(pk, ", ")+")")
+		}
+
+		var sqliteIndexes []struct {
+			Name   string `db:"name"`
+			Unique bool   `db:"unique"`
+			Origin string `db:"origin"` // c: CREATE INDEX, u: UNIQUE constraint, pk: primary key
+		}
+		if err := src.SelectContext(ctx, &sqliteIndexes,
+			`SELECT name, "unique", origin FROM pragma_index_list(?) ORDER BY name`, table); err != nil {
+			return nil, fmt.Errorf("reading the indexes of %s: %w", table, err)
+		}
+		for _, idx := range sqliteIndexes {
+			var cols []string
+			if err := src.SelectContext(ctx, &cols, "SELECT name FROM pragma_index_info(?) ORDER BY seqno", idx.Name); err != nil {
+				return nil, fmt.Errorf("reading index %s: %w", idx.Name, err)
+			}
+			switch idx.Origin {
+			case "u":
+				defs = append(defs, "UNIQUE ("+strings.Join(cols, ", ")+")")
+			case "c":
+				unique := ""
+				if idx.Unique {
+					unique = "UNIQUE "
+				}
+				indexes = append(indexes, fmt.Sprintf("CREATE %sINDEX IF NOT EXISTS %s ON %s (%s)",
+					unique, idx.Name, table, strings.Join(cols, ", ")))
+			}
+		}
+
+		statements = append(statements, fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n    %s\n)",
+			table, strings.Join(defs, ",\n    ")))
+	}
+	return append(statements, indexes...), nil
+}
+
+// postgresColumn translates one column definition.
+func postgresColumn(col sqliteColumn, identity bool) (string, error) {
+	typ, ok := postgresTypes[strings.ToUpper(col.Type)]
+	if !ok {
+		return "", fmt.Errorf("column %s: no Postgres type for %q", col.Name, col.Type)
+	}
+	def := col.Name + " " + typ
+	if identity {
+		return def + " GENERATED BY DEFAULT AS IDENTITY", nil
+	}
+	if col.NotNull {
+		def += " NOT NULL"
+	}
+	if col.Default != nil {
+		value := *col.Default
+		if typ == "BOOLEAN" {
+			// SQLite stores booleans as 0 and 1; Postgres won't cast those.
+			value = map[string]string{"0": "FALSE", "1": "TRUE"}[value]
+			if value == "" {
+				return "", fmt.Errorf("column %s: unexpected boolean default %q", col.Name, *col.Default)
+			}
+		}
+		def += " DEFAULT " + value
+	}
+	return def, nil
+}
+
+func primaryKeySize(columns []sqliteColumn) int {
+	n := 0
+	for _, col := range columns {
+		n = max(n, col.PK)
+	}
+	return n
+}
+
+// ResetPostgresSequences moves the identity sequence of every table
+// PostgresSchema gave one past the highest id copied, so rows inserted
+// afterwards don't collide with them.
+func ResetPostgresSequences(ctx context.Context, src, dst *sqlx.DB) error {
+	tables, err := sqliteTables(ctx, src)
+	if err != nil {
+		return err
+	}
+	for _, table := range tables {
+		var createSQL string
+		if err := src.GetContext(ctx, &createSQL, "SELECT sql FROM sqlite_master WHERE type = 'table' AND name = ?", table); err != nil {
+			return fmt.Errorf("reading the schema of %s: %w", table, err)
+		}
+		if !strings.Contains(strings.ToUpper(createSQL), "AUTOINCREMENT") {
+			continue
+		}
+		var id string
+		if err := src.GetContext(ctx, &id, "SELECT name FROM pragma_table_info(?) WHERE pk = 1", table); err != nil {
+			return fmt.Errorf("reading the id column of %s: %w", table, err)
+		}
+		_, err := dst.ExecContext(ctx, fmt.Sprintf(
+			"SELECT setval(pg_get_serial_sequence('%s', '%s'), COALESCE(MAX(%s), 0) + 1, false) FROM %s",
+			table, id, id, table))
+		if err != nil {
+			return fmt.Errorf("resetting the sequence of %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// sqliteTimeFormats are the layouts DATETIME columns are written in:
+// SQLite's CURRENT_TIMESTAMP and what go-sqlite3 writes for a time.Time.
+var sqliteTimeFormats = []string{
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02T15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05Z07:00",
+	"2006-01-02",
+}
+
+// postgresValue converts a value read from a SQLite column of the declared
+// type into one Postgres accepts for the type PostgresSchema gave it:
+// 0 and 1 for booleans, and text for times go-sqlite3 didn't parse itself.
+func postgresValue(declared string, v any) (any, error) {
+	if b, ok := v.([]byte); ok && strings.ToUpper(declared) != "BLOB" {
+		v = string(b)
+	}
+	switch strings.ToUpper(declared) {
+	case "BOOLEAN":
+		switch b := v.(type) {
+		case int64:
+			return b != 0, nil
+		case string:
+			return b == "1" || strings.EqualFold(b, "true"), nil
+		}
+	case "DATETIME":
+		s, ok := v.(string)
+		if !ok {
+			break
+		}
+		for _, layout := range sqliteTimeFormats {
+			if t, err := time.ParseInLocation(layout, s, time.UTC); err == nil {
+				return t, nil
+			}
+		}
+		return nil, fmt.Errorf("unreadable time %q", s)
+	}
+	return v, nil
+}
+
+func sqliteTables(ctx context.Context, src *sqlx.DB) ([]string, error) {
+	var tables []string
+	err := src.SelectContext(ctx, &tables,
+		"SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("listing tables: %w", err)
+	}
+	return tables, nil
+}
+
+func sqliteColumns(ctx context.Context, src *sqlx.DB, table string) ([]sqliteColumn, error) {
+	var columns []sqliteColumn
+	err := src.SelectContext(ctx, &columns,
+		"SELECT name, type, \"notnull\", dflt_value, pk FROM pragma_table_info(?) ORDER BY cid", table)
+	if err != nil {
+		return nil, fmt.Errorf("reading the columns of %s: %w", table, err)
+	}
+	return columns, nil
+}
@@ -0,0 +1,191 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jmoiron/sqlx"
+	"github.com/mattn/go-sqlite3"
+
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+func TestPostgresSchema(t *testing.T) {
+	db, err := NewDatabase(filepath.Join(t.TempDir(), "src.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	statements, err := PostgresSchema(context.Background(), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := strings.Join(statements, ";\n")
+	for _, want := range []string{
+		"CREATE TABLE IF NOT EXISTS logos (",
+		"id BIGINT GENERATED BY DEFAULT AS IDENTITY",
+		"has_xs BOOLEAN NOT NULL DEFAULT FALSE",
+		"whiten_background BOOLEAN,",
+		"status TEXT NOT NULL DEFAULT 'pending'",
+		"created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP",
+		"cache_hit_rate DOUBLE PRECISION",
+		"PRIMARY KEY (key_id, day)",
+		"UNIQUE (symbol, hash)",
+		"UNIQUE (symbol)",
+		"CREATE INDEX IF NOT EXISTS idx_logos_status ON logos (status)",
+	} {
+		if !strings.Contains(schema, want) {
+			t.Errorf("expected the schema to contain %q", want)
+		}
+	}
+	if strings.Contains(schema, "AUTOINCREMENT") || strings.Contains(schema, "DATETIME") {
+		t.Error("expected no SQLite-only syntax in the Postgres schema")
+	}
+}
+
+func TestPostgresValue(t *testing.T) {
+	at := time.Date(2025, 3, 4, 5, 6, 7, 0, time.UTC)
+	tests := []struct {
+		declared string
+		in, want any
+	}{
+		{"BOOLEAN", int64(1), true},
+		{"BOOLEAN", int64(0), false},
+		{"boolean", true, true},
+		{"DATETIME", "2025-03-04 05:06:07", at},
+		{"DATETIME", "2025-03-04 05:06:07+00:00", at},
+		{"DATETIME", at, at},
+		{"DATETIME", nil, nil},
+		{"TEXT", []byte("abc"), "abc"},
+		{"INTEGER", int64(42), int64(42)},
+	}
+	for _, tt := range tests {
+		got, err := postgresValue(tt.declared, tt.in)
+		if err != nil {
+			t.Errorf("%s %v: %v", tt.declared, tt.in, err)
+			continue
+		}
+		if gotTime, ok := got.(time.Time); ok {
+			if !gotTime.Equal(tt.want.(time.Time)) {
+				t.Errorf("%s %v: expected %v, got %v", tt.declared, tt.in, tt.want, got)
+			}
+		} else if got != tt.want {
+			t.Errorf("%s %v: expected %v (%T), got %v (%T)", tt.declared, tt.in, tt.want, tt.want, got, got)
+		}
+	}
+	if _, err := postgresValue("DATETIME", "yesterday"); err == nil {
+		t.Error("expected an error for an unreadable time")
+	}
+}
+
+func init() {
+	// A SQLite driver under another name: CopyDatabase converts values for
+	// any target that isn't "sqlite3", which these tests can check without
+	// a Postgres server.
+	sql.Register("sqlite3-as-other", &sqlite3.SQLiteDriver{})
+}
+
+func TestCopyDatabase_ConvertsForOtherDrivers(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	src, err := NewDatabase(filepath.Join(dir, "src.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+	created, err := NewDatabase(filepath.Join(dir, "dst.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	created.Close()
+	dst, err := sqlx.Open("sqlite3-as-other", filepath.Join(dir, "dst.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dst.Close()
+
+	logos := NewLogoRepository(src)
+	if err := logos.Create(ctx, &model.Logo{Symbol: "AAPL", Source: "test", Status: model.StatusProcessed}); err != nil {
+		t.Fatal(err)
+	}
+	if err := logos.SetSizeAvailable(ctx, "AAPL", model.SizeM); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := CopyDatabase(ctx, src, dst, CopyOptions{}); err != nil {
+		t.Fatalf("CopyDatabase: %v", err)
+	}
+	var row struct {
+		HasM      bool      `db:"has_m"`
+		HasL      bool      `db:"has_l"`
+		CreatedAt time.Time `db:"created_at"`
+	}
+	if err := dst.GetContext(ctx, &row, "SELECT has_m, has_l, created_at FROM logos WHERE symbol = 'AAPL'"); err != nil {
+		t.Fatal(err)
+	}
+	if !row.HasM || row.HasL || time.Since(row.CreatedAt) > time.Hour {
+		t.Errorf("expected the converted values to read back the same, got %+v", row)
+	}
+}
+
+// TestCopyDatabase_Postgres copies into a real Postgres database when
+// LOGO_SERVICE_TEST_POSTGRES has the DSN of an empty one.
+func TestCopyDatabase_Postgres(t *testing.T) {
+	dsn := os.Getenv("LOGO_SERVICE_TEST_POSTGRES")
+	if dsn == "" {
+		t.Skip("LOGO_SERVICE_TEST_POSTGRES not set")
+	}
+	ctx := context.Background()
+	src, err := NewDatabase(filepath.Join(t.TempDir(), "src.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+	dst, err := sqlx.Connect("pgx", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dst.Close()
+
+	logos := NewLogoRepository(src)
+	for _, symbol := range []string{"AAPL", "MSFT"} {
+		if err := logos.Create(ctx, &model.Logo{Symbol: symbol, Source: "test", Status: model.StatusProcessed}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := logos.SetSizeAvailable(ctx, "MSFT", model.SizeM); err != nil {
+		t.Fatal(err)
+	}
+
+	statements, err := PostgresSchema(ctx, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range statements {
+		if _, err := dst.ExecContext(ctx, stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+	if _, err := CopyDatabase(ctx, src, dst, CopyOptions{}); err != nil {
+		t.Fatalf("CopyDatabase: %v", err)
+	}
+	if err := ResetPostgresSequences(ctx, src, dst); err != nil {
+		t.Fatal(err)
+	}
+
+	var hasM bool
+	if err := dst.GetContext(ctx, &hasM, "SELECT has_m FROM logos WHERE symbol = 'MSFT'"); err != nil || !hasM {
+		t.Errorf("expected MSFT's size flag as a boolean, got %v (%v)", hasM, err)
+	}
+	var id int64
+	if err := dst.GetContext(ctx, &id, "INSERT INTO logos (symbol) VALUES ('NEW') RETURNING id"); err != nil || id <= 2 {
+		t.Errorf("expected a new id past the copied ones, got %d (%v)", id, err)
+	}
+}
@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+// RequestedSymbolRepository tracks demand for symbols we failed to serve.
+// Every 404 bumps a counter, so the most-wanted missing tickers float to the top.
+type RequestedSymbolRepository interface {
+	RecordMiss(ctx context.Context, symbol string) error
+	ListMissing(ctx context.Context, limit int) ([]model.RequestedSymbol, error)
+}
+
+type sqliteRequestedSymbolRepository struct {
+	handles
+}
+
+// NewRequestedSymbolRepository creates a new SQLite-backed RequestedSymbolRepository.
+func NewRequestedSymbolRepository(db *sqlx.DB, reader ...*sqlx.DB) RequestedSymbolRepository {
+	return &sqliteRequestedSymbolRepository{handles: newHandles(db, reader)}
+}
+
+// RecordMiss inserts the symbol or increments its counter if it's already tracked.
+// SQLite's ON CONFLICT ... DO UPDATE is an upsert in a single statement.
+func (r *sqliteRequestedSymbolRepository) RecordMiss(ctx context.Context, symbol string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO requested_symbols (symbol) VALUES (?)
+		ON CONFLICT(symbol) DO UPDATE SET
+			request_count = request_count + 1,
+			last_requested = CURRENT_TIMESTAMP
+	`, symbol)
+	if err != nil {
+		return fmt.Errorf("recording miss for %s: %w", symbol, err)
+	}
+	return nil
+}
+
+// ListMissing returns the most-requested symbols that still have no processed logo,
+// sorted by demand. Symbols acquired since their last miss drop out automatically.
+func (r *sqliteRequestedSymbolRepository) ListMissing(ctx context.Context, limit int) ([]model.RequestedSymbol, error) {
+	var missing []model.RequestedSymbol
+	err := r.read.SelectContext(ctx, &missing, `
+		SELECT rs.symbol, rs.request_count, rs.first_requested, rs.last_requested
+		FROM requested_symbols rs
+		LEFT JOIN logos l ON l.symbol = rs.symbol
+		WHERE l.status IS NULL OR l.status != ?
+		ORDER BY rs.request_count DESC, rs.last_requested DESC
+		LIMIT ?
+	`, model.StatusProcessed, limit)
+	if err != nil {
+		return nil, fmt.Errorf("listing missing symbols: %w", err)
+	}
+	return missing, nil
+}
@@ -0,0 +1,190 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+// StatsHistoryRepository keeps one snapshot of the stats per day, for trend
+// charts (see model.StatsSnapshot).
+type StatsHistoryRepository interface {
+	// Collect counts what a snapshot of day ("2006-01-02") holds: the logos
+	// as they stand now, and the day's LLM calls, requests and misses.
+	// LLMSpendCents and CacheHitRate are left for the caller.
+	Collect(ctx context.Context, day string) (*model.StatsSnapshot, error)
+	// Record saves a snapshot, replacing the one of the same day.
+	Record(ctx context.Context, snapshot *model.StatsSnapshot) error
+	// List returns the snapshots from day since on, oldest first.
+	List(ctx context.Context, since string) ([]model.StatsSnapshot, error)
+	// Activity counts what happened from since on, for the alert rules.
+	Activity(ctx context.Context, since time.Time) (*model.Activity, error)
+}
+
+type sqliteStatsHistoryRepository struct {
+	handles
+}
+
+// NewStatsHistoryRepository creates a new SQLite-backed StatsHistoryRepository.
+func NewStatsHistoryRepository(db *sqlx.DB, reader ...*sqlx.DB) StatsHistoryRepository {
+	return &sqliteStatsHistoryRepository{handles: newHandles(db, reader)}
+}
+
+// sourceKind is the SQL for the part of logos.source before ":".
+const sourceKind = "CASE WHEN instr(source, ':') > 0 THEN substr(source, 1, instr(source, ':') - 1) ELSE source END"
+
+func (r *sqliteStatsHistoryRepository) Collect(ctx context.Context, day string) (*model.StatsSnapshot, error) {
+	start, err := time.Parse(time.DateOnly, day)
+	if err != nil {
+		return nil, fmt.Errorf("collecting stats: %w", err)
+	}
+	// Timestamps are stored in UTC, as text that sorts like the time it
+	// holds, so a day is everything from its date up to the next one.
+	next := start.AddDate(0, 0, 1).Format(time.DateOnly)
+
+	snapshot := &model.StatsSnapshot{Day: day, BySource: map[string]int64{}}
+	err = r.read.GetContext(ctx, snapshot, `
+		SELECT COUNT(*) AS total,
+			COUNT(CASE WHEN status = ? THEN 1 END) AS processed,
+			COUNT(CASE WHEN status = ? THEN 1 END) AS pending,
+			COUNT(CASE WHEN status = ? THEN 1 END) AS failed,
+			COUNT(CASE WHEN status = ? THEN 1 END) AS not_found,
+			COUNT(CASE WHEN status = ? THEN 1 END) AS review
+		FROM logos WHERE deleted_at IS NULL
+	`, model.StatusProcessed, model.StatusPending, model.StatusFailed, model.StatusNotFound, model.StatusReview)
+	if err != nil {
+		return nil, fmt.Errorf("counting logos: %w", err)
+	}
+
+	var sources []struct {
+		Source string `db:"source"`
+		Logos  int64  `db:"logos"`
+	}
+	err = r.read.SelectContext(ctx, &sources,
+		"SELECT "+sourceKind+" AS source, COUNT(*) AS logos FROM logos WHERE deleted_at IS NULL AND source != '' GROUP BY 1")
+	if err != nil {
+		return nil, fmt.Errorf("counting logos by source: %w", err)
+	}
+	for _, s := range sources {
+		snapshot.BySource[s.Source] = s.Logos
+	}
+
+	// Go note: one row of scalar subqueries scans into the struct like a table row.
+	err = r.read.GetContext(ctx, snapshot, `
+		SELECT
+			(SELECT COUNT(*) FROM llm_calls WHERE created_at >= ? AND created_at < ?) AS llm_calls,
+			(SELECT COALESCE(SUM(requests), 0) FROM api_usage WHERE day = ?) AS requests,
+			(SELECT COUNT(DISTINCT trace) FROM acquisition_attempts WHERE created_at >= ? AND created_at < ?) AS misses
+	`, day, next, day, day, next)
+	if err != nil {
+		return nil, fmt.Errorf("counting the day's activity: %w", err)
+	}
+	return snapshot, nil
+}
+
+// Record upserts the day's row and replaces its source counts.
+//
+// Go note: the transaction makes a reader see the old snapshot or the new
+// one, never the row of one with the sources of the other.
+func (r *sqliteStatsHistoryRepository) Record(ctx context.Context, snapshot *model.StatsSnapshot) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("recording stats of %s: %w", snapshot.Day, err)
+	}
+	defer tx.Rollback() // No-op after Commit
+
+	_, err = tx.NamedExecContext(ctx, `
+		INSERT INTO stats_history (day, total, processed, pending, failed, not_found, review,
+			llm_calls, llm_spend_cents, requests, misses, cache_hit_rate, recorded_at)
+		VALUES (:day, :total, :processed, :pending, :failed, :not_found, :review,
+			:llm_calls, :llm_spend_cents, :requests, :misses, :cache_hit_rate, CURRENT_TIMESTAMP)
+		ON CONFLICT(day) DO UPDATE SET
+			total = excluded.total,
+			processed = excluded.processed,
+			pending = excluded.pending,
+			failed = excluded.failed,
+			not_found = excluded.not_found,
+			review = excluded.review,
+			llm_calls = excluded.llm_calls,
+			llm_spend_cents = excluded.llm_spend_cents,
+			requests = excluded.requests,
+			misses = excluded.misses,
+			cache_hit_rate = excluded.cache_hit_rate,
+			recorded_at = excluded.recorded_at
+	`, snapshot)
+	if err != nil {
+		return fmt.Errorf("recording stats of %s: %w", snapshot.Day, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM stats_history_sources WHERE day = ?", snapshot.Day); err != nil {
+		return fmt.Errorf("recording sources of %s: %w", snapshot.Day, err)
+	}
+	for source, logos := range snapshot.BySource {
+		_, err := tx.ExecContext(ctx, "INSERT INTO stats_history_sources (day, source, logos) VALUES (?, ?, ?)",
+			snapshot.Day, source, logos)
+		if err != nil {
+			return fmt.Errorf("recording sources of %s: %w", snapshot.Day, err)
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *sqliteStatsHistoryRepository) List(ctx context.Context, since string) ([]model.StatsSnapshot, error) {
+	snapshots := []model.StatsSnapshot{}
+	if err := r.read.SelectContext(ctx, &snapshots,
+		"SELECT * FROM stats_history WHERE day >= ? ORDER BY day", since); err != nil {
+		return nil, fmt.Errorf("listing stats history: %w", err)
+	}
+
+	var sources []struct {
+		Day    string `db:"day"`
+		Source string `db:"source"`
+		Logos  int64  `db:"logos"`
+	}
+	if err := r.read.SelectContext(ctx, &sources,
+		"SELECT day, source, logos FROM stats_history_sources WHERE day >= ?", since); err != nil {
+		return nil, fmt.Errorf("listing stats history sources: %w", err)
+	}
+	byDay := make(map[string]map[string]int64, len(snapshots))
+	for _, s := range sources {
+		if byDay[s.Day] == nil {
+			byDay[s.Day] = map[string]int64{}
+		}
+		byDay[s.Day][s.Source] = s.Logos
+	}
+	for i := range snapshots {
+		snapshots[i].BySource = byDay[snapshots[i].Day]
+		if snapshots[i].BySource == nil {
+			snapshots[i].BySource = map[string]int64{}
+		}
+	}
+	return snapshots, nil
+}
+
+func (r *sqliteStatsHistoryRepository) Activity(ctx context.Context, since time.Time) (*model.Activity, error) {
+	since = since.UTC()
+	// Stamped by SQLite or by Go, a time starts like this: comparing with
+	// it as text compares the times.
+	from := since.Format(time.DateTime)
+	activity := &model.Activity{}
+	// A failure is a cache miss no provider found the logo on, and at least
+	// one couldn't be asked: plain misses are the logo not existing.
+	err := r.read.GetContext(ctx, activity, `
+		SELECT
+			(SELECT COUNT(*) FROM (
+				SELECT trace FROM acquisition_attempts WHERE created_at >= ? GROUP BY trace
+				HAVING SUM(outcome = ?) = 0 AND SUM(outcome = ?) > 0
+			)) AS acquisition_failures,
+			(SELECT COUNT(DISTINCT trace) FROM acquisition_attempts WHERE created_at >= ?) AS misses,
+			(SELECT COUNT(*) FROM llm_calls WHERE created_at >= ?) AS llm_calls,
+			(SELECT COALESCE(SUM(requests), 0) FROM api_usage WHERE day >= ?) AS requests
+	`, from, model.AttemptFound, model.AttemptUnavailable, from, from, since.Format(time.DateOnly))
+	if err != nil {
+		return nil, fmt.Errorf("counting activity: %w", err)
+	}
+	return activity, nil
+}
@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+func TestStatsHistoryRepository_Collect(t *testing.T) {
+	deps := setupTestDB(t)
+	ctx := context.Background()
+	// llm_calls are stamped by the database: collect today.
+	now := time.Now().UTC()
+	today, yesterday := now.Format(time.DateOnly), now.AddDate(0, 0, -1)
+
+	for _, logo := range []*model.Logo{
+		{Symbol: "AAPL", Source: "github:acme/logos", Status: model.StatusProcessed},
+		{Symbol: "MSFT", Source: "github:other/logos", Status: model.StatusProcessed},
+		{Symbol: "TSLA", Source: "llm:anthropic", Status: model.StatusReview},
+		{Symbol: "NOPE", Status: model.StatusFailed},
+		{Symbol: "GONE", Source: "llm:openai", Status: model.StatusProcessed},
+	} {
+		if err := deps.logoRepo.Create(ctx, logo); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := deps.logoRepo.SoftDelete(ctx, "GONE", now); err != nil {
+		t.Fatal(err)
+	}
+	for range 3 {
+		if err := deps.llmCallRepo.Create(ctx, &model.LLMCall{Symbol: "TSLA", Provider: "anthropic", Model: "m"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := deps.usageRepo.Add(ctx, []model.KeyUsage{
+		{KeyID: "a", Day: today, Requests: 40},
+		{KeyID: "b", Day: today, Requests: 60},
+		{KeyID: "a", Day: yesterday.Format(time.DateOnly), Requests: 999},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	// Two misses today (one asked two providers), one yesterday.
+	for _, a := range []model.Attempt{
+		{Trace: "t1", Symbol: "TSLA", Provider: "github", Outcome: "miss", CreatedAt: now},
+		{Trace: "t1", Symbol: "TSLA", Provider: "llm", Outcome: "found", CreatedAt: now},
+		{Trace: "t2", Symbol: "NOPE", Provider: "github", Outcome: "miss", CreatedAt: now},
+		{Trace: "t0", Symbol: "NOPE", Provider: "github", Outcome: "miss", CreatedAt: yesterday},
+	} {
+		if err := deps.attemptRepo.Record(ctx, &a); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := deps.statsRepo.Collect(ctx, today)
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	want := &model.StatsSnapshot{
+		Day: today, Total: 4, Processed: 2, Failed: 1, Review: 1,
+		BySource: map[string]int64{"github": 2, "llm": 1},
+		LLMCalls: 3, Requests: 100, Misses: 2,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Collect = %+v, want %+v", got, want)
+	}
+
+	if _, err := deps.statsRepo.Collect(ctx, "yesterday"); err == nil {
+		t.Error("expected an error for a day that isn't a date")
+	}
+}
+
+func TestStatsHistoryRepository_RecordAndList(t *testing.T) {
+	deps := setupTestDB(t)
+	ctx := context.Background()
+	rate := 0.9
+
+	for _, s := range []model.StatsSnapshot{
+		{Day: "2025-01-01", Total: 1, BySource: map[string]int64{"github": 1}},
+		{Day: "2025-01-02", Total: 2, BySource: map[string]int64{"github": 2}},
+		// Recorded again later the same day: replaces the first one.
+		{Day: "2025-01-02", Total: 3, BySource: map[string]int64{"llm": 3}, Requests: 10, Misses: 1, CacheHitRate: &rate},
+		{Day: "2024-12-01", Total: 9},
+	} {
+		if err := deps.statsRepo.Record(ctx, &s); err != nil {
+			t.Fatalf("Record(%s): %v", s.Day, err)
+		}
+	}
+
+	snapshots, err := deps.statsRepo.List(ctx, "2025-01-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 2 || snapshots[0].Day != "2025-01-01" || snapshots[1].Day != "2025-01-02" {
+		t.Fatalf("expected the 2 days since 2025-01-01, oldest first, got %+v", snapshots)
+	}
+	last := snapshots[1]
+	if last.Total != 3 || !reflect.DeepEqual(last.BySource, map[string]int64{"llm": 3}) ||
+		last.CacheHitRate == nil || *last.CacheHitRate != rate || last.RecordedAt.IsZero() {
+		t.Errorf("expected the day's last snapshot, got %+v", last)
+	}
+	if snapshots[0].CacheHitRate != nil {
+		t.Errorf("a day without requests has no hit rate, got %v", *snapshots[0].CacheHitRate)
+	}
+}
+
+func TestStatsHistoryRepository_Activity(t *testing.T) {
+	deps := setupTestDB(t)
+	ctx := context.Background()
+	now := time.Now()
+	hourAgo := now.Add(-time.Hour)
+
+	for _, a := range []model.Attempt{
+		// Failed: nobody found it, GitHub couldn't be asked.
+		{Trace: "t1", Symbol: "A", Provider: "github", Outcome: model.AttemptUnavailable, CreatedAt: now},
+		{Trace: "t1", Symbol: "A", Provider: "llm", Outcome: model.AttemptMiss, CreatedAt: now},
+		// Found after GitHub failed: not a failure.
+		{Trace: "t2", Symbol: "B", Provider: "github", Outcome: model.AttemptUnavailable, CreatedAt: now},
+		{Trace: "t2", Symbol: "B", Provider: "llm", Outcome: model.AttemptFound, CreatedAt: now},
+		// A plain miss.
+		{Trace: "t3", Symbol: "C", Provider: "github", Outcome: model.AttemptMiss, CreatedAt: now},
+		// Failed, but before the window.
+		{Trace: "t0", Symbol: "D", Provider: "github", Outcome: model.AttemptUnavailable, CreatedAt: hourAgo.Add(-time.Minute)},
+	} {
+		if err := deps.attemptRepo.Record(ctx, &a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := deps.llmCallRepo.Create(ctx, &model.LLMCall{Symbol: "B", Provider: "anthropic", Model: "m"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := deps.usageRepo.Add(ctx, []model.KeyUsage{{KeyID: "a", Day: now.UTC().Format(time.DateOnly), Requests: 20}}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := deps.statsRepo.Activity(ctx, hourAgo)
+	if err != nil {
+		t.Fatalf("Activity: %v", err)
+	}
+	want := model.Activity{AcquisitionFailures: 1, Misses: 3, LLMCalls: 1, Requests: 20}
+	if *got != want {
+		t.Errorf("Activity = %+v, want %+v", *got, want)
+	}
+}
@@ -0,0 +1,213 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+// encodeSymbol turns a symbol into a safe directory name.
+// Letters, digits and "-" pass through; "." becomes "_" (BRK.B → BRK_B) so
+// names stay readable; every other byte becomes %XX (^GSPC → %5EGSPC).
+// The result never contains separators or dots, so ".." or "a/b" can't
+// escape baseDir even if validation upstream is bypassed.
+// Windows device names (CON, PRN, NUL, COM1...) can't be used as directory
+// names there, so their first letter is escaped too (CON → %43ON).
+func encodeSymbol(symbol string) string {
+	var b strings.Builder
+	for i := 0; i < len(symbol); i++ {
+		c := symbol[i]
+		switch {
+		case i == 0 && reservedNames[strings.ToUpper(symbol)]:
+			fmt.Fprintf(&b, "%%%02X", c)
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '-':
+			b.WriteByte(c)
+		case c == '.':
+			b.WriteByte('_')
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// reservedNames are device names Windows refuses as file or directory names.
+var reservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// shardDir returns the directories a symbol's directory is nested under.
+// Sharding keeps any single directory from holding 10k+ entries, which makes
+// listings, backups and NFS painfully slow.
+//   - prefix: first two characters of the encoded name ("AA" for AAPL)
+//   - hash: two levels from a SHA-256 of the symbol ("3f/a9"), evenly spread
+//     even though tickers cluster heavily on some letters
+func shardDir(layout Layout, symbol, encoded string) string {
+	switch layout {
+	case LayoutPrefix:
+		if len(encoded) < 2 {
+			return encoded
+		}
+		return encoded[:2]
+	case LayoutHash:
+		sum := sha256.Sum256([]byte(symbol))
+		h := hex.EncodeToString(sum[:2])
+		return path.Join(h[:2], h[2:4])
+	default:
+		return ""
+	}
+}
+
+// decodeSymbol reverses encodeSymbol, mapping a directory name back to its symbol.
+func decodeSymbol(name string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch c {
+		case '_':
+			b.WriteByte('.')
+		case '%':
+			if i+2 >= len(name) {
+				return "", fmt.Errorf("truncated escape in %q", name)
+			}
+			v, err := strconv.ParseUint(name[i+1:i+3], 16, 8)
+			if err != nil {
+				return "", fmt.Errorf("bad escape in %q: %w", name, err)
+			}
+			b.WriteByte(byte(v))
+			i += 2
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), nil
+}
+
+// migratingPrefix marks directories moved aside during MigrateLayout.
+const migratingPrefix = ".migrating-"
+
+// MigrateLayout moves every symbol directory under baseDir into the current
+// layout, whatever layout (or pre-encoding naming) it was written with.
+// A symbol directory is recognized by holding PNGs directly — shard
+// directories only ever contain other directories. Emptied shard directories
+// are removed afterwards. Safe to run repeatedly.
+// Returns the number of directories moved. Stores that aren't on disk are
+// never in an older layout, so there is nothing to do for them.
+func (fs *FileSystem) MigrateLayout() (int, error) {
+	if fs.baseDir == "" {
+		return 0, nil
+	}
+	type pending struct{ name, symbol string }
+	var found []pending
+
+	err := filepath.WalkDir(fs.baseDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() || path == fs.baseDir || !containsPNG(path) {
+			return nil
+		}
+
+		// Leftovers from an interrupted run sit at the top level, already aside.
+		name := strings.TrimPrefix(d.Name(), migratingPrefix)
+		if symbol, ok := legacySymbol(name); ok && path != fs.SymbolDir(symbol) {
+			found = append(found, pending{name: path, symbol: symbol})
+		}
+		return filepath.SkipDir // Never descend into a symbol directory
+	})
+	if err != nil {
+		return 0, fmt.Errorf("walking logo directory: %w", err)
+	}
+
+	// Two passes: move everything aside before creating any shard. An old "AA"
+	// shard and a flat "AA" symbol directory are the same path, so shards
+	// created mid-way could otherwise land inside a directory not yet moved.
+	for i, p := range found {
+		tmp := filepath.Join(fs.baseDir, migratingPrefix+encodeSymbol(p.symbol))
+		if p.name != tmp {
+			if err := os.Rename(p.name, tmp); err != nil {
+				return 0, fmt.Errorf("moving %s aside: %w", p.name, err)
+			}
+		}
+		found[i].name = tmp
+	}
+
+	for i, p := range found {
+		target := fs.SymbolDir(p.symbol)
+		if _, err := os.Stat(target); err == nil {
+			return i, fmt.Errorf("cannot migrate %s: %s already exists", p.symbol, target)
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return i, fmt.Errorf("creating shard directory: %w", err)
+		}
+		if err := os.Rename(p.name, target); err != nil {
+			return i, fmt.Errorf("moving %s: %w", p.symbol, err)
+		}
+	}
+
+	if err := removeEmptyDirs(fs.baseDir); err != nil {
+		return len(found), fmt.Errorf("cleaning up shard directories: %w", err)
+	}
+	return len(found), nil
+}
+
+// legacySymbol recovers the symbol from a directory name, raw or encoded.
+func legacySymbol(name string) (string, bool) {
+	if model.ValidSymbol(name) {
+		return name, true
+	}
+	symbol, err := decodeSymbol(name)
+	if err != nil || !model.ValidSymbol(symbol) {
+		return "", false
+	}
+	return symbol, true
+}
+
+// containsPNG reports whether dir has at least one .png file directly inside it.
+func containsPNG(dir string) bool {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".png") {
+			return true
+		}
+	}
+	return false
+}
+
+// removeEmptyDirs deletes empty directories below root (but never root itself),
+// deepest first so parents emptied along the way go too.
+func removeEmptyDirs(root string) error {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		dir := filepath.Join(root, e.Name())
+		if err := removeEmptyDirs(dir); err != nil {
+			return err
+		}
+		if rest, err := os.ReadDir(dir); err == nil && len(rest) == 0 {
+			if err := os.Remove(dir); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+// UsageRepository keeps daily request counts per API key (see model.KeyUsage).
+type UsageRepository interface {
+	// Add adds counts to each key's row for its day, creating the row if needed.
+	Add(ctx context.Context, usage []model.KeyUsage) error
+	// Summary totals each key's usage from day since ("2006-01-02") on,
+	// with the LLM calls its misses caused, most rejected first.
+	Summary(ctx context.Context, since string) ([]model.KeyUsage, error)
+	// Transferred returns the bytes served to each key from day since on,
+	// by key ID, for the monthly transfer caps.
+	Transferred(ctx context.Context, since string) (map[string]int64, error)
+}
+
+type sqliteUsageRepository struct {
+	handles
+}
+
+// NewUsageRepository creates a new SQLite-backed UsageRepository.
+func NewUsageRepository(db *sqlx.DB, reader ...*sqlx.DB) UsageRepository {
+	return &sqliteUsageRepository{handles: newHandles(db, reader)}
+}
+
+// Add upserts one row per key and day. The tier is overwritten, so a key
+// moved to another plan shows its current one.
+func (r *sqliteUsageRepository) Add(ctx context.Context, usage []model.KeyUsage) error {
+	for _, u := range usage {
+		_, err := r.db.NamedExecContext(ctx, `
+			INSERT INTO api_usage (key_id, day, tier, requests, rejected, credits_used, bytes_served)
+			VALUES (:key_id, :day, :tier, :requests, :rejected, :credits_used, :bytes_served)
+			ON CONFLICT(key_id, day) DO UPDATE SET
+				tier = excluded.tier,
+				requests = requests + excluded.requests,
+				rejected = rejected + excluded.rejected,
+				credits_used = credits_used + excluded.credits_used,
+				bytes_served = bytes_served + excluded.bytes_served
+		`, u)
+		if err != nil {
+			return fmt.Errorf("adding usage for key %s: %w", u.KeyID, err)
+		}
+	}
+	return nil
+}
+
+// Summary returns one row per key, with Day left empty and the tier of the
+// key's latest day. A key whose only activity is LLM calls (its requests
+// not saved yet) still gets a row.
+func (r *sqliteUsageRepository) Summary(ctx context.Context, since string) ([]model.KeyUsage, error) {
+	usage := []model.KeyUsage{}
+	err := r.read.SelectContext(ctx, &usage, `
+		SELECT key_id,
+			COALESCE((SELECT tier FROM api_usage latest WHERE latest.key_id = activity.key_id ORDER BY day DESC LIMIT 1), '') AS tier,
+			SUM(requests) AS requests, SUM(rejected) AS rejected, SUM(credits_used) AS credits_used,
+			SUM(bytes_served) AS bytes_served, SUM(llm_calls) AS llm_calls, SUM(llm_failed) AS llm_failed
+		FROM (
+			SELECT key_id, requests, rejected, credits_used, bytes_served, 0 AS llm_calls, 0 AS llm_failed
+			FROM api_usage WHERE day >= ?
+			UNION ALL
+			SELECT key_id, 0, 0, 0, 0, 1, CASE WHEN success THEN 0 ELSE 1 END
+			FROM llm_calls WHERE key_id != '' AND created_at >= ?
+		) activity
+		GROUP BY key_id
+		ORDER BY rejected DESC, requests DESC, key_id
+	`, since, since)
+	if err != nil {
+		return nil, fmt.Errorf("summarizing API usage: %w", err)
+	}
+	return usage, nil
+}
+
+func (r *sqliteUsageRepository) Transferred(ctx context.Context, since string) (map[string]int64, error) {
+	var rows []struct {
+		KeyID string `db:"key_id"`
+		Bytes int64  `db:"bytes"`
+	}
+	err := r.read.SelectContext(ctx, &rows,
+		"SELECT key_id, SUM(bytes_served) AS bytes FROM api_usage WHERE day >= ? GROUP BY key_id", since)
+	if err != nil {
+		return nil, fmt.Errorf("summing bytes served: %w", err)
+	}
+	transferred := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		transferred[row.KeyID] = row.Bytes
+	}
+	return transferred, nil
+}
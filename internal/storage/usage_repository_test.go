@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+func TestUsageRepository_AddAndSummary(t *testing.T) {
+	deps := setupTestDB(t)
+	ctx := context.Background()
+	repo := deps.usageRepo
+
+	// Two flushes on the same day add up; the key changed tier on the 2nd.
+	for _, batch := range [][]model.KeyUsage{
+		{
+			{KeyID: "aaa", Day: "2025-01-01", Tier: "free", Requests: 10, Rejected: 4, BytesServed: 1000},
+			{KeyID: "bbb", Day: "2025-01-01", Tier: "partner", Requests: 50, CreditsUsed: 5},
+		},
+		{
+			{KeyID: "aaa", Day: "2025-01-01", Tier: "free", Requests: 5, Rejected: 1},
+			{KeyID: "aaa", Day: "2025-01-02", Tier: "partner", Requests: 3, BytesServed: 500},
+			{KeyID: "ccc", Day: "2024-12-01", Tier: "free", Requests: 99, Rejected: 99},
+		},
+	} {
+		if err := repo.Add(ctx, batch); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+
+	usage, err := repo.Summary(ctx, "2025-01-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []model.KeyUsage{
+		{KeyID: "aaa", Tier: "partner", Requests: 18, Rejected: 5, BytesServed: 1500},
+		{KeyID: "bbb", Tier: "partner", Requests: 50, CreditsUsed: 5},
+	}
+	if len(usage) != len(want) {
+		t.Fatalf("expected %d keys (ccc is too old), got %+v", len(want), usage)
+	}
+	for i := range want {
+		if usage[i] != want[i] {
+			t.Errorf("key %d: got %+v, want %+v", i, usage[i], want[i])
+		}
+	}
+
+	transferred, err := repo.Transferred(ctx, "2025-01-02")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transferred) != 1 || transferred["aaa"] != 500 {
+		t.Errorf("unexpected bytes transferred since 2025-01-02: %v", transferred)
+	}
+}
+
+func TestUsageRepository_SummaryLLMCalls(t *testing.T) {
+	deps := setupTestDB(t)
+	ctx := context.Background()
+
+	if err := deps.usageRepo.Add(ctx, []model.KeyUsage{{KeyID: "aaa", Day: "2025-01-01", Tier: "free", Requests: 10}}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	// Two searches by aaa, one by bbb (requests not flushed yet), one by no key.
+	for _, call := range []model.LLMCall{
+		{Symbol: "AAA", Provider: "anthropic", Model: "m", Success: true, KeyID: "aaa"},
+		{Symbol: "ZZZQ", Provider: "anthropic", Model: "m", KeyID: "aaa"},
+		{Symbol: "BBB", Provider: "openai", Model: "m", KeyID: "bbb"},
+		{Symbol: "CCC", Provider: "openai", Model: "m", Success: true},
+	} {
+		if err := deps.llmCallRepo.Create(ctx, &call); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+
+	usage, err := deps.usageRepo.Summary(ctx, "2025-01-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []model.KeyUsage{
+		{KeyID: "aaa", Tier: "free", Requests: 10, LLMCalls: 2, LLMFailed: 1},
+		{KeyID: "bbb", LLMCalls: 1, LLMFailed: 1},
+	}
+	if len(usage) != len(want) {
+		t.Fatalf("expected %d keys, got %+v", len(want), usage)
+	}
+	for i := range want {
+		if usage[i] != want[i] {
+			t.Errorf("key %d: got %+v, want %+v", i, usage[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+// VariantRepository keeps the accounting for cached logo variants: how big
+// each file is and when it was last served. Summing a column is much faster
+// than walking logo_dir every time the cache needs its total size.
+type VariantRepository interface {
+	Record(ctx context.Context, v *model.LogoVariant) error
+	Touch(ctx context.Context, symbol string, size model.LogoSize, variant string) error
+	TotalBytes(ctx context.Context) (int64, error)
+	LeastRecentlyUsed(ctx context.Context, limit int) ([]model.LogoVariant, error)
+	Delete(ctx context.Context, symbol string, size model.LogoSize, variant string) error
+	DeleteBySymbol(ctx context.Context, symbol string) error
+}
+
+type sqliteVariantRepository struct {
+	db *sqlx.DB
+}
+
+// NewVariantRepository creates a new SQLite-backed VariantRepository.
+func NewVariantRepository(db *sqlx.DB) VariantRepository {
+	return &sqliteVariantRepository{db: db}
+}
+
+// accessTime is the last_accessed value for "now". CURRENT_TIMESTAMP only has
+// second resolution, so variants written in a burst would all tie for "least
+// recently used". The driver stores time.Time as text with nanoseconds, which
+// sorts chronologically as long as every value is in UTC.
+func accessTime() time.Time {
+	return time.Now().UTC()
+}
+
+// Record inserts a variant, or refreshes its size and access time if it's
+// already tracked (the file was rewritten).
+func (r *sqliteVariantRepository) Record(ctx context.Context, v *model.LogoVariant) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO logo_variants (symbol, size, variant, bytes, last_accessed)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(symbol, size, variant) DO UPDATE SET
+			bytes = excluded.bytes,
+			last_accessed = excluded.last_accessed
+	`, v.Symbol, v.Size, v.Variant, v.Bytes, accessTime())
+	if err != nil {
+		return fmt.Errorf("recording variant %s/%s/%s: %w", v.Symbol, v.Size, v.Variant, err)
+	}
+	return nil
+}
+
+// Touch marks a variant as just served.
+func (r *sqliteVariantRepository) Touch(ctx context.Context, symbol string, size model.LogoSize, variant string) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE logo_variants SET last_accessed = ? WHERE symbol = ? AND size = ? AND variant = ?`,
+		accessTime(), symbol, size, variant)
+	if err != nil {
+		return fmt.Errorf("touching variant %s/%s/%s: %w", symbol, size, variant, err)
+	}
+	return nil
+}
+
+// TotalBytes returns the combined size of all cached variants.
+// COALESCE turns the NULL that SUM returns for an empty table into 0.
+func (r *sqliteVariantRepository) TotalBytes(ctx context.Context) (int64, error) {
+	var total int64
+	if err := r.db.GetContext(ctx, &total, `SELECT COALESCE(SUM(bytes), 0) FROM logo_variants`); err != nil {
+		return 0, fmt.Errorf("summing variant bytes: %w", err)
+	}
+	return total, nil
+}
+
+// LeastRecentlyUsed returns up to limit variants, least recently served first.
+func (r *sqliteVariantRepository) LeastRecentlyUsed(ctx context.Context, limit int) ([]model.LogoVariant, error) {
+	var variants []model.LogoVariant
+	err := r.db.SelectContext(ctx, &variants, `
+		SELECT symbol, size, variant, bytes, created_at, last_accessed
+		FROM logo_variants
+		ORDER BY last_accessed ASC
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("listing variants: %w", err)
+	}
+	return variants, nil
+}
+
+// Delete stops tracking one variant.
+func (r *sqliteVariantRepository) Delete(ctx context.Context, symbol string, size model.LogoSize, variant string) error {
+	_, err := r.db.ExecContext(ctx,
+		`DELETE FROM logo_variants WHERE symbol = ? AND size = ? AND variant = ?`,
+		symbol, size, variant)
+	if err != nil {
+		return fmt.Errorf("deleting variant %s/%s/%s: %w", symbol, size, variant, err)
+	}
+	return nil
+}
+
+// DeleteBySymbol stops tracking every variant of a symbol.
+func (r *sqliteVariantRepository) DeleteBySymbol(ctx context.Context, symbol string) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM logo_variants WHERE symbol = ?`, symbol); err != nil {
+		return fmt.Errorf("deleting variants of %s: %w", symbol, err)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+func TestVariantRepository_Accounting(t *testing.T) {
+	deps := setupTestDB(t)
+	ctx := context.Background()
+	repo := deps.variantRepo
+
+	for _, v := range []model.LogoVariant{
+		{Symbol: "AAPL", Size: model.SizeM, Variant: "bg_ffffff", Bytes: 100},
+		{Symbol: "AAPL", Size: model.SizeS, Variant: "bg_ffffff", Bytes: 50},
+		{Symbol: "MSFT", Size: model.SizeM, Variant: "bg_000000", Bytes: 200},
+	} {
+		if err := repo.Record(ctx, &v); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+
+	// Re-recording replaces the size rather than adding to it.
+	if err := repo.Record(ctx, &model.LogoVariant{Symbol: "MSFT", Size: model.SizeM, Variant: "bg_000000", Bytes: 250}); err != nil {
+		t.Fatal(err)
+	}
+	if total, err := repo.TotalBytes(ctx); err != nil || total != 400 {
+		t.Fatalf("TotalBytes = %d, %v; want 400", total, err)
+	}
+
+	// Serving the oldest one makes it the most recently used.
+	if err := repo.Touch(ctx, "AAPL", model.SizeM, "bg_ffffff"); err != nil {
+		t.Fatal(err)
+	}
+	lru, err := repo.LeastRecentlyUsed(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lru) != 3 || lru[0].Size != model.SizeS || lru[2].Size != model.SizeM || lru[2].Symbol != "AAPL" {
+		t.Errorf("unexpected LRU order: %+v", lru)
+	}
+
+	if err := repo.DeleteBySymbol(ctx, "AAPL"); err != nil {
+		t.Fatal(err)
+	}
+	if total, _ := repo.TotalBytes(ctx); total != 250 {
+		t.Errorf("TotalBytes after DeleteBySymbol = %d, want 250", total)
+	}
+}
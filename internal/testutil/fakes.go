@@ -0,0 +1,114 @@
+// Package testutil provides test doubles and helpers shared by package tests.
+// It's only imported from _test.go files, so none of it ends up in binaries.
+package testutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/fleveque/logo-service/internal/model"
+	"github.com/fleveque/logo-service/internal/provider"
+	"github.com/fleveque/logo-service/internal/storage"
+)
+
+// ErrNoLogo is returned by FakeProvider for symbols it has no result for.
+var ErrNoLogo = errors.New("fake provider: no logo")
+
+// FakeProvider returns canned results by symbol and records every call.
+// It satisfies both service.LogoFetcher (GetLogo) and service.LogoSearcher
+// (FindLogo), so the same type stands in for the GitHub and LLM layers.
+type FakeProvider struct {
+	Results map[string]*provider.LogoResult
+	Err     error // If set, every call fails with it
+	Hang    bool  // If set, every call waits for its context to be done
+
+	// Go note: the service may call providers from several goroutines
+	// (concurrent requests), so the call log needs a mutex.
+	mu           sync.Mutex
+	calls        []string
+	companyNames []string
+}
+
+// NewFakeProvider creates a FakeProvider that knows the given results.
+func NewFakeProvider(results ...*provider.LogoResult) *FakeProvider {
+	f := &FakeProvider{Results: make(map[string]*provider.LogoResult)}
+	for _, r := range results {
+		f.Results[r.Symbol] = r
+	}
+	return f
+}
+
+// GetLogo implements service.LogoFetcher.
+func (f *FakeProvider) GetLogo(ctx context.Context, symbol string) (*provider.LogoResult, error) {
+	return f.lookup(ctx, symbol, "")
+}
+
+// FindLogo implements service.LogoSearcher.
+func (f *FakeProvider) FindLogo(ctx context.Context, symbol, companyName string) (*provider.LogoResult, error) {
+	return f.lookup(ctx, symbol, companyName)
+}
+
+func (f *FakeProvider) lookup(ctx context.Context, symbol, companyName string) (*provider.LogoResult, error) {
+	f.mu.Lock()
+	f.calls = append(f.calls, symbol)
+	f.companyNames = append(f.companyNames, companyName)
+	f.mu.Unlock()
+
+	if f.Hang {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	result, ok := f.Results[symbol]
+	if !ok {
+		return nil, fmt.Errorf("%w for %s", ErrNoLogo, symbol)
+	}
+	// Return a copy so callers mutating the result don't change the canned one.
+	copied := *result
+	return &copied, nil
+}
+
+// Calls returns the symbols looked up so far, in order.
+func (f *FakeProvider) Calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.calls...)
+}
+
+// CompanyNames returns the company name passed with each call ("" for GetLogo).
+func (f *FakeProvider) CompanyNames() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.companyNames...)
+}
+
+// FakeProcessor stands in for service.ImageProcessor without libvips: it
+// writes the source bytes unchanged as every size, so reads return them.
+// It satisfies service.LogoProcessor.
+type FakeProcessor struct {
+	FS  *storage.FileSystem
+	Err error // If set, ProcessAll fails without writing anything
+
+	Options model.ProcessOptions // The options of the last ProcessAll call
+}
+
+// ProcessAll implements service.LogoProcessor.
+func (p *FakeProcessor) ProcessAll(symbol string, imageData []byte, opts model.ProcessOptions) (map[model.LogoSize]bool, error) {
+	p.Options = opts
+	if p.Err != nil {
+		return nil, p.Err
+	}
+
+	results := make(map[model.LogoSize]bool, len(model.AllSizes))
+	for _, size := range model.AllSizes {
+		if err := p.FS.Write(symbol, size, imageData); err != nil {
+			return results, err
+		}
+		results[size] = true
+	}
+	return results, nil
+}
@@ -0,0 +1,356 @@
+package testutil
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// FakeGitHub serves the GitHub endpoints GitHubProvider uses:
+//
+//	GET /raw/{owner}/{repo}/main/{path}            (raw.githubusercontent.com)
+//	GET /api/repos/{owner}/{repo}/git/trees/main   (api.github.com Git Trees API)
+//	GET /api/repos/{owner}/{repo}/license          (api.github.com license API)
+//	GET /api/repos/{owner}/{repo}                  (api.github.com repos API, for private)
+//	GET /api/repos/{owner}/{repo}/contents/{path}  (api.github.com Contents API, raw)
+//
+// Point a provider at it with:
+//
+//	gh.SetBaseURLs(fake.RawBaseURL(), fake.APIBaseURL())
+type FakeGitHub struct {
+	Server *httptest.Server
+
+	mu       sync.Mutex
+	files    map[string]map[string][]byte // repo → path → content
+	licenses map[string]string            // repo → SPDX id
+	private  map[string]string            // repo → the token that reads it
+}
+
+// NewFakeGitHub starts a fake GitHub server, shut down when the test finishes.
+func NewFakeGitHub(t *testing.T) *FakeGitHub {
+	t.Helper()
+
+	f := &FakeGitHub{
+		files:    make(map[string]map[string][]byte),
+		licenses: make(map[string]string),
+		private:  make(map[string]string),
+	}
+
+	// Go 1.22+ ServeMux supports methods and {wildcards} in patterns —
+	// {path...} matches the rest of the URL, slashes included.
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /raw/{owner}/{repo}/main/{path...}", f.serveRaw)
+	mux.HandleFunc("GET /api/repos/{owner}/{repo}/git/trees/main", f.serveTree)
+	mux.HandleFunc("GET /api/repos/{owner}/{repo}/license", f.serveLicense)
+	mux.HandleFunc("GET /api/repos/{owner}/{repo}", f.serveRepo)
+	mux.HandleFunc("GET /api/repos/{owner}/{repo}/contents/{path...}", f.serveContents)
+
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Server.Close)
+	return f
+}
+
+// RawBaseURL replaces https://raw.githubusercontent.com.
+func (f *FakeGitHub) RawBaseURL() string { return f.Server.URL + "/raw" }
+
+// APIBaseURL replaces https://api.github.com.
+func (f *FakeGitHub) APIBaseURL() string { return f.Server.URL + "/api" }
+
+// AddLogo stores a logo where the ticker-logo repos keep them: ticker_icons/{SYMBOL}.png.
+func (f *FakeGitHub) AddLogo(repo, symbol string, data []byte) {
+	f.AddFile(repo, "ticker_icons/"+symbol+".png", data)
+}
+
+// AddFile stores an arbitrary file in a repo.
+func (f *FakeGitHub) AddFile(repo, path string, data []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.files[repo] == nil {
+		f.files[repo] = make(map[string][]byte)
+	}
+	f.files[repo][path] = data
+}
+
+// SetLicense makes the license API report spdxID for a repo.
+// Repos without one answer 404, like unlicensed repos on GitHub.
+func (f *FakeGitHub) SetLicense(repo, spdxID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.licenses[repo] = spdxID
+}
+
+// SetPrivate makes a repo private, readable with token only. Like on
+// GitHub, its raw files are 404 and the API answers 404 without the token;
+// with it, files come from the Contents API.
+func (f *FakeGitHub) SetPrivate(repo, token string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.private[repo] = token
+}
+
+// hidden reports whether r may not see repo: private, and the request
+// doesn't carry its token (or, with raw, isn't through the API at all).
+func (f *FakeGitHub) hidden(r *http.Request, repo string, raw bool) bool {
+	f.mu.Lock()
+	token, private := f.private[repo]
+	f.mu.Unlock()
+	return private && (raw || r.Header.Get("Authorization") != "Bearer "+token)
+}
+
+func (f *FakeGitHub) serveRepo(w http.ResponseWriter, r *http.Request) {
+	repo := r.PathValue("owner") + "/" + r.PathValue("repo")
+
+	f.mu.Lock()
+	_, exists := f.files[repo]
+	_, private := f.private[repo]
+	f.mu.Unlock()
+
+	if !exists || f.hidden(r, repo, false) {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"full_name": repo, "private": private})
+}
+
+func (f *FakeGitHub) serveContents(w http.ResponseWriter, r *http.Request) {
+	repo := r.PathValue("owner") + "/" + r.PathValue("repo")
+	if f.hidden(r, repo, false) || r.Header.Get("Accept") != "application/vnd.github.raw" {
+		http.NotFound(w, r)
+		return
+	}
+	f.serveFile(w, r, repo)
+}
+
+func (f *FakeGitHub) serveLicense(w http.ResponseWriter, r *http.Request) {
+	repo := r.PathValue("owner") + "/" + r.PathValue("repo")
+	if f.hidden(r, repo, false) {
+		http.NotFound(w, r)
+		return
+	}
+
+	f.mu.Lock()
+	spdxID, ok := f.licenses[repo]
+	f.mu.Unlock()
+
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"license": map[string]string{"spdx_id": spdxID},
+	})
+}
+
+func (f *FakeGitHub) serveRaw(w http.ResponseWriter, r *http.Request) {
+	repo := r.PathValue("owner") + "/" + r.PathValue("repo")
+	if f.hidden(r, repo, true) {
+		http.NotFound(w, r)
+		return
+	}
+	f.serveFile(w, r, repo)
+}
+
+// serveFile serves the file at the request's path in repo.
+func (f *FakeGitHub) serveFile(w http.ResponseWriter, r *http.Request, repo string) {
+	f.mu.Lock()
+	data, ok := f.files[repo][r.PathValue("path")]
+	f.mu.Unlock()
+
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	// Like GitHub, files carry an ETag, and a conditional request for one
+	// that hasn't changed gets a 304 (http.ServeContent handles those).
+	sum := sha256.Sum256(data)
+	w.Header().Set("ETag", fmt.Sprintf(`"%x"`, sum[:8]))
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+}
+
+func (f *FakeGitHub) serveTree(w http.ResponseWriter, r *http.Request) {
+	repo := r.PathValue("owner") + "/" + r.PathValue("repo")
+	if f.hidden(r, repo, false) {
+		http.NotFound(w, r)
+		return
+	}
+
+	f.mu.Lock()
+	files, ok := f.files[repo]
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	f.mu.Unlock()
+
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	// Map iteration order is random; sort so imports run in a stable order.
+	sort.Strings(paths)
+
+	type entry struct {
+		Path string `json:"path"`
+		Type string `json:"type"`
+	}
+	tree := struct {
+		Tree      []entry `json:"tree"`
+		Truncated bool    `json:"truncated"`
+	}{}
+	for _, p := range paths {
+		tree.Tree = append(tree.Tree, entry{Path: p, Type: "blob"})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(tree)
+}
+
+// FakeOpenAI is a minimal OpenAI-compatible chat completions server. For
+// symbols it knows, it immediately "calls" submit_logo_url with a URL on the
+// same server, which then serves the image — so the whole LLM path (tool
+// call parsing, image download) runs without network access or API keys.
+//
+// Point a client at it with llm.OpenAIOptions{BaseURL: fake.BaseURL()}.
+type FakeOpenAI struct {
+	Server *httptest.Server
+
+	mu       sync.Mutex
+	answers  map[string]fakeAnswer // symbol → answer
+	images   map[string][]byte     // image path → content
+	requests int
+}
+
+type fakeAnswer struct {
+	LogoURL     string `json:"logo_url"`
+	CompanyName string `json:"company_name"`
+	Source      string `json:"source"`
+	Confidence  string `json:"confidence"`
+}
+
+// promptSymbol extracts the ticker from the prompt built by llm.buildPrompt.
+var promptSymbol = regexp.MustCompile(`ticker symbol "([^"]+)"`)
+
+// NewFakeOpenAI starts a fake OpenAI server, shut down when the test finishes.
+func NewFakeOpenAI(t *testing.T) *FakeOpenAI {
+	t.Helper()
+
+	f := &FakeOpenAI{
+		answers: make(map[string]fakeAnswer),
+		images:  make(map[string][]byte),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/chat/completions", f.serveCompletion)
+	mux.HandleFunc("GET /images/{name}", f.serveImage)
+
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Server.Close)
+	return f
+}
+
+// BaseURL replaces https://api.openai.com/v1.
+func (f *FakeOpenAI) BaseURL() string { return f.Server.URL + "/v1" }
+
+// AddLogo makes the fake model answer for symbol with an image served by the fake.
+func (f *FakeOpenAI) AddLogo(symbol, companyName, confidence string, data []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name := strings.ReplaceAll(symbol, ".", "_") + ".png"
+	f.images[name] = data
+	f.answers[symbol] = fakeAnswer{
+		LogoURL:     f.Server.URL + "/images/" + name,
+		CompanyName: companyName,
+		Source:      "fake-openai",
+		Confidence:  confidence,
+	}
+}
+
+// Requests returns how many chat completions were requested.
+func (f *FakeOpenAI) Requests() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests
+}
+
+func (f *FakeOpenAI) serveCompletion(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Messages []struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var symbol string
+	for _, m := range req.Messages {
+		if m.Role == "user" {
+			if match := promptSymbol.FindStringSubmatch(m.Content); match != nil {
+				symbol = match[1]
+			}
+		}
+	}
+
+	f.mu.Lock()
+	f.requests++
+	answer, ok := f.answers[symbol]
+	f.mu.Unlock()
+
+	message := map[string]any{"role": "assistant", "content": "I could not find a logo."}
+	finishReason := "stop"
+	if ok {
+		args, _ := json.Marshal(answer)
+		message = map[string]any{
+			"role": "assistant",
+			"tool_calls": []map[string]any{{
+				"id":   "call_1",
+				"type": "function",
+				"function": map[string]any{
+					"name":      "submit_logo_url",
+					"arguments": string(args),
+				},
+			}},
+		}
+		finishReason = "tool_calls"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"id":     "chatcmpl-fake",
+		"object": "chat.completion",
+		"choices": []map[string]any{{
+			"index":         0,
+			"message":       message,
+			"finish_reason": finishReason,
+		}},
+	})
+}
+
+func (f *FakeOpenAI) serveImage(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	data, ok := f.images[r.PathValue("name")]
+	f.mu.Unlock()
+
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	_, _ = w.Write(data)
+}
@@ -0,0 +1,34 @@
+package testutil
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/fleveque/logo-service/internal/storage"
+)
+
+// NewDB creates a migrated SQLite database in a temp directory.
+// It's closed automatically when the test finishes.
+func NewDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+
+	db, err := storage.NewDatabase(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("creating test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// NewFileSystem creates a FileSystem in memory, so tests don't touch the disk.
+func NewFileSystem(t *testing.T) *storage.FileSystem {
+	t.Helper()
+
+	fs, err := storage.NewMemoryFileSystem(storage.LayoutHash)
+	if err != nil {
+		t.Fatalf("creating test filesystem: %v", err)
+	}
+	return fs
+}
@@ -0,0 +1,60 @@
+// Package version tells which build is running: the version, commit and
+// build date are set at link time, e.g.
+//
+//	go build -ldflags "-X github.com/fleveque/logo-service/internal/version.Version=v1.4.0 \
+//	    -X github.com/fleveque/logo-service/internal/version.Commit=$(git rev-parse HEAD) \
+//	    -X github.com/fleveque/logo-service/internal/version.Date=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// `make build` and the Dockerfile do it. A plain `go build` in a git
+// checkout still gets the commit and its date from the Go toolchain.
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Set by -ldflags "-X". They're variables, not constants, for -X to work.
+var (
+	Version = "dev"
+	Commit  = ""
+	Date    = ""
+)
+
+// Info describes the running build.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	Date      string `json:"date"`
+	GoVersion string `json:"go_version"`
+	Modified  bool   `json:"modified,omitempty"` // Built from a checkout with uncommitted changes
+}
+
+// Get returns the running build's Info. What -ldflags didn't set comes
+// from the VCS stamp of the Go toolchain, or is "unknown".
+func Get() Info {
+	info := Info{Version: Version, Commit: Commit, Date: Date, GoVersion: runtime.Version()}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = s.Value
+				}
+			case "vcs.time":
+				if info.Date == "" {
+					info.Date = s.Value
+				}
+			case "vcs.modified":
+				info.Modified = s.Value == "true"
+			}
+		}
+	}
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	if info.Date == "" {
+		info.Date = "unknown"
+	}
+	return info
+}
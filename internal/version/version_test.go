@@ -0,0 +1,22 @@
+package version
+
+import "testing"
+
+func TestGet(t *testing.T) {
+	defer func(v, c, d string) { Version, Commit, Date = v, c, d }(Version, Commit, Date)
+	Version, Commit, Date = "v1.4.0", "abc123", "2025-01-02T03:04:05Z"
+
+	info := Get()
+	if info.Version != "v1.4.0" || info.Commit != "abc123" || info.Date != "2025-01-02T03:04:05Z" {
+		t.Errorf("expected the -ldflags values, got %+v", info)
+	}
+	if info.GoVersion == "" {
+		t.Error("expected the Go version")
+	}
+
+	// Unset and without a VCS stamp (as in tests), they're "unknown".
+	Commit, Date = "", ""
+	if info := Get(); info.Commit == "" || info.Date == "" {
+		t.Errorf("expected a commit and date, got %+v", info)
+	}
+}
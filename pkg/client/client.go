@@ -0,0 +1,186 @@
+// Package client is a Go client for the logo-service HTTP API, for programs
+// that talk to a running server rather than embedding the pipeline (for
+// that, see pkg/logos).
+//
+// It only depends on the standard library and the model types, so it
+// builds without libvips.
+//
+//	c := client.New("https://logos.example.com", os.Getenv("LOGO_API_KEY"))
+//	png, err := c.Logo(ctx, "AAPL", client.SizeM)
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fleveque/logo-service/internal/model"
+)
+
+// Go note: these are type aliases of the internal model types — the same
+// types pkg/logos exposes — so a Logo from either package is interchangeable.
+type (
+	// Logo is the stored record for a symbol, as returned by Metadata.
+	Logo = model.Logo
+	// Size is one of the rendered sizes, SizeXS to SizeXL.
+	Size = model.LogoSize
+	// Stats counts the logos in the catalog by status.
+	Stats = model.Stats
+)
+
+// Rendered sizes, from 16px to 256px squares.
+const (
+	SizeXS = model.SizeXS
+	SizeS  = model.SizeS
+	SizeM  = model.SizeM
+	SizeL  = model.SizeL
+	SizeXL = model.SizeXL
+)
+
+// Errors for the responses callers usually handle. Check them with errors.Is;
+// anything else is an *APIError.
+var (
+	ErrNotFound = errors.New("logo not found")               // 404: no logo (or held for review)
+	ErrPending  = errors.New("logo acquisition in progress") // 202: retry after RetryAfter
+	ErrBlocked  = errors.New("logo is no longer available")  // 410: taken down
+)
+
+// PendingError is returned while the server is acquiring a logo. It wraps
+// ErrPending and carries the server's Retry-After.
+type PendingError struct {
+	RetryAfter time.Duration
+}
+
+func (e *PendingError) Error() string {
+	return fmt.Sprintf("%v, retry after %s", ErrPending, e.RetryAfter)
+}
+
+// Unwrap makes errors.Is(err, ErrPending) work.
+func (e *PendingError) Unwrap() error { return ErrPending }
+
+// APIError is any other non-success response.
+type APIError struct {
+	StatusCode int
+	Message    string // The server's "error" field, or the raw body
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("logo-service: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// Client calls a logo-service server. It's safe for concurrent use.
+type Client struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// New creates a client for the server at baseURL (e.g. "http://localhost:8080").
+// apiKey is sent as X-API-Key; admin calls (Stats) need an admin key.
+//
+// The default timeout is generous: a cache miss can wait on a web search.
+func New(baseURL, apiKey string) *Client {
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		httpClient: &http.Client{Timeout: 2 * time.Minute},
+	}
+}
+
+// SetHTTPClient replaces the default HTTP client, e.g. to change the
+// timeout or add a transport.
+func (c *Client) SetHTTPClient(hc *http.Client) {
+	c.httpClient = hc
+}
+
+// Logo returns the PNG for a symbol at the given size. A logo the server
+// hasn't cached is acquired first, so the call can take a while.
+func (c *Client) Logo(ctx context.Context, symbol string, size Size) ([]byte, error) {
+	q := url.Values{"size": {string(size)}}
+	resp, err := c.get(ctx, "/api/v1/logos/"+url.PathEscape(symbol)+"?"+q.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// Metadata returns the stored record for a symbol. It never triggers an
+// acquisition; a symbol the server has never seen gives ErrNotFound.
+func (c *Client) Metadata(ctx context.Context, symbol string) (*Logo, error) {
+	var logo Logo
+	if err := c.getJSON(ctx, "/api/v1/logos/"+url.PathEscape(symbol)+"/metadata", &logo); err != nil {
+		return nil, err
+	}
+	return &logo, nil
+}
+
+// Stats counts the logos in the catalog by status. Needs an admin key.
+func (c *Client) Stats(ctx context.Context) (*Stats, error) {
+	var stats Stats
+	if err := c.getJSON(ctx, "/api/v1/admin/stats", &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+func (c *Client) getJSON(ctx context.Context, path string, v any) error {
+	resp, err := c.get(ctx, path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) get(ctx context.Context, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+	return c.httpClient.Do(req)
+}
+
+// responseError maps a non-200 response to one of the package's errors.
+func responseError(resp *http.Response) error {
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusGone:
+		return ErrBlocked
+	case http.StatusAccepted:
+		seconds, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+		return &PendingError{RetryAfter: time.Duration(seconds) * time.Second}
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	apiErr := &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(body))}
+	var parsed struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(body, &parsed) == nil && parsed.Error != "" {
+		apiErr.Message = parsed.Error
+	}
+	return apiErr
+}
@@ -0,0 +1,75 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-API-Key") != "key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"missing API key"}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/api/v1/logos/AAPL":
+			if r.URL.Query().Get("size") != "xl" {
+				t.Errorf("expected size=xl, got %q", r.URL.RawQuery)
+			}
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write([]byte("png"))
+		case "/api/v1/logos/NEW":
+			w.Header().Set("Retry-After", "5")
+			w.WriteHeader(http.StatusAccepted)
+		case "/api/v1/logos/GONE":
+			w.WriteHeader(http.StatusGone)
+		case "/api/v1/logos/AAPL/metadata":
+			_, _ = w.Write([]byte(`{"symbol":"AAPL","status":"processed","has_xl":true}`))
+		case "/api/v1/admin/stats":
+			_, _ = w.Write([]byte(`{"total":3,"processed":2,"not_found":1}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL+"/", "key")
+	ctx := context.Background()
+
+	if data, err := c.Logo(ctx, "AAPL", SizeXL); err != nil || string(data) != "png" {
+		t.Errorf("Logo: got %q, %v", data, err)
+	}
+
+	_, err := c.Logo(ctx, "NEW", SizeM)
+	var pending *PendingError
+	if !errors.Is(err, ErrPending) || !errors.As(err, &pending) || pending.RetryAfter != 5*time.Second {
+		t.Errorf("expected a pending error with a 5s retry, got %v", err)
+	}
+	if _, err := c.Logo(ctx, "GONE", SizeM); !errors.Is(err, ErrBlocked) {
+		t.Errorf("expected ErrBlocked, got %v", err)
+	}
+	if _, err := c.Logo(ctx, "NOPE", SizeM); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	logo, err := c.Metadata(ctx, "AAPL")
+	if err != nil || logo.Symbol != "AAPL" || !logo.HasXL {
+		t.Errorf("Metadata: got %+v, %v", logo, err)
+	}
+
+	stats, err := c.Stats(ctx)
+	if err != nil || stats.Total != 3 || stats.NotFound != 1 {
+		t.Errorf("Stats: got %+v, %v", stats, err)
+	}
+
+	_, err = New(srv.URL, "wrong").Stats(ctx)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized || apiErr.Message != "missing API key" {
+		t.Errorf("expected a 401 APIError, got %v", err)
+	}
+}
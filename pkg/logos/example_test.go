@@ -0,0 +1,40 @@
+package logos_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/fleveque/logo-service/pkg/logos"
+)
+
+// A data pipeline that writes a medium-sized logo for each holding, using
+// the same config (and so the same cache) as the running server.
+func Example() {
+	cfg, err := logos.LoadConfig(os.Getenv("LOGO_CONFIG_PATH"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	svc, err := logos.Open(cfg, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer svc.Close()
+
+	ctx := context.Background()
+	for _, symbol := range []string{"AAPL", "MSFT", "BRK.B"} {
+		png, err := svc.Logo(ctx, symbol, logos.SizeM)
+		switch {
+		case errors.Is(err, logos.ErrNotFound):
+			fmt.Println(symbol, "has no logo")
+			continue
+		case err != nil:
+			log.Fatal(err)
+		}
+		if err := os.WriteFile(symbol+".png", png, 0644); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
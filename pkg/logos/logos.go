@@ -0,0 +1,151 @@
+// Package logos embeds logo acquisition in other Go programs — a data
+// pipeline, a chat bot — without running the HTTP server. It's the same
+// pipeline the server uses (local cache, then GitHub, then the LLMs),
+// reading the same config and sharing the same database and logo directory.
+//
+// This package is the stable API: the internal packages behind it change
+// freely, the names here don't.
+//
+//	cfg, err := logos.LoadConfig("config.yaml")
+//	svc, err := logos.Open(cfg, nil)
+//	defer svc.Close()
+//	png, err := svc.Logo(ctx, "AAPL", logos.SizeM)
+package logos
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"github.com/fleveque/logo-service/internal/app"
+	"github.com/fleveque/logo-service/internal/config"
+	"github.com/fleveque/logo-service/internal/model"
+	"github.com/fleveque/logo-service/internal/service"
+	"github.com/fleveque/logo-service/internal/storage"
+)
+
+// Go note: `type A = B` is a type alias, not a new type — Logo *is*
+// model.Logo. Code outside this module can't import internal/ packages, but
+// it can use their types through a public alias, methods and all.
+type (
+	// Config is the service configuration, as read by LoadConfig.
+	Config = config.Config
+	// Logo is the stored record for a symbol: source, status, available sizes.
+	Logo = model.Logo
+	// Size is one of the rendered sizes, SizeXS to SizeXL.
+	Size = model.LogoSize
+	// Status is a logo's place in its lifecycle, e.g. StatusProcessed.
+	Status = model.LogoStatus
+	// Stats counts the logos in the catalog by status.
+	Stats = model.Stats
+)
+
+// Rendered sizes, from 16px to 256px squares.
+const (
+	SizeXS = model.SizeXS
+	SizeS  = model.SizeS
+	SizeM  = model.SizeM
+	SizeL  = model.SizeL
+	SizeXL = model.SizeXL
+)
+
+// Logo statuses.
+const (
+	StatusPending   = model.StatusPending
+	StatusProcessed = model.StatusProcessed
+	StatusFailed    = model.StatusFailed
+	StatusNotFound  = model.StatusNotFound
+	StatusReview    = model.StatusReview
+)
+
+// Errors returned by Service methods. Check them with errors.Is.
+var (
+	ErrInvalidSymbol      = model.ErrInvalidSymbol        // Not a well-formed ticker
+	ErrNoRecord           = storage.ErrNotFound           // Metadata: nothing stored for the symbol
+	ErrNotFound           = service.ErrLogoNotFound       // No provider has a logo for it
+	ErrPendingReview      = service.ErrPendingReview      // Held back until an admin approves it
+	ErrAcquisitionPending = service.ErrAcquisitionPending // Being acquired right now; retry shortly
+	ErrBlocked            = storage.ErrSymbolBlocked      // Taken down; never served
+	ErrLowDiskSpace       = storage.ErrLowDiskSpace       // Not cached, and no room to acquire it
+	ErrInvalidColor       = service.ErrInvalidColor       // Background isn't six hex digits
+)
+
+// LoadConfig reads a config file like the server's (config.example.yaml).
+// An empty path uses the defaults and LOGO_* environment variables only.
+func LoadConfig(path string) (*Config, error) {
+	return config.Load(path)
+}
+
+// Service acquires, caches and serves logos. It's safe for concurrent use.
+type Service struct {
+	core *app.Core
+}
+
+// Open opens the configured storage and builds the pipeline on it.
+// logger may be nil to log nothing. Call Close when done.
+//
+// Several programs can share one database and logo directory — SQLite and
+// the file layout handle it — but only the server runs the disk monitor:
+// an embedded Service doesn't refuse acquisitions when space runs low.
+func Open(cfg *Config, logger *zap.Logger) (*Service, error) {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	core, err := app.NewCore(cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+	return &Service{core: core}, nil
+}
+
+// Close releases the database.
+func (s *Service) Close() error {
+	return s.core.Close()
+}
+
+// Logo returns the PNG for a symbol at the given size, acquiring it first
+// if it isn't cached. symbol is normalized like in the HTTP API ("aapl"
+// works).
+func (s *Service) Logo(ctx context.Context, symbol string, size Size) ([]byte, error) {
+	symbol, err := model.NormalizeSymbol(symbol)
+	if err != nil {
+		return nil, err
+	}
+	return s.core.Service.GetLogo(ctx, symbol, size)
+}
+
+// LogoWithBackground returns the logo flattened onto a solid color, given
+// as six hex digits with or without #.
+func (s *Service) LogoWithBackground(ctx context.Context, symbol string, size Size, hexColor string) ([]byte, error) {
+	symbol, err := model.NormalizeSymbol(symbol)
+	if err != nil {
+		return nil, err
+	}
+	return s.core.Service.GetLogoWithBackground(ctx, symbol, size, hexColor)
+}
+
+// Metadata returns the stored record for a symbol without acquiring
+// anything. It returns ErrNoRecord if the symbol was never seen.
+func (s *Service) Metadata(ctx context.Context, symbol string) (*Logo, error) {
+	symbol, err := model.NormalizeSymbol(symbol)
+	if err != nil {
+		return nil, err
+	}
+	return s.core.Service.GetMetadata(ctx, symbol)
+}
+
+// CachedLogo returns a logo only if it's already stored: it never asks
+// GitHub or the LLMs, so it's free and fast. It returns ErrNoRecord for a
+// symbol never seen; other misses (e.g. a not_found record) are plain errors.
+func (s *Service) CachedLogo(ctx context.Context, symbol string, size Size) ([]byte, error) {
+	symbol, err := model.NormalizeSymbol(symbol)
+	if err != nil {
+		return nil, err
+	}
+	return s.core.Service.GetCachedLogo(ctx, symbol, size)
+}
+
+// Stats counts the logos in the catalog by status.
+func (s *Service) Stats(ctx context.Context) (*Stats, error) {
+	return s.core.Service.Stats(ctx)
+}